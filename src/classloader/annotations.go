@@ -0,0 +1,371 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+)
+
+// This file contains the parsing of the attributes that hold the annotations that are
+// visible at run time: RuntimeVisibleAnnotations, RuntimeVisibleParameterAnnotations,
+// RuntimeVisibleTypeAnnotations, and AnnotationDefault. (Annotations that are not visible
+// at run time are not parsed, just as in HotSpot.) The annotations are kept on the class,
+// its fields, and its methods, from where they're retrieved by reflection. The layout of
+// these attributes is described here:
+// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.16
+
+// Annotation is an annotation of a class, field, method, or method parameter
+type Annotation struct {
+	Type     string              // the field descriptor of the annotation interface, e.g., Ljava/lang/Deprecated;
+	Elements []AnnotationElement // the element-value pairs, in the order in which they appear
+}
+
+// AnnotationElement is an element-value pair of an annotation. The elements that are not
+// given values take the default values declared in the annotation interface.
+type AnnotationElement struct {
+	Name  string
+	Value ElementValue
+}
+
+// ElementValue is the value of an annotation element, or the default value of an element.
+// Tag is the tag of the element_value structure, which gives the type of Value:
+//   - B, C, I, S, Z, and J: an int64
+//   - D and F: a float64
+//   - s: a string
+//   - e: an EnumValue
+//   - c: a string holding the return descriptor of the class, e.g., Ljava/lang/String; or V
+//   - @: an *Annotation
+//   - [: a []ElementValue
+type ElementValue struct {
+	Tag   byte
+	Value any
+}
+
+// EnumValue is the value of an annotation element whose type is an enum
+type EnumValue struct {
+	Type string // the field descriptor of the enum class
+	Name string // the name of the enum constant
+}
+
+// TypeAnnotation is an annotation of a type used in the declaration of a class, field, or
+// method. The target type and target info identify the type that's annotated; the type
+// path identifies the part of that type that's annotated, for generic and array types.
+type TypeAnnotation struct {
+	TargetType byte
+	TargetInfo []byte // the raw target_info item, whose layout depends on TargetType
+	TypePath   []TypePathEntry
+	Annotation Annotation
+}
+
+// TypePathEntry is a step of a type annotation's type path
+type TypePathEntry struct {
+	Kind          byte
+	ArgumentIndex byte
+}
+
+// attributeReader reads the contents of an attribute whose items are u1 and u2 values and
+// indexes into the CP: the annotation attributes here and those in nestedClasses.go
+type attributeReader struct {
+	klass *ParsedClass
+	bytes []byte
+	pos   int
+}
+
+var errAnnotationTooShort = errors.New("attribute is too short")
+
+func (r *attributeReader) u1() (int, error) {
+	if r.pos >= len(r.bytes) {
+		return 0, errAnnotationTooShort
+	}
+	r.pos += 1
+	return int(r.bytes[r.pos-1]), nil
+}
+
+func (r *attributeReader) u2() (int, error) {
+	if r.pos+2 > len(r.bytes) {
+		return 0, errAnnotationTooShort
+	}
+	r.pos += 2
+	return int(r.bytes[r.pos-2])<<8 | int(r.bytes[r.pos-1]), nil
+}
+
+// skip skips over n bytes, returning them
+func (r *attributeReader) skip(n int) ([]byte, error) {
+	if r.pos+n > len(r.bytes) {
+		return nil, errAnnotationTooShort
+	}
+	r.pos += n
+	return r.bytes[r.pos-n : r.pos], nil
+}
+
+// utf8 reads a CP index that must point to a UTF8 entry and returns the string
+func (r *attributeReader) utf8() (string, error) {
+	index, err := r.u2()
+	if err != nil {
+		return "", err
+	}
+	return r.utf8At(index)
+}
+
+// utf8At returns the string in the UTF8 entry at a CP index
+func (r *attributeReader) utf8At(index int) (string, error) {
+	if index < 1 || index >= len(r.klass.cpIndex) || r.klass.cpIndex[index].entryType != UTF8 {
+		return "", fmt.Errorf("CP entry #%d is not a UTF8 entry", index)
+	}
+	return r.klass.utf8Refs[r.klass.cpIndex[index].slot].content, nil
+}
+
+// the annotations in a RuntimeVisibleAnnotations attribute:
+//
+//	u2         num_annotations;
+//	annotation annotations[num_annotations];
+func (r *attributeReader) annotations() ([]Annotation, error) {
+	count, err := r.u2()
+	if err != nil {
+		return nil, err
+	}
+	annotations := make([]Annotation, count)
+	for i := range annotations {
+		if annotations[i], err = r.annotation(); err != nil {
+			return nil, err
+		}
+	}
+	return annotations, nil
+}
+
+// an annotation:
+//
+//	u2 type_index;
+//	u2 num_element_value_pairs;
+//	{   u2            element_name_index;
+//	    element_value value;
+//	} element_value_pairs[num_element_value_pairs];
+func (r *attributeReader) annotation() (Annotation, error) {
+	var annotation Annotation
+	var err error
+	if annotation.Type, err = r.utf8(); err != nil {
+		return annotation, err
+	}
+	count, err := r.u2()
+	if err != nil {
+		return annotation, err
+	}
+	annotation.Elements = make([]AnnotationElement, count)
+	for i := range annotation.Elements {
+		if annotation.Elements[i].Name, err = r.utf8(); err != nil {
+			return annotation, err
+		}
+		if annotation.Elements[i].Value, err = r.elementValue(); err != nil {
+			return annotation, err
+		}
+	}
+	return annotation, nil
+}
+
+// an element_value, whose contents depend on its tag:
+//
+//	u1 tag;
+//	union {
+//	    u2 const_value_index;
+//	    {   u2 type_name_index;
+//	        u2 const_name_index;
+//	    } enum_const_value;
+//	    u2 class_info_index;
+//	    annotation annotation_value;
+//	    {   u2            num_values;
+//	        element_value values[num_values];
+//	    } array_value;
+//	} value;
+func (r *attributeReader) elementValue() (ElementValue, error) {
+	tag, err := r.u1()
+	if err != nil {
+		return ElementValue{}, err
+	}
+	value := ElementValue{Tag: byte(tag)}
+
+	switch tag {
+	case 'B', 'C', 'I', 'S', 'Z', 'J', 'D', 'F':
+		value.Value, err = r.constant(byte(tag))
+	case 's', 'c':
+		value.Value, err = r.utf8()
+	case 'e':
+		var enum EnumValue
+		if enum.Type, err = r.utf8(); err == nil {
+			enum.Name, err = r.utf8()
+		}
+		value.Value = enum
+	case '@':
+		var annotation Annotation
+		annotation, err = r.annotation()
+		value.Value = &annotation
+	case '[':
+		var count int
+		if count, err = r.u2(); err != nil {
+			break
+		}
+		values := make([]ElementValue, count)
+		for i := 0; i < count && err == nil; i++ {
+			values[i], err = r.elementValue()
+		}
+		value.Value = values
+	default:
+		err = fmt.Errorf("invalid element value tag: %q", rune(tag))
+	}
+	return value, err
+}
+
+// constant reads the CP index of the constant value of a primitive element
+func (r *attributeReader) constant(tag byte) (any, error) {
+	index, err := r.u2()
+	if err != nil {
+		return nil, err
+	}
+	if index < 1 || index >= len(r.klass.cpIndex) {
+		return nil, fmt.Errorf("invalid CP index for constant: %d", index)
+	}
+
+	entry := r.klass.cpIndex[index]
+	switch {
+	case entry.entryType == IntConst && tag != 'J' && tag != 'D' && tag != 'F':
+		return int64(r.klass.intConsts[entry.slot]), nil
+	case entry.entryType == LongConst && tag == 'J':
+		return r.klass.longConsts[entry.slot], nil
+	case entry.entryType == FloatConst && tag == 'F':
+		return float64(r.klass.floats[entry.slot]), nil
+	case entry.entryType == DoubleConst && tag == 'D':
+		return r.klass.doubles[entry.slot], nil
+	}
+	return nil, fmt.Errorf("CP entry #%d is not a constant of type %c", index, tag)
+}
+
+// a type annotation:
+//
+//	u1 target_type;
+//	union { ... } target_info;
+//	type_path target_path;
+//	u2 type_index;
+//	u2 num_element_value_pairs;
+//	{ ... } element_value_pairs[num_element_value_pairs];
+func (r *attributeReader) typeAnnotation() (TypeAnnotation, error) {
+	var typeAnnotation TypeAnnotation
+	targetType, err := r.u1()
+	if err != nil {
+		return typeAnnotation, err
+	}
+	typeAnnotation.TargetType = byte(targetType)
+
+	// the size of the target_info item depends on the target type
+	var size int
+	switch targetType {
+	case 0x00, 0x01, 0x16: // type parameter, formal parameter
+		size = 1
+	case 0x10, 0x11, 0x12, 0x17, 0x42, 0x43, 0x44, 0x45, 0x46: // supertype, type parameter bound, throws, catch, offset
+		size = 2
+	case 0x13, 0x14, 0x15: // empty target: a field, a return type, or a receiver
+		size = 0
+	case 0x47, 0x48, 0x49, 0x4A, 0x4B: // type argument
+		size = 3
+	case 0x40, 0x41: // local variable: a table of 6-byte entries
+		start := r.pos
+		tableLength, err := r.u2()
+		if err != nil {
+			return typeAnnotation, err
+		}
+		r.pos = start
+		size = 2 + 6*tableLength
+	default:
+		return typeAnnotation, fmt.Errorf("invalid type annotation target type: 0x%02X", targetType)
+	}
+	if typeAnnotation.TargetInfo, err = r.skip(size); err != nil {
+		return typeAnnotation, err
+	}
+
+	pathLength, err := r.u1()
+	if err != nil {
+		return typeAnnotation, err
+	}
+	for i := 0; i < pathLength; i++ {
+		step, err := r.skip(2)
+		if err != nil {
+			return typeAnnotation, err
+		}
+		typeAnnotation.TypePath = append(typeAnnotation.TypePath,
+			TypePathEntry{Kind: step[0], ArgumentIndex: step[1]})
+	}
+
+	typeAnnotation.Annotation, err = r.annotation()
+	return typeAnnotation, err
+}
+
+// finish checks that the whole attribute has been read and converts any error into a
+// class format error that names the attribute and the field or method it belongs to,
+// if any (where is "" for the attributes of the class itself)
+func (r *attributeReader) finish(att attr, where string, err error) error {
+	if err == nil && r.pos != len(r.bytes) {
+		err = errors.New("unexpected bytes at end of attribute")
+	}
+	if err != nil {
+		if where != "" {
+			where += " of "
+		}
+		return cfe(fmt.Sprintf("Invalid %s attribute in %sclass %s: %s",
+			r.klass.utf8Refs[att.attrName].content, where, r.klass.className, err.Error()))
+	}
+	return nil
+}
+
+// parseAnnotationsAttribute parses a RuntimeVisibleAnnotations attribute. where identifies
+// the field or method that the attribute belongs to, for error messages.
+func parseAnnotationsAttribute(att attr, klass *ParsedClass, where string) ([]Annotation, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	annotations, err := r.annotations()
+	return annotations, r.finish(att, where, err)
+}
+
+// parseParameterAnnotationsAttribute parses a RuntimeVisibleParameterAnnotations attribute,
+// which holds the annotations of each of a method's parameters:
+//
+//	u1 num_parameters;
+//	{   u2         num_annotations;
+//	    annotation annotations[num_annotations];
+//	} parameter_annotations[num_parameters];
+func parseParameterAnnotationsAttribute(att attr, klass *ParsedClass, where string) ([][]Annotation, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	count, err := r.u1()
+	var paramAnnotations [][]Annotation
+	for i := 0; i < count && err == nil; i++ {
+		var annotations []Annotation
+		annotations, err = r.annotations()
+		paramAnnotations = append(paramAnnotations, annotations)
+	}
+	return paramAnnotations, r.finish(att, where, err)
+}
+
+// parseAnnotationDefaultAttribute parses an AnnotationDefault attribute, which holds the
+// default value of the element of an annotation interface that's declared by a method
+func parseAnnotationDefaultAttribute(att attr, klass *ParsedClass, where string) (*ElementValue, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	value, err := r.elementValue()
+	return &value, r.finish(att, where, err)
+}
+
+// parseTypeAnnotationsAttribute parses a RuntimeVisibleTypeAnnotations attribute:
+//
+//	u2              num_annotations;
+//	type_annotation annotations[num_annotations];
+func parseTypeAnnotationsAttribute(att attr, klass *ParsedClass, where string) ([]TypeAnnotation, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	count, err := r.u2()
+	var typeAnnotations []TypeAnnotation
+	for i := 0; i < count && err == nil; i++ {
+		var typeAnnotation TypeAnnotation
+		typeAnnotation, err = r.typeAnnotation()
+		typeAnnotations = append(typeAnnotations, typeAnnotation)
+	}
+	return typeAnnotations, r.finish(att, where, err)
+}
@@ -0,0 +1,183 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package classloader
+
+import (
+	"io"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// annotationTestClass returns a parsed class whose CP holds the entries used by the
+// annotation attributes in the tests below
+func annotationTestClass() *ParsedClass {
+	klass := ParsedClass{className: "test/Annotated"}
+	klass.cpIndex = []cpEntry{
+		{},
+		{UTF8, 0},        // 1: RuntimeVisibleAnnotations
+		{UTF8, 1},        // 2: Ltest/Marker;
+		{UTF8, 2},        // 3: value
+		{IntConst, 0},    // 4: 42
+		{UTF8, 3},        // 5: names
+		{UTF8, 4},        // 6: a string
+		{UTF8, 5},        // 7: Ltest/Color;
+		{UTF8, 6},        // 8: RED
+		{LongConst, 0},   // 9: 7L
+		{Dummy, 0},       // 10: the second slot of the long
+		{UTF8, 7},        // 11: Ljava/lang/String;
+		{DoubleConst, 0}, // 12: 2.5
+		{Dummy, 0},       // 13
+		{UTF8, 8},        // 14: RuntimeVisibleParameterAnnotations
+		{UTF8, 9},        // 15: AnnotationDefault
+		{UTF8, 10},       // 16: RuntimeVisibleTypeAnnotations
+		{FloatConst, 0},  // 17: 1.5f
+	}
+	klass.utf8Refs = []utf8Entry{{"RuntimeVisibleAnnotations"}, {"Ltest/Marker;"}, {"value"}, {"names"},
+		{"a string"}, {"Ltest/Color;"}, {"RED"}, {"Ljava/lang/String;"}, {"RuntimeVisibleParameterAnnotations"},
+		{"AnnotationDefault"}, {"RuntimeVisibleTypeAnnotations"}}
+	klass.intConsts = []int{42}
+	klass.longConsts = []int64{7}
+	klass.doubles = []float64{2.5}
+	klass.floats = []float32{1.5}
+	klass.cpCount = len(klass.cpIndex)
+	return &klass
+}
+
+func TestParseAnnotationsAttribute(t *testing.T) {
+	globals.InitGlobals("test")
+	klass := annotationTestClass()
+
+	// two @Marker annotations that have elements of each kind. (The element names are reused,
+	// which doesn't matter to the parser.)
+	att := attr{attrName: 0, attrContent: []byte{
+		0, 2, // two annotations
+		0, 2, 0, 3, // the type, Marker, and 3 elements
+		0, 3, 'I', 0, 4, // value = 42
+		0, 5, '[', 0, 1, 's', 0, 6, // names = {"a string"}
+		0, 3, 'e', 0, 7, 0, 8, // value = Color.RED
+		0, 2, 0, 3, // Marker with 3 elements
+		0, 3, '@', 0, 2, 0, 0, // value = @Marker
+		0, 5, 'c', 0, 11, // names = String.class
+		0, 3, 'J', 0, 9, // value = 7L
+	}}
+
+	annotations, err := parseAnnotationsAttribute(att, klass, "")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing RuntimeVisibleAnnotations: %s", err.Error())
+	}
+
+	expected := []Annotation{
+		{Type: "Ltest/Marker;", Elements: []AnnotationElement{
+			{Name: "value", Value: ElementValue{Tag: 'I', Value: int64(42)}},
+			{Name: "names", Value: ElementValue{Tag: '[', Value: []ElementValue{{Tag: 's', Value: "a string"}}}},
+			{Name: "value", Value: ElementValue{Tag: 'e', Value: EnumValue{Type: "Ltest/Color;", Name: "RED"}}},
+		}},
+		{Type: "Ltest/Marker;", Elements: []AnnotationElement{
+			{Name: "value", Value: ElementValue{Tag: '@', Value: &Annotation{Type: "Ltest/Marker;", Elements: []AnnotationElement{}}}},
+			{Name: "names", Value: ElementValue{Tag: 'c', Value: "Ljava/lang/String;"}},
+			{Name: "value", Value: ElementValue{Tag: 'J', Value: int64(7)}},
+		}},
+	}
+	if !reflect.DeepEqual(annotations, expected) {
+		t.Errorf("Expected annotations %+v, got %+v", expected, annotations)
+	}
+}
+
+func TestParseParameterAnnotationsAndDefault(t *testing.T) {
+	globals.InitGlobals("test")
+	klass := annotationTestClass()
+
+	// two parameters: the first has no annotations, the second has @Marker(value=2.5)
+	att := attr{attrName: 8, attrContent: []byte{
+		2,
+		0, 0,
+		0, 1, 0, 2, 0, 1, 0, 3, 'D', 0, 12,
+	}}
+	paramAnnotations, err := parseParameterAnnotationsAttribute(att, klass, "method m()V")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing RuntimeVisibleParameterAnnotations: %s", err.Error())
+	}
+	if len(paramAnnotations) != 2 || len(paramAnnotations[0]) != 0 || len(paramAnnotations[1]) != 1 ||
+		paramAnnotations[1][0].Elements[0].Value.Value != 2.5 {
+		t.Errorf("Unexpected parameter annotations: %+v", paramAnnotations)
+	}
+
+	att = attr{attrName: 9, attrContent: []byte{'F', 0, 17}}
+	value, err := parseAnnotationDefaultAttribute(att, klass, "method value()F")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing AnnotationDefault: %s", err.Error())
+	}
+	if value.Tag != 'F' || value.Value != 1.5 {
+		t.Errorf("Expected default value 1.5, got %+v", value)
+	}
+}
+
+func TestParseTypeAnnotationsAttribute(t *testing.T) {
+	globals.InitGlobals("test")
+	klass := annotationTestClass()
+
+	att := attr{attrName: 10, attrContent: []byte{
+		0, 2,
+		0x13,    // a field's type: no target info
+		1, 3, 0, // type path: a type argument, #0
+		0, 2, 0, 0, // @Marker
+		0x40,                   // a local variable
+		0, 1, 0, 1, 0, 2, 0, 3, // table of 1 entry
+		0,          // empty type path
+		0, 2, 0, 0, // @Marker
+	}}
+	typeAnnotations, err := parseTypeAnnotationsAttribute(att, klass, "field f")
+	if err != nil {
+		t.Fatalf("Unexpected error parsing RuntimeVisibleTypeAnnotations: %s", err.Error())
+	}
+	if len(typeAnnotations) != 2 {
+		t.Fatalf("Expected 2 type annotations, got %d", len(typeAnnotations))
+	}
+	if typeAnnotations[0].TargetType != 0x13 || len(typeAnnotations[0].TargetInfo) != 0 ||
+		!reflect.DeepEqual(typeAnnotations[0].TypePath, []TypePathEntry{{Kind: 3, ArgumentIndex: 0}}) ||
+		typeAnnotations[0].Annotation.Type != "Ltest/Marker;" {
+		t.Errorf("Unexpected first type annotation: %+v", typeAnnotations[0])
+	}
+	if typeAnnotations[1].TargetType != 0x40 || len(typeAnnotations[1].TargetInfo) != 8 ||
+		len(typeAnnotations[1].TypePath) != 0 {
+		t.Errorf("Unexpected second type annotation: %+v", typeAnnotations[1])
+	}
+}
+
+func TestParseInvalidAnnotations(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	klass := annotationTestClass()
+	for _, content := range [][]byte{
+		{0, 1, 0, 2, 0, 1, 0, 3, 'I', 0, 9}, // an int element whose constant is a long
+		{0, 1, 0, 2, 0, 1, 0, 3, 'x', 0, 4}, // an invalid tag
+		{0, 1, 0, 4, 0, 0},                  // an annotation type that's not a UTF8 entry
+		{0, 2, 0, 2, 0, 0},                  // too few annotations
+		{0, 1, 0, 2, 0, 0, 0},               // too many bytes
+	} {
+		if _, err := parseAnnotationsAttribute(attr{attrName: 0, attrContent: content}, klass, ""); err == nil {
+			t.Errorf("Expected an error parsing invalid annotations %v", content)
+		}
+	}
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stderr = normalStderr
+
+	if !strings.Contains(string(out), "Invalid RuntimeVisibleAnnotations attribute in class test/Annotated") {
+		t.Errorf("Expected class format error message, got: %s", string(out))
+	}
+}
@@ -10,7 +10,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"jacobin/globals"
 	"jacobin/log"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -30,11 +34,16 @@ type ResourceEntry struct {
 }
 
 type Archive struct {
-	Filename   string
-	entryCache map[string]ResourceEntry
-	manifest   map[string]string
+	Filename     string
+	entryCache   map[string]ResourceEntry
+	manifest     map[string]string
+	multiRelease bool // does the manifest specify Multi-Release: true?
 }
 
+// the directory in a multi-release JAR file that holds the versioned entries,
+// e.g., META-INF/versions/11/com/example/Cls.class
+const versionsDir = "META-INF/versions/"
+
 type LoadResult struct {
 	Success       bool
 	Data          *[]byte
@@ -70,12 +79,13 @@ func (archive *Archive) scanArchive() error {
 	for _, file := range reader.File {
 		entry := archive.recordFile(file)
 		if entry.Type == Manifest {
-			if archive.parseManifest(file); err != nil {
+			if err = archive.parseManifest(file); err != nil {
 				return err
 			}
 		}
 	}
 
+	archive.multiRelease = strings.EqualFold(archive.manifest["Multi-Release"], "true")
 	return nil
 }
 
@@ -102,33 +112,85 @@ func (archive *Archive) recordFile(file *zip.File) ResourceEntry {
 	return entry
 }
 
+// parseManifest reads the main attributes of the manifest. Per the JAR file specification,
+// lines end in CR LF, LF, or CR, and a line that begins with a space continues the
+// previous line (which is how long values, such as a Class-Path, are wrapped).
 func (archive *Archive) parseManifest(file *zip.File) error {
 	rc, err := file.Open()
-
-	defer rc.Close()
-
 	if err != nil {
 		return err
 	}
+	defer rc.Close()
 
 	data, err := io.ReadAll(rc)
+	if err != nil {
+		return err
+	}
 
-	contents := string(data)
+	contents := strings.ReplaceAll(string(data), "\r\n", "\n")
+	contents = strings.ReplaceAll(contents, "\r", "\n")
 
-	lines := strings.Split(contents, "\r\n")
+	var lines []string
+	for _, line := range strings.Split(contents, "\n") {
+		if strings.HasPrefix(line, " ") && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line == "" && len(lines) > 0 {
+			break // the main attributes end at the first blank line
+		}
+		lines = append(lines, line)
+	}
 
 	for _, line := range lines {
-		parts := strings.Split(line, ":")
-		if len(parts) > 1 {
-			archive.manifest[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		key, value, found := strings.Cut(line, ":")
+		if found {
+			archive.manifest[strings.TrimSpace(key)] = strings.TrimSpace(value)
 		}
 	}
 
 	return nil
 }
 
-func (archive *Archive) hasResource(name string, resourceType ResourceType) bool {
+// getClassPath returns the entries of the manifest's Class-Path attribute. These are
+// URLs, separated by spaces, which are resolved relative to the directory of the JAR file.
+func (archive *Archive) getClassPath() []string {
+	var entries []string
+	for _, entry := range strings.Fields(archive.manifest["Class-Path"]) {
+		if path, err := url.PathUnescape(entry); err == nil {
+			entry = path
+		}
+		entry = filepath.FromSlash(strings.TrimPrefix(entry, "file:"))
+		if !filepath.IsAbs(entry) {
+			entry = filepath.Join(filepath.Dir(archive.Filename), entry)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// resolveEntry returns the entry for the named resource. In a multi-release JAR file, this
+// is the entry in the highest-numbered META-INF/versions directory that's no higher than the
+// Java version Jacobin supports, if there is one, and otherwise the unversioned entry.
+func (archive *Archive) resolveEntry(name string, resourceType ResourceType) (ResourceEntry, bool) {
+	if archive.multiRelease {
+		for version := globals.GetGlobalRef().MaxJavaVersion; version >= 9; version-- {
+			prefix := versionsDir + strconv.Itoa(version) + "/"
+			if resourceType == ClassFile { // class entries are cached by name in dotted format
+				prefix = strings.ReplaceAll(prefix, "/", ".")
+			}
+			if item, ok := archive.entryCache[prefix+name]; ok && item.Type == resourceType {
+				return item, true
+			}
+		}
+	}
+
 	item, ok := archive.entryCache[name]
+	return item, ok
+}
+
+func (archive *Archive) hasResource(name string, resourceType ResourceType) bool {
+	item, ok := archive.resolveEntry(name, resourceType)
 
 	if !ok {
 		return false
@@ -138,7 +200,7 @@ func (archive *Archive) hasResource(name string, resourceType ResourceType) bool
 }
 
 func (archive *Archive) loadClass(className string) (*LoadResult, error) {
-	item, ok := archive.entryCache[className]
+	item, ok := archive.resolveEntry(className, ClassFile)
 
 	if !ok {
 		err := errors.New(fmt.Sprintf("Unable to load class %s in archive %s", className, archive.Filename))
@@ -7,6 +7,8 @@
 package classloader
 
 import (
+	"archive/zip"
+	"jacobin/globals"
 	"os"
 	"path/filepath"
 	"testing"
@@ -102,3 +104,77 @@ func TestLoadClassDoesNotExist(t *testing.T) {
 		t.Error("Expected error loading class, but didn't get one.")
 	}
 }
+
+// writeTestJar creates a JAR file containing the given entries, in order
+func writeTestJar(t *testing.T, filename string, entries [][2]string) {
+	_ = os.MkdirAll(filepath.Dir(filename), 0755)
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("Unable to create %s: %s", filename, err.Error())
+	}
+	defer file.Close()
+
+	writer := zip.NewWriter(file)
+	for _, entry := range entries {
+		w, _ := writer.Create(entry[0])
+		_, _ = w.Write([]byte(entry[1]))
+	}
+	_ = writer.Close()
+}
+
+func TestManifestClassPathWithContinuationLines(t *testing.T) {
+	jarName := filepath.Join(t.TempDir(), "app.jar")
+	manifest := "Manifest-Version: 1.0\r\nMain-Class: com.example.Main\r\n" +
+		"Class-Path: lib/first.jar lib/sec\r\n ond.jar\r\n\r\n" +
+		"Name: com/example/Main.class\r\nClass-Path: ignored.jar\r\n"
+	writeTestJar(t, jarName, [][2]string{{"META-INF/MANIFEST.MF", manifest}})
+
+	jar, err := NewJarFile(jarName)
+	if err != nil {
+		t.Fatalf("Unexpected error reading %s: %s", jarName, err.Error())
+	}
+
+	if jar.getMainClass() != "com.example.Main" {
+		t.Errorf("Expected Main-Class to be com.example.Main, got: %s", jar.getMainClass())
+	}
+
+	classPath := jar.getClassPath()
+	libDir := filepath.Join(filepath.Dir(jarName), "lib")
+	if len(classPath) != 2 || classPath[0] != filepath.Join(libDir, "first.jar") ||
+		classPath[1] != filepath.Join(libDir, "second.jar") {
+		t.Errorf("Expected Class-Path entries relative to the JAR file, got: %v", classPath)
+	}
+}
+
+// in a multi-release JAR file, the class in the highest versioned directory that's
+// no higher than the Java version Jacobin supports is the one that's loaded
+func TestMultiReleaseJarPrefersVersionedEntries(t *testing.T) {
+	globals.InitGlobals("test")
+	dir := t.TempDir()
+	entries := [][2]string{
+		{"pkg/A.class", "base"},
+		{"META-INF/versions/9/pkg/A.class", "java 9"},
+		{"META-INF/versions/11/pkg/A.class", "java 11"},
+		{"META-INF/versions/99/pkg/A.class", "java 99"},
+		{"pkg/B.class", "base"},
+	}
+
+	multiRelease := filepath.Join(dir, "multi.jar")
+	manifest := [2]string{"META-INF/MANIFEST.MF", "Manifest-Version: 1.0\nMulti-Release: true\n"}
+	writeTestJar(t, multiRelease, append([][2]string{manifest}, entries...))
+	jar, _ := NewJarFile(multiRelease)
+	for className, expected := range map[string]string{"pkg.A": "java 11", "pkg.B": "base"} {
+		result, err := jar.loadClass(className)
+		if err != nil || string(*result.Data) != expected {
+			t.Errorf("Expected %s to be loaded from the %s entry, got: %v", className, expected, result)
+		}
+	}
+
+	singleRelease := filepath.Join(dir, "single.jar")
+	writeTestJar(t, singleRelease, entries)
+	jar, _ = NewJarFile(singleRelease)
+	result, err := jar.loadClass("pkg.A")
+	if err != nil || string(*result.Data) != "base" {
+		t.Errorf("Expected versioned entries to be ignored in a JAR that isn't multi-release, got: %v", result)
+	}
+}
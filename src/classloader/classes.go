@@ -34,6 +34,14 @@ type ClData struct {
 	Attributes      []Attr
 	SourceFile      string
 	Bootstraps      []BootstrapMethod
+	Annotations     []Annotation // the runtime-visible annotations, see annotations.go
+	TypeAnnotations []TypeAnnotation
+	InnerClasses    []InnerClass      // the nested classes, see nestedClasses.go
+	EnclosingMethod *EnclosingMethod  // the method enclosing a local or anonymous class, if any
+	NestHost        string            // the host named by the NestHost attribute, if any
+	NestMembers     []string          // the members of the nest, if this class is its host
+	Signature       string            // the generic signature, if any
+	Record          []RecordComponent // the components of a record class, see records.go
 	CP              CPool
 	Access          AccessFlags
 	ClInit          byte // 0 = no clinit, 1 = clinit not run, 2 clinit run
@@ -76,11 +84,13 @@ type AccessFlags struct {
 // Likewise certain fields needed there (counts) are not used here.
 
 type Field struct {
-	AccessFlags int
-	Name        uint16 // index of the UTF-8 entry in the CP
-	Desc        uint16 // index of the UTF-8 entry in the CP
-	IsStatic    bool   // is the field static?
-	Attributes  []Attr
+	AccessFlags     int
+	Name            uint16 // index of the UTF-8 entry in the CP
+	Desc            uint16 // index of the UTF-8 entry in the CP
+	IsStatic        bool   // is the field static?
+	Attributes      []Attr
+	Annotations     []Annotation
+	TypeAnnotations []TypeAnnotation
 }
 
 // the methods of the class, including the constructors
@@ -93,6 +103,11 @@ type Method struct {
 	Exceptions  []uint16 // indexes into Utf8Refs in the CP
 	Parameters  []ParamAttrib
 	Deprecated  bool // is the method deprecated?
+
+	Annotations          []Annotation
+	ParameterAnnotations [][]Annotation // the annotations of each parameter
+	AnnotationDefault    *ElementValue  // the default value, if the method is an annotation element
+	TypeAnnotations      []TypeAnnotation
 }
 
 type CodeAttrib struct {
@@ -232,7 +247,7 @@ func FetchMethodAndCP(className, methName, methType string) (MTentry, error) {
 
 	// look for the method in the MTable
 	methFQN := className + "." + methName + methType // FQN = fully qualified name
-	methEntry := MTable[methFQN]
+	methEntry, _ := MTableFetch(methFQN)
 
 	if methEntry.Meth != nil { // we found the entry in the MTable
 		if methEntry.MType == 'J' {
@@ -326,6 +341,15 @@ func FetchMethodAndCP(className, methName, methType string) (MTentry, error) {
 			shutdown.Exit(shutdown.JVM_EXCEPTION)
 			return MTentry{}, errors.New(errMsg) // dummy return needed for tests
 		}
+
+		// a superclass method might be a gfunction (e.g., java/lang/Thread.start()),
+		// which is found only in the MTable
+		superEntry, found := MTableFetch(className + "." + searchName)
+		if found && superEntry.MType == 'G' {
+			AddEntry(&MTable, methFQN, superEntry)
+			return superEntry, nil
+		}
+
 		methRef, ok = k.Data.MethodTable[searchName]
 		if ok {
 			m = *methRef
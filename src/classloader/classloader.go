@@ -36,7 +36,7 @@ type Classloader struct {
 	Name       string
 	Parent     string
 	ClassCount int
-	Archives   map[string]*Archive // TODO: I think this should be moved to classpath when we make it a thing
+	Archives   map[string]*Archive // the JAR files on the classpath that have been read, by filename
 }
 
 // AppCL is the application classloader, which loads most of the app's classes
@@ -68,6 +68,14 @@ type ParsedClass struct {
 	sourceFile      string
 	bootstrapCount  int // the number of bootstrap methods
 	bootstraps      []bootstrapMethod
+	annotations     []Annotation // the class's RuntimeVisibleAnnotations
+	typeAnnotations []TypeAnnotation
+	innerClasses    []InnerClass     // see nestedClasses.go
+	enclosingMethod *EnclosingMethod // only for local and anonymous classes
+	nestHost        string
+	nestMembers     []string
+	signature       string            // the generic signature of the class, if any
+	record          []RecordComponent // the components of a record class; nil for other classes
 
 	deprecated bool
 
@@ -108,12 +116,14 @@ type ParsedClass struct {
 
 // the fields defined in the class
 type field struct {
-	accessFlags int
-	isStatic    bool
-	name        int         // index of the UTF-8 entry in the CP
-	description int         // index of the UTF-8 entry in the CP
-	constValue  interface{} // the constant value if any was defined
-	attributes  []attr
+	accessFlags     int
+	isStatic        bool
+	name            int         // index of the UTF-8 entry in the CP
+	description     int         // index of the UTF-8 entry in the CP
+	constValue      interface{} // the constant value if any was defined
+	attributes      []attr
+	annotations     []Annotation
+	typeAnnotations []TypeAnnotation
 }
 
 // the methods of the class, including the constructors
@@ -127,6 +137,11 @@ type method struct {
 	// pointing to names of exception classes this method is knownto throw
 	parameters []paramAttrib
 	deprecated bool // is the method deprecated?
+
+	annotations       []Annotation
+	paramAnnotations  [][]Annotation // the annotations of each parameter
+	annotationDefault *ElementValue  // the default value, if the method is an annotation element
+	typeAnnotations   []TypeAnnotation
 }
 
 type codeAttrib struct {
@@ -328,24 +343,11 @@ loadAclass:
 		return err
 	}
 
-	// Load class from a jar file?
-	if len(globals.GetGlobalRef().StartingJar) > 0 {
-		validName := util.ConvertToPlatformPathSeparators(className)
-		_ = log.Log("LoadClassFromNameOnly: LoadClassFromJar "+validName, log.CLASS)
-		_, _, err = LoadClassFromJar(AppCL, validName, globals.GetGlobalRef().StartingJar)
-		if err != nil {
-			_ = log.Log("LoadClassFromNameOnly: LoadClassFromJar "+validName+" failed", log.SEVERE)
-			_ = log.Log(err.Error(), log.SEVERE)
-		}
-		return err
-	}
-
-	// Loading from a local file system class
-	// TODO: classpath
-	validName := util.ConvertToPlatformPathSeparators(className)
-	_ = log.Log("LoadClassFromNameOnly: Loaded class from file "+validName, log.CLASS)
-	_, superclassIndex, err := LoadClassFromFile(AppCL, validName)
+	// Load class from the classpath, which consists of the JAR file if Jacobin was
+	// started with -jar, or else the directories and JAR files specified by -cp
+	_, superclassIndex, err := LoadClassFromClasspath(AppCL, className)
 	if err != nil {
+		_ = log.Log(err.Error(), log.CLASS)
 		errMsg := fmt.Sprintf("LoadClassFromNameOnly for %s failed", className)
 		globals.GetGlobalRef().FuncThrowException(excNames.ClassNotFoundException, errMsg)
 		return errors.New(errMsg) // return for tests only
@@ -359,6 +361,17 @@ loadAclass:
 	return err
 }
 
+// CanLoadClass reports whether the named class, in java/lang/Class format, is loaded
+// or can be found in a jmod file or on the classpath. It doesn't load the class, so
+// it's used to check for the existence of a class where an absent class is not fatal.
+func CanLoadClass(className string) bool {
+	if MethAreaFetch(className) != nil || JmodMapFetch(className) != "" {
+		return true
+	}
+	_, _, found := findOnClasspath(AppCL, className)
+	return found
+}
+
 // LoadClassFromFile first canonicalizes the filename, and reads
 // the indicated file, and runs it through the classloader.
 func LoadClassFromFile(cl Classloader, fname string) (uint32, uint32, error) {
@@ -456,6 +469,16 @@ func ParseAndPostClass(cl *Classloader, filename string, rawBytes []byte) (uint3
 	}
 	_ = log.Log("Class "+fullyParsedClass.className+" has been format-checked.", log.FINEST)
 
+	// verify the bytecode, if -Xverify calls for it
+	if shouldVerify(fullyParsedClass.className) {
+		if err = verifyClass(&fullyParsedClass); err != nil {
+			_ = log.Log("ParseAndPostClass: error verifying "+filename+": "+err.Error(), log.SEVERE)
+			globals.GetGlobalRef().FuncThrowException(excNames.VerifyError, err.Error())
+			return types.InvalidStringIndex, types.InvalidStringIndex, fmt.Errorf("verification error")
+		}
+		_ = log.Log("Class "+fullyParsedClass.className+" has been verified.", log.FINEST)
+	}
+
 	// prepare the class for posting
 	classToPost := convertToPostableClass(&fullyParsedClass)
 	eKF := Klass{
@@ -500,6 +523,8 @@ func convertToPostableClass(fullyParsedClass *ParsedClass) ClData {
 			kdf.Name = uint16(fullyParsedClass.fields[i].name)
 			kdf.Desc = uint16(fullyParsedClass.fields[i].description)
 			kdf.IsStatic = fullyParsedClass.fields[i].isStatic
+			kdf.Annotations = fullyParsedClass.fields[i].annotations
+			kdf.TypeAnnotations = fullyParsedClass.fields[i].typeAnnotations
 			if len(fullyParsedClass.fields[i].attributes) > 0 {
 				for j := 0; j < len(fullyParsedClass.fields[i].attributes); j++ {
 					kdfa := Attr{}
@@ -562,6 +587,7 @@ func convertToPostableClass(fullyParsedClass *ParsedClass) ClData {
 
 			if fullyParsedClass.methods[i].codeAttr.sourceLineTable != nil {
 				if len(*fullyParsedClass.methods[i].codeAttr.sourceLineTable) > 0 {
+					kdm.CodeAttr.BytecodeSourceMap = *fullyParsedClass.methods[i].codeAttr.sourceLineTable
 					jmeth.CodeAttr.BytecodeSourceMap = *fullyParsedClass.methods[i].codeAttr.sourceLineTable
 				}
 			} else {
@@ -601,6 +627,10 @@ func convertToPostableClass(fullyParsedClass *ParsedClass) ClData {
 				}
 			}
 			kdm.Deprecated = fullyParsedClass.methods[i].deprecated
+			kdm.Annotations = fullyParsedClass.methods[i].annotations
+			kdm.ParameterAnnotations = fullyParsedClass.methods[i].paramAnnotations
+			kdm.AnnotationDefault = fullyParsedClass.methods[i].annotationDefault
+			kdm.TypeAnnotations = fullyParsedClass.methods[i].typeAnnotations
 			jmeth.deprecated = fullyParsedClass.methods[i].deprecated
 
 			methodTableKey := methName + methDesc
@@ -626,6 +656,14 @@ func convertToPostableClass(fullyParsedClass *ParsedClass) ClData {
 		}
 	}
 	kd.SourceFile = fullyParsedClass.sourceFile
+	kd.Annotations = fullyParsedClass.annotations
+	kd.TypeAnnotations = fullyParsedClass.typeAnnotations
+	kd.InnerClasses = fullyParsedClass.innerClasses
+	kd.EnclosingMethod = fullyParsedClass.enclosingMethod
+	kd.NestHost = fullyParsedClass.nestHost
+	kd.NestMembers = fullyParsedClass.nestMembers
+	kd.Signature = fullyParsedClass.signature
+	kd.Record = fullyParsedClass.record
 	if len(fullyParsedClass.bootstraps) > 0 {
 		for j := 0; j < len(fullyParsedClass.bootstraps); j++ {
 			kdbs := BootstrapMethod{
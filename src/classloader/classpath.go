@@ -0,0 +1,131 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"errors"
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/types"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// The classpath is the ordered list of directories and JAR files in which the
+// application classes are searched for. It's set from the first of these that's present:
+//   - the JAR file specified by -jar
+//   - -cp, -classpath, or --class-path on the command line
+//   - the CLASSPATH environment variable
+//   - the current directory
+//
+// Entries are separated by the platform's path-list separator (: or ;). An entry
+// consisting of a directory followed by /* stands for all the JAR files in that
+// directory (but not its subdirectories), as in HotSpot.
+
+// ParseClasspath converts a classpath string into its ordered list of entries, expanding
+// any wildcard entries into the JAR files they refer to. Empty entries refer to the
+// current directory.
+func ParseClasspath(classpath string) []string {
+	var entries []string
+	for _, entry := range filepath.SplitList(classpath) {
+		switch {
+		case entry == "":
+			entries = append(entries, ".")
+		case entry == "*" || strings.HasSuffix(entry, "/*") || strings.HasSuffix(entry, `\*`):
+			entries = append(entries, expandClasspathWildcard(strings.TrimSuffix(entry, "*"))...)
+		default:
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// expandClasspathWildcard returns the JAR files in a directory, in directory order.
+// A wildcard that refers to a missing directory expands to nothing.
+func expandClasspathWildcard(dir string) []string {
+	if dir == "" {
+		dir = "."
+	}
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		_ = log.Log("ParseClasspath: cannot read classpath directory "+dir, log.FINE)
+		return nil
+	}
+
+	var jars []string
+	for _, file := range files {
+		ext := strings.ToLower(filepath.Ext(file.Name()))
+		if !file.IsDir() && ext == ".jar" {
+			jars = append(jars, filepath.Join(dir, file.Name()))
+		}
+	}
+	return jars
+}
+
+// LoadClassFromClasspath searches the classpath entries, in order, for the class, which is
+// named in internal format (java/lang/String), and loads it from the first entry that
+// contains it. JAR files are read only once: their contents are cached in the classloader's
+// Archives. If no entry contains the class, an error is returned, but no exception is thrown.
+func LoadClassFromClasspath(cl Classloader, className string) (uint32, uint32, error) {
+	entry, isDir, found := findOnClasspath(cl, className)
+	if !found {
+		errMsg := fmt.Sprintf("LoadClassFromClasspath: %s not found on classpath: %s",
+			className, strings.Join(globals.GetGlobalRef().Classpath, string(os.PathListSeparator)))
+		return types.InvalidStringIndex, types.InvalidStringIndex, errors.New(errMsg)
+	}
+
+	if isDir {
+		_ = log.Log("LoadClassFromClasspath: Load "+className+" from directory "+entry, log.CLASS)
+		return LoadClassFromFile(cl, filepath.Join(entry, filepath.FromSlash(className)+".class"))
+	}
+	_ = log.Log("LoadClassFromClasspath: Load "+className+" from JAR file "+entry, log.CLASS)
+	return LoadClassFromJar(cl, strings.ReplaceAll(className, "/", "."), entry)
+}
+
+// findOnClasspath returns the first classpath entry--a directory or a JAR file--that
+// contains the class, which is named in internal format. As in HotSpot, the entries in
+// the Class-Path attribute of a JAR file's manifest are searched immediately after the
+// JAR file itself.
+func findOnClasspath(cl Classloader, className string) (entry string, isDir bool, found bool) {
+	entries := slices.Clone(globals.GetGlobalRef().Classpath)
+	searched := make(map[string]bool)
+
+	for len(entries) > 0 {
+		entry = entries[0]
+		entries = entries[1:]
+		if searched[entry] { // a JAR file can be listed in more than one Class-Path
+			continue
+		}
+		searched[entry] = true
+
+		info, err := os.Stat(entry)
+		if err != nil {
+			continue // as in HotSpot, classpath entries that don't exist are ignored
+		}
+
+		if info.IsDir() {
+			filename := filepath.Join(entry, filepath.FromSlash(className)+".class")
+			if _, err = os.Stat(filename); err == nil {
+				return entry, true, true
+			}
+			continue
+		}
+
+		jar, err := getJarFile(cl, entry)
+		if err != nil {
+			continue
+		}
+		if jar.hasResource(strings.ReplaceAll(className, "/", "."), ClassFile) {
+			return entry, false, true
+		}
+		entries = append(jar.getClassPath(), entries...)
+	}
+	return "", false, false
+}
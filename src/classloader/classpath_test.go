@@ -0,0 +1,88 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParseClasspathExpandsWildcards(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b.jar", "a.JAR", "notes.txt"} {
+		_ = os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	_ = os.Mkdir(filepath.Join(dir, "sub.jar"), 0755) // directories aren't JAR files
+
+	sep := string(os.PathListSeparator)
+	entries := ParseClasspath("classes" + sep + sep + filepath.Join(dir, "*") + sep + "lib.jar")
+	expected := []string{"classes", ".", filepath.Join(dir, "a.JAR"), filepath.Join(dir, "b.jar"), "lib.jar"}
+	if !slices.Equal(entries, expected) {
+		t.Errorf("Expected classpath entries %v, got: %v", expected, entries)
+	}
+}
+
+// classes are loaded from the first classpath entry that contains them, skipping
+// entries that don't exist
+func TestLoadClassFromClasspathSearchesInOrder(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	_ = log.SetLogLevel(log.WARNING)
+	InitMethodArea()
+	cl := Classloader{Name: "app", Parent: "extension", Archives: make(map[string]*Archive)}
+
+	jarName, _ := getJarFileName(GOOD_JAR_NAME)
+	testdata := filepath.Dir(jarName)
+	globals.GetGlobalRef().Classpath = []string{filepath.Join(testdata, "missing"), jarName, testdata}
+
+	if _, _, err := LoadClassFromClasspath(cl, "jacobin/HelloWorld"); err != nil {
+		t.Errorf("Expected jacobin/HelloWorld to be loaded from %s, got: %s", jarName, err.Error())
+	}
+	if _, ok := cl.Archives[jarName]; !ok {
+		t.Errorf("Expected %s to be cached in the classloader's archives", jarName)
+	}
+
+	if _, _, err := LoadClassFromClasspath(cl, "Hello"); err != nil {
+		t.Errorf("Expected Hello to be loaded from %s, got: %s", testdata, err.Error())
+	}
+	if MethAreaFetch("Hello") == nil {
+		t.Error("Expected Hello to be in the method area")
+	}
+
+	if _, _, err := LoadClassFromClasspath(cl, "NoSuchClass"); err == nil {
+		t.Error("Expected an error loading a class that isn't on the classpath")
+	}
+}
+
+// the JAR files in the Class-Path of a JAR file's manifest are searched after that JAR file
+func TestLoadClassFromClasspathFollowsManifestClassPath(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	_ = log.SetLogLevel(log.WARNING)
+	InitMethodArea()
+	cl := Classloader{Name: "app", Parent: "extension", Archives: make(map[string]*Archive)}
+
+	jarName, _ := getJarFileName(GOOD_JAR_NAME)
+	helloBytes, err := os.ReadFile(filepath.Join(filepath.Dir(jarName), "Hello.class"))
+	if err != nil {
+		t.Fatalf("Unable to read Hello.class: %s", err.Error())
+	}
+
+	dir := t.TempDir()
+	appJar := filepath.Join(dir, "app.jar")
+	writeTestJar(t, appJar, [][2]string{{"META-INF/MANIFEST.MF", "Class-Path: lib/dep.jar app.jar\n"}})
+	writeTestJar(t, filepath.Join(dir, "lib", "dep.jar"), [][2]string{{"Hello.class", string(helloBytes)}})
+	globals.GetGlobalRef().Classpath = []string{appJar}
+
+	if _, _, err = LoadClassFromClasspath(cl, "Hello"); err != nil {
+		t.Errorf("Expected Hello to be loaded from lib/dep.jar, got: %s", err.Error())
+	}
+}
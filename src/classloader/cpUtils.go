@@ -220,3 +220,34 @@ func GetClassNameFromCPclassref(CP *CPool, cpIndex uint16) string {
 		return *entry.StringVal
 	}
 }
+
+// GetMemberInfoFromCPref receives a CP entry index that points to a field, method,
+// or interface method reference and returns the class name, member name, and the
+// member's descriptor. It's used principally for resolving method handles.
+func GetMemberInfoFromCPref(CP *CPool, cpIndex int) (string, string, string) {
+	if cpIndex < 1 || cpIndex >= len(CP.CpIndex) {
+		return "", "", ""
+	}
+
+	var classIndex, nameAndTypeCPindex uint16
+	entry := CP.CpIndex[cpIndex]
+	switch entry.Type {
+	case FieldRef:
+		classIndex = CP.FieldRefs[entry.Slot].ClassIndex
+		nameAndTypeCPindex = CP.FieldRefs[entry.Slot].NameAndType
+	case MethodRef:
+		classIndex = CP.MethodRefs[entry.Slot].ClassIndex
+		nameAndTypeCPindex = CP.MethodRefs[entry.Slot].NameAndType
+	case Interface:
+		classIndex = CP.InterfaceRefs[entry.Slot].ClassIndex
+		nameAndTypeCPindex = CP.InterfaceRefs[entry.Slot].NameAndType
+	default:
+		return "", "", ""
+	}
+
+	className := GetClassNameFromCPclassref(CP, classIndex)
+	nameAndType := CP.NameAndTypes[CP.CpIndex[nameAndTypeCPindex].Slot]
+	memberName := FetchUTF8stringFromCPEntryNumber(CP, nameAndType.NameIndex)
+	memberDesc := FetchUTF8stringFromCPEntryNumber(CP, nameAndType.DescIndex)
+	return className, memberName, memberDesc
+}
@@ -59,8 +59,8 @@ type JmEntry struct {
 type Function func([]interface{}) interface{}
 
 // MTmutex is used for updates to the MTable because multiple threads could be
-// updating it simultaneously.
-var MTmutex sync.Mutex
+// updating it simultaneously. Reads take the read lock via MTableFetch().
+var MTmutex sync.RWMutex
 
 // adds an entry to the MTable, using a mutex
 func AddEntry(tbl *MT, key string, mte MTentry) {
@@ -71,3 +71,12 @@ func AddEntry(tbl *MT, key string, mte MTentry) {
 	mt[key] = mte
 	MTmutex.Unlock()
 }
+
+// MTableFetch retrieves an entry from the MTable, using a mutex. The boolean
+// is false if the method is not in the MTable.
+func MTableFetch(key string) (MTentry, bool) {
+	MTmutex.RLock()
+	mte, ok := MTable[key]
+	MTmutex.RUnlock()
+	return mte, ok
+}
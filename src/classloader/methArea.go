@@ -11,6 +11,7 @@ import (
 	"fmt"
 	"jacobin/globals"
 	"jacobin/log"
+	"jacobin/object"
 	"jacobin/stringPool"
 	"jacobin/types"
 	"sort"
@@ -23,6 +24,11 @@ var MethArea *sync.Map
 var methAreaSize = 0
 var MethAreaMutex sync.RWMutex // All additions or updates to MethArea map come through this mutex
 
+// classMirrors contains the java.lang.Class objects (the mirrors) of the classes, array
+// types, and primitive types. Key is the name given to ClassMirror().
+var classMirrors = &sync.Map{}
+var classClassName = "java/lang/Class"
+
 // InitMethodArea initializes MethArea (the method area table of loaded classes),
 // initializes the counter of classes, and preloads the synthetic array classes.
 func InitMethodArea() {
@@ -30,12 +36,29 @@ func InitMethodArea() {
 	ma := sync.Map{}
 	MethArea = &ma
 	methAreaSize = 0
+	classMirrors = &sync.Map{}
 	MethAreaMutex.Unlock()
 
 	// preload the synthetic classes for arrays
 	MethAreaPreload()
 }
 
+// ClassMirror returns the java.lang.Class object that represents the named class, array
+// type, or primitive type. Classes and array types are named in internal format
+// (java/lang/String and [Ljava/lang/String;) and primitive types by their Java names
+// (int). There's a single mirror for each name, so mirrors can be compared by identity,
+// as in x.getClass() == Y.class, and used as the monitor of a class's static synchronized
+// methods. The mirror holds the name in its name field, as a Go string.
+func ClassMirror(name string) *object.Object {
+	if mirror, ok := classMirrors.Load(name); ok {
+		return mirror.(*object.Object)
+	}
+	mirror := object.MakeEmptyObjectWithClassName(&classClassName)
+	mirror.FieldTable["name"] = object.Field{Ftype: types.GolangString, Fvalue: name}
+	actual, _ := classMirrors.LoadOrStore(name, mirror)
+	return actual.(*object.Object)
+}
+
 // MethAreaPreload preloads the synthetic entries for array types into
 // the method area.
 func MethAreaPreload() {
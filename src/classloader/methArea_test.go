@@ -256,3 +256,24 @@ func TestMethArea42(t *testing.T) {
 	tryMethod(t, "java/io/BufferedOutputStream", "<init>", "(Ljava/io/OutputStream;I)V")
 	tryMethod(t, "java/io/InputStream", "<init>", "()V")
 }
+
+func TestClassMirrorIsCanonical(t *testing.T) {
+	globals.InitGlobals("test")
+	InitMethodArea()
+
+	mirror := ClassMirror("java/lang/String")
+	if mirror != ClassMirror("java/lang/String") {
+		t.Errorf("Expected the same mirror for each lookup of java/lang/String")
+	}
+	if mirror == ClassMirror("[Ljava/lang/String;") || mirror == ClassMirror("int") {
+		t.Errorf("Expected distinct mirrors for distinct classes")
+	}
+	if name := mirror.FieldTable["name"].Fvalue; name != "java/lang/String" {
+		t.Errorf("Expected mirror named java/lang/String, got %v", name)
+	}
+
+	InitMethodArea()
+	if mirror == ClassMirror("java/lang/String") {
+		t.Errorf("Expected a new mirror after the method area was reinitialized")
+	}
+}
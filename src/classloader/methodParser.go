@@ -81,6 +81,7 @@ func parseMethods(bytes []byte, loc int, klass *ParsedClass) (int, error) {
 				log.FINEST)
 		}
 
+		where := "method " + klass.utf8Refs[nameSlot].content + klass.utf8Refs[descSlot].content
 		for j := 0; j < attrCount; j++ {
 			attrib, location, err5 := fetchAttribute(klass, bytes, pos)
 			pos = location
@@ -99,6 +100,8 @@ func parseMethods(bytes []byte, loc int, klass *ParsedClass) (int, error) {
 					if parseCodeAttribute(attrib, &meth, klass) != nil {
 						return pos, cfe("") // error msg will already have been shown to user
 					}
+				case "AnnotationDefault":
+					meth.annotationDefault, err5 = parseAnnotationDefaultAttribute(attrib, klass, where)
 				case "Deprecated":
 					meth.deprecated = true
 					_ = log.Log("    Attribute: Deprecated", log.FINEST)
@@ -118,9 +121,18 @@ func parseMethods(bytes []byte, loc int, klass *ParsedClass) (int, error) {
 					// if parseMethodParametersAttribute(attrib, &meth, klass) != nil {
 					// 	return pos, cfe("") // error msg will already have been shown to user
 					// }
+				case "RuntimeVisibleAnnotations":
+					meth.annotations, err5 = parseAnnotationsAttribute(attrib, klass, where)
+				case "RuntimeVisibleParameterAnnotations":
+					meth.paramAnnotations, err5 = parseParameterAnnotationsAttribute(attrib, klass, where)
+				case "RuntimeVisibleTypeAnnotations":
+					meth.typeAnnotations, err5 = parseTypeAnnotationsAttribute(attrib, klass, where)
 				default:
 					_ = log.Log("    Attribute: "+klass.utf8Refs[attrib.attrName].content, log.FINEST)
 				}
+				if err5 != nil {
+					return pos, err5 // the error message will already have been shown
+				}
 
 			} else {
 				return pos, cfe("Error fetching method attribute in method: " +
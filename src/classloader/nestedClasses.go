@@ -0,0 +1,196 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/stringPool"
+	"strings"
+)
+
+// This file contains the parsing of the class attributes that describe nested classes and
+// generic types: InnerClasses, EnclosingMethod, NestHost, NestMembers, and Signature; and
+// the determination of a class's nest host, as described in JEP 181. The attributes are
+// described here: https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.6
+//
+// Nestmates may access each other's private members. Jacobin doesn't check access to
+// private members, so no check is needed in the interpreter; the nests are used by
+// reflection, in Class.getNestHost() and Class.isNestmateOf().
+
+// InnerClass is an entry of the InnerClasses attribute, which describes a nested class
+// that's either a member of this class or referred to by it
+type InnerClass struct {
+	Name       string // the internal name of the nested class, e.g., java/util/Map$Entry
+	OuterName  string // the class of which it is a member; "" for local and anonymous classes
+	SimpleName string // the name in the source code; "" for anonymous classes
+	Access     int    // the access flags declared in the source code, e.g., private or static
+}
+
+// EnclosingMethod holds the EnclosingMethod attribute of a local or anonymous class
+type EnclosingMethod struct {
+	Class string // the innermost class that encloses the declaration of the class
+	Name  string // the method in which the class is declared; "" if it's not declared in a
+	Desc  string // method or constructor, but, e.g., in the initializer of a field
+}
+
+// className returns the name of the class whose ClassRef entry is at the CP index
+func (r *attributeReader) className() (string, error) {
+	index, err := r.u2()
+	if err != nil {
+		return "", err
+	}
+	return classNameAt(r.klass, index)
+}
+
+// classNameAt returns the name of the class whose ClassRef entry is at a CP index
+func classNameAt(klass *ParsedClass, index int) (string, error) {
+	if index < 1 || index >= len(klass.cpIndex) || klass.cpIndex[index].entryType != ClassRef {
+		return "", fmt.Errorf("CP entry #%d is not a ClassRef entry", index)
+	}
+	namePtr := stringPool.GetStringPointer(klass.classRefs[klass.cpIndex[index].slot])
+	if namePtr == nil {
+		return "", fmt.Errorf("CP entry #%d does not point to a class name", index)
+	}
+	return *namePtr, nil
+}
+
+// parseInnerClassesAttribute parses an InnerClasses attribute:
+//
+//	u2 number_of_classes;
+//	{   u2 inner_class_info_index;
+//	    u2 outer_class_info_index;
+//	    u2 inner_name_index;
+//	    u2 inner_class_access_flags;
+//	} classes[number_of_classes];
+//
+// The outer class and the inner name are 0 when the class has none.
+func parseInnerClassesAttribute(att attr, klass *ParsedClass) ([]InnerClass, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	count, err := r.u2()
+	var innerClasses []InnerClass
+	for i := 0; i < count && err == nil; i++ {
+		var inner InnerClass
+		if inner.Name, err = r.className(); err != nil {
+			break
+		}
+		var outerIndex, nameIndex int
+		if outerIndex, err = r.u2(); err == nil && outerIndex != 0 {
+			inner.OuterName, err = classNameAt(klass, outerIndex)
+		}
+		if err == nil {
+			if nameIndex, err = r.u2(); err == nil && nameIndex != 0 {
+				inner.SimpleName, err = r.utf8At(nameIndex)
+			}
+		}
+		if err == nil {
+			inner.Access, err = r.u2()
+		}
+		innerClasses = append(innerClasses, inner)
+	}
+	return innerClasses, r.finish(att, "", err)
+}
+
+// parseEnclosingMethodAttribute parses an EnclosingMethod attribute:
+//
+//	u2 class_index;
+//	u2 method_index;
+//
+// where the method index points to a NameAndType entry, or is 0.
+func parseEnclosingMethodAttribute(att attr, klass *ParsedClass) (*EnclosingMethod, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	var enclosing EnclosingMethod
+	var err error
+	if enclosing.Class, err = r.className(); err == nil {
+		var methodIndex int
+		if methodIndex, err = r.u2(); err == nil && methodIndex != 0 {
+			if methodIndex >= len(klass.cpIndex) || klass.cpIndex[methodIndex].entryType != NameAndType {
+				err = fmt.Errorf("CP entry #%d is not a NameAndType entry", methodIndex)
+			} else {
+				enclosing.Name, enclosing.Desc, err = ResolveCPnameAndType(klass, methodIndex)
+			}
+		}
+	}
+	return &enclosing, r.finish(att, "", err)
+}
+
+// parseNestHostAttribute parses a NestHost attribute, which holds the CP index of the
+// ClassRef of the host of the nest the class belongs to
+func parseNestHostAttribute(att attr, klass *ParsedClass) (string, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	host, err := r.className()
+	return host, r.finish(att, "", err)
+}
+
+// parseNestMembersAttribute parses a NestMembers attribute, which lists the classes that
+// belong to the nest of which this class is the host:
+//
+//	u2 number_of_classes;
+//	u2 classes[number_of_classes];
+func parseNestMembersAttribute(att attr, klass *ParsedClass) ([]string, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	count, err := r.u2()
+	var members []string
+	for i := 0; i < count && err == nil; i++ {
+		var member string
+		member, err = r.className()
+		members = append(members, member)
+	}
+	return members, r.finish(att, "", err)
+}
+
+// parseSignatureAttribute parses a Signature attribute, which holds the CP index of the
+// generic signature of the class, e.g., <T:Ljava/lang/Object;>Ljava/util/AbstractList<TT;>;
+func parseSignatureAttribute(att attr, klass *ParsedClass) (string, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	signature, err := r.utf8()
+	return signature, r.finish(att, "", err)
+}
+
+// NestHost returns the name of the host of the nest to which the named class belongs. As
+// specified by JEP 181, a class without a NestHost attribute is the host of its own nest,
+// as is a class whose host can't be loaded, is in another package, or doesn't list the
+// class among its members.
+func NestHost(className string) string {
+	k := MethAreaFetch(className)
+	if k == nil || k.Data == nil || k.Data.NestHost == "" {
+		return className
+	}
+
+	hostName := k.Data.NestHost
+	host := MethAreaFetch(hostName)
+	if host == nil {
+		if !CanLoadClass(hostName) || LoadClassFromNameOnly(hostName) != nil {
+			return className
+		}
+		if WaitForClassStatus(hostName) != nil {
+			return className
+		}
+		host = MethAreaFetch(hostName)
+	}
+	if host == nil || host.Data == nil || packageOf(hostName) != packageOf(className) {
+		return className
+	}
+	for _, member := range host.Data.NestMembers {
+		if member == className {
+			return hostName
+		}
+	}
+	return className
+}
+
+// AreNestmates reports whether two classes belong to the same nest
+func AreNestmates(className1, className2 string) bool {
+	return className1 == className2 || NestHost(className1) == NestHost(className2)
+}
+
+// packageOf returns the package part of an internal class name
+func packageOf(className string) string {
+	if i := strings.LastIndex(className, "/"); i >= 0 {
+		return className[:i]
+	}
+	return ""
+}
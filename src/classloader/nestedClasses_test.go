@@ -0,0 +1,169 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package classloader
+
+import (
+	"io"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/stringPool"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// nestedTestClass returns a parsed class whose CP holds the entries used by the nested-class
+// attributes in the tests below
+func nestedTestClass() *ParsedClass {
+	klass := ParsedClass{className: "test/Outer$Inner"}
+	klass.cpIndex = []cpEntry{
+		{},
+		{ClassRef, 0},    // 1: test/Outer$Inner
+		{ClassRef, 1},    // 2: test/Outer
+		{UTF8, 0},        // 3: Inner
+		{ClassRef, 2},    // 4: test/Outer$1
+		{NameAndType, 0}, // 5: run()V
+		{UTF8, 1},        // 6: run
+		{UTF8, 2},        // 7: ()V
+		{UTF8, 3},        // 8: a signature
+		{UTF8, 4},        // 9: InnerClasses
+	}
+	klass.utf8Refs = []utf8Entry{{"Inner"}, {"run"}, {"()V"}, {"Ljava/util/ArrayList<Ljava/lang/String;>;"},
+		{"InnerClasses"}}
+	for _, name := range []string{"test/Outer$Inner", "test/Outer", "test/Outer$1"} {
+		klass.classRefs = append(klass.classRefs, stringPool.GetStringIndex(&name))
+	}
+	klass.nameAndTypes = []nameAndTypeEntry{{6, 7}}
+	klass.cpCount = len(klass.cpIndex)
+	return &klass
+}
+
+func TestParseInnerClassesAttribute(t *testing.T) {
+	globals.InitGlobals("test")
+	klass := nestedTestClass()
+
+	att := attr{attrName: 4, attrContent: []byte{
+		0, 2,
+		0, 1, 0, 2, 0, 3, 0, 0x0A, // Inner, a private static member of Outer
+		0, 4, 0, 0, 0, 0, 0, 0, // Outer$1, an anonymous class
+	}}
+	innerClasses, err := parseInnerClassesAttribute(att, klass)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing InnerClasses: %s", err.Error())
+	}
+	expected := []InnerClass{
+		{Name: "test/Outer$Inner", OuterName: "test/Outer", SimpleName: "Inner", Access: 0x0A},
+		{Name: "test/Outer$1"},
+	}
+	if !reflect.DeepEqual(innerClasses, expected) {
+		t.Errorf("Expected inner classes %+v, got %+v", expected, innerClasses)
+	}
+}
+
+func TestParseEnclosingMethodNestAndSignatureAttributes(t *testing.T) {
+	globals.InitGlobals("test")
+	klass := nestedTestClass()
+
+	enclosing, err := parseEnclosingMethodAttribute(attr{attrName: 4, attrContent: []byte{0, 2, 0, 5}}, klass)
+	if err != nil || *enclosing != (EnclosingMethod{Class: "test/Outer", Name: "run", Desc: "()V"}) {
+		t.Errorf("Expected Outer.run()V as the enclosing method, got %+v (error: %v)", enclosing, err)
+	}
+	enclosing, err = parseEnclosingMethodAttribute(attr{attrName: 4, attrContent: []byte{0, 2, 0, 0}}, klass)
+	if err != nil || *enclosing != (EnclosingMethod{Class: "test/Outer"}) {
+		t.Errorf("Expected Outer with no enclosing method, got %+v (error: %v)", enclosing, err)
+	}
+
+	host, err := parseNestHostAttribute(attr{attrName: 4, attrContent: []byte{0, 2}}, klass)
+	if err != nil || host != "test/Outer" {
+		t.Errorf("Expected nest host test/Outer, got %q (error: %v)", host, err)
+	}
+
+	members, err := parseNestMembersAttribute(attr{attrName: 4, attrContent: []byte{0, 2, 0, 1, 0, 4}}, klass)
+	if err != nil || !reflect.DeepEqual(members, []string{"test/Outer$Inner", "test/Outer$1"}) {
+		t.Errorf("Expected nest members Outer$Inner and Outer$1, got %v (error: %v)", members, err)
+	}
+
+	signature, err := parseSignatureAttribute(attr{attrName: 4, attrContent: []byte{0, 8}}, klass)
+	if err != nil || signature != "Ljava/util/ArrayList<Ljava/lang/String;>;" {
+		t.Errorf("Expected the class's signature, got %q (error: %v)", signature, err)
+	}
+}
+
+func TestParseInvalidNestedClassAttributes(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	klass := nestedTestClass()
+	att := attr{attrName: 4} // InnerClasses
+	for _, content := range [][]byte{
+		{0, 1, 0, 3, 0, 0, 0, 0, 0, 0}, // an inner class that's not a ClassRef
+		{0, 1, 0, 1, 0, 2, 0, 2, 0, 0}, // an inner name that's not a UTF8 entry
+		{0, 1, 0, 1, 0, 2, 0, 3},       // too short
+	} {
+		att.attrContent = content
+		if _, err := parseInnerClassesAttribute(att, klass); err == nil {
+			t.Errorf("Expected an error parsing invalid InnerClasses %v", content)
+		}
+	}
+	att.attrContent = []byte{0, 2, 0, 6}
+	if _, err := parseEnclosingMethodAttribute(att, klass); err == nil {
+		t.Errorf("Expected an error parsing EnclosingMethod whose method is not a NameAndType")
+	}
+	att.attrContent = []byte{0, 2, 0}
+	if _, err := parseNestHostAttribute(att, klass); err == nil {
+		t.Errorf("Expected an error parsing NestHost with extra bytes")
+	}
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stderr = normalStderr
+
+	if !strings.Contains(string(out), "Invalid InnerClasses attribute in class test/Outer$Inner") {
+		t.Errorf("Expected class format error message, got: %s", string(out))
+	}
+}
+
+func TestNestHost(t *testing.T) {
+	globals.InitGlobals("test")
+	InitMethodArea()
+
+	insert := func(name, host string, members ...string) {
+		MethAreaInsert(name, &Klass{Status: 'L', Loader: "test",
+			Data: &ClData{Name: name, NestHost: host, NestMembers: members}})
+	}
+	insert("test/Outer", "", "test/Outer$Inner", "test/Outer$1", "other/Stranger")
+	insert("test/Outer$Inner", "test/Outer")
+	insert("test/Outer$1", "test/Outer")
+	insert("test/Impostor", "test/Outer")  // not listed by the host
+	insert("other/Stranger", "test/Outer") // listed by the host, but in another package
+	insert("test/Unrelated", "")
+
+	for className, expected := range map[string]string{
+		"test/Outer":       "test/Outer",
+		"test/Outer$Inner": "test/Outer",
+		"test/Outer$1":     "test/Outer",
+		"test/Impostor":    "test/Impostor",
+		"other/Stranger":   "other/Stranger",
+		"test/Unrelated":   "test/Unrelated",
+	} {
+		if host := NestHost(className); host != expected {
+			t.Errorf("NestHost(%s): expected %s, got %s", className, expected, host)
+		}
+	}
+
+	if !AreNestmates("test/Outer$Inner", "test/Outer$1") || !AreNestmates("test/Outer", "test/Outer$Inner") {
+		t.Errorf("Expected the members of test/Outer's nest to be nestmates")
+	}
+	if AreNestmates("test/Outer$Inner", "test/Impostor") || AreNestmates("test/Outer", "test/Unrelated") {
+		t.Errorf("Expected classes outside test/Outer's nest not to be its nestmates")
+	}
+}
@@ -558,6 +558,17 @@ func parseFields(bytes []byte, loc int, klass *ParsedClass) (int, error) {
 			} else { // append the attribute only if it's not ConstantValue
 				f.attributes = append(f.attributes, attribute)
 			}
+
+			where := "field " + klass.utf8Refs[f.name].content
+			switch attrName {
+			case "RuntimeVisibleAnnotations":
+				f.annotations, err = parseAnnotationsAttribute(attribute, klass, where)
+			case "RuntimeVisibleTypeAnnotations":
+				f.typeAnnotations, err = parseTypeAnnotationsAttribute(attribute, klass, where)
+			}
+			if err != nil {
+				return pos, err
+			}
 			pos = k
 		}
 
@@ -662,6 +673,54 @@ func parseClassAttributes(bytes []byte, loc int, klass *ParsedClass) (int, error
 		case "Deprecated":
 			klass.deprecated = true
 
+		case "EnclosingMethod":
+			klass.enclosingMethod, err = parseEnclosingMethodAttribute(attrib, klass)
+			if err != nil {
+				return pos, err
+			}
+
+		case "InnerClasses":
+			klass.innerClasses, err = parseInnerClassesAttribute(attrib, klass)
+			if err != nil {
+				return pos, err
+			}
+
+		case "NestHost":
+			klass.nestHost, err = parseNestHostAttribute(attrib, klass)
+			if err != nil {
+				return pos, err
+			}
+
+		case "NestMembers":
+			klass.nestMembers, err = parseNestMembersAttribute(attrib, klass)
+			if err != nil {
+				return pos, err
+			}
+
+		case "Record":
+			klass.record, err = parseRecordAttribute(attrib, klass)
+			if err != nil {
+				return pos, err
+			}
+
+		case "RuntimeVisibleAnnotations":
+			klass.annotations, err = parseAnnotationsAttribute(attrib, klass, "")
+			if err != nil {
+				return pos, err
+			}
+
+		case "RuntimeVisibleTypeAnnotations":
+			klass.typeAnnotations, err = parseTypeAnnotationsAttribute(attrib, klass, "")
+			if err != nil {
+				return pos, err
+			}
+
+		case "Signature":
+			klass.signature, err = parseSignatureAttribute(attrib, klass)
+			if err != nil {
+				return pos, err
+			}
+
 		case "SourceFile":
 			sourceNameIndex, _ := intFrom2Bytes(attrib.attrContent, 0)
 			utf8slot := klass.cpIndex[sourceNameIndex].slot
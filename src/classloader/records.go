@@ -0,0 +1,111 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package classloader
+
+import "fmt"
+
+// This file contains the parsing of the Record attribute, which javac (since Java 16)
+// gives to record classes and which lists the components of the record. The attribute is
+// described here: https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.30
+//
+// The methods that records get for free--toString(), equals(), and hashCode()--are not
+// in the class file: they're INVOKEDYNAMIC calls whose bootstrap method is
+// java.lang.runtime.ObjectMethods.bootstrap(), which is implemented in jvm/objectMethods.go.
+
+// RecordComponent is a component of a record class, in the order declared in the record's
+// header. Each component has a private final field and an accessor method of the same name.
+type RecordComponent struct {
+	Name            string
+	Desc            string       // the field descriptor, e.g., Ljava/lang/String;
+	Signature       string       // the generic signature, if any
+	Annotations     []Annotation // the runtime-visible annotations
+	TypeAnnotations []TypeAnnotation
+}
+
+// u4 reads a four-byte value, such as the length of an attribute
+func (r *attributeReader) u4() (int, error) {
+	high, err := r.u2()
+	if err != nil {
+		return 0, err
+	}
+	low, err := r.u2()
+	return high<<16 | low, err
+}
+
+// parseRecordAttribute parses a Record attribute:
+//
+//	u2 components_count;
+//	{   u2             name_index;
+//	    u2             descriptor_index;
+//	    u2             attributes_count;
+//	    attribute_info attributes[attributes_count];
+//	} components[components_count];
+//
+// Of the attributes of a component, only Signature and the runtime-visible annotations are
+// kept; the others are skipped.
+func parseRecordAttribute(att attr, klass *ParsedClass) ([]RecordComponent, error) {
+	r := attributeReader{klass: klass, bytes: att.attrContent}
+	count, err := r.u2()
+	components := []RecordComponent{} // a record with no components is still a record
+	for i := 0; i < count && err == nil; i++ {
+		var component RecordComponent
+		component, err = r.recordComponent()
+		components = append(components, component)
+	}
+	return components, r.finish(att, "", err)
+}
+
+// recordComponent reads a component of a Record attribute, including its attributes
+func (r *attributeReader) recordComponent() (RecordComponent, error) {
+	var component RecordComponent
+	var err error
+	if component.Name, err = r.utf8(); err != nil {
+		return component, err
+	}
+	if component.Desc, err = r.utf8(); err != nil {
+		return component, err
+	}
+	attrCount, err := r.u2()
+	for i := 0; i < attrCount && err == nil; i++ {
+		var name string
+		var length int
+		if name, err = r.utf8(); err != nil {
+			break
+		}
+		if length, err = r.u4(); err != nil {
+			break
+		}
+		if r.pos+length > len(r.bytes) {
+			return component, errAnnotationTooShort
+		}
+
+		// the component's attributes are read by a reader of their own, so that they're
+		// checked to be exactly as long as they say
+		sub := attributeReader{klass: r.klass, bytes: r.bytes[r.pos : r.pos+length]}
+		r.pos += length
+		switch name {
+		case "Signature":
+			component.Signature, err = sub.utf8()
+		case "RuntimeVisibleAnnotations":
+			component.Annotations, err = sub.annotations()
+		case "RuntimeVisibleTypeAnnotations":
+			var count int
+			count, err = sub.u2()
+			for j := 0; j < count && err == nil; j++ {
+				var typeAnnotation TypeAnnotation
+				typeAnnotation, err = sub.typeAnnotation()
+				component.TypeAnnotations = append(component.TypeAnnotations, typeAnnotation)
+			}
+		default:
+			sub.pos = length
+		}
+		if err == nil && sub.pos != length {
+			err = fmt.Errorf("unexpected bytes at end of %s attribute of component %s", name, component.Name)
+		}
+	}
+	return component, err
+}
@@ -0,0 +1,94 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package classloader
+
+import (
+	"io"
+	"jacobin/globals"
+	"jacobin/log"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// recordTestClass returns a parsed class whose CP holds the entries used by the Record
+// attributes in the tests below
+func recordTestClass() *ParsedClass {
+	klass := ParsedClass{className: "test/Pair"}
+	klass.utf8Refs = []utf8Entry{{"Record"}, {"first"}, {"I"}, {"second"}, {"Ljava/util/List;"},
+		{"Signature"}, {"Ljava/util/List<Ljava/lang/String;>;"}, {"RuntimeVisibleAnnotations"},
+		{"Ljava/lang/Deprecated;"}, {"Synthetic"}}
+	klass.cpIndex = []cpEntry{{}}
+	for i := range klass.utf8Refs {
+		klass.cpIndex = append(klass.cpIndex, cpEntry{UTF8, i}) // UTF8 entry n is at CP index n+1
+	}
+	klass.cpCount = len(klass.cpIndex)
+	return &klass
+}
+
+func TestParseRecordAttribute(t *testing.T) {
+	globals.InitGlobals("test")
+	klass := recordTestClass()
+
+	att := attr{attrName: 0, attrContent: []byte{
+		0, 2,
+		0, 2, 0, 3, 0, 0, // int first
+		0, 4, 0, 5, 0, 3, // List<String> second, with three attributes:
+		0, 6, 0, 0, 0, 2, 0, 7, // Signature
+		0, 8, 0, 0, 0, 6, 0, 1, 0, 9, 0, 0, // RuntimeVisibleAnnotations: @Deprecated
+		0, 10, 0, 0, 0, 0, // Synthetic, which is skipped
+	}}
+	components, err := parseRecordAttribute(att, klass)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing Record: %s", err.Error())
+	}
+	expected := []RecordComponent{
+		{Name: "first", Desc: "I"},
+		{Name: "second", Desc: "Ljava/util/List;", Signature: "Ljava/util/List<Ljava/lang/String;>;",
+			Annotations: []Annotation{{Type: "Ljava/lang/Deprecated;", Elements: []AnnotationElement{}}}},
+	}
+	if !reflect.DeepEqual(components, expected) {
+		t.Errorf("Expected components %+v, got %+v", expected, components)
+	}
+
+	// a record without components has an empty, rather than nil, list of them
+	components, err = parseRecordAttribute(attr{attrName: 0, attrContent: []byte{0, 0}}, klass)
+	if err != nil || components == nil || len(components) != 0 {
+		t.Errorf("Expected no components, got %+v (error: %v)", components, err)
+	}
+}
+
+func TestParseInvalidRecordAttribute(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	klass := recordTestClass()
+	for _, content := range [][]byte{
+		{0, 1, 0, 2, 0, 3},                                  // too short
+		{0, 1, 0, 2, 0, 20, 0, 0},                           // a descriptor that's not in the CP
+		{0, 1, 0, 2, 0, 3, 0, 1, 0, 6, 0, 0, 0, 3},          // an attribute longer than the component
+		{0, 1, 0, 2, 0, 3, 0, 1, 0, 6, 0, 0, 0, 3, 0, 7, 0}, // a Signature attribute with an extra byte
+		{0, 1, 0, 2, 0, 3, 0, 0, 0},                         // extra bytes after the components
+	} {
+		if _, err := parseRecordAttribute(attr{attrName: 0, attrContent: content}, klass); err == nil {
+			t.Errorf("Expected an error parsing invalid Record %v", content)
+		}
+	}
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stderr = normalStderr
+
+	if !strings.Contains(string(out), "Invalid Record attribute in class test/Pair") {
+		t.Errorf("Expected class format error message, got: %s", string(out))
+	}
+}
@@ -0,0 +1,1533 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package classloader
+
+import (
+	"fmt"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/opcodes"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"strings"
+)
+
+// This file contains the bytecode verifier, which runs after the format check of a class
+// (see formatCheck.go). It implements verification by type checking, as specified in:
+// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.10.1
+// The bytecode of each method is checked one instruction at a time against the types
+// of the locals and of the operand stack. At branch targets and exception handlers,
+// those types are given by the method's StackMapTable attribute, which the compiler
+// generates, so a single linear pass over the bytecode suffices.
+//
+// Classes whose version is earlier than 50 (Java 6) have no stack map frames and would
+// need verification by type inference, which Jacobin does not do. Such classes are not
+// verified, nor are methods in version 50 classes that use JSR and RET.
+//
+// Whether a reference type is assignable to a class type sometimes requires knowing the
+// superclasses of classes that haven't been loaded yet. Classes are not loaded for this
+// purpose unless they're in the JDK, so types whose class can't be found are considered
+// assignable.
+
+// verifyClass verifies the bytecode of all the methods in the class. The error
+// describes the first failure found.
+func verifyClass(klass *ParsedClass) error {
+	if klass.javaVersion < 50 {
+		return nil
+	}
+
+	for i := range klass.methods {
+		meth := &klass.methods[i]
+		if len(meth.codeAttr.code) == 0 { // abstract and native methods have no bytecode
+			continue
+		}
+		if err := verifyMethod(klass, meth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shouldVerify determines whether the named class is verified, per the -Xverify option.
+// At the default level, every class that isn't part of the JDK is verified, whichever
+// classloader loads it.
+func shouldVerify(className string) bool {
+	switch globals.GetGlobalRef().VerifyLevel {
+	case globals.VerifyAll:
+		return true
+	case globals.VerifyRemote: // classes that aren't part of the JDK
+		return !util.IsFilePartOfJDK(&className)
+	}
+	return false
+}
+
+// ==== verification types ====
+
+const throwableClassName = "java/lang/Throwable"
+
+// the kinds of verification types
+const (
+	vtTop = iota
+	vtInt
+	vtFloat
+	vtLong
+	vtDouble
+	vtNull
+	vtUninitThis // the uninitialized 'this' in a constructor
+	vtUninit     // an object created by NEW whose constructor hasn't been called
+	vtRef        // a class, interface, or array type
+)
+
+// a verification type. Longs and doubles occupy two locals and two words on the
+// operand stack; the second of these has the type top.
+type vtype struct {
+	kind   int
+	name   string // the class name or array descriptor of a vtRef
+	offset int    // the location of the NEW instruction that created a vtUninit
+}
+
+var (
+	topType    = vtype{kind: vtTop}
+	intType    = vtype{kind: vtInt}
+	floatType  = vtype{kind: vtFloat}
+	longType   = vtype{kind: vtLong}
+	doubleType = vtype{kind: vtDouble}
+	nullType   = vtype{kind: vtNull}
+)
+
+func refType(name string) vtype { return vtype{kind: vtRef, name: name} }
+
+func (t vtype) isReference() bool {
+	return t.kind == vtNull || t.kind == vtUninitThis || t.kind == vtUninit || t.kind == vtRef
+}
+
+func (t vtype) isCategory2() bool { return t.kind == vtLong || t.kind == vtDouble }
+
+func (t vtype) String() string {
+	switch t.kind {
+	case vtTop:
+		return "top"
+	case vtInt:
+		return "integer"
+	case vtFloat:
+		return "float"
+	case vtLong:
+		return "long"
+	case vtDouble:
+		return "double"
+	case vtNull:
+		return "null"
+	case vtUninitThis:
+		return "uninitializedThis"
+	case vtUninit:
+		return fmt.Sprintf("uninitialized(%d)", t.offset)
+	}
+	return "'" + t.name + "'"
+}
+
+// typeFromDescriptor returns the verification type of a field descriptor. As in the
+// JVM, booleans, bytes, chars, and shorts are ints.
+func typeFromDescriptor(desc string) vtype {
+	switch desc[0] {
+	case 'B', 'C', 'I', 'S', 'Z':
+		return intType
+	case 'F':
+		return floatType
+	case 'J':
+		return longType
+	case 'D':
+		return doubleType
+	case 'L':
+		return refType(desc[1 : len(desc)-1])
+	}
+	return refType(desc) // an array
+}
+
+// a frame of types: the types of the locals and of the operand stack
+type typeFrame struct {
+	locals []vtype
+	stack  []vtype
+}
+
+func (f typeFrame) copy() typeFrame {
+	return typeFrame{
+		locals: append([]vtype(nil), f.locals...),
+		stack:  append([]vtype(nil), f.stack...),
+	}
+}
+
+// ==== the verification of a method ====
+
+type methodVerifier struct {
+	klass     *ParsedClass
+	className string
+	name      string // the method's name
+	desc      string // the method's descriptor
+	code      []byte
+	maxStack  int
+	maxLocals int
+	isInstr   []bool             // whether an instruction starts at a given location
+	stackMaps map[int]*typeFrame // the frames of the StackMapTable, by location
+	retType   string             // the method's return type descriptor
+	cur       typeFrame          // the types at the instruction being verified
+	pc        int                // the location of the instruction being verified
+}
+
+// a VerifyError describing the problem at the current instruction
+func (v *methodVerifier) fail(format string, args ...any) error {
+	return fmt.Errorf("%s in method %s.%s%s at offset %d",
+		fmt.Sprintf(format, args...), util.ConvertInternalClassNameToUserFormat(v.className),
+		v.name, v.desc, v.pc)
+}
+
+func verifyMethod(klass *ParsedClass, meth *method) error {
+	v := &methodVerifier{
+		klass:     klass,
+		className: klass.className,
+		name:      klass.utf8Refs[meth.name].content,
+		desc:      klass.utf8Refs[meth.description].content,
+		code:      meth.codeAttr.code,
+		maxStack:  meth.codeAttr.maxStack,
+		maxLocals: meth.codeAttr.maxLocals,
+	}
+
+	if err := v.findInstructions(); err != nil {
+		return err
+	}
+	if v.isInstr == nil { // the method uses JSR and RET, which can't be verified by type checking
+		_ = log.Log("Verifier: skipping "+v.className+"."+v.name+v.desc+", which uses JSR/RET", log.FINE)
+		return nil
+	}
+
+	initial, err := v.initialFrame(meth)
+	if err != nil {
+		return err
+	}
+	if err := v.parseStackMapTable(meth, initial); err != nil {
+		return err
+	}
+	for _, ex := range meth.codeAttr.exceptions {
+		if ex.startPc < 0 || ex.startPc >= ex.endPc || ex.endPc > len(v.code) ||
+			!v.isInstr[ex.startPc] || (ex.endPc < len(v.code) && !v.isInstr[ex.endPc]) {
+			return v.fail("Illegal exception table range")
+		}
+		if ex.handlerPc < 0 || ex.handlerPc >= len(v.code) || !v.isInstr[ex.handlerPc] {
+			return v.fail("Illegal exception table handler")
+		}
+	}
+
+	v.cur = v.padLocals(initial)
+	fallsThrough := true // whether execution can reach the next instruction from the previous one
+	for v.pc = 0; v.pc < len(v.code); {
+		if stackMap, ok := v.stackMaps[v.pc]; ok {
+			if fallsThrough {
+				if err := v.checkFrameAssignable(v.cur, *stackMap); err != nil {
+					return err
+				}
+			}
+			v.cur = stackMap.copy()
+		} else if !fallsThrough {
+			return v.fail("Expecting a stackmap frame at branch target")
+		}
+
+		incoming := v.cur.copy()
+		if err := v.checkHandlers(meth, incoming.locals); err != nil {
+			return err
+		}
+
+		length, continues, err := v.execute()
+		if err != nil {
+			return err
+		}
+		if isStore(v.code, v.pc) { // the handlers must also accept the stored local
+			if err := v.checkHandlers(meth, v.cur.locals); err != nil {
+				return err
+			}
+		}
+
+		fallsThrough = continues
+		v.pc += length
+	}
+
+	if fallsThrough {
+		v.pc = len(v.code)
+		return v.fail("Falling off the end of the code")
+	}
+	return nil
+}
+
+// findInstructions locates the start of every instruction, checking that each instruction
+// is valid and lies entirely within the bytecode. If the method uses JSR or RET and the
+// class's version is 50, isInstr is left nil; in later versions, this is a VerifyError.
+func (v *methodVerifier) findInstructions() error {
+	isInstr := make([]bool, len(v.code))
+	for v.pc = 0; v.pc < len(v.code); {
+		opcode := v.code[v.pc]
+		if opcode == opcodes.JSR || opcode == opcodes.JSR_W || opcode == opcodes.RET {
+			if v.klass.javaVersion == 50 {
+				return nil
+			}
+			return v.fail("Illegal instruction %s in class file version %d",
+				opcodes.BytecodeNames[opcode], v.klass.javaVersion)
+		}
+
+		length := instructionLength(v.code, v.pc)
+		if length <= 0 || v.pc+length > len(v.code) {
+			return v.fail("Bad instruction 0x%02X", opcode)
+		}
+		isInstr[v.pc] = true
+		v.pc += length
+	}
+	v.isInstr = isInstr
+	return nil
+}
+
+// instructionLength returns the length of the instruction at pc, or 0 if it's invalid
+func instructionLength(code []byte, pc int) int {
+	opcode := code[pc]
+	switch {
+	case opcode == opcodes.TABLESWITCH || opcode == opcodes.LOOKUPSWITCH:
+		operands := pc + 1 + (4-(pc+1)%4)%4
+		if operands+12 > len(code) {
+			return 0
+		}
+		if opcode == opcodes.TABLESWITCH {
+			low := int32From4Bytes(code, operands+4)
+			high := int32From4Bytes(code, operands+8)
+			if low > high {
+				return 0
+			}
+			return operands - pc + 12 + 4*int(high-low+1)
+		}
+		npairs := int32From4Bytes(code, operands+4)
+		if npairs < 0 {
+			return 0
+		}
+		return operands - pc + 8 + 8*int(npairs)
+	case opcode == opcodes.WIDE:
+		if pc+1 < len(code) && code[pc+1] == opcodes.IINC {
+			return 6
+		}
+		return 4
+	case opcode == opcodes.BIPUSH || opcode == opcodes.LDC || opcode == opcodes.NEWARRAY ||
+		(opcode >= opcodes.ILOAD && opcode <= opcodes.ALOAD) ||
+		(opcode >= opcodes.ISTORE && opcode <= opcodes.ASTORE) || opcode == opcodes.RET:
+		return 2
+	case opcode == opcodes.SIPUSH || opcode == opcodes.LDC_W || opcode == opcodes.LDC2_W ||
+		opcode == opcodes.IINC || (opcode >= opcodes.IFEQ && opcode <= opcodes.JSR) ||
+		(opcode >= opcodes.GETSTATIC && opcode <= opcodes.INVOKESTATIC) ||
+		opcode == opcodes.NEW || opcode == opcodes.ANEWARRAY || opcode == opcodes.CHECKCAST ||
+		opcode == opcodes.INSTANCEOF || opcode == opcodes.IFNULL || opcode == opcodes.IFNONNULL:
+		return 3
+	case opcode == opcodes.MULTIANEWARRAY:
+		return 4
+	case opcode == opcodes.INVOKEINTERFACE || opcode == opcodes.INVOKEDYNAMIC ||
+		opcode == opcodes.GOTO_W || opcode == opcodes.JSR_W:
+		return 5
+	case opcode < opcodes.BREAKPOINT:
+		return 1
+	}
+	return 0
+}
+
+// isStore reports whether the instruction at pc changes a local
+func isStore(code []byte, pc int) bool {
+	opcode := code[pc]
+	if opcode == opcodes.WIDE {
+		opcode = code[pc+1]
+	}
+	return (opcode >= opcodes.ISTORE && opcode <= opcodes.ASTORE_3) || opcode == opcodes.IINC
+}
+
+// initialFrame returns the types of the locals at the start of the method, which hold
+// 'this' (unless the method is static) and the method's arguments
+func (v *methodVerifier) initialFrame(meth *method) (typeFrame, error) {
+	params, ret, ok := util.ParseMethodDescriptor(v.desc)
+	if !ok {
+		return typeFrame{}, v.fail("Invalid method descriptor")
+	}
+	v.retType = ret
+
+	var locals []vtype
+	if meth.accessFlags&0x0008 == 0 { // not a static method
+		if v.name == "<init>" && v.className != types.ObjectClassName {
+			locals = append(locals, vtype{kind: vtUninitThis})
+		} else {
+			locals = append(locals, refType(v.className))
+		}
+	}
+	for _, param := range params {
+		t := typeFromDescriptor(param)
+		locals = append(locals, t)
+		if t.isCategory2() {
+			locals = append(locals, topType)
+		}
+	}
+	if len(locals) > v.maxLocals {
+		return typeFrame{}, v.fail("Arguments can't fit into locals")
+	}
+	return typeFrame{locals: locals}, nil
+}
+
+// padLocals fills the locals that a frame doesn't mention with top
+func (v *methodVerifier) padLocals(f typeFrame) typeFrame {
+	f = f.copy()
+	for len(f.locals) < v.maxLocals {
+		f.locals = append(f.locals, topType)
+	}
+	return f
+}
+
+// parseStackMapTable reads the method's StackMapTable attribute, if any, into v.stackMaps.
+// The attribute's format is described in:
+// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.4
+func (v *methodVerifier) parseStackMapTable(meth *method, initial typeFrame) error {
+	v.stackMaps = make(map[int]*typeFrame)
+	var content []byte
+	for _, a := range meth.codeAttr.attributes {
+		if v.klass.utf8Refs[a.attrName].content == "StackMapTable" {
+			content = a.attrContent
+			break
+		}
+	}
+	if content == nil {
+		return nil
+	}
+
+	v.pc = 0
+	truncated := func() error { return v.fail("Truncated StackMapTable attribute") }
+	if len(content) < 2 {
+		return truncated()
+	}
+	count := int(content[0])<<8 | int(content[1])
+	pos := 2
+
+	u2 := func() (int, bool) {
+		if pos+2 > len(content) {
+			return 0, false
+		}
+		pos += 2
+		return int(content[pos-2])<<8 | int(content[pos-1]), true
+	}
+	readTypes := func(n int) ([]vtype, error) {
+		var result []vtype
+		for i := 0; i < n; i++ {
+			if pos >= len(content) {
+				return nil, truncated()
+			}
+			tag := content[pos]
+			pos++
+			var t vtype
+			switch tag {
+			case 0:
+				t = topType
+			case 1:
+				t = intType
+			case 2:
+				t = floatType
+			case 3:
+				t = doubleType
+			case 4:
+				t = longType
+			case 5:
+				t = nullType
+			case 6:
+				t = vtype{kind: vtUninitThis}
+			case 7:
+				index, ok := u2()
+				if !ok {
+					return nil, truncated()
+				}
+				name, ok := v.classNameAt(index)
+				if !ok {
+					return nil, v.fail("Bad class index %d in StackMapTable", index)
+				}
+				t = refType(name)
+			case 8:
+				offset, ok := u2()
+				if !ok {
+					return nil, truncated()
+				}
+				if offset >= len(v.code) || !v.isInstr[offset] || v.code[offset] != opcodes.NEW {
+					return nil, v.fail("Bad uninitialized type offset %d in StackMapTable", offset)
+				}
+				t = vtype{kind: vtUninit, offset: offset}
+			default:
+				return nil, v.fail("Bad verification type tag %d in StackMapTable", tag)
+			}
+			result = append(result, t)
+			if t.isCategory2() {
+				result = append(result, topType)
+			}
+		}
+		return result, nil
+	}
+
+	locals := initial.locals
+	offset := -1
+	for i := 0; i < count; i++ {
+		if pos >= len(content) {
+			return truncated()
+		}
+		frameType := int(content[pos])
+		pos++
+
+		var delta int
+		var stack []vtype
+		var err error
+		ok := true
+		switch {
+		case frameType <= 63: // same_frame
+			delta = frameType
+		case frameType <= 127: // same_locals_1_stack_item_frame
+			delta = frameType - 64
+			stack, err = readTypes(1)
+		case frameType < 247:
+			return v.fail("Bad frame type %d in StackMapTable", frameType)
+		case frameType == 247: // same_locals_1_stack_item_frame_extended
+			delta, ok = u2()
+			if ok {
+				stack, err = readTypes(1)
+			}
+		case frameType <= 250: // chop_frame
+			delta, ok = u2()
+			locals = append([]vtype(nil), locals...)
+			for k := 251 - frameType; k > 0 && ok; k-- {
+				if len(locals) == 0 {
+					return v.fail("Bad chop frame in StackMapTable")
+				}
+				n := len(locals)
+				if n >= 2 && locals[n-1].kind == vtTop && locals[n-2].isCategory2() {
+					locals = locals[:n-2]
+				} else {
+					locals = locals[:n-1]
+				}
+			}
+		case frameType == 251: // same_frame_extended
+			delta, ok = u2()
+		case frameType <= 254: // append_frame
+			delta, ok = u2()
+			if ok {
+				var added []vtype
+				added, err = readTypes(frameType - 251)
+				locals = append(append([]vtype(nil), locals...), added...)
+			}
+		default: // full_frame
+			var n int
+			if delta, ok = u2(); ok {
+				if n, ok = u2(); ok {
+					if locals, err = readTypes(n); err == nil {
+						if n, ok = u2(); ok {
+							stack, err = readTypes(n)
+						}
+					}
+				}
+			}
+		}
+		if !ok {
+			return truncated()
+		}
+		if err != nil {
+			return err
+		}
+
+		offset += delta + 1
+		v.pc = offset
+		if offset >= len(v.code) || !v.isInstr[offset] {
+			return v.fail("StackMapTable error: bad offset")
+		}
+		if len(locals) > v.maxLocals {
+			return v.fail("StackMapTable error: the frame has more locals than max_locals")
+		}
+		if len(stack) > v.maxStack {
+			return v.fail("StackMapTable error: the frame's stack is larger than max_stack")
+		}
+		f := v.padLocals(typeFrame{locals: locals, stack: stack})
+		v.stackMaps[offset] = &f
+	}
+
+	if pos != len(content) {
+		return v.fail("StackMapTable has extra bytes")
+	}
+	return nil
+}
+
+// checkHandlers checks that every exception handler whose range covers the current
+// instruction has a stack map frame that accepts the given locals and, on the
+// operand stack, the exception that the handler catches
+func (v *methodVerifier) checkHandlers(meth *method, locals []vtype) error {
+	for _, ex := range meth.codeAttr.exceptions {
+		if v.pc < ex.startPc || v.pc >= ex.endPc {
+			continue
+		}
+
+		catchType := refType(throwableClassName)
+		if ex.catchType != 0 {
+			name, ok := v.classNameAt(ex.catchType)
+			if !ok {
+				return v.fail("Bad catch type in exception table")
+			}
+			catchType = refType(name)
+			if !v.isAssignable(catchType, refType(throwableClassName)) {
+				return v.fail("Catch type is not a subclass of Throwable")
+			}
+		}
+
+		handlerFrame, ok := v.stackMaps[ex.handlerPc]
+		if !ok {
+			return v.fail("Expecting a stackmap frame at exception handler %d", ex.handlerPc)
+		}
+		excFrame := typeFrame{locals: locals, stack: []vtype{catchType}}
+		if err := v.checkFrameAssignable(excFrame, *handlerFrame); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkFrameAssignable checks that every type in frame 'from' is assignable to the
+// corresponding type in frame 'to'
+func (v *methodVerifier) checkFrameAssignable(from, to typeFrame) error {
+	if len(from.stack) != len(to.stack) {
+		return v.fail("Inconsistent stack height %d != %d", len(from.stack), len(to.stack))
+	}
+	for i := range from.locals {
+		if !v.isAssignable(from.locals[i], to.locals[i]) {
+			return v.fail("Type %s (current frame, locals[%d]) is not assignable to %s (stack map, locals[%d])",
+				from.locals[i], i, to.locals[i], i)
+		}
+	}
+	for i := range from.stack {
+		if !v.isAssignable(from.stack[i], to.stack[i]) {
+			return v.fail("Type %s (current frame, stack[%d]) is not assignable to %s (stack map, stack[%d])",
+				from.stack[i], i, to.stack[i], i)
+		}
+	}
+	return nil
+}
+
+// ==== assignability ====
+
+// isAssignable reports whether a value of type 'from' can be used where type 'to' is expected
+func (v *methodVerifier) isAssignable(from, to vtype) bool {
+	switch {
+	case from == to || to.kind == vtTop:
+		return true
+	case to.kind != vtRef:
+		return false
+	case from.kind == vtNull:
+		return true
+	case from.kind == vtRef:
+		return v.isClassAssignable(from.name, to.name)
+	}
+	return false
+}
+
+// isClassAssignable reports whether the class or array type 'from' is assignable to the
+// class or array type 'to'. As in the JVM spec, interfaces are treated as Object.
+func (v *methodVerifier) isClassAssignable(from, to string) bool {
+	if from == to || to == types.ObjectClassName {
+		return true
+	}
+
+	if strings.HasPrefix(from, "[") {
+		if !strings.HasPrefix(to, "[") {
+			return to == "java/lang/Cloneable" || to == "java/io/Serializable"
+		}
+		fromComponent, toComponent := from[1:], to[1:]
+		fromIsRef := fromComponent[0] == 'L' || fromComponent[0] == '['
+		toIsRef := toComponent[0] == 'L' || toComponent[0] == '['
+		if !fromIsRef || !toIsRef {
+			return fromComponent == toComponent
+		}
+		return v.isClassAssignable(typeFromDescriptor(fromComponent).name, typeFromDescriptor(toComponent).name)
+	}
+	if strings.HasPrefix(to, "[") {
+		return false
+	}
+
+	isInterface, _, found := v.classInfo(to)
+	if !found || isInterface {
+		return true
+	}
+	for cls := from; cls != to; {
+		_, superclass, found := v.classInfo(cls)
+		if !found {
+			return true // can't tell, so assume it's assignable
+		}
+		if superclass == "" {
+			return false
+		}
+		cls = superclass
+	}
+	return true
+}
+
+// classInfo returns whether the named class is an interface and the name of its superclass,
+// which is "" for java/lang/Object. found is false if the class is not loaded and not part
+// of the JDK.
+func (v *methodVerifier) classInfo(className string) (isInterface bool, superclass string, found bool) {
+	if className == v.className {
+		if v.klass.superClassIndex != types.InvalidStringIndex && className != types.ObjectClassName {
+			superclass = *stringPool.GetStringPointer(v.klass.superClassIndex)
+		}
+		return v.klass.classIsInterface, superclass, true
+	}
+
+	k := MethAreaFetch(className)
+	if k == nil && JmodMapSize() > 0 && JmodMapFetch(className) != "" {
+		if LoadClassFromNameOnly(className) == nil {
+			k = MethAreaFetch(className)
+		}
+	}
+	if k == nil || k.Data == nil {
+		return false, "", false
+	}
+
+	if className != types.ObjectClassName {
+		superclass = *stringPool.GetStringPointer(k.Data.SuperclassIndex)
+	}
+	return k.Data.Access.ClassIsInterface, superclass, true
+}
+
+// ==== the constant pool ====
+
+// classNameAt returns the class name (or array descriptor) of the ClassRef at CP entry index
+func (v *methodVerifier) classNameAt(index int) (string, bool) {
+	cp := v.klass.cpIndex
+	if index < 1 || index >= len(cp) || cp[index].entryType != ClassRef {
+		return "", false
+	}
+	namePtr := stringPool.GetStringPointer(v.klass.classRefs[cp[index].slot])
+	if namePtr == nil {
+		return "", false
+	}
+	return *namePtr, true
+}
+
+// memberRef returns the class, name, and descriptor of the field or method referred to by
+// the CP entry at index, which must be of the given type (FieldRef, MethodRef, or Interface)
+func (v *methodVerifier) memberRef(index int, entryTypes ...int) (className, name, desc string, err error) {
+	cp := v.klass.cpIndex
+	if index < 1 || index >= len(cp) {
+		return "", "", "", v.fail("Illegal constant pool index %d", index)
+	}
+
+	var classIndex, natIndex int
+	entry := cp[index]
+	for _, entryType := range entryTypes {
+		if entry.entryType != entryType {
+			continue
+		}
+		switch entryType {
+		case FieldRef:
+			classIndex, natIndex = v.klass.fieldRefs[entry.slot].classIndex, v.klass.fieldRefs[entry.slot].nameAndTypeIndex
+		case MethodRef:
+			classIndex, natIndex = v.klass.methodRefs[entry.slot].classIndex, v.klass.methodRefs[entry.slot].nameAndTypeIndex
+		case Interface:
+			classIndex, natIndex = v.klass.interfaceRefs[entry.slot].classIndex, v.klass.interfaceRefs[entry.slot].nameAndTypeIndex
+		}
+	}
+	if natIndex == 0 {
+		return "", "", "", v.fail("Illegal type at constant pool entry %d", index)
+	}
+
+	className, ok := v.classNameAt(classIndex)
+	if !ok {
+		return "", "", "", v.fail("Illegal class reference at constant pool entry %d", index)
+	}
+	name, desc, err = ResolveCPnameAndType(v.klass, natIndex)
+	if err != nil {
+		return "", "", "", v.fail("Illegal name and type at constant pool entry %d", index)
+	}
+	return className, name, desc, nil
+}
+
+// ldcType returns the type of the value that LDC, LDC_W, or LDC2_W pushes for the
+// CP entry at index
+func (v *methodVerifier) ldcType(index int, wide bool) (vtype, error) {
+	cp := v.klass.cpIndex
+	if index < 1 || index >= len(cp) {
+		return topType, v.fail("Illegal constant pool index %d", index)
+	}
+
+	var t vtype
+	switch cp[index].entryType {
+	case IntConst:
+		t = intType
+	case FloatConst:
+		t = floatType
+	case LongConst:
+		t = longType
+	case DoubleConst:
+		t = doubleType
+	case StringConst:
+		t = refType(types.StringClassName)
+	case ClassRef:
+		t = refType("java/lang/Class")
+	case MethodType:
+		t = refType("java/lang/invoke/MethodType")
+	case MethodHandle:
+		t = refType("java/lang/invoke/MethodHandle")
+	case Dynamic:
+		_, desc, err := ResolveCPnameAndType(v.klass, v.klass.dynamics[cp[index].slot].nameAndType)
+		if err != nil || desc == "" {
+			return topType, v.fail("Illegal dynamic constant at constant pool entry %d", index)
+		}
+		t = typeFromDescriptor(desc)
+	default:
+		return topType, v.fail("Illegal type at constant pool entry %d", index)
+	}
+
+	if t.isCategory2() != wide {
+		return topType, v.fail("Illegal type at constant pool entry %d for %s",
+			index, opcodes.BytecodeNames[v.code[v.pc]])
+	}
+	return t, nil
+}
+
+// ==== the operand stack and locals ====
+
+func (v *methodVerifier) push(t vtype) error {
+	v.cur.stack = append(v.cur.stack, t)
+	if t.isCategory2() {
+		v.cur.stack = append(v.cur.stack, topType)
+	}
+	if len(v.cur.stack) > v.maxStack {
+		return v.fail("Exceeded max stack size")
+	}
+	return nil
+}
+
+// popWord pops one word off the operand stack, whatever its type
+func (v *methodVerifier) popWord() (vtype, error) {
+	n := len(v.cur.stack)
+	if n == 0 {
+		return topType, v.fail("Operand stack underflow")
+	}
+	t := v.cur.stack[n-1]
+	v.cur.stack = v.cur.stack[:n-1]
+	return t, nil
+}
+
+// pop pops a value that must be assignable to the expected type
+func (v *methodVerifier) pop(expected vtype) (vtype, error) {
+	if expected.isCategory2() {
+		if upper, err := v.popWord(); err != nil {
+			return topType, err
+		} else if upper.kind != vtTop {
+			return topType, v.fail("Bad type on operand stack: expected %s", expected)
+		}
+	}
+	t, err := v.popWord()
+	if err != nil {
+		return topType, err
+	}
+	if !v.isAssignable(t, expected) {
+		return topType, v.fail("Bad type on operand stack: %s is not assignable to %s", t, expected)
+	}
+	return t, nil
+}
+
+// popRef pops a reference, whatever its type
+func (v *methodVerifier) popRef() (vtype, error) {
+	t, err := v.popWord()
+	if err == nil && !t.isReference() {
+		err = v.fail("Bad type on operand stack: expected a reference, got %s", t)
+	}
+	return t, err
+}
+
+// popArray pops an array reference (or null), which must satisfy isValid, and returns its type
+func (v *methodVerifier) popArray(isValid func(desc string) bool) (vtype, error) {
+	t, err := v.popWord()
+	if err != nil {
+		return topType, err
+	}
+	if t.kind == vtNull || (t.kind == vtRef && strings.HasPrefix(t.name, "[") && isValid(t.name)) {
+		return t, nil
+	}
+	return topType, v.fail("Bad type on operand stack: %s is not a valid array for %s",
+		t, opcodes.BytecodeNames[v.code[v.pc]])
+}
+
+// popWords pops the given number of words for the stack manipulation instructions (DUP
+// and the like), which must not split a long or double
+func (v *methodVerifier) popWords(n int) ([]vtype, error) {
+	if len(v.cur.stack) < n {
+		return nil, v.fail("Operand stack underflow")
+	}
+	words := append([]vtype(nil), v.cur.stack[len(v.cur.stack)-n:]...)
+	if words[0].kind == vtTop { // the deepest word is the second half of a long or double
+		return nil, v.fail("Bad type on operand stack: %s would split a long or double",
+			opcodes.BytecodeNames[v.code[v.pc]])
+	}
+	v.cur.stack = v.cur.stack[:len(v.cur.stack)-n]
+	return words, nil
+}
+
+func (v *methodVerifier) pushWords(groups ...[]vtype) error {
+	for _, group := range groups {
+		v.cur.stack = append(v.cur.stack, group...)
+	}
+	if len(v.cur.stack) > v.maxStack {
+		return v.fail("Exceeded max stack size")
+	}
+	return nil
+}
+
+// load checks that the local at index holds a value of the expected type and pushes it
+func (v *methodVerifier) load(index int, expected vtype) error {
+	size := 1
+	if expected.isCategory2() {
+		size = 2
+	}
+	if index+size > len(v.cur.locals) {
+		return v.fail("Illegal local variable number %d", index)
+	}
+
+	t := v.cur.locals[index]
+	if expected.kind == vtRef { // ALOAD loads any reference, including uninitialized ones
+		if !t.isReference() {
+			return v.fail("Bad local variable type: expected a reference in locals[%d], got %s", index, t)
+		}
+	} else if t != expected || (size == 2 && v.cur.locals[index+1].kind != vtTop) {
+		return v.fail("Bad local variable type: expected %s in locals[%d], got %s", expected, index, t)
+	}
+	return v.push(t)
+}
+
+// store sets the type of the local at index. A long or double that occupied the
+// previous local is no longer valid.
+func (v *methodVerifier) store(index int, t vtype) error {
+	size := 1
+	if t.isCategory2() {
+		size = 2
+	}
+	if index+size > len(v.cur.locals) {
+		return v.fail("Illegal local variable number %d", index)
+	}
+
+	if index > 0 && v.cur.locals[index-1].isCategory2() {
+		v.cur.locals[index-1] = topType
+	}
+	v.cur.locals[index] = t
+	if size == 2 {
+		v.cur.locals[index+1] = topType
+	}
+	return nil
+}
+
+// popAndStore pops a value of the expected type and stores it in the local at index
+func (v *methodVerifier) popAndStore(index int, expected vtype) error {
+	var t vtype
+	var err error
+	if expected.kind == vtRef { // ASTORE stores any reference, including uninitialized ones
+		t, err = v.popRef()
+	} else {
+		t, err = v.pop(expected)
+	}
+	if err != nil {
+		return err
+	}
+	return v.store(index, t)
+}
+
+// checkBranch checks that the current types are acceptable at the branch target
+func (v *methodVerifier) checkBranch(offset int) error {
+	target := v.pc + offset
+	if target < 0 || target >= len(v.code) || !v.isInstr[target] {
+		return v.fail("Illegal target of jump or branch: %d", target)
+	}
+	stackMap, ok := v.stackMaps[target]
+	if !ok {
+		return v.fail("Expecting a stackmap frame at branch target %d", target)
+	}
+	return v.checkFrameAssignable(v.cur, *stackMap)
+}
+
+// popArgs pops the arguments of a method with the given descriptor and returns
+// the method's return type descriptor
+func (v *methodVerifier) popArgs(desc string) (string, error) {
+	params, ret, ok := util.ParseMethodDescriptor(desc)
+	if !ok {
+		return "", v.fail("Invalid method descriptor %s", desc)
+	}
+	for i := len(params) - 1; i >= 0; i-- {
+		if _, err := v.pop(typeFromDescriptor(params[i])); err != nil {
+			return "", err
+		}
+	}
+	return ret, nil
+}
+
+func (v *methodVerifier) pushReturn(ret string) error {
+	if ret == "V" {
+		return nil
+	}
+	return v.push(typeFromDescriptor(ret))
+}
+
+// initialize replaces all occurrences of an uninitialized type with the initialized
+// class type, after its constructor is invoked
+func (v *methodVerifier) initialize(uninit vtype, className string) {
+	initialized := refType(className)
+	for i, t := range v.cur.locals {
+		if t == uninit {
+			v.cur.locals[i] = initialized
+		}
+	}
+	for i, t := range v.cur.stack {
+		if t == uninit {
+			v.cur.stack[i] = initialized
+		}
+	}
+}
+
+// ==== the instructions ====
+
+// execute checks the instruction at v.pc and updates v.cur with its effects. It
+// returns the length of the instruction and whether execution can continue with the
+// next instruction.
+func (v *methodVerifier) execute() (length int, fallsThrough bool, err error) {
+	code := v.code
+	pc := v.pc
+	opcode := code[pc]
+	length = instructionLength(code, pc)
+	fallsThrough = true
+	u2 := func(pos int) int { return int(code[pos])<<8 | int(code[pos+1]) }
+	offset16 := func() int { return int(int16(u2(pc + 1))) }
+
+	switch opcode {
+	case opcodes.NOP:
+	case opcodes.ACONST_NULL:
+		err = v.push(nullType)
+	case opcodes.ICONST_M1, opcodes.ICONST_0, opcodes.ICONST_1, opcodes.ICONST_2,
+		opcodes.ICONST_3, opcodes.ICONST_4, opcodes.ICONST_5, opcodes.BIPUSH, opcodes.SIPUSH:
+		err = v.push(intType)
+	case opcodes.LCONST_0, opcodes.LCONST_1:
+		err = v.push(longType)
+	case opcodes.FCONST_0, opcodes.FCONST_1, opcodes.FCONST_2:
+		err = v.push(floatType)
+	case opcodes.DCONST_0, opcodes.DCONST_1:
+		err = v.push(doubleType)
+	case opcodes.LDC, opcodes.LDC_W, opcodes.LDC2_W:
+		index := int(code[pc+1])
+		if opcode != opcodes.LDC {
+			index = u2(pc + 1)
+		}
+		var t vtype
+		if t, err = v.ldcType(index, opcode == opcodes.LDC2_W); err == nil {
+			err = v.push(t)
+		}
+
+	case opcodes.ILOAD, opcodes.LLOAD, opcodes.FLOAD, opcodes.DLOAD, opcodes.ALOAD:
+		err = v.load(int(code[pc+1]), loadStoreType(opcode-opcodes.ILOAD))
+	case opcodes.ILOAD_0, opcodes.ILOAD_1, opcodes.ILOAD_2, opcodes.ILOAD_3,
+		opcodes.LLOAD_0, opcodes.LLOAD_1, opcodes.LLOAD_2, opcodes.LLOAD_3,
+		opcodes.FLOAD_0, opcodes.FLOAD_1, opcodes.FLOAD_2, opcodes.FLOAD_3,
+		opcodes.DLOAD_0, opcodes.DLOAD_1, opcodes.DLOAD_2, opcodes.DLOAD_3,
+		opcodes.ALOAD_0, opcodes.ALOAD_1, opcodes.ALOAD_2, opcodes.ALOAD_3:
+		n := int(opcode - opcodes.ILOAD_0)
+		err = v.load(n%4, loadStoreType(byte(n/4)))
+	case opcodes.ISTORE, opcodes.LSTORE, opcodes.FSTORE, opcodes.DSTORE, opcodes.ASTORE:
+		err = v.popAndStore(int(code[pc+1]), loadStoreType(opcode-opcodes.ISTORE))
+	case opcodes.ISTORE_0, opcodes.ISTORE_1, opcodes.ISTORE_2, opcodes.ISTORE_3,
+		opcodes.LSTORE_0, opcodes.LSTORE_1, opcodes.LSTORE_2, opcodes.LSTORE_3,
+		opcodes.FSTORE_0, opcodes.FSTORE_1, opcodes.FSTORE_2, opcodes.FSTORE_3,
+		opcodes.DSTORE_0, opcodes.DSTORE_1, opcodes.DSTORE_2, opcodes.DSTORE_3,
+		opcodes.ASTORE_0, opcodes.ASTORE_1, opcodes.ASTORE_2, opcodes.ASTORE_3:
+		n := int(opcode - opcodes.ISTORE_0)
+		err = v.popAndStore(n%4, loadStoreType(byte(n/4)))
+	case opcodes.WIDE:
+		index := u2(pc + 2)
+		switch op := code[pc+1]; {
+		case op >= opcodes.ILOAD && op <= opcodes.ALOAD:
+			err = v.load(index, loadStoreType(op-opcodes.ILOAD))
+		case op >= opcodes.ISTORE && op <= opcodes.ASTORE:
+			err = v.popAndStore(index, loadStoreType(op-opcodes.ISTORE))
+		case op == opcodes.IINC:
+			err = v.iinc(index)
+		default:
+			err = v.fail("Bad wide instruction")
+		}
+	case opcodes.IINC:
+		err = v.iinc(int(code[pc+1]))
+
+	case opcodes.IALOAD, opcodes.BALOAD, opcodes.CALOAD, opcodes.SALOAD:
+		if _, err = v.pop(intType); err == nil {
+			if _, err = v.popArray(primitiveArrayOf(opcode)); err == nil {
+				err = v.push(intType)
+			}
+		}
+	case opcodes.LALOAD, opcodes.FALOAD, opcodes.DALOAD:
+		if _, err = v.pop(intType); err == nil {
+			if _, err = v.popArray(primitiveArrayOf(opcode)); err == nil {
+				err = v.push(typeFromDescriptor(primitiveArrayTypes[opcode][0][1:]))
+			}
+		}
+	case opcodes.AALOAD:
+		var array vtype
+		if _, err = v.pop(intType); err == nil {
+			if array, err = v.popArray(isReferenceArray); err == nil {
+				if array.kind == vtNull {
+					err = v.push(nullType)
+				} else {
+					err = v.push(typeFromDescriptor(array.name[1:]))
+				}
+			}
+		}
+	case opcodes.IASTORE, opcodes.LASTORE, opcodes.FASTORE, opcodes.DASTORE,
+		opcodes.BASTORE, opcodes.CASTORE, opcodes.SASTORE:
+		value := typeFromDescriptor(primitiveArrayTypes[opcode][0][1:])
+		if _, err = v.pop(value); err == nil {
+			if _, err = v.pop(intType); err == nil {
+				_, err = v.popArray(primitiveArrayOf(opcode))
+			}
+		}
+	case opcodes.AASTORE:
+		if _, err = v.popRef(); err == nil {
+			if _, err = v.pop(intType); err == nil {
+				_, err = v.popArray(isReferenceArray)
+			}
+		}
+
+	case opcodes.POP:
+		_, err = v.popWords(1)
+	case opcodes.POP2:
+		_, err = v.popWords(2)
+	case opcodes.DUP:
+		var w1 []vtype
+		if w1, err = v.popWords(1); err == nil {
+			err = v.pushWords(w1, w1)
+		}
+	case opcodes.DUP_X1, opcodes.DUP_X2, opcodes.DUP2, opcodes.DUP2_X1, opcodes.DUP2_X2:
+		// the words being duplicated, and those they're inserted beneath
+		dupSize, underSize := 1, 0
+		switch opcode {
+		case opcodes.DUP_X1:
+			underSize = 1
+		case opcodes.DUP_X2:
+			underSize = 2
+		case opcodes.DUP2:
+			dupSize = 2
+		case opcodes.DUP2_X1:
+			dupSize, underSize = 2, 1
+		case opcodes.DUP2_X2:
+			dupSize, underSize = 2, 2
+		}
+		var dup, under []vtype
+		if dup, err = v.popWords(dupSize); err == nil {
+			if under, err = v.popWords(underSize); err == nil {
+				err = v.pushWords(dup, under, dup)
+			}
+		}
+	case opcodes.SWAP:
+		var w1, w2 []vtype
+		if w1, err = v.popWords(1); err == nil {
+			if w2, err = v.popWords(1); err == nil {
+				err = v.pushWords(w1, w2)
+			}
+		}
+
+	case opcodes.IADD, opcodes.ISUB, opcodes.IMUL, opcodes.IDIV, opcodes.IREM,
+		opcodes.ISHL, opcodes.ISHR, opcodes.IUSHR, opcodes.IAND, opcodes.IOR, opcodes.IXOR:
+		err = v.operation(intType, intType, intType)
+	case opcodes.LADD, opcodes.LSUB, opcodes.LMUL, opcodes.LDIV, opcodes.LREM,
+		opcodes.LAND, opcodes.LOR, opcodes.LXOR:
+		err = v.operation(longType, longType, longType)
+	case opcodes.LSHL, opcodes.LSHR, opcodes.LUSHR:
+		err = v.operation(longType, intType, longType)
+	case opcodes.FADD, opcodes.FSUB, opcodes.FMUL, opcodes.FDIV, opcodes.FREM:
+		err = v.operation(floatType, floatType, floatType)
+	case opcodes.DADD, opcodes.DSUB, opcodes.DMUL, opcodes.DDIV, opcodes.DREM:
+		err = v.operation(doubleType, doubleType, doubleType)
+	case opcodes.INEG:
+		err = v.conversion(intType, intType)
+	case opcodes.LNEG:
+		err = v.conversion(longType, longType)
+	case opcodes.FNEG:
+		err = v.conversion(floatType, floatType)
+	case opcodes.DNEG:
+		err = v.conversion(doubleType, doubleType)
+	case opcodes.I2L:
+		err = v.conversion(intType, longType)
+	case opcodes.I2F:
+		err = v.conversion(intType, floatType)
+	case opcodes.I2D:
+		err = v.conversion(intType, doubleType)
+	case opcodes.L2I:
+		err = v.conversion(longType, intType)
+	case opcodes.L2F:
+		err = v.conversion(longType, floatType)
+	case opcodes.L2D:
+		err = v.conversion(longType, doubleType)
+	case opcodes.F2I:
+		err = v.conversion(floatType, intType)
+	case opcodes.F2L:
+		err = v.conversion(floatType, longType)
+	case opcodes.F2D:
+		err = v.conversion(floatType, doubleType)
+	case opcodes.D2I:
+		err = v.conversion(doubleType, intType)
+	case opcodes.D2L:
+		err = v.conversion(doubleType, longType)
+	case opcodes.D2F:
+		err = v.conversion(doubleType, floatType)
+	case opcodes.I2B, opcodes.I2C, opcodes.I2S:
+		err = v.conversion(intType, intType)
+	case opcodes.LCMP:
+		err = v.operation(longType, longType, intType)
+	case opcodes.FCMPL, opcodes.FCMPG:
+		err = v.operation(floatType, floatType, intType)
+	case opcodes.DCMPL, opcodes.DCMPG:
+		err = v.operation(doubleType, doubleType, intType)
+
+	case opcodes.IFEQ, opcodes.IFNE, opcodes.IFLT, opcodes.IFGE, opcodes.IFGT, opcodes.IFLE:
+		if _, err = v.pop(intType); err == nil {
+			err = v.checkBranch(offset16())
+		}
+	case opcodes.IF_ICMPEQ, opcodes.IF_ICMPNE, opcodes.IF_ICMPLT,
+		opcodes.IF_ICMPGE, opcodes.IF_ICMPGT, opcodes.IF_ICMPLE:
+		if err = v.operation(intType, intType, topType); err == nil {
+			err = v.checkBranch(offset16())
+		}
+	case opcodes.IF_ACMPEQ, opcodes.IF_ACMPNE:
+		if _, err = v.popRef(); err == nil {
+			if _, err = v.popRef(); err == nil {
+				err = v.checkBranch(offset16())
+			}
+		}
+	case opcodes.IFNULL, opcodes.IFNONNULL:
+		if _, err = v.popRef(); err == nil {
+			err = v.checkBranch(offset16())
+		}
+	case opcodes.GOTO:
+		err = v.checkBranch(offset16())
+		fallsThrough = false
+	case opcodes.GOTO_W:
+		err = v.checkBranch(int(int32From4Bytes(code, pc+1)))
+		fallsThrough = false
+	case opcodes.TABLESWITCH, opcodes.LOOKUPSWITCH:
+		if _, err = v.pop(intType); err == nil {
+			err = v.checkSwitch()
+		}
+		fallsThrough = false
+
+	case opcodes.IRETURN, opcodes.LRETURN, opcodes.FRETURN, opcodes.DRETURN, opcodes.ARETURN:
+		if v.retType == "V" {
+			err = v.fail("Method expects no return value")
+		} else if _, err = v.pop(typeFromDescriptor(v.retType)); err == nil {
+			returned := map[byte]int{opcodes.IRETURN: vtInt, opcodes.LRETURN: vtLong,
+				opcodes.FRETURN: vtFloat, opcodes.DRETURN: vtDouble, opcodes.ARETURN: vtRef}
+			if typeFromDescriptor(v.retType).kind != returned[opcode] {
+				err = v.fail("Wrong return type in function")
+			}
+		}
+		fallsThrough = false
+	case opcodes.RETURN:
+		if v.retType != "V" {
+			err = v.fail("Method expects a return value")
+		}
+		for _, t := range v.cur.locals {
+			if t.kind == vtUninitThis {
+				err = v.fail("Constructor must call super() or this() before return")
+			}
+		}
+		fallsThrough = false
+	case opcodes.ATHROW:
+		_, err = v.pop(refType(throwableClassName))
+		fallsThrough = false
+
+	case opcodes.GETSTATIC, opcodes.PUTSTATIC, opcodes.GETFIELD, opcodes.PUTFIELD:
+		err = v.fieldAccess(opcode, u2(pc+1))
+	case opcodes.INVOKEVIRTUAL, opcodes.INVOKESPECIAL, opcodes.INVOKESTATIC, opcodes.INVOKEINTERFACE:
+		err = v.invoke(opcode, u2(pc+1))
+	case opcodes.INVOKEDYNAMIC:
+		err = v.invokedynamic(u2(pc + 1))
+
+	case opcodes.NEW:
+		if _, ok := v.classNameAt(u2(pc + 1)); !ok {
+			err = v.fail("Illegal constant pool index for NEW")
+		} else {
+			err = v.push(vtype{kind: vtUninit, offset: pc})
+		}
+	case opcodes.NEWARRAY:
+		arrayType, ok := newarrayTypes[code[pc+1]]
+		if !ok {
+			err = v.fail("Illegal NEWARRAY type %d", code[pc+1])
+		} else if _, err = v.pop(intType); err == nil {
+			err = v.push(refType(arrayType))
+		}
+	case opcodes.ANEWARRAY:
+		component, ok := v.classNameAt(u2(pc + 1))
+		if !ok {
+			err = v.fail("Illegal constant pool index for ANEWARRAY")
+		} else if _, err = v.pop(intType); err == nil {
+			if !strings.HasPrefix(component, "[") {
+				component = "L" + component + ";"
+			}
+			err = v.push(refType("[" + component))
+		}
+	case opcodes.MULTIANEWARRAY:
+		arrayType, ok := v.classNameAt(u2(pc + 1))
+		dimensions := int(code[pc+3])
+		if !ok || dimensions < 1 || strings.Count(arrayType, "[") < dimensions ||
+			!strings.HasPrefix(arrayType, strings.Repeat("[", dimensions)) {
+			err = v.fail("Illegal class or dimensions for MULTIANEWARRAY")
+		}
+		for i := 0; i < dimensions && err == nil; i++ {
+			_, err = v.pop(intType)
+		}
+		if err == nil {
+			err = v.push(refType(arrayType))
+		}
+	case opcodes.ARRAYLENGTH:
+		if _, err = v.popArray(func(string) bool { return true }); err == nil {
+			err = v.push(intType)
+		}
+	case opcodes.CHECKCAST, opcodes.INSTANCEOF:
+		className, ok := v.classNameAt(u2(pc + 1))
+		if !ok {
+			err = v.fail("Illegal constant pool index for %s", opcodes.BytecodeNames[opcode])
+		} else if _, err = v.popRef(); err == nil {
+			if opcode == opcodes.CHECKCAST {
+				err = v.push(refType(className))
+			} else {
+				err = v.push(intType)
+			}
+		}
+	case opcodes.MONITORENTER, opcodes.MONITOREXIT:
+		_, err = v.popRef()
+
+	default: // JSR, JSR_W, and RET are rejected by findInstructions()
+		err = v.fail("Bad instruction 0x%02X", opcode)
+	}
+	return length, fallsThrough, err
+}
+
+// the type of the ILOAD, LLOAD, FLOAD, DLOAD, and ALOAD family of instructions, and of the
+// corresponding stores, given the position of the instruction in the family
+func loadStoreType(position byte) vtype {
+	return []vtype{intType, longType, floatType, doubleType, refType(types.ObjectClassName)}[position]
+}
+
+// the array types that the primitive array loads and stores operate on
+var primitiveArrayTypes = map[byte][]string{
+	opcodes.IALOAD: {"[I"}, opcodes.LALOAD: {"[J"}, opcodes.FALOAD: {"[F"}, opcodes.DALOAD: {"[D"},
+	opcodes.BALOAD: {"[B", "[Z"}, opcodes.CALOAD: {"[C"}, opcodes.SALOAD: {"[S"},
+	opcodes.IASTORE: {"[I"}, opcodes.LASTORE: {"[J"}, opcodes.FASTORE: {"[F"}, opcodes.DASTORE: {"[D"},
+	opcodes.BASTORE: {"[B", "[Z"}, opcodes.CASTORE: {"[C"}, opcodes.SASTORE: {"[S"},
+}
+
+func primitiveArrayOf(opcode byte) func(string) bool {
+	return func(desc string) bool {
+		for _, arrayType := range primitiveArrayTypes[opcode] {
+			if desc == arrayType {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isReferenceArray(desc string) bool {
+	return len(desc) > 1 && (desc[1] == 'L' || desc[1] == '[')
+}
+
+// the array types created by NEWARRAY, by its operand
+var newarrayTypes = map[byte]string{
+	4: "[Z", 5: "[C", 6: "[F", 7: "[D", 8: "[B", 9: "[S", 10: "[I", 11: "[J",
+}
+
+// operation pops two operands of the given types and pushes a result of the given type
+// (unless it's top, as for the comparisons in IF_ICMPxx)
+func (v *methodVerifier) operation(operand1, operand2, result vtype) error {
+	if _, err := v.pop(operand2); err != nil {
+		return err
+	}
+	if _, err := v.pop(operand1); err != nil {
+		return err
+	}
+	if result.kind == vtTop {
+		return nil
+	}
+	return v.push(result)
+}
+
+// conversion pops an operand of one type and pushes a result of another
+func (v *methodVerifier) conversion(operand, result vtype) error {
+	if _, err := v.pop(operand); err != nil {
+		return err
+	}
+	return v.push(result)
+}
+
+func (v *methodVerifier) iinc(index int) error {
+	if index >= len(v.cur.locals) || v.cur.locals[index] != intType {
+		return v.fail("Bad local variable type: expected an integer in locals[%d] for IINC", index)
+	}
+	return nil
+}
+
+// checkSwitch checks the targets of TABLESWITCH and LOOKUPSWITCH
+func (v *methodVerifier) checkSwitch() error {
+	operands := v.pc + 1 + (4-(v.pc+1)%4)%4
+	targets := []int{int(int32From4Bytes(v.code, operands))} // the default
+	if v.code[v.pc] == opcodes.TABLESWITCH {
+		low := int32From4Bytes(v.code, operands+4)
+		high := int32From4Bytes(v.code, operands+8)
+		for i := 0; i <= int(high-low); i++ {
+			targets = append(targets, int(int32From4Bytes(v.code, operands+12+4*i)))
+		}
+	} else {
+		npairs := int(int32From4Bytes(v.code, operands+4))
+		for i := 0; i < npairs; i++ {
+			pair := operands + 8 + 8*i
+			if i > 0 && int32From4Bytes(v.code, pair) <= int32From4Bytes(v.code, pair-8) {
+				return v.fail("Bad lookupswitch instruction: keys are not sorted")
+			}
+			targets = append(targets, int(int32From4Bytes(v.code, pair+4)))
+		}
+	}
+
+	for _, target := range targets {
+		if err := v.checkBranch(target); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *methodVerifier) fieldAccess(opcode byte, index int) error {
+	className, _, desc, err := v.memberRef(index, FieldRef)
+	if err != nil {
+		return err
+	}
+	fieldType := typeFromDescriptor(desc)
+
+	switch opcode {
+	case opcodes.GETSTATIC:
+		return v.push(fieldType)
+	case opcodes.PUTSTATIC:
+		_, err = v.pop(fieldType)
+		return err
+	case opcodes.GETFIELD:
+		if _, err = v.pop(refType(className)); err != nil {
+			return err
+		}
+		return v.push(fieldType)
+	}
+
+	// PUTFIELD: a constructor can set the fields of its own class before calling super()
+	if _, err = v.pop(fieldType); err != nil {
+		return err
+	}
+	objRef, err := v.popRef()
+	if err != nil {
+		return err
+	}
+	if objRef.kind == vtUninitThis && className == v.className {
+		return nil
+	}
+	if !v.isAssignable(objRef, refType(className)) {
+		return v.fail("Bad type on operand stack: %s is not assignable to %s", objRef, refType(className))
+	}
+	return nil
+}
+
+func (v *methodVerifier) invoke(opcode byte, index int) error {
+	var className, name, desc string
+	var err error
+	switch opcode {
+	case opcodes.INVOKEVIRTUAL:
+		className, name, desc, err = v.memberRef(index, MethodRef)
+	case opcodes.INVOKEINTERFACE:
+		className, name, desc, err = v.memberRef(index, Interface)
+	default: // INVOKESPECIAL and INVOKESTATIC can refer to interface methods since Java 8
+		className, name, desc, err = v.memberRef(index, MethodRef, Interface)
+	}
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(name, "<") && (name != "<init>" || opcode != opcodes.INVOKESPECIAL) {
+		return v.fail("Illegal call to internal method %s", name)
+	}
+
+	ret, err := v.popArgs(desc)
+	if err != nil {
+		return err
+	}
+
+	if opcode == opcodes.INVOKEINTERFACE {
+		params, _, _ := util.ParseMethodDescriptor(desc)
+		words := 1
+		for _, param := range params {
+			words += 1
+			if typeFromDescriptor(param).isCategory2() {
+				words += 1
+			}
+		}
+		if int(v.code[v.pc+3]) != words || v.code[v.pc+4] != 0 {
+			return v.fail("Inconsistent args count operand in invokeinterface")
+		}
+	}
+
+	if opcode != opcodes.INVOKESTATIC {
+		objRef, err := v.popRef()
+		if err != nil {
+			return err
+		}
+
+		if name == "<init>" {
+			switch objRef.kind {
+			case vtUninitThis: // this() or super() in a constructor
+				v.initialize(objRef, v.className)
+			case vtUninit:
+				newClass, _ := v.classNameAt(int(v.code[objRef.offset+1])<<8 | int(v.code[objRef.offset+2]))
+				if newClass != className {
+					return v.fail("Call to wrong <init> method: %s for a new %s", className, newClass)
+				}
+				v.initialize(objRef, className)
+			default:
+				return v.fail("Bad type on operand stack: %s is not an uninitialized object", objRef)
+			}
+		} else if objRef.kind == vtUninit || objRef.kind == vtUninitThis {
+			return v.fail("Bad type on operand stack: %s is not initialized", objRef)
+		} else if !v.isAssignable(objRef, refType(className)) {
+			return v.fail("Bad type on operand stack: %s is not assignable to %s", objRef, refType(className))
+		}
+	}
+
+	return v.pushReturn(ret)
+}
+
+func (v *methodVerifier) invokedynamic(index int) error {
+	cp := v.klass.cpIndex
+	if index < 1 || index >= len(cp) || cp[index].entryType != InvokeDynamic {
+		return v.fail("Illegal constant pool index for INVOKEDYNAMIC")
+	}
+	if v.code[v.pc+3] != 0 || v.code[v.pc+4] != 0 {
+		return v.fail("Third and fourth operand bytes of invokedynamic must be zero")
+	}
+
+	_, desc, err := ResolveCPnameAndType(v.klass, v.klass.invokeDynamics[cp[index].slot].nameAndType)
+	if err != nil {
+		return v.fail("Illegal name and type for INVOKEDYNAMIC")
+	}
+	ret, err := v.popArgs(desc)
+	if err != nil {
+		return err
+	}
+	return v.pushReturn(ret)
+}
+
+// int32From4Bytes returns the signed 32-bit big-endian value at pos
+func int32From4Bytes(code []byte, pos int) int32 {
+	return int32(uint32(code[pos])<<24 | uint32(code[pos+1])<<16 | uint32(code[pos+2])<<8 | uint32(code[pos+3]))
+}
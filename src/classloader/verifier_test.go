@@ -0,0 +1,121 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package classloader
+
+import (
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/opcodes"
+	"strings"
+	"testing"
+)
+
+// newVerifierClass returns a version 52 (Java 8) class containing a single static method
+// with the given descriptor, bytecode, and StackMapTable attribute (if stackMap isn't nil)
+func newVerifierClass(desc string, maxStack, maxLocals int, code, stackMap []byte) *ParsedClass {
+	globals.InitGlobals("test")
+	log.Init()
+
+	klass := ParsedClass{javaVersion: 52, className: "VerifierTest"}
+	klass.utf8Refs = []utf8Entry{{""}, {"test"}, {desc}, {"StackMapTable"}}
+	meth := method{
+		accessFlags: 0x0008, // static
+		name:        1,
+		description: 2,
+		codeAttr:    codeAttrib{maxStack: maxStack, maxLocals: maxLocals, code: code},
+	}
+	if stackMap != nil {
+		meth.codeAttr.attributes = []attr{{attrName: 3, attrSize: len(stackMap), attrContent: stackMap}}
+	}
+	klass.methods = []method{meth}
+	return &klass
+}
+
+func TestVerifyValidMethod(t *testing.T) {
+	code := []byte{opcodes.ILOAD_0, opcodes.ILOAD_1, opcodes.IADD, opcodes.IRETURN}
+	klass := newVerifierClass("(II)I", 2, 2, code, nil)
+	if err := verifyClass(klass); err != nil {
+		t.Errorf("Expected valid method to pass verification, got: %s", err.Error())
+	}
+}
+
+func TestVerifyStackUnderflow(t *testing.T) {
+	code := []byte{opcodes.ILOAD_0, opcodes.IADD, opcodes.IRETURN}
+	klass := newVerifierClass("(I)I", 2, 1, code, nil)
+	err := verifyClass(klass)
+	if err == nil || !strings.Contains(err.Error(), "Operand stack underflow") {
+		t.Errorf("Expected stack underflow error, got: %v", err)
+	}
+}
+
+func TestVerifyWrongLocalType(t *testing.T) {
+	code := []byte{opcodes.ILOAD_0, opcodes.IRETURN}
+	klass := newVerifierClass("(F)I", 1, 1, code, nil)
+	err := verifyClass(klass)
+	if err == nil || !strings.Contains(err.Error(), "Bad local variable type") ||
+		!strings.Contains(err.Error(), "VerifierTest.test(F)I at offset 0") {
+		t.Errorf("Expected bad local variable type error, got: %v", err)
+	}
+}
+
+func TestVerifyBranchTargetNeedsStackMapFrame(t *testing.T) {
+	// static int abs(int i) { if (i >= 0) return i; return -i; }, with the branches reversed
+	code := []byte{
+		opcodes.ILOAD_0,
+		opcodes.IFGE, 0x00, 0x06, // to offset 7
+		opcodes.ILOAD_0,
+		opcodes.INEG,
+		opcodes.IRETURN,
+		opcodes.ILOAD_0, // offset 7
+		opcodes.IRETURN,
+	}
+
+	// a single same_frame at offset 7
+	klass := newVerifierClass("(I)I", 1, 1, code, []byte{0x00, 0x01, 0x07})
+	if err := verifyClass(klass); err != nil {
+		t.Errorf("Expected valid method to pass verification, got: %s", err.Error())
+	}
+
+	klass = newVerifierClass("(I)I", 1, 1, code, nil)
+	err := verifyClass(klass)
+	if err == nil || !strings.Contains(err.Error(), "Expecting a stackmap frame") {
+		t.Errorf("Expected missing stackmap frame error, got: %v", err)
+	}
+}
+
+func TestVerifyRejectsJSRAfterVersion50(t *testing.T) {
+	code := []byte{opcodes.JSR, 0x00, 0x03, opcodes.RETURN}
+	klass := newVerifierClass("()V", 1, 1, code, nil)
+	err := verifyClass(klass)
+	if err == nil || !strings.Contains(err.Error(), "Illegal instruction") {
+		t.Errorf("Expected JSR to be rejected in a version 52 class, got: %v", err)
+	}
+
+	klass.javaVersion = 50 // JSR is allowed, but the method isn't verified
+	if err = verifyClass(klass); err != nil {
+		t.Errorf("Expected method with JSR in a version 50 class to be skipped, got: %s", err.Error())
+	}
+}
+
+func TestShouldVerifyHonorsXverify(t *testing.T) {
+	globals.InitGlobals("test")
+	gl := globals.GetGlobalRef()
+
+	if !shouldVerify("com/example/App") || shouldVerify("java/lang/String") {
+		t.Error("Expected -Xverify:remote to verify only classes outside the JDK")
+	}
+
+	gl.VerifyLevel = globals.VerifyAll
+	if !shouldVerify("java/lang/String") {
+		t.Error("Expected -Xverify:all to verify JDK classes")
+	}
+
+	gl.VerifyLevel = globals.VerifyNone
+	if shouldVerify("com/example/App") {
+		t.Error("Expected -Xverify:none to verify no classes")
+	}
+}
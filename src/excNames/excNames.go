@@ -148,6 +148,7 @@ const (
 	InvalidPreferencesFormatException
 	InvalidTypeException
 	InvocationException
+	InvocationTargetException
 	IOException
 	JMException
 	JShellException
@@ -370,6 +371,7 @@ var JVMexceptionNames = []string{
 	"java.util.prefs.InvalidPreferencesFormatException",         // VERIFIED
 	"com.sun.jdi.InvalidTypeException",                          // VERIFIED
 	"com.sun.jdi.InvocationException",                           // VERIFIED
+	"java.lang.reflect.InvocationTargetException",               // VERIFIED
 	"java.io.IOException",                                       // VERIFIED
 	"javax.management.JMException",                              // VERIFIED
 	"jdk.jshell.JShellException",                                // VERIFIED
@@ -13,15 +13,12 @@ import (
 	"jacobin/excNames"
 	"jacobin/frames"
 	"jacobin/log"
+	"jacobin/object"
 	"jacobin/stringPool"
+	"jacobin/types"
 	"jacobin/util"
 )
 
-// the three likely superclasses of a given exception
-var javaLangError = "java/lang/Error"
-var javaLangException = "java/lang/Exception"
-var javaLangThrowable = "java/lang/Throwable"
-
 // This routine looks for a handler for the given exception (excName) in the
 // current frame stack working its way up the frame stack (fs). If one is found,
 // it returns a pointer to that frame, otherwise it returns nil. Param pc is the
@@ -34,6 +31,10 @@ func FindCatchFrame(fs *list.List, exceptName string, pc int) (*frames.Frame, in
 
 	for fr := fs.Front(); fr != nil; {
 		var f = fr.Value.(*frames.Frame)
+		if f.Ftype == 'G' { // the base frame of a call from a gfunction: handlers beyond it don't apply
+			return nil, -1
+		}
+
 		var searchPC int
 		if f.ExceptionPC == -1 {
 			searchPC = f.PC
@@ -56,12 +57,44 @@ func FindCatchFrame(fs *list.List, exceptName string, pc int) (*frames.Frame, in
 	return excFrame, excPC
 }
 
+// UnwindToCatchFrame pops the frames above the catch frame off the frame stack,
+// so that the catch frame is at the top. Synchronized methods whose frames are
+// discarded this way release their monitors.
+func UnwindToCatchFrame(fs *list.List, catchFrame *frames.Frame) {
+	for fs.Len() > 0 {
+		f := fs.Front().Value.(*frames.Frame)
+		if f == catchFrame {
+			break
+		}
+		if f.SyncObject != nil {
+			object.MonitorExit(f.SyncObject.(*object.Object), f.Thread)
+			f.SyncObject = nil
+		}
+		fs.Remove(fs.Front())
+	}
+}
+
+// UnwindToBaseFrame passes an exception that's not caught by a method called from a
+// gfunction (via jvm.InvokeMethod) back to the caller: the frames above the call's base
+// frame are discarded and the exception is recorded in the base frame, whose Ftype is 'G'.
+// It returns false if there's no base frame on the frame stack.
+func UnwindToBaseFrame(fs *list.List, throwObj *object.Object) bool {
+	for e := fs.Front(); e != nil; e = e.Next() {
+		if base := e.Value.(*frames.Frame); base.Ftype == 'G' {
+			UnwindToCatchFrame(fs, base)
+			base.Thrown = throwObj
+			return true
+		}
+	}
+	return false
+}
+
 // locateExceptionFrame (private to package exceptions) is a helper function for FindCatchFrame
 func locateExceptionFrame(f *frames.Frame, excName string, pc int) (*frames.Frame, int) {
 	// get the method and check for an exception catch table
 	// get the full method nameclassloader.MTable = {map[string]classloader.MTentry}
 	fullMethName := f.ClName + "." + f.MethName + f.MethType
-	methEntry, found := classloader.MTable[fullMethName]
+	methEntry, found := classloader.MTableFetch(fullMethName)
 	if !found {
 		errMsg := fmt.Sprintf("locateExceptionFrame: Method %s not found in MTable", fullMethName)
 		minimalAbort(excNames.InternalException, errMsg)
@@ -85,32 +118,52 @@ func locateExceptionFrame(f *frames.Frame, excName string, pc int) (*frames.Fram
 		// per https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.3
 		// the StartPC value is inclusive, the EndPC value is exclusive
 		if pc >= entry.StartPc && pc < entry.EndPc {
+			// a catch type of 0 catches all exceptions. javac generates these handlers
+			// for finally blocks and to release the monitor of a synchronized block.
+			if entry.CatchType == 0 {
+				return f, entry.HandlerPc
+			}
+
 			// found a handler, now check that it's for the right exception
 			CP := f.CP.(*classloader.CPool)
 			catchName :=
 				classloader.GetClassNameFromCPclassref(CP, uint16(entry.CatchType))
 
-			// TODO: add support for checking for subclasses
-			// In the meantime, check for a direct match or one of the typical
-			// superclasses.
-			if catchName == excName ||
-				catchName == "java/lang/Throwable" ||
-				catchName == "java/lang/Exception" ||
-				catchName == "java/lang/Error" {
+			// the handler catches the exception if it's the catch type or any subclass of it
+			if isSameOrSubclass(excName, catchName) {
 				return f, entry.HandlerPc
-			} else {
-				catchClass := classloader.MethAreaFetch(catchName)
-				if catchClass == nil { // if the class isn't found, skip it
-					continue // in theory, this should be impossible
-				}
-				if catchClass.Data.SuperclassIndex == stringPool.GetStringIndex(&javaLangThrowable) ||
-					catchClass.Data.SuperclassIndex == stringPool.GetStringIndex(&javaLangException) ||
-					catchClass.Data.SuperclassIndex == stringPool.GetStringIndex(&javaLangError) {
-					return f, entry.HandlerPc
-				}
 			}
 		}
 	}
 	// if we got this far, no exception handler was found
 	return nil, -1
 }
+
+// isSameOrSubclass reports whether the thrown exception, excName, is the class that a
+// handler catches, catchName, or one of its subclasses. It walks up the exception's
+// superclass chain, loading any superclasses that are not yet in the method area.
+func isSameOrSubclass(excName, catchName string) bool {
+	className := excName
+	for {
+		if className == catchName {
+			return true
+		}
+		if className == types.ObjectClassName {
+			return false
+		}
+
+		k := classloader.MethAreaFetch(className)
+		if k == nil {
+			if classloader.LoadClassFromNameOnly(className) != nil {
+				errMsg := fmt.Sprintf("isSameOrSubclass: could not load class %s", className)
+				_ = log.Log(errMsg, log.SEVERE)
+				return false
+			}
+			k = classloader.MethAreaFetch(className)
+		}
+		if k == nil || k.Data == nil {
+			return false
+		}
+		className = *stringPool.GetStringPointer(k.Data.SuperclassIndex)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package exceptions
+
+import (
+	"jacobin/classloader"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"testing"
+)
+
+// inserts a class with the given superclass into the method area
+func insertTestClass(name, superclass string) {
+	k := classloader.Klass{
+		Status: 'F',
+		Loader: "testloader",
+		Data:   &classloader.ClData{Name: name},
+	}
+	k.Data.SuperclassIndex = stringPool.GetStringIndex(&superclass)
+	classloader.MethAreaInsert(name, &k)
+}
+
+// a FileNotFoundException should be caught by handlers for every class in its
+// superclass chain, and by no others
+func TestIsSameOrSubclassWalksSuperclassChain(t *testing.T) {
+	globals.InitGlobals("test")
+	_ = log.SetLogLevel(log.WARNING)
+	classloader.InitMethodArea()
+
+	insertTestClass("java/lang/Throwable", types.ObjectClassName)
+	insertTestClass("java/lang/Exception", "java/lang/Throwable")
+	insertTestClass("java/io/IOException", "java/lang/Exception")
+	insertTestClass("java/io/FileNotFoundException", "java/io/IOException")
+	insertTestClass("java/lang/RuntimeException", "java/lang/Exception")
+
+	thrown := "java/io/FileNotFoundException"
+	for _, catchType := range []string{thrown, "java/io/IOException",
+		"java/lang/Exception", "java/lang/Throwable"} {
+		if !isSameOrSubclass(thrown, catchType) {
+			t.Errorf("Expected %s to be caught by a handler for %s", thrown, catchType)
+		}
+	}
+
+	if isSameOrSubclass(thrown, "java/lang/RuntimeException") {
+		t.Errorf("Did not expect %s to be caught by a handler for java/lang/RuntimeException", thrown)
+	}
+	if isSameOrSubclass("java/lang/Exception", "java/io/IOException") {
+		t.Error("Did not expect java/lang/Exception to be caught by a handler for java/io/IOException")
+	}
+}
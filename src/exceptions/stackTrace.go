@@ -0,0 +1,265 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package exceptions
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/util"
+	"strings"
+)
+
+// This file contains the routines that format Throwables and their stack traces
+// the way HotSpot does. The StackTraceElements (STEs) that make up a stack trace
+// are built by gfunction.FillInStackTrace(); their fields are Go values:
+//     declaringClass  string  the class name in internal format (java/lang/String)
+//     methodName      string
+//     fileName        string  "" if the class has no SourceFile attribute
+//     lineNumber      int64   -1 if the method has no LineNumberTable
+//     moduleName      string
+//
+// A Throwable's cause is in its "cause" field, which, as in Throwable.java, refers
+// to the Throwable itself until the cause is set. Its suppressed exceptions are in
+// the Jacobin-specific field "suppressed", a []*object.Object.
+//
+// As in HotSpot, a Throwable is shown by its toString() method. That method is called
+// only if the application overrides it, or overrides getMessage() or getLocalizedMessage(),
+// which Throwable.toString() uses; otherwise, the string is formatted here.
+
+// StackTraceElementString returns the STE in the format of
+// StackTraceElement.toString(), e.g.: pkg.Cls.meth(Cls.java:42)
+func StackTraceElementString(ste *object.Object) string {
+	declaringClass, _ := ste.FieldTable["declaringClass"].Fvalue.(string)
+	methodName, _ := ste.FieldTable["methodName"].Fvalue.(string)
+	fileName, _ := ste.FieldTable["fileName"].Fvalue.(string)
+	moduleName, _ := ste.FieldTable["moduleName"].Fvalue.(string)
+	lineNumber := int64(-1)
+	if line, ok := ste.FieldTable["lineNumber"].Fvalue.(int64); ok {
+		lineNumber = line
+	}
+
+	var sb strings.Builder
+	if moduleName != "" {
+		sb.WriteString(moduleName + "/")
+	}
+	sb.WriteString(util.ConvertInternalClassNameToUserFormat(declaringClass) + "." + methodName + "(")
+	switch {
+	case lineNumber == -2:
+		sb.WriteString("Native Method")
+	case fileName == "":
+		sb.WriteString("Unknown Source")
+	case lineNumber >= 0:
+		sb.WriteString(fmt.Sprintf("%s:%d", fileName, lineNumber))
+	default:
+		sb.WriteString(fileName)
+	}
+	sb.WriteString(")")
+	return sb.String()
+}
+
+// StackTraceElements returns the STEs in the stack trace of a Throwable, or
+// nil if its stack trace has not been filled in
+func StackTraceElements(throwable *object.Object) []*object.Object {
+	steArray, ok := throwable.FieldTable["stackTrace"].Fvalue.(*object.Object)
+	if !ok || object.IsNull(steArray) {
+		return nil
+	}
+	elements, _ := steArray.FieldTable["value"].Fvalue.([]*object.Object)
+	return elements
+}
+
+// ThrowableString returns the Throwable in the format of Throwable.toString(),
+// that is, the class name followed by the detail message, if any
+func ThrowableString(throwable *object.Object) string {
+	className := util.ConvertInternalClassNameToUserFormat(*stringPool.GetStringPointer(throwable.KlassName))
+	msg, ok := DetailMessage(throwable)
+	if !ok {
+		return className
+	}
+	return className + ": " + msg
+}
+
+// the methods that, if overridden, change the string form of a Throwable
+var throwableStringMethods = []string{
+	"toString()Ljava/lang/String;",
+	"getMessage()Ljava/lang/String;",
+	"getLocalizedMessage()Ljava/lang/String;",
+}
+
+// ThrowableToString returns the string form of a Throwable. If its class overrides
+// toString() or the methods that Throwable.toString() uses, the Throwable's toString()
+// is called on the frame stack fs, and an exception that it throws is returned as a
+// JavaException. Otherwise, the string is formatted by ThrowableString().
+func ThrowableToString(fs *list.List, throwable *object.Object) (string, error) {
+	className := *stringPool.GetStringPointer(throwable.KlassName)
+	if fs == nil || !overridesThrowableString(className) {
+		return ThrowableString(throwable), nil
+	}
+
+	ret, err := globals.GetGlobalRef().FuncInvokeMethod(fs, className, "toString", "()Ljava/lang/String;", []any{throwable})
+	var javaEx *JavaException
+	if errors.As(err, &javaEx) {
+		return "", err
+	}
+	if err != nil {
+		return ThrowableString(throwable), nil
+	}
+	str, ok := ret.(*object.Object)
+	if !ok || object.IsNull(str) {
+		return "null", nil // as the string is shown by println()
+	}
+	return object.GoStringFromStringObject(str), nil
+}
+
+// overridesThrowableString reports whether the named class, or one of its superclasses
+// that's not part of the JDK, declares one of the throwableStringMethods
+func overridesThrowableString(className string) bool {
+	for !util.IsFilePartOfJDK(&className) {
+		k := classloader.MethAreaFetch(className)
+		if k == nil || k.Data == nil {
+			return false
+		}
+		for _, meth := range throwableStringMethods {
+			if _, ok := k.Data.MethodTable[meth]; ok {
+				return true
+			}
+		}
+		superclassNamePtr := stringPool.GetStringPointer(k.Data.SuperclassIndex)
+		if superclassNamePtr == nil {
+			return false
+		}
+		className = *superclassNamePtr
+	}
+	return false
+}
+
+// DetailMessage returns the detail message of a Throwable. The second return
+// value is false if the message is null.
+func DetailMessage(throwable *object.Object) (string, bool) {
+	switch msg := throwable.FieldTable["detailMessage"].Fvalue.(type) {
+	case []byte:
+		return string(msg), true
+	case string:
+		return msg, true
+	case *object.Object:
+		if object.IsNull(msg) {
+			return "", false
+		}
+		switch value := msg.FieldTable["value"].Fvalue.(type) {
+		case []byte:
+			return string(value), true
+		case uint32:
+			return *stringPool.GetStringPointer(value), true
+		}
+	}
+	return "", false
+}
+
+// Cause returns the cause of a Throwable, or nil if the cause is null or unset
+func Cause(throwable *object.Object) *object.Object {
+	cause, ok := throwable.FieldTable["cause"].Fvalue.(*object.Object)
+	if !ok || object.IsNull(cause) || cause == throwable {
+		return nil
+	}
+	return cause
+}
+
+// Suppressed returns the exceptions that were suppressed in order to deliver the Throwable
+func Suppressed(throwable *object.Object) []*object.Object {
+	suppressed, _ := throwable.FieldTable["suppressed"].Fvalue.([]*object.Object)
+	return suppressed
+}
+
+// StackTraceString returns the text printed by Throwable.printStackTrace(): the
+// Throwable followed by one line for each entry in its stack trace, then the
+// stack traces of its suppressed exceptions and of its cause. As in HotSpot, the
+// frames that these enclosed stack traces have in common with the stack trace
+// that encloses them are replaced by "... n more". The Throwables are shown by
+// ThrowableToString(), which calls toString() on fs if it's overridden. If one of those
+// calls throws an exception, printStackTrace() would throw it, so it's returned along
+// with the stack trace, in which that Throwable is shown by ThrowableString().
+func StackTraceString(fs *list.List, throwable *object.Object) (string, error) {
+	var sb strings.Builder
+	var firstErr error
+	toString := func(throwable *object.Object) string {
+		str, err := ThrowableToString(fs, throwable)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			return ThrowableString(throwable)
+		}
+		return str
+	}
+	dejaVu := map[*object.Object]bool{throwable: true} // guards against circular causes
+
+	sb.WriteString(toString(throwable) + "\n")
+	trace := StackTraceElements(throwable)
+	for _, ste := range trace {
+		sb.WriteString("\tat " + StackTraceElementString(ste) + "\n")
+	}
+
+	for _, se := range Suppressed(throwable) {
+		writeEnclosedStackTrace(&sb, se, trace, "Suppressed: ", "\t", dejaVu, toString)
+	}
+	if cause := Cause(throwable); cause != nil {
+		writeEnclosedStackTrace(&sb, cause, trace, "Caused by: ", "", dejaVu, toString)
+	}
+	return sb.String(), firstErr
+}
+
+// writeEnclosedStackTrace writes the stack trace of a suppressed exception or cause
+// (throwable) that is enclosed in the stack trace, enclosingTrace, of another Throwable.
+// This follows Throwable.printEnclosedStackTrace() in the JDK.
+func writeEnclosedStackTrace(sb *strings.Builder, throwable *object.Object, enclosingTrace []*object.Object,
+	caption, prefix string, dejaVu map[*object.Object]bool, toString func(*object.Object) string) {
+	if dejaVu[throwable] {
+		sb.WriteString(prefix + caption + "[CIRCULAR REFERENCE: " + toString(throwable) + "]\n")
+		return
+	}
+	dejaVu[throwable] = true
+
+	// compute the number of frames in common with the enclosing trace
+	trace := StackTraceElements(throwable)
+	m := len(trace) - 1
+	for n := len(enclosingTrace) - 1; m >= 0 && n >= 0 && stackTraceElementsEqual(trace[m], enclosingTrace[n]); n-- {
+		m--
+	}
+	framesInCommon := len(trace) - 1 - m
+
+	sb.WriteString(prefix + caption + toString(throwable) + "\n")
+	for i := 0; i <= m; i++ {
+		sb.WriteString(prefix + "\tat " + StackTraceElementString(trace[i]) + "\n")
+	}
+	if framesInCommon != 0 {
+		sb.WriteString(fmt.Sprintf("%s\t... %d more\n", prefix, framesInCommon))
+	}
+
+	for _, se := range Suppressed(throwable) {
+		writeEnclosedStackTrace(sb, se, trace, "Suppressed: ", prefix+"\t", dejaVu, toString)
+	}
+	if cause := Cause(throwable); cause != nil {
+		writeEnclosedStackTrace(sb, cause, trace, "Caused by: ", prefix, dejaVu, toString)
+	}
+}
+
+// stackTraceElementsEqual reports whether two STEs refer to the same location,
+// as StackTraceElement.equals() does
+func stackTraceElementsEqual(ste1, ste2 *object.Object) bool {
+	for _, field := range []string{"declaringClass", "methodName", "fileName", "lineNumber",
+		"classLoaderName", "moduleName"} {
+		if ste1.FieldTable[field].Fvalue != ste2.FieldTable[field].Fvalue {
+			return false
+		}
+	}
+	return true
+}
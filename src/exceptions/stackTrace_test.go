@@ -0,0 +1,153 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package exceptions
+
+import (
+	"container/list"
+	"errors"
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"testing"
+)
+
+func TestStackTraceElementString(t *testing.T) {
+	globals.InitGlobals("test")
+
+	ste := object.MakeEmptyObject()
+	ste.FieldTable["declaringClass"] = object.Field{Fvalue: "pkg/Cls"}
+	ste.FieldTable["methodName"] = object.Field{Fvalue: "meth"}
+	ste.FieldTable["fileName"] = object.Field{Fvalue: "Cls.java"}
+	ste.FieldTable["lineNumber"] = object.Field{Ftype: types.Int, Fvalue: int64(42)}
+	if s := StackTraceElementString(ste); s != "pkg.Cls.meth(Cls.java:42)" {
+		t.Errorf("unexpected stack trace element: %s", s)
+	}
+
+	ste.FieldTable["lineNumber"] = object.Field{Ftype: types.Int, Fvalue: int64(-1)}
+	if s := StackTraceElementString(ste); s != "pkg.Cls.meth(Cls.java)" {
+		t.Errorf("unexpected stack trace element without line number: %s", s)
+	}
+
+	ste.FieldTable["fileName"] = object.Field{Fvalue: ""}
+	if s := StackTraceElementString(ste); s != "pkg.Cls.meth(Unknown Source)" {
+		t.Errorf("unexpected stack trace element without source file: %s", s)
+	}
+}
+
+// makes an STE for the method meth of class Main at the given line
+func makeTestSTE(meth string, line int64) *object.Object {
+	ste := object.MakeEmptyObject()
+	ste.FieldTable["declaringClass"] = object.Field{Fvalue: "Main"}
+	ste.FieldTable["methodName"] = object.Field{Fvalue: meth}
+	ste.FieldTable["fileName"] = object.Field{Fvalue: "Main.java"}
+	ste.FieldTable["lineNumber"] = object.Field{Ftype: types.Int, Fvalue: line}
+	return ste
+}
+
+// makes a Throwable of the given class whose stack trace consists of the given STEs
+func makeTestThrowable(className, msg string, trace ...*object.Object) *object.Object {
+	throwable := object.MakeEmptyObject()
+	throwable.KlassName = stringPool.GetStringIndex(&className)
+	throwable.FieldTable["detailMessage"] = object.Field{Ftype: types.Ref, Fvalue: object.StringObjectFromGoString(msg)}
+
+	steClassName := "java/lang/StackTraceElement"
+	stackTrace := object.Make1DimRefArray(&steClassName, int64(len(trace)))
+	copy(stackTrace.FieldTable["value"].Fvalue.([]*object.Object), trace)
+	throwable.FieldTable["stackTrace"] = object.Field{Ftype: types.Ref, Fvalue: stackTrace}
+	return throwable
+}
+
+// the stack traces of causes and suppressed exceptions omit the frames they have in
+// common with the enclosing trace, as HotSpot does
+func TestStackTraceStringWithCauseAndSuppressed(t *testing.T) {
+	globals.InitGlobals("test")
+
+	mainSTE := makeTestSTE("main", 3)
+	cause := makeTestThrowable("java/io/IOException", "disk", makeTestSTE("read", 20), makeTestSTE("load", 10), mainSTE)
+	closeErr := makeTestThrowable("java/lang/IllegalStateException", "close", makeTestSTE("close", 30), mainSTE)
+	throwable := makeTestThrowable("java/lang/RuntimeException", "failed", makeTestSTE("load", 12), mainSTE)
+	throwable.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: cause}
+	throwable.FieldTable["suppressed"] = object.Field{Ftype: types.RefArray, Fvalue: []*object.Object{closeErr}}
+
+	expected := "java.lang.RuntimeException: failed\n" +
+		"\tat Main.load(Main.java:12)\n" +
+		"\tat Main.main(Main.java:3)\n" +
+		"\tSuppressed: java.lang.IllegalStateException: close\n" +
+		"\t\tat Main.close(Main.java:30)\n" +
+		"\t\t... 1 more\n" +
+		"Caused by: java.io.IOException: disk\n" +
+		"\tat Main.read(Main.java:20)\n" +
+		"\tat Main.load(Main.java:10)\n" +
+		"\t... 1 more\n"
+	if s, _ := StackTraceString(nil, throwable); s != expected {
+		t.Errorf("unexpected stack trace, expected:\n%s\nobserved:\n%s", expected, s)
+	}
+}
+
+// a cause that refers back to an enclosing Throwable is shown as a circular reference
+func TestStackTraceStringWithCircularCause(t *testing.T) {
+	globals.InitGlobals("test")
+
+	first := makeTestThrowable("java/lang/Exception", "first")
+	second := makeTestThrowable("java/lang/Exception", "second")
+	first.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: second}
+	second.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: first}
+
+	expected := "java.lang.Exception: first\n" +
+		"Caused by: java.lang.Exception: second\n" +
+		"Caused by: [CIRCULAR REFERENCE: java.lang.Exception: first]\n"
+	if s, _ := StackTraceString(nil, first); s != expected {
+		t.Errorf("unexpected stack trace, expected:\n%s\nobserved:\n%s", expected, s)
+	}
+}
+
+// a Throwable whose class overrides toString(), or inherits an override from another
+// application class, is shown by calling its toString(), as HotSpot does
+func TestStackTraceStringCallsOverriddenToString(t *testing.T) {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+
+	superclass := "java/lang/RuntimeException"
+	appException := "test/AppException"
+	classloader.MethAreaInsert(appException, &classloader.Klass{Status: 'X', Loader: "test", Data: &classloader.ClData{
+		Name: appException, SuperclassIndex: stringPool.GetStringIndex(&superclass),
+		MethodTable: map[string]*classloader.Method{"getMessage()Ljava/lang/String;": {}}}})
+	classloader.MethAreaInsert("test/SubException", &classloader.Klass{Status: 'X', Loader: "test", Data: &classloader.ClData{
+		Name: "test/SubException", SuperclassIndex: stringPool.GetStringIndex(&appException)}})
+
+	var thrown *object.Object
+	globals.GetGlobalRef().FuncInvokeMethod = func(fs *list.List, className, methName, methType string, args []any) (any, error) {
+		if thrown != nil {
+			return nil, &JavaException{Throwable: thrown}
+		}
+		return object.StringObjectFromGoString("custom " + className), nil
+	}
+
+	cause := makeTestThrowable("test/SubException", "inner", makeTestSTE("main", 3))
+	throwable := makeTestThrowable("java/lang/IllegalStateException", "outer", makeTestSTE("main", 3))
+	throwable.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: cause}
+
+	expected := "java.lang.IllegalStateException: outer\n" +
+		"\tat Main.main(Main.java:3)\n" +
+		"Caused by: custom test/SubException\n" +
+		"\t... 1 more\n"
+	fs := frames.CreateFrameStack()
+	if s, err := StackTraceString(fs, throwable); s != expected || err != nil {
+		t.Errorf("unexpected stack trace (error: %v), expected:\n%s\nobserved:\n%s", err, expected, s)
+	}
+
+	// an exception thrown by toString() is returned
+	thrown = makeTestThrowable("java/lang/ArithmeticException", "in toString")
+	_, err := StackTraceString(fs, throwable)
+	var javaEx *JavaException
+	if !errors.As(err, &javaEx) || javaEx.Throwable != thrown {
+		t.Errorf("expected the exception thrown by toString(), got %v", err)
+	}
+}
@@ -14,7 +14,9 @@ import (
 	"jacobin/log"
 	"jacobin/object"
 	"jacobin/shutdown"
+	"jacobin/stringPool"
 	"jacobin/thread"
+	"jacobin/types"
 	"jacobin/util"
 	"os"
 	"runtime/debug"
@@ -31,6 +33,17 @@ const (
 	NotCaught = false
 )
 
+// JavaException is the error returned when Java code called by Jacobin (see
+// jvm.InvokeMethod) throws an exception that it doesn't catch. Throwable is the
+// exception, which the caller rethrows, wraps, or handles.
+type JavaException struct {
+	Throwable *object.Object
+}
+
+func (e *JavaException) Error() string {
+	return ThrowableString(e.Throwable)
+}
+
 // ThrowExNil simply calls ThrowEx with a nil pointer for the frame.
 func ThrowExNil(which int, msg string) bool {
 	return ThrowEx(which, msg, nil)
@@ -79,8 +92,8 @@ func ThrowEx(which int, msg string, f *frames.Frame) bool {
 		f.ExceptionPC = f.PC
 	}
 
-	th, ok := glob.Threads[f.Thread].(*thread.ExecThread)
-	if !ok {
+	th := thread.GetThread(f.Thread)
+	if th == nil {
 		errMsg := fmt.Sprintf("[ThrowEx] glob.Threads index not found or entry corrupted, thread index: %d", f.Thread)
 		minimalAbort(excNames.InternalException, errMsg)
 	}
@@ -93,26 +106,26 @@ func ThrowEx(which int, msg string, f *frames.Frame) bool {
 		// and that the returned frame is the frame
 		// containing the catch logic, referred to here as the catchFrame.
 		// now, set up the execution of the catch code by:
-		// 0. popping off the frames that are above the catch frame,
+		// 0. creating a new objRef for the exception and filling in its stack trace
+		//    while the frames where the exception occurred are still on the frame stack
+		// 1. popping off the frames that are above the catch frame,
 		//    if any--so that top frame in the frame stack is the catch frame
-		// 1. creating a new objRef for the exception
 		// 2. pushing the objRef on the op stack of the frame
 		// 3. setting the PC to point to the catch code (which expects the objRef at TOS)
 		caughtMsg := fmt.Sprintf("[ThrowEx] caught %s, msg: %s", exceptionCPname, msg)
 		_ = log.Log(caughtMsg, log.TRACE_INST)
 
-		th = glob.Threads[f.Thread].(*thread.ExecThread)
 		fs = th.Stack
-		for fs.Len() > 0 { // remove the frames we examined that did not have the catch logic
-			fr := fs.Front().Value
-			if fr == catchFrame {
-				break
-			} else {
-				fs.Remove(fs.Front())
+		objRef, _ := glob.FuncInstantiateClass(exceptionCPname, fs)
+		if throwObj, ok := objRef.(*object.Object); ok {
+			if msg != "" {
+				throwObj.FieldTable["detailMessage"] = object.Field{
+					Ftype: types.Ref, Fvalue: object.StringObjectFromGoString(msg)}
 			}
+			glob.FuncFillInStackTrace([]any{fs, throwObj})
 		}
 
-		objRef, _ := glob.FuncInstantiateClass(exceptionCPname, fs)
+		UnwindToCatchFrame(fs, catchFrame)
 		catchFrame.TOS = 0
 		catchFrame.OpStack[0] = objRef // push the objRef
 		catchFrame.PC = catchPC
@@ -137,47 +150,72 @@ func ThrowEx(which int, msg string, f *frames.Frame) bool {
 	}
 
 	throwObj := throwObject.(*object.Object)
+	if msg != "" {
+		throwObj.FieldTable["detailMessage"] = object.Field{
+			Ftype: types.Ref, Fvalue: object.StringObjectFromGoString(msg)}
+	}
 	params := []any{fs, throwObj}
 	glob.FuncFillInStackTrace(params)
 
-	excInfo := fmt.Sprintf("%s: %s", exceptionNameForUser, msg)
-	_, _ = fmt.Fprintln(os.Stderr, excInfo)
-
-	stackTrace := throwObj.FieldTable["stackTrace"].Fvalue.(*object.Object)
-	traceEntries := stackTrace.FieldTable["value"].Fvalue.([]*object.Object)
-
-	// now print out the JVM stack
-	for _, traceEntry := range traceEntries {
-		// HotSpot uses a slightly different format for method names:
-		// package.class.method, we prefer package/class.method, so we format
-		// method name according to whether -strictJDK is in force
-		var declaringClass string
-		if glob.StrictJDK {
-			declaringClass = util.ConvertInternalClassNameToUserFormat(
-				traceEntry.FieldTable["declaringClass"].Fvalue.(string))
-		} else {
-			declaringClass = traceEntry.FieldTable["declaringClass"].Fvalue.(string)
+	// an exception thrown in a method called from a gfunction goes back to the gfunction
+	if UnwindToBaseFrame(fs, throwObj) {
+		return Caught
+	}
+
+	// the exception is passed to the thread's uncaught exception handler, which prints
+	// its stack trace by default, and the thread ends. This call doesn't return.
+	glob.FuncUncaughtEx(th, throwObj, func() {
+		if !glob.StrictJDK {
+			// the next statement disables showing the line that identifies
+			// the cause of a golang panic, because if we got here, there
+			// was no panic, rather just an uncaught exception. So we show
+			// the golang stack without implying there was a panic.
+			glob.PanicCauseShown = true
+			ShowGoStackTrace("")
 		}
+	})
+	return NotCaught
+}
+
+// Throw throws an existing exception object, such as one passed back in a JavaException,
+// from frame f. Unlike ThrowEx(), it doesn't create the exception or fill in its stack
+// trace. In tests, an exception that's not caught is not passed to the thread's uncaught
+// exception handler; Throw simply returns NotCaught.
+func Throw(throwObj *object.Object, f *frames.Frame) bool {
+	exceptionName := *stringPool.GetStringPointer(throwObj.KlassName)
+	traceMsg := fmt.Sprintf("[Throw] %s", exceptionName)
+	_ = log.Log(traceMsg, log.TRACE_INST)
 
-		traceInfo := fmt.Sprintf("  at %s.%s(%s:%s)",
-			declaringClass,
-			traceEntry.FieldTable["methodName"].Fvalue.(string),
-			traceEntry.FieldTable["fileName"].Fvalue.(string),
-			traceEntry.FieldTable["sourceLine"].Fvalue.(string))
-		_, _ = fmt.Fprintln(os.Stderr, traceInfo)
+	if f.ExceptionPC == -1 {
+		f.ExceptionPC = f.PC
 	}
 
-	if !glob.StrictJDK {
-		// the next statement disables showing the line that identifies
-		// the cause of a golang panic, because if we got here, there
-		// was no panic, rather just an uncaught exception. So we show
-		// the golang stack without implying there was a panic.
-		glob.PanicCauseShown = true
-		ShowGoStackTrace("")
+	th := thread.GetThread(f.Thread)
+	fs := f.FrameStack
+	if fs == nil && th != nil {
+		fs = th.Stack
 	}
 
-	_ = shutdown.Exit(shutdown.JVM_EXCEPTION) // in test mode, this call returns
-	return NotCaught                          // only applies to tests
+	catchFrame, catchPC := FindCatchFrame(fs, exceptionName, f.ExceptionPC)
+	if catchFrame != nil {
+		UnwindToCatchFrame(fs, catchFrame)
+		catchFrame.TOS = 0
+		catchFrame.OpStack[0] = throwObj
+		catchFrame.PC = catchPC
+		f.ExceptionPC = -1 // see the note in ThrowEx()
+		return Caught
+	}
+
+	if UnwindToBaseFrame(fs, throwObj) {
+		return Caught
+	}
+
+	glob := globals.GetGlobalRef()
+	if th == nil || glob.JacobinName == "test" {
+		return NotCaught
+	}
+	glob.FuncUncaughtEx(th, throwObj, nil) // this doesn't return
+	return NotCaught
 }
 
 /* This code is not called. However, before deleting it, we want to make sure it won't be
@@ -46,11 +46,14 @@ type Frame struct {
 	CP           interface{}   // will hold a *classloader.CPool (constant pool ptr) but due to circularity must be done this way
 	Locals       []interface{} // local variables
 	OpStack      []interface{} // operand stack
+	OpStackWide  []bool        // in the dispatch-table interpreter, marks the op stack entries that hold a long or double
 	TOS          int           // top of the operand stack
 	PC           int           // program counter (index into the bytecode of the method)
 	Ftype        byte          // type of method in frame: 'J' = java, 'G' = Golang, 'N' = native
 	ExceptionPC  int           // program counter at the moment the PC threw an exception
 	WideInEffect bool          // WideInEffect indicates if the wide instruction is in effect in the current frame
+	SyncObject   any           // for synchronized methods, the *object.Object whose monitor the method holds
+	Thrown       any           // in the base frame of a call from a gfunction, the *object.Object thrown but not caught by the called method
 }
 
 // CreateFrameStack creates a stack of frames. Implemented as a list in which
@@ -97,6 +97,9 @@ func MTableLoadGFunctions(MTable *classloader.MT) {
 	Load_Lang_Long()
 	Load_Lang_Math()
 	Load_Lang_Object()
+	Load_Lang_Process()
+	Load_Lang_ProcessBuilder()
+	Load_Lang_Runtime()
 	Load_Lang_Short()
 	Load_Lang_StackTraceELement()
 	Load_Lang_String()
@@ -104,9 +107,18 @@ func MTableLoadGFunctions(MTable *classloader.MT) {
 	Load_Lang_StringBuilder()
 	Load_Lang_System()
 	Load_Lang_Thread()
+	Load_Lang_ThreadGroup()
 	Load_Lang_Throwable()
 	Load_Lang_UTF16()
 
+	// java/lang/annotation/*
+	Load_Lang_Annotation()
+
+	// java/lang/reflect/*
+	Load_Lang_Reflect()
+	Load_Lang_Reflect_Type()
+	Load_Lang_Record()
+
 	// java/math/*
 	Load_Math_Big_Integer()
 
@@ -17,6 +17,7 @@ import (
 	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/object"
+	"jacobin/thread"
 	"slices"
 	"sync"
 	"time"
@@ -121,12 +122,7 @@ func RunGfunction(mt classloader.MTentry, fs *list.List,
 		// var errorDetails string
 		errBlk := *ret.(*GErrBlk)
 
-		var threadName string
-		if f.Thread == 1 {
-			threadName = "main"
-		} else {
-			threadName = fmt.Sprintf("%d", f.Thread)
-		}
+		threadName := thread.GetThreadName(f.Thread)
 		errMsg := fmt.Sprintf("%s in thread: %s, method: %s",
 			errBlk.ErrMsg, threadName, fullMethName)
 		status := exceptions.ThrowEx(errBlk.ExceptionType, errMsg, f)
@@ -138,6 +134,15 @@ func RunGfunction(mt classloader.MTentry, fs *list.List,
 		}
 
 	case error:
+		// an exception thrown by Java code that the gfunction called is rethrown here
+		var javaEx *exceptions.JavaException
+		if errors.As(ret.(error), &javaEx) {
+			if exceptions.Throw(javaEx.Throwable, f) != exceptions.Caught {
+				return ret.(error) // applies only if in test
+			}
+			return CaughtGfunctionException
+		}
+
 		errMsg := (ret.(error)).Error()
 		status := exceptions.ThrowEx(excNames.NativeMethodException, errMsg, f)
 		if status != exceptions.Caught {
@@ -147,6 +152,18 @@ func RunGfunction(mt classloader.MTentry, fs *list.List,
 		}
 	}
 
+	// an allocation by the gfunction that took the heap past the limit (-Xmx)
+	// throws an OutOfMemoryError once the gfunction has returned
+	if object.HeapOverflowed() {
+		object.ThrowingOutOfMemoryError(true) // leave room for the exception
+		status := exceptions.ThrowEx(excNames.OutOfMemoryError, "Java heap space", f)
+		object.ThrowingOutOfMemoryError(false)
+		if status != exceptions.Caught {
+			return errors.New("OutOfMemoryError: Java heap space") // applies only if in test
+		}
+		return CaughtGfunctionException
+	}
+
 	// if return is not an errBlk or an error, then it's a legitimate
 	// return value, so return it.
 	return ret
@@ -0,0 +1,47 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"strings"
+	"testing"
+)
+
+// a gfunction whose allocations take the heap past the limit (-Xmx) throws an
+// OutOfMemoryError when it returns
+func TestRunGfunctionPastHeapLimit(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	defer func() { g.MaxHeapSize = 0 }()
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(frames.CreateFrame(1))
+	mt := classloader.MTentry{MType: 'G', Meth: GMeth{
+		ParamSlots: 0,
+		GFunction: func([]interface{}) interface{} {
+			return object.StringObjectFromGoString("allocated")
+		},
+	}}
+	run := func() any {
+		var params []interface{}
+		return RunGfunction(mt, fs, "test/Alloc", "make", "()Ljava/lang/String;", &params, false, false)
+	}
+
+	if ret, ok := run().(*object.Object); !ok || object.GoStringFromStringObject(ret) != "allocated" {
+		t.Errorf("expected the gfunction's string when there's no maximum heap size, got %v", ret)
+	}
+
+	g.MaxHeapSize = 1 << 10 // -Xmx1k, which is smaller than the Go heap in use
+	err, ok := run().(error)
+	if !ok || !strings.Contains(err.Error(), "Java heap space") {
+		t.Errorf("expected an OutOfMemoryError, got %v", err)
+	}
+}
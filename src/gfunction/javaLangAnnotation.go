@@ -0,0 +1,763 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/object"
+	"jacobin/statics"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// The annotations of classes, methods, constructors, and fields, as returned by reflection.
+// The class loader parses the annotations that are visible at run time (see
+// classloader/annotations.go); here, they're turned into annotation objects.
+//
+// As in HotSpot, an annotation object is an instance of a proxy class that implements the
+// annotation interface. Jacobin spins one proxy class for each annotation interface: each
+// of its methods is a G function that returns the value of an element, or implements one
+// of the methods of java.lang.annotation.Annotation--annotationType(), equals(), hashCode(),
+// and toString(). The element values are converted to Java values when the annotation
+// object is created and are kept in the object's fields, named for the elements. Each
+// annotation in a class file is represented by a single annotation object.
+//
+// Annotations whose interfaces can't be found are ignored, as in HotSpot.
+
+var annotationClassName = "java/lang/annotation/Annotation"
+var annotationArrayClassName = "[Ljava/lang/annotation/Annotation;"
+
+// the descriptor of java.lang.annotation.Inherited, which marks annotations that a class
+// inherits from its superclasses
+const inheritedAnnotationType = "Ljava/lang/annotation/Inherited;"
+
+// the proxy classes spun so far, by annotation interface name
+var annotationProxies = struct {
+	sync.Mutex
+	classes map[string]string
+	count   atomic.Int64
+}{classes: make(map[string]string)}
+
+// the annotation objects created so far, by the annotation they represent
+var annotationObjects = sync.Map{} // *classloader.Annotation -> *object.Object
+
+// the annotations represented by annotation objects: used by equals(), hashCode(), and toString()
+var annotationsOfObjects = sync.Map{} // *object.Object -> *annotationData
+
+// annotationData holds an annotation and the values of all its elements, including the
+// defaulted elements, which are listed in the order in which they're shown by toString()
+type annotationData struct {
+	typeName string
+	names    []string
+	values   map[string]classloader.ElementValue
+}
+
+func Load_Lang_Annotation() {
+
+	MethodSignatures["java/lang/Class.getAnnotation(Ljava/lang/Class;)Ljava/lang/annotation/Annotation;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    annotatedGetAnnotation,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Class.getAnnotations()[Ljava/lang/annotation/Annotation;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    annotatedGetAnnotations,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Class.getDeclaredAnnotations()[Ljava/lang/annotation/Annotation;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    annotatedGetDeclaredAnnotations,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Class.isAnnotationPresent(Ljava/lang/Class;)Z"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    annotatedIsAnnotationPresent,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Class.isAnnotation()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsAnnotation,
+		}
+
+	for _, member := range []string{"java/lang/reflect/Method", "java/lang/reflect/Constructor", "java/lang/reflect/Field",
+		"java/lang/reflect/RecordComponent"} {
+		MethodSignatures[member+".getAnnotation(Ljava/lang/Class;)Ljava/lang/annotation/Annotation;"] =
+			GMeth{
+				ParamSlots:   1,
+				GFunction:    annotatedGetAnnotation,
+				NeedsContext: true,
+			}
+
+		MethodSignatures[member+".getAnnotations()[Ljava/lang/annotation/Annotation;"] =
+			GMeth{
+				ParamSlots:   0,
+				GFunction:    annotatedGetAnnotations,
+				NeedsContext: true,
+			}
+
+		MethodSignatures[member+".getDeclaredAnnotations()[Ljava/lang/annotation/Annotation;"] =
+			GMeth{
+				ParamSlots:   0,
+				GFunction:    annotatedGetDeclaredAnnotations,
+				NeedsContext: true,
+			}
+
+		MethodSignatures[member+".isAnnotationPresent(Ljava/lang/Class;)Z"] =
+			GMeth{
+				ParamSlots:   1,
+				GFunction:    annotatedIsAnnotationPresent,
+				NeedsContext: true,
+			}
+	}
+
+	MethodSignatures["java/lang/reflect/Method.getParameterAnnotations()[[Ljava/lang/annotation/Annotation;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    executableGetParameterAnnotations,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.getParameterAnnotations()[[Ljava/lang/annotation/Annotation;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    executableGetParameterAnnotations,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.getDefaultValue()Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    methodGetDefaultValue,
+			NeedsContext: true,
+		}
+}
+
+// "java/lang/Class.getAnnotation(Ljava/lang/Class;)Ljava/lang/annotation/Annotation;" and
+// the same method of Method, Constructor, and Field: returns the element's annotation of the
+// given type, or null if it has none
+func annotatedGetAnnotation(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	element := params[1].(*object.Object)
+	annotationType, ok := params[2].(*object.Object)
+	if !ok || object.IsNull(annotationType) {
+		return getGErrBlk(excNames.NullPointerException, "annotation type is null")
+	}
+
+	annotations, errBlk := elementAnnotations(fs, element, true)
+	if errBlk != nil {
+		return errBlk
+	}
+	typeName := getClassName(annotationType)
+	for _, annotation := range annotations {
+		if annotationDataOf(annotation).typeName == typeName {
+			return annotation
+		}
+	}
+	return object.Null
+}
+
+// "java/lang/Class.getAnnotations()[Ljava/lang/annotation/Annotation;" and the same method
+// of Method, Constructor, and Field. A class's annotations include those it inherits from
+// its superclasses.
+func annotatedGetAnnotations(params []interface{}) interface{} {
+	annotations, errBlk := elementAnnotations(params[0].(*list.List), params[1].(*object.Object), true)
+	if errBlk != nil {
+		return errBlk
+	}
+	return makeRefArray(annotationClassName, annotations)
+}
+
+// "java/lang/Class.getDeclaredAnnotations()[Ljava/lang/annotation/Annotation;" and the same
+// method of Method, Constructor, and Field
+func annotatedGetDeclaredAnnotations(params []interface{}) interface{} {
+	annotations, errBlk := elementAnnotations(params[0].(*list.List), params[1].(*object.Object), false)
+	if errBlk != nil {
+		return errBlk
+	}
+	return makeRefArray(annotationClassName, annotations)
+}
+
+// "java/lang/Class.isAnnotationPresent(Ljava/lang/Class;)Z" and the same method of Method,
+// Constructor, and Field
+func annotatedIsAnnotationPresent(params []interface{}) interface{} {
+	annotation := annotatedGetAnnotation(params)
+	if errBlk, ok := annotation.(*GErrBlk); ok {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(!object.IsNull(annotation))
+}
+
+// "java/lang/Class.isAnnotation()Z" -- true if the class is an annotation interface
+func classIsAnnotation(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(k != nil && k.Data.Access.ClassIsAnnotation)
+}
+
+// "java/lang/reflect/Method.getParameterAnnotations()[[Ljava/lang/annotation/Annotation;" and
+// the same method of Constructor: returns an array of the annotations of each parameter. As in
+// HotSpot, if the class file gives annotations for fewer parameters than the descriptor has
+// (as for the implicit parameters of some constructors), the first parameters have none.
+func executableGetParameterAnnotations(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	member := params[1].(*object.Object)
+	meth, errBlk := reflectedMethod(member)
+	if errBlk != nil {
+		return errBlk
+	}
+
+	paramDescs, _, _ := util.ParseMethodDescriptor(memberDescriptor(member))
+	paramAnnotations := make([]*object.Object, len(paramDescs))
+	offset := len(paramDescs) - len(meth.ParameterAnnotations)
+	for i := range paramAnnotations {
+		var annotations []*object.Object
+		if i >= offset && i-offset < len(meth.ParameterAnnotations) {
+			if annotations, errBlk = annotationObjectsFor(fs, meth.ParameterAnnotations[i-offset]); errBlk != nil {
+				return errBlk
+			}
+		}
+		paramAnnotations[i] = makeRefArray(annotationClassName, annotations)
+	}
+
+	array := makeRefArray(annotationArrayClassName, paramAnnotations)
+	arrayType := types.Array + annotationArrayClassName
+	array.FieldTable["value"] = object.Field{Ftype: arrayType, Fvalue: array.FieldTable["value"].Fvalue}
+	array.KlassName = stringPool.GetStringIndex(&arrayType)
+	return array
+}
+
+// "java/lang/reflect/Method.getDefaultValue()Ljava/lang/Object;" returns the default value of
+// the element of an annotation interface that the method declares, or null if it has none.
+// Primitive values are boxed.
+func methodGetDefaultValue(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	member := params[1].(*object.Object)
+	meth, errBlk := reflectedMethod(member)
+	if errBlk != nil {
+		return errBlk
+	}
+	if meth.AnnotationDefault == nil {
+		return object.Null
+	}
+	_, retDesc, _ := util.ParseMethodDescriptor(memberDescriptor(member))
+	value, errBlk := javaElementValue(fs, *meth.AnnotationDefault, retDesc)
+	if errBlk != nil {
+		return errBlk
+	}
+	return boxValue(retDesc, value)
+}
+
+// elementAnnotations returns the annotation objects of a class, method, constructor, or
+// field. If inherited is true, the annotations of a class include the inheritable
+// annotations of its superclasses, except those whose types it declares itself.
+func elementAnnotations(fs *list.List, element *object.Object, inherited bool) ([]*object.Object, *GErrBlk) {
+	if *stringPool.GetStringPointer(element.KlassName) != classClassName {
+		annotations, errBlk := memberAnnotations(element)
+		if errBlk != nil {
+			return nil, errBlk
+		}
+		return annotationObjectsFor(fs, annotations)
+	}
+
+	k, errBlk := reflectedClass(element)
+	if k == nil || errBlk != nil {
+		return nil, errBlk
+	}
+	result, errBlk := annotationObjectsFor(fs, k.Data.Annotations)
+	if errBlk != nil || !inherited || k.Data.Access.ClassIsInterface {
+		return result, errBlk
+	}
+
+	present := make(map[string]bool)
+	for _, annotation := range result {
+		present[annotationDataOf(annotation).typeName] = true
+	}
+	for k.Data.Name != types.ObjectClassName {
+		superName := *stringPool.GetStringPointer(k.Data.SuperclassIndex)
+		if k, errBlk = loadClassForReflection(superName); errBlk != nil {
+			return nil, errBlk
+		}
+		annotations, errBlk := annotationObjectsFor(fs, k.Data.Annotations)
+		if errBlk != nil {
+			return nil, errBlk
+		}
+		for _, annotation := range annotations {
+			typeName := annotationDataOf(annotation).typeName
+			if !present[typeName] && isInheritedAnnotation(typeName) {
+				present[typeName] = true
+				result = append(result, annotation)
+			}
+		}
+	}
+	return result, nil
+}
+
+// memberAnnotations returns the annotations of a Method, Constructor, Field, or
+// RecordComponent object
+func memberAnnotations(member *object.Object) ([]classloader.Annotation, *GErrBlk) {
+	switch *stringPool.GetStringPointer(member.KlassName) {
+	case recordComponentClassName:
+		component, errBlk := recordComponentOf(member)
+		if component == nil || errBlk != nil {
+			return nil, errBlk
+		}
+		return component.Annotations, nil
+	case methodClassName, constructorClassName:
+		meth, errBlk := reflectedMethod(member)
+		if errBlk != nil {
+			return nil, errBlk
+		}
+		return meth.Annotations, nil
+	}
+
+	k, errBlk := loadClassForReflection(memberClassName(member))
+	if errBlk != nil {
+		return nil, errBlk
+	}
+	name := memberName(member)
+	for _, field := range k.Data.Fields {
+		if k.Data.CP.Utf8Refs[field.Name] == name {
+			return field.Annotations, nil
+		}
+	}
+	return nil, nil
+}
+
+// reflectedMethod returns the method in the method area that's represented by a Method or
+// Constructor object
+func reflectedMethod(member *object.Object) (*classloader.Method, *GErrBlk) {
+	className := memberClassName(member)
+	k, errBlk := loadClassForReflection(className)
+	if errBlk != nil {
+		return nil, errBlk
+	}
+	name := memberName(member)
+	if *stringPool.GetStringPointer(member.KlassName) == constructorClassName {
+		name = "<init>"
+	}
+	meth, ok := k.Data.MethodTable[name+memberDescriptor(member)]
+	if !ok {
+		errMsg := fmt.Sprintf("method %s.%s%s not found", className, name, memberDescriptor(member))
+		return nil, getGErrBlk(excNames.NoSuchMethodException, errMsg)
+	}
+	return meth, nil
+}
+
+// isInheritedAnnotation reports whether the named annotation interface is itself annotated
+// with @Inherited
+func isInheritedAnnotation(typeName string) bool {
+	k, errBlk := loadClassForReflection(typeName)
+	if errBlk != nil {
+		return false
+	}
+	for _, annotation := range k.Data.Annotations {
+		if annotation.Type == inheritedAnnotationType {
+			return true
+		}
+	}
+	return false
+}
+
+// annotationObjectsFor returns the annotation objects for a list of annotations, leaving out
+// those whose interfaces can't be found
+func annotationObjectsFor(fs *list.List, annotations []classloader.Annotation) ([]*object.Object, *GErrBlk) {
+	var objects []*object.Object
+	for i := range annotations {
+		if !classloader.CanLoadClass(annotationTypeName(&annotations[i])) {
+			continue
+		}
+		obj, errBlk := annotationObject(fs, &annotations[i])
+		if errBlk != nil {
+			return nil, errBlk
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// annotationTypeName returns the name of the interface of an annotation
+func annotationTypeName(annotation *classloader.Annotation) string {
+	return strings.TrimSuffix(strings.TrimPrefix(annotation.Type, types.Ref), ";")
+}
+
+// annotationDataOf returns the annotation represented by an annotation object
+func annotationDataOf(obj *object.Object) *annotationData {
+	data, _ := annotationsOfObjects.Load(obj)
+	return data.(*annotationData)
+}
+
+// annotationObject returns the annotation object that represents an annotation, creating it
+// if need be. The values of its elements, explicit or defaulted, are stored in its fields.
+func annotationObject(fs *list.List, annotation *classloader.Annotation) (*object.Object, *GErrBlk) {
+	if obj, ok := annotationObjects.Load(annotation); ok {
+		return obj.(*object.Object), nil
+	}
+
+	typeName := annotationTypeName(annotation)
+	k, errBlk := loadClassForReflection(typeName)
+	if errBlk != nil {
+		return nil, errBlk
+	}
+	elements := annotationElements(k)
+
+	data := &annotationData{typeName: typeName, values: make(map[string]classloader.ElementValue)}
+	for _, element := range annotation.Elements {
+		if _, ok := elements[element.Name]; ok && data.values[element.Name].Tag == 0 {
+			data.names = append(data.names, element.Name)
+			data.values[element.Name] = element.Value
+		}
+	}
+	var defaulted []string
+	for name, meth := range elements {
+		if _, ok := data.values[name]; !ok && meth.AnnotationDefault != nil {
+			defaulted = append(defaulted, name)
+			data.values[name] = *meth.AnnotationDefault
+		}
+	}
+	sort.Strings(defaulted)
+	data.names = append(data.names, defaulted...)
+
+	proxyName := annotationProxyClass(k, elements)
+	obj := object.MakeEmptyObjectWithClassName(&proxyName)
+	for _, name := range data.names {
+		_, retDesc, _ := util.ParseMethodDescriptor(k.Data.CP.Utf8Refs[elements[name].Desc])
+		value, errBlk := javaElementValue(fs, data.values[name], retDesc)
+		if errBlk != nil {
+			return nil, errBlk
+		}
+		ftype := retDesc
+		if strings.HasPrefix(retDesc, types.Ref) {
+			ftype = types.Ref
+		}
+		obj.FieldTable[name] = object.Field{Ftype: ftype, Fvalue: value}
+	}
+
+	annotationsOfObjects.Store(obj, data)
+	actual, _ := annotationObjects.LoadOrStore(annotation, obj)
+	return actual.(*object.Object), nil
+}
+
+// annotationElements returns the methods of an annotation interface that declare its
+// elements, by name
+func annotationElements(k *classloader.Klass) map[string]*classloader.Method {
+	elements := make(map[string]*classloader.Method)
+	for _, meth := range k.Data.MethodTable {
+		name := k.Data.CP.Utf8Refs[meth.Name]
+		if meth.AccessFlags&modifierStatic == 0 && !strings.HasPrefix(name, "<") {
+			elements[name] = meth
+		}
+	}
+	return elements
+}
+
+// annotationProxyClass returns the name of the proxy class for an annotation interface,
+// spinning the class if need be. The class implements the annotation interface and
+// java.lang.annotation.Annotation; its methods are G functions.
+func annotationProxyClass(k *classloader.Klass, elements map[string]*classloader.Method) string {
+	annotationProxies.Lock()
+	defer annotationProxies.Unlock()
+	if proxyName, ok := annotationProxies.classes[k.Data.Name]; ok && classloader.MethAreaFetch(proxyName) != nil {
+		return proxyName
+	}
+
+	proxyName := "jdk/proxy1/$Proxy" + strconv.FormatInt(annotationProxies.count.Add(1), 10)
+	var methods []spunMethod
+	for name, meth := range elements {
+		elementName := name
+		methods = append(methods, spunMethod{name, k.Data.CP.Utf8Refs[meth.Desc], GMeth{
+			ParamSlots: 0,
+			GFunction: func(params []interface{}) interface{} {
+				return annotationElementValue(params[0].(*object.Object), elementName)
+			},
+		}})
+	}
+	methods = append(methods,
+		spunMethod{"annotationType", "()Ljava/lang/Class;", GMeth{ParamSlots: 0, GFunction: annotationAnnotationType}},
+		spunMethod{"equals", "(Ljava/lang/Object;)Z", GMeth{ParamSlots: 1, GFunction: annotationEquals}},
+		spunMethod{"hashCode", "()I", GMeth{ParamSlots: 0, GFunction: annotationHashCode}},
+		spunMethod{"toString", "()Ljava/lang/String;", GMeth{ParamSlots: 0, GFunction: annotationToString}})
+
+	typeName := k.Data.Name
+	spinClass(proxyName, k.Loader, []string{typeName, annotationClassName}, methods)
+	annotationProxies.classes[typeName] = proxyName
+	return proxyName
+}
+
+// the method of an annotation object that returns the value of an element. As in HotSpot,
+// an array value is copied, so that the annotation can't be changed.
+func annotationElementValue(obj *object.Object, name string) interface{} {
+	field, ok := obj.FieldTable[name]
+	if !ok {
+		errMsg := fmt.Sprintf("%s missing element %s",
+			util.ConvertInternalClassNameToUserFormat(annotationDataOf(obj).typeName), name)
+		return getGErrBlk(excNames.IncompleteAnnotationException, errMsg)
+	}
+	array, ok := field.Fvalue.(*object.Object)
+	if !ok || object.IsNull(array) || !strings.HasPrefix(field.Ftype, types.Array) {
+		return field.Fvalue
+	}
+
+	copied := object.MakeEmptyObject()
+	copied.KlassName = array.KlassName
+	value := array.FieldTable["value"]
+	switch elements := value.Fvalue.(type) {
+	case []int64:
+		value.Fvalue = append([]int64(nil), elements...)
+	case []float64:
+		value.Fvalue = append([]float64(nil), elements...)
+	case []byte:
+		value.Fvalue = append([]byte(nil), elements...)
+	case []*object.Object:
+		value.Fvalue = append([]*object.Object(nil), elements...)
+	}
+	copied.FieldTable["value"] = value
+	return copied
+}
+
+// "annotationType()Ljava/lang/Class;" of an annotation object
+func annotationAnnotationType(params []interface{}) interface{} {
+	return makeClassObject(annotationDataOf(params[0].(*object.Object)).typeName)
+}
+
+// "equals(Ljava/lang/Object;)Z" of an annotation object: true if the other object is an
+// annotation of the same type whose elements have the same values
+func annotationEquals(params []interface{}) interface{} {
+	other, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(other) {
+		return types.JavaBoolFalse
+	}
+	otherData, ok := annotationsOfObjects.Load(other)
+	if !ok {
+		return types.JavaBoolFalse
+	}
+	data := annotationDataOf(params[0].(*object.Object))
+	return types.ConvertGoBoolToJavaBool(data.typeName == otherData.(*annotationData).typeName &&
+		reflect.DeepEqual(data.values, otherData.(*annotationData).values))
+}
+
+// "hashCode()I" of an annotation object: as specified by java.lang.annotation.Annotation,
+// the sum of the hash codes of the elements, each of which is (127 * the hash code of the
+// element's name) XOR the hash code of its value. The hash codes of classes and enum
+// constants are those of their names.
+func annotationHashCode(params []interface{}) interface{} {
+	data := annotationDataOf(params[0].(*object.Object))
+	var hash int32
+	for _, name := range data.names {
+		hash += (127 * javaStringHash(name)) ^ elementValueHash(data.values[name])
+	}
+	return int64(hash)
+}
+
+// "toString()Ljava/lang/String;" of an annotation object, in the format used by HotSpot:
+// @com.example.Marker(count=3, names={"a", "b"})
+func annotationToString(params []interface{}) interface{} {
+	data := annotationDataOf(params[0].(*object.Object))
+	return object.StringObjectFromGoString(annotationString(data))
+}
+
+func annotationString(data *annotationData) string {
+	var elements []string
+	for _, name := range data.names {
+		elements = append(elements, name+"="+elementValueString(data.values[name]))
+	}
+	return "@" + util.ConvertInternalClassNameToUserFormat(data.typeName) + "(" + strings.Join(elements, ", ") + ")"
+}
+
+// elementValueString returns an element value as it's shown by toString()
+func elementValueString(value classloader.ElementValue) string {
+	switch value.Tag {
+	case 'B':
+		return fmt.Sprintf("(byte)0x%02x", uint8(value.Value.(int64)))
+	case 'C':
+		return strconv.QuoteRune(rune(value.Value.(int64)))
+	case 'Z':
+		return strconv.FormatBool(value.Value.(int64) != 0)
+	case 'J':
+		return strconv.FormatInt(value.Value.(int64), 10) + "L"
+	case 'F':
+		return strconv.FormatFloat(value.Value.(float64), 'g', -1, 32) + "f"
+	case 'D':
+		return strconv.FormatFloat(value.Value.(float64), 'g', -1, 64)
+	case 's':
+		return strconv.Quote(value.Value.(string))
+	case 'e':
+		return value.Value.(classloader.EnumValue).Name
+	case 'c':
+		return util.ConvertInternalClassNameToUserFormat(getClassName(classObjectForDescriptor(value.Value.(string)))) + ".class"
+	case '@':
+		annotation := value.Value.(*classloader.Annotation)
+		data := annotationData{typeName: annotationTypeName(annotation), values: make(map[string]classloader.ElementValue)}
+		for _, element := range annotation.Elements {
+			data.names = append(data.names, element.Name)
+			data.values[element.Name] = element.Value
+		}
+		return annotationString(&data)
+	case '[':
+		var elements []string
+		for _, element := range value.Value.([]classloader.ElementValue) {
+			elements = append(elements, elementValueString(element))
+		}
+		return "{" + strings.Join(elements, ", ") + "}"
+	}
+	return fmt.Sprint(value.Value) // 'I' and 'S'
+}
+
+// elementValueHash returns the hash code of an element value, as computed by the hashCode()
+// method of its wrapper class, String, or (for arrays) java.util.Arrays
+func elementValueHash(value classloader.ElementValue) int32 {
+	switch v := value.Value.(type) {
+	case int64:
+		switch value.Tag {
+		case 'Z':
+			if v != 0 {
+				return 1231
+			}
+			return 1237
+		case 'J':
+			return int32(v ^ int64(uint64(v)>>32))
+		}
+		return int32(v)
+	case float64:
+		if value.Tag == 'F' {
+			return int32(math.Float32bits(float32(v)))
+		}
+		bits := math.Float64bits(v)
+		return int32(bits ^ bits>>32)
+	case string:
+		return javaStringHash(v)
+	case classloader.EnumValue:
+		return javaStringHash(v.Name)
+	case *classloader.Annotation:
+		var hash int32
+		for _, element := range v.Elements {
+			hash += (127 * javaStringHash(element.Name)) ^ elementValueHash(element.Value)
+		}
+		return hash
+	case []classloader.ElementValue:
+		hash := int32(1)
+		for _, element := range v {
+			hash = 31*hash + elementValueHash(element)
+		}
+		return hash
+	}
+	return 0
+}
+
+// javaStringHash returns the hash code of a string, as computed by String.hashCode()
+func javaStringHash(str string) int32 {
+	hash := int32(0)
+	for _, ch := range str {
+		hash = 31*hash + int32(ch)
+	}
+	return hash
+}
+
+// javaElementValue converts an element value to the Java value returned by the element's
+// method, whose return type is given by desc
+func javaElementValue(fs *list.List, value classloader.ElementValue, desc string) (any, *GErrBlk) {
+	switch value.Tag {
+	case 'B', 'C', 'I', 'S', 'Z', 'J', 'D', 'F':
+		if desc != string(value.Tag) {
+			break
+		}
+		return value.Value, nil
+	case 's':
+		if desc != "Ljava/lang/String;" {
+			break
+		}
+		return object.StringObjectFromGoString(value.Value.(string)), nil
+	case 'c':
+		if desc != "Ljava/lang/Class;" {
+			break
+		}
+		return classObjectForDescriptor(value.Value.(string)), nil
+	case 'e':
+		enum := value.Value.(classloader.EnumValue)
+		if desc != enum.Type {
+			break
+		}
+		enumClass := strings.TrimSuffix(strings.TrimPrefix(enum.Type, types.Ref), ";")
+		if errBlk := initializeClass(fs, enumClass); errBlk != nil {
+			return nil, errBlk
+		}
+		constant, ok := statics.QueryStatic(enumClass + "." + enum.Name)
+		if !ok {
+			errMsg := util.ConvertInternalClassNameToUserFormat(enumClass) + "." + enum.Name
+			return nil, getGErrBlk(excNames.EnumConstantNotPresentException, errMsg)
+		}
+		return constant.Value, nil
+	case '@':
+		if desc != value.Value.(*classloader.Annotation).Type {
+			break
+		}
+		return annotationObject(fs, value.Value.(*classloader.Annotation))
+	case '[':
+		if !strings.HasPrefix(desc, types.Array) {
+			break
+		}
+		return javaElementArray(fs, value.Value.([]classloader.ElementValue), desc[1:])
+	}
+	errMsg := fmt.Sprintf("element value of type %c doesn't match %s", value.Tag, desc)
+	return nil, getGErrBlk(excNames.AnnotationTypeMismatchException, errMsg)
+}
+
+// javaElementArray converts the values of an array element to a Java array whose elements
+// are of the type given by desc
+func javaElementArray(fs *list.List, values []classloader.ElementValue, desc string) (any, *GErrBlk) {
+	var array *object.Object
+	switch desc {
+	case types.Bool, types.Byte:
+		array = object.Make1DimArray(object.BYTE, int64(len(values)))
+	case types.Char, types.Short, types.Int, types.Long:
+		array = object.Make1DimArray(object.INT, int64(len(values)))
+	case types.Float, types.Double:
+		array = object.Make1DimArray(object.FLOAT, int64(len(values)))
+	default:
+		elementClass := strings.TrimSuffix(strings.TrimPrefix(desc, types.Ref), ";")
+		array = object.Make1DimRefArray(&elementClass, int64(len(values)))
+	}
+
+	for i, element := range values {
+		value, errBlk := javaElementValue(fs, element, desc)
+		if errBlk != nil {
+			return nil, errBlk
+		}
+		switch elements := array.FieldTable["value"].Fvalue.(type) {
+		case []byte:
+			elements[i] = byte(value.(int64))
+		case []int64:
+			elements[i] = value.(int64)
+		case []float64:
+			elements[i] = value.(float64)
+		case []*object.Object:
+			elements[i] = value.(*object.Object)
+		}
+	}
+	return array, nil
+}
@@ -0,0 +1,238 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/object"
+	"jacobin/statics"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"testing"
+)
+
+// annotationTestSetup adds to the classes set up by reflectTestSetup() an enum, test/Color,
+// and an inheritable annotation interface, test/Marker, with these elements:
+//
+//	int value() default 1;
+//	String[] names() default {};
+//	Color color() default Color.RED;
+//
+// test/Widget is annotated with @Marker(value=5, names={"a", "b"}) and its method getCount()
+// with @Marker(color=Color.BLUE). test/SubWidget extends test/Widget.
+func annotationTestSetup() *list.List {
+	fs := reflectTestSetup()
+
+	reflectTestClass("test/Color", "java/lang/Enum", classloader.AccessFlags{ClassIsPublic: true, ClassIsEnum: true}, nil, nil)
+	colorClassName := "test/Color"
+	for _, name := range []string{"RED", "BLUE"} {
+		constant := object.MakeEmptyObjectWithClassName(&colorClassName)
+		constant.FieldTable["name"] = object.Field{Ftype: types.Ref, Fvalue: object.StringObjectFromGoString(name)}
+		_ = statics.AddStatic("test/Color."+name, statics.Static{Type: "Ltest/Color;", Value: constant})
+	}
+
+	marker := reflectTestClass("test/Marker", types.ObjectClassName,
+		classloader.AccessFlags{ClassIsPublic: true, ClassIsInterface: true, ClassIsAbstract: true, ClassIsAnnotation: true},
+		nil,
+		[][3]any{
+			{"value", "()I", modifierPublic | modifierAbstract},
+			{"names", "()[Ljava/lang/String;", modifierPublic | modifierAbstract},
+			{"color", "()Ltest/Color;", modifierPublic | modifierAbstract},
+		})
+	marker.Data.Annotations = []classloader.Annotation{{Type: inheritedAnnotationType}}
+	marker.Data.MethodTable["value()I"].AnnotationDefault = &classloader.ElementValue{Tag: 'I', Value: int64(1)}
+	marker.Data.MethodTable["names()[Ljava/lang/String;"].AnnotationDefault =
+		&classloader.ElementValue{Tag: '[', Value: []classloader.ElementValue{}}
+	marker.Data.MethodTable["color()Ltest/Color;"].AnnotationDefault =
+		&classloader.ElementValue{Tag: 'e', Value: classloader.EnumValue{Type: "Ltest/Color;", Name: "RED"}}
+
+	widget := classloader.MethAreaFetch("test/Widget")
+	widget.Data.Annotations = []classloader.Annotation{
+		{Type: "Ltest/Marker;", Elements: []classloader.AnnotationElement{
+			{Name: "value", Value: classloader.ElementValue{Tag: 'I', Value: int64(5)}},
+			{Name: "names", Value: classloader.ElementValue{Tag: '[', Value: []classloader.ElementValue{
+				{Tag: 's', Value: "a"}, {Tag: 's', Value: "b"}}}},
+		}},
+	}
+	widget.Data.MethodTable["getCount()I"].Annotations = []classloader.Annotation{
+		{Type: "Ltest/Marker;", Elements: []classloader.AnnotationElement{
+			{Name: "color", Value: classloader.ElementValue{Tag: 'e', Value: classloader.EnumValue{Type: "Ltest/Color;", Name: "BLUE"}}},
+		}},
+	}
+	widget.Data.MethodTable["twice(D)D"].ParameterAnnotations = [][]classloader.Annotation{{{Type: "Ltest/Marker;"}}}
+
+	reflectTestClass("test/SubWidget", "test/Widget", classloader.AccessFlags{ClassIsPublic: true}, nil, nil)
+	return fs
+}
+
+// invokeSpunMethod calls a method of an object whose class was spun by spinClass(), such
+// as an annotation, through the MTable, as INVOKEINTERFACE does
+func invokeSpunMethod(t *testing.T, obj *object.Object, methodName string, args ...any) any {
+	t.Helper()
+	className := *stringPool.GetStringPointer(obj.KlassName)
+	mte, ok := classloader.MTableFetch(className + "." + methodName)
+	if !ok || mte.MType != 'G' {
+		t.Fatalf("%s.%s not found in the MTable", className, methodName)
+	}
+	return mte.Meth.(GMeth).GFunction(append([]interface{}{obj}, args...))
+}
+
+func TestClassGetAnnotation(t *testing.T) {
+	fs := annotationTestSetup()
+	widget, marker := makeClassObject("test/Widget"), makeClassObject("test/Marker")
+
+	annotation, ok := annotatedGetAnnotation([]interface{}{fs, widget, marker}).(*object.Object)
+	if !ok || object.IsNull(annotation) {
+		t.Fatalf("Widget.getAnnotation(Marker): expected an annotation, got %v", annotation)
+	}
+	if annotatedGetAnnotation([]interface{}{fs, widget, marker}) != annotation {
+		t.Errorf("Widget.getAnnotation(Marker): expected the same annotation object each time")
+	}
+	if annotatedIsAnnotationPresent([]interface{}{fs, widget, marker}) != types.JavaBoolTrue {
+		t.Errorf("Widget.isAnnotationPresent(Marker): expected true")
+	}
+	if annotatedIsAnnotationPresent([]interface{}{fs, widget, makeClassObject("test/Widget")}) != types.JavaBoolFalse {
+		t.Errorf("Widget.isAnnotationPresent(Widget): expected false")
+	}
+	if _, ok = annotatedGetAnnotation([]interface{}{fs, widget, object.Null}).(*GErrBlk); !ok {
+		t.Errorf("Widget.getAnnotation(null): expected NullPointerException")
+	}
+	if classIsAnnotation([]interface{}{marker}) != types.JavaBoolTrue || classIsAnnotation([]interface{}{widget}) != types.JavaBoolFalse {
+		t.Errorf("isAnnotation(): expected true for Marker and false for Widget")
+	}
+
+	// the annotation object implements the annotation interface
+	proxy := classloader.MethAreaFetch(*stringPool.GetStringPointer(annotation.KlassName))
+	if proxy == nil || !isAssignableTo(proxy.Data.Name, "test/Marker") || !isAssignableTo(proxy.Data.Name, annotationClassName) {
+		t.Errorf("expected the annotation's class to implement test/Marker and Annotation")
+	}
+	if invokeSpunMethod(t, annotation, "annotationType()Ljava/lang/Class;") != marker {
+		t.Errorf("annotationType(): expected test/Marker")
+	}
+
+	// explicit and defaulted elements
+	if value := invokeSpunMethod(t, annotation, "value()I"); value != int64(5) {
+		t.Errorf("value(): expected 5, got %v", value)
+	}
+	names := invokeSpunMethod(t, annotation, "names()[Ljava/lang/String;").(*object.Object)
+	elements := names.FieldTable["value"].Fvalue.([]*object.Object)
+	if len(elements) != 2 || object.GoStringFromStringObject(elements[1]) != "b" {
+		t.Errorf("names(): expected {\"a\", \"b\"}, got %v", elements)
+	}
+	elements[0] = object.Null // the array returned is a copy
+	names = invokeSpunMethod(t, annotation, "names()[Ljava/lang/String;").(*object.Object)
+	if object.IsNull(names.FieldTable["value"].Fvalue.([]*object.Object)[0]) {
+		t.Errorf("names(): expected a copy of the array")
+	}
+	color := invokeSpunMethod(t, annotation, "color()Ltest/Color;").(*object.Object)
+	if red, _ := statics.QueryStatic("test/Color.RED"); color != red.Value {
+		t.Errorf("color(): expected Color.RED")
+	}
+
+	expected := `@test.Marker(value=5, names={"a", "b"}, color=RED)`
+	if str := object.GoStringFromStringObject(invokeSpunMethod(t, annotation, "toString()Ljava/lang/String;").(*object.Object)); str != expected {
+		t.Errorf("toString(): expected %s, got %s", expected, str)
+	}
+}
+
+func TestAnnotationEqualsAndHashCode(t *testing.T) {
+	fs := annotationTestSetup()
+	k := classloader.MethAreaFetch("test/SubWidget")
+	k.Data.Annotations = []classloader.Annotation{
+		{Type: "Ltest/Marker;", Elements: []classloader.AnnotationElement{
+			{Name: "names", Value: classloader.ElementValue{Tag: '[', Value: []classloader.ElementValue{
+				{Tag: 's', Value: "a"}, {Tag: 's', Value: "b"}}}},
+			{Name: "value", Value: classloader.ElementValue{Tag: 'I', Value: int64(5)}},
+		}},
+	}
+
+	marker := makeClassObject("test/Marker")
+	widget := annotatedGetAnnotation([]interface{}{fs, makeClassObject("test/Widget"), marker}).(*object.Object)
+	subWidget := annotatedGetAnnotation([]interface{}{fs, makeClassObject("test/SubWidget"), marker}).(*object.Object)
+	getCount := annotatedGetAnnotation([]interface{}{fs, makeMethodObject("test/Widget", "getCount", "()I", modifierPublic), marker}).(*object.Object)
+
+	if widget == subWidget || invokeSpunMethod(t, widget, "equals(Ljava/lang/Object;)Z", subWidget) != types.JavaBoolTrue {
+		t.Errorf("equals(): expected distinct annotations with the same values to be equal")
+	}
+	if invokeSpunMethod(t, widget, "equals(Ljava/lang/Object;)Z", getCount) != types.JavaBoolFalse ||
+		invokeSpunMethod(t, widget, "equals(Ljava/lang/Object;)Z", marker) != types.JavaBoolFalse {
+		t.Errorf("equals(): expected annotations with different values, and non-annotations, to be unequal")
+	}
+
+	hash := invokeSpunMethod(t, widget, "hashCode()I")
+	if hash != invokeSpunMethod(t, subWidget, "hashCode()I") {
+		t.Errorf("hashCode(): expected equal annotations to have the same hash code")
+	}
+	// value=5: (127 * "value".hashCode()) ^ 5; names={"a", "b"}: (127 * "names".hashCode()) ^ (31 * (31 + 97) + 98)
+	// color=RED: (127 * "color".hashCode()) ^ "RED".hashCode()
+	value, names, color := int32(111972721), int32(104585032), int32(94842723)
+	expected := ((127 * value) ^ 5) + ((127 * names) ^ (31*(31+97) + 98)) + ((127 * color) ^ 81009)
+	if hash != int64(expected) {
+		t.Errorf("hashCode(): expected %d, got %v", expected, hash)
+	}
+}
+
+func TestInheritedAndMemberAnnotations(t *testing.T) {
+	fs := annotationTestSetup()
+	subWidget, marker := makeClassObject("test/SubWidget"), makeClassObject("test/Marker")
+
+	// @Marker is @Inherited, so SubWidget has Widget's annotation, but doesn't declare it
+	annotations := annotatedGetAnnotations([]interface{}{fs, subWidget}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(annotations) != 1 || annotations[0] != annotatedGetAnnotation([]interface{}{fs, makeClassObject("test/Widget"), marker}) {
+		t.Errorf("SubWidget.getAnnotations(): expected Widget's @Marker, got %v", annotations)
+	}
+	declared := annotatedGetDeclaredAnnotations([]interface{}{fs, subWidget}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(declared) != 0 {
+		t.Errorf("SubWidget.getDeclaredAnnotations(): expected none, got %v", declared)
+	}
+
+	getCount := makeMethodObject("test/Widget", "getCount", "()I", modifierPublic)
+	annotation := annotatedGetAnnotation([]interface{}{fs, getCount, marker}).(*object.Object)
+	if color := invokeSpunMethod(t, annotation, "color()Ltest/Color;"); color != statics.GetStaticValue("test/Color", "BLUE") {
+		t.Errorf("getCount's @Marker: expected color=BLUE")
+	}
+	if annotatedIsAnnotationPresent([]interface{}{fs, makeFieldObject("test/Widget", "count", "I", modifierPrivate), marker}) != types.JavaBoolFalse {
+		t.Errorf("Widget.count.isAnnotationPresent(Marker): expected false")
+	}
+
+	// the parameters of twice(D)D
+	twice := makeMethodObject("test/Widget", "twice", "(D)D", modifierPublic|modifierStatic)
+	paramAnnotations := executableGetParameterAnnotations([]interface{}{fs, twice}).(*object.Object)
+	if className := *stringPool.GetStringPointer(paramAnnotations.KlassName); className != "[[Ljava/lang/annotation/Annotation;" {
+		t.Errorf("getParameterAnnotations(): expected an Annotation[][], got %s", className)
+	}
+	params := paramAnnotations.FieldTable["value"].Fvalue.([]*object.Object)
+	if len(params) != 1 || len(params[0].FieldTable["value"].Fvalue.([]*object.Object)) != 1 {
+		t.Errorf("getParameterAnnotations(): expected one annotation on one parameter, got %v", params)
+	}
+
+	// the default value of an element
+	value := methodGetDefaultValue([]interface{}{fs, makeMethodObject("test/Marker", "value", "()I", modifierPublic)}).(*object.Object)
+	if value.FieldTable["value"].Fvalue != int64(1) {
+		t.Errorf("Marker.value().getDefaultValue(): expected Integer 1, got %v", value.FieldTable["value"].Fvalue)
+	}
+	if methodGetDefaultValue([]interface{}{fs, getCount}) != object.Null {
+		t.Errorf("Widget.getCount().getDefaultValue(): expected null")
+	}
+}
+
+func TestAnnotationElementTypeMismatch(t *testing.T) {
+	fs := annotationTestSetup()
+	classloader.MethAreaFetch("test/SubWidget").Data.Annotations = []classloader.Annotation{
+		{Type: "Ltest/Marker;", Elements: []classloader.AnnotationElement{
+			{Name: "value", Value: classloader.ElementValue{Tag: 's', Value: "five"}},
+		}},
+	}
+	// an element value of the wrong type, as when an annotation interface is changed after use
+	errBlk, ok := annotatedGetDeclaredAnnotations([]interface{}{fs, makeClassObject("test/SubWidget")}).(*GErrBlk)
+	if ok && errBlk.ExceptionType == excNames.AnnotationTypeMismatchException {
+		return
+	}
+	t.Errorf("expected AnnotationTypeMismatchException, got %v", errBlk)
+}
@@ -7,20 +7,127 @@
 package gfunction
 
 import (
+	"container/list"
 	"errors"
 	"fmt"
 	"jacobin/classloader"
 	"jacobin/excNames"
+	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/object"
 	"jacobin/shutdown"
 	"jacobin/statics"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"sort"
+	"strings"
 )
 
 // Implementation of some of the functions in Java/lang/Class.
+//
+// Each class, array type, and primitive type is represented by a single Class object,
+// its mirror, which is kept in the method area (see classloader.ClassMirror()). The
+// mirror holds the name of the class in its name field, as a Go string: classes and
+// arrays are named in internal format (java/lang/String and [Ljava/lang/String;) and
+// primitive types by their Java names (int). The reflection methods build their results
+// from the class's entry in the method area: Method, Constructor, and Field objects are
+// described in javaLangReflect.go.
+
+var classClassName = "java/lang/Class"
+
+// the interfaces implemented by all arrays
+var arrayInterfaceNames = []string{"java/lang/Cloneable", "java/io/Serializable"}
+
+// the Java names of the primitive types, by field descriptor
+var primitiveClassNames = map[string]string{
+	"Z": "boolean",
+	"B": "byte",
+	"C": "char",
+	"D": "double",
+	"F": "float",
+	"I": "int",
+	"J": "long",
+	"S": "short",
+	"V": "void",
+}
 
 func Load_Lang_Class() {
 
+	MethodSignatures["java/lang/Class.forName(Ljava/lang/String;)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    classForName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Class.forName(Ljava/lang/String;ZLjava/lang/ClassLoader;)Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots:   3,
+			GFunction:    classForNameInitialize,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Class.getDeclaredClasses()[Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetDeclaredClasses,
+		}
+
+	MethodSignatures["java/lang/Class.getDeclaringClass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetDeclaringClass,
+		}
+
+	MethodSignatures["java/lang/Class.getDeclaredConstructors()[Ljava/lang/reflect/Constructor;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetDeclaredConstructors,
+		}
+
+	MethodSignatures["java/lang/Class.getDeclaredFields()[Ljava/lang/reflect/Field;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetDeclaredFields,
+		}
+
+	MethodSignatures["java/lang/Class.getDeclaredMethods()[Ljava/lang/reflect/Method;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetDeclaredMethods,
+		}
+
+	MethodSignatures["java/lang/Class.getMethods()[Ljava/lang/reflect/Method;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetMethods,
+		}
+
+	MethodSignatures["java/lang/Class.getEnclosingClass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetEnclosingClass,
+		}
+
+	MethodSignatures["java/lang/Class.getEnclosingConstructor()Ljava/lang/reflect/Constructor;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetEnclosingConstructor,
+		}
+
+	MethodSignatures["java/lang/Class.getEnclosingMethod()Ljava/lang/reflect/Method;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetEnclosingMethod,
+		}
+
+	MethodSignatures["java/lang/Class.getModifiers()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetModifiers,
+		}
+
 	MethodSignatures["java/lang/Class.getPrimitiveClass(Ljava/lang/String;)Ljava/lang/Class;"] =
 		GMeth{
 			ParamSlots: 1,
@@ -51,48 +158,124 @@ func Load_Lang_Class() {
 			GFunction:  getName,
 		}
 
+	MethodSignatures["java/lang/Class.cast(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classCast,
+		}
+
+	MethodSignatures["java/lang/Class.getComponentType()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetComponentType,
+		}
+
+	MethodSignatures["java/lang/Class.getInterfaces()[Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetInterfaces,
+		}
+
+	MethodSignatures["java/lang/Class.getNestHost()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetNestHost,
+		}
+
+	MethodSignatures["java/lang/Class.getNestMembers()[Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetNestMembers,
+		}
+
+	MethodSignatures["java/lang/Class.getPackageName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetPackageName,
+		}
+
+	MethodSignatures["java/lang/Class.getSimpleName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetSimpleName,
+		}
+
+	MethodSignatures["java/lang/Class.getSuperclass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetSuperclass,
+		}
+
+	MethodSignatures["java/lang/Class.isAnonymousClass()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsAnonymousClass,
+		}
+
+	MethodSignatures["java/lang/Class.isArray()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsArray,
+		}
+
+	MethodSignatures["java/lang/Class.isAssignableFrom(Ljava/lang/Class;)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classIsAssignableFrom,
+		}
+
+	MethodSignatures["java/lang/Class.isInstance(Ljava/lang/Object;)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classIsInstance,
+		}
+
+	MethodSignatures["java/lang/Class.isInterface()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsInterface,
+		}
+
+	MethodSignatures["java/lang/Class.isLocalClass()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsLocalClass,
+		}
+
+	MethodSignatures["java/lang/Class.isMemberClass()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsMemberClass,
+		}
+
+	MethodSignatures["java/lang/Class.isNestmateOf(Ljava/lang/Class;)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  classIsNestmateOf,
+		}
+
+	MethodSignatures["java/lang/Class.isPrimitive()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsPrimitive,
+		}
 }
 
-// getPrimitiveClass() takes a one-word descriptor of a primitive and
-// returns  apointer to the native primitive class that corresponds to it.
+// getPrimitiveClass() takes the name of a primitive type (or void) and
+// returns the Class object that represents it, such as Integer.TYPE.
 // This duplicates the behavior of OpenJDK JVMs.
 // "java/lang/Class.getPrimitiveClass(Ljava/lang/String;)Ljava/lang/Class;"
 func getPrimitiveClass(params []interface{}) interface{} {
 	primitive := params[0].(*object.Object)
 	str := object.GoStringFromStringObject(primitive)
 
-	var k *classloader.Klass
-	var err error
-	switch str {
-	case "boolean":
-		k, err = simpleClassLoadByName("java/lang/Boolean")
-	case "byte":
-		k, err = simpleClassLoadByName("java/lang/Byte")
-	case "char":
-		k, err = simpleClassLoadByName("java/lang/Character")
-	case "double":
-		k, err = simpleClassLoadByName("java/lang/Double")
-	case "float":
-		k, err = simpleClassLoadByName("java/lang/Float")
-	case "int":
-		k, err = simpleClassLoadByName("java/lang/Integer")
-	case "long":
-		k, err = simpleClassLoadByName("java/lang/Long")
-	case "short":
-		k, err = simpleClassLoadByName("java/lang/Short")
-	case "void":
-		k, err = simpleClassLoadByName("java/lang/Void")
-	default:
-		k = nil
-		err = errors.New("getPrimitiveClass: unrecognized primitive")
-	}
-
-	if err == nil {
-		return k
-	} else {
-		errMsg := fmt.Sprintf("Does not handle: %s", str)
-		return getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	for _, name := range primitiveClassNames {
+		if str == name {
+			return makeClassObject(name)
+		}
 	}
+	errMsg := fmt.Sprintf("Does not handle: %s", str)
+	return getGErrBlk(excNames.IllegalArgumentException, errMsg)
 }
 
 // simpleClassLoadByName() just checks the MethodArea cache for the loaded
@@ -127,13 +310,829 @@ func getAssertionsEnabledStatus([]interface{}) interface{} {
 	// note that statics have been preloaded before this function
 	// can be called, and CLI processing has also occurred. So, we
 	// know we have the latest assertion-enabled status.
-	x := statics.Statics["main.$assertionsDisabled"].Value.(int64)
+	assertStatus, _ := statics.QueryStatic("main.$assertionsDisabled")
+	x := assertStatus.Value.(int64)
 	return 1 - x // return the 0 if disabled, 1 if not.
 }
 
-// "java/lang/Class.getName()Ljava/lang/String;"
+// "java/lang/Class.getName()Ljava/lang/String;" returns the name of the class in the
+// format used by Java: java.lang.String, [Ljava.lang.String;, or int
 func getName(params []interface{}) interface{} {
-	primitive := params[0].(*object.Object)
-	str := object.GoStringFromStringObject(primitive)
-	return str
+	name := getClassName(params[0].(*object.Object))
+	return object.StringObjectFromGoString(strings.ReplaceAll(name, "/", "."))
+}
+
+// "java/lang/Class.forName(Ljava/lang/String;)Ljava/lang/Class;" loads and initializes the named class
+func classForName(params []interface{}) interface{} {
+	return forName(params[0].(*list.List), params[1], true)
+}
+
+// "java/lang/Class.forName(Ljava/lang/String;ZLjava/lang/ClassLoader;)Ljava/lang/Class;" loads the
+// named class and, if requested, initializes it. Jacobin has a single class loader, which is used
+// whatever loader is passed.
+func classForNameInitialize(params []interface{}) interface{} {
+	return forName(params[0].(*list.List), params[1], params[2].(int64) != types.JavaBoolFalse)
+}
+
+// forName returns the Class object for a class or array type, given its name in the format
+// used by Java. As in HotSpot, primitive types can't be looked up this way. If initialize is
+// true, the class's static initializer is run, if it hasn't been already.
+func forName(fs *list.List, nameObj any, initialize bool) interface{} {
+	name, ok := nameObj.(*object.Object)
+	if !ok || object.IsNull(name) {
+		return getGErrBlk(excNames.NullPointerException, "Class.forName(): class name is null")
+	}
+	javaName := object.GoStringFromStringObject(name)
+	className := strings.ReplaceAll(javaName, ".", "/")
+
+	elementName := className
+	if strings.HasPrefix(className, "[") {
+		elementDesc := strings.TrimLeft(className, "[")
+		switch {
+		case len(elementDesc) == 1 && elementDesc != "V" && primitiveClassNames[elementDesc] != "":
+			return makeClassObject(className)
+		case len(elementDesc) > 2 && elementDesc[0] == 'L' && strings.HasSuffix(elementDesc, ";"):
+			elementName = elementDesc[1 : len(elementDesc)-1]
+		default:
+			return getGErrBlk(excNames.ClassNotFoundException, javaName)
+		}
+	}
+
+	if elementName == "" || strings.ContainsAny(elementName, "[;") || !classloader.CanLoadClass(elementName) {
+		return getGErrBlk(excNames.ClassNotFoundException, javaName)
+	}
+	if _, errBlk := loadClassForReflection(elementName); errBlk != nil {
+		return errBlk
+	}
+	if initialize && elementName == className {
+		if errBlk := initializeClass(fs, className); errBlk != nil {
+			return errBlk
+		}
+	}
+	return makeClassObject(className)
+}
+
+// "java/lang/Class.getDeclaredConstructors()[Ljava/lang/reflect/Constructor;" returns the
+// constructors declared by the class. Interfaces, arrays, and primitive types have none.
+func classGetDeclaredConstructors(params []interface{}) interface{} {
+	var ctors []*object.Object
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k != nil {
+		for _, meth := range sortedMethods(k) {
+			if k.Data.CP.Utf8Refs[meth.Name] == "<init>" {
+				ctors = append(ctors, makeConstructorObject(k.Data.Name, k.Data.CP.Utf8Refs[meth.Desc], meth.AccessFlags))
+			}
+		}
+	}
+	return makeRefArray(constructorClassName, ctors)
+}
+
+// "java/lang/Class.getDeclaredFields()[Ljava/lang/reflect/Field;" returns the fields declared
+// by the class, in the order in which they're declared
+func classGetDeclaredFields(params []interface{}) interface{} {
+	var fields []*object.Object
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k != nil {
+		for _, field := range k.Data.Fields {
+			fields = append(fields, makeFieldObject(k.Data.Name,
+				k.Data.CP.Utf8Refs[field.Name], k.Data.CP.Utf8Refs[field.Desc], field.AccessFlags))
+		}
+	}
+	return makeRefArray(fieldClassName, fields)
+}
+
+// "java/lang/Class.getDeclaredMethods()[Ljava/lang/reflect/Method;" returns the methods declared
+// by the class, other than its constructors and static initializer
+func classGetDeclaredMethods(params []interface{}) interface{} {
+	var methods []*object.Object
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k != nil {
+		for _, meth := range sortedMethods(k) {
+			name := k.Data.CP.Utf8Refs[meth.Name]
+			if name != "<init>" && name != "<clinit>" {
+				methods = append(methods, makeMethodObject(k.Data.Name, name, k.Data.CP.Utf8Refs[meth.Desc], meth.AccessFlags))
+			}
+		}
+	}
+	return makeRefArray(methodClassName, methods)
+}
+
+// "java/lang/Class.getMethods()[Ljava/lang/reflect/Method;" returns the public methods of the
+// class, including those it inherits from its superclasses and superinterfaces. A method that's
+// overridden is returned only once, as declared by the class closest to this one. As in HotSpot,
+// the static methods of superinterfaces are not included, nor are the methods of Object when
+// the class is an interface.
+func classGetMethods(params []interface{}) interface{} {
+	var methods []*object.Object
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+
+	seen := make(map[string]bool)
+	addMethods := func(k *classloader.Klass, inherited bool) {
+		for _, meth := range sortedMethods(k) {
+			name := k.Data.CP.Utf8Refs[meth.Name]
+			desc := k.Data.CP.Utf8Refs[meth.Desc]
+			if meth.AccessFlags&modifierPublic == 0 || name == "<init>" || name == "<clinit>" || seen[name+desc] ||
+				(inherited && k.Data.Access.ClassIsInterface && meth.AccessFlags&modifierStatic != 0) {
+				continue
+			}
+			seen[name+desc] = true
+			methods = append(methods, makeMethodObject(k.Data.Name, name, desc, meth.AccessFlags))
+		}
+	}
+
+	var interfaces []string
+	for c := k; c != nil; {
+		addMethods(c, c != k)
+		for _, index := range c.Data.Interfaces {
+			interfaces = append(interfaces, *stringPool.GetStringPointer(uint32(index)))
+		}
+		if c.Data.Access.ClassIsInterface || c.Data.Name == types.ObjectClassName {
+			break
+		}
+		c, errBlk = loadClassForReflection(*stringPool.GetStringPointer(c.Data.SuperclassIndex))
+		if errBlk != nil {
+			return errBlk
+		}
+	}
+
+	visited := make(map[string]bool)
+	for len(interfaces) > 0 {
+		name := interfaces[0]
+		interfaces = interfaces[1:]
+		if visited[name] {
+			continue
+		}
+		visited[name] = true
+		intf, errBlk := loadClassForReflection(name)
+		if errBlk != nil {
+			return errBlk
+		}
+		addMethods(intf, true)
+		for _, index := range intf.Data.Interfaces {
+			interfaces = append(interfaces, *stringPool.GetStringPointer(uint32(index)))
+		}
+	}
+	return makeRefArray(methodClassName, methods)
+}
+
+// "java/lang/Class.getModifiers()I" returns the modifiers of the class, as encoded by
+// java.lang.reflect.Modifier. As in HotSpot, primitive types and arrays are final and
+// abstract, and an array is public if its element type is. The modifiers of a nested
+// class are those declared in the source code, which are kept in its InnerClasses
+// attribute, since a nested class can be private, protected, or static.
+func classGetModifiers(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	if isPrimitiveClassName(name) {
+		return int64(modifierPublic | modifierFinal | modifierAbstract)
+	}
+	if strings.HasPrefix(name, "[") {
+		modifiers := int64(modifierFinal | modifierAbstract)
+		elementDesc := strings.TrimLeft(name, "[")
+		if len(elementDesc) == 1 {
+			return modifiers | modifierPublic
+		}
+		elementClass, errBlk := loadClassForReflection(strings.TrimSuffix(elementDesc[1:], ";"))
+		if errBlk != nil {
+			return errBlk
+		}
+		if elementClass.Data.Access.ClassIsPublic {
+			modifiers |= modifierPublic
+		}
+		return modifiers
+	}
+
+	k, errBlk := loadClassForReflection(name)
+	if errBlk != nil {
+		return errBlk
+	}
+	if entry := innerClassEntry(k); entry != nil {
+		return int64(entry.Access &^ accSuper)
+	}
+	var modifiers int64
+	access := k.Data.Access
+	for _, flag := range []struct {
+		set      bool
+		modifier int64
+	}{
+		{access.ClassIsPublic, modifierPublic},
+		{access.ClassIsFinal, modifierFinal},
+		{access.ClassIsInterface, modifierInterface},
+		{access.ClassIsAbstract, modifierAbstract},
+		{access.ClassIsSynthetic, modifierSynthetic},
+		{access.ClassIsAnnotation, modifierAnnotation},
+		{access.ClassIsEnum, modifierEnum},
+	} {
+		if flag.set {
+			modifiers |= flag.modifier
+		}
+	}
+	return modifiers
+}
+
+// "java/lang/Class.getSuperclass()Ljava/lang/Class;" returns the superclass of the class. As
+// in HotSpot, it's null for Object, interfaces, and primitive types, and Object for arrays.
+func classGetSuperclass(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	if isPrimitiveClassName(name) {
+		return object.Null
+	}
+	if strings.HasPrefix(name, "[") {
+		return makeClassObject(types.ObjectClassName)
+	}
+	k, errBlk := loadClassForReflection(name)
+	if errBlk != nil {
+		return errBlk
+	}
+	if name == types.ObjectClassName || k.Data.Access.ClassIsInterface {
+		return object.Null
+	}
+	return makeClassObject(*stringPool.GetStringPointer(k.Data.SuperclassIndex))
+}
+
+// "java/lang/Class.getInterfaces()[Ljava/lang/Class;" returns the interfaces directly
+// implemented by the class (or extended by the interface), in the order in which they're
+// declared. Arrays implement Cloneable and Serializable; primitive types implement none.
+func classGetInterfaces(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	var interfaces []*object.Object
+	switch {
+	case isPrimitiveClassName(name):
+	case strings.HasPrefix(name, "["):
+		for _, iface := range arrayInterfaceNames {
+			interfaces = append(interfaces, makeClassObject(iface))
+		}
+	default:
+		k, errBlk := loadClassForReflection(name)
+		if errBlk != nil {
+			return errBlk
+		}
+		for _, index := range k.Data.Interfaces {
+			interfaces = append(interfaces, makeClassObject(*stringPool.GetStringPointer(uint32(index))))
+		}
+	}
+	return makeRefArray(classClassName, interfaces)
+}
+
+// "java/lang/Class.isInterface()Z"
+func classIsInterface(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	if strings.HasPrefix(name, "[") || isPrimitiveClassName(name) {
+		return types.JavaBoolFalse
+	}
+	k, errBlk := loadClassForReflection(name)
+	if errBlk != nil {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(k.Data.Access.ClassIsInterface)
+}
+
+// "java/lang/Class.isArray()Z"
+func classIsArray(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	return types.ConvertGoBoolToJavaBool(strings.HasPrefix(name, "["))
+}
+
+// "java/lang/Class.isPrimitive()Z" -- true for the primitive types and void
+func classIsPrimitive(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	return types.ConvertGoBoolToJavaBool(isPrimitiveClassName(name))
+}
+
+// "java/lang/Class.getComponentType()Ljava/lang/Class;" returns the type of the elements
+// of an array, or null if the class is not an array
+func classGetComponentType(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	if !strings.HasPrefix(name, "[") {
+		return object.Null
+	}
+	return classObjectForDescriptor(name[1:])
+}
+
+// "java/lang/Class.isInstance(Ljava/lang/Object;)Z" -- the dynamic equivalent of instanceof:
+// true if the object is not null and could be cast to the class
+func classIsInstance(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	obj, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(obj) {
+		return types.JavaBoolFalse
+	}
+	return types.ConvertGoBoolToJavaBool(isAssignableTo(objectClassName(obj), name))
+}
+
+// "java/lang/Class.isAssignableFrom(Ljava/lang/Class;)Z" reports whether the given class is
+// this class, or a subclass or implementation of it. A primitive type is assignable only
+// from itself.
+func classIsAssignableFrom(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	from, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(from) {
+		return getGErrBlk(excNames.NullPointerException, "Class.isAssignableFrom() called with null")
+	}
+	return types.ConvertGoBoolToJavaBool(isAssignableTo(getClassName(from), name))
+}
+
+// "java/lang/Class.cast(Ljava/lang/Object;)Ljava/lang/Object;" returns the object, after
+// checking that it can be cast to the class. Null can be cast to any class.
+func classCast(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	obj, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(obj) {
+		return object.Null
+	}
+	objClassName := objectClassName(obj)
+	if !isAssignableTo(objClassName, name) {
+		errMsg := fmt.Sprintf("Cannot cast %s to %s",
+			util.ConvertInternalClassNameToUserFormat(objClassName), util.ConvertInternalClassNameToUserFormat(name))
+		return getGErrBlk(excNames.ClassCastException, errMsg)
+	}
+	return obj
+}
+
+// "java/lang/Class.getSimpleName()Ljava/lang/String;" returns the name of the class as it
+// appears in the source code: String, String[], or int
+func classGetSimpleName(params []interface{}) interface{} {
+	name := getClassName(params[0].(*object.Object))
+	return object.StringObjectFromGoString(simpleClassName(name))
+}
+
+// "java/lang/Class.getPackageName()Ljava/lang/String;" returns the name of the class's
+// package, or "" for the unnamed package. An array is in the package of its elements,
+// and the primitive types are in java.lang.
+func classGetPackageName(params []interface{}) interface{} {
+	name := strings.TrimLeft(getClassName(params[0].(*object.Object)), "[")
+	if isPrimitiveClassName(name) || len(name) == 1 { // a primitive type or an array of one
+		return object.StringObjectFromGoString("java.lang")
+	}
+	name = strings.TrimSuffix(strings.TrimPrefix(name, types.Ref), ";")
+	packageName := ""
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		packageName = util.ConvertInternalClassNameToUserFormat(name[:i])
+	}
+	return object.StringObjectFromGoString(packageName)
+}
+
+// "java/lang/Class.getDeclaringClass()Ljava/lang/Class;" returns the class of which the
+// class is a member, or null if it's a top-level, local, or anonymous class
+func classGetDeclaringClass(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil || declaringClassName(k) == "" {
+		return object.Null
+	}
+	return makeClassObject(declaringClassName(k))
+}
+
+// "java/lang/Class.getEnclosingClass()Ljava/lang/Class;" returns the class in which the class
+// is declared: the declaring class of a member class, or the class whose code declares a
+// local or anonymous class. It's null for top-level classes.
+func classGetEnclosingClass(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil || enclosingClassName(k) == "" {
+		return object.Null
+	}
+	return makeClassObject(enclosingClassName(k))
+}
+
+// "java/lang/Class.getEnclosingMethod()Ljava/lang/reflect/Method;" returns the method in which
+// a local or anonymous class is declared, or null if it's not declared in a method
+func classGetEnclosingMethod(params []interface{}) interface{} {
+	return enclosingMember(params[0].(*object.Object), false)
+}
+
+// "java/lang/Class.getEnclosingConstructor()Ljava/lang/reflect/Constructor;" returns the
+// constructor in which a local or anonymous class is declared, or null if it's not declared
+// in a constructor
+func classGetEnclosingConstructor(params []interface{}) interface{} {
+	return enclosingMember(params[0].(*object.Object), true)
+}
+
+// enclosingMember returns the Method or Constructor object for the method or constructor
+// in which a local or anonymous class is declared, or null if it's not declared in one
+func enclosingMember(classObj *object.Object, constructor bool) interface{} {
+	k, errBlk := reflectedClass(classObj)
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil || k.Data.EnclosingMethod == nil {
+		return object.Null
+	}
+	enclosing := k.Data.EnclosingMethod
+	if enclosing.Name == "" || enclosing.Name == "<clinit>" || (enclosing.Name == "<init>") != constructor {
+		return object.Null
+	}
+
+	enclosingClass, errBlk := loadClassForReflection(enclosing.Class)
+	if errBlk != nil {
+		return errBlk
+	}
+	var modifiers int
+	if meth, ok := enclosingClass.Data.MethodTable[enclosing.Name+enclosing.Desc]; ok {
+		modifiers = meth.AccessFlags
+	}
+	if constructor {
+		return makeConstructorObject(enclosing.Class, enclosing.Desc, modifiers)
+	}
+	return makeMethodObject(enclosing.Class, enclosing.Name, enclosing.Desc, modifiers)
+}
+
+// "java/lang/Class.getDeclaredClasses()[Ljava/lang/Class;" returns the member classes and
+// interfaces declared by the class, including the private ones, but not the local and
+// anonymous classes
+func classGetDeclaredClasses(params []interface{}) interface{} {
+	var classes []*object.Object
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k != nil {
+		for _, entry := range k.Data.InnerClasses {
+			if entry.OuterName == k.Data.Name && entry.Name != k.Data.Name {
+				classes = append(classes, makeClassObject(entry.Name))
+			}
+		}
+	}
+	return makeRefArray(classClassName, classes)
+}
+
+// "java/lang/Class.isAnonymousClass()Z" -- true if the class is declared by an anonymous
+// class expression, and so has no name in the source code
+func classIsAnonymousClass(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(k != nil && isAnonymousClass(k))
+}
+
+// "java/lang/Class.isLocalClass()Z" -- true if the class is a named class declared in a
+// block of code, such as a method
+func classIsLocalClass(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(k != nil && k.Data.EnclosingMethod != nil && !isAnonymousClass(k))
+}
+
+// "java/lang/Class.isMemberClass()Z" -- true if the class is declared as a member of another class
+func classIsMemberClass(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(k != nil && declaringClassName(k) != "")
+}
+
+// "java/lang/Class.getNestHost()Ljava/lang/Class;" returns the host of the nest to which the
+// class belongs (see classloader.NestHost()). Arrays and primitive types are their own hosts.
+func classGetNestHost(params []interface{}) interface{} {
+	classObj := params[0].(*object.Object)
+	k, errBlk := reflectedClass(classObj)
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil {
+		return classObj
+	}
+	return makeClassObject(classloader.NestHost(k.Data.Name))
+}
+
+// "java/lang/Class.getNestMembers()[Ljava/lang/Class;" returns the classes in the nest to
+// which the class belongs: the host, followed by the members that it lists
+func classGetNestMembers(params []interface{}) interface{} {
+	classObj := params[0].(*object.Object)
+	k, errBlk := reflectedClass(classObj)
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil {
+		return makeRefArray(classClassName, []*object.Object{classObj})
+	}
+
+	hostName := classloader.NestHost(k.Data.Name)
+	members := []*object.Object{makeClassObject(hostName)}
+	if host := classloader.MethAreaFetch(hostName); host != nil {
+		for _, member := range host.Data.NestMembers {
+			if member != hostName {
+				members = append(members, makeClassObject(member))
+			}
+		}
+	}
+	return makeRefArray(classClassName, members)
+}
+
+// "java/lang/Class.isNestmateOf(Ljava/lang/Class;)Z" -- true if the given class is in the same
+// nest as this class, and so, as specified by JEP 181, the two can access each other's
+// private members
+func classIsNestmateOf(params []interface{}) interface{} {
+	classObj := params[0].(*object.Object)
+	other, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(other) {
+		return getGErrBlk(excNames.NullPointerException, "Class.isNestmateOf() called with null")
+	}
+	if classObj == other {
+		return types.JavaBoolTrue
+	}
+	for _, c := range []*object.Object{classObj, other} {
+		k, errBlk := reflectedClass(c)
+		if errBlk != nil {
+			return errBlk
+		}
+		if k == nil { // arrays and primitive types are the only members of their nests
+			return types.JavaBoolFalse
+		}
+	}
+	return types.ConvertGoBoolToJavaBool(classloader.AreNestmates(getClassName(classObj), getClassName(other)))
+}
+
+// innerClassEntry returns the entry that describes a nested class in its own InnerClasses
+// attribute, or nil if it's a top-level class
+func innerClassEntry(k *classloader.Klass) *classloader.InnerClass {
+	for i := range k.Data.InnerClasses {
+		if k.Data.InnerClasses[i].Name == k.Data.Name {
+			return &k.Data.InnerClasses[i]
+		}
+	}
+	return nil
+}
+
+// declaringClassName returns the name of the class of which a member class is a member,
+// or "" if the class is not a member class
+func declaringClassName(k *classloader.Klass) string {
+	if entry := innerClassEntry(k); entry != nil && k.Data.EnclosingMethod == nil {
+		return entry.OuterName
+	}
+	return ""
+}
+
+// enclosingClassName returns the name of the class in which a class is declared, or "" for
+// a top-level class
+func enclosingClassName(k *classloader.Klass) string {
+	if k.Data.EnclosingMethod != nil {
+		return k.Data.EnclosingMethod.Class
+	}
+	return declaringClassName(k)
+}
+
+// isAnonymousClass reports whether a class is anonymous: a local class without a simple name
+func isAnonymousClass(k *classloader.Klass) bool {
+	if k.Data.EnclosingMethod == nil {
+		return false
+	}
+	entry := innerClassEntry(k)
+	return entry == nil || entry.SimpleName == ""
+}
+
+// simpleClassName returns the simple name of the named class. For a nested class that's
+// been loaded, this is the name given in its InnerClasses attribute, which is empty for
+// an anonymous class. For other nested classes, it's the part of the name after the last
+// $, without the digits that javac puts at the start of the names of local classes;
+// anonymous classes, whose names are just digits, have an empty simple name.
+func simpleClassName(name string) string {
+	if strings.HasPrefix(name, "[") {
+		return simpleClassName(getClassName(classObjectForDescriptor(name[1:]))) + "[]"
+	}
+	if k := classloader.MethAreaFetch(name); k != nil && k.Data != nil && len(k.Data.InnerClasses) > 0 {
+		if entry := innerClassEntry(k); entry != nil {
+			return entry.SimpleName
+		}
+		return name[strings.LastIndex(name, "/")+1:] // a top-level class
+	}
+	name = name[strings.LastIndex(name, "/")+1:]
+	if i := strings.LastIndex(name, "$"); i >= 0 {
+		name = strings.TrimLeft(name[i+1:], "0123456789")
+	}
+	return name
+}
+
+// objectClassName returns the name of an object's class. The names of arrays of objects
+// are stored without the terminating semicolon, so it's added here.
+func objectClassName(obj *object.Object) string {
+	name := *stringPool.GetStringPointer(obj.KlassName)
+	if strings.HasPrefix(name, "[") && strings.HasPrefix(strings.TrimLeft(name, "["), types.Ref) &&
+		!strings.HasSuffix(name, ";") {
+		name += ";"
+	}
+	return name
+}
+
+// makeClassObject returns the Class object (the mirror) for the named class, array, or primitive type
+func makeClassObject(name string) *object.Object {
+	return classloader.ClassMirror(name)
+}
+
+// classObjectForDescriptor returns the Class object for the type given by a field descriptor
+// (or V, for void), such as the type of a field or a method's parameter
+func classObjectForDescriptor(desc string) *object.Object {
+	if name, ok := primitiveClassNames[desc]; ok {
+		return makeClassObject(name)
+	}
+	if strings.HasPrefix(desc, types.Ref) {
+		return makeClassObject(strings.TrimSuffix(desc[1:], ";"))
+	}
+	return makeClassObject(desc) // an array
+}
+
+// getClassName returns the name of the class represented by a Class object
+func getClassName(classObj *object.Object) string {
+	name, _ := classObj.FieldTable["name"].Fvalue.(string)
+	return name
+}
+
+// isPrimitiveClassName reports whether a class name is that of a primitive type (or void)
+func isPrimitiveClassName(name string) bool {
+	for _, primitive := range primitiveClassNames {
+		if name == primitive {
+			return true
+		}
+	}
+	return false
+}
+
+// reflectedClass returns the loaded class represented by a Class object, or nil if it
+// represents an array or a primitive type, which declare no members of their own
+func reflectedClass(classObj *object.Object) (*classloader.Klass, *GErrBlk) {
+	name := getClassName(classObj)
+	if strings.HasPrefix(name, "[") || isPrimitiveClassName(name) {
+		return nil, nil
+	}
+	return loadClassForReflection(name)
+}
+
+// loadClassForReflection returns the named class from the method area, loading it first if
+// need be. Unlike simpleClassLoadByName(), it throws ClassNotFoundException if there's no
+// such class, rather than shutting down.
+func loadClassForReflection(className string) (*classloader.Klass, *GErrBlk) {
+	k := classloader.MethAreaFetch(className)
+	if k == nil {
+		javaName := strings.ReplaceAll(className, "/", ".")
+		if !classloader.CanLoadClass(className) {
+			return nil, getGErrBlk(excNames.ClassNotFoundException, javaName)
+		}
+		if err := classloader.LoadClassFromNameOnly(className); err != nil {
+			return nil, getGErrBlk(excNames.ClassNotFoundException, javaName)
+		}
+	}
+	if err := classloader.WaitForClassStatus(className); err != nil {
+		return nil, getGErrBlk(excNames.ClassNotFoundException, err.Error())
+	}
+	k = classloader.MethAreaFetch(className)
+	if k == nil || k.Data == nil {
+		return nil, getGErrBlk(excNames.ClassNotFoundException, strings.ReplaceAll(className, "/", "."))
+	}
+	return k, nil
+}
+
+// initializeClass runs the static initializer of a class, if it hasn't been run yet, as
+// the interpreter does when a class is first used. Instantiating the class runs the
+// initializer and sets up the class's static fields.
+func initializeClass(fs *list.List, className string) *GErrBlk {
+	k := classloader.MethAreaFetch(className)
+	if k != nil && k.Data != nil && k.Data.ClInit != types.ClInitNotRun {
+		return nil
+	}
+	if _, err := globals.GetGlobalRef().FuncInstantiateClass(className, fs); err != nil {
+		errMsg := fmt.Sprintf("could not initialize class %s: %s",
+			util.ConvertInternalClassNameToUserFormat(className), err.Error())
+		return getGErrBlk(excNames.ExceptionInInitializerError, errMsg)
+	}
+	return nil
+}
+
+// isAssignableTo reports whether the named class is the target class, or a subclass or
+// implementation of it. The superclasses and superinterfaces are loaded, if need be.
+func isAssignableTo(className, target string) bool {
+	if className == target {
+		return true
+	}
+	if isPrimitiveClassName(className) || isPrimitiveClassName(target) {
+		return false
+	}
+	if target == types.ObjectClassName {
+		return true
+	}
+	if strings.HasPrefix(className, "[") {
+		return isArrayAssignableTo(className, target)
+	}
+	k, errBlk := loadClassForReflection(className)
+	if errBlk != nil {
+		return false
+	}
+	if className != types.ObjectClassName && !k.Data.Access.ClassIsInterface &&
+		isAssignableTo(*stringPool.GetStringPointer(k.Data.SuperclassIndex), target) {
+		return true
+	}
+	for _, index := range k.Data.Interfaces {
+		if isAssignableTo(*stringPool.GetStringPointer(uint32(index)), target) {
+			return true
+		}
+	}
+	return false
+}
+
+// isArrayAssignableTo reports whether the named array type can be assigned to the target
+// class. An array can be assigned to Object and to the interfaces it implements, and to
+// another array type if its elements can be: an array of primitives only to an array of
+// the same primitive type.
+func isArrayAssignableTo(className, target string) bool {
+	for _, iface := range arrayInterfaceNames {
+		if target == iface {
+			return true
+		}
+	}
+	if !strings.HasPrefix(target, "[") {
+		return false
+	}
+	element, targetElement := className[1:], target[1:]
+	if strings.HasPrefix(element, types.Ref) && strings.HasPrefix(targetElement, types.Ref) {
+		return isAssignableTo(strings.TrimSuffix(element[1:], ";"), strings.TrimSuffix(targetElement[1:], ";"))
+	}
+	if strings.HasPrefix(element, "[") && strings.HasPrefix(targetElement, "[") {
+		return isAssignableTo(element, targetElement)
+	}
+	return false
+}
+
+// sortedMethods returns the methods of a class sorted by name and descriptor. HotSpot
+// doesn't specify an order, but this makes the results of reflection repeatable.
+func sortedMethods(k *classloader.Klass) []*classloader.Method {
+	keys := make([]string, 0, len(k.Data.MethodTable))
+	for key := range k.Data.MethodTable {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	methods := make([]*classloader.Method, len(keys))
+	for i, key := range keys {
+		methods[i] = k.Data.MethodTable[key]
+	}
+	return methods
+}
+
+// makeRefArray returns a Java array of the given objects
+func makeRefArray(className string, elements []*object.Object) *object.Object {
+	array := object.Make1DimRefArray(&className, int64(len(elements)))
+	copy(array.FieldTable["value"].Fvalue.([]*object.Object), elements)
+	return array
+}
+
+// spunMethod is a method of a class spun by spinClass(), which is implemented by a G function
+type spunMethod struct {
+	name  string
+	desc  string
+	gmeth GMeth
+}
+
+// spinClass adds to the method area a final, synthetic class whose methods are G functions.
+// Such classes implement Java interfaces for objects that are created by G functions, such
+// as annotations and generic types, so that the interface methods can be invoked on them.
+func spinClass(className, loader string, interfaces []string, methods []spunMethod) {
+	var cp classloader.CPool
+	methodTable := make(map[string]*classloader.Method)
+	for _, meth := range methods {
+		cp.Utf8Refs = append(cp.Utf8Refs, meth.name, meth.desc)
+		methodTable[meth.name+meth.desc] = &classloader.Method{
+			AccessFlags: modifierPublic | modifierFinal,
+			Name:        uint16(len(cp.Utf8Refs) - 2),
+			Desc:        uint16(len(cp.Utf8Refs) - 1),
+		}
+		classloader.AddEntry(&classloader.MTable, className+"."+meth.name+meth.desc,
+			classloader.MTentry{Meth: meth.gmeth, MType: 'G'})
+	}
+
+	interfaceIndexes := make([]uint16, len(interfaces))
+	for i := range interfaces {
+		interfaceIndexes[i] = uint16(stringPool.GetStringIndex(&interfaces[i]))
+	}
+	klass := classloader.Klass{
+		Status: 'L',
+		Loader: loader,
+		Data: &classloader.ClData{
+			Name:            className,
+			NameIndex:       stringPool.GetStringIndex(&className),
+			SuperclassIndex: types.ObjectPoolStringIndex,
+			Interfaces:      interfaceIndexes,
+			MethodTable:     methodTable,
+			CP:              cp,
+			Access:          classloader.AccessFlags{ClassIsPublic: true, ClassIsFinal: true, ClassIsSynthetic: true},
+			ClInit:          types.NoClinit,
+		},
+	}
+	classloader.MethAreaInsert(className, &klass)
 }
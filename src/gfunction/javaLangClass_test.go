@@ -0,0 +1,283 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"testing"
+)
+
+// classTestSetup adds an interface, test/Shape, and a class that implements it, test/Box, to
+// the classes set up by reflectTestSetup()
+func classTestSetup() {
+	reflectTestSetup()
+	reflectTestClass("test/Shape", types.ObjectClassName,
+		classloader.AccessFlags{ClassIsPublic: true, ClassIsInterface: true, ClassIsAbstract: true}, nil, nil)
+	box := reflectTestClass("test/Box", types.ObjectClassName, classloader.AccessFlags{ClassIsPublic: true}, nil, nil)
+	shape := "test/Shape"
+	box.Data.Interfaces = []uint16{uint16(stringPool.GetStringIndex(&shape))}
+}
+
+func TestGetClassReturnsMirror(t *testing.T) {
+	classTestSetup()
+	className := "test/Box"
+	box := object.MakeEmptyObjectWithClassName(&className)
+	other := object.MakeEmptyObjectWithClassName(&className)
+
+	mirror := objectGetClass([]interface{}{box})
+	if mirror != classloader.ClassMirror("test/Box") || mirror != objectGetClass([]interface{}{other}) {
+		t.Errorf("getClass(): expected the mirror of test/Box, got %v", mirror)
+	}
+	if mirror == objectGetClass([]interface{}{object.StringObjectFromGoString("box")}) {
+		t.Errorf("getClass(): expected a String's class to differ from test/Box")
+	}
+
+	// arrays of objects are named without the semicolon, but have the same mirror as the array type
+	array := makeRefArray("test/Box", nil)
+	if objectGetClass([]interface{}{array}) != makeClassObject("[Ltest/Box;") {
+		t.Errorf("getClass(): expected the mirror of [Ltest/Box;")
+	}
+}
+
+func TestClassTypeQueries(t *testing.T) {
+	classTestSetup()
+	box, shape := makeClassObject("test/Box"), makeClassObject("test/Shape")
+	intClass, intArray := makeClassObject("int"), makeClassObject("[I")
+	boxArray := makeClassObject("[Ltest/Box;")
+
+	className := func(classObj any) string {
+		if object.IsNull(classObj) {
+			return "null"
+		}
+		return getClassName(classObj.(*object.Object))
+	}
+	for _, test := range []struct {
+		what     string
+		got      any
+		expected string
+	}{
+		{"Box.getSuperclass()", className(classGetSuperclass([]interface{}{box})), types.ObjectClassName},
+		{"Shape.getSuperclass()", className(classGetSuperclass([]interface{}{shape})), "null"},
+		{"Object.getSuperclass()", className(classGetSuperclass([]interface{}{makeClassObject(types.ObjectClassName)})), "null"},
+		{"int.getSuperclass()", className(classGetSuperclass([]interface{}{intClass})), "null"},
+		{"Box[].getSuperclass()", className(classGetSuperclass([]interface{}{boxArray})), types.ObjectClassName},
+		{"Box[].getComponentType()", className(classGetComponentType([]interface{}{boxArray})), "test/Box"},
+		{"int[].getComponentType()", className(classGetComponentType([]interface{}{intArray})), "int"},
+		{"Box.getComponentType()", className(classGetComponentType([]interface{}{box})), "null"},
+		{"Box.getSimpleName()", object.GoStringFromStringObject(classGetSimpleName([]interface{}{box}).(*object.Object)), "Box"},
+		{"int[].getSimpleName()", object.GoStringFromStringObject(classGetSimpleName([]interface{}{intArray}).(*object.Object)), "int[]"},
+		{"Box.getPackageName()", object.GoStringFromStringObject(classGetPackageName([]interface{}{box}).(*object.Object)), "test"},
+		{"Box[].getPackageName()", object.GoStringFromStringObject(classGetPackageName([]interface{}{boxArray}).(*object.Object)), "test"},
+		{"int.getPackageName()", object.GoStringFromStringObject(classGetPackageName([]interface{}{intClass}).(*object.Object)), "java.lang"},
+	} {
+		if test.got != test.expected {
+			t.Errorf("%s: expected %s, got %v", test.what, test.expected, test.got)
+		}
+	}
+
+	for _, test := range []struct {
+		what     string
+		got      any
+		expected int64
+	}{
+		{"Shape.isInterface()", classIsInterface([]interface{}{shape}), types.JavaBoolTrue},
+		{"Box.isInterface()", classIsInterface([]interface{}{box}), types.JavaBoolFalse},
+		{"int[].isArray()", classIsArray([]interface{}{intArray}), types.JavaBoolTrue},
+		{"Box.isArray()", classIsArray([]interface{}{box}), types.JavaBoolFalse},
+		{"int.isPrimitive()", classIsPrimitive([]interface{}{intClass}), types.JavaBoolTrue},
+		{"int[].isPrimitive()", classIsPrimitive([]interface{}{intArray}), types.JavaBoolFalse},
+		{"Shape.isAssignableFrom(Box)", classIsAssignableFrom([]interface{}{shape, box}), types.JavaBoolTrue},
+		{"Box.isAssignableFrom(Shape)", classIsAssignableFrom([]interface{}{box, shape}), types.JavaBoolFalse},
+		{"Object.isAssignableFrom(int[])", classIsAssignableFrom([]interface{}{makeClassObject(types.ObjectClassName), intArray}), types.JavaBoolTrue},
+		{"Object.isAssignableFrom(int)", classIsAssignableFrom([]interface{}{makeClassObject(types.ObjectClassName), intClass}), types.JavaBoolFalse},
+		{"Shape[].isAssignableFrom(Box[])", classIsAssignableFrom([]interface{}{makeClassObject("[Ltest/Shape;"), boxArray}), types.JavaBoolTrue},
+		{"Object[].isAssignableFrom(int[])", classIsAssignableFrom([]interface{}{makeClassObject("[Ljava/lang/Object;"), intArray}), types.JavaBoolFalse},
+		{"Cloneable.isAssignableFrom(int[])", classIsAssignableFrom([]interface{}{makeClassObject("java/lang/Cloneable"), intArray}), types.JavaBoolTrue},
+	} {
+		if test.got != test.expected {
+			t.Errorf("%s: expected %d, got %v", test.what, test.expected, test.got)
+		}
+	}
+
+	interfaces := classGetInterfaces([]interface{}{box}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(interfaces) != 1 || interfaces[0] != shape {
+		t.Errorf("Box.getInterfaces(): expected [test/Shape], got %v", interfaces)
+	}
+	interfaces = classGetInterfaces([]interface{}{intArray}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(interfaces) != 2 || getClassName(interfaces[0]) != "java/lang/Cloneable" {
+		t.Errorf("int[].getInterfaces(): expected Cloneable and Serializable, got %v", interfaces)
+	}
+}
+
+func TestClassSimpleNames(t *testing.T) {
+	for name, expected := range map[string]string{
+		"Widget":                  "Widget",
+		"java/util/Map$Entry":     "Entry",
+		"test/Outer$1":            "",
+		"test/Outer$1Local":       "Local",
+		"[[Ljava/util/Map$Entry;": "Entry[][]",
+		"[D":                      "double[]",
+		"boolean":                 "boolean",
+	} {
+		if got := simpleClassName(name); got != expected {
+			t.Errorf("simpleClassName(%s): expected %q, got %q", name, expected, got)
+		}
+	}
+}
+
+func TestClassIsInstanceAndCast(t *testing.T) {
+	classTestSetup()
+	className := "test/Box"
+	box := object.MakeEmptyObjectWithClassName(&className)
+	shape := makeClassObject("test/Shape")
+
+	if classIsInstance([]interface{}{shape, box}) != types.JavaBoolTrue {
+		t.Errorf("Shape.isInstance(box): expected true")
+	}
+	if classIsInstance([]interface{}{shape, object.Null}) != types.JavaBoolFalse {
+		t.Errorf("Shape.isInstance(null): expected false")
+	}
+	if classCast([]interface{}{shape, box}) != box {
+		t.Errorf("Shape.cast(box): expected the box to be returned")
+	}
+	if classCast([]interface{}{shape, object.Null}) != object.Null {
+		t.Errorf("Shape.cast(null): expected null")
+	}
+
+	errBlk, ok := classCast([]interface{}{makeClassObject("java/lang/String"), box}).(*GErrBlk)
+	if !ok || errBlk.ExceptionType != excNames.ClassCastException ||
+		errBlk.ErrMsg != "Cannot cast test.Box to java.lang.String" {
+		t.Errorf("String.cast(box): expected ClassCastException, got %v", errBlk)
+	}
+	if _, ok = classIsAssignableFrom([]interface{}{shape, object.Null}).(*GErrBlk); !ok {
+		t.Errorf("Shape.isAssignableFrom(null): expected NullPointerException")
+	}
+}
+
+// nestedClassTestSetup adds test/Outer and its nested classes: a private static member class,
+// test/Outer$Inner; an anonymous class declared in run(), test/Outer$1; and a local class
+// declared in the constructor, test/Outer$1Local. All are in test/Outer's nest.
+func nestedClassTestSetup() {
+	reflectTestSetup()
+	inner := classloader.InnerClass{Name: "test/Outer$Inner", OuterName: "test/Outer", SimpleName: "Inner",
+		Access: modifierPrivate | modifierStatic}
+	anonymous := classloader.InnerClass{Name: "test/Outer$1"}
+	local := classloader.InnerClass{Name: "test/Outer$1Local", SimpleName: "Local"}
+
+	outer := reflectTestClass("test/Outer", types.ObjectClassName, classloader.AccessFlags{ClassIsPublic: true}, nil,
+		[][3]any{{"<init>", "()V", modifierPublic}, {"run", "()V", modifierPublic}})
+	outer.Data.InnerClasses = []classloader.InnerClass{inner, anonymous, local}
+	outer.Data.NestMembers = []string{inner.Name, anonymous.Name, local.Name}
+
+	for _, nested := range []struct {
+		entry     classloader.InnerClass
+		enclosing *classloader.EnclosingMethod
+	}{
+		{inner, nil},
+		{anonymous, &classloader.EnclosingMethod{Class: "test/Outer", Name: "run", Desc: "()V"}},
+		{local, &classloader.EnclosingMethod{Class: "test/Outer", Name: "<init>", Desc: "()V"}},
+	} {
+		k := reflectTestClass(nested.entry.Name, types.ObjectClassName, classloader.AccessFlags{}, nil, nil)
+		k.Data.InnerClasses = []classloader.InnerClass{nested.entry}
+		k.Data.EnclosingMethod = nested.enclosing
+		k.Data.NestHost = "test/Outer"
+	}
+}
+
+func TestNestedClasses(t *testing.T) {
+	nestedClassTestSetup()
+	outer, inner := makeClassObject("test/Outer"), makeClassObject("test/Outer$Inner")
+	anonymous, local := makeClassObject("test/Outer$1"), makeClassObject("test/Outer$1Local")
+	widget := makeClassObject("test/Widget")
+
+	className := func(classObj any) string {
+		if object.IsNull(classObj) {
+			return "null"
+		}
+		return getClassName(classObj.(*object.Object))
+	}
+	simpleName := func(classObj *object.Object) string {
+		return object.GoStringFromStringObject(classGetSimpleName([]interface{}{classObj}).(*object.Object))
+	}
+	queries := func(classObj *object.Object) [3]any {
+		return [3]any{
+			classIsMemberClass([]interface{}{classObj}),
+			classIsLocalClass([]interface{}{classObj}),
+			classIsAnonymousClass([]interface{}{classObj}),
+		}
+	}
+	truth, falsity := types.JavaBoolTrue, types.JavaBoolFalse
+
+	for _, test := range []struct {
+		name          string
+		got, expected any
+	}{
+		{"Inner.getDeclaringClass()", className(classGetDeclaringClass([]interface{}{inner})), "test/Outer"},
+		{"Inner.getEnclosingClass()", className(classGetEnclosingClass([]interface{}{inner})), "test/Outer"},
+		{"Inner.getSimpleName()", simpleName(inner), "Inner"},
+		{"Inner.getModifiers()", classGetModifiers([]interface{}{inner}), int64(modifierPrivate | modifierStatic)},
+		{"Inner member/local/anonymous", queries(inner), [3]any{truth, falsity, falsity}},
+		{"1.getDeclaringClass()", className(classGetDeclaringClass([]interface{}{anonymous})), "null"},
+		{"1.getEnclosingClass()", className(classGetEnclosingClass([]interface{}{anonymous})), "test/Outer"},
+		{"1.getSimpleName()", simpleName(anonymous), ""},
+		{"1 member/local/anonymous", queries(anonymous), [3]any{falsity, falsity, truth}},
+		{"1Local.getSimpleName()", simpleName(local), "Local"},
+		{"1Local member/local/anonymous", queries(local), [3]any{falsity, truth, falsity}},
+		{"Outer.getEnclosingClass()", className(classGetEnclosingClass([]interface{}{outer})), "null"},
+		{"Outer member/local/anonymous", queries(outer), [3]any{falsity, falsity, falsity}},
+	} {
+		if test.got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, test.got)
+		}
+	}
+
+	// the enclosing method or constructor of local and anonymous classes
+	method := classGetEnclosingMethod([]interface{}{anonymous}).(*object.Object)
+	if memberName(method) != "run" || memberClassName(method) != "test/Outer" {
+		t.Errorf("1.getEnclosingMethod(): expected test/Outer.run(), got %s.%s", memberClassName(method), memberName(method))
+	}
+	if !object.IsNull(classGetEnclosingConstructor([]interface{}{anonymous})) ||
+		!object.IsNull(classGetEnclosingMethod([]interface{}{local})) ||
+		!object.IsNull(classGetEnclosingMethod([]interface{}{inner})) {
+		t.Errorf("getEnclosingMethod/Constructor(): expected null for classes not declared in one")
+	}
+	if ctor := classGetEnclosingConstructor([]interface{}{local}).(*object.Object); memberDescriptor(ctor) != "()V" {
+		t.Errorf("1Local.getEnclosingConstructor(): expected test/Outer()")
+	}
+
+	// only member classes are declared classes
+	declared := classGetDeclaredClasses([]interface{}{outer}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(declared) != 1 || declared[0] != inner {
+		t.Errorf("Outer.getDeclaredClasses(): expected only Outer$Inner, got %v", declared)
+	}
+
+	// the nest
+	if host := classGetNestHost([]interface{}{anonymous}); host != outer {
+		t.Errorf("1.getNestHost(): expected test/Outer, got %s", className(host))
+	}
+	if host := classGetNestHost([]interface{}{widget}); host != widget {
+		t.Errorf("Widget.getNestHost(): expected test/Widget, got %s", className(host))
+	}
+	members := classGetNestMembers([]interface{}{inner}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(members) != 4 || members[0] != outer || members[3] != local {
+		t.Errorf("Inner.getNestMembers(): expected Outer and its 3 nested classes, got %v", members)
+	}
+	if classIsNestmateOf([]interface{}{inner, anonymous}) != truth || classIsNestmateOf([]interface{}{outer, local}) != truth {
+		t.Errorf("isNestmateOf(): expected classes in test/Outer's nest to be nestmates")
+	}
+	if classIsNestmateOf([]interface{}{inner, widget}) != falsity ||
+		classIsNestmateOf([]interface{}{inner, makeClassObject("int")}) != falsity {
+		t.Errorf("isNestmateOf(): expected classes outside test/Outer's nest not to be nestmates")
+	}
+	if _, ok := classIsNestmateOf([]interface{}{inner, object.Null}).(*GErrBlk); !ok {
+		t.Errorf("isNestmateOf(null): expected NullPointerException")
+	}
+}
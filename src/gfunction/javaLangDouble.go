@@ -61,6 +61,12 @@ func Load_Lang_Double() {
 			GFunction:  doubleParseDouble,
 		}
 
+	MethodSignatures["java/lang/Double.valueOf(D)Ljava/lang/Double;"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  doubleValueOf,
+		}
+
 	MethodSignatures["java/lang/Double.toString()Ljava/lang/String;"] =
 		GMeth{
 			ParamSlots: 0,
@@ -138,6 +144,12 @@ func doubleDoubleValue(params []interface{}) interface{} {
 	return parmObj.FieldTable["value"].Fvalue.(float64)
 }
 
+// "java/lang/Double.valueOf(D)Ljava/lang/Double;"
+func doubleValueOf(params []interface{}) interface{} {
+	doubleValue := params[0].(float64)
+	return populator("java/lang/Double", types.Double, doubleValue)
+}
+
 // "java/lang/Double.equals(Ljava/lang/Object;)Z"
 func doubleEquals(params []interface{}) interface{} {
 	var dd1, dd2 float64
@@ -7,6 +7,7 @@
 package gfunction
 
 import (
+	"jacobin/types"
 	"math"
 	"unsafe"
 )
@@ -19,6 +20,12 @@ func Load_Lang_Float() {
 			GFunction:  justReturn,
 		}
 
+	MethodSignatures["java/lang/Float.valueOf(F)Ljava/lang/Float;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  floatValueOf,
+		}
+
 	// Native functions or caller to native functions
 
 	MethodSignatures["java/lang/Float.floatToIntBits(F)I"] =
@@ -64,3 +71,9 @@ func floatToIntBits(params []interface{}) interface{} {
 	}
 	return 0x7fc00000 // equivalent to Java's 0x7fc00000
 }
+
+// "java/lang/Float.valueOf(F)Ljava/lang/Float;"
+func floatValueOf(params []interface{}) interface{} {
+	floatValue := params[0].(float64)
+	return populator("java/lang/Float", types.Float, floatValue)
+}
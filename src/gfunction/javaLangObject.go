@@ -7,11 +7,13 @@
 package gfunction
 
 import (
+	"container/list"
 	"fmt"
-	"jacobin/classloader"
 	"jacobin/excNames"
 	"jacobin/object"
 	"jacobin/types"
+	"math"
+	"time"
 )
 
 // Implementation of some of the functions in Java/lang/Class.
@@ -30,66 +32,58 @@ func Load_Lang_Object() {
 			GFunction:  objectGetClass,
 		}
 
+	MethodSignatures["java/lang/Object.notify()V"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    objectNotify,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Object.notifyAll()V"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    objectNotifyAll,
+			NeedsContext: true,
+		}
+
 	MethodSignatures["java/lang/Object.toString()Ljava/lang/String;"] =
 		GMeth{
 			ParamSlots: 0,
 			GFunction:  objectToString,
 		}
 
-}
+	MethodSignatures["java/lang/Object.wait()V"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    objectWait,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Object.wait(J)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    objectWaitMillis,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Object.wait(JI)V"] =
+		GMeth{
+			ParamSlots:   3,
+			GFunction:    objectWaitMillisNanos,
+			NeedsContext: true,
+		}
 
-// === the internal representation of a java.lang.Class() instance ===
-// this is not a faithful reproduction of the OpenJDK version, but rather
-// the one we use in Jacobin
-type javaLangClass struct {
-	accessFlags    classloader.AccessFlags
-	name           string
-	superClassName string
-	interfaceNames []string
-	constantPool   classloader.CPool
-	fields         []classloader.Field
-	methods        map[string]*classloader.Method
-	loader         string
-	superClass     string
-	interfaces     []uint16 // indices into UTF8Refs
-	// instanceSlotCount uint
-	// staticSlotCount   uint
-	// staticVars        Slots
 }
 
-// "java/lang/Object.getClass()Ljava/lang/Class;"
+// "java/lang/Object.getClass()Ljava/lang/Class;" -- returns the class's mirror, so
+// x.getClass() == Y.class compares the identities of the classes
 func objectGetClass(params []interface{}) interface{} {
 	objPtr := params[0].(*object.Object)
 	if objPtr == nil || objPtr.KlassName == types.InvalidStringIndex {
 		errMsg := fmt.Sprintf("Invalid object in objectGetClass(): %T", params[0])
 		return getGErrBlk(excNames.IllegalArgumentException, errMsg)
 	}
-
-	jlc := javaLangClass{}
-	jlc.name = object.GoStringFromStringPoolIndex(objPtr.KlassName)
-
-	// get a pointer to the class contents from the method area
-	o := classloader.MethAreaFetch(jlc.name)
-	if o == nil {
-		errMsg := fmt.Sprintf("Class %s not loaded", jlc.name)
-		return getGErrBlk(excNames.ClassNotLoadedException, errMsg)
-	}
-
-	// syntactic sugar
-	obj := *o
-
-	// create the empty java.lang.Class structure
-	jlc.loader = obj.Loader
-
-	// fill in the jlc
-	objData := *obj.Data
-	jlc.constantPool = objData.CP
-	jlc.superClass = object.GoStringFromStringPoolIndex(objData.SuperclassIndex)
-	jlc.fields = objData.Fields
-	jlc.interfaces = objData.Interfaces
-	jlc.methods = objData.MethodTable
-	jlc.accessFlags = objData.Access
-	return &jlc
+	return makeClassObject(objectClassName(objPtr))
 }
 
 // "java/lang/Object.toString()Ljava/lang/String;"
@@ -107,3 +101,84 @@ func objectToString(params []interface{}) interface{} {
 	errMsg := fmt.Sprintf("Unsupported parameter type: %T", params[0])
 	return getGErrBlk(excNames.IllegalArgumentException, errMsg)
 }
+
+// "java/lang/Object.notify()V"
+func objectNotify(params []interface{}) interface{} {
+	return notifyWaiters(params, false)
+}
+
+// "java/lang/Object.notifyAll()V"
+func objectNotifyAll(params []interface{}) interface{} {
+	return notifyWaiters(params, true)
+}
+
+// wakes up one or all of the threads waiting on the monitor of the object in params[1].
+// params[0] is the frame stack of the current thread.
+func notifyWaiters(params []interface{}, all bool) interface{} {
+	fs := params[0].(*list.List)
+	obj := params[1].(*object.Object)
+	th := getCurrentExecThread(fs)
+	if th == nil || !object.MonitorNotify(obj, th.ID, all) {
+		return getGErrBlk(excNames.IllegalMonitorStateException, "current thread is not owner")
+	}
+	return nil
+}
+
+// "java/lang/Object.wait()V"
+func objectWait(params []interface{}) interface{} {
+	return waitOnMonitor(params[0].(*list.List), params[1].(*object.Object), 0)
+}
+
+// "java/lang/Object.wait(J)V"
+func objectWaitMillis(params []interface{}) interface{} {
+	millis := params[2].(int64)
+	if millis < 0 {
+		return getGErrBlk(excNames.IllegalArgumentException, "timeout value is negative")
+	}
+	return waitOnMonitor(params[0].(*list.List), params[1].(*object.Object), millis)
+}
+
+// "java/lang/Object.wait(JI)V" -- as in the JDK, any nanoseconds round the timeout up by a millisecond
+func objectWaitMillisNanos(params []interface{}) interface{} {
+	millis := params[2].(int64)
+	nanos := params[4].(int64)
+	if millis < 0 {
+		return getGErrBlk(excNames.IllegalArgumentException, "timeout value is negative")
+	}
+	if nanos < 0 || nanos > 999999 {
+		return getGErrBlk(excNames.IllegalArgumentException, "nanosecond timeout value out of range")
+	}
+	if nanos > 0 && millis < math.MaxInt64 {
+		millis++
+	}
+	return waitOnMonitor(params[0].(*list.List), params[1].(*object.Object), millis)
+}
+
+// the current thread waits on the monitor of obj for the given number of milliseconds
+// (0 = until notified). The thread must own the monitor. If the thread is interrupted
+// before or during the wait, its interrupt status is cleared and InterruptedException
+// is thrown (once the thread has re-acquired the monitor).
+func waitOnMonitor(fs *list.List, obj *object.Object, millis int64) interface{} {
+	th := getCurrentExecThread(fs)
+	if th == nil || object.MonitorOwner(obj) != th.ID {
+		return getGErrBlk(excNames.IllegalMonitorStateException, "current thread is not owner")
+	}
+
+	if th.ClearInterrupt() {
+		return getGErrBlk(excNames.InterruptedException, "wait interrupted")
+	}
+
+	if millis > math.MaxInt64/int64(time.Millisecond) {
+		millis = 0 // a wait of more than 292 years is a wait forever
+	}
+
+	reason := object.MonitorWait(obj, th.ID, time.Duration(millis)*time.Millisecond, th.InterruptCh)
+	switch reason {
+	case object.WaitNotOwner:
+		return getGErrBlk(excNames.IllegalMonitorStateException, "current thread is not owner")
+	case object.WaitInterrupted:
+		th.ClearInterrupt()
+		return getGErrBlk(excNames.InterruptedException, "wait interrupted")
+	}
+	return nil
+}
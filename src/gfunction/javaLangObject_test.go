@@ -0,0 +1,69 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"jacobin/excNames"
+	"jacobin/object"
+	"testing"
+)
+
+func TestObjectWaitNotifyWithoutMonitor(t *testing.T) {
+	fs, _ := threadTestSetup()
+	obj := object.MakeEmptyObject()
+
+	ret := objectWait([]interface{}{fs, obj})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalMonitorStateException {
+		t.Errorf("expected an IllegalMonitorStateException from wait(), got %v", ret)
+	}
+
+	ret = objectNotify([]interface{}{fs, obj})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalMonitorStateException {
+		t.Errorf("expected an IllegalMonitorStateException from notify(), got %v", ret)
+	}
+
+	ret = objectNotifyAll([]interface{}{fs, obj})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalMonitorStateException {
+		t.Errorf("expected an IllegalMonitorStateException from notifyAll(), got %v", ret)
+	}
+}
+
+func TestObjectWaitTimesOutAndKeepsMonitor(t *testing.T) {
+	fs, mainThread := threadTestSetup()
+	obj := object.MakeEmptyObject()
+	object.MonitorEnter(obj, mainThread.ID)
+	object.MonitorEnter(obj, mainThread.ID)
+
+	if ret := objectWaitMillis([]interface{}{fs, obj, int64(5), int64(5)}); ret != nil {
+		t.Errorf("expected wait(5) to time out normally, got %v", ret)
+	}
+	if object.MonitorOwner(obj) != mainThread.ID {
+		t.Error("expected the monitor to be re-acquired after wait()")
+	}
+
+	ret := objectWaitMillisNanos([]interface{}{fs, obj, int64(1), int64(1), int64(1_000_000)})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("expected an IllegalArgumentException for nanos out of range, got %v", ret)
+	}
+
+	// a thread that is interrupted before it waits throws at once
+	mainThread.Interrupt()
+	ret = objectWait([]interface{}{fs, obj})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.InterruptedException {
+		t.Errorf("expected an InterruptedException from wait(), got %v", ret)
+	}
+	if mainThread.IsInterrupted() {
+		t.Error("expected the interrupt status to be cleared")
+	}
+
+	// the monitor was entered twice, so it takes two exits to release it
+	object.MonitorExit(obj, mainThread.ID)
+	object.MonitorExit(obj, mainThread.ID)
+	if object.MonitorOwner(obj) != 0 {
+		t.Error("expected the monitor to be released")
+	}
+}
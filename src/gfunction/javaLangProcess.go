@@ -0,0 +1,368 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"errors"
+	"jacobin/excNames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// java.lang.Process is implemented in Go. A Process object holds its state, a process,
+// in the Jacobin-specific field named below. Its streams are FileInputStreams and
+// FileOutputStreams whose file handles are the ends of the pipes to the child process,
+// so they can be read and written by the java.io gfunctions, and wrapped in readers
+// and writers, just like any other file stream.
+const processField = "process"
+
+var processClassName = "java/lang/Process"
+
+type process struct {
+	cmd       *exec.Cmd
+	stdin     *os.File // the pipes to the child process, or nil if the stream is redirected
+	stdout    *os.File
+	stderr    *os.File
+	done      chan struct{} // closed when the process has exited
+	exitValue int64
+	mutex     sync.Mutex // guards the creation of the stream objects
+	streams   [3]*object.Object
+}
+
+func Load_Lang_Process() {
+
+	MethodSignatures["java/lang/Process.destroy()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processDestroy,
+		}
+
+	MethodSignatures["java/lang/Process.destroyForcibly()Ljava/lang/Process;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processDestroyForcibly,
+		}
+
+	MethodSignatures["java/lang/Process.exitValue()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processExitValue,
+		}
+
+	MethodSignatures["java/lang/Process.getErrorStream()Ljava/io/InputStream;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processGetErrorStream,
+		}
+
+	MethodSignatures["java/lang/Process.getInputStream()Ljava/io/InputStream;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processGetInputStream,
+		}
+
+	MethodSignatures["java/lang/Process.getOutputStream()Ljava/io/OutputStream;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processGetOutputStream,
+		}
+
+	MethodSignatures["java/lang/Process.isAlive()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processIsAlive,
+		}
+
+	MethodSignatures["java/lang/Process.pid()J"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processPid,
+		}
+
+	MethodSignatures["java/lang/Process.waitFor()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processWaitFor,
+		}
+
+	MethodSignatures["java/lang/Process.waitFor(JLjava/util/concurrent/TimeUnit;)Z"] =
+		GMeth{
+			ParamSlots:   3, // the long timeout takes two slots
+			GFunction:    processWaitForTimeout,
+			NeedsContext: true,
+		}
+}
+
+// startProcess starts the command with its stdin, stdout, and stderr set up
+// per the redirects, and starts a goroutine that waits for it to exit
+func startProcess(cmd *exec.Cmd, redirects [3]*processRedirect, redirectErrorStream bool) (*process, error) {
+	proc := &process{cmd: cmd, done: make(chan struct{})}
+	var childEnds []*os.File // the files the child uses, which the parent closes once the child starts
+	closeAll := func(files []*os.File) {
+		for _, file := range files {
+			_ = file.Close()
+		}
+	}
+
+	for stream, redirect := range redirects {
+		if stream == 2 && redirectErrorStream {
+			cmd.Stderr = cmd.Stdout
+			break
+		}
+
+		var file, parentEnd *os.File
+		var err error
+		switch redirect.kind {
+		case redirectInherit:
+			file = []*os.File{os.Stdin, os.Stdout, os.Stderr}[stream]
+		case redirectRead:
+			file, err = os.Open(redirect.file)
+		case redirectWrite:
+			file, err = os.OpenFile(redirect.file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0666)
+		case redirectAppend:
+			file, err = os.OpenFile(redirect.file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+		default: // redirectPipe
+			var r, w *os.File
+			r, w, err = os.Pipe()
+			if stream == 0 {
+				file, parentEnd = r, w
+			} else {
+				file, parentEnd = w, r
+			}
+		}
+		if err != nil {
+			closeAll(childEnds)
+			closeAll([]*os.File{proc.stdin, proc.stdout, proc.stderr})
+			return nil, err
+		}
+		if redirect.kind != redirectInherit {
+			childEnds = append(childEnds, file)
+		}
+
+		switch stream {
+		case 0:
+			cmd.Stdin, proc.stdin = file, parentEnd
+		case 1:
+			cmd.Stdout, proc.stdout = file, parentEnd
+		case 2:
+			cmd.Stderr, proc.stderr = file, parentEnd
+		}
+	}
+
+	err := cmd.Start()
+	closeAll(childEnds)
+	if err != nil {
+		closeAll([]*os.File{proc.stdin, proc.stdout, proc.stderr})
+		return nil, err
+	}
+
+	go func() {
+		err := cmd.Wait()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			proc.exitValue = int64(exitErr.ExitCode())
+			// as in HotSpot, a process killed by a signal exits with 128 + the signal number
+			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+				proc.exitValue = 128 + int64(status.Signal())
+			}
+		}
+		close(proc.done)
+	}()
+	return proc, nil
+}
+
+// makeProcessObject returns a Process object for the process
+func makeProcessObject(proc *process) *object.Object {
+	procObj := object.MakeEmptyObjectWithClassName(&processClassName)
+	procObj.FieldTable[processField] = object.Field{Ftype: types.Ref, Fvalue: proc}
+	return procObj
+}
+
+// getProcess returns the state of a Process object
+func getProcess(procObj *object.Object) (*process, *GErrBlk) {
+	proc, ok := procObj.FieldTable[processField].Fvalue.(*process)
+	if !ok {
+		return nil, getGErrBlk(excNames.IllegalStateException, "Process was not started by ProcessBuilder")
+	}
+	return proc, nil
+}
+
+// hasExited reports whether the process has exited
+func (proc *process) hasExited() bool {
+	select {
+	case <-proc.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// getStream returns the FileInputStream or FileOutputStream for stdin, stdout, or stderr.
+// As in HotSpot, a stream that's redirected is a null stream: reading it returns EOF at
+// once and writing to it does nothing. Each call returns the same stream object.
+func (proc *process) getStream(stream int) (*object.Object, *GErrBlk) {
+	proc.mutex.Lock()
+	defer proc.mutex.Unlock()
+	if proc.streams[stream] != nil {
+		return proc.streams[stream], nil
+	}
+
+	file := []*os.File{proc.stdin, proc.stdout, proc.stderr}[stream]
+	if file == nil {
+		var err error
+		file, err = os.OpenFile(os.DevNull, []int{os.O_WRONLY, os.O_RDONLY, os.O_RDONLY}[stream], 0)
+		if err != nil {
+			return nil, getGErrBlk(excNames.IOException, err.Error())
+		}
+	}
+
+	className := []string{"java/io/FileOutputStream", "java/io/FileInputStream", "java/io/FileInputStream"}[stream]
+	streamObj := object.MakeEmptyObjectWithClassName(&className)
+	streamObj.FieldTable[FilePath] = object.Field{Ftype: types.ByteArray, Fvalue: []byte(file.Name())}
+	streamObj.FieldTable[FileHandle] = object.Field{Ftype: types.FileHandle, Fvalue: file}
+	proc.streams[stream] = streamObj
+	return streamObj, nil
+}
+
+// returns the stream for stdin, stdout, or stderr of the Process in params[0]
+func processGetStream(params []interface{}, stream int) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	streamObj, errBlk := proc.getStream(stream)
+	if errBlk != nil {
+		return errBlk
+	}
+	return streamObj
+}
+
+// "java/lang/Process.getOutputStream()Ljava/io/OutputStream;" returns the stream
+// connected to the process's stdin
+func processGetOutputStream(params []interface{}) interface{} {
+	return processGetStream(params, 0)
+}
+
+// "java/lang/Process.getInputStream()Ljava/io/InputStream;" returns the stream
+// connected to the process's stdout
+func processGetInputStream(params []interface{}) interface{} {
+	return processGetStream(params, 1)
+}
+
+// "java/lang/Process.getErrorStream()Ljava/io/InputStream;" returns the stream
+// connected to the process's stderr
+func processGetErrorStream(params []interface{}) interface{} {
+	return processGetStream(params, 2)
+}
+
+// "java/lang/Process.waitFor()I" waits for the process to exit and returns its exit value
+func processWaitFor(params []interface{}) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	<-proc.done
+	return proc.exitValue
+}
+
+// "java/lang/Process.waitFor(JLjava/util/concurrent/TimeUnit;)Z" waits for the process to
+// exit for at most the timeout. It returns true if the process has exited.
+func processWaitForTimeout(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	proc, errBlk := getProcess(params[1].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	unit, ok := params[3].(*object.Object)
+	if !ok || object.IsNull(unit) {
+		return getGErrBlk(excNames.NullPointerException, "TimeUnit is null")
+	}
+
+	unitClassName := *stringPool.GetStringPointer(unit.KlassName)
+	nanos, err := globals.GetGlobalRef().FuncInvokeMethod(fs, unitClassName, "toNanos", "(J)J",
+		[]any{unit, params[2].(int64)})
+	if err != nil {
+		return getGErrBlk(excNames.InternalException, err.Error())
+	}
+
+	timer := time.NewTimer(time.Duration(nanos.(int64)))
+	defer timer.Stop()
+	select {
+	case <-proc.done:
+		return types.JavaBoolTrue
+	case <-timer.C:
+		return types.JavaBoolFalse
+	}
+}
+
+// "java/lang/Process.exitValue()I"
+func processExitValue(params []interface{}) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if !proc.hasExited() {
+		return getGErrBlk(excNames.IllegalThreadStateException, "process hasn't exited")
+	}
+	return proc.exitValue
+}
+
+// "java/lang/Process.isAlive()Z"
+func processIsAlive(params []interface{}) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if proc.hasExited() {
+		return types.JavaBoolFalse
+	}
+	return types.JavaBoolTrue
+}
+
+// "java/lang/Process.pid()J"
+func processPid(params []interface{}) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	return int64(proc.cmd.Process.Pid)
+}
+
+// "java/lang/Process.destroy()V" asks the process to terminate. On Windows, where
+// there's no SIGTERM, the process is killed.
+func processDestroy(params []interface{}) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if !proc.hasExited() {
+		if err := proc.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+			_ = proc.cmd.Process.Kill()
+		}
+	}
+	return nil
+}
+
+// "java/lang/Process.destroyForcibly()Ljava/lang/Process;"
+func processDestroyForcibly(params []interface{}) interface{} {
+	proc, errBlk := getProcess(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if !proc.hasExited() {
+		_ = proc.cmd.Process.Kill()
+	}
+	return params[0]
+}
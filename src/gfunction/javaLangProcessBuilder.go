@@ -0,0 +1,653 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"fmt"
+	"jacobin/excNames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/statics"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// java.lang.ProcessBuilder and ProcessBuilder.Redirect are implemented entirely in Go,
+// on top of os/exec. A ProcessBuilder object holds its state in a processBuilder, and
+// a Redirect object its state in a processRedirect, each in the Jacobin-specific
+// field named below. ProcessBuilder.start() returns a java.lang.Process, which is
+// implemented in javaLangProcess.go.
+const processBuilderField = "processBuilder"
+const processRedirectField = "redirect"
+
+var processBuilderClassName = "java/lang/ProcessBuilder"
+var processRedirectClassName = "java/lang/ProcessBuilder$Redirect"
+
+type processBuilder struct {
+	command             []string
+	directory           *object.Object      // a java.io.File, or nil for the current directory
+	environment         *object.Object      // a java.util.Map, or nil if environment() wasn't called
+	redirects           [3]*processRedirect // for stdin, stdout, and stderr
+	redirectErrorStream bool
+}
+
+// the kinds of redirects, per ProcessBuilder.Redirect.Type
+const (
+	redirectPipe = iota
+	redirectInherit
+	redirectRead
+	redirectWrite
+	redirectAppend
+)
+
+type processRedirect struct {
+	kind int
+	file string // the file to read, write, or append to
+}
+
+func Load_Lang_ProcessBuilder() {
+
+	MethodSignatures["java/lang/ProcessBuilder.<init>([Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderInitArray,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.<init>(Ljava/util/List;)V"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    processBuilderInitList,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.command()Ljava/util/List;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    processBuilderGetCommand,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.command([Ljava/lang/String;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderCommandArray,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.command(Ljava/util/List;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    processBuilderCommandList,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.directory()Ljava/io/File;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processBuilderGetDirectory,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.directory(Ljava/io/File;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderDirectory,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.environment()Ljava/util/Map;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    processBuilderEnvironment,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.inheritIO()Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processBuilderInheritIO,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectErrorStream()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processBuilderGetRedirectErrorStream,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectErrorStream(Z)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectErrorStream,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectInput()Ljava/lang/ProcessBuilder$Redirect;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processBuilderGetRedirectInput,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectOutput()Ljava/lang/ProcessBuilder$Redirect;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processBuilderGetRedirectOutput,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectError()Ljava/lang/ProcessBuilder$Redirect;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  processBuilderGetRedirectError,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectInput(Ljava/io/File;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectInputFile,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectOutput(Ljava/io/File;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectOutputFile,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectError(Ljava/io/File;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectErrorFile,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectInput(Ljava/lang/ProcessBuilder$Redirect;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectInput,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectOutput(Ljava/lang/ProcessBuilder$Redirect;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectOutput,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.redirectError(Ljava/lang/ProcessBuilder$Redirect;)Ljava/lang/ProcessBuilder;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  processBuilderRedirectError,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder.start()Ljava/lang/Process;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    processBuilderStart,
+			NeedsContext: true,
+		}
+
+	// ---- ProcessBuilder.Redirect ----
+
+	MethodSignatures["java/lang/ProcessBuilder$Redirect.<clinit>()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  redirectClinit,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder$Redirect.from(Ljava/io/File;)Ljava/lang/ProcessBuilder$Redirect;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  redirectFrom,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder$Redirect.to(Ljava/io/File;)Ljava/lang/ProcessBuilder$Redirect;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  redirectTo,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder$Redirect.appendTo(Ljava/io/File;)Ljava/lang/ProcessBuilder$Redirect;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  redirectAppendTo,
+		}
+
+	MethodSignatures["java/lang/ProcessBuilder$Redirect.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  redirectToString,
+		}
+}
+
+// getProcessBuilder returns the state of a ProcessBuilder, creating it if needed
+func getProcessBuilder(pbObj *object.Object) *processBuilder {
+	pb, ok := pbObj.FieldTable[processBuilderField].Fvalue.(*processBuilder)
+	if !ok {
+		pb = &processBuilder{}
+		for i := range pb.redirects {
+			pb.redirects[i] = &processRedirect{kind: redirectPipe}
+		}
+		pbObj.FieldTable[processBuilderField] = object.Field{Ftype: types.Ref, Fvalue: pb}
+	}
+	return pb
+}
+
+// stringArrayToGo converts a Java String[] to a slice of Go strings
+func stringArrayToGo(arrayObj *object.Object) ([]string, *GErrBlk) {
+	if object.IsNull(arrayObj) {
+		return nil, getGErrBlk(excNames.NullPointerException, "command array is null")
+	}
+	elements, _ := arrayObj.FieldTable["value"].Fvalue.([]*object.Object)
+	strs := make([]string, len(elements))
+	for i, element := range elements {
+		if object.IsNull(element) {
+			return nil, getGErrBlk(excNames.NullPointerException, "command element is null")
+		}
+		strs[i] = object.GoStringFromStringObject(element)
+	}
+	return strs, nil
+}
+
+// javaCollectionToGo converts a java.util.Collection of Strings (such as a List) to a
+// slice of Go strings, by calling the collection's toArray() method
+func javaCollectionToGo(fs *list.List, collection *object.Object) ([]string, *GErrBlk) {
+	if object.IsNull(collection) {
+		return nil, getGErrBlk(excNames.NullPointerException, "collection is null")
+	}
+	className := *stringPool.GetStringPointer(collection.KlassName)
+	array, err := globals.GetGlobalRef().FuncInvokeMethod(fs, className, "toArray",
+		"()[Ljava/lang/Object;", []any{collection})
+	if err != nil {
+		return nil, getGErrBlk(excNames.InternalException, err.Error())
+	}
+	return stringArrayToGo(array.(*object.Object))
+}
+
+// goStringsToJavaList creates a java.util.ArrayList containing the strings
+func goStringsToJavaList(fs *list.List, strs []string) (*object.Object, *GErrBlk) {
+	glob := globals.GetGlobalRef()
+	listClassName := "java/util/ArrayList"
+	listObj, err := glob.FuncInstantiateClass(listClassName, fs)
+	if err == nil {
+		_, err = glob.FuncInvokeMethod(fs, listClassName, "<init>", "()V", []any{listObj})
+	}
+	for i := 0; err == nil && i < len(strs); i++ {
+		_, err = glob.FuncInvokeMethod(fs, listClassName, "add", "(Ljava/lang/Object;)Z",
+			[]any{listObj, object.StringObjectFromGoString(strs[i])})
+	}
+	if err != nil {
+		return nil, getGErrBlk(excNames.InternalException, err.Error())
+	}
+	return listObj.(*object.Object), nil
+}
+
+// goStringMapToJava creates a java.util.HashMap containing the keys and values in the Go map
+func goStringMapToJava(fs *list.List, strMap map[string]string) (*object.Object, *GErrBlk) {
+	glob := globals.GetGlobalRef()
+	mapClassName := "java/util/HashMap"
+	mapObj, err := glob.FuncInstantiateClass(mapClassName, fs)
+	if err == nil {
+		_, err = glob.FuncInvokeMethod(fs, mapClassName, "<init>", "()V", []any{mapObj})
+	}
+
+	keys := make([]string, 0, len(strMap))
+	for key := range strMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i := 0; err == nil && i < len(keys); i++ {
+		_, err = glob.FuncInvokeMethod(fs, mapClassName, "put",
+			"(Ljava/lang/Object;Ljava/lang/Object;)Ljava/lang/Object;",
+			[]any{mapObj, object.StringObjectFromGoString(keys[i]), object.StringObjectFromGoString(strMap[keys[i]])})
+	}
+	if err != nil {
+		return nil, getGErrBlk(excNames.InternalException, err.Error())
+	}
+	return mapObj.(*object.Object), nil
+}
+
+// javaStringMapToGo converts a java.util.Map whose keys and values are Strings to a Go map
+func javaStringMapToGo(fs *list.List, mapObj *object.Object) (map[string]string, *GErrBlk) {
+	glob := globals.GetGlobalRef()
+	mapClassName := *stringPool.GetStringPointer(mapObj.KlassName)
+	keySet, err := glob.FuncInvokeMethod(fs, mapClassName, "keySet", "()Ljava/util/Set;", []any{mapObj})
+	if err != nil {
+		return nil, getGErrBlk(excNames.InternalException, err.Error())
+	}
+	keys, errBlk := javaCollectionToGo(fs, keySet.(*object.Object))
+	if errBlk != nil {
+		return nil, errBlk
+	}
+
+	strMap := make(map[string]string, len(keys))
+	for _, key := range keys {
+		value, err := glob.FuncInvokeMethod(fs, mapClassName, "get", "(Ljava/lang/Object;)Ljava/lang/Object;",
+			[]any{mapObj, object.StringObjectFromGoString(key)})
+		if err != nil {
+			return nil, getGErrBlk(excNames.InternalException, err.Error())
+		}
+		if valueObj, ok := value.(*object.Object); ok && !object.IsNull(valueObj) {
+			strMap[key] = object.GoStringFromStringObject(valueObj)
+		}
+	}
+	return strMap, nil
+}
+
+// getenvMap returns the environment variables of the Jacobin process
+func getenvMap() map[string]string {
+	env := make(map[string]string)
+	for _, entry := range os.Environ() {
+		if key, value, found := strings.Cut(entry, "="); found && key != "" {
+			env[key] = value
+		}
+	}
+	return env
+}
+
+// "java/lang/ProcessBuilder.<init>([Ljava/lang/String;)V"
+func processBuilderInitArray(params []interface{}) interface{} {
+	command, errBlk := stringArrayToGo(params[1].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	getProcessBuilder(params[0].(*object.Object)).command = command
+	return nil
+}
+
+// "java/lang/ProcessBuilder.<init>(Ljava/util/List;)V"
+func processBuilderInitList(params []interface{}) interface{} {
+	command, errBlk := javaCollectionToGo(params[0].(*list.List), params[2].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	getProcessBuilder(params[1].(*object.Object)).command = command
+	return nil
+}
+
+// "java/lang/ProcessBuilder.command()Ljava/util/List;" returns a new list: unlike
+// in HotSpot, changes to it do not change the ProcessBuilder's command
+func processBuilderGetCommand(params []interface{}) interface{} {
+	pb := getProcessBuilder(params[1].(*object.Object))
+	listObj, errBlk := goStringsToJavaList(params[0].(*list.List), pb.command)
+	if errBlk != nil {
+		return errBlk
+	}
+	return listObj
+}
+
+// "java/lang/ProcessBuilder.command([Ljava/lang/String;)Ljava/lang/ProcessBuilder;"
+func processBuilderCommandArray(params []interface{}) interface{} {
+	if errBlk := processBuilderInitArray(params); errBlk != nil {
+		return errBlk
+	}
+	return params[0]
+}
+
+// "java/lang/ProcessBuilder.command(Ljava/util/List;)Ljava/lang/ProcessBuilder;"
+func processBuilderCommandList(params []interface{}) interface{} {
+	if errBlk := processBuilderInitList(params); errBlk != nil {
+		return errBlk
+	}
+	return params[1]
+}
+
+// "java/lang/ProcessBuilder.directory()Ljava/io/File;"
+func processBuilderGetDirectory(params []interface{}) interface{} {
+	pb := getProcessBuilder(params[0].(*object.Object))
+	if pb.directory == nil {
+		return object.Null
+	}
+	return pb.directory
+}
+
+// "java/lang/ProcessBuilder.directory(Ljava/io/File;)Ljava/lang/ProcessBuilder;" A null
+// directory means the current directory.
+func processBuilderDirectory(params []interface{}) interface{} {
+	pb := getProcessBuilder(params[0].(*object.Object))
+	pb.directory = nil
+	if dir, ok := params[1].(*object.Object); ok && !object.IsNull(dir) {
+		pb.directory = dir
+	}
+	return params[0]
+}
+
+// "java/lang/ProcessBuilder.environment()Ljava/util/Map;" returns a map that's initially a
+// copy of the environment of the Jacobin process. Changes to it are seen by start().
+func processBuilderEnvironment(params []interface{}) interface{} {
+	pb := getProcessBuilder(params[1].(*object.Object))
+	if pb.environment == nil {
+		envMap, errBlk := goStringMapToJava(params[0].(*list.List), getenvMap())
+		if errBlk != nil {
+			return errBlk
+		}
+		pb.environment = envMap
+	}
+	return pb.environment
+}
+
+// "java/lang/ProcessBuilder.inheritIO()Ljava/lang/ProcessBuilder;"
+func processBuilderInheritIO(params []interface{}) interface{} {
+	pb := getProcessBuilder(params[0].(*object.Object))
+	for i := range pb.redirects {
+		pb.redirects[i] = &processRedirect{kind: redirectInherit}
+	}
+	return params[0]
+}
+
+// "java/lang/ProcessBuilder.redirectErrorStream()Z"
+func processBuilderGetRedirectErrorStream(params []interface{}) interface{} {
+	if getProcessBuilder(params[0].(*object.Object)).redirectErrorStream {
+		return types.JavaBoolTrue
+	}
+	return types.JavaBoolFalse
+}
+
+// "java/lang/ProcessBuilder.redirectErrorStream(Z)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectErrorStream(params []interface{}) interface{} {
+	getProcessBuilder(params[0].(*object.Object)).redirectErrorStream = params[1].(int64) != types.JavaBoolFalse
+	return params[0]
+}
+
+// returns the Redirect for stdin, stdout, or stderr
+func processBuilderGetRedirect(params []interface{}, stream int) interface{} {
+	return makeRedirectObject(getProcessBuilder(params[0].(*object.Object)).redirects[stream])
+}
+
+// "java/lang/ProcessBuilder.redirectInput()Ljava/lang/ProcessBuilder$Redirect;"
+func processBuilderGetRedirectInput(params []interface{}) interface{} {
+	return processBuilderGetRedirect(params, 0)
+}
+
+// "java/lang/ProcessBuilder.redirectOutput()Ljava/lang/ProcessBuilder$Redirect;"
+func processBuilderGetRedirectOutput(params []interface{}) interface{} {
+	return processBuilderGetRedirect(params, 1)
+}
+
+// "java/lang/ProcessBuilder.redirectError()Ljava/lang/ProcessBuilder$Redirect;"
+func processBuilderGetRedirectError(params []interface{}) interface{} {
+	return processBuilderGetRedirect(params, 2)
+}
+
+// sets the Redirect for stdin, stdout, or stderr. As in HotSpot, stdin can't be redirected
+// to a file that's written, and stdout and stderr can't be redirected from a file that's read.
+func processBuilderSetRedirect(params []interface{}, stream int) interface{} {
+	redirectObj, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(redirectObj) {
+		return getGErrBlk(excNames.NullPointerException, "redirect is null")
+	}
+	redirect, ok := redirectObj.FieldTable[processRedirectField].Fvalue.(*processRedirect)
+	if !ok {
+		return getGErrBlk(excNames.IllegalArgumentException, "not a ProcessBuilder.Redirect")
+	}
+
+	readsFile := redirect.kind == redirectRead
+	writesFile := redirect.kind == redirectWrite || redirect.kind == redirectAppend
+	if (stream == 0 && writesFile) || (stream != 0 && readsFile) {
+		errMsg := fmt.Sprintf("Redirect invalid for %s: %s",
+			[]string{"reading", "writing", "writing"}[stream], redirectString(redirect))
+		return getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	}
+
+	getProcessBuilder(params[0].(*object.Object)).redirects[stream] = redirect
+	return params[0]
+}
+
+// "java/lang/ProcessBuilder.redirectInput(Ljava/lang/ProcessBuilder$Redirect;)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectInput(params []interface{}) interface{} {
+	return processBuilderSetRedirect(params, 0)
+}
+
+// "java/lang/ProcessBuilder.redirectOutput(Ljava/lang/ProcessBuilder$Redirect;)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectOutput(params []interface{}) interface{} {
+	return processBuilderSetRedirect(params, 1)
+}
+
+// "java/lang/ProcessBuilder.redirectError(Ljava/lang/ProcessBuilder$Redirect;)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectError(params []interface{}) interface{} {
+	return processBuilderSetRedirect(params, 2)
+}
+
+// "java/lang/ProcessBuilder.redirectInput(Ljava/io/File;)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectInputFile(params []interface{}) interface{} {
+	redirect := redirectFrom(params[1:])
+	if errBlk, ok := redirect.(*GErrBlk); ok {
+		return errBlk
+	}
+	return processBuilderSetRedirect([]interface{}{params[0], redirect}, 0)
+}
+
+// "java/lang/ProcessBuilder.redirectOutput(Ljava/io/File;)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectOutputFile(params []interface{}) interface{} {
+	redirect := redirectTo(params[1:])
+	if errBlk, ok := redirect.(*GErrBlk); ok {
+		return errBlk
+	}
+	return processBuilderSetRedirect([]interface{}{params[0], redirect}, 1)
+}
+
+// "java/lang/ProcessBuilder.redirectError(Ljava/io/File;)Ljava/lang/ProcessBuilder;"
+func processBuilderRedirectErrorFile(params []interface{}) interface{} {
+	redirect := redirectTo(params[1:])
+	if errBlk, ok := redirect.(*GErrBlk); ok {
+		return errBlk
+	}
+	return processBuilderSetRedirect([]interface{}{params[0], redirect}, 2)
+}
+
+// "java/lang/ProcessBuilder.start()Ljava/lang/Process;" starts the process, whose stdin,
+// stdout, and stderr are either pipes to the Process's streams or are redirected
+func processBuilderStart(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	pb := getProcessBuilder(params[1].(*object.Object))
+	if len(pb.command) == 0 {
+		return getGErrBlk(excNames.IndexOutOfBoundsException, "Index 0 out of bounds for length 0")
+	}
+
+	cmd := exec.Command(pb.command[0], pb.command[1:]...)
+	dirMsg := ""
+	if pb.directory != nil {
+		cmd.Dir = string(pb.directory.FieldTable[FilePath].Fvalue.([]byte))
+		dirMsg = fmt.Sprintf(" (in directory \"%s\")", cmd.Dir)
+	}
+
+	if pb.environment != nil {
+		env, errBlk := javaStringMapToGo(fs, pb.environment)
+		if errBlk != nil {
+			return errBlk
+		}
+		for key, value := range env {
+			cmd.Env = append(cmd.Env, key+"="+value)
+		}
+		sort.Strings(cmd.Env)
+		if cmd.Env == nil {
+			cmd.Env = []string{} // an empty environment, rather than the inherited one
+		}
+	}
+
+	proc, err := startProcess(cmd, pb.redirects, pb.redirectErrorStream)
+	if err != nil {
+		errMsg := fmt.Sprintf("Cannot run program \"%s\"%s: %s", pb.command[0], dirMsg, err.Error())
+		return getGErrBlk(excNames.IOException, errMsg)
+	}
+	return makeProcessObject(proc)
+}
+
+// ---- ProcessBuilder.Redirect ----
+
+// makeRedirectObject returns a ProcessBuilder.Redirect object for the redirect
+func makeRedirectObject(redirect *processRedirect) *object.Object {
+	redirectObj := object.MakeEmptyObjectWithClassName(&processRedirectClassName)
+	redirectObj.FieldTable[processRedirectField] = object.Field{Ftype: types.Ref, Fvalue: redirect}
+	return redirectObj
+}
+
+// "java/lang/ProcessBuilder$Redirect.<clinit>()V" creates the static Redirects PIPE, INHERIT,
+// and DISCARD. As in HotSpot, DISCARD writes to the null device.
+func redirectClinit([]interface{}) interface{} {
+	redirects := map[string]*processRedirect{
+		"PIPE":    {kind: redirectPipe},
+		"INHERIT": {kind: redirectInherit},
+		"DISCARD": {kind: redirectWrite, file: os.DevNull},
+	}
+	for name, redirect := range redirects {
+		_ = statics.AddStatic(processRedirectClassName+"."+name, statics.Static{
+			Type:  "L" + processRedirectClassName + ";",
+			Value: makeRedirectObject(redirect),
+		})
+	}
+	return nil
+}
+
+// makes a Redirect to or from the file passed in params[0]
+func makeFileRedirect(params []interface{}, kind int) interface{} {
+	fileObj, ok := params[0].(*object.Object)
+	if !ok || object.IsNull(fileObj) {
+		return getGErrBlk(excNames.NullPointerException, "file is null")
+	}
+	path, ok := fileObj.FieldTable[FilePath].Fvalue.([]byte)
+	if !ok {
+		return getGErrBlk(excNames.IllegalArgumentException, "File object lacks a FilePath field")
+	}
+	return makeRedirectObject(&processRedirect{kind: kind, file: string(path)})
+}
+
+// "java/lang/ProcessBuilder$Redirect.from(Ljava/io/File;)Ljava/lang/ProcessBuilder$Redirect;"
+func redirectFrom(params []interface{}) interface{} {
+	return makeFileRedirect(params, redirectRead)
+}
+
+// "java/lang/ProcessBuilder$Redirect.to(Ljava/io/File;)Ljava/lang/ProcessBuilder$Redirect;"
+func redirectTo(params []interface{}) interface{} {
+	return makeFileRedirect(params, redirectWrite)
+}
+
+// "java/lang/ProcessBuilder$Redirect.appendTo(Ljava/io/File;)Ljava/lang/ProcessBuilder$Redirect;"
+func redirectAppendTo(params []interface{}) interface{} {
+	return makeFileRedirect(params, redirectAppend)
+}
+
+// redirectString returns the redirect in the format of Redirect.toString()
+func redirectString(redirect *processRedirect) string {
+	switch redirect.kind {
+	case redirectRead:
+		return "redirect to read from file \"" + redirect.file + "\""
+	case redirectWrite:
+		return "redirect to write to file \"" + redirect.file + "\""
+	case redirectAppend:
+		return "redirect to append to file \"" + redirect.file + "\""
+	case redirectInherit:
+		return "INHERIT"
+	default:
+		return "PIPE"
+	}
+}
+
+// "java/lang/ProcessBuilder$Redirect.toString()Ljava/lang/String;"
+func redirectToString(params []interface{}) interface{} {
+	redirect, ok := params[0].(*object.Object).FieldTable[processRedirectField].Fvalue.(*processRedirect)
+	if !ok {
+		return getGErrBlk(excNames.IllegalArgumentException, "not a ProcessBuilder.Redirect")
+	}
+	return object.StringObjectFromGoString(redirectString(redirect))
+}
@@ -0,0 +1,110 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"jacobin/excNames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/types"
+	"os/exec"
+	"runtime"
+	"testing"
+)
+
+// startTestProcess runs the command via ProcessBuilder.start(), skipping the test
+// if the command isn't available on this platform
+func startTestProcess(t *testing.T, command ...string) *object.Object {
+	if runtime.GOOS == "windows" {
+		t.Skip("test uses Unix commands")
+	}
+	if _, err := exec.LookPath(command[0]); err != nil {
+		t.Skipf("%s is not available", command[0])
+	}
+
+	globals.InitGlobals("test")
+	pbObj := object.MakeEmptyObjectWithClassName(&processBuilderClassName)
+	getProcessBuilder(pbObj).command = command
+
+	procObj, ok := processBuilderStart([]interface{}{list.New(), pbObj}).(*object.Object)
+	if !ok {
+		t.Fatalf("ProcessBuilder.start() did not return a Process")
+	}
+	return procObj
+}
+
+func TestProcessStreamsUseFileStreamGfunctions(t *testing.T) {
+	procObj := startTestProcess(t, "cat")
+
+	stdin := processGetOutputStream([]interface{}{procObj}).(*object.Object)
+	for _, b := range []byte("hi") {
+		if ret := fosWriteOne([]interface{}{stdin, int64(b)}); ret != nil {
+			t.Fatalf("unexpected error from write(): %v", ret)
+		}
+	}
+	if ret := fosClose([]interface{}{stdin}); ret != nil {
+		t.Fatalf("unexpected error from close(): %v", ret)
+	}
+
+	stdout := processGetInputStream([]interface{}{procObj}).(*object.Object)
+	if stdout != processGetInputStream([]interface{}{procObj}) {
+		t.Errorf("getInputStream() should return the same stream each time")
+	}
+	var output []byte
+	for {
+		b := fisReadOne([]interface{}{stdout}).(int64)
+		if b == -1 {
+			break
+		}
+		output = append(output, byte(b))
+	}
+	if string(output) != "hi" {
+		t.Errorf("expected the process to echo \"hi\", got %q", output)
+	}
+
+	if exitValue := processWaitFor([]interface{}{procObj}); exitValue != int64(0) {
+		t.Errorf("expected exit value 0, got %v", exitValue)
+	}
+}
+
+func TestProcessExitValueAndDestroy(t *testing.T) {
+	procObj := startTestProcess(t, "sleep", "10")
+
+	errBlk, ok := processExitValue([]interface{}{procObj}).(*GErrBlk)
+	if !ok || errBlk.ExceptionType != excNames.IllegalThreadStateException {
+		t.Errorf("expected IllegalThreadStateException from exitValue() of a running process, got %v", errBlk)
+	}
+	if processIsAlive([]interface{}{procObj}) != types.JavaBoolTrue {
+		t.Errorf("expected isAlive() to be true for a running process")
+	}
+
+	processDestroy([]interface{}{procObj})
+	if exitValue := processWaitFor([]interface{}{procObj}); exitValue != int64(143) { // 128 + SIGTERM
+		t.Errorf("expected exit value 143 after destroy(), got %v", exitValue)
+	}
+	if exitValue := processExitValue([]interface{}{procObj}); exitValue != int64(143) {
+		t.Errorf("expected exitValue() to return 143, got %v", exitValue)
+	}
+}
+
+func TestProcessBuilderStartFailure(t *testing.T) {
+	globals.InitGlobals("test")
+	pbObj := object.MakeEmptyObjectWithClassName(&processBuilderClassName)
+	getProcessBuilder(pbObj).command = []string{"no-such-command-for-jacobin"}
+
+	errBlk, ok := processBuilderStart([]interface{}{list.New(), pbObj}).(*GErrBlk)
+	if !ok || errBlk.ExceptionType != excNames.IOException {
+		t.Fatalf("expected IOException from start() of a missing command, got %v", errBlk)
+	}
+
+	getProcessBuilder(pbObj).command = nil
+	errBlk, ok = processBuilderStart([]interface{}{list.New(), pbObj}).(*GErrBlk)
+	if !ok || errBlk.ExceptionType != excNames.IndexOutOfBoundsException {
+		t.Errorf("expected IndexOutOfBoundsException from start() of an empty command, got %v", errBlk)
+	}
+}
@@ -0,0 +1,197 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"jacobin/classloader"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+)
+
+// The reflection of record classes: Class.isRecord() and getRecordComponents(), and
+// java.lang.reflect.RecordComponent. The components are parsed by the class loader from
+// the record's Record attribute (see classloader/records.go). Like a Field object, a
+// RecordComponent object holds its declaring record (clazz), its name, and its field
+// descriptor; it's created by makeMember() and has no modifiers.
+//
+// The toString(), equals(), and hashCode() methods of records are implemented by the
+// ObjectMethods bootstrap method, in jvm/objectMethods.go.
+
+var recordClassName = "java/lang/Record"
+var recordComponentClassName = "java/lang/reflect/RecordComponent"
+
+func Load_Lang_Record() {
+
+	MethodSignatures["java/lang/Class.getRecordComponents()[Ljava/lang/reflect/RecordComponent;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetRecordComponents,
+		}
+
+	MethodSignatures["java/lang/Class.isRecord()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classIsRecord,
+		}
+
+	// --- java.lang.reflect.RecordComponent ---
+
+	MethodSignatures["java/lang/reflect/RecordComponent.getAccessor()Ljava/lang/reflect/Method;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  recordComponentGetAccessor,
+		}
+
+	MethodSignatures["java/lang/reflect/RecordComponent.getDeclaringRecord()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetDeclaringClass,
+		}
+
+	MethodSignatures["java/lang/reflect/RecordComponent.getGenericSignature()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  recordComponentGetGenericSignature,
+		}
+
+	MethodSignatures["java/lang/reflect/RecordComponent.getGenericType()Ljava/lang/reflect/Type;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  recordComponentGetGenericType,
+		}
+
+	MethodSignatures["java/lang/reflect/RecordComponent.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetName,
+		}
+
+	MethodSignatures["java/lang/reflect/RecordComponent.getType()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  fieldGetType,
+		}
+
+	MethodSignatures["java/lang/reflect/RecordComponent.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  recordComponentToString,
+		}
+}
+
+// "java/lang/Class.isRecord()Z" -- true if the class is a record: a direct subclass of
+// java.lang.Record that has a Record attribute
+func classIsRecord(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	return types.ConvertGoBoolToJavaBool(isRecordClass(k))
+}
+
+// "java/lang/Class.getRecordComponents()[Ljava/lang/reflect/RecordComponent;" returns the
+// components of a record in the order in which they're declared, or null if the class is
+// not a record. Each call returns new objects.
+func classGetRecordComponents(params []interface{}) interface{} {
+	k, errBlk := reflectedClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if !isRecordClass(k) {
+		return object.Null
+	}
+	var components []*object.Object
+	for _, component := range k.Data.Record {
+		components = append(components, makeMember(recordComponentClassName, k.Data.Name, component.Name, component.Desc, 0))
+	}
+	return makeRefArray(recordComponentClassName, components)
+}
+
+// "java/lang/reflect/RecordComponent.getAccessor()Ljava/lang/reflect/Method;" returns the
+// method that returns the value of the component, which has the component's name
+func recordComponentGetAccessor(params []interface{}) interface{} {
+	component := params[0].(*object.Object)
+	k, errBlk := loadClassForReflection(memberClassName(component))
+	if errBlk != nil {
+		return errBlk
+	}
+	name := memberName(component)
+	desc := "()" + memberDescriptor(component)
+	meth, ok := k.Data.MethodTable[name+desc]
+	if !ok {
+		return object.Null
+	}
+	return makeMethodObject(k.Data.Name, name, desc, meth.AccessFlags)
+}
+
+// "java/lang/reflect/RecordComponent.getGenericSignature()Ljava/lang/String;" returns the
+// generic signature of the component, or null if its type is not generic
+func recordComponentGetGenericSignature(params []interface{}) interface{} {
+	component, errBlk := recordComponentOf(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if component == nil || component.Signature == "" {
+		return object.Null
+	}
+	return object.StringObjectFromGoString(component.Signature)
+}
+
+// "java/lang/reflect/RecordComponent.getGenericType()Ljava/lang/reflect/Type;" returns the
+// type of the component as it's declared in the source code: a generic type if it has a
+// generic signature, and otherwise the same Class object as getType()
+func recordComponentGetGenericType(params []interface{}) interface{} {
+	obj := params[0].(*object.Object)
+	component, errBlk := recordComponentOf(obj)
+	if errBlk != nil {
+		return errBlk
+	}
+	if component == nil || component.Signature == "" {
+		return classObjectForDescriptor(memberDescriptor(obj))
+	}
+
+	p := signatureParser{signature: component.Signature, declaration: memberClassName(obj)}
+	genericType := p.javaType()
+	if p.err == nil && p.pos != len(p.signature) {
+		p.fail("unexpected characters at end of signature")
+	}
+	if p.err != nil {
+		return p.formatError()
+	}
+	return genericType
+}
+
+// "java/lang/reflect/RecordComponent.toString()Ljava/lang/String;" returns the type and the
+// name of the component, e.g., java.lang.String name
+func recordComponentToString(params []interface{}) interface{} {
+	component := params[0].(*object.Object)
+	str := typeName(classObjectForDescriptor(memberDescriptor(component))) + " " + memberName(component)
+	return object.StringObjectFromGoString(str)
+}
+
+// isRecordClass reports whether a loaded class is a record. Arrays and primitive types
+// (for which k is nil) are not.
+func isRecordClass(k *classloader.Klass) bool {
+	return k != nil && k.Data.Record != nil && *stringPool.GetStringPointer(k.Data.SuperclassIndex) == recordClassName
+}
+
+// recordComponentOf returns the component in the Record attribute that's represented by a
+// RecordComponent object
+func recordComponentOf(obj *object.Object) (*classloader.RecordComponent, *GErrBlk) {
+	k, errBlk := loadClassForReflection(memberClassName(obj))
+	if errBlk != nil {
+		return nil, errBlk
+	}
+	name := memberName(obj)
+	for i := range k.Data.Record {
+		if k.Data.Record[i].Name == name {
+			return &k.Data.Record[i], nil
+		}
+	}
+	return nil, nil
+}
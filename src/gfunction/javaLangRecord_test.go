@@ -0,0 +1,112 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"jacobin/classloader"
+	"jacobin/object"
+	"jacobin/types"
+	"testing"
+)
+
+// recordTestSetup adds test/Pair, the record
+//
+//	record Pair(int first, @Marker List<String> second)
+func recordTestSetup() *list.List {
+	fs := annotationTestSetup()
+	reflectTestClass("java/lang/Record", types.ObjectClassName,
+		classloader.AccessFlags{ClassIsPublic: true, ClassIsAbstract: true}, nil, nil)
+	pair := reflectTestClass("test/Pair", "java/lang/Record", classloader.AccessFlags{ClassIsPublic: true, ClassIsFinal: true},
+		[][3]any{
+			{"first", "I", modifierPrivate | modifierFinal},
+			{"second", "Ljava/util/List;", modifierPrivate | modifierFinal},
+		},
+		[][3]any{
+			{"<init>", "(ILjava/util/List;)V", modifierPublic},
+			{"first", "()I", modifierPublic},
+			{"second", "()Ljava/util/List;", modifierPublic},
+		})
+	pair.Data.Record = []classloader.RecordComponent{
+		{Name: "first", Desc: "I"},
+		{Name: "second", Desc: "Ljava/util/List;", Signature: "Ljava/util/List<Ljava/lang/String;>;",
+			Annotations: []classloader.Annotation{{Type: "Ltest/Marker;"}}},
+	}
+	return fs
+}
+
+func TestClassIsRecord(t *testing.T) {
+	recordTestSetup()
+	for className, expected := range map[string]int64{
+		"test/Pair":        types.JavaBoolTrue,
+		"test/Widget":      types.JavaBoolFalse,
+		"java/lang/Record": types.JavaBoolFalse,
+		"int":              types.JavaBoolFalse,
+		"[Ltest/Pair;":     types.JavaBoolFalse,
+	} {
+		if ret := classIsRecord([]interface{}{makeClassObject(className)}); ret != expected {
+			t.Errorf("%s.isRecord(): expected %d, got %v", className, expected, ret)
+		}
+	}
+}
+
+func TestClassGetRecordComponents(t *testing.T) {
+	fs := recordTestSetup()
+
+	if ret := classGetRecordComponents([]interface{}{makeClassObject("test/Widget")}); !object.IsNull(ret) {
+		t.Errorf("Widget.getRecordComponents(): expected null for a class that's not a record")
+	}
+
+	array := classGetRecordComponents([]interface{}{makeClassObject("test/Pair")}).(*object.Object)
+	components := array.FieldTable["value"].Fvalue.([]*object.Object)
+	if len(components) != 2 {
+		t.Fatalf("getRecordComponents(): expected 2 components, got %d", len(components))
+	}
+	first, second := components[0], components[1]
+
+	if name := reflectGetName([]interface{}{second}).(*object.Object); object.GoStringFromStringObject(name) != "second" {
+		t.Errorf("getName(): expected second, got %s", object.GoStringFromStringObject(name))
+	}
+	if reflectGetDeclaringClass([]interface{}{first}) != makeClassObject("test/Pair") {
+		t.Errorf("getDeclaringRecord(): expected test/Pair")
+	}
+	if fieldGetType([]interface{}{first}) != makeClassObject("int") ||
+		fieldGetType([]interface{}{second}) != makeClassObject("java/util/List") {
+		t.Errorf("getType(): expected int and java.util.List")
+	}
+	if str := recordComponentToString([]interface{}{second}).(*object.Object); object.GoStringFromStringObject(str) != "java.util.List second" {
+		t.Errorf("toString(): expected \"java.util.List second\", got %q", object.GoStringFromStringObject(str))
+	}
+
+	accessor := recordComponentGetAccessor([]interface{}{first}).(*object.Object)
+	if memberName(accessor) != "first" || memberDescriptor(accessor) != "()I" || memberModifiers(accessor) != modifierPublic {
+		t.Errorf("getAccessor(): expected public int first(), got %s%s", memberName(accessor), memberDescriptor(accessor))
+	}
+
+	// the generic type of a component whose type is not generic is its class
+	if !object.IsNull(recordComponentGetGenericSignature([]interface{}{first})) {
+		t.Errorf("getGenericSignature(): expected null for int first")
+	}
+	if recordComponentGetGenericType([]interface{}{first}) != makeClassObject("int") {
+		t.Errorf("getGenericType(): expected int for int first")
+	}
+	signature := recordComponentGetGenericSignature([]interface{}{second}).(*object.Object)
+	if object.GoStringFromStringObject(signature) != "Ljava/util/List<Ljava/lang/String;>;" {
+		t.Errorf("getGenericSignature(): unexpected signature %s", object.GoStringFromStringObject(signature))
+	}
+	genericType := recordComponentGetGenericType([]interface{}{second}).(*object.Object)
+	if name := typeName(genericType); name != "java.util.List<java.lang.String>" {
+		t.Errorf("getGenericType(): expected java.util.List<java.lang.String>, got %s", name)
+	}
+
+	// the annotations of a component are those in its RuntimeVisibleAnnotations attribute
+	marker := makeClassObject("test/Marker")
+	if annotatedIsAnnotationPresent([]interface{}{fs, second, marker}) != types.JavaBoolTrue ||
+		annotatedIsAnnotationPresent([]interface{}{fs, first, marker}) != types.JavaBoolFalse {
+		t.Errorf("isAnnotationPresent(): expected only the second component to be annotated with @Marker")
+	}
+}
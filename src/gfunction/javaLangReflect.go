@@ -0,0 +1,629 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/exceptions"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/statics"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"strings"
+)
+
+// Implementation of java.lang.reflect.Method, Constructor, and Field, whose objects are
+// returned by the reflection methods of java.lang.Class. Each of these objects holds its
+// declaring class (clazz), its name, its modifiers, and its descriptor--a Go string that's
+// the method descriptor of a method or constructor, or the field descriptor of a field.
+// The override field is set by setAccessible().
+//
+// Primitive arguments and return values are boxed in their wrapper objects, and unboxed
+// with the widening conversions that HotSpot allows. Jacobin doesn't check access to
+// private members, so setAccessible() matters only when setting a final field. Methods
+// are invoked on the calling thread. As in HotSpot, an exception thrown by an invoked
+// method or constructor is wrapped in an InvocationTargetException, whose cause is the
+// exception.
+
+var methodClassName = "java/lang/reflect/Method"
+var constructorClassName = "java/lang/reflect/Constructor"
+var fieldClassName = "java/lang/reflect/Field"
+var invocationTargetExceptionClassName = "java/lang/reflect/InvocationTargetException"
+
+// the modifiers of classes and their members, as encoded by java.lang.reflect.Modifier
+const (
+	modifierPublic     = 0x0001
+	modifierPrivate    = 0x0002
+	modifierStatic     = 0x0008
+	modifierFinal      = 0x0010
+	modifierInterface  = 0x0200
+	modifierAbstract   = 0x0400
+	modifierSynthetic  = 0x1000
+	modifierAnnotation = 0x2000
+	modifierEnum       = 0x4000
+
+	accSuper = 0x0020 // the ACC_SUPER flag of classes, which is not a modifier
+)
+
+// the wrapper classes of the primitive types, by field descriptor
+var wrapperClassNames = map[string]string{
+	"Z": "java/lang/Boolean",
+	"B": "java/lang/Byte",
+	"C": "java/lang/Character",
+	"D": "java/lang/Double",
+	"F": "java/lang/Float",
+	"I": "java/lang/Integer",
+	"J": "java/lang/Long",
+	"S": "java/lang/Short",
+}
+
+// the primitive types to which each primitive type can be converted by reflection
+var wideningConversions = map[string]string{
+	"Z": "Z",
+	"B": "BSIJFD",
+	"C": "CIJFD",
+	"D": "D",
+	"F": "FD",
+	"I": "IJFD",
+	"J": "JFD",
+	"S": "SIJFD",
+}
+
+func Load_Lang_Reflect() {
+
+	// --- java.lang.reflect.Method ---
+
+	MethodSignatures["java/lang/reflect/Method.getDeclaringClass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetDeclaringClass,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.getModifiers()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetModifiers,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetName,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.getParameterCount()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetParameterCount,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.getParameterTypes()[Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetParameterTypes,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.getReturnType()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  methodGetReturnType,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.invoke(Ljava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    methodInvoke,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/reflect/Method.setAccessible(Z)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  reflectSetAccessible,
+		}
+
+	// --- java.lang.reflect.Constructor ---
+
+	MethodSignatures["java/lang/reflect/Constructor.getDeclaringClass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetDeclaringClass,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.getModifiers()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetModifiers,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetName,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.getParameterCount()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetParameterCount,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.getParameterTypes()[Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetParameterTypes,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.newInstance([Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    constructorNewInstance,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/reflect/Constructor.setAccessible(Z)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  reflectSetAccessible,
+		}
+
+	// --- java.lang.reflect.Field ---
+
+	MethodSignatures["java/lang/reflect/Field.get(Ljava/lang/Object;)Ljava/lang/Object;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    fieldGet,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/reflect/Field.getDeclaringClass()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetDeclaringClass,
+		}
+
+	MethodSignatures["java/lang/reflect/Field.getModifiers()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetModifiers,
+		}
+
+	MethodSignatures["java/lang/reflect/Field.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  reflectGetName,
+		}
+
+	MethodSignatures["java/lang/reflect/Field.getType()Ljava/lang/Class;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  fieldGetType,
+		}
+
+	MethodSignatures["java/lang/reflect/Field.set(Ljava/lang/Object;Ljava/lang/Object;)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    fieldSet,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/reflect/Field.setAccessible(Z)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  reflectSetAccessible,
+		}
+
+	// --- java.lang.reflect.AccessibleObject ---
+
+	MethodSignatures["java/lang/reflect/AccessibleObject.setAccessible(Z)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  reflectSetAccessible,
+		}
+}
+
+// makeMember creates a Method, Constructor, or Field object
+func makeMember(memberClassName, declaringClass, name, desc string, modifiers int) *object.Object {
+	member := object.MakeEmptyObjectWithClassName(&memberClassName)
+	member.FieldTable["clazz"] = object.Field{Ftype: types.Ref, Fvalue: makeClassObject(declaringClass)}
+	member.FieldTable["name"] = object.Field{Ftype: types.Ref, Fvalue: object.StringObjectFromGoString(name)}
+	member.FieldTable["descriptor"] = object.Field{Ftype: types.GolangString, Fvalue: desc}
+	member.FieldTable["modifiers"] = object.Field{Ftype: types.Int, Fvalue: int64(modifiers)}
+	member.FieldTable["override"] = object.Field{Ftype: types.Bool, Fvalue: types.JavaBoolFalse}
+	return member
+}
+
+// makeMethodObject returns a Method object for a method of the named class
+func makeMethodObject(className, name, desc string, modifiers int) *object.Object {
+	return makeMember(methodClassName, className, name, desc, modifiers)
+}
+
+// makeConstructorObject returns a Constructor object for a constructor of the named
+// class. As in HotSpot, its name is the name of the class.
+func makeConstructorObject(className, desc string, modifiers int) *object.Object {
+	return makeMember(constructorClassName, className, strings.ReplaceAll(className, "/", "."), desc, modifiers)
+}
+
+// makeFieldObject returns a Field object for a field of the named class
+func makeFieldObject(className, name, desc string, modifiers int) *object.Object {
+	return makeMember(fieldClassName, className, name, desc, modifiers)
+}
+
+// the accessors of the parts of a Method, Constructor, or Field object
+func memberClassName(member *object.Object) string {
+	return getClassName(member.FieldTable["clazz"].Fvalue.(*object.Object))
+}
+
+func memberName(member *object.Object) string {
+	return object.GoStringFromStringObject(member.FieldTable["name"].Fvalue.(*object.Object))
+}
+
+func memberDescriptor(member *object.Object) string {
+	return member.FieldTable["descriptor"].Fvalue.(string)
+}
+
+func memberModifiers(member *object.Object) int64 {
+	return member.FieldTable["modifiers"].Fvalue.(int64)
+}
+
+// "java/lang/reflect/Method.getDeclaringClass()Ljava/lang/Class;" and the same method of
+// Constructor and Field
+func reflectGetDeclaringClass(params []interface{}) interface{} {
+	member := params[0].(*object.Object)
+	return makeClassObject(memberClassName(member))
+}
+
+// "java/lang/reflect/Method.getModifiers()I" and the same method of Constructor and Field
+func reflectGetModifiers(params []interface{}) interface{} {
+	return memberModifiers(params[0].(*object.Object))
+}
+
+// "java/lang/reflect/Method.getName()Ljava/lang/String;" and the same method of Constructor and Field
+func reflectGetName(params []interface{}) interface{} {
+	member := params[0].(*object.Object)
+	return member.FieldTable["name"].Fvalue
+}
+
+// "java/lang/reflect/Method.getParameterCount()I" and the same method of Constructor
+func reflectGetParameterCount(params []interface{}) interface{} {
+	paramDescs, _, _ := util.ParseMethodDescriptor(memberDescriptor(params[0].(*object.Object)))
+	return int64(len(paramDescs))
+}
+
+// "java/lang/reflect/Method.getParameterTypes()[Ljava/lang/Class;" and the same method of
+// Constructor. Each call returns a new array.
+func reflectGetParameterTypes(params []interface{}) interface{} {
+	paramDescs, _, _ := util.ParseMethodDescriptor(memberDescriptor(params[0].(*object.Object)))
+	paramTypes := make([]*object.Object, len(paramDescs))
+	for i, desc := range paramDescs {
+		paramTypes[i] = classObjectForDescriptor(desc)
+	}
+	return makeRefArray(classClassName, paramTypes)
+}
+
+// "java/lang/reflect/Method.setAccessible(Z)V" and the same method of Constructor, Field, and
+// AccessibleObject
+func reflectSetAccessible(params []interface{}) interface{} {
+	member := params[0].(*object.Object)
+	member.FieldTable["override"] = object.Field{Ftype: types.Bool, Fvalue: params[1].(int64)}
+	return nil
+}
+
+// "java/lang/reflect/Method.getReturnType()Ljava/lang/Class;"
+func methodGetReturnType(params []interface{}) interface{} {
+	_, retDesc, _ := util.ParseMethodDescriptor(memberDescriptor(params[0].(*object.Object)))
+	return classObjectForDescriptor(retDesc)
+}
+
+// "java/lang/reflect/Method.invoke(Ljava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;"
+// invokes the method on an object, or, if the method is static, on its class, which is first
+// initialized. As in HotSpot, an instance method is looked up in the object's class, so that
+// overriding methods are invoked. The result is boxed, and it's null for a void method.
+func methodInvoke(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	method := params[1].(*object.Object)
+	className := memberClassName(method)
+	name := memberName(method)
+	desc := memberDescriptor(method)
+	paramDescs, retDesc, _ := util.ParseMethodDescriptor(desc)
+
+	args, errBlk := unboxArgs(paramDescs, params[3])
+	if errBlk != nil {
+		return errBlk
+	}
+
+	glob := globals.GetGlobalRef()
+	var ret any
+	var err error
+	if memberModifiers(method)&modifierStatic != 0 {
+		if errBlk = initializeClass(fs, className); errBlk != nil {
+			return errBlk
+		}
+		ret, err = glob.FuncInvokeMethod(fs, className, name, desc, args)
+	} else {
+		obj, ok := params[2].(*object.Object)
+		if !ok || object.IsNull(obj) {
+			errMsg := fmt.Sprintf("Cannot invoke %s.%s() on a null object",
+				util.ConvertInternalClassNameToUserFormat(className), name)
+			return getGErrBlk(excNames.NullPointerException, errMsg)
+		}
+		objClassName := *stringPool.GetStringPointer(obj.KlassName)
+		if !isAssignableTo(objClassName, className) {
+			return getGErrBlk(excNames.IllegalArgumentException, "object is not an instance of declaring class")
+		}
+
+		// private methods aren't overridden, and default methods are found in their interface
+		if memberModifiers(method)&modifierPrivate == 0 {
+			if _, err = classloader.FetchMethodAndCP(objClassName, name, desc); err == nil {
+				className = objClassName
+			}
+		}
+		ret, err = glob.FuncInvokeMethod(fs, className, name, desc, append([]any{obj}, args...))
+	}
+
+	if err != nil {
+		return invocationError(fs, "Method.invoke()", err)
+	}
+	return boxValue(retDesc, ret)
+}
+
+// "java/lang/reflect/Constructor.newInstance([Ljava/lang/Object;)Ljava/lang/Object;" creates an
+// instance of the constructor's class and runs the constructor on it
+func constructorNewInstance(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	ctor := params[1].(*object.Object)
+	className := memberClassName(ctor)
+	desc := memberDescriptor(ctor)
+	paramDescs, _, _ := util.ParseMethodDescriptor(desc)
+
+	k, errBlk := loadClassForReflection(className)
+	if errBlk != nil {
+		return errBlk
+	}
+	if k.Data.Access.ClassIsAbstract || k.Data.Access.ClassIsInterface {
+		return getGErrBlk(excNames.InstantiationException, util.ConvertInternalClassNameToUserFormat(className))
+	}
+
+	args, errBlk := unboxArgs(paramDescs, params[2])
+	if errBlk != nil {
+		return errBlk
+	}
+
+	glob := globals.GetGlobalRef()
+	obj, err := glob.FuncInstantiateClass(className, fs)
+	if err == nil {
+		_, err = glob.FuncInvokeMethod(fs, className, "<init>", desc, append([]any{obj}, args...))
+	}
+	if err != nil {
+		return invocationError(fs, "Constructor.newInstance()", err)
+	}
+	return obj
+}
+
+// invocationError returns the result of Method.invoke() or Constructor.newInstance() when
+// the invoked method fails. An exception thrown by the method is wrapped in a new
+// InvocationTargetException, which is rethrown by RunGfunction(). As in the constructor
+// InvocationTargetException(Throwable), the exception is the target, and so the cause.
+func invocationError(fs *list.List, caller string, err error) interface{} {
+	var javaEx *exceptions.JavaException
+	if !errors.As(err, &javaEx) {
+		return getGErrBlk(excNames.InternalException, caller+": "+err.Error())
+	}
+
+	glob := globals.GetGlobalRef()
+	ref, err := glob.FuncInstantiateClass(invocationTargetExceptionClassName, fs)
+	if err != nil {
+		return getGErrBlk(excNames.InternalException, caller+": "+err.Error())
+	}
+	wrapper := ref.(*object.Object)
+	wrapper.FieldTable["target"] = object.Field{Ftype: types.Ref, Fvalue: javaEx.Throwable}
+	wrapper.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: javaEx.Throwable}
+	glob.FuncFillInStackTrace([]any{fs, wrapper})
+	return &exceptions.JavaException{Throwable: wrapper}
+}
+
+// "java/lang/reflect/Field.getType()Ljava/lang/Class;"
+func fieldGetType(params []interface{}) interface{} {
+	return classObjectForDescriptor(memberDescriptor(params[0].(*object.Object)))
+}
+
+// "java/lang/reflect/Field.get(Ljava/lang/Object;)Ljava/lang/Object;" returns the value of the
+// field in an object, or, if the field is static, in its class. A primitive value is boxed.
+func fieldGet(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	field := params[1].(*object.Object)
+	desc := memberDescriptor(field)
+
+	if memberModifiers(field)&modifierStatic != 0 {
+		static, errBlk := staticField(fs, field)
+		if errBlk != nil {
+			return errBlk
+		}
+		return boxValue(desc, static.Value)
+	}
+
+	obj, errBlk := fieldHolder(field, params[2])
+	if errBlk != nil {
+		return errBlk
+	}
+	return boxValue(desc, obj.FieldTable[memberName(field)].Fvalue)
+}
+
+// "java/lang/reflect/Field.set(Ljava/lang/Object;Ljava/lang/Object;)V" sets the value of the
+// field in an object, or, if the field is static, in its class. A primitive value is unboxed.
+// As in HotSpot, a final field can be set only if it's not static and setAccessible(true)
+// has been called.
+func fieldSet(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	field := params[1].(*object.Object)
+	name := memberName(field)
+	desc := memberDescriptor(field)
+	modifiers := memberModifiers(field)
+
+	isStatic := modifiers&modifierStatic != 0
+	if modifiers&modifierFinal != 0 &&
+		(isStatic || field.FieldTable["override"].Fvalue.(int64) == types.JavaBoolFalse) {
+		errMsg := fmt.Sprintf("Can not set final field %s.%s",
+			util.ConvertInternalClassNameToUserFormat(memberClassName(field)), name)
+		return getGErrBlk(excNames.IllegalAccessException, errMsg)
+	}
+
+	value, ok := unboxValue(desc, params[3])
+	if !ok {
+		errMsg := fmt.Sprintf("Can not set field %s.%s to the given value",
+			util.ConvertInternalClassNameToUserFormat(memberClassName(field)), name)
+		return getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	}
+
+	if isStatic {
+		if _, errBlk := staticField(fs, field); errBlk != nil {
+			return errBlk
+		}
+		_ = statics.AddStatic(memberClassName(field)+"."+name, statics.Static{Type: desc, Value: value})
+		return nil
+	}
+
+	obj, errBlk := fieldHolder(field, params[2])
+	if errBlk != nil {
+		return errBlk
+	}
+	obj.FieldTable[name] = object.Field{Ftype: obj.FieldTable[name].Ftype, Fvalue: value}
+	return nil
+}
+
+// staticField returns the entry in the statics table for a static field, first
+// initializing the field's class if need be
+func staticField(fs *list.List, field *object.Object) (statics.Static, *GErrBlk) {
+	className := memberClassName(field)
+	fieldName := className + "." + memberName(field)
+	static, ok := statics.QueryStatic(fieldName)
+	if !ok {
+		if _, err := globals.GetGlobalRef().FuncInstantiateClass(className, fs); err == nil {
+			static, ok = statics.QueryStatic(fieldName)
+		}
+	}
+	if !ok {
+		errMsg := fmt.Sprintf("static field %s not found", util.ConvertInternalClassNameToUserFormat(fieldName))
+		return static, getGErrBlk(excNames.NoSuchFieldError, errMsg)
+	}
+	return static, nil
+}
+
+// fieldHolder checks that the object passed to Field.get() or set() holds the field
+func fieldHolder(field *object.Object, holder any) (*object.Object, *GErrBlk) {
+	className := memberClassName(field)
+	name := memberName(field)
+	obj, ok := holder.(*object.Object)
+	if !ok || object.IsNull(obj) {
+		errMsg := fmt.Sprintf("Cannot access field %s.%s of a null object",
+			util.ConvertInternalClassNameToUserFormat(className), name)
+		return nil, getGErrBlk(excNames.NullPointerException, errMsg)
+	}
+	if _, ok = obj.FieldTable[name]; !ok || !isAssignableTo(*stringPool.GetStringPointer(obj.KlassName), className) {
+		errMsg := fmt.Sprintf("Can not access field %s.%s of %s",
+			util.ConvertInternalClassNameToUserFormat(className), name,
+			util.ConvertInternalClassNameToUserFormat(*stringPool.GetStringPointer(obj.KlassName)))
+		return nil, getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	}
+	return obj, nil
+}
+
+// unboxArgs converts the arguments passed to Method.invoke() or Constructor.newInstance()
+// in an Object array to the types of the parameters. A null array stands for no arguments.
+func unboxArgs(paramDescs []string, argArray any) ([]any, *GErrBlk) {
+	var argObjs []*object.Object
+	if array, ok := argArray.(*object.Object); ok && !object.IsNull(array) {
+		argObjs = array.FieldTable["value"].Fvalue.([]*object.Object)
+	}
+	if len(argObjs) != len(paramDescs) {
+		errMsg := fmt.Sprintf("wrong number of arguments: %d expected: %d", len(argObjs), len(paramDescs))
+		return nil, getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	}
+
+	args := make([]any, len(paramDescs))
+	for i, desc := range paramDescs {
+		arg, ok := unboxValue(desc, argObjs[i])
+		if !ok {
+			return nil, getGErrBlk(excNames.IllegalArgumentException, "argument type mismatch")
+		}
+		args[i] = arg
+	}
+	return args, nil
+}
+
+// boxValue returns a value of the type given by a field descriptor as an object: a primitive
+// value is boxed in its wrapper object, and a reference is returned as is. The value of a
+// void method (whose type is V) is null.
+func boxValue(desc string, value any) any {
+	wrapper, ok := wrapperClassNames[desc]
+	if !ok {
+		if desc == "V" || value == nil {
+			return object.Null
+		}
+		return value
+	}
+
+	switch v := value.(type) {
+	case bool:
+		value = types.ConvertGoBoolToJavaBool(v)
+	case int:
+		value = int64(v)
+	case float32:
+		value = float64(v)
+	}
+	return object.MakePrimitiveObject(wrapper, desc, value)
+}
+
+// unboxValue converts an object to the type given by a field descriptor, as reflection does
+// when passing arguments and setting fields: a primitive is unboxed from its wrapper object,
+// with any widening conversion that's needed, and a reference must be null or an instance of
+// the type. (For arrays, only the fact that the object is an array is checked.) The result
+// is false if the object can't be converted.
+func unboxValue(desc string, arg any) (any, bool) {
+	obj, _ := arg.(*object.Object)
+	if _, primitive := wrapperClassNames[desc]; !primitive {
+		if object.IsNull(obj) {
+			return object.Null, true
+		}
+		className := *stringPool.GetStringPointer(obj.KlassName)
+		if strings.HasPrefix(desc, types.Array) {
+			return obj, strings.HasPrefix(className, types.Array)
+		}
+		return obj, isAssignableTo(className, strings.TrimSuffix(desc[1:], ";"))
+	}
+
+	if object.IsNull(obj) {
+		return nil, false
+	}
+	var from string
+	className := *stringPool.GetStringPointer(obj.KlassName)
+	for primitive, wrapper := range wrapperClassNames {
+		if wrapper == className {
+			from = primitive
+		}
+	}
+	if from == "" || !strings.Contains(wideningConversions[from], desc) {
+		return nil, false
+	}
+
+	value := obj.FieldTable["value"].Fvalue
+	switch v := value.(type) {
+	case bool:
+		return types.ConvertGoBoolToJavaBool(v), true
+	case int64:
+		if types.IsFloatingPoint(desc) {
+			return float64(v), true
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,567 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"strings"
+)
+
+// The generic types returned by Class.getGenericSuperclass() and getGenericInterfaces(),
+// which are parsed from the class's generic signature--the Signature attribute that javac
+// writes for classes that have type parameters or generic supertypes. (The format of
+// signatures is given here: https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-4.html#jvms-4.7.9.1)
+//
+// As in HotSpot, a type that's not generic is represented by its Class object. The generic
+// types--java.lang.reflect.ParameterizedType, TypeVariable, WildcardType, and GenericArrayType--
+// are represented by instances of classes that Jacobin spins, using the names of HotSpot's
+// classes, whose methods are G functions. The parts of a type are kept in the object's fields.
+
+var typeClassName = "java/lang/reflect/Type"
+
+var parameterizedTypeClassName = "sun/reflect/generics/reflectiveObjects/ParameterizedTypeImpl"
+var typeVariableClassName = "sun/reflect/generics/reflectiveObjects/TypeVariableImpl"
+var wildcardTypeClassName = "sun/reflect/generics/reflectiveObjects/WildcardTypeImpl"
+var genericArrayTypeClassName = "sun/reflect/generics/reflectiveObjects/GenericArrayTypeImpl"
+
+func Load_Lang_Reflect_Type() {
+
+	MethodSignatures["java/lang/Class.getGenericInterfaces()[Ljava/lang/reflect/Type;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetGenericInterfaces,
+		}
+
+	MethodSignatures["java/lang/Class.getGenericSuperclass()Ljava/lang/reflect/Type;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  classGetGenericSuperclass,
+		}
+}
+
+// "java/lang/Class.getGenericSuperclass()Ljava/lang/reflect/Type;" returns the superclass
+// as it's declared in the source code: a ParameterizedType if it has type arguments, such
+// as AbstractList<String>, and otherwise the same Class object as getSuperclass()
+func classGetGenericSuperclass(params []interface{}) interface{} {
+	k, errBlk := genericClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil || k.Data.Access.ClassIsInterface {
+		return classGetSuperclass(params)
+	}
+	superclass, _, errBlk := parseClassSignature(k)
+	if errBlk != nil {
+		return errBlk
+	}
+	return superclass
+}
+
+// "java/lang/Class.getGenericInterfaces()[Ljava/lang/reflect/Type;" returns the interfaces
+// implemented by the class as they're declared in the source code, as for getGenericSuperclass()
+func classGetGenericInterfaces(params []interface{}) interface{} {
+	k, errBlk := genericClass(params[0].(*object.Object))
+	if errBlk != nil {
+		return errBlk
+	}
+	if k == nil {
+		interfaces := classGetInterfaces(params)
+		if array, ok := interfaces.(*object.Object); ok {
+			return makeRefArray(typeClassName, array.FieldTable["value"].Fvalue.([]*object.Object))
+		}
+		return interfaces
+	}
+	_, interfaces, errBlk := parseClassSignature(k)
+	if errBlk != nil {
+		return errBlk
+	}
+	return makeRefArray(typeClassName, interfaces)
+}
+
+// genericClass returns the class represented by a Class object if it has a generic
+// signature, or nil if it doesn't, or is an array or a primitive type
+func genericClass(classObj *object.Object) (*classloader.Klass, *GErrBlk) {
+	k, errBlk := reflectedClass(classObj)
+	if errBlk != nil || k == nil || k.Data.Signature == "" {
+		return nil, errBlk
+	}
+	return k, nil
+}
+
+// parseClassSignature returns the generic superclass and interfaces of a class
+// from its signature:
+//
+//	ClassSignature: [TypeParameters] SuperclassSignature {SuperinterfaceSignature}
+func parseClassSignature(k *classloader.Klass) (*object.Object, []*object.Object, *GErrBlk) {
+	p := signatureParser{signature: k.Data.Signature, declaration: k.Data.Name}
+	p.typeParameters()
+	superclass := p.classType()
+	var interfaces []*object.Object
+	for p.err == nil && p.pos < len(p.signature) {
+		interfaces = append(interfaces, p.classType())
+	}
+	if p.err != nil {
+		return nil, nil, p.formatError()
+	}
+	if k.Data.Access.ClassIsInterface {
+		superclass = object.Null
+	}
+	return superclass, interfaces, nil
+}
+
+// signatureParser parses a generic signature into generic type objects
+type signatureParser struct {
+	signature   string
+	pos         int
+	declaration string // the class whose signature is being parsed
+	err         error
+}
+
+// typeParameter is the declaration of a type variable: its name and its bounds
+type typeParameter struct {
+	name   string
+	bounds []*object.Object
+}
+
+func (p *signatureParser) peek() byte {
+	if p.err != nil || p.pos >= len(p.signature) {
+		return 0
+	}
+	return p.signature[p.pos]
+}
+
+func (p *signatureParser) expect(c byte) {
+	if p.peek() != c {
+		p.fail(fmt.Sprintf("expected %q", c))
+		return
+	}
+	p.pos += 1
+}
+
+func (p *signatureParser) fail(msg string) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%s at position %d", msg, p.pos)
+	}
+}
+
+// identifier reads a name, which ends at one of the characters that separate names
+func (p *signatureParser) identifier() string {
+	start := p.pos
+	for p.err == nil && p.pos < len(p.signature) && !strings.ContainsRune(".;[/<>:", rune(p.signature[p.pos])) {
+		p.pos += 1
+	}
+	if p.pos == start {
+		p.fail("expected an identifier")
+	}
+	return p.signature[start:p.pos]
+}
+
+// typeParameters reads the declarations of type parameters, if any:
+//
+//	TypeParameters: < TypeParameter {TypeParameter} >
+//	TypeParameter: Identifier : [ReferenceTypeSignature] {: ReferenceTypeSignature}
+//
+// As in HotSpot, a type variable that has no bounds is bounded by Object.
+func (p *signatureParser) typeParameters() []typeParameter {
+	var parameters []typeParameter
+	if p.peek() != '<' {
+		return parameters
+	}
+	p.expect('<')
+	for p.err == nil && p.peek() != '>' {
+		parameter := typeParameter{name: p.identifier()}
+		p.expect(':')
+		if c := p.peek(); c != ':' && c != '>' && p.err == nil {
+			parameter.bounds = append(parameter.bounds, p.referenceType())
+		}
+		for p.peek() == ':' {
+			p.expect(':')
+			parameter.bounds = append(parameter.bounds, p.referenceType())
+		}
+		if len(parameter.bounds) == 0 {
+			parameter.bounds = []*object.Object{makeClassObject(types.ObjectClassName)}
+		}
+		parameters = append(parameters, parameter)
+	}
+	p.expect('>')
+	return parameters
+}
+
+// referenceType reads the signature of a class, type variable, or array type
+func (p *signatureParser) referenceType() *object.Object {
+	switch p.peek() {
+	case 'L':
+		return p.classType()
+	case 'T':
+		p.expect('T')
+		name := p.identifier()
+		p.expect(';')
+		return makeTypeVariable(name, p.declaration)
+	case '[':
+		p.expect('[')
+		component := p.javaType()
+		if p.err != nil {
+			return object.Null
+		}
+		if isClassObject(component) { // an array of a class or a primitive type is a class
+			return makeClassObject("[" + descriptorOfClass(getClassName(component)))
+		}
+		array := object.MakeEmptyObjectWithClassName(&genericArrayTypeClassName)
+		array.FieldTable["genericComponentType"] = object.Field{Ftype: types.Ref, Fvalue: component}
+		spinGenericTypeClasses()
+		return array
+	}
+	p.fail("expected a reference type")
+	return object.Null
+}
+
+// javaType reads the signature of a reference type or a primitive type
+func (p *signatureParser) javaType() *object.Object {
+	if name, ok := primitiveClassNames[string(p.peek())]; ok && p.peek() != 'V' {
+		p.pos += 1
+		return makeClassObject(name)
+	}
+	return p.referenceType()
+}
+
+// classType reads the signature of a class type, which names the class and gives its type
+// arguments, if any, and those of the classes it's nested in:
+//
+//	ClassTypeSignature: L [PackageSpecifier] SimpleClassTypeSignature {. SimpleClassTypeSignature} ;
+//	SimpleClassTypeSignature: Identifier [TypeArguments]
+func (p *signatureParser) classType() *object.Object {
+	p.expect('L')
+	rawName := p.identifier()
+	for p.peek() == '/' {
+		p.expect('/')
+		rawName += "/" + p.identifier()
+	}
+
+	var classType *object.Object
+	var owner *object.Object
+	for p.err == nil {
+		arguments := p.typeArguments()
+		if len(arguments) > 0 || isParameterizedType(owner) {
+			classType = makeParameterizedType(rawName, arguments, owner)
+		} else {
+			classType = makeClassObject(rawName)
+		}
+		if p.peek() != '.' {
+			break
+		}
+		p.expect('.')
+		owner = classType
+		rawName += "$" + p.identifier()
+	}
+	p.expect(';')
+	if p.err != nil {
+		return object.Null
+	}
+	return classType
+}
+
+// typeArguments reads the type arguments of a class, if any:
+//
+//	TypeArguments: < TypeArgument {TypeArgument} >
+//	TypeArgument: [+|-] ReferenceTypeSignature | *
+func (p *signatureParser) typeArguments() []*object.Object {
+	var arguments []*object.Object
+	if p.peek() != '<' {
+		return arguments
+	}
+	p.expect('<')
+	for p.err == nil && p.peek() != '>' {
+		var upper, lower []*object.Object
+		switch p.peek() {
+		case '*':
+			p.expect('*')
+			upper = []*object.Object{makeClassObject(types.ObjectClassName)}
+		case '+':
+			p.expect('+')
+			upper = []*object.Object{p.referenceType()}
+		case '-':
+			p.expect('-')
+			upper = []*object.Object{makeClassObject(types.ObjectClassName)}
+			lower = []*object.Object{p.referenceType()}
+		default:
+			arguments = append(arguments, p.referenceType())
+			continue
+		}
+		wildcard := object.MakeEmptyObjectWithClassName(&wildcardTypeClassName)
+		wildcard.FieldTable["upperBounds"] = object.Field{Ftype: types.Ref, Fvalue: makeRefArray(typeClassName, upper)}
+		wildcard.FieldTable["lowerBounds"] = object.Field{Ftype: types.Ref, Fvalue: makeRefArray(typeClassName, lower)}
+		spinGenericTypeClasses()
+		arguments = append(arguments, wildcard)
+	}
+	p.expect('>')
+	return arguments
+}
+
+// formatError returns the error thrown for a signature that can't be parsed, which in
+// HotSpot is a GenericSignatureFormatError, a subclass of ClassFormatError
+func (p *signatureParser) formatError() *GErrBlk {
+	errMsg := fmt.Sprintf("Signature Parse error: %s in signature %s of class %s",
+		p.err.Error(), p.signature, util.ConvertInternalClassNameToUserFormat(p.declaration))
+	return getGErrBlk(excNames.ClassFormatError, errMsg)
+}
+
+// makeParameterizedType returns a ParameterizedType for a class and its type arguments. As
+// in HotSpot, the owner of a nested class that's not given in the signature is the class
+// in which it's declared.
+func makeParameterizedType(rawName string, arguments []*object.Object, owner *object.Object) *object.Object {
+	if owner == nil {
+		owner = object.Null
+		if k, errBlk := loadClassForReflection(rawName); errBlk == nil {
+			if entry := innerClassEntry(k); entry != nil && entry.OuterName != "" {
+				owner = makeClassObject(entry.OuterName)
+			}
+		}
+	}
+	parameterized := object.MakeEmptyObjectWithClassName(&parameterizedTypeClassName)
+	parameterized.FieldTable["rawType"] = object.Field{Ftype: types.Ref, Fvalue: makeClassObject(rawName)}
+	parameterized.FieldTable["ownerType"] = object.Field{Ftype: types.Ref, Fvalue: owner}
+	parameterized.FieldTable["actualTypeArguments"] =
+		object.Field{Ftype: types.Ref, Fvalue: makeRefArray(typeClassName, arguments)}
+	spinGenericTypeClasses()
+	return parameterized
+}
+
+// makeTypeVariable returns a TypeVariable for a type variable used in the signature of the
+// named class. The class that declares the variable is found when it's needed, since the
+// signature may still be being parsed.
+func makeTypeVariable(name, signatureClass string) *object.Object {
+	variable := object.MakeEmptyObjectWithClassName(&typeVariableClassName)
+	variable.FieldTable["name"] = object.Field{Ftype: types.GolangString, Fvalue: name}
+	variable.FieldTable["signatureClass"] = object.Field{Ftype: types.GolangString, Fvalue: signatureClass}
+	spinGenericTypeClasses()
+	return variable
+}
+
+// genericDeclaration returns the name of the class that declares a type variable
+func genericDeclaration(variable *object.Object) string {
+	return typeVariableDeclaration(variable.FieldTable["signatureClass"].Fvalue.(string),
+		variable.FieldTable["name"].Fvalue.(string))
+}
+
+// typeVariableDeclaration returns the name of the class that declares a type variable used
+// in the signature of the named class: the class itself or the innermost class that
+// encloses it and declares the variable. (The declarations of generic methods that enclose
+// local classes are not considered.)
+func typeVariableDeclaration(className, name string) string {
+	for declaration := className; declaration != ""; {
+		k := classloader.MethAreaFetch(declaration)
+		if k == nil || k.Data == nil {
+			break
+		}
+		if _, ok := typeParameterOf(k, name); ok {
+			return declaration
+		}
+		declaration = enclosingClassName(k)
+	}
+	return className
+}
+
+// typeParameterOf returns the declaration of a type variable of the class, if it has one
+func typeParameterOf(k *classloader.Klass, name string) (typeParameter, bool) {
+	if k.Data.Signature == "" {
+		return typeParameter{}, false
+	}
+	p := signatureParser{signature: k.Data.Signature, declaration: k.Data.Name}
+	for _, parameter := range p.typeParameters() {
+		if parameter.name == name {
+			return parameter, true
+		}
+	}
+	return typeParameter{}, false
+}
+
+// isParameterizedType reports whether an object is a ParameterizedType
+func isParameterizedType(obj *object.Object) bool {
+	return !object.IsNull(obj) && *stringPool.GetStringPointer(obj.KlassName) == parameterizedTypeClassName
+}
+
+// isClassObject reports whether an object is a Class object, rather than a generic type
+func isClassObject(obj *object.Object) bool {
+	return !object.IsNull(obj) && *stringPool.GetStringPointer(obj.KlassName) == classClassName
+}
+
+// spinGenericTypeClasses spins the classes of the generic types, if they haven't been spun
+func spinGenericTypeClasses() {
+	if k := classloader.MethAreaFetch(parameterizedTypeClassName); k != nil && k.Data.Access.ClassIsSynthetic {
+		return
+	}
+
+	common := []spunMethod{
+		{"equals", "(Ljava/lang/Object;)Z", GMeth{ParamSlots: 1, GFunction: genericTypeEquals}},
+		{"getTypeName", "()Ljava/lang/String;", GMeth{ParamSlots: 0, GFunction: genericTypeGetTypeName}},
+		{"hashCode", "()I", GMeth{ParamSlots: 0, GFunction: genericTypeHashCode}},
+		{"toString", "()Ljava/lang/String;", GMeth{ParamSlots: 0, GFunction: genericTypeGetTypeName}},
+	}
+	getter := func(name, desc, field string) spunMethod {
+		return spunMethod{name, desc, GMeth{ParamSlots: 0, GFunction: func(params []interface{}) interface{} {
+			return genericTypeField(params[0].(*object.Object), field)
+		}}}
+	}
+
+	spinClass(genericArrayTypeClassName, "bootstrap",
+		[]string{"java/lang/reflect/GenericArrayType", typeClassName},
+		append([]spunMethod{
+			getter("getGenericComponentType", "()Ljava/lang/reflect/Type;", "genericComponentType"),
+		}, common...))
+	spinClass(wildcardTypeClassName, "bootstrap",
+		[]string{"java/lang/reflect/WildcardType", typeClassName},
+		append([]spunMethod{
+			getter("getLowerBounds", "()[Ljava/lang/reflect/Type;", "lowerBounds"),
+			getter("getUpperBounds", "()[Ljava/lang/reflect/Type;", "upperBounds"),
+		}, common...))
+	spinClass(typeVariableClassName, "bootstrap",
+		[]string{"java/lang/reflect/TypeVariable", typeClassName},
+		append([]spunMethod{
+			{"getBounds", "()[Ljava/lang/reflect/Type;", GMeth{ParamSlots: 0, GFunction: typeVariableGetBounds}},
+			{"getGenericDeclaration", "()Ljava/lang/reflect/GenericDeclaration;",
+				GMeth{ParamSlots: 0, GFunction: typeVariableGetGenericDeclaration}},
+			{"getName", "()Ljava/lang/String;", GMeth{ParamSlots: 0, GFunction: genericTypeGetTypeName}},
+		}, common...))
+	spinClass(parameterizedTypeClassName, "bootstrap",
+		[]string{"java/lang/reflect/ParameterizedType", typeClassName},
+		append([]spunMethod{
+			getter("getActualTypeArguments", "()[Ljava/lang/reflect/Type;", "actualTypeArguments"),
+			getter("getOwnerType", "()Ljava/lang/reflect/Type;", "ownerType"),
+			getter("getRawType", "()Ljava/lang/reflect/Type;", "rawType"),
+		}, common...))
+}
+
+// genericTypeField returns a field of a generic type object. Arrays are copied, as in HotSpot.
+func genericTypeField(obj *object.Object, name string) interface{} {
+	value := obj.FieldTable[name].Fvalue
+	if array, ok := value.(*object.Object); ok && !object.IsNull(array) &&
+		strings.HasPrefix(*stringPool.GetStringPointer(array.KlassName), "[") {
+		return makeRefArray(typeClassName, array.FieldTable["value"].Fvalue.([]*object.Object))
+	}
+	return value
+}
+
+// "getGenericDeclaration()Ljava/lang/reflect/GenericDeclaration;" of a TypeVariable returns
+// the class that declares the variable
+func typeVariableGetGenericDeclaration(params []interface{}) interface{} {
+	return makeClassObject(genericDeclaration(params[0].(*object.Object)))
+}
+
+// "getBounds()[Ljava/lang/reflect/Type;" of a TypeVariable returns the bounds declared for
+// the variable by its generic declaration
+func typeVariableGetBounds(params []interface{}) interface{} {
+	variable := params[0].(*object.Object)
+	declaration := genericDeclaration(variable)
+	bounds := []*object.Object{makeClassObject(types.ObjectClassName)}
+	if k := classloader.MethAreaFetch(declaration); k != nil && k.Data != nil {
+		if parameter, ok := typeParameterOf(k, variable.FieldTable["name"].Fvalue.(string)); ok {
+			bounds = parameter.bounds
+		}
+	}
+	return makeRefArray(typeClassName, bounds)
+}
+
+// "getTypeName()Ljava/lang/String;" and "toString()Ljava/lang/String;" of a generic type
+func genericTypeGetTypeName(params []interface{}) interface{} {
+	return object.StringObjectFromGoString(typeName(params[0].(*object.Object)))
+}
+
+// "equals(Ljava/lang/Object;)Z" of a generic type: two types are equal if they're of the
+// same kind and have the same parts, which is shown by their names, and, for type
+// variables, have the same generic declaration
+func genericTypeEquals(params []interface{}) interface{} {
+	this := params[0].(*object.Object)
+	other, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(other) || other.KlassName != this.KlassName {
+		return types.JavaBoolFalse
+	}
+	if this.KlassName == stringPool.GetStringIndex(&typeVariableClassName) &&
+		genericDeclaration(this) != genericDeclaration(other) {
+		return types.JavaBoolFalse
+	}
+	return types.ConvertGoBoolToJavaBool(typeName(this) == typeName(other))
+}
+
+// "hashCode()I" of a generic type, which is consistent with equals()
+func genericTypeHashCode(params []interface{}) interface{} {
+	return int64(javaStringHash(typeName(params[0].(*object.Object))))
+}
+
+// typeName returns the name of a type as it's given by Type.getTypeName(): for example,
+// java.lang.String[], java.util.Map$Entry<K, V>, or ? extends java.lang.Number
+func typeName(obj *object.Object) string {
+	if object.IsNull(obj) {
+		return "null"
+	}
+	field := func(name string) *object.Object {
+		return obj.FieldTable[name].Fvalue.(*object.Object)
+	}
+	names := func(array *object.Object) []string {
+		var names []string
+		for _, element := range array.FieldTable["value"].Fvalue.([]*object.Object) {
+			names = append(names, typeName(element))
+		}
+		return names
+	}
+
+	switch *stringPool.GetStringPointer(obj.KlassName) {
+	case parameterizedTypeClassName:
+		rawName := getClassName(field("rawType"))
+		var name string
+		if owner := field("ownerType"); object.IsNull(owner) {
+			name = util.ConvertInternalClassNameToUserFormat(rawName)
+		} else if isParameterizedType(owner) {
+			ownerRawName := getClassName(owner.FieldTable["rawType"].Fvalue.(*object.Object))
+			name = typeName(owner) + "$" + strings.TrimPrefix(rawName, ownerRawName+"$")
+		} else {
+			name = typeName(owner) + "$" + simpleClassName(rawName)
+		}
+		if arguments := names(field("actualTypeArguments")); len(arguments) > 0 {
+			name += "<" + strings.Join(arguments, ", ") + ">"
+		}
+		return name
+	case typeVariableClassName:
+		return obj.FieldTable["name"].Fvalue.(string)
+	case wildcardTypeClassName:
+		if lower := names(field("lowerBounds")); len(lower) > 0 {
+			return "? super " + strings.Join(lower, " & ")
+		}
+		upper := names(field("upperBounds"))
+		if len(upper) == 0 || (len(upper) == 1 && upper[0] == "java.lang.Object") {
+			return "?"
+		}
+		return "? extends " + strings.Join(upper, " & ")
+	case genericArrayTypeClassName:
+		return typeName(field("genericComponentType")) + "[]"
+	}
+
+	// a Class object
+	name := getClassName(obj)
+	if strings.HasPrefix(name, "[") {
+		return typeName(classObjectForDescriptor(name[1:])) + "[]"
+	}
+	return util.ConvertInternalClassNameToUserFormat(name)
+}
+
+// descriptorOfClass returns the field descriptor of the named class, array, or primitive type
+func descriptorOfClass(name string) string {
+	for desc, primitive := range primitiveClassNames {
+		if name == primitive {
+			return desc
+		}
+	}
+	if strings.HasPrefix(name, "[") {
+		return name
+	}
+	return types.Ref + name + ";"
+}
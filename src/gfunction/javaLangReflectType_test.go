@@ -0,0 +1,136 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"jacobin/classloader"
+	"jacobin/object"
+	"jacobin/types"
+	"testing"
+)
+
+// genericTestSetup adds test/Holder, whose signature is that of:
+//
+//	class Holder<K, V extends Comparable<V>> extends HashMap<K, List<? extends Number>>
+//	    implements Supplier<V[]>, Consumer<int[]>, Function<?, ? super K>, Generic<K>.Member<V>
+func genericTestSetup() {
+	reflectTestSetup()
+	holder := reflectTestClass("test/Holder", "java/util/HashMap", classloader.AccessFlags{ClassIsPublic: true}, nil, nil)
+	holder.Data.Signature = "<K:Ljava/lang/Object;V::Ljava/lang/Comparable<TV;>;>" +
+		"Ljava/util/HashMap<TK;Ljava/util/List<+Ljava/lang/Number;>;>;" +
+		"Ljava/util/function/Supplier<[TV;>;" +
+		"Ljava/util/function/Consumer<[I>;" +
+		"Ljava/util/function/Function<*-TK;>;" +
+		"Ltest/Generic<TK;>.Member<TV;>;"
+	reflectTestClass("test/Broken", types.ObjectClassName, classloader.AccessFlags{}, nil, nil).Data.Signature =
+		"Ljava/util/List<Ljava/lang/String;"
+}
+
+func TestGetGenericSuperclass(t *testing.T) {
+	genericTestSetup()
+	superclass := classGetGenericSuperclass([]interface{}{makeClassObject("test/Holder")}).(*object.Object)
+
+	getTypeName := func(obj *object.Object) string {
+		return object.GoStringFromStringObject(invokeSpunMethod(t, obj, "getTypeName()Ljava/lang/String;").(*object.Object))
+	}
+	if name := getTypeName(superclass); name != "java.util.HashMap<K, java.util.List<? extends java.lang.Number>>" {
+		t.Errorf("getGenericSuperclass(): unexpected type %s", name)
+	}
+	if raw := invokeSpunMethod(t, superclass, "getRawType()Ljava/lang/reflect/Type;"); raw != makeClassObject("java/util/HashMap") {
+		t.Errorf("getRawType(): expected HashMap")
+	}
+	if owner := invokeSpunMethod(t, superclass, "getOwnerType()Ljava/lang/reflect/Type;"); !object.IsNull(owner) {
+		t.Errorf("getOwnerType(): expected null for a top-level class")
+	}
+
+	arguments := invokeSpunMethod(t, superclass, "getActualTypeArguments()[Ljava/lang/reflect/Type;").(*object.Object)
+	elements := arguments.FieldTable["value"].Fvalue.([]*object.Object)
+	if len(elements) != 2 {
+		t.Fatalf("getActualTypeArguments(): expected 2 arguments, got %d", len(elements))
+	}
+	k := elements[0]
+	if name := invokeSpunMethod(t, k, "getName()Ljava/lang/String;"); object.GoStringFromStringObject(name.(*object.Object)) != "K" {
+		t.Errorf("getName(): expected K")
+	}
+	if declaration := invokeSpunMethod(t, k, "getGenericDeclaration()Ljava/lang/reflect/GenericDeclaration;"); declaration != makeClassObject("test/Holder") {
+		t.Errorf("getGenericDeclaration(): expected test/Holder")
+	}
+	bounds := invokeSpunMethod(t, k, "getBounds()[Ljava/lang/reflect/Type;").(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(bounds) != 1 || bounds[0] != makeClassObject(types.ObjectClassName) {
+		t.Errorf("getBounds(): expected K to be bounded by Object, got %v", bounds)
+	}
+
+	// the array of arguments is a copy
+	elements[0] = object.Null
+	arguments = invokeSpunMethod(t, superclass, "getActualTypeArguments()[Ljava/lang/reflect/Type;").(*object.Object)
+	if object.IsNull(arguments.FieldTable["value"].Fvalue.([]*object.Object)[0]) {
+		t.Errorf("getActualTypeArguments(): expected a copy of the arguments")
+	}
+
+	// a class without a signature has the same generic superclass as its superclass
+	if classGetGenericSuperclass([]interface{}{makeClassObject("test/Widget")}) != makeClassObject(types.ObjectClassName) {
+		t.Errorf("Widget.getGenericSuperclass(): expected Object")
+	}
+}
+
+func TestGetGenericInterfaces(t *testing.T) {
+	genericTestSetup()
+	interfaces := classGetGenericInterfaces([]interface{}{makeClassObject("test/Holder")}).(*object.Object)
+	elements := interfaces.FieldTable["value"].Fvalue.([]*object.Object)
+
+	var names []string
+	for _, element := range elements {
+		names = append(names, typeName(element))
+	}
+	expected := []string{
+		"java.util.function.Supplier<V[]>",
+		"java.util.function.Consumer<int[]>",
+		"java.util.function.Function<?, ? super K>",
+		"test.Generic<K>$Member<V>",
+	}
+	if len(names) != len(expected) {
+		t.Fatalf("getGenericInterfaces(): expected %v, got %v", expected, names)
+	}
+	for i := range expected {
+		if names[i] != expected[i] {
+			t.Errorf("getGenericInterfaces()[%d]: expected %s, got %s", i, expected[i], names[i])
+		}
+	}
+
+	// V[] is a generic array type whose component is V, which is bounded by Comparable<V>
+	supplierArgs := invokeSpunMethod(t, elements[0], "getActualTypeArguments()[Ljava/lang/reflect/Type;").(*object.Object)
+	array := supplierArgs.FieldTable["value"].Fvalue.([]*object.Object)[0]
+	v := invokeSpunMethod(t, array, "getGenericComponentType()Ljava/lang/reflect/Type;").(*object.Object)
+	bounds := invokeSpunMethod(t, v, "getBounds()[Ljava/lang/reflect/Type;").(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	if len(bounds) != 1 || typeName(bounds[0]) != "java.lang.Comparable<V>" {
+		t.Errorf("getBounds(): expected V to be bounded by Comparable<V>, got %v", bounds)
+	}
+
+	// the owner of a member of a parameterized class is parameterized
+	owner := invokeSpunMethod(t, elements[3], "getOwnerType()Ljava/lang/reflect/Type;").(*object.Object)
+	if typeName(owner) != "test.Generic<K>" {
+		t.Errorf("getOwnerType(): expected test.Generic<K>, got %s", typeName(owner))
+	}
+
+	// types parsed separately are equal if they have the same parts
+	again := classGetGenericInterfaces([]interface{}{makeClassObject("test/Holder")}).(*object.Object)
+	other := again.FieldTable["value"].Fvalue.([]*object.Object)[2]
+	if invokeSpunMethod(t, elements[2], "equals(Ljava/lang/Object;)Z", other) != types.JavaBoolTrue ||
+		invokeSpunMethod(t, elements[2], "hashCode()I") != invokeSpunMethod(t, other, "hashCode()I") {
+		t.Errorf("equals(): expected equal types with equal hash codes")
+	}
+	if invokeSpunMethod(t, elements[2], "equals(Ljava/lang/Object;)Z", elements[0]) != types.JavaBoolFalse {
+		t.Errorf("equals(): expected different types to be unequal")
+	}
+}
+
+func TestInvalidGenericSignature(t *testing.T) {
+	genericTestSetup()
+	if _, ok := classGetGenericSuperclass([]interface{}{makeClassObject("test/Broken")}).(*GErrBlk); !ok {
+		t.Errorf("getGenericSuperclass(): expected an error for an invalid signature")
+	}
+}
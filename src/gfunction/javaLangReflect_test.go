@@ -0,0 +1,342 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/statics"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"testing"
+)
+
+// reflectTestClass adds a class to the method area with the given fields and methods,
+// which are given as name, descriptor, and access flags
+func reflectTestClass(name, superclass string, access classloader.AccessFlags, fields, methods [][3]any) *classloader.Klass {
+	var cp classloader.CPool
+	utf8 := func(s string) uint16 {
+		cp.Utf8Refs = append(cp.Utf8Refs, s)
+		return uint16(len(cp.Utf8Refs) - 1)
+	}
+
+	clData := classloader.ClData{
+		Name:        name,
+		NameIndex:   stringPool.GetStringIndex(&name),
+		MethodTable: make(map[string]*classloader.Method),
+		Access:      access,
+	}
+	if superclass != "" {
+		clData.SuperclassIndex = stringPool.GetStringIndex(&superclass)
+	}
+	for _, f := range fields {
+		clData.Fields = append(clData.Fields, classloader.Field{
+			AccessFlags: f[2].(int), Name: utf8(f[0].(string)), Desc: utf8(f[1].(string)),
+			IsStatic: f[2].(int)&modifierStatic != 0})
+	}
+	for _, m := range methods {
+		clData.MethodTable[m[0].(string)+m[1].(string)] = &classloader.Method{
+			AccessFlags: m[2].(int), Name: utf8(m[0].(string)), Desc: utf8(m[1].(string))}
+	}
+	clData.CP = cp
+
+	k := &classloader.Klass{Status: 'L', Loader: "app", Data: &clData}
+	classloader.MethAreaInsert(name, k)
+	return k
+}
+
+func reflectTestSetup() *list.List {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+	reflectTestClass(types.ObjectClassName, "", classloader.AccessFlags{ClassIsPublic: true}, nil,
+		[][3]any{{"<init>", "()V", modifierPublic}, {"hashCode", "()I", modifierPublic}, {"clone", "()Ljava/lang/Object;", 0x0004}})
+	reflectTestClass("test/Widget", types.ObjectClassName, classloader.AccessFlags{ClassIsPublic: true, ClassIsFinal: true},
+		[][3]any{
+			{"count", "I", modifierPrivate},
+			{"LIMIT", "J", modifierPublic | modifierStatic | modifierFinal},
+			{"label", "Ljava/lang/String;", modifierPublic | modifierFinal},
+		},
+		[][3]any{
+			{"<init>", "()V", modifierPublic},
+			{"<init>", "(I)V", modifierPrivate},
+			{"<clinit>", "()V", modifierStatic},
+			{"getCount", "()I", modifierPublic},
+			{"hashCode", "()I", modifierPublic},
+			{"reset", "()V", modifierPrivate},
+			{"twice", "(D)D", modifierPublic | modifierStatic},
+		})
+
+	fs := frames.CreateFrameStack()
+	_ = frames.PushFrame(fs, frames.CreateFrame(2))
+	return fs
+}
+
+// the names of the Method, Constructor, or Field objects in an array
+func memberNames(array any) []string {
+	var names []string
+	for _, member := range array.(*object.Object).FieldTable["value"].Fvalue.([]*object.Object) {
+		names = append(names, memberName(member)+memberDescriptor(member))
+	}
+	return names
+}
+
+func objectArray(elements ...*object.Object) *object.Object {
+	return makeRefArray(types.ObjectClassName, elements)
+}
+
+func TestClassGetMembers(t *testing.T) {
+	reflectTestSetup()
+	widget := makeClassObject("test/Widget")
+
+	checkNames := func(what string, got []string, expected ...string) {
+		t.Helper()
+		if len(got) != len(expected) {
+			t.Fatalf("%s: expected %v, got %v", what, expected, got)
+		}
+		for i := range got {
+			if got[i] != expected[i] {
+				t.Errorf("%s: expected %v, got %v", what, expected, got)
+				return
+			}
+		}
+	}
+
+	checkNames("getDeclaredMethods", memberNames(classGetDeclaredMethods([]interface{}{widget})),
+		"getCount()I", "hashCode()I", "reset()V", "twice(D)D")
+	checkNames("getDeclaredConstructors", memberNames(classGetDeclaredConstructors([]interface{}{widget})),
+		"test.Widget()V", "test.Widget(I)V")
+	checkNames("getDeclaredFields", memberNames(classGetDeclaredFields([]interface{}{widget})),
+		"countI", "LIMITJ", "labelLjava/lang/String;")
+
+	// the public methods include those inherited from Object, unless they're overridden
+	methods := classGetMethods([]interface{}{widget}).(*object.Object).FieldTable["value"].Fvalue.([]*object.Object)
+	var names []string
+	for _, m := range methods {
+		names = append(names, memberClassName(m)+"."+memberName(m))
+	}
+	checkNames("getMethods", names, "test/Widget.getCount", "test/Widget.hashCode", "test/Widget.twice")
+
+	// arrays and primitive types have no members
+	checkNames("getDeclaredMethods of int", memberNames(classGetDeclaredMethods([]interface{}{makeClassObject("int")})))
+
+	if mods := classGetModifiers([]interface{}{widget}); mods != int64(modifierPublic|modifierFinal) {
+		t.Errorf("expected the modifiers of test.Widget to be public final, got %v", mods)
+	}
+	if mods := classGetModifiers([]interface{}{makeClassObject("[Ltest/Widget;")}); mods != int64(modifierPublic|modifierFinal|modifierAbstract) {
+		t.Errorf("expected the modifiers of test.Widget[] to be public final abstract, got %v", mods)
+	}
+	name := getName([]interface{}{makeClassObject("[Ltest/Widget;")}).(*object.Object)
+	if object.GoStringFromStringObject(name) != "[Ltest.Widget;" {
+		t.Errorf("expected the name of the array class to be [Ltest.Widget;, got %s", object.GoStringFromStringObject(name))
+	}
+}
+
+func TestClassForName(t *testing.T) {
+	fs := reflectTestSetup()
+	var instantiated []string
+	globals.GetGlobalRef().FuncInstantiateClass = func(className string, _ *list.List) (any, error) {
+		instantiated = append(instantiated, className)
+		classloader.MethAreaFetch(className).Data.ClInit = types.ClInitRun
+		return object.MakeEmptyObjectWithClassName(&className), nil
+	}
+	classloader.MethAreaFetch("test/Widget").Data.ClInit = types.ClInitNotRun
+
+	// loading a class without initializing it doesn't run its static initializer
+	ret := classForNameInitialize([]interface{}{fs, object.StringObjectFromGoString("test.Widget"), types.JavaBoolFalse, object.Null})
+	if getClassName(ret.(*object.Object)) != "test/Widget" || len(instantiated) != 0 {
+		t.Errorf("expected test.Widget to be loaded but not initialized, got %v, %v", ret, instantiated)
+	}
+
+	ret = classForName([]interface{}{fs, object.StringObjectFromGoString("test.Widget")})
+	if getClassName(ret.(*object.Object)) != "test/Widget" || len(instantiated) != 1 {
+		t.Errorf("expected test.Widget to be loaded and initialized, got %v, %v", ret, instantiated)
+	}
+	_ = classForName([]interface{}{fs, object.StringObjectFromGoString("test.Widget")})
+	if len(instantiated) != 1 {
+		t.Error("expected test.Widget to be initialized only once")
+	}
+
+	for javaName, className := range map[string]string{"[Ltest.Widget;": "[Ltest/Widget;", "[[I": "[[I"} {
+		ret = classForName([]interface{}{fs, object.StringObjectFromGoString(javaName)})
+		if classObj, ok := ret.(*object.Object); !ok || getClassName(classObj) != className {
+			t.Errorf("expected the class %s, got %v", className, ret)
+		}
+	}
+
+	for _, javaName := range []string{"[V", "[Ltest.Widget", "[[", "test/Widget;"} {
+		ret = classForName([]interface{}{fs, object.StringObjectFromGoString(javaName)})
+		if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.ClassNotFoundException {
+			t.Errorf("expected ClassNotFoundException for %s, got %v", javaName, ret)
+		}
+	}
+	ret = classForName([]interface{}{fs, object.Null})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.NullPointerException {
+		t.Errorf("expected NullPointerException for a null name, got %v", ret)
+	}
+}
+
+func TestMethodInvoke(t *testing.T) {
+	fs := reflectTestSetup()
+	type call struct {
+		className, name, desc string
+		args                  []any
+	}
+	var calls []call
+	globals.GetGlobalRef().FuncInvokeMethod = func(_ *list.List, className, name, desc string, args []any) (any, error) {
+		calls = append(calls, call{className, name, desc, args})
+		switch desc {
+		case "(D)D":
+			return args[0].(float64) * 2, nil
+		case "()I":
+			return int64(42), nil
+		}
+		return nil, nil
+	}
+
+	// the arguments of a static method are unboxed, widening them if need be, and the result is boxed
+	twice := makeMethodObject("test/Widget", "twice", "(D)D", modifierPublic|modifierStatic)
+	ret := methodInvoke([]interface{}{fs, twice, object.Null, objectArray(populator("java/lang/Integer", types.Int, int64(21)))})
+	result, ok := ret.(*object.Object)
+	if !ok || *stringPool.GetStringPointer(result.KlassName) != "java/lang/Double" || result.FieldTable["value"].Fvalue != 42.0 {
+		t.Errorf("expected Double 42.0, got %v", ret)
+	}
+
+	// an instance method is invoked on the object, and a void method returns null
+	widgetClass := "test/Widget"
+	widget := object.MakeEmptyObjectWithClassName(&widgetClass)
+	getCount := makeMethodObject(types.ObjectClassName, "hashCode", "()I", modifierPublic)
+	ret = methodInvoke([]interface{}{fs, getCount, widget, object.Null})
+	if result, ok = ret.(*object.Object); !ok || result.FieldTable["value"].Fvalue != int64(42) {
+		t.Errorf("expected Integer 42, got %v", ret)
+	}
+	last := calls[len(calls)-1]
+	if last.className != "test/Widget" || len(last.args) != 1 || last.args[0] != widget {
+		t.Errorf("expected the overriding hashCode() to be invoked on the object, got %v", last)
+	}
+	reset := makeMethodObject("test/Widget", "reset", "()V", modifierPrivate)
+	if ret = methodInvoke([]interface{}{fs, reset, widget, objectArray()}); !object.IsNull(ret) {
+		t.Errorf("expected null from a void method, got %v", ret)
+	}
+
+	for _, bad := range []struct {
+		params  []interface{}
+		excType int
+	}{
+		{[]interface{}{fs, reset, object.Null, object.Null}, excNames.NullPointerException},
+		{[]interface{}{fs, reset, object.StringObjectFromGoString("x"), object.Null}, excNames.IllegalArgumentException},
+		{[]interface{}{fs, twice, object.Null, object.Null}, excNames.IllegalArgumentException},
+		{[]interface{}{fs, twice, object.Null, objectArray(object.StringObjectFromGoString("x"))}, excNames.IllegalArgumentException},
+		{[]interface{}{fs, twice, object.Null, objectArray(object.Null)}, excNames.IllegalArgumentException},
+	} {
+		if errBlk, ok := methodInvoke(bad.params).(*GErrBlk); !ok || errBlk.ExceptionType != bad.excType {
+			t.Errorf("expected exception %d, got %v", bad.excType, errBlk)
+		}
+	}
+
+	// a constructor is run on a new instance of its class
+	globals.GetGlobalRef().FuncInstantiateClass = func(className string, _ *list.List) (any, error) {
+		return object.MakeEmptyObjectWithClassName(&className), nil
+	}
+	ctor := makeConstructorObject("test/Widget", "(I)V", modifierPrivate)
+	ret = constructorNewInstance([]interface{}{fs, ctor, objectArray(populator("java/lang/Character", types.Char, int64('A')))})
+	last = calls[len(calls)-1]
+	if last.name != "<init>" || len(last.args) != 2 || last.args[0] != ret || last.args[1] != int64('A') {
+		t.Errorf("expected <init>(I)V to be run on the new object, got %v", last)
+	}
+}
+
+func TestFieldGetAndSet(t *testing.T) {
+	fs := reflectTestSetup()
+	widgetClass := "test/Widget"
+	widget := object.MakeEmptyObjectWithClassName(&widgetClass)
+	widget.FieldTable["count"] = object.Field{Ftype: types.Int, Fvalue: int64(3)}
+	widget.FieldTable["label"] = object.Field{Ftype: "Ljava/lang/String;", Fvalue: object.Null}
+
+	count := makeFieldObject("test/Widget", "count", "I", modifierPrivate)
+	ret := fieldGet([]interface{}{fs, count, widget})
+	if value, ok := ret.(*object.Object); !ok || value.FieldTable["value"].Fvalue != int64(3) {
+		t.Errorf("expected Integer 3, got %v", ret)
+	}
+	if ret = fieldSet([]interface{}{fs, count, widget, populator("java/lang/Short", types.Short, int64(7))}); ret != nil {
+		t.Errorf("expected the field to be set, got %v", ret)
+	}
+	if widget.FieldTable["count"].Fvalue != int64(7) {
+		t.Errorf("expected count to be 7, got %v", widget.FieldTable["count"].Fvalue)
+	}
+	if errBlk, ok := fieldSet([]interface{}{fs, count, widget, populator("java/lang/Long", types.Long, int64(7))}).(*GErrBlk); !ok ||
+		errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("expected IllegalArgumentException when narrowing a long, got %v", errBlk)
+	}
+
+	// a final field can be set only after setAccessible(true)
+	label := makeFieldObject("test/Widget", "label", "Ljava/lang/String;", modifierPublic|modifierFinal)
+	str := object.StringObjectFromGoString("gizmo")
+	if errBlk, ok := fieldSet([]interface{}{fs, label, widget, str}).(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalAccessException {
+		t.Errorf("expected IllegalAccessException when setting a final field, got %v", errBlk)
+	}
+	_ = reflectSetAccessible([]interface{}{label, types.JavaBoolTrue})
+	if ret = fieldSet([]interface{}{fs, label, widget, str}); ret != nil || fieldGet([]interface{}{fs, label, widget}) != str {
+		t.Errorf("expected the final field to be set after setAccessible(true), got %v", ret)
+	}
+
+	// static fields are kept in the statics table
+	_ = statics.AddStatic("test/Widget.LIMIT", statics.Static{Type: types.Long, Value: int64(100)})
+	limit := makeFieldObject("test/Widget", "LIMIT", "J", modifierPublic|modifierStatic|modifierFinal)
+	if value, ok := fieldGet([]interface{}{fs, limit, object.Null}).(*object.Object); !ok || value.FieldTable["value"].Fvalue != int64(100) {
+		t.Errorf("expected Long 100, got %v", value)
+	}
+
+	if errBlk, ok := fieldGet([]interface{}{fs, count, object.Null}).(*GErrBlk); !ok || errBlk.ExceptionType != excNames.NullPointerException {
+		t.Errorf("expected NullPointerException for a null object, got %v", errBlk)
+	}
+}
+
+func TestBoxAndUnboxValues(t *testing.T) {
+	reflectTestSetup()
+
+	boxed := boxValue("Z", true).(*object.Object)
+	if *stringPool.GetStringPointer(boxed.KlassName) != "java/lang/Boolean" || boxed.FieldTable["value"].Fvalue != types.JavaBoolTrue {
+		t.Errorf("expected Boolean true, got %v", boxed.FieldTable["value"])
+	}
+	if !object.IsNull(boxValue("V", nil)) || !object.IsNull(boxValue("Ljava/lang/String;", nil)) {
+		t.Error("expected void and null references to be boxed as null")
+	}
+
+	for _, test := range []struct {
+		desc     string
+		arg      *object.Object
+		expected any
+		ok       bool
+	}{
+		{"I", populator("java/lang/Integer", types.Int, int64(5)), int64(5), true},
+		{"J", populator("java/lang/Byte", types.Byte, int64(5)), int64(5), true},
+		{"D", populator("java/lang/Long", types.Long, int64(5)), 5.0, true},
+		{"F", populator("java/lang/Float", types.Float, 1.5), 1.5, true},
+		{"I", populator("java/lang/Long", types.Long, int64(5)), nil, false},
+		{"C", populator("java/lang/Byte", types.Byte, int64(5)), nil, false},
+		{"Z", object.MakePrimitiveObject("java/lang/Boolean", types.Bool, types.JavaBoolTrue), types.JavaBoolTrue, true},
+		{"I", object.Null, nil, false},
+	} {
+		value, ok := unboxValue(test.desc, test.arg)
+		if ok != test.ok || (ok && value != test.expected) {
+			t.Errorf("unboxValue(%s): expected %v, %v, got %v, %v", test.desc, test.expected, test.ok, value, ok)
+		}
+	}
+
+	widgetClass := "test/Widget"
+	widget := object.MakeEmptyObjectWithClassName(&widgetClass)
+	if _, ok := unboxValue("Ljava/lang/Object;", widget); !ok {
+		t.Error("expected a Widget to be passed as an Object")
+	}
+	if _, ok := unboxValue("Ljava/lang/String;", widget); ok {
+		t.Error("expected a Widget not to be passed as a String")
+	}
+}
@@ -0,0 +1,221 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"jacobin/excNames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/shutdown"
+	"jacobin/thread"
+	"jacobin/types"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Implementation of java.lang.Runtime. There is a single Runtime object, which is
+// returned by getRuntime(). The shutdown hooks registered via addShutdownHook() are
+// Thread objects that have not been started; when the JVM exits, shutdown.Exit()
+// starts them all, via RunShutdownHooks(), and waits for them to finish.
+
+var runtimeClassName = "java/lang/Runtime"
+
+var theRuntime struct {
+	once sync.Once
+	obj  *object.Object
+}
+
+var shutdownHooks struct {
+	sync.Mutex
+	hooks        []*thread.ExecThread // in order of registration
+	shuttingDown bool                 // once set, no hooks can be added or removed
+}
+
+func Load_Lang_Runtime() {
+
+	MethodSignatures["java/lang/Runtime.addShutdownHook(Ljava/lang/Thread;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  runtimeAddShutdownHook,
+		}
+
+	MethodSignatures["java/lang/Runtime.availableProcessors()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  runtimeAvailableProcessors,
+		}
+
+	MethodSignatures["java/lang/Runtime.exit(I)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  runtimeExit,
+		}
+
+	MethodSignatures["java/lang/Runtime.freeMemory()J"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  runtimeFreeMemory,
+		}
+
+	MethodSignatures["java/lang/Runtime.gc()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  forceGC,
+		}
+
+	MethodSignatures["java/lang/Runtime.getRuntime()Ljava/lang/Runtime;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  runtimeGetRuntime,
+		}
+
+	MethodSignatures["java/lang/Runtime.halt(I)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  runtimeHalt,
+		}
+
+	MethodSignatures["java/lang/Runtime.maxMemory()J"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  runtimeMaxMemory,
+		}
+
+	MethodSignatures["java/lang/Runtime.removeShutdownHook(Ljava/lang/Thread;)Z"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  runtimeRemoveShutdownHook,
+		}
+
+	MethodSignatures["java/lang/Runtime.totalMemory()J"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  runtimeTotalMemory,
+		}
+}
+
+// "java/lang/Runtime.getRuntime()Ljava/lang/Runtime;"
+func runtimeGetRuntime([]interface{}) interface{} {
+	theRuntime.once.Do(func() {
+		theRuntime.obj = object.MakeEmptyObjectWithClassName(&runtimeClassName)
+	})
+	return theRuntime.obj
+}
+
+// "java/lang/Runtime.addShutdownHook(Ljava/lang/Thread;)V" registers a thread that
+// is started when the JVM exits. The thread must not have been started.
+func runtimeAddShutdownHook(params []interface{}) interface{} {
+	if object.IsNull(params[1]) {
+		return getGErrBlk(excNames.NullPointerException, "Shutdown hook is null")
+	}
+	hook, errBlk := getExecThreadOrErr(params[1])
+	if errBlk != nil {
+		return errBlk
+	}
+
+	shutdownHooks.Lock()
+	defer shutdownHooks.Unlock()
+	if shutdownHooks.shuttingDown {
+		return getGErrBlk(excNames.IllegalStateException, "Shutdown in progress")
+	}
+	if hook.Started {
+		return getGErrBlk(excNames.IllegalArgumentException, "Hook already running")
+	}
+	if slices.Contains(shutdownHooks.hooks, hook) {
+		return getGErrBlk(excNames.IllegalArgumentException, "Hook previously registered")
+	}
+	shutdownHooks.hooks = append(shutdownHooks.hooks, hook)
+	return nil
+}
+
+// "java/lang/Runtime.removeShutdownHook(Ljava/lang/Thread;)Z" returns true if
+// the thread was registered as a shutdown hook
+func runtimeRemoveShutdownHook(params []interface{}) interface{} {
+	if object.IsNull(params[1]) {
+		return getGErrBlk(excNames.NullPointerException, "Shutdown hook is null")
+	}
+	hook := getExecThread(params[1])
+
+	shutdownHooks.Lock()
+	defer shutdownHooks.Unlock()
+	if shutdownHooks.shuttingDown {
+		return getGErrBlk(excNames.IllegalStateException, "Shutdown in progress")
+	}
+	i := slices.Index(shutdownHooks.hooks, hook)
+	if hook == nil || i < 0 {
+		return types.JavaBoolFalse
+	}
+	shutdownHooks.hooks = slices.Delete(shutdownHooks.hooks, i, i+1)
+	return types.JavaBoolTrue
+}
+
+// RunShutdownHooks starts all the registered shutdown hooks and waits for them to
+// finish. As in HotSpot, the hooks run concurrently, in no particular order. It's
+// called by shutdown.Exit() via globals.FuncRunShutdownHooks.
+func RunShutdownHooks() {
+	shutdownHooks.Lock()
+	shutdownHooks.shuttingDown = true
+	hooks := shutdownHooks.hooks
+	shutdownHooks.hooks = nil
+	shutdownHooks.Unlock()
+
+	glob := globals.GetGlobalRef()
+	var started []*thread.ExecThread
+	for _, hook := range hooks {
+		glob.ThreadLock.Lock()
+		alreadyStarted := hook.Started
+		hook.Started = true
+		glob.ThreadLock.Unlock()
+		if alreadyStarted { // the hook was started after it was registered
+			continue
+		}
+		if err := glob.FuncStartThread(hook); err == nil {
+			started = append(started, hook)
+		}
+	}
+
+	for _, hook := range started {
+		<-hook.Done
+	}
+}
+
+// "java/lang/Runtime.exit(I)V" exits the JVM after running the shutdown hooks, as
+// System.exit() does
+func runtimeExit(params []interface{}) interface{} {
+	return exitI(params[1:])
+}
+
+// "java/lang/Runtime.halt(I)V" exits the JVM at once, without running the shutdown hooks
+func runtimeHalt(params []interface{}) interface{} {
+	shutdown.Halt(int(params[1].(int64)))
+	return nil // this code is not executed as previous line ends Jacobin
+}
+
+// "java/lang/Runtime.availableProcessors()I"
+func runtimeAvailableProcessors([]interface{}) interface{} {
+	return int64(runtime.NumCPU())
+}
+
+// "java/lang/Runtime.freeMemory()J" returns the free part of the committed heap
+func runtimeFreeMemory([]interface{}) interface{} {
+	_, free, _ := object.HeapStats()
+	return free
+}
+
+// "java/lang/Runtime.maxMemory()J" returns the maximum heap size (-Xmx), or
+// Long.MAX_VALUE if there's no limit
+func runtimeMaxMemory([]interface{}) interface{} {
+	_, _, maxHeap := object.HeapStats()
+	return maxHeap
+}
+
+// "java/lang/Runtime.totalMemory()J" returns the size of the committed heap
+func runtimeTotalMemory([]interface{}) interface{} {
+	total, _, _ := object.HeapStats()
+	return total
+}
@@ -0,0 +1,134 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"jacobin/excNames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/thread"
+	"jacobin/types"
+	"math"
+	"testing"
+)
+
+// resets the shutdown hooks, which are global, before and after a test
+func resetShutdownHooks(t *testing.T) {
+	reset := func() {
+		shutdownHooks.Lock()
+		shutdownHooks.hooks = nil
+		shutdownHooks.shuttingDown = false
+		shutdownHooks.Unlock()
+	}
+	reset()
+	t.Cleanup(reset)
+}
+
+func TestRuntimeGetRuntime(t *testing.T) {
+	globals.InitGlobals("test")
+	rt := runtimeGetRuntime(nil).(*object.Object)
+	if rt != runtimeGetRuntime(nil).(*object.Object) {
+		t.Error("expected getRuntime() to return the same Runtime object each time")
+	}
+	if object.GoStringFromStringPoolIndex(rt.KlassName) != runtimeClassName {
+		t.Errorf("expected a %s object, got %s", runtimeClassName, object.GoStringFromStringPoolIndex(rt.KlassName))
+	}
+}
+
+func TestRuntimeAddAndRemoveShutdownHooks(t *testing.T) {
+	fs, _ := threadTestSetup()
+	resetShutdownHooks(t)
+	rt := runtimeGetRuntime(nil)
+
+	hook1 := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, hook1})
+	hook2 := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, hook2})
+
+	if ret := runtimeAddShutdownHook([]interface{}{rt, hook1}); ret != nil {
+		t.Fatalf("addShutdownHook returned %v", ret)
+	}
+	if ret := runtimeAddShutdownHook([]interface{}{rt, hook2}); ret != nil {
+		t.Fatalf("addShutdownHook returned %v", ret)
+	}
+
+	ret := runtimeAddShutdownHook([]interface{}{rt, hook1})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("expected an IllegalArgumentException for a hook registered twice, got %v", ret)
+	}
+	ret = runtimeAddShutdownHook([]interface{}{rt, object.Null})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.NullPointerException {
+		t.Errorf("expected a NullPointerException for a null hook, got %v", ret)
+	}
+
+	started := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, started})
+	getExecThread(started).Started = true
+	ret = runtimeAddShutdownHook([]interface{}{rt, started})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("expected an IllegalArgumentException for a started hook, got %v", ret)
+	}
+
+	if runtimeRemoveShutdownHook([]interface{}{rt, hook2}) != types.JavaBoolTrue {
+		t.Error("expected removeShutdownHook to return true for a registered hook")
+	}
+	if runtimeRemoveShutdownHook([]interface{}{rt, hook2}) != types.JavaBoolFalse {
+		t.Error("expected removeShutdownHook to return false for a hook that's been removed")
+	}
+}
+
+func TestRuntimeRunShutdownHooks(t *testing.T) {
+	fs, _ := threadTestSetup()
+	resetShutdownHooks(t)
+	rt := runtimeGetRuntime(nil)
+
+	var ran []*thread.ExecThread
+	globals.GetGlobalRef().FuncStartThread = func(t any) error {
+		th := t.(*thread.ExecThread)
+		ran = append(ran, th)
+		close(th.Done)
+		return nil
+	}
+
+	hook1 := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, hook1})
+	hook2 := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, hook2})
+	_ = runtimeAddShutdownHook([]interface{}{rt, hook1})
+	_ = runtimeAddShutdownHook([]interface{}{rt, hook2})
+	_ = runtimeRemoveShutdownHook([]interface{}{rt, hook2})
+
+	RunShutdownHooks()
+	if len(ran) != 1 || ran[0] != getExecThread(hook1) {
+		t.Fatalf("expected only the registered hook to run, got %v", ran)
+	}
+	if !getExecThread(hook1).Started {
+		t.Error("expected the hook to be marked as started")
+	}
+
+	// once the JVM is shutting down, hooks can be neither added nor removed
+	ret := runtimeAddShutdownHook([]interface{}{rt, hook2})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalStateException {
+		t.Errorf("expected an IllegalStateException during shutdown, got %v", ret)
+	}
+	ret = runtimeRemoveShutdownHook([]interface{}{rt, hook1})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalStateException {
+		t.Errorf("expected an IllegalStateException during shutdown, got %v", ret)
+	}
+}
+
+func TestRuntimeMemory(t *testing.T) {
+	globals.InitGlobals("test")
+	total := runtimeTotalMemory(nil).(int64)
+	free := runtimeFreeMemory(nil).(int64)
+	if total <= 0 || free < 0 || free > total {
+		t.Errorf("expected 0 <= freeMemory <= totalMemory, got %d and %d", free, total)
+	}
+	if maxMemory := runtimeMaxMemory(nil).(int64); maxMemory != math.MaxInt64 {
+		t.Errorf("expected maxMemory to be Long.MAX_VALUE with no -Xmx, got %d", maxMemory)
+	}
+}
@@ -8,18 +8,16 @@ package gfunction
 
 import (
 	"container/list"
-	"fmt"
 	"jacobin/classloader"
-	"jacobin/excNames"
 	"jacobin/exceptions"
 	"jacobin/frames"
 	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/object"
 	"jacobin/shutdown"
+	"jacobin/stringPool"
+	"jacobin/types"
 	"jacobin/util"
-	"sort"
-	"strings"
 )
 
 // StackTraceElement is a class that is primarily used by Throwable to gather data about the
@@ -43,6 +41,41 @@ func Load_Lang_StackTraceELement() {
 			GFunction:  initStackTraceElements,
 		}
 
+	MethodSignatures["java/lang/StackTraceElement.getClassName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  steGetClassName,
+		}
+
+	MethodSignatures["java/lang/StackTraceElement.getFileName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  steGetFileName,
+		}
+
+	MethodSignatures["java/lang/StackTraceElement.getLineNumber()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  steGetLineNumber,
+		}
+
+	MethodSignatures["java/lang/StackTraceElement.getMethodName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  steGetMethodName,
+		}
+
+	MethodSignatures["java/lang/StackTraceElement.isNativeMethod()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  steIsNativeMethod,
+		}
+
+	MethodSignatures["java/lang/StackTraceElement.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  steToString,
+		}
 }
 
 /*
@@ -67,6 +100,7 @@ func Load_Lang_StackTraceELement() {
 		return stackTrace;
 	}
 */
+// In Jacobin, the depth is at most the number of frames in the stack trace (see stackTraceFrames()).
 func of(params []interface{}) interface{} {
 
 	throwable := params[0].(*object.Object)
@@ -80,6 +114,11 @@ func of(params []interface{}) interface{} {
 		shutdown.Exit(shutdown.JVM_EXCEPTION)
 	}
 
+	traceFrames, _ := stackTraceFrames(throwable, jvmStackRef)
+	if depth > int64(len(traceFrames)) {
+		depth = int64(len(traceFrames))
+	}
+
 	// create the 1-dimensional array of stackTraceElements
 	stackTraceElementClassName := "java/lang/StackTraceElement"
 	stackTrace := object.Make1DimRefArray(&stackTraceElementClassName, depth)
@@ -108,29 +147,74 @@ func initStackTraceElements(params []interface{}) interface{} {
 	arrayObjPtr := params[0].(*object.Object) // the array of stackTraceElements we'll fill in
 	arrayObj := *arrayObjPtr
 	rawSteArray := arrayObj.FieldTable["value"].Fvalue.([]*object.Object)
-	// rawSteArray := *rawSteArrayPtr
 
 	throwable := params[1].(*object.Object) // pointer to the Throwable object
 	jvmStack := throwable.FieldTable["frameStackRef"].Fvalue.(*list.List)
 
-	var i = 0
-	for e := jvmStack.Front(); e != nil; e = e.Next() {
-		frame := e.Value.(*frames.Frame)
-		ste := rawSteArray[i]
-		i += 1
-		initStackTraceElement(ste, frame)
+	traceFrames, pcs := stackTraceFrames(throwable, jvmStack)
+	for i := 0; i < len(traceFrames) && i < len(rawSteArray); i++ {
+		initStackTraceElement(rawSteArray[i], traceFrames[i], pcs[i])
 	}
 
 	return nil
 }
 
+// stackTraceFrames returns the frames of the JVM stack that appear in the throwable's
+// stack trace, from the top of the stack down, and the PC in each of them. As in HotSpot,
+// the frames of the constructors that created the throwable (and of fillInStackTrace())
+// are omitted, as are the base frames of calls to Java methods from gfunctions.
+func stackTraceFrames(throwable *object.Object, jvmStack *list.List) ([]*frames.Frame, []int) {
+	// the throwable's class and its superclasses, whose constructors are omitted
+	throwableClasses := make(map[string]bool)
+	for className := *stringPool.GetStringPointer(throwable.KlassName); className != types.ObjectClassName; {
+		throwableClasses[className] = true
+		k := classloader.MethAreaFetch(className)
+		if k == nil || k.Data == nil {
+			break
+		}
+		className = *stringPool.GetStringPointer(k.Data.SuperclassIndex)
+	}
+
+	var traceFrames []*frames.Frame
+	var pcs []int
+	skipping := true // still skipping the frames that created the throwable
+	for e := jvmStack.Front(); e != nil; e = e.Next() {
+		frame := e.Value.(*frames.Frame)
+		if frame.Ftype == 'G' {
+			continue
+		}
+		if skipping && throwableClasses[frame.ClName] &&
+			(frame.MethName == "<init>" || frame.MethName == "fillInStackTrace") {
+			continue
+		}
+		skipping = false
+
+		// the frame at the top of the stack is executing the instruction that threw the
+		// exception. Every other frame has advanced its PC past the instruction that
+		// invoked the frame above it, unless that was a call to a gfunction.
+		pc := frame.PC
+		if e == jvmStack.Front() {
+			if frame.ExceptionPC != -1 {
+				pc = frame.ExceptionPC
+			}
+		} else if e.Prev().Value.(*frames.Frame).Ftype != 'G' && pc > 0 {
+			pc -= 1
+		}
+
+		traceFrames = append(traceFrames, frame)
+		pcs = append(pcs, pc)
+	}
+	return traceFrames, pcs
+}
+
 // initStackTraceElement accepts a single stackTraceElement and JVM stack
 // info and fills in the former with the latter. It's a private method and
 // called only from initStackTraceElements(), so we don't need it to strictly
 // follow the HotSpot way of implementing it. Official definition:
 // initStackTraceElement(Ljava/lang/StackTraceElement;Ljava/lang/StackFrameInfo;)V
-func initStackTraceElement(ste *object.Object, frm *frames.Frame) {
-	frame := *frm
+// The fields are Go values, rather than Java objects; they're described in
+// exceptions/stackTrace.go.
+func initStackTraceElement(ste *object.Object, frame *frames.Frame, pc int) {
 	stackTrace := *ste
 
 	// helper function to facilitate subsequent field updates
@@ -144,97 +228,83 @@ func initStackTraceElement(ste *object.Object, frm *frames.Frame) {
 	addField("declaringClass", frame.ClName)
 	addField("methodName", frame.MethName)
 
+	// the source line number comes from the method's LineNumberTable attribute, if any
+	var loader, fileName, module string
+	lineNumber := int64(-1)
 	methClass := classloader.MethAreaFetch(frame.ClName)
-
-	addField("classLoaderName", methClass.Loader)
-	addField("fileName", methClass.Data.SourceFile)
-	addField("moduleName", methClass.Data.Module)
-
-	// now get the source line number for any non-JDK classes and non-constructors
-
-	addField("sourceLine", "") // the default if no source line data is available
-	if !util.IsFilePartOfJDK(&frame.MethName) && !strings.HasPrefix(frame.MethName, "<init>") {
-		rawMethod, _ := classloader.FetchMethodAndCP(frame.ClName, frame.MethName, frame.MethType)
-		if rawMethod.MType == 'G' { // nothing more to do if it's a native method
-			return
-		}
-		method, ok := rawMethod.Meth.(classloader.JmEntry)
-		if !ok {
-			errMsg := fmt.Sprintf("initStackTraceElement: %s.%s, Invalid operand type for rawMethod.Meth: %T",
-				util.ConvertInternalClassNameToUserFormat(frame.ClName), frame.MethName, rawMethod.Meth)
-			_ = exceptions.ThrowEx(excNames.InternalException, errMsg, &frame)
-		}
-		for i := 0; i < len(method.Attribs); i++ {
-			index := method.Attribs[i].AttrName
-			if method.Cp.Utf8Refs[index] == "LineNumberTable" {
-				if frame.ExceptionPC == -1 { // if the exception occurred in a different frame, exceptionPC = -1
-					frame.ExceptionPC = frame.PC
-				}
-				line := searchLineNumberTable(method.Attribs[i].AttrContent, frame.ExceptionPC)
-				if line != -1 { // -1 means not found
-					addField("sourceLine", fmt.Sprintf("%d", line))
-				}
-			}
+	if methClass != nil && methClass.Data != nil {
+		loader = methClass.Loader
+		fileName = methClass.Data.SourceFile
+		module = methClass.Data.Module
+		if meth, ok := methClass.Data.MethodTable[frame.MethName+frame.MethType]; ok {
+			lineNumber = int64(searchLineNumberTable(meth.CodeAttr.BytecodeSourceMap, pc))
 		}
 	}
+
+	addField("classLoaderName", loader)
+	addField("fileName", fileName)
+	addField("moduleName", module)
+	stackTrace.FieldTable["lineNumber"] = object.Field{Ftype: types.Int, Fvalue: lineNumber}
 }
 
-// get the source line number from the location of the bytecode where exception occurred
-//
-// We first create a table of entries consisting of bytecode number and source line number
-// then we sort the table, then we traverse the table to find the matching line number
-func searchLineNumberTable(attrContent []byte, PC int) int {
-	entryCount := uint(attrContent[0])*256 + uint(attrContent[1])
-	loc := 2 // 2 bytes into attrContent for entry count uint16 entryCount
-	if entryCount < 1 {
-		return -1
+// searchLineNumberTable returns the source line number of the bytecode at PC, given the
+// method's table of bytecode locations and source lines, which is sorted by location.
+// Returns -1 if the table doesn't cover the bytecode.
+func searchLineNumberTable(table []classloader.BytecodeToSourceLine, PC int) int {
+	line := -1
+	for _, entry := range table {
+		if int(entry.BytecodePos) > PC {
+			break
+		}
+		line = int(entry.SourceLine)
 	}
+	return line
+}
 
-	var table b2sTable
-	var i uint
-
-	// build the table
-	for i = 0; i < entryCount; i++ {
-		bytecodeNumber := uint16(attrContent[loc])*256 + uint16(attrContent[loc+1])
-		sourceLineNumber := uint16(attrContent[loc+2])*256 + uint16(attrContent[loc+3])
-		loc += 4
+// ---- the accessors of StackTraceElement ----
 
-		tableEntry := BytecodeToSourceLine{bytecodeNumber, sourceLineNumber}
-		table = append(table, tableEntry)
-	}
+// java/lang/StackTraceElement.getClassName()Ljava/lang/String;
+func steGetClassName(params []interface{}) interface{} {
+	ste := params[0].(*object.Object)
+	className := ste.FieldTable["declaringClass"].Fvalue.(string)
+	return object.StringObjectFromGoString(util.ConvertInternalClassNameToUserFormat(className))
+}
 
-	// sort the table
-	if len(table) > 1 {
-		sort.Sort(b2sTable(table))
+// java/lang/StackTraceElement.getFileName()Ljava/lang/String; returns null if the file is unknown
+func steGetFileName(params []interface{}) interface{} {
+	ste := params[0].(*object.Object)
+	fileName := ste.FieldTable["fileName"].Fvalue.(string)
+	if fileName == "" {
+		return object.Null
 	}
+	return object.StringObjectFromGoString(fileName)
+}
 
-	// traverse the table
-	var prev uint16 = 0
-	for i = 0; i < entryCount; i++ {
-		bytecodeNumber := table[i].BytecodePos
-		sourceLineNumber := table[i].SourceLine
-		if bytecodeNumber > uint16(PC) {
-			break
-		} else if bytecodeNumber == uint16(PC) {
-			prev = sourceLineNumber
-			break
-		} else {
-			prev = sourceLineNumber
-		}
+// java/lang/StackTraceElement.getLineNumber()I returns a negative number if the line is unknown
+func steGetLineNumber(params []interface{}) interface{} {
+	ste := params[0].(*object.Object)
+	if line, ok := ste.FieldTable["lineNumber"].Fvalue.(int64); ok {
+		return line
 	}
-	return int(prev)
+	return int64(-1)
 }
 
-// the following four lines are all needed for the call to Sort()
-type b2sTable []BytecodeToSourceLine
+// java/lang/StackTraceElement.getMethodName()Ljava/lang/String;
+func steGetMethodName(params []interface{}) interface{} {
+	ste := params[0].(*object.Object)
+	return object.StringObjectFromGoString(ste.FieldTable["methodName"].Fvalue.(string))
+}
 
-func (t b2sTable) Len() int           { return len(t) }
-func (t b2sTable) Swap(k, j int)      { (t)[k], (t)[j] = (t)[j], (t)[k] }
-func (t b2sTable) Less(k, j int) bool { return (t)[k].BytecodePos < (t)[j].BytecodePos }
+// java/lang/StackTraceElement.isNativeMethod()Z
+func steIsNativeMethod(params []interface{}) interface{} {
+	if steGetLineNumber(params).(int64) == -2 {
+		return types.JavaBoolTrue
+	}
+	return types.JavaBoolFalse
+}
 
-// BytecodeToSourceLine maps the PC in a method to the
-// corresponding source line in the original source file.
-type BytecodeToSourceLine struct {
-	BytecodePos uint16
-	SourceLine  uint16
+// java/lang/StackTraceElement.toString()Ljava/lang/String;
+func steToString(params []interface{}) interface{} {
+	ste := params[0].(*object.Object)
+	return object.StringObjectFromGoString(exceptions.StackTraceElementString(ste))
 }
@@ -7,9 +7,12 @@
 package gfunction
 
 import (
+	"container/list"
+	"errors"
 	"fmt"
 	"jacobin/classloader"
 	"jacobin/excNames"
+	"jacobin/exceptions"
 	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/object"
@@ -20,8 +23,10 @@ import (
 	"os"
 	"os/user"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -73,10 +78,52 @@ func Load_Lang_System() {
 			GFunction:  forceGC,
 		}
 
-	MethodSignatures["java/lang/System.getProperty(Ljava/lang/String;)Ljava/lang/String;"] =
+	MethodSignatures["java/lang/System.getenv()Ljava/util/Map;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    getenvAll,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/System.getenv(Ljava/lang/String;)Ljava/lang/String;"] =
 		GMeth{
 			ParamSlots: 1,
-			GFunction:  getProperty,
+			GFunction:  getenv,
+		}
+
+	MethodSignatures["java/lang/System.getProperty(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    getProperty,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/System.getProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    getProperty,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/System.setProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    setProperty,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/System.clearProperty(Ljava/lang/String;)Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    clearProperty,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/System.getProperties()Ljava/util/Properties;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    getProperties,
+			NeedsContext: true,
 		}
 
 	MethodSignatures["java/lang/System.console()Ljava/io/Console;"] =
@@ -294,6 +341,7 @@ func nanoTime([]interface{}) interface{} {
 func exitI(params []interface{}) interface{} {
 	exitCode := params[0].(int64)
 	var exitStatus = int(exitCode)
+	globals.GetGlobalRef().ExitNow = true // don't wait for other threads to finish
 	shutdown.Exit(exitStatus)
 	return 0 // this code is not executed as previous line ends Jacobin
 }
@@ -305,83 +353,310 @@ func forceGC([]interface{}) interface{} {
 }
 
 // Get a property
-func getProperty(params []interface{}) interface{} {
-	propObj := params[0].(*object.Object) // string
-	propStr := object.GoStringFromStringObject(propObj)
+// The system properties. These are initialized from the values Jacobin knows at start-up,
+// then from the -D options on the command line, which override them. After that, they
+// change only through System.setProperty() and System.clearProperty().
+//
+// The first call to System.getProperties() copies them into a java.util.Properties object,
+// which from then on is the property table: the System property methods call its methods,
+// so that changes made through either are seen by both.
+var systemProperties map[string]string
+var systemPropertiesObj *object.Object // the Properties object, once getProperties() has been called
+var systemPropertiesVersion int        // incremented by each change to systemProperties
+var systemPropertiesLock sync.Mutex
+
+var propertiesClassName = "java/util/Properties"
+
+// InitSystemProperties loads the system properties. It's called once the command
+// line has been processed, so that java.class.path and user.dir reflect the classpath
+// and working directory that the application was launched with.
+func InitSystemProperties() {
+	systemPropertiesLock.Lock()
+	defer systemPropertiesLock.Unlock()
+	loadSystemProperties()
+	systemPropertiesObj = nil
+}
 
-	var value string
+// CopySystemProperties returns a copy of the system properties, such as for -XshowSettings.
+// It doesn't reflect changes made after System.getProperties() has been called.
+func CopySystemProperties() map[string]string {
+	systemPropertiesLock.Lock()
+	defer systemPropertiesLock.Unlock()
+	if systemProperties == nil {
+		loadSystemProperties()
+	}
+	properties := make(map[string]string, len(systemProperties))
+	for key, value := range systemProperties {
+		properties[key] = value
+	}
+	return properties
+}
+
+// loadSystemProperties builds the property table. systemPropertiesLock must be held.
+func loadSystemProperties() {
 	g := globals.GetGlobalRef()
 	operSys := runtime.GOOS
 
-	switch propStr {
-	case "file.encoding":
-		value = g.FileEncoding
-	case "file.separator":
-		value = string(os.PathSeparator)
-	case "java.class.path":
-		value = "." // OpenJDK JVM default value
-	case "java.compiler": // the name of the JIT compiler (we don't have a JIT)
-		value = "no JIT"
-	case "java.home":
-		value = g.JavaHome
-	case "java.io.tmpdir":
-		value = os.TempDir()
-	case "java.library.path":
-		value = g.JavaHome
-	case "java.vendor":
-		value = "Jacobin"
-	case "java.vendor.url":
-		value = "https://jacobin.org"
-	case "java.vendor.version":
-		value = g.Version
-	case "java.version":
-		value = strconv.Itoa(g.MaxJavaVersion)
-	// case "java.version.date":
-	// 	need to get this
-	case "java.vm.name":
-		value = fmt.Sprintf(
-			"Jacobin VM v. %s (Java %d) 64-bit VM", g.Version, g.MaxJavaVersion)
-	case "java.vm.specification.name":
-		value = "Java Virtual Machine Specification"
-	case "java.vm.specification.vendor":
-		value = "Oracle and Jacobin"
-	case "java.vm.specification.version":
-		value = strconv.Itoa(g.MaxJavaVersion)
-	case "java.vm.vendor":
-		value = "Jacobin"
-	case "java.vm.version":
-		value = strconv.Itoa(g.MaxJavaVersion)
-	case "line.separator":
-		if operSys == "windows" {
-			value = "\\r\\n"
-		} else {
-			value = "\\n"
+	systemProperties = map[string]string{
+		"file.encoding":                 g.FileEncoding,
+		"file.separator":                string(os.PathSeparator),
+		"java.class.path":               strings.Join(g.Classpath, string(os.PathListSeparator)),
+		"java.compiler":                 "no JIT", // the name of the JIT compiler (we don't have a JIT)
+		"java.home":                     g.JavaHome,
+		"java.io.tmpdir":                os.TempDir(),
+		"java.library.path":             g.JavaHome,
+		"java.vendor":                   "Jacobin",
+		"java.vendor.url":               "https://jacobin.org",
+		"java.vendor.version":           g.Version,
+		"java.version":                  strconv.Itoa(g.MaxJavaVersion),
+		"java.vm.name":                  fmt.Sprintf("Jacobin VM v. %s (Java %d) 64-bit VM", g.Version, g.MaxJavaVersion),
+		"java.vm.specification.name":    "Java Virtual Machine Specification",
+		"java.vm.specification.vendor":  "Oracle and Jacobin",
+		"java.vm.specification.version": strconv.Itoa(g.MaxJavaVersion),
+		"java.vm.vendor":                "Jacobin",
+		"java.vm.version":               strconv.Itoa(g.MaxJavaVersion),
+		"line.separator":                "\n",
+		"native.encoding":               "UTF8", // hard to find out what this is, so hard-coding to UTF8
+		"os.arch":                       runtime.GOARCH,
+		"os.name":                       operSys,
+		"os.version":                    "not yet available",
+		"path.separator":                string(os.PathListSeparator),
+	}
+	// "java.version.date": need to get this
+
+	if operSys == "windows" {
+		systemProperties["line.separator"] = "\r\n"
+	}
+	if dir, err := os.Getwd(); err == nil { // present working directory
+		systemProperties["user.dir"] = dir
+	}
+	if currentUser, err := user.Current(); err == nil {
+		systemProperties["user.home"] = currentUser.HomeDir
+		systemProperties["user.name"] = currentUser.Name
+	}
+	systemProperties["user.timezone"], _ = time.Now().Zone()
+
+	for key, value := range g.SystemProperties { // the -D options
+		systemProperties[key] = value
+	}
+}
+
+// checkPropertyKey returns an error block if the key passed to a System property method
+// is null or empty, as these throw NullPointerException and IllegalArgumentException
+func checkPropertyKey(param interface{}) (string, *GErrBlk) {
+	keyObj, ok := param.(*object.Object)
+	if !ok || object.IsNull(keyObj) {
+		return "", getGErrBlk(excNames.NullPointerException, "key can't be null")
+	}
+	key := object.GoStringFromStringObject(keyObj)
+	if key == "" {
+		return "", getGErrBlk(excNames.IllegalArgumentException, "key can't be empty")
+	}
+	return key, nil
+}
+
+// java/lang/System.getProperty(Ljava/lang/String;)Ljava/lang/String; returns the value of the
+// property, or null if there is none. The two-argument form returns the default instead of null.
+func getProperty(params []interface{}) interface{} {
+	key, errBlk := checkPropertyKey(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	fs := params[len(params)-1].(*list.List)
+	hasDefault := len(params) > 2
+
+	systemPropertiesLock.Lock()
+	if props := systemPropertiesObj; props != nil {
+		systemPropertiesLock.Unlock() // not held while running Java code
+		if hasDefault {
+			return callProperties(fs, props, "getProperty",
+				"(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String;", params[0], params[1])
+		}
+		return callProperties(fs, props, "getProperty", "(Ljava/lang/String;)Ljava/lang/String;", params[0])
+	}
+	defer systemPropertiesLock.Unlock()
+	if systemProperties == nil {
+		loadSystemProperties()
+	}
+
+	value, ok := systemProperties[key]
+	if !ok {
+		if hasDefault {
+			return params[1] // the default value
+		}
+		return object.Null
+	}
+	return object.StringObjectFromGoString(value)
+}
+
+// java/lang/System.setProperty(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/String; sets
+// the property and returns its previous value, or null if it had none
+func setProperty(params []interface{}) interface{} {
+	key, errBlk := checkPropertyKey(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	valueObj, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(valueObj) { // Properties can't hold null values
+		return getGErrBlk(excNames.NullPointerException, "value can't be null")
+	}
+	fs := params[2].(*list.List)
+
+	systemPropertiesLock.Lock()
+	if props := systemPropertiesObj; props != nil {
+		systemPropertiesLock.Unlock() // not held while running Java code
+		return callProperties(fs, props, "setProperty",
+			"(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/Object;", params[0], valueObj)
+	}
+	defer systemPropertiesLock.Unlock()
+	if systemProperties == nil {
+		loadSystemProperties()
+	}
+
+	previous, hadValue := systemProperties[key]
+	systemProperties[key] = object.GoStringFromStringObject(valueObj)
+	systemPropertiesVersion++
+	if !hadValue {
+		return object.Null
+	}
+	return object.StringObjectFromGoString(previous)
+}
+
+// java/lang/System.clearProperty(Ljava/lang/String;)Ljava/lang/String; removes the property
+// and returns its previous value, or null if it had none
+func clearProperty(params []interface{}) interface{} {
+	key, errBlk := checkPropertyKey(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	fs := params[1].(*list.List)
+
+	systemPropertiesLock.Lock()
+	if props := systemPropertiesObj; props != nil {
+		systemPropertiesLock.Unlock() // not held while running Java code
+		return callProperties(fs, props, "remove", "(Ljava/lang/Object;)Ljava/lang/Object;", params[0])
+	}
+	defer systemPropertiesLock.Unlock()
+	if systemProperties == nil {
+		loadSystemProperties()
+	}
+
+	previous, hadValue := systemProperties[key]
+	if !hadValue {
+		return object.Null
+	}
+	delete(systemProperties, key)
+	systemPropertiesVersion++
+	return object.StringObjectFromGoString(previous)
+}
+
+// java/lang/System.getProperties()Ljava/util/Properties; returns the java.util.Properties
+// object that holds the system properties. The first call creates it from the property
+// table; every call returns the same object, as in HotSpot.
+func getProperties(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+
+	for {
+		systemPropertiesLock.Lock()
+		if props := systemPropertiesObj; props != nil {
+			systemPropertiesLock.Unlock()
+			return props
+		}
+		if systemProperties == nil {
+			loadSystemProperties()
+		}
+		keys := make([]string, 0, len(systemProperties))
+		for key := range systemProperties {
+			keys = append(keys, key)
 		}
-	case "native.encoding": // hard to find out what this is, so hard-coding to UTF8
-		value = "UTF8"
-	case "os.arch":
-		value = runtime.GOARCH
-	case "os.name":
-		value = operSys
-	case "os.version":
-		value = "not yet available"
-	case "path.separator":
-		value = string(os.PathSeparator)
-	case "user.dir": // present working directory
-		value, _ = os.Getwd()
-	case "user.home":
-		currentUser, _ := user.Current()
-		value = currentUser.HomeDir
-	case "user.name":
-		currentUser, _ := user.Current()
-		value = currentUser.Name
-	case "user.timezone":
-		now := time.Now()
-		value, _ = now.Zone()
-	default:
+		sort.Strings(keys)
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = systemProperties[key]
+		}
+		version := systemPropertiesVersion
+		systemPropertiesLock.Unlock() // not held while running Java code
+
+		props, err := makePropertiesObject(fs, keys, values)
+		if err != nil {
+			return propertiesError(err)
+		}
+
+		// the object becomes the property table unless another thread has installed one
+		// or changed a property in the meantime, in which case this is tried again
+		systemPropertiesLock.Lock()
+		if systemPropertiesObj == nil && systemPropertiesVersion == version {
+			systemPropertiesObj = props
+			systemPropertiesLock.Unlock()
+			return props
+		}
+		systemPropertiesLock.Unlock()
+	}
+}
+
+// makePropertiesObject creates a java.util.Properties object that holds the given properties
+func makePropertiesObject(fs *list.List, keys, values []string) (*object.Object, error) {
+	glob := globals.GetGlobalRef()
+	propertiesObj, err := glob.FuncInstantiateClass(propertiesClassName, fs)
+	if err != nil {
+		return nil, err
+	}
+	props := propertiesObj.(*object.Object)
+	if _, err = glob.FuncInvokeMethod(fs, propertiesClassName, "<init>", "()V", []any{props}); err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		args := []any{props, object.StringObjectFromGoString(key), object.StringObjectFromGoString(values[i])}
+		_, err = glob.FuncInvokeMethod(fs, propertiesClassName, "setProperty",
+			"(Ljava/lang/String;Ljava/lang/String;)Ljava/lang/Object;", args)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return props, nil
+}
+
+// callProperties calls a method of the Properties object that holds the system properties
+// and returns its result
+func callProperties(fs *list.List, props *object.Object, name, desc string, args ...any) interface{} {
+	ret, err := globals.GetGlobalRef().FuncInvokeMethod(fs, propertiesClassName, name, desc, append([]any{props}, args...))
+	if err != nil {
+		return propertiesError(err)
+	}
+	return ret
+}
+
+// propertiesError returns the error of a call to the Properties object: an exception thrown
+// by its Java code is returned as is, so that RunGfunction() rethrows it, and any other
+// error becomes an InternalException
+func propertiesError(err error) interface{} {
+	var javaEx *exceptions.JavaException
+	if errors.As(err, &javaEx) {
+		return err
+	}
+	return getGErrBlk(excNames.InternalException, "System properties: "+err.Error())
+}
+
+// java/lang/System.getenv(Ljava/lang/String;)Ljava/lang/String; returns the value of
+// an environment variable, or null if it's not defined
+func getenv(params []interface{}) interface{} {
+	name, ok := params[0].(*object.Object)
+	if !ok || object.IsNull(name) {
+		return getGErrBlk(excNames.NullPointerException, "getenv: name is null")
+	}
+	value, found := os.LookupEnv(object.GoStringFromStringObject(name))
+	if !found {
 		return object.Null
 	}
+	return object.StringObjectFromGoString(value)
+}
 
-	obj := object.StringObjectFromGoString(value)
-	return obj
+// java/lang/System.getenv()Ljava/util/Map; returns a java.util.Map of the environment
+// variables. Unlike in HotSpot, the map is not unmodifiable, but changes to it have no effect.
+func getenvAll(params []interface{}) interface{} {
+	envMap, errBlk := goStringMapToJava(params[0].(*list.List), getenvMap())
+	if errBlk != nil {
+		return errBlk
+	}
+	return envMap
 }
@@ -7,9 +7,16 @@
 package gfunction
 
 import (
+	"container/list"
+	"errors"
+	"jacobin/excNames"
+	"jacobin/exceptions"
+	"jacobin/frames"
 	"jacobin/globals"
 	"jacobin/object"
 	"jacobin/stringPool"
+	"jacobin/types"
+	"os"
 	"strings"
 	"testing"
 )
@@ -244,3 +251,156 @@ func TestArrayCopyInvalidLength(t *testing.T) {
 		t.Errorf("Expected error re invalid length, got %s", errMsg)
 	}
 }
+
+// -D options override the built-in properties, and java.class.path reflects the classpath
+func TestSystemPropertiesFromLaunchConfiguration(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	g.Classpath = []string{"classes", "lib.jar"}
+	g.SystemProperties["app.mode"] = "test"
+	g.SystemProperties["java.vendor"] = "Someone Else"
+	InitSystemProperties()
+	fs := frames.CreateFrameStack()
+
+	expected := map[string]string{
+		"java.class.path": "classes" + string(os.PathListSeparator) + "lib.jar",
+		"app.mode":        "test",
+		"java.vendor":     "Someone Else",
+	}
+	for key, value := range expected {
+		ret := getProperty([]interface{}{object.StringObjectFromGoString(key), fs}).(*object.Object)
+		if object.GoStringFromStringObject(ret) != value {
+			t.Errorf("Expected %s to be %s, got: %s", key, value, object.GoStringFromStringObject(ret))
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	ret := getProperty([]interface{}{object.StringObjectFromGoString("user.dir"), fs}).(*object.Object)
+	if object.GoStringFromStringObject(ret) != cwd {
+		t.Errorf("Expected user.dir to be %s, got: %s", cwd, object.GoStringFromStringObject(ret))
+	}
+}
+
+func TestSystemSetGetAndClearProperty(t *testing.T) {
+	globals.InitGlobals("test")
+	InitSystemProperties()
+	fs := frames.CreateFrameStack()
+	key := object.StringObjectFromGoString("jacobin.test")
+
+	if ret := setProperty([]interface{}{key, object.StringObjectFromGoString("one"), fs}); !object.IsNull(ret.(*object.Object)) {
+		t.Errorf("Expected setProperty of a new property to return null, got: %v", ret)
+	}
+	ret := setProperty([]interface{}{key, object.StringObjectFromGoString("two"), fs}).(*object.Object)
+	if object.GoStringFromStringObject(ret) != "one" {
+		t.Errorf("Expected setProperty to return the previous value, got: %s", object.GoStringFromStringObject(ret))
+	}
+
+	ret = clearProperty([]interface{}{key, fs}).(*object.Object)
+	if object.GoStringFromStringObject(ret) != "two" {
+		t.Errorf("Expected clearProperty to return the previous value, got: %s", object.GoStringFromStringObject(ret))
+	}
+	if ret = getProperty([]interface{}{key, fs}).(*object.Object); !object.IsNull(ret) {
+		t.Errorf("Expected a cleared property to be null, got: %s", object.GoStringFromStringObject(ret))
+	}
+
+	def := object.StringObjectFromGoString("default")
+	if getProperty([]interface{}{key, def, fs}) != def {
+		t.Error("Expected getProperty(key, default) to return the default for a missing property")
+	}
+
+	empty := object.StringObjectFromGoString("")
+	if errBlk, ok := getProperty([]interface{}{empty, fs}).(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Error("Expected IllegalArgumentException for an empty key")
+	}
+	if errBlk, ok := setProperty([]interface{}{object.Null, def, fs}).(*GErrBlk); !ok || errBlk.ExceptionType != excNames.NullPointerException {
+		t.Error("Expected NullPointerException for a null key")
+	}
+}
+
+// getProperties() returns the same Properties object on every call, and once it exists, the
+// System property methods read and change it
+func TestSystemGetPropertiesIsThePropertyTable(t *testing.T) {
+	globals.InitGlobals("test")
+	InitSystemProperties()
+	fs := frames.CreateFrameStack()
+	glob := globals.GetGlobalRef()
+
+	// a Properties object is modeled by a Go map
+	tables := map[*object.Object]map[string]*object.Object{}
+	glob.FuncInstantiateClass = func(className string, _ *list.List) (any, error) {
+		return object.MakeEmptyObjectWithClassName(&className), nil
+	}
+	glob.FuncInvokeMethod = func(_ *list.List, className, name, desc string, args []any) (any, error) {
+		props := args[0].(*object.Object)
+		if name == "<init>" {
+			tables[props] = map[string]*object.Object{}
+			return nil, nil
+		}
+		table := tables[props]
+		key := object.GoStringFromStringObject(args[1].(*object.Object))
+		previous, ok := table[key]
+		if !ok {
+			previous = object.Null
+		}
+		switch name {
+		case "setProperty":
+			table[key] = args[2].(*object.Object)
+		case "remove":
+			delete(table, key)
+		}
+		return previous, nil
+	}
+
+	props := getProperties([]interface{}{fs}).(*object.Object)
+	if getProperties([]interface{}{fs}) != props {
+		t.Fatal("expected getProperties() to return the same object on every call")
+	}
+	if value := tables[props]["java.vendor"]; value == nil || object.GoStringFromStringObject(value) != "Jacobin" {
+		t.Errorf("expected the Properties object to hold java.vendor, got %v", value)
+	}
+
+	// System.getProperties().setProperty(k, v) is seen by System.getProperty(k)
+	tables[props]["jacobin.test"] = object.StringObjectFromGoString("set")
+	key := object.StringObjectFromGoString("jacobin.test")
+	if ret := getProperty([]interface{}{key, fs}).(*object.Object); object.GoStringFromStringObject(ret) != "set" {
+		t.Errorf("expected getProperty() to read the Properties object, got %s", object.GoStringFromStringObject(ret))
+	}
+	setProperty([]interface{}{key, object.StringObjectFromGoString("changed"), fs})
+	if value := tables[props]["jacobin.test"]; object.GoStringFromStringObject(value) != "changed" {
+		t.Errorf("expected setProperty() to change the Properties object, got %s", object.GoStringFromStringObject(value))
+	}
+	clearProperty([]interface{}{key, fs})
+	if _, ok := tables[props]["jacobin.test"]; ok {
+		t.Error("expected clearProperty() to remove the property from the Properties object")
+	}
+
+	// an exception thrown by the Properties object is returned as is, so that it's rethrown
+	throwable := object.MakeEmptyObjectWithClassName(&types.ObjectClassName)
+	glob.FuncInvokeMethod = func(*list.List, string, string, string, []any) (any, error) {
+		return nil, &exceptions.JavaException{Throwable: throwable}
+	}
+	var javaEx *exceptions.JavaException
+	if err, ok := getProperty([]interface{}{key, fs}).(error); !ok || !errors.As(err, &javaEx) || javaEx.Throwable != throwable {
+		t.Error("expected getProperty() to return the exception thrown by the Properties object")
+	}
+}
+
+func TestSystemGetenv(t *testing.T) {
+	globals.InitGlobals("test")
+	t.Setenv("JACOBIN_TEST_GETENV", "some value")
+
+	value := getenv([]interface{}{object.StringObjectFromGoString("JACOBIN_TEST_GETENV")})
+	if object.GoStringFromStringObject(value.(*object.Object)) != "some value" {
+		t.Errorf("expected getenv() to return \"some value\", got %v", value)
+	}
+
+	value = getenv([]interface{}{object.StringObjectFromGoString("JACOBIN_TEST_GETENV_UNDEFINED")})
+	if !object.IsNull(value.(*object.Object)) {
+		t.Errorf("expected getenv() of an undefined variable to return null, got %v", value)
+	}
+
+	errBlk, ok := getenv([]interface{}{object.Null}).(*GErrBlk)
+	if !ok || errBlk.ExceptionType != excNames.NullPointerException {
+		t.Errorf("expected NullPointerException from getenv(null), got %v", errBlk)
+	}
+}
@@ -1,13 +1,25 @@
 /*
  * Jacobin VM - A Java virtual machine
- * Copyright (c) 2023 by  the Jacobin authors. Consult jacobin.org.
+ * Copyright (c) 2023-4 by  the Jacobin authors. Consult jacobin.org.
  * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
  */
 
 package gfunction
 
 import (
+	"container/list"
+	"errors"
+	"fmt"
 	"jacobin/excNames"
+	"jacobin/exceptions"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/thread"
+	"jacobin/types"
+	"runtime"
+	"sync/atomic"
 	"time"
 )
 
@@ -27,29 +39,736 @@ import (
  could mean an empty slice).
 */
 
+// A java.lang.Thread object in Jacobin holds a pointer to its execution thread
+// (a thread.ExecThread) in the Jacobin-specific field named below. All the
+// state of the Java thread (its name, daemon status, etc.) is kept there.
+const threadExecField = "execThread"
+
+var threadClassName = "java/lang/Thread"
+
+// threadInitNumber is used to generate the default names of threads: Thread-0, Thread-1, etc.
+var threadInitNumber atomic.Int64
+
 func Load_Lang_Thread() {
 
+	MethodSignatures["java/lang/Thread.<clinit>()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  justReturn,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>()V"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    threadInit,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/Runnable;)V"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    threadInitRunnable,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/Runnable;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    threadInitRunnableName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    threadInitName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/Runnable;)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    threadInitGroupRunnable,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    threadInitGroupName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/Runnable;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots:   3,
+			GFunction:    threadInitGroupRunnableName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/Runnable;Ljava/lang/String;J)V"] =
+		GMeth{
+			ParamSlots:   5,
+			GFunction:    threadInitGroupRunnableName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.currentThread()Ljava/lang/Thread;"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    threadCurrentThread,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.getDefaultUncaughtExceptionHandler()Ljava/lang/Thread$UncaughtExceptionHandler;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetDefaultUncaughtExceptionHandler,
+		}
+
+	MethodSignatures["java/lang/Thread.getId()J"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetId,
+		}
+
+	MethodSignatures["java/lang/Thread.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetName,
+		}
+
+	MethodSignatures["java/lang/Thread.getPriority()I"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetPriority,
+		}
+
+	MethodSignatures["java/lang/Thread.getThreadGroup()Ljava/lang/ThreadGroup;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetThreadGroup,
+		}
+
+	MethodSignatures["java/lang/Thread.getUncaughtExceptionHandler()Ljava/lang/Thread$UncaughtExceptionHandler;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetUncaughtExceptionHandler,
+		}
+
+	MethodSignatures["java/lang/Thread.interrupt()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadInterrupt,
+		}
+
+	MethodSignatures["java/lang/Thread.interrupted()Z"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    threadInterrupted,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.isAlive()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadIsAlive,
+		}
+
+	MethodSignatures["java/lang/Thread.isDaemon()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadIsDaemon,
+		}
+
+	MethodSignatures["java/lang/Thread.isInterrupted()Z"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadIsInterrupted,
+		}
+
+	MethodSignatures["java/lang/Thread.join()V"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    threadJoin,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.join(J)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    threadJoinMillis,
+			NeedsContext: true,
+		}
+
 	MethodSignatures["java/lang/Thread.registerNatives()V"] =
 		GMeth{
 			ParamSlots: 0,
 			GFunction:  justReturn,
 		}
 
+	MethodSignatures["java/lang/Thread.run()V"] =
+		GMeth{
+			ParamSlots:   0,
+			GFunction:    threadRun,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.setDaemon(Z)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadSetDaemon,
+		}
+
+	MethodSignatures["java/lang/Thread.setDefaultUncaughtExceptionHandler(Ljava/lang/Thread$UncaughtExceptionHandler;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadSetDefaultUncaughtExceptionHandler,
+		}
+
+	MethodSignatures["java/lang/Thread.setName(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadSetName,
+		}
+
+	MethodSignatures["java/lang/Thread.setPriority(I)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadSetPriority,
+		}
+
+	MethodSignatures["java/lang/Thread.setUncaughtExceptionHandler(Ljava/lang/Thread$UncaughtExceptionHandler;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  threadSetUncaughtExceptionHandler,
+		}
+
 	MethodSignatures["java/lang/Thread.sleep(J)V"] =
 		GMeth{
-			ParamSlots: 2,
-			GFunction:  threadSleep,
+			ParamSlots:   2,
+			GFunction:    threadSleep,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Thread.start()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadStart,
+		}
+
+	MethodSignatures["java/lang/Thread.threadId()J"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGetId,
+		}
+
+	MethodSignatures["java/lang/Thread.toString()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadToString,
+		}
+
+	MethodSignatures["java/lang/Thread.yield()V"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadYield,
+		}
+
+}
+
+// get the execution thread of a java.lang.Thread object. Returns nil if there is none.
+func getExecThread(obj any) *thread.ExecThread {
+	threadObj, ok := obj.(*object.Object)
+	if !ok || object.IsNull(threadObj) {
+		return nil
+	}
+	fld, ok := threadObj.FieldTable[threadExecField]
+	if !ok {
+		return nil
+	}
+	th, _ := fld.Fvalue.(*thread.ExecThread)
+	return th
+}
+
+// get the execution thread of a java.lang.Thread object or an error block if there is none
+func getExecThreadOrErr(obj any) (*thread.ExecThread, *GErrBlk) {
+	th := getExecThread(obj)
+	if th == nil {
+		errMsg := fmt.Sprintf("Invalid or uninitialized Thread object: %v", obj)
+		return nil, getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	}
+	return th, nil
+}
+
+// get the execution thread that is running the frame at the top of the frame stack
+func getCurrentExecThread(fs *list.List) *thread.ExecThread {
+	if fs == nil || fs.Len() == 0 {
+		return nil
+	}
+	f := fs.Front().Value.(*frames.Frame)
+	return thread.GetThread(f.Thread)
+}
+
+// attach a new execution thread to a Thread object. The new thread inherits its
+// daemon status, priority, and (if none is specified) thread group from the thread
+// that creates it, as in HotSpot.
+func initThread(fs *list.List, threadObj *object.Object, group, target, name any) interface{} {
+	newThread := thread.CreateThread()
+	th := &newThread
+	th.JavaThread = threadObj
+
+	if parent := getCurrentExecThread(fs); parent != nil {
+		glob := globals.GetGlobalRef()
+		glob.ThreadLock.Lock()
+		th.Daemon = parent.Daemon
+		th.Priority = parent.Priority
+		th.Group = parent.Group
+		glob.ThreadLock.Unlock()
+	}
+	if group != nil && !object.IsNull(group) {
+		th.Group = group
+	}
+
+	if target != nil && !object.IsNull(target) {
+		th.Target = target
+	}
+
+	switch name.(type) {
+	case nil:
+		th.Name = fmt.Sprintf("Thread-%d", threadInitNumber.Add(1)-1)
+	case *object.Object:
+		nameObj := name.(*object.Object)
+		if object.IsNull(nameObj) {
+			return getGErrBlk(excNames.NullPointerException, "name cannot be null")
 		}
+		th.Name = object.GoStringFromStringObject(nameObj)
+	}
+
+	threadObj.FieldTable[threadExecField] = object.Field{Ftype: types.Ref, Fvalue: th}
+	return nil
+}
+
+// "java/lang/Thread.<init>()V"
+func threadInit(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), nil, nil, nil)
+}
+
+// "java/lang/Thread.<init>(Ljava/lang/Runnable;)V"
+func threadInitRunnable(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), nil, params[2], nil)
+}
+
+// "java/lang/Thread.<init>(Ljava/lang/Runnable;Ljava/lang/String;)V"
+func threadInitRunnableName(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), nil, params[2], params[3])
+}
+
+// "java/lang/Thread.<init>(Ljava/lang/String;)V"
+func threadInitName(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), nil, nil, params[2])
+}
+
+// "java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/Runnable;)V"
+func threadInitGroupRunnable(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), params[2], params[3], nil)
+}
+
+// "java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/String;)V"
+func threadInitGroupName(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), params[2], nil, params[3])
+}
 
+// "java/lang/Thread.<init>(Ljava/lang/ThreadGroup;Ljava/lang/Runnable;Ljava/lang/String;)V"
+// and the same constructor with a trailing stack-size parameter (which is ignored)
+func threadInitGroupRunnableName(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	return initThread(fs, params[1].(*object.Object), params[2], params[3], params[4])
 }
 
-// "java/lang/Thread.sleep(J)V"
+// "java/lang/Thread.currentThread()Ljava/lang/Thread;"
+// The main thread is not created by the application, so its Thread object
+// is created here the first time it's requested.
+func threadCurrentThread(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	th := getCurrentExecThread(fs)
+	if th == nil {
+		errMsg := "Thread.currentThread(): current thread not found"
+		return getGErrBlk(excNames.InternalException, errMsg)
+	}
+
+	return getJavaThread(th)
+}
+
+// get the Thread object of an execution thread, creating it if need be (as it is
+// for the main thread, which is not created by the application)
+func getJavaThread(th *thread.ExecThread) *object.Object {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	if th.JavaThread == nil {
+		threadObj := object.MakeEmptyObjectWithClassName(&threadClassName)
+		threadObj.FieldTable[threadExecField] = object.Field{Ftype: types.Ref, Fvalue: th}
+		th.JavaThread = threadObj
+	}
+	return th.JavaThread.(*object.Object)
+}
+
+// "java/lang/Thread.getId()J" and "java/lang/Thread.threadId()J"
+func threadGetId(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	return int64(th.ID)
+}
+
+// "java/lang/Thread.getName()Ljava/lang/String;"
+func threadGetName(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	return object.StringObjectFromGoString(th.GetName())
+}
+
+// "java/lang/Thread.setName(Ljava/lang/String;)V"
+func threadSetName(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	nameObj, ok := params[1].(*object.Object)
+	if !ok || object.IsNull(nameObj) {
+		return getGErrBlk(excNames.NullPointerException, "name cannot be null")
+	}
+	th.SetName(object.GoStringFromStringObject(nameObj))
+	return nil
+}
+
+// "java/lang/Thread.getPriority()I"
+func threadGetPriority(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	return th.GetPriority()
+}
+
+// "java/lang/Thread.setPriority(I)V"
+func threadSetPriority(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	priority := params[1].(int64)
+	if priority < thread.MinPriority || priority > thread.MaxPriority {
+		errMsg := fmt.Sprintf("Thread priority out of range: %d", priority)
+		return getGErrBlk(excNames.IllegalArgumentException, errMsg)
+	}
+	th.SetPriority(priority)
+	return nil
+}
+
+// "java/lang/Thread.interrupt()V"
+func threadInterrupt(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	th.Interrupt()
+	return nil
+}
+
+// "java/lang/Thread.interrupted()Z" -- returns and clears the interrupt status of the current thread
+func threadInterrupted(params []interface{}) interface{} {
+	th := getCurrentExecThread(params[0].(*list.List))
+	if th != nil && th.ClearInterrupt() {
+		return types.JavaBoolTrue
+	}
+	return types.JavaBoolFalse
+}
+
+// "java/lang/Thread.isInterrupted()Z" -- returns the interrupt status without clearing it
+func threadIsInterrupted(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	if th.IsInterrupted() {
+		return types.JavaBoolTrue
+	}
+	return types.JavaBoolFalse
+}
+
+// "java/lang/Thread.isAlive()Z"
+func threadIsAlive(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	if th.IsAlive() {
+		return types.JavaBoolTrue
+	}
+	return types.JavaBoolFalse
+}
+
+// "java/lang/Thread.isDaemon()Z"
+func threadIsDaemon(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	if th.IsDaemon() {
+		return types.JavaBoolTrue
+	}
+	return types.JavaBoolFalse
+}
+
+// "java/lang/Thread.setDaemon(Z)V" -- can be called only before the thread is started
+func threadSetDaemon(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	if !th.SetDaemon(params[1].(int64) != types.JavaBoolFalse) {
+		errMsg := fmt.Sprintf("Thread %s is already running", th.GetName())
+		return getGErrBlk(excNames.IllegalThreadStateException, errMsg)
+	}
+	return nil
+}
+
+// "java/lang/Thread.start()V"
+func threadStart(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	alreadyStarted := th.Started
+	th.Started = true
+	glob.ThreadLock.Unlock()
+	if alreadyStarted {
+		errMsg := fmt.Sprintf("Thread %s has already been started", th.GetName())
+		return getGErrBlk(excNames.IllegalThreadStateException, errMsg)
+	}
+
+	if err := glob.FuncStartThread(th); err != nil {
+		return getGErrBlk(excNames.InternalException, err.Error())
+	}
+	return nil
+}
+
+// "java/lang/Thread.run()V" -- runs the Runnable passed to the constructor, if any.
+// This is called on the thread's own goroutine after start(), or on the calling
+// thread if the application calls run() directly.
+func threadRun(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	th, errBlk := getExecThreadOrErr(params[1])
+	if errBlk != nil {
+		return errBlk
+	}
+	if th.Target == nil {
+		return nil
+	}
+
+	target := th.Target.(*object.Object)
+	targetClass := *stringPool.GetStringPointer(target.KlassName)
+	_, err := globals.GetGlobalRef().FuncInvokeMethod(fs, targetClass, "run", "()V", []any{target})
+	var javaEx *exceptions.JavaException
+	if errors.As(err, &javaEx) {
+		return err // an exception thrown by the target's run() is rethrown by RunGfunction()
+	}
+	if err != nil {
+		return getGErrBlk(excNames.InternalException, err.Error())
+	}
+	return nil
+}
+
+// "java/lang/Thread.join()V" -- waits for the thread to finish
+func threadJoin(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	th, errBlk := getExecThreadOrErr(params[1])
+	if errBlk != nil {
+		return errBlk
+	}
+	return waitForThread(fs, th, 0)
+}
+
+// "java/lang/Thread.join(J)V" -- waits at most the given number of milliseconds
+// for the thread to finish. A wait of 0 means wait forever.
+func threadJoinMillis(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	th, errBlk := getExecThreadOrErr(params[1])
+	if errBlk != nil {
+		return errBlk
+	}
+	millis := params[2].(int64)
+	if millis < 0 {
+		return getGErrBlk(excNames.IllegalArgumentException, "timeout value is negative")
+	}
+	return waitForThread(fs, th, millis)
+}
+
+// the current thread (the one running on frame stack fs) waits for thread th to
+// finish, for at most millis milliseconds (0 = no limit). The wait ends with an
+// InterruptedException if the current thread is interrupted.
+func waitForThread(fs *list.List, th *thread.ExecThread, millis int64) interface{} {
+	var interrupt <-chan struct{}
+	if current := getCurrentExecThread(fs); current != nil {
+		if current.ClearInterrupt() {
+			return getGErrBlk(excNames.InterruptedException, "join interrupted")
+		}
+		interrupt = current.InterruptCh
+	}
+
+	if !th.IsAlive() {
+		return nil
+	}
+
+	var timer <-chan time.Time
+	if millis > 0 {
+		t := time.NewTimer(time.Duration(millis) * time.Millisecond)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case <-th.Done:
+	case <-timer:
+	case <-interrupt:
+		getCurrentExecThread(fs).ClearInterrupt()
+		return getGErrBlk(excNames.InterruptedException, "join interrupted")
+	}
+	return nil
+}
+
+// "java/lang/Thread.getThreadGroup()Ljava/lang/ThreadGroup;" -- threads that aren't
+// created in a specific thread group belong to the main thread group
+func threadGetThreadGroup(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	return getThreadGroup(th)
+}
+
+// "java/lang/Thread.setUncaughtExceptionHandler(Ljava/lang/Thread$UncaughtExceptionHandler;)V"
+// A null handler removes the thread's handler.
+func threadSetUncaughtExceptionHandler(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	th.UncaughtHandler = nil
+	if !object.IsNull(params[1]) {
+		th.UncaughtHandler = params[1]
+	}
+	return nil
+}
+
+// "java/lang/Thread.getUncaughtExceptionHandler()Ljava/lang/Thread$UncaughtExceptionHandler;"
+// As in HotSpot, if the thread has no handler of its own, its thread group is returned.
+func threadGetUncaughtExceptionHandler(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	if handler := getUncaughtHandler(th); handler != nil {
+		return handler
+	}
+	return getThreadGroup(th)
+}
+
+// "java/lang/Thread.setDefaultUncaughtExceptionHandler(Ljava/lang/Thread$UncaughtExceptionHandler;)V"
+func threadSetDefaultUncaughtExceptionHandler(params []interface{}) interface{} {
+	defaultUncaughtHandler.Lock()
+	defer defaultUncaughtHandler.Unlock()
+	defaultUncaughtHandler.handler = nil
+	if !object.IsNull(params[0]) {
+		defaultUncaughtHandler.handler = params[0].(*object.Object)
+	}
+	return nil
+}
+
+// "java/lang/Thread.getDefaultUncaughtExceptionHandler()Ljava/lang/Thread$UncaughtExceptionHandler;"
+func threadGetDefaultUncaughtExceptionHandler([]interface{}) interface{} {
+	if handler := getDefaultUncaughtHandler(); handler != nil {
+		return handler
+	}
+	return object.Null
+}
+
+// "java/lang/Thread.toString()Ljava/lang/String;" -- same format as HotSpot's
+func threadToString(params []interface{}) interface{} {
+	th, errBlk := getExecThreadOrErr(params[0])
+	if errBlk != nil {
+		return errBlk
+	}
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	name, priority, started := th.Name, th.Priority, th.Started
+	glob.ThreadLock.Unlock()
+
+	group := "" // terminated threads no longer belong to a group
+	if !started || th.IsAlive() {
+		groupName := threadGroupGetName([]interface{}{getThreadGroup(th)}).(*object.Object)
+		group = "null"
+		if !object.IsNull(groupName) {
+			group = object.GoStringFromStringObject(groupName)
+		}
+	}
+	str := fmt.Sprintf("Thread[#%d,%s,%d,%s]", th.ID, name, priority, group)
+	return object.StringObjectFromGoString(str)
+}
+
+// "java/lang/Thread.yield()V"
+func threadYield([]interface{}) interface{} {
+	runtime.Gosched()
+	return nil
+}
+
+// "java/lang/Thread.sleep(J)V" -- the sleep ends with an InterruptedException
+// if the thread is interrupted
 func threadSleep(params []interface{}) interface{} {
-	sleepTime, ok := params[0].(int64)
+	fs := params[0].(*list.List)
+	sleepTime, ok := params[1].(int64)
 	if !ok {
 		errMsg := "Parameter must be an int64 (long)"
 		return getGErrBlk(excNames.IOException, errMsg)
 	}
-	time.Sleep(time.Duration(sleepTime) * time.Millisecond)
+	if sleepTime < 0 {
+		return getGErrBlk(excNames.IllegalArgumentException, "timeout value is negative")
+	}
+
+	th := getCurrentExecThread(fs)
+	if th == nil {
+		time.Sleep(time.Duration(sleepTime) * time.Millisecond)
+		return nil
+	}
+
+	if th.ClearInterrupt() {
+		return getGErrBlk(excNames.InterruptedException, "sleep interrupted")
+	}
+
+	timer := time.NewTimer(time.Duration(sleepTime) * time.Millisecond)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-th.InterruptCh:
+		th.ClearInterrupt()
+		return getGErrBlk(excNames.InterruptedException, "sleep interrupted")
+	}
 	return nil
 }
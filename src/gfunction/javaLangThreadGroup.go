@@ -0,0 +1,220 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"fmt"
+	"jacobin/excNames"
+	"jacobin/exceptions"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/thread"
+	"jacobin/types"
+	"os"
+	"sync"
+)
+
+// java.lang.ThreadGroup and the handling of uncaught exceptions. When an exception is
+// not caught on a thread, it's dispatched, as in HotSpot, to the thread's uncaught
+// exception handler, if it has one, or else to its thread group. The thread group
+// passes it to its parent group, if any, and then to the default handler (set by
+// Thread.setDefaultUncaughtExceptionHandler()). If there's no default handler, the
+// exception's stack trace is printed. A ThreadGroup subclass can override
+// uncaughtException() to handle the exceptions of all the threads in the group.
+//
+// Jacobin keeps only a thread group's name and parent. Threads that are not created
+// in a specific group (including the main thread) belong to the main thread group.
+
+var threadGroupClassName = "java/lang/ThreadGroup"
+
+// the signature of Thread.UncaughtExceptionHandler.uncaughtException()
+const uncaughtExceptionSignature = "(Ljava/lang/Thread;Ljava/lang/Throwable;)V"
+
+var mainThreadGroup struct {
+	once  sync.Once
+	group *object.Object
+}
+
+var defaultUncaughtHandler struct {
+	sync.Mutex
+	handler *object.Object
+}
+
+func Load_Lang_ThreadGroup() {
+
+	MethodSignatures["java/lang/ThreadGroup.<init>(Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    threadGroupInitName,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/ThreadGroup.<init>(Ljava/lang/ThreadGroup;Ljava/lang/String;)V"] =
+		GMeth{
+			ParamSlots: 2,
+			GFunction:  threadGroupInitParentName,
+		}
+
+	MethodSignatures["java/lang/ThreadGroup.getName()Ljava/lang/String;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGroupGetName,
+		}
+
+	MethodSignatures["java/lang/ThreadGroup.getParent()Ljava/lang/ThreadGroup;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  threadGroupGetParent,
+		}
+
+	MethodSignatures["java/lang/ThreadGroup.uncaughtException(Ljava/lang/Thread;Ljava/lang/Throwable;)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    threadGroupUncaughtException,
+			NeedsContext: true,
+		}
+}
+
+// initialize a thread group with its name and parent
+func initThreadGroup(group *object.Object, parent *object.Object, name any) interface{} {
+	if object.IsNull(parent) {
+		return getGErrBlk(excNames.NullPointerException, "parent thread group is null")
+	}
+	group.FieldTable["name"] = object.Field{Ftype: types.Ref, Fvalue: name}
+	group.FieldTable["parent"] = object.Field{Ftype: types.Ref, Fvalue: parent}
+	return nil
+}
+
+// "java/lang/ThreadGroup.<init>(Ljava/lang/String;)V" -- the parent is the
+// thread group of the current thread
+func threadGroupInitName(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	parent := getThreadGroup(getCurrentExecThread(fs))
+	return initThreadGroup(params[1].(*object.Object), parent, params[2])
+}
+
+// "java/lang/ThreadGroup.<init>(Ljava/lang/ThreadGroup;Ljava/lang/String;)V"
+func threadGroupInitParentName(params []interface{}) interface{} {
+	parent, _ := params[1].(*object.Object)
+	return initThreadGroup(params[0].(*object.Object), parent, params[2])
+}
+
+// "java/lang/ThreadGroup.getName()Ljava/lang/String;"
+func threadGroupGetName(params []interface{}) interface{} {
+	group := params[0].(*object.Object)
+	if name, ok := group.FieldTable["name"].Fvalue.(*object.Object); ok {
+		return name
+	}
+	return object.Null
+}
+
+// "java/lang/ThreadGroup.getParent()Ljava/lang/ThreadGroup;" -- the main thread group has no parent
+func threadGroupGetParent(params []interface{}) interface{} {
+	group := params[0].(*object.Object)
+	if parent, ok := group.FieldTable["parent"].Fvalue.(*object.Object); ok {
+		return parent
+	}
+	return object.Null
+}
+
+// "java/lang/ThreadGroup.uncaughtException(Ljava/lang/Thread;Ljava/lang/Throwable;)V"
+func threadGroupUncaughtException(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	group := params[1].(*object.Object)
+	threadObj, throwable := params[2], params[3]
+
+	if parent, ok := group.FieldTable["parent"].Fvalue.(*object.Object); ok && !object.IsNull(parent) {
+		invokeUncaughtHandler(fs, parent, threadObj, throwable)
+	} else if handler := getDefaultUncaughtHandler(); handler != nil {
+		invokeUncaughtHandler(fs, handler, threadObj, throwable)
+	} else {
+		printUncaughtException(fs, threadObj, throwable)
+	}
+	return nil
+}
+
+// get the thread group of an execution thread
+func getThreadGroup(th *thread.ExecThread) *object.Object {
+	if th != nil {
+		glob := globals.GetGlobalRef()
+		glob.ThreadLock.Lock()
+		group, ok := th.Group.(*object.Object)
+		glob.ThreadLock.Unlock()
+		if ok {
+			return group
+		}
+	}
+
+	mainThreadGroup.once.Do(func() {
+		mainThreadGroup.group = object.MakeEmptyObjectWithClassName(&threadGroupClassName)
+		mainThreadGroup.group.FieldTable["name"] = object.Field{
+			Ftype: types.Ref, Fvalue: object.StringObjectFromGoString("main")}
+		mainThreadGroup.group.FieldTable["parent"] = object.Field{Ftype: types.Ref, Fvalue: object.Null}
+	})
+	return mainThreadGroup.group
+}
+
+// get the uncaught exception handler set for a thread, or nil if there is none
+func getUncaughtHandler(th *thread.ExecThread) *object.Object {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	handler, _ := th.UncaughtHandler.(*object.Object)
+	return handler
+}
+
+// get the default uncaught exception handler, or nil if there is none
+func getDefaultUncaughtHandler() *object.Object {
+	defaultUncaughtHandler.Lock()
+	defer defaultUncaughtHandler.Unlock()
+	return defaultUncaughtHandler.handler
+}
+
+// call the uncaughtException() method of a handler or thread group. As in HotSpot,
+// any exception thrown by the handler is ignored.
+func invokeUncaughtHandler(fs *list.List, handler *object.Object, threadObj, throwable any) {
+	className := *stringPool.GetStringPointer(handler.KlassName)
+	_, _ = globals.GetGlobalRef().FuncInvokeMethod(fs, className, "uncaughtException",
+		uncaughtExceptionSignature, []any{handler, threadObj, throwable})
+}
+
+// print the stack trace of an uncaught exception in the same format as HotSpot
+func printUncaughtException(fs *list.List, threadObj, throwable any) {
+	name := "main"
+	if th := getExecThread(threadObj); th != nil {
+		name = th.GetName()
+	}
+	throwObj, ok := throwable.(*object.Object)
+	if !ok || object.IsNull(throwObj) {
+		_, _ = fmt.Fprintf(os.Stderr, "Exception in thread \"%s\" null\n", name)
+		return
+	}
+	trace, _ := exceptions.StackTraceString(fs, throwObj)
+	_, _ = fmt.Fprintf(os.Stderr, "Exception in thread \"%s\" %s", name, trace)
+}
+
+// DispatchUncaughtException passes an exception that was not caught on a thread to the
+// thread's uncaught exception handler or, if it has none, to its thread group. It's
+// called on the dying thread, whose frame stack is used to run the handler. It returns
+// false if there's no application handler (so the stack trace is simply printed).
+func DispatchUncaughtException(th *thread.ExecThread, throwable *object.Object) bool {
+	threadObj := getJavaThread(th)
+	handler := getUncaughtHandler(th)
+	if handler == nil {
+		handler = getThreadGroup(th)
+	}
+	applicationHandler := handler != mainThreadGroup.group || getDefaultUncaughtHandler() != nil
+
+	if handler == mainThreadGroup.group { // there's no need to run ThreadGroup's method as Java code
+		_ = threadGroupUncaughtException([]interface{}{th.Stack, handler, threadObj, throwable})
+	} else {
+		invokeUncaughtHandler(th.Stack, handler, threadObj, throwable)
+	}
+	return applicationHandler
+}
@@ -0,0 +1,111 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"jacobin/globals"
+	"jacobin/object"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestThreadGroupsAndHandlers(t *testing.T) {
+	fs, mainThread := threadTestSetup()
+
+	// the main thread belongs to the main thread group, which has no parent
+	mainGroup := getThreadGroup(mainThread)
+	if name := threadGroupGetName([]interface{}{mainGroup}).(*object.Object); object.GoStringFromStringObject(name) != "main" {
+		t.Errorf("expected the main thread group to be named 'main', got %s", object.GoStringFromStringObject(name))
+	}
+	if !object.IsNull(threadGroupGetParent([]interface{}{mainGroup})) {
+		t.Error("expected the main thread group to have no parent")
+	}
+
+	group := object.MakeEmptyObjectWithClassName(&threadGroupClassName)
+	if ret := threadGroupInitName([]interface{}{fs, group, object.StringObjectFromGoString("workers")}); ret != nil {
+		t.Fatalf("ThreadGroup.<init> returned %v", ret)
+	}
+	if threadGroupGetParent([]interface{}{group}) != mainGroup {
+		t.Error("expected a new thread group's parent to be the current thread's group")
+	}
+
+	// a thread created in a group belongs to it, and so do the threads it creates
+	threadObj := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInitGroupName([]interface{}{fs, threadObj, group, object.StringObjectFromGoString("worker")})
+	if threadGetThreadGroup([]interface{}{threadObj}) != group {
+		t.Error("expected the thread to belong to the group it was created in")
+	}
+	str := threadToString([]interface{}{threadObj}).(*object.Object)
+	if expected := fmt.Sprintf("Thread[#%d,worker,5,workers]", getExecThread(threadObj).ID); object.GoStringFromStringObject(str) != expected {
+		t.Errorf("expected %s, got %s", expected, object.GoStringFromStringObject(str))
+	}
+	if threadGetUncaughtExceptionHandler([]interface{}{threadObj}) != group {
+		t.Error("expected a thread without a handler to return its group as its handler")
+	}
+
+	handlerClass := "test/Handler"
+	handler := object.MakeEmptyObjectWithClassName(&handlerClass)
+	_ = threadSetUncaughtExceptionHandler([]interface{}{threadObj, handler})
+	if threadGetUncaughtExceptionHandler([]interface{}{threadObj}) != handler {
+		t.Error("expected the thread's handler to be returned")
+	}
+	_ = threadSetUncaughtExceptionHandler([]interface{}{threadObj, object.Null})
+	if threadGetUncaughtExceptionHandler([]interface{}{threadObj}) != group {
+		t.Error("expected a null handler to remove the thread's handler")
+	}
+}
+
+func TestDispatchUncaughtException(t *testing.T) {
+	_, mainThread := threadTestSetup()
+	glob := globals.GetGlobalRef()
+	defer func() { _ = threadSetDefaultUncaughtExceptionHandler([]interface{}{object.Null}) }()
+
+	excClass := "java/lang/RuntimeException"
+	throwable := object.MakeEmptyObjectWithClassName(&excClass)
+	throwable.FieldTable["detailMessage"] = object.Field{Ftype: "Ljava/lang/String;",
+		Fvalue: object.StringObjectFromGoString("boom")}
+
+	// with no handler, the stack trace is printed as HotSpot does
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+	handled := DispatchUncaughtException(mainThread, throwable)
+	_ = w.Close()
+	os.Stderr = normalStderr
+	out, _ := io.ReadAll(r)
+
+	if handled {
+		t.Error("expected no application handler to be reported")
+	}
+	if !strings.HasPrefix(string(out), "Exception in thread \"main\" java.lang.RuntimeException: boom") {
+		t.Errorf("expected the uncaught exception to be printed, got: %s", string(out))
+	}
+
+	// the default handler gets the exceptions of threads that have no handler of their own
+	handlerClass := "test/DefaultHandler"
+	handler := object.MakeEmptyObjectWithClassName(&handlerClass)
+	_ = threadSetDefaultUncaughtExceptionHandler([]interface{}{handler})
+	if threadGetDefaultUncaughtExceptionHandler(nil) != handler {
+		t.Error("expected the default handler to be returned")
+	}
+
+	var invoked []any
+	glob.FuncInvokeMethod = func(fs *list.List, className, methName, methType string, args []any) (any, error) {
+		invoked = append([]any{className, methName}, args...)
+		return nil, nil
+	}
+	if !DispatchUncaughtException(mainThread, throwable) {
+		t.Error("expected the default handler to be reported as an application handler")
+	}
+	if len(invoked) != 5 || invoked[0] != handlerClass || invoked[2] != handler || invoked[4] != throwable {
+		t.Errorf("expected the default handler to be called, got %v", invoked)
+	}
+}
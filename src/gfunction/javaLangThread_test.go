@@ -0,0 +1,183 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package gfunction
+
+import (
+	"container/list"
+	"fmt"
+	"jacobin/excNames"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/thread"
+	"jacobin/types"
+	"strings"
+	"testing"
+)
+
+// creates a frame stack whose top frame runs on a newly created main thread
+func threadTestSetup() (*list.List, *thread.ExecThread) {
+	globals.InitGlobals("test")
+	mainThread := thread.CreateThread()
+	mainThread.Name = "main"
+	mainThread.Started = true
+	mainThread.AddThreadToTable(globals.GetGlobalRef())
+
+	fs := frames.CreateFrameStack()
+	f := frames.CreateFrame(2)
+	f.Thread = mainThread.ID
+	_ = frames.PushFrame(fs, f)
+	return fs, &mainThread
+}
+
+func TestJavaLangThreadInitAndNames(t *testing.T) {
+	fs, _ := threadTestSetup()
+
+	threadObj := object.MakeEmptyObjectWithClassName(&threadClassName)
+	name := object.StringObjectFromGoString("worker")
+	if ret := threadInitName([]interface{}{fs, threadObj, name}); ret != nil {
+		t.Fatalf("threadInitName returned %v", ret)
+	}
+
+	nameObj := threadGetName([]interface{}{threadObj}).(*object.Object)
+	if object.GoStringFromStringObject(nameObj) != "worker" {
+		t.Errorf("expected thread name 'worker', got %s", object.GoStringFromStringObject(nameObj))
+	}
+
+	_ = threadSetName([]interface{}{threadObj, object.StringObjectFromGoString("renamed")})
+	nameObj = threadGetName([]interface{}{threadObj}).(*object.Object)
+	if object.GoStringFromStringObject(nameObj) != "renamed" {
+		t.Errorf("expected thread name 'renamed', got %s", object.GoStringFromStringObject(nameObj))
+	}
+
+	ret := threadSetName([]interface{}{threadObj, object.Null})
+	if _, ok := ret.(*GErrBlk); !ok {
+		t.Errorf("expected an error block for a null name, got %T", ret)
+	}
+
+	// a thread created without a name gets a name of the form Thread-n
+	unnamed := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, unnamed})
+	th := getExecThread(unnamed)
+	if th == nil || len(th.Name) < len("Thread-0") || th.Name[:7] != "Thread-" {
+		t.Errorf("expected a default thread name, got %v", th)
+	}
+}
+
+func TestJavaLangThreadDaemonAndAlive(t *testing.T) {
+	fs, mainThread := threadTestSetup()
+	mainThread.Daemon = true // new threads inherit the daemon status of their creator
+
+	threadObj := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, threadObj})
+	if threadIsDaemon([]interface{}{threadObj}) != types.JavaBoolTrue {
+		t.Error("expected new thread to inherit daemon status")
+	}
+
+	_ = threadSetDaemon([]interface{}{threadObj, types.JavaBoolFalse})
+	if threadIsDaemon([]interface{}{threadObj}) != types.JavaBoolFalse {
+		t.Error("expected thread not to be a daemon after setDaemon(false)")
+	}
+
+	if threadIsAlive([]interface{}{threadObj}) != types.JavaBoolFalse {
+		t.Error("expected an unstarted thread not to be alive")
+	}
+
+	// join() on an unstarted thread returns immediately
+	if ret := threadJoin([]interface{}{fs, threadObj}); ret != nil {
+		t.Errorf("threadJoin returned %v", ret)
+	}
+}
+
+func TestJavaLangThreadStartTwice(t *testing.T) {
+	fs, _ := threadTestSetup()
+	glob := globals.GetGlobalRef()
+
+	started := make(chan *thread.ExecThread, 1)
+	glob.FuncStartThread = func(t any) error {
+		th := t.(*thread.ExecThread)
+		started <- th
+		close(th.Done)
+		return nil
+	}
+
+	threadObj := object.MakeEmptyObjectWithClassName(&threadClassName)
+	_ = threadInit([]interface{}{fs, threadObj})
+	if ret := threadStart([]interface{}{threadObj}); ret != nil {
+		t.Fatalf("threadStart returned %v", ret)
+	}
+	if th := <-started; th != getExecThread(threadObj) {
+		t.Error("threadStart did not start the thread's own ExecThread")
+	}
+
+	// the thread has finished, so it's no longer alive, and it can't be restarted
+	if threadIsAlive([]interface{}{threadObj}) != types.JavaBoolFalse {
+		t.Error("expected a finished thread not to be alive")
+	}
+	str := threadToString([]interface{}{threadObj}).(*object.Object)
+	if !strings.HasSuffix(object.GoStringFromStringObject(str), ",]") {
+		t.Errorf("expected a finished thread to have no thread group, got %s", object.GoStringFromStringObject(str))
+	}
+	ret := threadStart([]interface{}{threadObj})
+	if _, ok := ret.(*GErrBlk); !ok {
+		t.Errorf("expected an error block when starting a thread twice, got %T", ret)
+	}
+}
+
+func TestJavaLangThreadCurrentThread(t *testing.T) {
+	fs, mainThread := threadTestSetup()
+
+	current := threadCurrentThread([]interface{}{fs})
+	if getExecThread(current) != mainThread {
+		t.Error("currentThread() did not return the running thread")
+	}
+	if threadCurrentThread([]interface{}{fs}) != current {
+		t.Error("currentThread() should return the same Thread object every time")
+	}
+
+	str := threadToString([]interface{}{current}).(*object.Object)
+	expected := fmt.Sprintf("Thread[#%d,main,5,main]", mainThread.ID)
+	if object.GoStringFromStringObject(str) != expected {
+		t.Errorf("expected %s, got %s", expected, object.GoStringFromStringObject(str))
+	}
+}
+
+func TestJavaLangThreadInterruptFlags(t *testing.T) {
+	fs, mainThread := threadTestSetup()
+	current := threadCurrentThread([]interface{}{fs})
+
+	if threadIsInterrupted([]interface{}{current}) != types.JavaBoolFalse {
+		t.Error("expected a new thread not to be interrupted")
+	}
+	_ = threadInterrupt([]interface{}{current})
+	if threadIsInterrupted([]interface{}{current}) != types.JavaBoolTrue {
+		t.Error("expected the thread to be interrupted after interrupt()")
+	}
+
+	// interrupted() returns the status and clears it
+	if threadInterrupted([]interface{}{fs}) != types.JavaBoolTrue {
+		t.Error("expected interrupted() to return true")
+	}
+	if threadInterrupted([]interface{}{fs}) != types.JavaBoolFalse {
+		t.Error("expected interrupted() to have cleared the interrupt status")
+	}
+
+	// sleeping with the interrupt status set throws an InterruptedException and clears the status
+	mainThread.Interrupt()
+	ret := threadSleep([]interface{}{fs, int64(10_000)})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.InterruptedException {
+		t.Errorf("expected an InterruptedException from sleep(), got %v", ret)
+	}
+	if mainThread.IsInterrupted() {
+		t.Error("expected sleep() to clear the interrupt status")
+	}
+
+	ret = threadSleep([]interface{}{fs, int64(-1)})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("expected an IllegalArgumentException for a negative sleep time, got %v", ret)
+	}
+}
@@ -10,11 +10,15 @@ import (
 	"container/list"
 	"errors"
 	"fmt"
+	"jacobin/excNames"
+	"jacobin/exceptions"
+	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/object"
 	"jacobin/shutdown"
 	"jacobin/statics"
 	"jacobin/types"
+	"os"
 )
 
 func Load_Lang_Throwable() {
@@ -26,16 +30,80 @@ func Load_Lang_Throwable() {
 			NeedsContext: true,
 		}
 
+	MethodSignatures["java/lang/Throwable.<init>(Ljava/lang/Throwable;)V"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    throwableInitWithCause,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Throwable.<init>(Ljava/lang/String;Ljava/lang/Throwable;)V"] =
+		GMeth{
+			ParamSlots:   2,
+			GFunction:    throwableInitWithMessageAndCause,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Throwable.<init>(Ljava/lang/String;Ljava/lang/Throwable;ZZ)V"] =
+		GMeth{
+			ParamSlots:   4,
+			GFunction:    throwableInitWithMessageAndCause,
+			NeedsContext: true,
+		}
+
 	MethodSignatures["java/lang/Throwable.<clinit>()V"] =
 		GMeth{
 			ParamSlots: 0,
 			GFunction:  throwableClinit,
 		}
 
-	MethodSignatures["java/lang/Throwable.getOurStackTrace:()[Ljava/lang/StackTraceElement;"] =
+	MethodSignatures["java/lang/Throwable.getCause()Ljava/lang/Throwable;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getCause,
+		}
+
+	MethodSignatures["java/lang/Throwable.initCause(Ljava/lang/Throwable;)Ljava/lang/Throwable;"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  initCause,
+		}
+
+	MethodSignatures["java/lang/Throwable.addSuppressed(Ljava/lang/Throwable;)V"] =
+		GMeth{
+			ParamSlots: 1,
+			GFunction:  addSuppressed,
+		}
+
+	MethodSignatures["java/lang/Throwable.getSuppressed()[Ljava/lang/Throwable;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getSuppressed,
+		}
+
+	MethodSignatures["java/lang/Throwable.getOurStackTrace()[Ljava/lang/StackTraceElement;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getOurStackTrace,
+		}
+
+	MethodSignatures["java/lang/Throwable.getStackTrace()[Ljava/lang/StackTraceElement;"] =
+		GMeth{
+			ParamSlots: 0,
+			GFunction:  getStackTrace,
+		}
+
+	MethodSignatures["java/lang/Throwable.printStackTrace()V"] =
 		GMeth{
 			ParamSlots:   0,
-			GFunction:    getOurStackTrace,
+			GFunction:    printStackTrace,
+			NeedsContext: true,
+		}
+
+	MethodSignatures["java/lang/Throwable.printStackTrace(Ljava/io/PrintStream;)V"] =
+		GMeth{
+			ParamSlots:   1,
+			GFunction:    printStackTrace,
 			NeedsContext: true,
 		}
 
@@ -118,7 +186,7 @@ func throwableClinit([]interface{}) interface{} {
  *         pertaining to this throwable.
  */
 func FillInStackTrace(params []interface{}) interface{} {
-	// get our parameters vetted and ready for use, then call getOurStackTrace()
+	// get our parameters vetted and ready for use, then call GetStackTraces()
 	if len(params) != 2 {
 		errMsg := fmt.Sprintf("FillInStackTrace: expected two parameters, got: %d", len(params))
 		_ = log.Log(errMsg, log.SEVERE)
@@ -141,7 +209,7 @@ func FillInStackTrace(params []interface{}) interface{} {
 	// fmt.Printf("Throwable object contains: %v\n", objRef.FieldTable)
 
 	args := []interface{}{objRef}
-	stackData := getOurStackTrace(args)
+	stackData := GetStackTraces(args)
 	throwable := *objRef
 
 	stackTraceField := object.Field{
@@ -152,10 +220,163 @@ func FillInStackTrace(params []interface{}) interface{} {
 	return &stackTraceField
 }
 
-// as described above, this function simply chains to GetStackTraces
+// java/lang/Throwable.<init>(Ljava/lang/Throwable;)V creates a Throwable whose
+// detail message is the string form of its cause (or null if the cause is null)
+func throwableInitWithCause(params []interface{}) interface{} {
+	fs := params[0].(*list.List)
+	cause := params[2].(*object.Object)
+	message := object.Null
+	if !object.IsNull(cause) {
+		str, err := exceptions.ThrowableToString(fs, cause)
+		if err != nil {
+			return err
+		}
+		message = object.StringObjectFromGoString(str)
+	}
+	return initThrowable(fs, params[1].(*object.Object), message, cause, true, true)
+}
+
+// java/lang/Throwable.<init>(Ljava/lang/String;Ljava/lang/Throwable;)V, and the protected
+// constructor that adds enableSuppression and writableStackTrace, in that order
+func throwableInitWithMessageAndCause(params []interface{}) interface{} {
+	enableSuppression, writableStackTrace := true, true
+	if len(params) == 6 {
+		enableSuppression = params[4].(int64) != types.JavaBoolFalse
+		writableStackTrace = params[5].(int64) != types.JavaBoolFalse
+	}
+	return initThrowable(params[0].(*list.List), params[1].(*object.Object),
+		params[2].(*object.Object), params[3].(*object.Object), enableSuppression, writableStackTrace)
+}
+
+// initThrowable does the work of the Throwable constructors that take a cause. As in
+// Throwable.java, a Throwable that is constructed with suppression disabled ignores
+// calls to addSuppressed(), and one whose stack trace is not writable has an empty one.
+func initThrowable(fs *list.List, throwable, message, cause *object.Object,
+	enableSuppression, writableStackTrace bool) interface{} {
+	throwable.FieldTable["detailMessage"] = object.Field{Ftype: types.Ref, Fvalue: message}
+	throwable.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: cause}
+	if !enableSuppression {
+		throwable.FieldTable["enableSuppression"] = object.Field{Ftype: types.Bool, Fvalue: types.JavaBoolFalse}
+	}
+
+	if writableStackTrace {
+		FillInStackTrace([]interface{}{fs, throwable})
+	} else {
+		throwable.FieldTable["stackTrace"] = object.Field{Ftype: types.Ref, Fvalue: object.Null}
+	}
+	return nil
+}
+
+// java/lang/Throwable.getCause()Ljava/lang/Throwable; returns null if the cause is
+// unknown or nonexistent
+func getCause(params []interface{}) interface{} {
+	cause := exceptions.Cause(params[0].(*object.Object))
+	if cause == nil {
+		return object.Null
+	}
+	return cause
+}
+
+// java/lang/Throwable.initCause(Ljava/lang/Throwable;)Ljava/lang/Throwable; sets the
+// cause of a Throwable. As in HotSpot, this can be done at most once, and not at all if
+// the Throwable was constructed with a cause (even a null one).
+func initCause(params []interface{}) interface{} {
+	throwable := params[0].(*object.Object)
+	cause := params[1].(*object.Object)
+
+	current, ok := throwable.FieldTable["cause"].Fvalue.(*object.Object)
+	if ok && current != throwable {
+		causeString := "a null"
+		if !object.IsNull(cause) {
+			causeString = exceptions.ThrowableString(cause)
+		}
+		return getGErrBlk(excNames.IllegalStateException, "Can't overwrite cause with "+causeString)
+	}
+	if cause == throwable {
+		return getGErrBlk(excNames.IllegalArgumentException, "Self-causation not permitted")
+	}
+
+	throwable.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: cause}
+	return throwable
+}
+
+// java/lang/Throwable.addSuppressed(Ljava/lang/Throwable;)V records an exception that was
+// suppressed in order to deliver this one, as try-with-resources does when closing a
+// resource throws. Suppressed exceptions are kept in the Jacobin-specific field "suppressed"
+// (in Throwable.java, they're in a List that Jacobin does not initialize).
+func addSuppressed(params []interface{}) interface{} {
+	throwable := params[0].(*object.Object)
+	exception := params[1].(*object.Object)
+
+	if exception == throwable {
+		return getGErrBlk(excNames.IllegalArgumentException, "Self-suppression not permitted")
+	}
+	if object.IsNull(exception) {
+		return getGErrBlk(excNames.NullPointerException, "Cannot suppress a null exception.")
+	}
+	if throwable.FieldTable["enableSuppression"].Fvalue == types.JavaBoolFalse {
+		return nil
+	}
+
+	suppressed := append(exceptions.Suppressed(throwable), exception)
+	throwable.FieldTable["suppressed"] = object.Field{Ftype: types.RefArray, Fvalue: suppressed}
+	return nil
+}
+
+// java/lang/Throwable.getSuppressed()[Ljava/lang/Throwable; returns a new array
+// containing the suppressed exceptions, in the order they were added
+func getSuppressed(params []interface{}) interface{} {
+	suppressed := exceptions.Suppressed(params[0].(*object.Object))
+
+	throwableClassName := "java/lang/Throwable"
+	suppressedArray := object.Make1DimRefArray(&throwableClassName, int64(len(suppressed)))
+	copy(suppressedArray.FieldTable["value"].Fvalue.([]*object.Object), suppressed)
+	return suppressedArray
+}
+
+// getOurStackTrace returns the stack trace filled in by FillInStackTrace(). A Throwable
+// whose stack trace was never filled in has an empty one.
 func getOurStackTrace(params []interface{}) interface{} {
-	args := []interface{}{params[0].(*object.Object)}
-	return GetStackTraces(args)
+	throwable := params[0].(*object.Object)
+	stackTrace, ok := throwable.FieldTable["stackTrace"].Fvalue.(*object.Object)
+	if !ok || object.IsNull(stackTrace) {
+		stackTraceElementClassName := "java/lang/StackTraceElement"
+		return object.Make1DimRefArray(&stackTraceElementClassName, 0)
+	}
+	return stackTrace
+}
+
+// java/lang/Throwable.getStackTrace()[Ljava/lang/StackTraceElement; returns a copy of
+// the stack trace, so that changes to the returned array don't affect the Throwable
+func getStackTrace(params []interface{}) interface{} {
+	stackTrace := getOurStackTrace(params).(*object.Object)
+	elements := stackTrace.FieldTable["value"].Fvalue.([]*object.Object)
+
+	stackTraceElementClassName := "java/lang/StackTraceElement"
+	stackTraceCopy := object.Make1DimRefArray(&stackTraceElementClassName, int64(len(elements)))
+	copy(stackTraceCopy.FieldTable["value"].Fvalue.([]*object.Object), elements)
+	return stackTraceCopy
+}
+
+// java/lang/Throwable.printStackTrace()V prints to System.err, and
+// java/lang/Throwable.printStackTrace(Ljava/io/PrintStream;)V to the given stream,
+// in the same format as HotSpot. As there, an exception thrown by the toString() of one
+// of the Throwables is thrown, and nothing is printed.
+func printStackTrace(params []interface{}) interface{} {
+	throwable := params[0].(*object.Object)
+	fs := params[len(params)-1].(*list.List)
+	out := os.Stderr
+	if len(params) > 2 {
+		if stream, ok := params[1].(*os.File); ok {
+			out = stream
+		}
+	}
+	trace, err := exceptions.StackTraceString(fs, throwable)
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprint(out, trace)
+	return nil
 }
 
 // Calls stackTraceElement.of() which populates the entries in a
@@ -163,8 +384,11 @@ func getOurStackTrace(params []interface{}) interface{} {
 func GetStackTraces(params []interface{}) *object.Object {
 	throwable := params[0].(*object.Object)
 	stack := throwable.FieldTable["frameStackRef"].Fvalue.(*list.List)
-	depth := stack.Len()
-	args := []interface{}{throwable, int64(depth)}
+	depth := int64(stack.Len())
+	if maxDepth := globals.GetGlobalRef().MaxJavaStackTraceDepth; maxDepth > 0 && depth > maxDepth {
+		depth = maxDepth // as in HotSpot, deep stack traces, such as of a StackOverflowError, are truncated
+	}
+	args := []interface{}{throwable, depth}
 	retVal := of(args) // this is javaLangStackTraceElement.of()
 	return retVal.(*object.Object)
 }
@@ -9,6 +9,7 @@ package gfunction
 import (
 	"container/list"
 	"jacobin/classloader"
+	"jacobin/excNames"
 	"jacobin/frames"
 	"jacobin/globals"
 	"jacobin/log"
@@ -191,3 +192,117 @@ func InstantiateFillIn(name string, _ *list.List) (any, error) {
 	o.KlassName = stringPool.GetStringIndex(&name)
 	return o, nil
 }
+
+// the stack trace of a Throwable created by 'new' omits the frames of its constructors and
+// gets the source line of each method from the method's line number table
+func TestJavaLangThrowableStackTraceWithLineNumbers(t *testing.T) {
+	globals.InitGlobals("test")
+	log.Init()
+	_ = log.SetLogLevel(log.SEVERE)
+	classloader.InitMethodArea()
+
+	clData := classloader.ClData{
+		Name:            "pkg/Cls",
+		SuperclassIndex: types.ObjectPoolStringIndex,
+		SourceFile:      "Cls.java",
+		MethodTable:     make(map[string]*classloader.Method),
+	}
+	clData.MethodTable["meth()V"] = &classloader.Method{CodeAttr: classloader.CodeAttrib{
+		BytecodeSourceMap: []classloader.BytecodeToSourceLine{{BytecodePos: 0, SourceLine: 10}, {BytecodePos: 4, SourceLine: 11}, {BytecodePos: 8, SourceLine: 12}}}}
+	clData.MethodTable["main([Ljava/lang/String;)V"] = &classloader.Method{CodeAttr: classloader.CodeAttrib{
+		BytecodeSourceMap: []classloader.BytecodeToSourceLine{{BytecodePos: 0, SourceLine: 20}, {BytecodePos: 3, SourceLine: 21}}}}
+	classloader.MethAreaInsert("pkg/Cls", &classloader.Klass{Loader: "app", Data: &clData})
+
+	jvmStack := frames.CreateFrameStack()
+	mainFrame := frames.CreateFrame(2)
+	mainFrame.ClName, mainFrame.MethName, mainFrame.MethType = "pkg/Cls", "main", "([Ljava/lang/String;)V"
+	mainFrame.PC = 6 // past the invocation of meth() at 3
+	_ = frames.PushFrame(jvmStack, mainFrame)
+
+	methFrame := frames.CreateFrame(2)
+	methFrame.ClName, methFrame.MethName, methFrame.MethType = "pkg/Cls", "meth", "()V"
+	methFrame.PC = 7 // past the invocation of the Throwable's constructor at 4
+	_ = frames.PushFrame(jvmStack, methFrame)
+
+	initFrame := frames.CreateFrame(2)
+	initFrame.ClName, initFrame.MethName, initFrame.MethType = "java/lang/Throwable", "<init>", "()V"
+	_ = frames.PushFrame(jvmStack, initFrame)
+
+	str := "java/lang/Throwable"
+	throw := object.MakeEmptyObjectWithClassName(&str)
+	globals.GetGlobalRef().FuncInstantiateClass = InstantiateFillIn
+	FillInStackTrace([]interface{}{jvmStack, throw})
+
+	steArray := getStackTrace([]interface{}{throw}).(*object.Object)
+	elements := steArray.FieldTable["value"].Fvalue.([]*object.Object)
+	if len(elements) != 2 {
+		t.Fatalf("expected 2 stack trace elements, got: %d", len(elements))
+	}
+
+	expected := []string{"pkg.Cls.meth(Cls.java:11)", "pkg.Cls.main(Cls.java:21)"}
+	for i, ste := range elements {
+		steString := object.GoStringFromStringObject(steToString([]interface{}{ste}).(*object.Object))
+		if steString != expected[i] {
+			t.Errorf("expected stack trace element %s, got: %s", expected[i], steString)
+		}
+	}
+
+	if steGetLineNumber([]interface{}{elements[0]}).(int64) != 11 {
+		t.Errorf("expected line number 11, got: %d", steGetLineNumber([]interface{}{elements[0]}))
+	}
+}
+
+func TestJavaLangThrowableInitCause(t *testing.T) {
+	globals.InitGlobals("test")
+
+	throwable := object.MakeEmptyObject()
+	throwable.FieldTable["cause"] = object.Field{Ftype: types.Ref, Fvalue: throwable} // as set by Throwable()
+	if ret := getCause([]interface{}{throwable}); !object.IsNull(ret.(*object.Object)) {
+		t.Errorf("Expected null cause for a new Throwable, got: %v", ret)
+	}
+
+	ret := initCause([]interface{}{throwable, throwable})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("Expected IllegalArgumentException on self-causation, got: %v", ret)
+	}
+
+	cause := object.MakeEmptyObject()
+	if ret = initCause([]interface{}{throwable, cause}); ret != throwable {
+		t.Errorf("Expected initCause to return the Throwable, got: %v", ret)
+	}
+	if ret = getCause([]interface{}{throwable}); ret != cause {
+		t.Errorf("Expected getCause to return the cause, got: %v", ret)
+	}
+
+	ret = initCause([]interface{}{throwable, object.Null})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalStateException {
+		t.Errorf("Expected IllegalStateException when overwriting the cause, got: %v", ret)
+	}
+}
+
+func TestJavaLangThrowableAddSuppressed(t *testing.T) {
+	globals.InitGlobals("test")
+
+	throwable := object.MakeEmptyObject()
+	first, second := object.MakeEmptyObject(), object.MakeEmptyObject()
+	for _, suppressed := range []*object.Object{first, second} {
+		if ret := addSuppressed([]interface{}{throwable, suppressed}); ret != nil {
+			t.Errorf("Unexpected error from addSuppressed: %v", ret)
+		}
+	}
+
+	ret := addSuppressed([]interface{}{throwable, throwable})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.IllegalArgumentException {
+		t.Errorf("Expected IllegalArgumentException on self-suppression, got: %v", ret)
+	}
+	ret = addSuppressed([]interface{}{throwable, object.Null})
+	if errBlk, ok := ret.(*GErrBlk); !ok || errBlk.ExceptionType != excNames.NullPointerException {
+		t.Errorf("Expected NullPointerException on suppressing null, got: %v", ret)
+	}
+
+	suppressed := getSuppressed([]interface{}{throwable}).(*object.Object)
+	elements := suppressed.FieldTable["value"].Fvalue.([]*object.Object)
+	if len(elements) != 2 || elements[0] != first || elements[1] != second {
+		t.Errorf("Expected both suppressed exceptions in order, got: %v", elements)
+	}
+}
@@ -43,13 +43,25 @@ type Globals struct {
 
 	StartingClass string
 	StartingJar   string
+	Classpath     []string // the directories and JAR files searched for classes, in order
 	AppArgs       []string
 	Options       map[string]Option
 
+	SystemProperties map[string]string // the system properties set by -D options
+	VMFlags          map[string]any    // the -XX flags set on the command line, by name (see jvm/vmflags.go)
+	ShowSettings     string            // the -XshowSettings category: "all", "vm", or "properties"; "" if none
+
+	// ---- memory limits, set by -Xss, -Xmx, and -Xms or the equivalent -XX flags ----
+	ThreadStackSize int64 // the stack size of each thread, in bytes
+	MaxHeapSize     int64 // the maximum size of the heap, in bytes, or 0 if there's no limit
+	InitialHeapSize int64 // the initial size of the heap, in bytes, or 0 if not specified
+
+	MaxJavaStackTraceDepth int64 // the maximum number of frames in a stack trace, or 0 for no limit
+
 	// ---- classloading items ----
 	MaxJavaVersion    int // the Java version as commonly known, i.e. Java 11
 	MaxJavaVersionRaw int // the Java version as it appears in bytecode i.e., 55 (= Java 11)
-	VerifyLevel       int
+	VerifyLevel       int // which classes are verified, per -Xverify: VerifyNone, VerifyRemote, or VerifyAll
 
 	// ---- Java Home and Version ----
 	JavaHome    string
@@ -70,7 +82,7 @@ type Globals struct {
 
 	// ---- special switches ----
 	StrictJDK      bool // hew closely to actions and error messages of the JDK
-	NewInterpreter bool // use the new experimental interpreter
+	NewInterpreter bool // use the dispatch-table interpreter, interpret(), rather than runFrame()
 
 	// ---- list of addresses of arrays, see jvm/arrays.go for info ----
 	ArrayAddressList *list.List
@@ -100,8 +112,22 @@ type Globals struct {
 	FuncInstantiateClass func(string, *list.List) (any, error)
 	FuncThrowException   func(int, string) bool
 	FuncFillInStackTrace func([]any) any
+	FuncStartThread      func(any) error
+	FuncInvokeMethod     func(*list.List, string, string, string, []any) (any, error)
+	FuncRunShutdownHooks func()
+	FuncUncaughtEx       func(any, any, func())
 }
 
+// ---- bytecode verification levels (see VerifyLevel)
+const (
+	VerifyNone   = iota // no classes are verified
+	VerifyRemote        // classes that aren't part of the JDK are verified (the default)
+	VerifyAll           // all classes are verified
+)
+
+// DefaultThreadStackSize is the default stack size of a thread, 1MB, as in HotSpot on 64-bit platforms
+const DefaultThreadStackSize = 1024 * 1024
+
 // ---- trace categories
 var TraceInit bool
 var TraceCloadi bool
@@ -119,6 +145,10 @@ var StringIndexString uint32
 // LoaderWg is a wait group for various channels used for parallel loading of classes.
 var LoaderWg sync.WaitGroup
 
+// NonDaemonWg is a wait group for the running non-daemon application threads.
+// On a normal exit, the JVM waits for these threads to complete.
+var NonDaemonWg sync.WaitGroup
+
 // Standard Sleep amount in milliseconds used in various places.
 var SleepMsecs time.Duration = 5
 
@@ -128,18 +158,24 @@ var global Globals
 // InitGlobals initializes the global values that are known at start-up
 func InitGlobals(progName string) Globals {
 	global = Globals{
-		Version:           config.GetJacobinVersion(), // gets version and build #
-		VmModel:           "server",
-		ExitNow:           false,
-		JacobinName:       progName,
-		JacobinHome:       "",
-		JavaHome:          "",
-		JavaVersion:       "",
-		Options:           make(map[string]Option),
-		StartingClass:     "",
-		StartingJar:       "",
-		MaxJavaVersion:    21, // this value and MaxJavaVersionRaw must *always* be in sync
-		MaxJavaVersionRaw: 65, // this value and MaxJavaVersion must *always* be in sync
+		Version:                config.GetJacobinVersion(), // gets version and build #
+		VmModel:                "server",
+		ExitNow:                false,
+		JacobinName:            progName,
+		JacobinHome:            "",
+		JavaHome:               "",
+		JavaVersion:            "",
+		Options:                make(map[string]Option),
+		StartingClass:          "",
+		StartingJar:            "",
+		Classpath:              []string{"."},
+		SystemProperties:       make(map[string]string),
+		VMFlags:                make(map[string]any),
+		ThreadStackSize:        DefaultThreadStackSize,
+		MaxJavaStackTraceDepth: 1024,
+		MaxJavaVersion:         21, // this value and MaxJavaVersionRaw must *always* be in sync
+		MaxJavaVersionRaw:      65, // this value and MaxJavaVersion must *always* be in sync
+		VerifyLevel:            VerifyRemote,
 		// Threads:            ThreadList{list.New(), sync.Mutex{}},
 		ThreadNumber:         0, // first thread will be numbered 1, as increment occurs prior
 		JacobinBuildData:     nil,
@@ -154,6 +190,11 @@ func InitGlobals(progName string) Globals {
 		GoStackShown:         false,
 		FuncInstantiateClass: fakeInstantiateClass,
 		FuncThrowException:   fakeThrowEx,
+		FuncFillInStackTrace: fakeFillInStackTrace,
+		FuncStartThread:      fakeStartThread,
+		FuncInvokeMethod:     fakeInvokeMethod,
+		FuncRunShutdownHooks: fakeRunShutdownHooks,
+		FuncUncaughtEx:       fakeUncaughtEx,
 	}
 
 	// ----- String Pool and other values
@@ -311,6 +352,27 @@ func fakeThrowEx(whichEx int, msg string) bool {
 	return false
 }
 
+// Fake FillInStackTrace() in gfunction/javaLangThrowable.go. Exceptions thrown in
+// tests that don't set up the frame stack simply have no stack trace.
+func fakeFillInStackTrace(params []any) any {
+	return nil
+}
+
+// Fake StartThread() in jvm/threads.go
+func fakeStartThread(t any) error {
+	errMsg := "\n*Attempt to access uninitialized StartThread pointer func"
+	fmt.Fprint(os.Stderr, errMsg)
+	return errors.New(errMsg)
+}
+
+// Fake InvokeMethod() in jvm/threads.go
+func fakeInvokeMethod(fs *list.List, className, methName, methType string, args []any) (any, error) {
+	errMsg := fmt.Sprintf("\n*Attempt to access uninitialized InvokeMethod pointer func: %s.%s%s\n",
+		className, methName, methType)
+	fmt.Fprint(os.Stderr, errMsg)
+	return nil, errors.New(errMsg)
+}
+
 func InitStringPool() {
 
 	StringPoolLock.Lock()
@@ -340,3 +402,13 @@ func InitStringPool() {
 
 	StringPoolLock.Unlock()
 }
+
+// Fake RunShutdownHooks() in gfunction/javaLangRuntime.go. Until it's set up,
+// no application code has run, so there are no hooks to run.
+func fakeRunShutdownHooks() {}
+
+// Fake UncaughtException() in jvm/threads.go
+func fakeUncaughtEx(t any, throwable any, diagnostics func()) {
+	errMsg := "\n*Attempt to access uninitialized UncaughtException pointer func"
+	fmt.Fprint(os.Stderr, errMsg)
+}
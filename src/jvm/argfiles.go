@@ -0,0 +1,262 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// This file handles @argfiles and the JDK_JAVA_OPTIONS environment variable, as the
+// java launcher does. An argument of the form @file is replaced by the arguments in
+// the file, which are separated by whitespace. In the file:
+//   - a # outside a quoted string starts a comment that runs to the end of the line
+//   - an argument containing whitespace can be enclosed, in whole or in part, in
+//     single or double quotes
+//   - within quotes, a backslash escapes the next character, and \n, \r, \t, and \f
+//     have their usual meanings. A backslash at the end of a line continues the
+//     quoted string on the next line, skipping that line's leading whitespace.
+//
+// An argfile can contain other @files, which are expanded in turn. An argument that
+// begins with @@ is passed on with the first @ removed, and --disable-@files stops the
+// expansion of any further @files. Arguments following the main class (or the JAR file
+// specified by -jar) are application arguments, and so are never expanded.
+
+// the options whose value is the following argument
+var optionsWithSeparateArg = map[string]bool{
+	"-cp":          true,
+	"-classpath":   true,
+	"--class-path": true,
+	"-jar":         true,
+}
+
+// the options that are not allowed in JDK_JAVA_OPTIONS because they specify the
+// main class or cause the launcher to exit without running it
+var optionsNotAllowedInEnv = map[string]bool{
+	"-jar":      true,
+	"-h":        true,
+	"-help":     true,
+	"-?":        true,
+	"--help":    true,
+	"-version":  true,
+	"--version": true,
+	"--dry-run": true,
+}
+
+// argExpander tracks the state of the expansion of @files across the JDK_JAVA_OPTIONS
+// environment variable and the command line
+type argExpander struct {
+	disabled    bool            // set by --disable-@files
+	expectValue bool            // the next arg is the value of an option
+	expectJar   bool            // the next arg is the value of -jar
+	mainFound   bool            // the main class or JAR file has been seen
+	filesOpen   map[string]bool // the @files being expanded, to detect circular references
+}
+
+func newArgExpander() *argExpander {
+	return &argExpander{filesOpen: make(map[string]bool)}
+}
+
+// expand returns the args with all @files expanded
+func (ae *argExpander) expand(args []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		if !ae.mainFound && !ae.disabled && len(arg) > 1 && arg[0] == '@' {
+			if arg[1] != '@' {
+				fileArgs, err := ae.expandFile(arg[1:])
+				if err != nil {
+					return nil, err
+				}
+				expanded = append(expanded, fileArgs...)
+				continue
+			}
+			arg = arg[1:] // @@ stands for a literal @
+		}
+		ae.note(arg)
+		expanded = append(expanded, arg)
+	}
+	return expanded, nil
+}
+
+// expandFile returns the args in an @file, after expanding any @files it contains
+func (ae *argExpander) expandFile(filename string) ([]string, error) {
+	absName, err := filepath.Abs(filename)
+	if err != nil {
+		absName = filename
+	}
+	if ae.filesOpen[absName] {
+		return nil, fmt.Errorf("Error: circular reference to argument file %s", filename)
+	}
+
+	contents, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Error: could not open `%s'", filename)
+	}
+
+	ae.filesOpen[absName] = true
+	defer delete(ae.filesOpen, absName)
+	return ae.expand(parseArgFile(string(contents)))
+}
+
+// note updates the state of the expansion for an arg that's been expanded
+func (ae *argExpander) note(arg string) {
+	switch {
+	case ae.mainFound:
+	case ae.expectValue:
+		ae.expectValue = false
+		ae.mainFound = ae.expectJar
+	case arg == "--disable-@files":
+		ae.disabled = true
+	case optionsWithSeparateArg[arg]:
+		ae.expectValue = true
+		ae.expectJar = arg == "-jar"
+	case !strings.HasPrefix(arg, "-"):
+		ae.mainFound = true
+	}
+}
+
+// parseArgFile splits the contents of an @file into its args
+func parseArgFile(contents string) []string {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+	var quote byte // the quote character, if in a quoted string
+
+	for i := 0; i < len(contents); i++ {
+		c := contents[i]
+		if quote != 0 {
+			switch {
+			case c == quote:
+				quote = 0
+			case c == '\\' && i+1 < len(contents):
+				i++
+				switch contents[i] {
+				case 'n':
+					arg.WriteByte('\n')
+				case 'r':
+					arg.WriteByte('\r')
+				case 't':
+					arg.WriteByte('\t')
+				case 'f':
+					arg.WriteByte('\f')
+				case '\r', '\n': // a line continuation
+					if contents[i] == '\r' && i+1 < len(contents) && contents[i+1] == '\n' {
+						i++
+					}
+					for i+1 < len(contents) && strings.IndexByte(" \t\f", contents[i+1]) >= 0 {
+						i++
+					}
+				default:
+					arg.WriteByte(contents[i])
+				}
+			default:
+				arg.WriteByte(c)
+			}
+			continue
+		}
+
+		switch c {
+		case ' ', '\t', '\f', '\r', '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case '#':
+			if inArg {
+				arg.WriteByte(c)
+				continue
+			}
+			for i+1 < len(contents) && contents[i+1] != '\n' && contents[i+1] != '\r' {
+				i++
+			}
+		case '"', '\'':
+			quote = c
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
+
+// parseEnvOptions splits the value of an environment variable, such as JDK_JAVA_OPTIONS,
+// into its args. Args are separated by whitespace, and can be enclosed, in whole or in
+// part, in single or double quotes. Unlike in @files, there are no comments or escapes.
+func parseEnvOptions(value, varName string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	inArg := false
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch c {
+		case ' ', '\t', '\f', '\r', '\n':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		case '"', '\'':
+			end := strings.IndexByte(value[i+1:], c)
+			if end == -1 {
+				return nil, fmt.Errorf("Error: Unmatched quote in environment variable %s", varName)
+			}
+			arg.WriteString(value[i+1 : i+1+end])
+			i += end + 1
+			inArg = true
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// getJdkJavaOptions returns the args in the JDK_JAVA_OPTIONS environment variable, with
+// any @files expanded. As in the java launcher, these args are inserted ahead of the
+// command-line args, and they cannot specify the main class or options that cause the
+// launcher to exit.
+func getJdkJavaOptions(ae *argExpander) ([]string, error) {
+	value, ok := os.LookupEnv("JDK_JAVA_OPTIONS")
+	if !ok || strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "NOTE: Picked up JDK_JAVA_OPTIONS: %s\n", value)
+
+	envArgs, err := parseEnvOptions(value, "JDK_JAVA_OPTIONS")
+	if err != nil {
+		return nil, err
+	}
+	envArgs, err = ae.expand(envArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arg := range envArgs {
+		option, _, _ := getOptionRootAndArgs(arg)
+		if optionsNotAllowedInEnv[option] {
+			return nil, fmt.Errorf("Error: Option %s is not allowed in environment variable JDK_JAVA_OPTIONS", option)
+		}
+	}
+	if ae.mainFound {
+		return nil, errors.New("Error: Cannot specify main class in environment variable JDK_JAVA_OPTIONS")
+	}
+	return envArgs, nil
+}
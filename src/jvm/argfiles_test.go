@@ -0,0 +1,125 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"jacobin/globals"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseArgFileQuotesCommentsAndContinuations(t *testing.T) {
+	contents := "# a comment line\n" +
+		"-cp \"lib/cool app/jars:\\\n" +
+		"    lib/other app/jars\"   # a trailing comment\n" +
+		"-Dmsg='hello world' -Dpath=C:\\dir\\sub -Dq=\"a\\tb\"c\r\n" +
+		"''\n" +
+		"Main#NotAComment"
+
+	expected := []string{"-cp", "lib/cool app/jars:lib/other app/jars", "-Dmsg=hello world",
+		`-Dpath=C:\dir\sub`, "-Dq=a\tbc", "", "Main#NotAComment"}
+	args := parseArgFile(contents)
+	if !slices.Equal(args, expected) {
+		t.Errorf("expected args %q, got %q", expected, args)
+	}
+}
+
+func TestParseEnvOptions(t *testing.T) {
+	args, err := parseEnvOptions(`-Dmsg="hello world" -verbose:class  '-Dx=#1'`, "JDK_JAVA_OPTIONS")
+	expected := []string{"-Dmsg=hello world", "-verbose:class", "-Dx=#1"}
+	if err != nil || !slices.Equal(args, expected) {
+		t.Errorf("expected args %q, got %q (error: %v)", expected, args, err)
+	}
+
+	_, err = parseEnvOptions(`-Dmsg="hello`, "JDK_JAVA_OPTIONS")
+	if err == nil || !strings.Contains(err.Error(), "Unmatched quote") {
+		t.Errorf("expected an unmatched quote error, got: %v", err)
+	}
+}
+
+func TestExpandNestedArgFiles(t *testing.T) {
+	dir := t.TempDir()
+	inner := filepath.Join(dir, "inner.txt")
+	outer := filepath.Join(dir, "outer.txt")
+	_ = os.WriteFile(inner, []byte("-Dinner=1\n"), 0644)
+	_ = os.WriteFile(outer, []byte("-Douter=1 @"+inner+" @@literal\n"), 0644)
+
+	args, err := newArgExpander().expand([]string{"@" + outer, "Main", "@" + inner})
+	expected := []string{"-Douter=1", "-Dinner=1", "@literal", "Main", "@" + inner}
+	if err != nil || !slices.Equal(args, expected) {
+		t.Errorf("expected args %q, got %q (error: %v)", expected, args, err)
+	}
+
+	args, err = newArgExpander().expand([]string{"--disable-@files", "@" + inner, "Main"})
+	expected = []string{"--disable-@files", "@" + inner, "Main"}
+	if err != nil || !slices.Equal(args, expected) {
+		t.Errorf("expected no expansion after --disable-@files, got %q (error: %v)", args, err)
+	}
+
+	_ = os.WriteFile(inner, []byte("@"+outer), 0644)
+	if _, err = newArgExpander().expand([]string{"@" + outer}); err == nil {
+		t.Errorf("expected an error for circular @files")
+	}
+
+	_, err = newArgExpander().expand([]string{"@" + filepath.Join(dir, "missing.txt")})
+	if err == nil || !strings.Contains(err.Error(), "could not open") {
+		t.Errorf("expected an error for a missing @file, got: %v", err)
+	}
+}
+
+func TestHandleCliWithArgFileAndJdkJavaOptions(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	argFile := filepath.Join(t.TempDir(), "args.txt")
+	_ = os.WriteFile(argFile, []byte("-Dfrom.file=yes\nMain appArg1\n"), 0644)
+	t.Setenv("JDK_JAVA_OPTIONS", `-Dfrom.env="env value"`)
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	args := []string{"jacobin", "@" + argFile, "appArg2"}
+	err := HandleCli(args, &global)
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if global.SystemProperties["from.env"] != "env value" || global.SystemProperties["from.file"] != "yes" {
+		t.Errorf("expected properties from JDK_JAVA_OPTIONS and the @file, got: %v", global.SystemProperties)
+	}
+	if global.StartingClass != "Main" || !slices.Equal(global.AppArgs, []string{"appArg1", "appArg2"}) {
+		t.Errorf("expected Main with args appArg1 appArg2, got: %s %v", global.StartingClass, global.AppArgs)
+	}
+}
+
+func TestJdkJavaOptionsCannotSpecifyMainClass(t *testing.T) {
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	t.Setenv("JDK_JAVA_OPTIONS", "-Dx=1 Main")
+	_, err := getJdkJavaOptions(newArgExpander())
+	if err == nil || !strings.Contains(err.Error(), "Cannot specify main class") {
+		t.Errorf("expected a main class error, got: %v", err)
+	}
+
+	t.Setenv("JDK_JAVA_OPTIONS", "-jar app.jar")
+	_, err = getJdkJavaOptions(newArgExpander())
+	if err == nil || !strings.Contains(err.Error(), "Option -jar is not allowed") {
+		t.Errorf("expected a -jar error, got: %v", err)
+	}
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+}
@@ -2381,6 +2381,32 @@ func TestNewrray(t *testing.T) {
 	}
 }
 
+// NEWARRAY: an array that's bigger than the maximum heap size (-Xmx)
+// throws an OutOfMemoryError
+func TestNewrrayOutOfMemory(t *testing.T) {
+	f := newFrame(opcodes.NEWARRAY)
+	push(&f, int64(1<<20))                 // a million longs
+	f.Meth = append(f.Meth, object.T_LONG) // make it an array of longs
+
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	g.MaxHeapSize = 1 << 20 // -Xmx1m
+	defer func() { g.MaxHeapSize = 0 }()
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(&f) // push the new frame
+	err := runFrame(fs)
+	if err == nil {
+		t.Fatalf("NEWARRAY: Did not get expected OutOfMemoryError")
+	}
+	if !strings.Contains(err.Error(), "Java heap space") {
+		t.Errorf("NEWARRAY: Expecting 'Java heap space' error, got %s", err.Error())
+	}
+	if g.ArrayAddressList.Len() != 0 {
+		t.Errorf("NEWARRAY: Expecting no array to be created, got %d", g.ArrayAddressList.Len())
+	}
+}
+
 // NEWARRAY: Create new array of 13 bytes
 func TestNewrrayForByteArray(t *testing.T) {
 	f := newFrame(opcodes.NEWARRAY)
@@ -9,6 +9,7 @@ package jvm
 import (
 	"errors"
 	"fmt"
+	"jacobin/classloader"
 	"jacobin/execdata"
 	"jacobin/globals"
 	"jacobin/log"
@@ -27,23 +28,35 @@ func HandleCli(osArgs []string, Global *globals.Globals) (err error) {
 	// JAVA_HOME and JACOBIN_HOME were obtained in the init of globals.go. Here we just log them.
 	showJavaHomeArgs(Global)
 
-	// add command-line args to those extracted from the environment (if any)
-	cliArgs := javaEnvOptions + " "
-	for _, v := range osArgs[1:] {
-		cliArgs += v + " "
+	// expand any @files, both in JDK_JAVA_OPTIONS and on the command line. The args in
+	// JDK_JAVA_OPTIONS go ahead of those on the command line.
+	expander := newArgExpander()
+	launcherArgs, err := getJdkJavaOptions(expander)
+	if err == nil {
+		var cmdLineArgs []string
+		cmdLineArgs, err = expander.expand(osArgs[1:])
+		launcherArgs = append(launcherArgs, cmdLineArgs...)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		return err
 	}
-	Global.CommandLine = strings.TrimSpace(cliArgs)
-	_ = log.Log("Commandline: "+Global.CommandLine, log.FINE)
 
-	// pull out all the arguments into an array of strings. Note that an arg with spaces but
-	// within quotes is treated as a single arg
+	// pull out all the arguments into an array of strings
 	args := strings.Fields(javaEnvOptions)
-	for _, v := range osArgs[1:] {
-		args = append(args, v)
-	}
+	args = append(args, launcherArgs...)
+	Global.CommandLine = strings.Join(args, " ")
+	_ = log.Log("Commandline: "+Global.CommandLine, log.FINE)
 	Global.Args = args
 	showCopyright(Global)
 
+	// the CLASSPATH environment variable sets the classpath, unless it's set on the command line
+	if envClasspath := os.Getenv("CLASSPATH"); envClasspath != "" {
+		Global.Classpath = classloader.ParseClasspath(envClasspath)
+		_ = log.Log("CLASSPATH: "+envClasspath, log.FINE)
+	}
+
+	presetIgnoreUnrecognizedVMOptions(args, Global)
 	for i := 0; i < len(args); i++ {
 		var option, arg string
 		// if it's a JVM option (so, it begins with a hyphen)
@@ -59,8 +72,9 @@ func HandleCli(osArgs []string, Global *globals.Globals) (err error) {
 		}
 
 		// if the option is the name of the class to execute, note that then get
-		// all successive arguments and store them as app args in globPtr
-		if strings.HasSuffix(option, ".class") {
+		// all successive arguments and store them as app args in globPtr. The class
+		// is either a .class file or the name of a class to search for on the classpath.
+		if strings.HasSuffix(option, ".class") || !strings.HasPrefix(option, "-") {
 			Global.StartingClass = option
 			for i = i + 1; i < len(args); i++ {
 				Global.AppArgs = append(Global.AppArgs, args[i])
@@ -70,7 +84,18 @@ func HandleCli(osArgs []string, Global *globals.Globals) (err error) {
 
 		opt, ok := Global.Options[option]
 		if ok {
-			i, _ = opt.Action(i, arg, Global)
+			i, err = opt.Action(i, arg, Global)
+			if errors.Is(err, errFatalVMOption) {
+				_, _ = fmt.Fprintln(os.Stderr, err.Error())
+				return err
+			}
+			err = nil
+		} else if strings.HasPrefix(option, "-X") {
+			err = handleUnrecognizedVMOption(args[i], "Unrecognized option: "+args[i], Global)
+			if err != nil {
+				_, _ = fmt.Fprintln(os.Stderr, err.Error())
+				return err
+			}
 		} else {
 			_, _ = fmt.Fprintf(os.Stderr, "%s is not a recognized option. Exiting.\n", args[i])
 			shutdown.Exit(shutdown.JVM_EXCEPTION)
@@ -91,10 +116,27 @@ func getOptionRootAndArgs(option string) (string, string, error) {
 		return "", "", errors.New("empty option error")
 	}
 
-	// if the option has an embedded arg value, it'll come after a : or an =
-	argMarker := strings.Index(option, ":")
-	if argMarker == -1 {
-		argMarker = strings.Index(option, "=")
+	// -D options are -Dkey=value, in which the key and value can contain a : or an =,
+	// so the entire key=value is the arg value
+	if strings.HasPrefix(option, "-D") {
+		return "-D", option[2:], nil
+	}
+
+	// -Xss, -Xmx, and -Xms are followed immediately by a size, e.g., -Xmx2g
+	if len(option) > 4 {
+		if _, ok := xSizeOptions[option[:4]]; ok {
+			return option[:4], option[4:], nil
+		}
+	}
+
+	// if the option has an embedded arg value, it'll come after a : or an =. Options
+	// that begin with -- use only =, as their values can contain a : (e.g., --class-path)
+	argMarker := strings.Index(option, "=")
+	if !strings.HasPrefix(option, "--") {
+		argMarker = strings.Index(option, ":")
+		if argMarker == -1 {
+			argMarker = strings.Index(option, "=")
+		}
 	}
 
 	// if there's no embedded : or = then the option doesn't contain an arg value
@@ -106,15 +148,16 @@ func getOptionRootAndArgs(option string) (string, string, error) {
 
 }
 
-// you can can set JVM options using the three environment variables that are
+// you can can set JVM options using the two environment variables that are
 // inspected in this function. Note: order is important because later options
 // can override earlier ones. These are checked before any of the command-line
-// options are processed.
+// options are processed. The third such variable, JDK_JAVA_OPTIONS, is handled
+// by getJdkJavaOptions(), as it can contain @files.
 func getEnvArgs() string {
 	envArgs := ""
-	javaEnvKeys := [3]string{"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "JDK_JAVA_OPTIONS"}
+	javaEnvKeys := [2]string{"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS"}
 
-	for i := 0; i < 3; i++ { // if a string is found copy it and a trailing space
+	for i := 0; i < 2; i++ { // if a string is found copy it and a trailing space
 		envString := os.Getenv(javaEnvKeys[i])
 		if len(envString) > 0 {
 			envArgs += envString
@@ -149,8 +192,21 @@ Usage: jacobin [options] <mainclass> [args...]
 Arguments following the main class, source file, -jar <jarfile>,
 are passed as the arguments to main class.
 
+The arguments can include @argfiles, which are files that contain
+options and arguments. They are expanded until the main class.
+
 where options include:
+	-cp <class search path of directories and zip/jar files>
+	-classpath <class search path of directories and zip/jar files>
+	--class-path <class search path of directories and zip/jar files>
+	              A : separated list of directories, JAR archives,
+	              and ZIP archives to search for class files. A directory
+	              followed by /* stands for all the JAR files in it.
 	-client       to select the "client" VM
+	-D<name>=<value>
+	              set a system property
+	--disable-@files
+	              disable further argument file expansion
 	-verbose:[class|info|fine|finest]  enable verbose output
                   info, fine, finest are Jacobin-specific options providing
                     increasing amounts of detail. The finest level is used
@@ -162,8 +218,24 @@ where options include:
 	-showversion  print product version to the error stream and continue
 	--show-version
 				  print product version to the output stream and continue
+	-Xint         interpreted mode execution only (Jacobin's only mode)
+	-Xms<size>    set initial Java heap size
+	-Xmx<size>    set maximum Java heap size
+	-Xss<size>    set java thread stack size
+	              The <size> is in bytes, or can have a k, m, g, or t suffix.
+	-XshowSettings[:all|vm|properties]
+	              show the VM settings, the system properties, or both,
+	                and continue
+	-Xverify:[none|remote|all]
+	              verify the bytecode of no classes, of classes not part
+	                of the JDK (the default), or of all classes
+	-XX:+<flag> -XX:-<flag> -XX:<flag>=<value>
+	              set a VM flag. Unrecognized flags are an error unless
+	                -XX:+IgnoreUnrecognizedVMOptions is specified
 
 Jacobin-specific options:
+	-new          run bytecode with the dispatch-table interpreter
+	-old          run bytecode with the original (switch-based) interpreter (the default)
 	-strictJDK    make user messages conform closely to the JDK's format
 	-trace:inst   display instruction-level tracing data to the console`
 
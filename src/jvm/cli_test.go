@@ -11,6 +11,7 @@ import (
 	"jacobin/globals"
 	"jacobin/log"
 	"os"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -28,12 +29,12 @@ func TestGetJVMenvVariablesWhenAbsent(t *testing.T) {
 	}
 }
 
-// set two of the JVM environment variables and make sure
+// set the two JVM environment variables read by getEnvArgs() and make sure
 // they are fetched correctly and a space is inserted between them
 func TestGetJVMenvVariablesWhenTwoArePresent(t *testing.T) {
-	_ = os.Unsetenv("JAVA_TOOL_OPTIONS")
-	_ = os.Setenv("_JAVA_OPTIONS", "Hello,")
-	_ = os.Setenv("JDK_JAVA_OPTIONS", "Jacobin!")
+	_ = os.Setenv("JAVA_TOOL_OPTIONS", "Hello,")
+	_ = os.Setenv("_JAVA_OPTIONS", "Jacobin!")
+	_ = os.Unsetenv("JDK_JAVA_OPTIONS")
 
 	javaEnvVars := getEnvArgs()
 	if javaEnvVars != "Hello, Jacobin!" {
@@ -41,8 +42,8 @@ func TestGetJVMenvVariablesWhenTwoArePresent(t *testing.T) {
 	}
 
 	// clean up the environment
+	_ = os.Unsetenv("JAVA_TOOL_OPTIONS")
 	_ = os.Unsetenv("_JAVA_OPTIONS")
-	_ = os.Unsetenv("JDK_JAVA_OPTIONS")
 }
 
 // verify the output to stderr -help option is used
@@ -327,6 +328,76 @@ func TestSpecifyClientVM(t *testing.T) {
 	}
 }
 
+func TestSpecifyVerifyLevels(t *testing.T) {
+
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+	if global.VerifyLevel != globals.VerifyRemote {
+		t.Errorf("Default verification level should be VerifyRemote, got: %d", global.VerifyLevel)
+	}
+
+	args := []string{"jacobin", "-Xverify:none"}
+	_ = HandleCli(args, &global)
+	if global.VerifyLevel != globals.VerifyNone {
+		t.Errorf("-Xverify:none should set VerifyNone, got: %d", global.VerifyLevel)
+	}
+
+	args = []string{"jacobin", "-Xverify:all"}
+	_ = HandleCli(args, &global)
+	if global.VerifyLevel != globals.VerifyAll {
+		t.Errorf("-Xverify:all should set VerifyAll, got: %d", global.VerifyLevel)
+	}
+
+	_, err := verifyLevel(0, "some", &global)
+	if err == nil || global.VerifyLevel != globals.VerifyAll {
+		t.Errorf("Invalid -Xverify value should be rejected and ignored, got level: %d", global.VerifyLevel)
+	}
+}
+
+func TestSpecifyClasspath(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+	if !slices.Equal(global.Classpath, []string{"."}) {
+		t.Errorf("Default classpath should be the current directory, got: %v", global.Classpath)
+	}
+
+	sep := string(os.PathListSeparator)
+	args := []string{"jacobin", "-cp", "classes" + sep + "lib.jar", "com.example.Main", "appArg1"}
+	_ = HandleCli(args, &global)
+	if !slices.Equal(global.Classpath, []string{"classes", "lib.jar"}) {
+		t.Errorf("-cp should set the classpath entries in order, got: %v", global.Classpath)
+	}
+	if global.StartingClass != "com.example.Main" || len(global.AppArgs) != 1 {
+		t.Errorf("Expected com.example.Main with one app arg, got: %s %v", global.StartingClass, global.AppArgs)
+	}
+
+	global = globals.InitGlobals("test")
+	LoadOptionsTable(global)
+	args = []string{"jacobin", "--class-path=lib" + sep + "other.jar", "Main"}
+	_ = HandleCli(args, &global)
+	if !slices.Equal(global.Classpath, []string{"lib", "other.jar"}) {
+		t.Errorf("--class-path= should set the classpath, got: %v", global.Classpath)
+	}
+}
+
+func TestSpecifySystemProperties(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	args := []string{"jacobin", "-Dapp.url=http://host:80/?a=b", "-Dapp.flag", "Main"}
+	_ = HandleCli(args, &global)
+	if global.SystemProperties["app.url"] != "http://host:80/?a=b" {
+		t.Errorf("-D should set the property to everything after the first =, got: %s",
+			global.SystemProperties["app.url"])
+	}
+	if value, ok := global.SystemProperties["app.flag"]; !ok || value != "" {
+		t.Errorf("-D without a value should set the property to an empty string, got: %q", value)
+	}
+	if global.StartingClass != "Main" {
+		t.Errorf("Expected Main as the starting class, got: %s", global.StartingClass)
+	}
+}
+
 func TestSpecifyValidButUnsupportedOption(t *testing.T) {
 
 	global := globals.InitGlobals("test")
@@ -13,6 +13,7 @@ import (
 	"jacobin/classloader"
 	"jacobin/frames"
 	"jacobin/gfunction"
+	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/stringPool"
 	"jacobin/types"
@@ -116,6 +117,17 @@ func runJavaInitializer(m classloader.MData, k *classloader.Klass, fs *list.List
 		_ = log.Log(traceInfo, log.TRACE_INST)
 	}
 
+	if globals.GetGlobalRef().NewInterpreter {
+		// interpret() removes the initializer's frame when it returns
+		var err error
+		depth := fs.Len()
+		for fs.Len() >= depth && err == nil {
+			err = interpret(fs)
+		}
+		k.Data.ClInit = types.ClInitRun // flag showing we've run this class's <clinit>
+		return err
+	}
+
 	err := runFrame(fs)
 	k.Data.ClInit = types.ClInitRun // flag showing we've run this class's <clinit>
 	if err != nil {
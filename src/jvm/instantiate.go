@@ -164,6 +164,8 @@ func InstantiateClass(classname string, frameStack *list.List) (any, error) {
 	} // end of handling fields for classes with superclasses other than Object
 
 runInitializer:
+	object.NoteInstance(len(obj.FieldTable))
+
 	// run intialization blocks
 	_, ok := k.Data.MethodTable["<clinit>()V"]
 	if ok && k.Data.ClInit == types.ClInitNotRun {
@@ -253,7 +255,7 @@ func createField(f classloader.Field, k *classloader.Klass, classname string) (*
 		fieldName := k.Data.CP.Utf8Refs[f.Name]
 		fullFieldName := classname + "." + fieldName
 
-		_, alreadyPresent := statics.Statics[fullFieldName]
+		_, alreadyPresent := statics.QueryStatic(fullFieldName)
 		if !alreadyPresent { // add only if field has not been pre-loaded
 			_ = statics.AddStatic(fullFieldName, s)
 		}
@@ -262,6 +264,27 @@ func createField(f classloader.Field, k *classloader.Klass, classname string) (*
 }
 
 // Loads the class (if it's not already loaded) and makes sure it's accessible in the method area
+// instanceSize returns the estimated size of an instance of the named class, which has
+// an entry in its field table for each field of the class and its superclasses. The
+// classes are loaded if need be; a class that can't be loaded adds no fields, and the
+// error is reported when the class is instantiated.
+func instanceSize(className string) int64 {
+	fieldCount := 0
+	for className != types.ObjectClassName && loadThisClass(className) == nil {
+		k := classloader.MethAreaFetch(className)
+		if k == nil || k.Data == nil {
+			break
+		}
+		fieldCount += len(k.Data.Fields)
+		superclassNamePtr := stringPool.GetStringPointer(k.Data.SuperclassIndex)
+		if superclassNamePtr == nil {
+			break
+		}
+		className = *superclassNamePtr
+	}
+	return object.InstanceSize(fieldCount)
+}
+
 func loadThisClass(className string) error {
 	alreadyLoaded := classloader.MethAreaFetch(className)
 	if alreadyLoaded != nil { // if the class is already loaded, skip the rest of this
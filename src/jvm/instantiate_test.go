@@ -234,3 +234,24 @@ func TestLoadClassJavaLangObject(t *testing.T) {
 		t.Errorf("Got unexpected error from loadThisClass: %s", err.Error())
 	}
 }
+
+// the estimated size of an instance counts the fields of the class and its superclasses
+func TestInstanceSizeCountsInheritedFields(t *testing.T) {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+
+	parentName := "test/Parent"
+	classloader.MethAreaInsert(parentName, &classloader.Klass{Status: 'X', Loader: "test", Data: &classloader.ClData{
+		Name: parentName, SuperclassIndex: types.ObjectPoolStringIndex,
+		Fields: make([]classloader.Field, 2), ClInit: types.ClInitRun}})
+	classloader.MethAreaInsert("test/Child", &classloader.Klass{Status: 'X', Loader: "test", Data: &classloader.ClData{
+		Name: "test/Child", SuperclassIndex: stringPool.GetStringIndex(&parentName),
+		Fields: make([]classloader.Field, 3), ClInit: types.ClInitRun}})
+
+	if size := instanceSize("test/Child"); size != object.InstanceSize(5) {
+		t.Errorf("expected the size of an object with 5 fields, %d, got %d", object.InstanceSize(5), size)
+	}
+	if size := instanceSize(types.ObjectClassName); size != object.InstanceSize(0) {
+		t.Errorf("expected the size of an object without fields, %d, got %d", object.InstanceSize(0), size)
+	}
+}
@@ -4,11 +4,17 @@
  * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
  *
  * ================================================
- * THIS IS AN EXPERIMENTAL ALTERNATIVE TO run.go
- * The chages it makes:
+ * This is the dispatch-table interpreter, selected by the -new command-line option.
+ * It will replace the original interpreter, runFrame() in run.go, as the default once
+ * it passes every whole-class test that runFrame() passes. The differences:
  *  - Uses an array of functions rather than a switch for each bytecode
  *  - Does only one push and pull for 64-bit values (longs and doubles)
  *  - All severe errors use ThrowEx() to throw an exception. No errors based on return values.
+ *
+ * Because longs and doubles occupy a single op stack entry, the bytecodes that move
+ * stack words regardless of their type (POP2, DUP2, and the like) need to know which
+ * entries are longs and doubles. These are marked in the frame's OpStackWide slice
+ * by pushWide().
  */
 
 package jvm
@@ -25,223 +31,236 @@ import (
 	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/object"
+	"jacobin/opcodes"
 	"jacobin/statics"
 	"jacobin/stringPool"
 	"jacobin/types"
 	"jacobin/util"
+	"math"
 	"runtime/debug"
+	"strings"
+	"sync"
 )
 
 // set up a DispatchTable with 203 slots that correspond to the bytecodes
 // each slot being a pointer to a function that accepts a pointer to the
 // current frame and an int parameter. It returns an int that indicates
-// how much to increase that frame's PC (program counter) by.
+// how much to increase that frame's PC (program counter) by. A return
+// value of 0 means the function has changed the frame stack (or the PC
+// of the frame at its top), so execution resumes with the frame that's
+// now at the top of the frame stack.
 type BytecodeFunc func(*frames.Frame, int64) int
 
-var DispatchTable = [203]BytecodeFunc{
-	doNop,           // NOP         0x00
-	doAconstNull,    // ACONST_NULL 0x01
-	doIconstM1,      // ICONST_M1   0x02
-	doIconst0,       // ICONST_0    0x03
-	doIconst1,       // ICONST_1    0x04
-	doIconst2,       // ICONST_2    0x05
-	doIconst3,       // ICONST_3    0x06
-	doIconst4,       // ICONST_4    0x07
-	doIconst5,       // ICONST_5    0x08
-	doLconst0,       // LCONST_0    0x09
-	doLconst1,       // LCONST_1    0x0A
-	doFconst0,       // FCONST_0    0x0B
-	doFconst1,       // FCONST_1    0x0C
-	doFconst2,       // FCONST_2    0x0D
-	doDconst0,       // DCONST_0    0x0E
-	doDconst1,       // DCONST_1    0x0F
-	doBiPush,        // BIPUSH      0x10
-	notImplemented,  // SIPUSH      0x11
-	doLdc,           // LDC             0x12
-	doLdcw,          // LDC_W           0x13
-	notImplemented,  // LDC2_W          0x14
-	notImplemented,  // ILOAD           0x15
-	notImplemented,  // LLOAD           0x16
-	notImplemented,  // FLOAD           0x17
-	notImplemented,  // DLOAD           0x18
-	notImplemented,  // ALOAD           0x19
-	doIload0,        // ILOAD_0         0x1A
-	doIload1,        // ILOAD_1         0x1B
-	doIload2,        // ILOAD_2         0x1C
-	doIload3,        // ILOAD_3         0x1D
-	notImplemented,  // LLOAD_0         0x1E
-	notImplemented,  // LLOAD_1         0x1F
-	notImplemented,  // LLOAD_2         0x20
-	notImplemented,  // LLOAD_3         0x21
-	notImplemented,  // FLOAD_0         0x22
-	notImplemented,  // FLOAD_1         0x23
-	notImplemented,  // FLOAD_2         0x24
-	notImplemented,  // FLOAD_3         0x25
-	notImplemented,  // DLOAD_0         0x26
-	notImplemented,  // DLOAD_1         0x27
-	notImplemented,  // DLOAD_2         0x28
-	notImplemented,  // DLOAD_3         0x29
-	doAload0,        // ALOAD_0         0x2A
-	notImplemented,  // ALOAD_1         0x2B
-	notImplemented,  // ALOAD_2         0x2C
-	notImplemented,  // ALOAD_3         0x2D
-	notImplemented,  // IALOAD          0x2E
-	notImplemented,  // LALOAD          0x2F
-	notImplemented,  // FALOAD          0x30
-	notImplemented,  // DALOAD          0x31
-	notImplemented,  // AALOAD          0x32
-	notImplemented,  // BALOAD          0x33
-	notImplemented,  // CALOAD          0x34
-	notImplemented,  // SALOAD          0x35
-	doIstore,        // ISTORE          0x36
-	doIstore,        // LSTORE          0x37
-	notImplemented,  // FSTORE          0x38
-	notImplemented,  // DSTORE          0x39
-	notImplemented,  // ASTORE          0x3A
-	doIstore0,       // ISTORE_0        0x3B
-	doIstore1,       // ISTORE_1        0x3C
-	doIstore2,       // ISTORE_2        0x3D
-	doIstore3,       // ISTORE_3        0x3E
-	notImplemented,  // LSTORE_0        0x3F
-	notImplemented,  // LSTORE_1        0x40
-	notImplemented,  // LSTORE_2        0x41
-	notImplemented,  // LSTORE_3        0x42
-	notImplemented,  // FSTORE_0        0x43
-	notImplemented,  // FSTORE_1        0x44
-	notImplemented,  // FSTORE_2        0x45
-	notImplemented,  // FSTORE_3        0x46
-	notImplemented,  // DSTORE_0        0x47
-	notImplemented,  // DSTORE_1        0x48
-	notImplemented,  // DSTORE_2        0x49
-	notImplemented,  // DSTORE_3        0x4A
-	notImplemented,  // ASTORE_0        0x4B
-	notImplemented,  // ASTORE_1        0x4C
-	notImplemented,  // ASTORE_2        0x4D
-	notImplemented,  // ASTORE_3        0x4E
-	notImplemented,  // IASTORE         0x4F
-	notImplemented,  // LASTORE         0x50
-	notImplemented,  // FASTORE         0x51
-	notImplemented,  // DASTORE         0x52
-	notImplemented,  // AASTORE         0x53
-	notImplemented,  // BASTORE         0x54
-	notImplemented,  // CASTORE         0x55
-	notImplemented,  // SASTORE         0x56
-	notImplemented,  // POP             0x57
-	notImplemented,  // POP2            0x58
-	notImplemented,  // DUP             0x59
-	notImplemented,  // DUP_X1          0x5A
-	notImplemented,  // DUP_X2          0x5B
-	notImplemented,  // DUP2            0x5C
-	notImplemented,  // DUP2_X1         0x5D
-	notImplemented,  // DUP2_X2         0x5E
-	notImplemented,  // SWAP            0x5F
-	doIadd,          // IADD            0x60
-	notImplemented,  // LADD            0x61
-	notImplemented,  // FADD            0x62
-	notImplemented,  // DADD            0x63
-	doIsub,          // ISUB            0x64
-	notImplemented,  // LSUB            0x65
-	notImplemented,  // FSUB            0x66
-	notImplemented,  // DSUB            0x67
-	doImul,          // IMUL            0x68
-	notImplemented,  // LMUL            0x69
-	notImplemented,  // FMUL            0x6A
-	notImplemented,  // DMUL            0x6B
-	notImplemented,  // IDIV            0x6C
-	notImplemented,  // LDIV            0x6D
-	notImplemented,  // FDIV            0x6E
-	notImplemented,  // DDIV            0x6F
-	notImplemented,  // IREM            0x70
-	notImplemented,  // LREM            0x71
-	notImplemented,  // FREM            0x72
-	notImplemented,  // DREM            0x73
-	notImplemented,  // INEG            0x74
-	notImplemented,  // LNEG            0x75
-	notImplemented,  // FNEG            0x76
-	notImplemented,  // DNEG            0x77
-	notImplemented,  // ISHL            0x78
-	notImplemented,  // LSHL            0x79
-	notImplemented,  // ISHR            0x7A
-	notImplemented,  // LSHR            0x7B
-	notImplemented,  // IUSHR           0x7C
-	notImplemented,  // LUSHR           0x7D
-	notImplemented,  // IAND            0x7E
-	notImplemented,  // LAND            0x7F
-	notImplemented,  // IOR             0x80
-	notImplemented,  // LOR             0x81
-	notImplemented,  // IXOR            0x82
-	notImplemented,  // LXOR            0x83
-	doIinc,          // IINC            0x84
-	notImplemented,  // I2L             0x85
-	notImplemented,  // I2F             0x86
-	notImplemented,  // I2D             0x87
-	notImplemented,  // L2I             0x88
-	notImplemented,  // L2F             0x89
-	notImplemented,  // L2D             0x8A
-	notImplemented,  // F2I             0x8B
-	notImplemented,  // F2L             0x8C
-	notImplemented,  // F2D             0x8D
-	notImplemented,  // D2I             0x8E
-	notImplemented,  // D2L             0x8F
-	notImplemented,  // D2F             0x90
-	notImplemented,  // I2B             0x91
-	notImplemented,  // I2C             0x92
-	notImplemented,  // I2S             0x93
-	notImplemented,  // LCMP            0x94
-	notImplemented,  // FCMPL           0x95
-	notImplemented,  // FCMPG           0x96
-	notImplemented,  // DCMPL           0x97
-	notImplemented,  // DCMPG           0x98
-	notImplemented,  // IFEQ            0x99
-	notImplemented,  // IFNE            0x9A
-	notImplemented,  // IFLT            0x9B
-	notImplemented,  // IFGE            0x9C
-	notImplemented,  // IFGT            0x9D
-	notImplemented,  // IFLE            0x9E
-	notImplemented,  // IF_ICMPEQ       0x9F
-	notImplemented,  // IF_ICMPNE       0xA0
-	doIficmplt,      // IF_ICMPLT       0xA1
-	doIfIcmpge,      // IF_ICMPGE       0xA2
-	notImplemented,  // IF_ICMPGT       0xA3
-	notImplemented,  // IF_ICMPLE       0xA4
-	notImplemented,  // IF_ACMPEQ       0xA5
-	notImplemented,  // IF_ACMPNE       0xA6
-	doGoto,          // GOTO            0xA7
-	notImplemented,  // JSR             0xA8
-	notImplemented,  // RET             0xA9
-	notImplemented,  // TABLESWITCH     0xAA
-	notImplemented,  // LOOKUPSWITCH    0xAB
-	doIreturn,       // IRETURN         0xAC
-	notImplemented,  // LRETURN         0xAD
-	notImplemented,  // FRETURN         0xAE
-	notImplemented,  // DRETURN         0xAF
-	notImplemented,  // ARETURN         0xB0
-	doReturn,        // RETURN          0xB1
-	doGetStatic,     // GETSTATIC       0xB2
-	notImplemented,  // PUTSTATIC       0xB3
-	notImplemented,  // GETFIELD        0xB4
-	notImplemented,  // PUTFIELD        0xB5
-	doInvokeVirtual, // INVOKEVIRTUAL   0xB6
-	doInvokeSpecial, // INVOKESPECIAL   0xB7
-	doInvokestatic,  // INVOKESTATIC    0xB8
-	notImplemented,  // INVOKEINTERFACE 0xB9
-	notImplemented,  // INVOKEDYNAMIC   0xBA
-	notImplemented,  // NEW             0xBB
-	notImplemented,  // NEWARRAY        0xBC
-	notImplemented,  // ANEWARRAY       0xBD
-	notImplemented,  // ARRAYLENGTH     0xBE
-	notImplemented,  // ATHROW          0xBF
-	notImplemented,  // CHECKCAST       0xC0
-	notImplemented,  // INSTANCEOF      0xC1
-	notImplemented,  // MONITORENTER    0xC2
-	notImplemented,  // MONITOREXIT     0xC3
-	doWide,          // WIDE            0xC4
-	notImplemented,  // MULTIANEWARRAY  0xC5
-	notImplemented,  // IFNULL          0xC6
-	notImplemented,  // IFNONNULL       0xC7
-	notImplemented,  // GOTO_W          0xC8
-	notImplemented,  // JSR_W           0xC9
-	notImplemented,  // BREAKPOINT      0xCA
+// DispatchTable is filled in by init() because the functions that invoke
+// methods and initialize classes can run interpret(), which uses the table.
+var DispatchTable [203]BytecodeFunc
+
+func init() {
+	DispatchTable = [203]BytecodeFunc{
+		doNop,             // NOP             0x00
+		doAconstNull,      // ACONST_NULL     0x01
+		doIconstM1,        // ICONST_M1       0x02
+		doIconst0,         // ICONST_0        0x03
+		doIconst1,         // ICONST_1        0x04
+		doIconst2,         // ICONST_2        0x05
+		doIconst3,         // ICONST_3        0x06
+		doIconst4,         // ICONST_4        0x07
+		doIconst5,         // ICONST_5        0x08
+		doLconst0,         // LCONST_0        0x09
+		doLconst1,         // LCONST_1        0x0A
+		doFconst0,         // FCONST_0        0x0B
+		doFconst1,         // FCONST_1        0x0C
+		doFconst2,         // FCONST_2        0x0D
+		doDconst0,         // DCONST_0        0x0E
+		doDconst1,         // DCONST_1        0x0F
+		doBiPush,          // BIPUSH          0x10
+		doSiPush,          // SIPUSH          0x11
+		doLdc,             // LDC             0x12
+		doLdcw,            // LDC_W           0x13
+		doLdc2w,           // LDC2_W          0x14
+		doIload,           // ILOAD           0x15
+		doLload,           // LLOAD           0x16
+		doFload,           // FLOAD           0x17
+		doDload,           // DLOAD           0x18
+		doAload,           // ALOAD           0x19
+		doIload0,          // ILOAD_0         0x1A
+		doIload1,          // ILOAD_1         0x1B
+		doIload2,          // ILOAD_2         0x1C
+		doIload3,          // ILOAD_3         0x1D
+		doLload0,          // LLOAD_0         0x1E
+		doLload1,          // LLOAD_1         0x1F
+		doLload2,          // LLOAD_2         0x20
+		doLload3,          // LLOAD_3         0x21
+		doFload0,          // FLOAD_0         0x22
+		doFload1,          // FLOAD_1         0x23
+		doFload2,          // FLOAD_2         0x24
+		doFload3,          // FLOAD_3         0x25
+		doDload0,          // DLOAD_0         0x26
+		doDload1,          // DLOAD_1         0x27
+		doDload2,          // DLOAD_2         0x28
+		doDload3,          // DLOAD_3         0x29
+		doAload0,          // ALOAD_0         0x2A
+		doAload1,          // ALOAD_1         0x2B
+		doAload2,          // ALOAD_2         0x2C
+		doAload3,          // ALOAD_3         0x2D
+		doIaload,          // IALOAD          0x2E
+		doLaload,          // LALOAD          0x2F
+		doFaload,          // FALOAD          0x30
+		doDaload,          // DALOAD          0x31
+		doAaload,          // AALOAD          0x32
+		doBaload,          // BALOAD          0x33
+		doIaload,          // CALOAD          0x34
+		doIaload,          // SALOAD          0x35
+		doIstore,          // ISTORE          0x36
+		doLstore,          // LSTORE          0x37
+		doFstore,          // FSTORE          0x38
+		doLstore,          // DSTORE          0x39
+		doFstore,          // ASTORE          0x3A
+		doIstore0,         // ISTORE_0        0x3B
+		doIstore1,         // ISTORE_1        0x3C
+		doIstore2,         // ISTORE_2        0x3D
+		doIstore3,         // ISTORE_3        0x3E
+		doLstore0,         // LSTORE_0        0x3F
+		doLstore1,         // LSTORE_1        0x40
+		doLstore2,         // LSTORE_2        0x41
+		doLstore3,         // LSTORE_3        0x42
+		doFstore0,         // FSTORE_0        0x43
+		doFstore1,         // FSTORE_1        0x44
+		doFstore2,         // FSTORE_2        0x45
+		doFstore3,         // FSTORE_3        0x46
+		doLstore0,         // DSTORE_0        0x47
+		doLstore1,         // DSTORE_1        0x48
+		doLstore2,         // DSTORE_2        0x49
+		doLstore3,         // DSTORE_3        0x4A
+		doFstore0,         // ASTORE_0        0x4B
+		doFstore1,         // ASTORE_1        0x4C
+		doFstore2,         // ASTORE_2        0x4D
+		doFstore3,         // ASTORE_3        0x4E
+		doIastore,         // IASTORE         0x4F
+		doIastore,         // LASTORE         0x50
+		doFastore,         // FASTORE         0x51
+		doFastore,         // DASTORE         0x52
+		doAastore,         // AASTORE         0x53
+		doBastore,         // BASTORE         0x54
+		doIastore,         // CASTORE         0x55
+		doIastore,         // SASTORE         0x56
+		doPop,             // POP             0x57
+		doPop2,            // POP2            0x58
+		doDup,             // DUP             0x59
+		doDupx1,           // DUP_X1          0x5A
+		doDupx2,           // DUP_X2          0x5B
+		doDup2,            // DUP2            0x5C
+		doDup2x1,          // DUP2_X1         0x5D
+		doDup2x2,          // DUP2_X2         0x5E
+		doSwap,            // SWAP            0x5F
+		doIadd,            // IADD            0x60
+		doLadd,            // LADD            0x61
+		doFadd,            // FADD            0x62
+		doDadd,            // DADD            0x63
+		doIsub,            // ISUB            0x64
+		doLsub,            // LSUB            0x65
+		doFsub,            // FSUB            0x66
+		doDsub,            // DSUB            0x67
+		doImul,            // IMUL            0x68
+		doLmul,            // LMUL            0x69
+		doFmul,            // FMUL            0x6A
+		doDmul,            // DMUL            0x6B
+		doIdiv,            // IDIV            0x6C
+		doLdiv,            // LDIV            0x6D
+		doFdiv,            // FDIV            0x6E
+		doDdiv,            // DDIV            0x6F
+		doIrem,            // IREM            0x70
+		doLrem,            // LREM            0x71
+		doFrem,            // FREM            0x72
+		doDrem,            // DREM            0x73
+		doIneg,            // INEG            0x74
+		doLneg,            // LNEG            0x75
+		doFneg,            // FNEG            0x76
+		doDneg,            // DNEG            0x77
+		doIshl,            // ISHL            0x78
+		doLshl,            // LSHL            0x79
+		doIshr,            // ISHR            0x7A
+		doLshr,            // LSHR            0x7B
+		doIushr,           // IUSHR           0x7C
+		doLushr,           // LUSHR           0x7D
+		doIand,            // IAND            0x7E
+		doLand,            // LAND            0x7F
+		doIor,             // IOR             0x80
+		doLor,             // LOR             0x81
+		doIxor,            // IXOR            0x82
+		doLxor,            // LXOR            0x83
+		doIinc,            // IINC            0x84
+		doI2l,             // I2L             0x85
+		doI2f,             // I2F             0x86
+		doI2d,             // I2D             0x87
+		doL2i,             // L2I             0x88
+		doL2f,             // L2F             0x89
+		doL2d,             // L2D             0x8A
+		doF2i,             // F2I             0x8B
+		doF2l,             // F2L             0x8C
+		doF2d,             // F2D             0x8D
+		doD2i,             // D2I             0x8E
+		doD2l,             // D2L             0x8F
+		doD2f,             // D2F             0x90
+		doI2b,             // I2B             0x91
+		doI2c,             // I2C             0x92
+		doI2s,             // I2S             0x93
+		doLcmp,            // LCMP            0x94
+		doFcmpl,           // FCMPL           0x95
+		doFcmpg,           // FCMPG           0x96
+		doFcmpl,           // DCMPL           0x97
+		doFcmpg,           // DCMPG           0x98
+		doIfeq,            // IFEQ            0x99
+		doIfne,            // IFNE            0x9A
+		doIflt,            // IFLT            0x9B
+		doIfge,            // IFGE            0x9C
+		doIfgt,            // IFGT            0x9D
+		doIfle,            // IFLE            0x9E
+		doIficmpeq,        // IF_ICMPEQ       0x9F
+		doIficmpne,        // IF_ICMPNE       0xA0
+		doIficmplt,        // IF_ICMPLT       0xA1
+		doIficmpge,        // IF_ICMPGE       0xA2
+		doIficmpgt,        // IF_ICMPGT       0xA3
+		doIficmple,        // IF_ICMPLE       0xA4
+		doIfacmpeq,        // IF_ACMPEQ       0xA5
+		doIfacmpne,        // IF_ACMPNE       0xA6
+		doGoto,            // GOTO            0xA7
+		doJsr,             // JSR             0xA8
+		doRet,             // RET             0xA9
+		doTableswitch,     // TABLESWITCH     0xAA
+		doLookupswitch,    // LOOKUPSWITCH    0xAB
+		doIreturn,         // IRETURN         0xAC
+		doLreturn,         // LRETURN         0xAD
+		doIreturn,         // FRETURN         0xAE
+		doLreturn,         // DRETURN         0xAF
+		doIreturn,         // ARETURN         0xB0
+		doReturn,          // RETURN          0xB1
+		doGetStatic,       // GETSTATIC       0xB2
+		doPutStatic,       // PUTSTATIC       0xB3
+		doGetField,        // GETFIELD        0xB4
+		doPutField,        // PUTFIELD        0xB5
+		doInvokeVirtual,   // INVOKEVIRTUAL   0xB6
+		doInvokeSpecial,   // INVOKESPECIAL   0xB7
+		doInvokestatic,    // INVOKESTATIC    0xB8
+		doInvokeinterface, // INVOKEINTERFACE 0xB9
+		doInvokedynamic,   // INVOKEDYNAMIC   0xBA
+		doNew,             // NEW             0xBB
+		doNewarray,        // NEWARRAY        0xBC
+		doAnewarray,       // ANEWARRAY       0xBD
+		doArraylength,     // ARRAYLENGTH     0xBE
+		doAthrow,          // ATHROW          0xBF
+		doCheckcast,       // CHECKCAST       0xC0
+		doInstanceof,      // INSTANCEOF      0xC1
+		doMonitorenter,    // MONITORENTER    0xC2
+		doMonitorexit,     // MONITOREXIT     0xC3
+		doWide,            // WIDE            0xC4
+		doMultianewarray,  // MULTIANEWARRAY  0xC5
+		doIfnull,          // IFNULL          0xC6
+		doIfnonnull,       // IFNONNULL       0xC7
+		doGotow,           // GOTO_W          0xC8
+		doJsrw,            // JSR_W           0xC9
+		notImplemented,    // BREAKPOINT      0xCA
+	}
 }
 
 // the main interpreter loop. This loop takes responsibility for
@@ -249,10 +268,12 @@ var DispatchTable = [203]BytecodeFunc{
 // popping the current frame when a bytecode of the RETURN family
 // is encountered. In both cases, interpret() returns and the
 // runThread() loop goes to the top of the frame stack and calls
-// interpret() on the frame found there, if any.
-func interpret(fs *list.List) {
+// interpret() on the frame found there, if any. The returned error
+// is non-nil only if an exception was not caught, which can happen
+// only in tests (otherwise, an uncaught exception shuts down the JVM).
+func interpret(fs *list.List) error {
 	fr := fs.Front().Value.(*frames.Frame)
-	if fr.FrameStack == nil { // make sure the can reference the frame stack
+	if fr.FrameStack == nil { // the bytecode functions reach the frame stack via the frame
 		fr.FrameStack = fs
 	}
 
@@ -263,19 +284,44 @@ func interpret(fs *list.List) {
 		}
 
 		opcode := fr.Meth[fr.PC]
-		ret := DispatchTable[opcode](fr, 0)
+		fr.ExceptionPC = fr.PC // in the event of an exception, here's where we were
+
+		// a synchronized method releases its monitor when it returns
+		if fr.SyncObject != nil && opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN {
+			if !exitSyncMethod(fr) {
+				errMsg := fmt.Sprintf("%s: current thread is not the owner of the method's monitor",
+					opcodes.BytecodeNames[opcode])
+				if throwEx(fr, excNames.IllegalMonitorStateException, errMsg) != 0 {
+					return takeError(fr) // applies only if in test
+				}
+				return nil
+			}
+		}
+
+		var ret int
+		if int(opcode) < len(DispatchTable) {
+			ret = DispatchTable[opcode](fr, 0)
+		} else {
+			ret = notImplemented(fr, 0)
+		}
+
 		switch ret {
 		case 0:
 			// exiting will either end program or call this function again
 			// pointing to the top of the loop
-			return
+			return nil
 		case exceptions.ERROR_OCCURRED: // occurs only in tests
-			break
+			return takeError(fr)
 		default:
-
 			fr.PC += ret
 		}
 	}
+
+	// running off the end of the bytecode is a return from a void method
+	if fs.Len() > 0 && fs.Front().Value == fr {
+		fs.Remove(fs.Front())
+	}
+	return nil
 }
 
 // the functions, listed here in numerical order of the bytecode
@@ -294,13 +340,15 @@ func doIconst2(fr *frames.Frame, _ int64) int  { return pushInt(fr, int64(2)) }
 func doIconst3(fr *frames.Frame, _ int64) int  { return pushInt(fr, int64(3)) }
 func doIconst4(fr *frames.Frame, _ int64) int  { return pushInt(fr, int64(4)) }
 func doIconst5(fr *frames.Frame, _ int64) int  { return pushInt(fr, int64(5)) }
-func doLconst0(fr *frames.Frame, _ int64) int  { return pushInt(fr, int64(0)) }
-func doLconst1(fr *frames.Frame, _ int64) int  { return pushInt(fr, int64(1)) }
-func doFconst0(fr *frames.Frame, _ int64) int  { return pushFloat(fr, int64(0)) }
-func doFconst1(fr *frames.Frame, _ int64) int  { return pushFloat(fr, int64(1)) }
-func doFconst2(fr *frames.Frame, _ int64) int  { return pushFloat(fr, int64(2)) }
-func doDconst0(fr *frames.Frame, _ int64) int  { return pushFloat(fr, int64(0)) }
-func doDconst1(fr *frames.Frame, _ int64) int  { return pushFloat(fr, int64(1)) }
+func doLconst0(fr *frames.Frame, _ int64) int  { return pushLong(fr, int64(0)) }
+func doLconst1(fr *frames.Frame, _ int64) int  { return pushLong(fr, int64(1)) }
+
+// 0x0B - 0x0F FCONST and DCONST, push float or double onto stack
+func doFconst0(fr *frames.Frame, _ int64) int { return pushFloat(fr, int64(0)) }
+func doFconst1(fr *frames.Frame, _ int64) int { return pushFloat(fr, int64(1)) }
+func doFconst2(fr *frames.Frame, _ int64) int { return pushFloat(fr, int64(2)) }
+func doDconst0(fr *frames.Frame, _ int64) int { return pushDouble(fr, int64(0)) }
+func doDconst1(fr *frames.Frame, _ int64) int { return pushDouble(fr, int64(1)) }
 
 func doBiPush(fr *frames.Frame, _ int64) int { // 0x10 BIPUSH push following byte onto stack
 	wbyte := fr.Meth[fr.PC+1]
@@ -309,507 +357,1727 @@ func doBiPush(fr *frames.Frame, _ int64) int { // 0x10 BIPUSH push following byt
 	return 2
 }
 
+func doSiPush(fr *frames.Frame, _ int64) int { // 0x11 SIPUSH create int from next two bytes and push it
+	wint16 := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
+	push(fr, int64(wint16))
+	return 3
+}
+
 // 0x12, 0x13 LDC functions
 func doLdc(fr *frames.Frame, _ int64) int  { return ldc(fr, 1) }
 func doLdcw(fr *frames.Frame, _ int64) int { return ldc(fr, 2) }
 
-func doAload0(fr *frames.Frame, _ int64) int {
-	push(fr, fr.Locals[0])
-	return 1
-}
+func doLdc2w(fr *frames.Frame, _ int64) int { // 0x14 LDC2_W push long or double from CP indexed by next two bytes
+	idx := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
 
-func doIstore(fr *frames.Frame, _ int64) int { // 0x36, 0x37 ISTORE/LSTORE
-	var index int
-	var PCadvance int    // how much to advance fr.PC, the program counter
-	if fr.WideInEffect { // if wide is in effect, index is two bytes wide, otherwise one byte
-		index = (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
-		PCadvance = 2
-		fr.WideInEffect = false
-	} else {
-		index = int(fr.Meth[fr.PC+1])
-		PCadvance = 1
+	CPe := classloader.FetchCPentry(fr.CP.(*classloader.CPool), idx)
+	switch CPe.RetType {
+	case classloader.IS_INT64:
+		pushWide(fr, CPe.IntVal)
+	case classloader.IS_FLOAT64:
+		pushWide(fr, CPe.FloatVal)
+	default:
+		errMsg := fmt.Sprintf("%s, LDC2_W: Invalid type for bytecode operand", inMethod(fr))
+		return throwEx(fr, excNames.ClassFormatError, errMsg)
 	}
-
-	popped := pop(fr)
-	fr.Locals[index] = convertInterfaceToInt64(popped) // TODO: conversion needed?
-	return PCadvance + 1
+	return 3 // 2 for the index + 1 for the next bytecode
 }
 
-// 0x3B - 0x3E ISTORE_0 thru _3: Store popped TOS into locals specified as 0-3 in bytecode name
-func doIstore0(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(0)) }
-func doIstore1(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(1)) }
-func doIstore2(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(2)) }
-func doIstore3(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(3)) }
+// 0x15 - 0x19 ILOAD, LLOAD, FLOAD, DLOAD, ALOAD: push the local whose index is the next byte
+// (or the next two bytes, if WIDE is in effect)
+func doIload(fr *frames.Frame, _ int64) int {
+	index, PCadvance := localIndex(fr)
+	push(fr, fr.Locals[index])
+	return PCadvance
+}
 
-func doIload0(fr *frames.Frame, _ int64) int { return loadInt(fr, int64(0)) }
-func doIload1(fr *frames.Frame, _ int64) int { return loadInt(fr, int64(1)) }
-func doIload2(fr *frames.Frame, _ int64) int { return loadInt(fr, int64(2)) }
-func doIload3(fr *frames.Frame, _ int64) int { return loadInt(fr, int64(3)) }
+func doLload(fr *frames.Frame, _ int64) int { // also DLOAD
+	index, PCadvance := localIndex(fr)
+	pushWide(fr, fr.Locals[index])
+	return PCadvance
+}
 
-func doIadd(fr *frames.Frame, _ int64) int {
-	i2 := pop(fr).(int64)
-	i1 := pop(fr).(int64)
-	sum := add(i1, i2)
-	push(fr, sum)
+func doFload(fr *frames.Frame, f int64) int { return doIload(fr, f) }
+func doDload(fr *frames.Frame, f int64) int { return doLload(fr, f) }
+func doAload(fr *frames.Frame, f int64) int { return doIload(fr, f) }
+
+// 0x1A - 0x2D ILOAD_0 thru ALOAD_3: push the local specified as 0-3 in bytecode name
+func doIload0(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(0)) }
+func doIload1(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(1)) }
+func doIload2(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(2)) }
+func doIload3(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(3)) }
+func doLload0(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(0)) }
+func doLload1(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(1)) }
+func doLload2(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(2)) }
+func doLload3(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(3)) }
+func doFload0(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(0)) }
+func doFload1(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(1)) }
+func doFload2(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(2)) }
+func doFload3(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(3)) }
+func doDload0(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(0)) }
+func doDload1(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(1)) }
+func doDload2(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(2)) }
+func doDload3(fr *frames.Frame, _ int64) int { return loadWide(fr, int64(3)) }
+func doAload0(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(0)) }
+func doAload1(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(1)) }
+func doAload2(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(2)) }
+func doAload3(fr *frames.Frame, _ int64) int { return loadLocal(fr, int64(3)) }
+
+func doIaload(fr *frames.Frame, _ int64) int { // 0x2E IALOAD, also CALOAD and SALOAD
+	value, status, ok := arrayElement[int64](fr, "I/C/SALOAD")
+	if !ok {
+		return status
+	}
+	push(fr, value)
 	return 1
 }
 
-func doIsub(fr *frames.Frame, _ int64) int { // Ox64 ISUB subtract int64s from the op stack
-	i2 := pop(fr).(int64)
-	i1 := pop(fr).(int64)
-	diff := subtract(i1, i2)
-	push(fr, diff)
+func doLaload(fr *frames.Frame, _ int64) int { // 0x2F LALOAD push contents of a long array element
+	value, status, ok := arrayElement[int64](fr, "LALOAD")
+	if !ok {
+		return status
+	}
+	pushWide(fr, value)
 	return 1
 }
 
-func doImul(fr *frames.Frame, _ int64) int { // 0x68 IMUL multiply two int64s
-	i2 := pop(fr).(int64)
-	i1 := pop(fr).(int64)
-	product := multiply(i1, i2)
-	push(fr, product)
+func doFaload(fr *frames.Frame, _ int64) int { // 0x30 FALOAD push contents of a float array element
+	value, status, ok := arrayElement[float64](fr, "FALOAD")
+	if !ok {
+		return status
+	}
+	push(fr, value)
 	return 1
 }
 
-func doIinc(fr *frames.Frame, _ int64) int { // 0x84 IINC increment int varialbe
-	var index int
-	var increment int64
-	var PCtoSkip int
-	if fr.WideInEffect { // if wide is in effect, index  and increment are two bytes wide, otherwise one byte each
-		index = (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
-		increment = int64(fr.Meth[fr.PC+1])*256 + int64(fr.Meth[fr.PC+2])
-		PCtoSkip = 4
-		fr.WideInEffect = false
-	} else {
-		index = int(fr.Meth[fr.PC+1])
-		increment = byteToInt64(fr.Meth[fr.PC+2])
-		PCtoSkip = 2
+func doDaload(fr *frames.Frame, _ int64) int { // 0x31 DALOAD push contents of a double array element
+	value, status, ok := arrayElement[float64](fr, "DALOAD")
+	if !ok {
+		return status
 	}
-	orig := fr.Locals[index].(int64)
-	fr.Locals[index] = orig + increment
-	return PCtoSkip + 1
+	pushWide(fr, value)
+	return 1
 }
 
-func doIficmplt(fr *frames.Frame, _ int64) int { // 0xA1 IF_ICMPLT Compare ints for <
-	popValue := pop(fr)
-	val2 := convertInterfaceToInt64(popValue)
-	popValue = pop(fr)
-	val1 := convertInterfaceToInt64(popValue)
-	if val1 < val2 { // if comp succeeds, next 2 bytes hold instruction index
-		jumpTo := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
-		return int(jumpTo)
-	} else {
-		return 3 // the 2 bytes forming the unused jumpTo + 1 byte to next bytecode
+func doAaload(fr *frames.Frame, _ int64) int { // 0x32 AALOAD push contents of a reference array element
+	value, status, ok := arrayElement[*object.Object](fr, "AALOAD")
+	if !ok {
+		return status
 	}
+	push(fr, value)
+	return 1
 }
 
-func doIfIcmpge(fr *frames.Frame, _ int64) int { // 0xA2 IF_ICMPGE Compare ints for >=
-	popValue := pop(fr)
-	val2 := convertInterfaceToInt64(popValue)
-	popValue = pop(fr)
-	val1 := convertInterfaceToInt64(popValue)
-	if val1 >= val2 { // if comp succeeds, next 2 bytes hold instruction index
-		jumpTo := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
-		return int(jumpTo)
-	} else {
-		return 3 // the 2 bytes forming the unused jumpTo + 1 byte to next bytecode
+func doBaload(fr *frames.Frame, _ int64) int { // 0x33 BALOAD push contents of a byte/boolean array element
+	value, status, ok := arrayElement[byte](fr, "BALOAD")
+	if !ok {
+		return status
 	}
+	push(fr, int64(types.JavaByte(value))) // Java bytes are signed
+	return 1
 }
 
-func doGoto(fr *frames.Frame, _ int64) int { // 0xA7 GOTO unconditional jump within method
-	jumpTo := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
-	return int(jumpTo) // note the value can be negative to jump to earlier bytecode
+// 0x36 - 0x3A ISTORE, LSTORE, FSTORE, DSTORE, ASTORE: store popped TOS into the local whose
+// index is the next byte (or the next two bytes, if WIDE is in effect)
+func doIstore(fr *frames.Frame, _ int64) int {
+	index, PCadvance := localIndex(fr)
+	fr.Locals[index] = convertInterfaceToInt64(pop(fr))
+	return PCadvance
 }
 
-func doIreturn(fr *frames.Frame, _ int64) int { // 0xAC IRETURN return an int64 from method call
-	valToReturn := pop(fr)
-	f := fr.FrameStack.Front().Next().Value.(*frames.Frame)
-	push(f, valToReturn) // TODO: check what happens when main() ends on IRETURN
-	fr.FrameStack.Remove(fr.FrameStack.Front())
-	return 0
+func doLstore(fr *frames.Frame, _ int64) int { // also DSTORE
+	index, PCadvance := localIndex(fr)
+	value := pop(fr)
+	fr.Locals[index] = value // longs and doubles occupy two locals
+	fr.Locals[index+1] = value
+	return PCadvance
 }
 
-func doReturn(fr *frames.Frame, _ int64) int { // 0xB1 RETURN return from void methodjav
-	fr.FrameStack.Remove(fr.FrameStack.Front())
-	return 0
+func doFstore(fr *frames.Frame, _ int64) int { // also ASTORE
+	index, PCadvance := localIndex(fr)
+	fr.Locals[index] = pop(fr)
+	return PCadvance
 }
 
-func doGetStatic(fr *frames.Frame, _ int64) int { // 0xB2 GETSTATIC
-	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
-	// f.PC += 2
-	CP := fr.CP.(*classloader.CPool)
-	CPentry := CP.CpIndex[CPslot]
-	if CPentry.Type != classloader.FieldRef { // the pointed-to CP entry must be a field reference
-		globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-		errMsg := fmt.Sprintf("GETSTATIC: Expected a field ref, but got %d in"+
-			"location %d in method %s of class %s\n",
-			CPentry.Type, fr.PC, fr.MethName, fr.ClName)
-		exceptions.ThrowEx(excNames.NoSuchFieldException, errMsg, fr)
-	}
-
-	// get the field entry
-	field := CP.FieldRefs[CPentry.Slot]
+// 0x3B - 0x4E ISTORE_0 thru ASTORE_3: Store popped TOS into locals specified as 0-3 in bytecode name
+func doIstore0(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(0)) }
+func doIstore1(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(1)) }
+func doIstore2(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(2)) }
+func doIstore3(fr *frames.Frame, _ int64) int { return storeInt(fr, int64(3)) }
+func doLstore0(fr *frames.Frame, _ int64) int { return storeWide(fr, int64(0)) }
+func doLstore1(fr *frames.Frame, _ int64) int { return storeWide(fr, int64(1)) }
+func doLstore2(fr *frames.Frame, _ int64) int { return storeWide(fr, int64(2)) }
+func doLstore3(fr *frames.Frame, _ int64) int { return storeWide(fr, int64(3)) }
+func doFstore0(fr *frames.Frame, _ int64) int { return storeLocal(fr, int64(0)) }
+func doFstore1(fr *frames.Frame, _ int64) int { return storeLocal(fr, int64(1)) }
+func doFstore2(fr *frames.Frame, _ int64) int { return storeLocal(fr, int64(2)) }
+func doFstore3(fr *frames.Frame, _ int64) int { return storeLocal(fr, int64(3)) }
+
+func doIastore(fr *frames.Frame, _ int64) int { // 0x4F IASTORE, also LASTORE, CASTORE, and SASTORE
+	value := convertInterfaceToInt64(pop(fr))
+	return setArrayElement(fr, "I/C/S/LASTORE", value)
+}
 
-	// get the class entry from the field entry for this field. It's the class name.
-	classRef := field.ClassIndex
-	classNameIndex := CP.ClassRefs[CP.CpIndex[classRef].Slot]
-	classNamePtr := stringPool.GetStringPointer(classNameIndex)
-	className := *classNamePtr
+func doFastore(fr *frames.Frame, _ int64) int { // 0x51 FASTORE, also DASTORE
+	value := pop(fr).(float64)
+	return setArrayElement(fr, "F/DASTORE", value)
+}
 
-	// process the name and type entry for this field
-	nAndTindex := field.NameAndType
-	nAndTentry := CP.CpIndex[nAndTindex]
-	nAndTslot := nAndTentry.Slot
-	nAndT := CP.NameAndTypes[nAndTslot]
-	fieldNameIndex := nAndT.NameIndex
-	fieldName := classloader.FetchUTF8stringFromCPEntryNumber(CP, fieldNameIndex)
-	fieldName = className + "." + fieldName
-	if MainThread.Trace {
-		emitTraceFieldID("GETSTATIC", fieldName)
+func doAastore(fr *frames.Frame, _ int64) int { // 0x53 AASTORE store a reference in a reference array
+	value, ok := pop(fr).(*object.Object)
+	if !ok || value == nil {
+		value = object.Null
 	}
+	return setArrayElement(fr, "AASTORE", value)
+}
 
-	// was this static field previously loaded? Is so, get its location and move on.
-	prevLoaded, ok := statics.Statics[fieldName]
-	if !ok { // if field is not already loaded, then
-		// the class has not been instantiated, so
-		// instantiate the class
-		_, err := InstantiateClass(className, fr.FrameStack)
-		if err == nil {
-			prevLoaded, ok = statics.Statics[fieldName]
-		} else {
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := fmt.Sprintf("GETSTATIC: could not load class %s", className)
-			_ = log.Log(errMsg, log.SEVERE)
-			exceptions.ThrowEx(excNames.ClassNotFoundException, errMsg, fr)
-		}
-	}
+func doBastore(fr *frames.Frame, _ int64) int { // 0x54 BASTORE store a boolean or byte in byte array
+	value := convertInterfaceToByte(pop(fr))
+	return setArrayElement(fr, "BASTORE", value)
+}
 
-	// if the field can't be found even after instantiating the
-	// containing class, something is wrong so get out of here.
-	if !ok {
-		globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-		errMsg := fmt.Sprintf("GETSTATIC: could not find static field %s in class %s"+
-			"\n", fieldName, className)
-		exceptions.ThrowEx(excNames.NoSuchFieldException, errMsg, fr)
+func doPop(fr *frames.Frame, _ int64) int { // 0x57 POP pop an item off the stack and discard it
+	if fr.TOS < 0 {
+		errMsg := fmt.Sprintf("stack underflow in POP %s", inMethod(fr))
+		return throwEx(fr, excNames.InternalException, errMsg)
 	}
+	fr.TOS -= 1
+	return 1
+}
 
-	switch prevLoaded.Value.(type) {
-	case bool:
-		// a boolean, which might
-		// be stored as a boolean, a byte (in an array), or int64
-		// We want all forms normalized to int64
-		value := prevLoaded.Value.(bool)
-		prevLoaded.Value =
-			types.ConvertGoBoolToJavaBool(value)
-		push(fr, prevLoaded.Value)
-	case byte:
-		value := prevLoaded.Value.(byte)
-		prevLoaded.Value = int64(value)
-		push(fr, prevLoaded.Value)
-	case int:
-		value := prevLoaded.Value.(int)
-		push(fr, int64(value))
-	default:
-		push(fr, prevLoaded.Value)
+func doPop2(fr *frames.Frame, _ int64) int { // 0x58 POP2 pop two words (one long or double) off the stack
+	if _, ok := popWords(fr, 2); !ok {
+		return stackWordError(fr, "POP2")
 	}
-	return 3 // 2 for the CP slot + 1 for the next bytecode
+	return 1
 }
 
-func doInvokeVirtual(fr *frames.Frame, _ int64) int { // 0xB6 INVOKEVIRTUAL
-	var err error
-	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
-	// fr.PC += 2
-	CP := fr.CP.(*classloader.CPool)
-	CPentry := CP.CpIndex[CPslot]
-	if CPentry.Type != classloader.MethodRef { // the pointed-to CP entry must be a method reference
-		globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-		errMsg := fmt.Sprintf("INVOKEVIRTUAL: Expected a method ref, but got %d in"+
-			"location %d in method %s of class %s\n",
-			CPentry.Type, fr.PC, fr.MethName, fr.ClName)
-		status := exceptions.ThrowEx(excNames.WrongMethodTypeException, errMsg, fr)
-		if status != exceptions.Caught {
-			return exceptions.ERROR_OCCURRED // applies only if in test
-		}
+func doDup(fr *frames.Frame, _ int64) int { // 0x59 DUP push an item equal to the current top of the stack
+	if isWide(fr, fr.TOS) {
+		pushWide(fr, peek(fr))
+	} else {
+		push(fr, peek(fr))
 	}
+	return 1
+}
 
-	className, methodName, methodType :=
-		classloader.GetMethInfoFromCPmethref(CP, CPslot)
-
-	mtEntry := classloader.MTable[className+"."+methodName+methodType]
-	if mtEntry.Meth == nil { // if the method is not in the method table, find it
-		mtEntry, err = classloader.FetchMethodAndCP(className, methodName, methodType)
-		if err != nil || mtEntry.Meth == nil {
-			// TODO: search the superclasses, then the classpath and retry
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKEVIRTUAL: Class method not found: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.UnsupportedOperationException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
+func doDupx1(fr *frames.Frame, _ int64) int { // 0x5A DUP_X1 duplicate the top value and insert it two words down
+	value1, ok1 := popWords(fr, 1)
+	value2, ok2 := popWords(fr, 1)
+	if !ok1 || !ok2 {
+		return stackWordError(fr, "DUP_X1")
 	}
+	pushEntries(fr, value1, value2, value1)
+	return 1
+}
 
-	// if we have a native function (here, one implemented in golang, rather than Java),
-	// then follow the JVM spec and push the objectRef and the parameters to the function
-	// as parameters. Consult:
-	// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-6.html#jvms-6.5.invokevirtual
-	if mtEntry.MType == 'G' { // so we have a native golang function
-		// get the parameters/args off the stack
-		gmethData := mtEntry.Meth.(gfunction.GMeth)
-		paramCount := gmethData.ParamSlots
-		var params []interface{}
-		for i := 0; i < paramCount; i++ {
-			params = append(params, pop(fr))
-		}
-
-		// now get the objectRef (the object whose method we're invoking) or a *os.File (stream I/O)
-		popped := pop(fr)
-		params = append(params, popped)
-
-		ret := gfunction.RunGfunction(mtEntry, fr.FrameStack, className, methodName, methodType, &params, true, MainThread.Trace)
-		// if err != nil {
-		if ret != nil {
-			switch ret.(type) {
-			case error: // only occurs in testing
-				if globals.GetGlobalRef().JacobinName == "test" {
-					return exceptions.ERROR_OCCURRED
-				}
-				if errors.Is(ret.(error), gfunction.CaughtGfunctionException) {
-					return 3 // 2 for CP slot + 1 for next bytecode
-				}
-			default: // if it's not an error, then it's a legitimate return value, which we simply push
-				push(fr, ret)
-			}
-			// any exception will already have been handled.
-		}
-		return 3 // 2 for CP slot + 1 for next bytecode
-	}
-
-	if mtEntry.MType == 'J' { // it's a Java or Native function
-		m := mtEntry.Meth.(classloader.JmEntry)
-		if m.AccessFlags&0x0100 > 0 {
-			// Native code
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKEVIRTUAL: Native method requested: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.UnsupportedOperationException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
-		fram, err := createAndInitNewFrame(
-			className, methodName, methodType, &m, true, fr)
-		if err != nil {
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKEVIRTUAL: Error creating frame in: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
-
-		fr.PC += 3                    // 2 for PC slot, move to next bytecode before exiting
-		fr.FrameStack.PushFront(fram) // push the new frame
-		return 0
+func doDupx2(fr *frames.Frame, _ int64) int { // 0x5B DUP_X2 duplicate the top value and insert it three words down
+	value1, ok1 := popWords(fr, 1)
+	value2, ok2 := popWords(fr, 2) // two ints or one long or double
+	if !ok1 || !ok2 {
+		return stackWordError(fr, "DUP_X2")
 	}
-	return exceptions.ERROR_OCCURRED // in theory, unreachable
+	pushEntries(fr, value1, value2, value1)
+	return 1
 }
 
-func doInvokeSpecial(fr *frames.Frame, _ int64) int { // OxB7 INVOKESPECIAL
-	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
-	// f.PC += 2
-	CP := fr.CP.(*classloader.CPool)
-	className, methodName, methodType := classloader.GetMethInfoFromCPmethref(CP, CPslot)
-
-	// if it's a call to java/lang/Object."<init>"()V, which happens frequently,
-	// that function simply returns. So test for it here and if it is, skip the rest
-	fullConstructorName := className + "." + methodName + methodType
-	if fullConstructorName == "java/lang/Object.<init>()V" {
-		return 3 // 2 for the CPslot + 1 for next bytecode
+func doDup2(fr *frames.Frame, _ int64) int { // 0x5C DUP2 duplicate the top two words
+	value1, ok := popWords(fr, 2)
+	if !ok {
+		return stackWordError(fr, "DUP2")
 	}
+	pushEntries(fr, value1, value1)
+	return 1
+}
 
-	mtEntry, err := classloader.FetchMethodAndCP(className, methodName, methodType)
-	if err != nil || mtEntry.Meth == nil {
-		// TODO: search the classpath and retry
-		globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-		errMsg := "INVOKESPECIAL: Class method not found: " + className + "." + methodName + methodType
-		status := exceptions.ThrowEx(excNames.UnsupportedOperationException, errMsg, fr)
-		if status != exceptions.Caught {
-			return exceptions.ERROR_OCCURRED // applies only if in test
-		}
+func doDup2x1(fr *frames.Frame, _ int64) int { // 0x5D DUP2_X1 duplicate the top two words and insert them three words down
+	value1, ok1 := popWords(fr, 2)
+	value2, ok2 := popWords(fr, 1)
+	if !ok1 || !ok2 {
+		return stackWordError(fr, "DUP2_X1")
 	}
+	pushEntries(fr, value1, value2, value1)
+	return 1
+}
 
-	if mtEntry.MType == 'G' { // it's a golang method
-		// get the parameters/args, if any, off the stack
-		gmethData := mtEntry.Meth.(gfunction.GMeth)
-		paramCount := gmethData.ParamSlots
-		var params []interface{}
-		for i := 0; i < paramCount; i++ {
-			// This is not problematic because the params count in the gfunction definition
-			// counts slots, rather than items, so doubles and longs are listed as two slots.
-			params = append(params, pop(fr))
-		}
-
-		// now get the objectRef (the object whose method we're invoking)
-		objRef := pop(fr).(*object.Object)
-		params = append(params, objRef)
-
-		ret := gfunction.RunGfunction(mtEntry, fr.FrameStack, className, methodName, methodType, &params, true, MainThread.Trace)
-		if ret != nil {
-			switch ret.(type) {
-			case error:
-				if globals.GetGlobalRef().JacobinName == "test" {
-					return exceptions.ERROR_OCCURRED
-				}
-				if errors.Is(ret.(error), gfunction.CaughtGfunctionException) {
-					fr.PC += 1 // point to the next executable bytecode
-					return 3   // 2 for CP slot + 1 for next bytecode
-				}
-			default: // if it's not an error, then it's a legitimate return value, which we simply push
-				push(fr, ret)
-			}
-			// any exception will already have been handled.
-		}
-		return 3 // 2 for CP slot + 1 for next bytecode
-	}
-
-	if mtEntry.MType == 'J' {
-		// The arguments are correctly handled in createAndInitNewFrame()
-		m := mtEntry.Meth.(classloader.JmEntry)
-		if m.AccessFlags&0x0100 > 0 {
-			// Native code
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKESPECIAL: Native method requested: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.UnsupportedOperationException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
-		fram, err := createAndInitNewFrame(className, methodName, methodType, &m, true, fr)
-		if err != nil {
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKESPECIAL: Error creating frame in: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
-
-		fr.PC += 3                    // point to the next bytecode for when we return from the invoked method.
-		fr.FrameStack.PushFront(fram) // push the new frame
-		return 0
+func doDup2x2(fr *frames.Frame, _ int64) int { // 0x5E DUP2_X2 duplicate the top two words and insert them four words down
+	value1, ok1 := popWords(fr, 2)
+	value2, ok2 := popWords(fr, 2)
+	if !ok1 || !ok2 {
+		return stackWordError(fr, "DUP2_X2")
 	}
-	return exceptions.ERROR_OCCURRED // in theory, unreachable
+	pushEntries(fr, value1, value2, value1)
+	return 1
 }
 
-func doInvokestatic(fr *frames.Frame, _ int64) int { // 0xB8 INVOKESTATIC
-	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
-	CP := fr.CP.(*classloader.CPool)
-
-	className, methodName, methodType :=
-		classloader.GetMethInfoFromCPmethref(CP, CPslot)
-
-	mtEntry, err := classloader.FetchMethodAndCP(className, methodName, methodType)
-	if err != nil || mtEntry.Meth == nil {
-		// TODO: search the classpath and retry
-		globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-		errMsg := "INVOKESTATIC: Class method not found: " + className + "." + methodName + methodType
-		status := exceptions.ThrowEx(excNames.UnsupportedOperationException, errMsg, fr)
-		if status != exceptions.Caught {
-			return exceptions.ERROR_OCCURRED // applies only if in test
-		}
+func doSwap(fr *frames.Frame, _ int64) int { // 0x5F SWAP swap the top two words on the stack
+	value1, ok1 := popWords(fr, 1)
+	value2, ok2 := popWords(fr, 1)
+	if !ok1 || !ok2 {
+		return stackWordError(fr, "SWAP")
 	}
+	pushEntries(fr, value1, value2)
+	return 1
+}
 
-	// before we can run the method, we need to either instantiate the class and/or
-	// make sure that its static intializer block (if any) has been run. At this point,
-	// all we know is that the class exists and has been loaded.
-	k := classloader.MethAreaFetch(className)
-	if k.Data.ClInit == types.ClInitNotRun {
-		err = runInitializationBlock(k, nil, fr.FrameStack)
-		if err != nil {
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := fmt.Sprintf("INVOKESTATIC: error running initializer block in %s",
-				className+"."+methodName+methodType)
-			status := exceptions.ThrowEx(excNames.ClassNotLoadedException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
-	}
+// 0x60 - 0x6B: IADD, LADD, FADD, DADD, and the like for subtraction and multiplication. Ints
+// and floats are kept in int64s and float64s, so the results are narrowed to 32 bits here.
+func doIadd(fr *frames.Frame, _ int64) int {
+	i2 := pop(fr).(int64)
+	i1 := pop(fr).(int64)
+	push(fr, int64(int32(add(i1, i2))))
+	return 1
+}
 
-	if mtEntry.MType == 'G' {
-		gmethData := mtEntry.Meth.(gfunction.GMeth)
-		paramCount := gmethData.ParamSlots
-		var params []interface{}
-		for i := 0; i < paramCount; i++ {
-			params = append(params, pop(fr))
-		}
+func doLadd(fr *frames.Frame, _ int64) int {
+	l2 := pop(fr).(int64)
+	l1 := pop(fr).(int64)
+	pushWide(fr, add(l1, l2))
+	return 1
+}
 
-		// fr.PC += 2 // advance PC for the first two bytes of this bytecode
-		ret := gfunction.RunGfunction(mtEntry, fr.FrameStack, className, methodName, methodType, &params, false, MainThread.Trace)
-		if ret != nil {
-			switch ret.(type) {
-			case error:
-				if globals.GetGlobalRef().JacobinName == "test" {
-					return exceptions.ERROR_OCCURRED
-				} else if errors.Is(ret.(error), gfunction.CaughtGfunctionException) {
-					return 3
-				}
-			default: // if it's not an error, then it's a legitimate return value, which we simply push
-				push(fr, ret)
-				return 3
-			}
-		}
-		// any exception will already have been handled.
-	} else if mtEntry.MType == 'J' {
-		m := mtEntry.Meth.(classloader.JmEntry)
-		if m.AccessFlags&0x0100 > 0 {
-			// Native code
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKESTATIC: Native method requested: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.UnsupportedOperationException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
-		fram, err := createAndInitNewFrame(
-			className, methodName, methodType, &m, false, fr)
-		if err != nil {
-			globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-			errMsg := "INVOKESTATIC: Error creating frame in: " + className + "." + methodName + methodType
-			status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, fr)
-			if status != exceptions.Caught {
-				return exceptions.ERROR_OCCURRED // applies only if in test
-			}
-		}
+func doFadd(fr *frames.Frame, _ int64) int {
+	f2 := pop(fr).(float64)
+	f1 := pop(fr).(float64)
+	push(fr, float64(float32(add(f1, f2))))
+	return 1
+}
 
-		fr.PC += 2                    // 2 == initial PC advance in this bytecode (see above)
-		fr.PC += 1                    // to point to the next bytecode before exiting
-		fr.FrameStack.PushFront(fram) // push the new frame
-		return 0
-	}
-	return exceptions.ERROR_OCCURRED // in theory, unreachable code
+func doDadd(fr *frames.Frame, _ int64) int {
+	d2 := pop(fr).(float64)
+	d1 := pop(fr).(float64)
+	pushWide(fr, add(d1, d2))
+	return 1
 }
 
-func doWide(fr *frames.Frame, _ int64) int { // 0xC4 use wide versions of bytecode arguments
-	fr.WideInEffect = true
+func doIsub(fr *frames.Frame, _ int64) int { // Ox64 ISUB subtract int64s from the op stack
+	i2 := pop(fr).(int64)
+	i1 := pop(fr).(int64)
+	push(fr, int64(int32(subtract(i1, i2))))
 	return 1
 }
 
-func notImplemented(_ *frames.Frame, _ int64) int {
+func doLsub(fr *frames.Frame, _ int64) int {
+	l2 := pop(fr).(int64)
+	l1 := pop(fr).(int64)
+	pushWide(fr, subtract(l1, l2))
 	return 1
 }
 
-// === helper methods--that is, functions called by dispatched methods (in alpha order) ===
+func doFsub(fr *frames.Frame, _ int64) int {
+	f2 := pop(fr).(float64)
+	f1 := pop(fr).(float64)
+	push(fr, float64(float32(subtract(f1, f2))))
+	return 1
+}
 
-func loadInt(fr *frames.Frame, local int64) int {
-	push(fr, fr.Locals[local])
+func doDsub(fr *frames.Frame, _ int64) int {
+	d2 := pop(fr).(float64)
+	d1 := pop(fr).(float64)
+	pushWide(fr, subtract(d1, d2))
 	return 1
 }
 
-func ldc(fr *frames.Frame, width int) int {
-	var idx int
-	if width == 1 { // LDC uses a 1-byte index into the CP, LDC_W uses a 2-byte index
-		idx = int(fr.Meth[fr.PC+1])
-	} else {
+func doImul(fr *frames.Frame, _ int64) int { // 0x68 IMUL multiply two int64s
+	i2 := pop(fr).(int64)
+	i1 := pop(fr).(int64)
+	push(fr, int64(int32(multiply(i1, i2))))
+	return 1
+}
+
+func doLmul(fr *frames.Frame, _ int64) int {
+	l2 := pop(fr).(int64)
+	l1 := pop(fr).(int64)
+	pushWide(fr, multiply(l1, l2))
+	return 1
+}
+
+func doFmul(fr *frames.Frame, _ int64) int {
+	f2 := pop(fr).(float64)
+	f1 := pop(fr).(float64)
+	push(fr, float64(float32(multiply(f1, f2))))
+	return 1
+}
+
+func doDmul(fr *frames.Frame, _ int64) int {
+	d2 := pop(fr).(float64)
+	d1 := pop(fr).(float64)
+	pushWide(fr, multiply(d1, d2))
+	return 1
+}
+
+// 0x6C - 0x73: IDIV, LDIV, FDIV, DDIV, and the corresponding remainder bytecodes. Integer
+// division by zero throws an ArithmeticException; floating-point division by zero does not.
+func doIdiv(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	if val2 == 0 {
+		return divideByZero(fr, "IDIV", val1)
+	}
+	push(fr, int64(int32(val1/val2))) // MinInt32 / -1 overflows back to MinInt32
+	return 1
+}
+
+func doLdiv(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	if val2 == 0 {
+		return divideByZero(fr, "LDIV", val1)
+	}
+	pushWide(fr, val1/val2)
+	return 1
+}
+
+func doFdiv(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(float64)
+	val1 := pop(fr).(float64)
+	push(fr, float64(float32(val1/val2)))
+	return 1
+}
+
+func doDdiv(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(float64)
+	val1 := pop(fr).(float64)
+	pushWide(fr, val1/val2)
+	return 1
+}
+
+func doIrem(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	if val2 == 0 {
+		return divideByZero(fr, "IREM", val1)
+	}
+	push(fr, int64(int32(val1%val2)))
+	return 1
+}
+
+func doLrem(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	if val2 == 0 {
+		return divideByZero(fr, "LREM", val1)
+	}
+	pushWide(fr, val1%val2)
+	return 1
+}
+
+func doFrem(fr *frames.Frame, _ int64) int { // Java's % truncates, as math.Mod() does
+	val2 := pop(fr).(float64)
+	val1 := pop(fr).(float64)
+	push(fr, float64(float32(math.Mod(val1, val2))))
+	return 1
+}
+
+func doDrem(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(float64)
+	val1 := pop(fr).(float64)
+	pushWide(fr, math.Mod(val1, val2))
+	return 1
+}
+
+// 0x74 - 0x77: INEG, LNEG, FNEG, DNEG
+func doIneg(fr *frames.Frame, _ int64) int {
+	val := pop(fr).(int64)
+	push(fr, int64(-int32(val)))
+	return 1
+}
+
+func doLneg(fr *frames.Frame, _ int64) int {
+	val := pop(fr).(int64)
+	pushWide(fr, -val)
+	return 1
+}
+
+func doFneg(fr *frames.Frame, _ int64) int {
+	val := pop(fr).(float64)
+	push(fr, -val)
+	return 1
+}
+
+func doDneg(fr *frames.Frame, _ int64) int {
+	val := pop(fr).(float64)
+	pushWide(fr, -val)
+	return 1
+}
+
+// 0x78 - 0x7D: shifts. Per the JVM spec, only the low 5 bits (for ints) or 6 bits (for longs)
+// of the shift distance are used.
+func doIshl(fr *frames.Frame, _ int64) int {
+	shiftBy := pop(fr).(int64) & 0x1F
+	val := int32(pop(fr).(int64))
+	push(fr, int64(val<<shiftBy))
+	return 1
+}
+
+func doLshl(fr *frames.Frame, _ int64) int {
+	shiftBy := pop(fr).(int64) & 0x3F
+	val := pop(fr).(int64)
+	pushWide(fr, val<<shiftBy)
+	return 1
+}
+
+func doIshr(fr *frames.Frame, _ int64) int {
+	shiftBy := pop(fr).(int64) & 0x1F
+	val := int32(pop(fr).(int64))
+	push(fr, int64(val>>shiftBy))
+	return 1
+}
+
+func doLshr(fr *frames.Frame, _ int64) int {
+	shiftBy := pop(fr).(int64) & 0x3F
+	val := pop(fr).(int64)
+	pushWide(fr, val>>shiftBy)
+	return 1
+}
+
+func doIushr(fr *frames.Frame, _ int64) int {
+	shiftBy := pop(fr).(int64) & 0x1F
+	val := uint32(pop(fr).(int64))
+	push(fr, int64(int32(val>>shiftBy)))
+	return 1
+}
+
+func doLushr(fr *frames.Frame, _ int64) int {
+	shiftBy := pop(fr).(int64) & 0x3F
+	val := uint64(pop(fr).(int64))
+	pushWide(fr, int64(val>>shiftBy))
+	return 1
+}
+
+// 0x7E - 0x83: IAND, LAND, IOR, LOR, IXOR, LXOR
+func doIand(fr *frames.Frame, _ int64) int {
+	val2 := convertInterfaceToInt64(pop(fr)) // booleans use these bytecodes too
+	val1 := convertInterfaceToInt64(pop(fr))
+	push(fr, val1&val2)
+	return 1
+}
+
+func doLand(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	pushWide(fr, val1&val2)
+	return 1
+}
+
+func doIor(fr *frames.Frame, _ int64) int {
+	val2 := convertInterfaceToInt64(pop(fr))
+	val1 := convertInterfaceToInt64(pop(fr))
+	push(fr, val1|val2)
+	return 1
+}
+
+func doLor(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	pushWide(fr, val1|val2)
+	return 1
+}
+
+func doIxor(fr *frames.Frame, _ int64) int {
+	val2 := convertInterfaceToInt64(pop(fr))
+	val1 := convertInterfaceToInt64(pop(fr))
+	push(fr, val1^val2)
+	return 1
+}
+
+func doLxor(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	pushWide(fr, val1^val2)
+	return 1
+}
+
+func doIinc(fr *frames.Frame, _ int64) int { // 0x84 IINC increment int variable
+	var index int
+	var increment int64
+	var PCtoSkip int
+	if fr.WideInEffect { // if wide is in effect, index  and increment are two bytes wide, otherwise one byte each
+		index = (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
+		increment = int64((int16(fr.Meth[fr.PC+3]) * 256) + int16(fr.Meth[fr.PC+4]))
+		PCtoSkip = 4
+		fr.WideInEffect = false
+	} else {
+		index = int(fr.Meth[fr.PC+1])
+		increment = byteToInt64(fr.Meth[fr.PC+2])
+		PCtoSkip = 2
+	}
+	orig := convertInterfaceToInt64(fr.Locals[index])
+	fr.Locals[index] = int64(int32(orig + increment))
+	return PCtoSkip + 1
+}
+
+// 0x85 - 0x93: conversions between primitive types
+func doI2l(fr *frames.Frame, _ int64) int {
+	pushWide(fr, convertInterfaceToInt64(pop(fr)))
+	return 1
+}
+
+func doI2f(fr *frames.Frame, _ int64) int {
+	push(fr, float64(float32(convertInterfaceToInt64(pop(fr)))))
+	return 1
+}
+
+func doI2d(fr *frames.Frame, _ int64) int {
+	pushWide(fr, float64(convertInterfaceToInt64(pop(fr))))
+	return 1
+}
+
+func doL2i(fr *frames.Frame, _ int64) int {
+	push(fr, int64(int32(pop(fr).(int64))))
+	return 1
+}
+
+func doL2f(fr *frames.Frame, _ int64) int {
+	push(fr, float64(float32(pop(fr).(int64))))
+	return 1
+}
+
+func doL2d(fr *frames.Frame, _ int64) int {
+	pushWide(fr, float64(pop(fr).(int64)))
+	return 1
+}
+
+func doF2i(fr *frames.Frame, _ int64) int {
+	push(fr, floatToInt64(pop(fr).(float64), math.MinInt32, math.MaxInt32))
+	return 1
+}
+
+func doF2l(fr *frames.Frame, _ int64) int {
+	pushWide(fr, floatToInt64(pop(fr).(float64), math.MinInt64, math.MaxInt64))
+	return 1
+}
+
+func doF2d(fr *frames.Frame, _ int64) int {
+	pushWide(fr, pop(fr).(float64))
+	return 1
+}
+
+func doD2i(fr *frames.Frame, _ int64) int {
+	push(fr, floatToInt64(pop(fr).(float64), math.MinInt32, math.MaxInt32))
+	return 1
+}
+
+func doD2l(fr *frames.Frame, _ int64) int {
+	pushWide(fr, floatToInt64(pop(fr).(float64), math.MinInt64, math.MaxInt64))
+	return 1
+}
+
+func doD2f(fr *frames.Frame, _ int64) int {
+	push(fr, float64(float32(pop(fr).(float64))))
+	return 1
+}
+
+func doI2b(fr *frames.Frame, _ int64) int {
+	push(fr, int64(int8(convertInterfaceToInt64(pop(fr)))))
+	return 1
+}
+
+func doI2c(fr *frames.Frame, _ int64) int {
+	push(fr, int64(uint16(convertInterfaceToInt64(pop(fr)))))
+	return 1
+}
+
+func doI2s(fr *frames.Frame, _ int64) int {
+	push(fr, int64(int16(convertInterfaceToInt64(pop(fr)))))
+	return 1
+}
+
+func doLcmp(fr *frames.Frame, _ int64) int { // 0x94 LCMP compare two longs, push int -1, 0, or 1
+	val2 := pop(fr).(int64)
+	val1 := pop(fr).(int64)
+	switch {
+	case val1 > val2:
+		push(fr, int64(1))
+	case val1 < val2:
+		push(fr, int64(-1))
+	default:
+		push(fr, int64(0))
+	}
+	return 1
+}
+
+// 0x95 - 0x98: FCMPL, FCMPG, DCMPL, DCMPG. These differ only in how NaN is treated.
+func doFcmpl(fr *frames.Frame, _ int64) int { return floatCompare(fr, int64(-1)) }
+func doFcmpg(fr *frames.Frame, _ int64) int { return floatCompare(fr, int64(1)) }
+
+// 0x99 - 0x9E: IFEQ, IFNE, IFLT, IFGE, IFGT, IFLE compare int to 0 and jump if true
+func doIfeq(fr *frames.Frame, _ int64) int { return branch(fr, convertInterfaceToInt64(pop(fr)) == 0) }
+func doIfne(fr *frames.Frame, _ int64) int { return branch(fr, convertInterfaceToInt64(pop(fr)) != 0) }
+func doIflt(fr *frames.Frame, _ int64) int { return branch(fr, convertInterfaceToInt64(pop(fr)) < 0) }
+func doIfge(fr *frames.Frame, _ int64) int { return branch(fr, convertInterfaceToInt64(pop(fr)) >= 0) }
+func doIfgt(fr *frames.Frame, _ int64) int { return branch(fr, convertInterfaceToInt64(pop(fr)) > 0) }
+func doIfle(fr *frames.Frame, _ int64) int { return branch(fr, convertInterfaceToInt64(pop(fr)) <= 0) }
+
+// 0x9F - 0xA4: IF_ICMPEQ thru IF_ICMPLE compare two ints and jump if true
+func doIficmpeq(fr *frames.Frame, _ int64) int {
+	val2, val1 := popIntPair(fr)
+	return branch(fr, val1 == val2)
+}
+
+func doIficmpne(fr *frames.Frame, _ int64) int {
+	val2, val1 := popIntPair(fr)
+	return branch(fr, val1 != val2)
+}
+
+func doIficmplt(fr *frames.Frame, _ int64) int {
+	val2, val1 := popIntPair(fr)
+	return branch(fr, val1 < val2)
+}
+
+func doIficmpge(fr *frames.Frame, _ int64) int {
+	val2, val1 := popIntPair(fr)
+	return branch(fr, val1 >= val2)
+}
+
+func doIficmpgt(fr *frames.Frame, _ int64) int {
+	val2, val1 := popIntPair(fr)
+	return branch(fr, val1 > val2)
+}
+
+func doIficmple(fr *frames.Frame, _ int64) int {
+	val2, val1 := popIntPair(fr)
+	return branch(fr, val1 <= val2)
+}
+
+// 0xA5, 0xA6: IF_ACMPEQ, IF_ACMPNE compare two references and jump if true
+func doIfacmpeq(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr)
+	val1 := pop(fr)
+	return branch(fr, sameReference(val1, val2))
+}
+
+func doIfacmpne(fr *frames.Frame, _ int64) int {
+	val2 := pop(fr)
+	val1 := pop(fr)
+	return branch(fr, !sameReference(val1, val2))
+}
+
+func doGoto(fr *frames.Frame, _ int64) int { // 0xA7 GOTO unconditional jump within method
+	jumpTo := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
+	return int(jumpTo) // note the value can be negative to jump to earlier bytecode
+}
+
+func doJsr(fr *frames.Frame, _ int64) int { // 0xA8 JSR push the return address and jump
+	jumpTo := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
+	push(fr, int64(fr.PC+3)) // the return address is the bytecode after this one
+	return int(jumpTo)
+}
+
+func doRet(fr *frames.Frame, _ int64) int { // 0xA9 RET jump to the return address in a local
+	index, _ := localIndex(fr)
+	returnAddress := int(fr.Locals[index].(int64))
+	return returnAddress - fr.PC
+}
+
+func doTableswitch(fr *frames.Frame, _ int64) int { // 0xAA TABLESWITCH switch based on table of offsets
+	// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-6.html#jvms-6.5.tableswitch
+	// the operands begin at the first four-byte boundary after the bytecode
+	operands := fr.PC + 1 + switchPadding(fr.PC)
+	defaultJump := fourByteOperand(fr, operands)
+	lowValue := fourByteOperand(fr, operands+4)
+	highValue := fourByteOperand(fr, operands+8)
+
+	index := convertInterfaceToInt64(pop(fr)) // the value we're looking to match
+	if index < lowValue || index > highValue {
+		return int(defaultJump)
+	}
+	return int(fourByteOperand(fr, operands+12+int(index-lowValue)*4))
+}
+
+func doLookupswitch(fr *frames.Frame, _ int64) int { // 0xAB LOOKUPSWITCH switch using lookup table
+	// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-6.html#jvms-6.5.lookupswitch
+	operands := fr.PC + 1 + switchPadding(fr.PC)
+	defaultJump := fourByteOperand(fr, operands)
+	npairs := int(fourByteOperand(fr, operands+4))
+
+	key := convertInterfaceToInt64(pop(fr)) // the value we're switching on
+	for i := 0; i < npairs; i++ {
+		pair := operands + 8 + i*8
+		if fourByteOperand(fr, pair) == key {
+			return int(fourByteOperand(fr, pair+4))
+		}
+	}
+	return int(defaultJump)
+}
+
+// 0xAC - 0xB1: IRETURN, LRETURN, FRETURN, DRETURN, ARETURN, RETURN. The synchronized
+// method monitor, if any, has already been released in interpret().
+func doIreturn(fr *frames.Frame, _ int64) int { return returnFromMethod(fr, true, false) } // also F- and ARETURN
+func doLreturn(fr *frames.Frame, _ int64) int { return returnFromMethod(fr, true, true) }  // also DRETURN
+func doReturn(fr *frames.Frame, _ int64) int  { return returnFromMethod(fr, false, false) }
+
+func doGetStatic(fr *frames.Frame, _ int64) int { // 0xB2 GETSTATIC
+	className, fieldName, status, ok := staticFieldName(fr, "GETSTATIC")
+	if !ok {
+		return status
+	}
+
+	// was this static field previously loaded? Is so, get its location and move on.
+	prevLoaded, ok := statics.QueryStatic(fieldName)
+	if !ok { // if field is not already loaded, then
+		// the class has not been instantiated, so
+		// instantiate the class
+		_, err := InstantiateClass(className, fr.FrameStack)
+		if err != nil {
+			errMsg := fmt.Sprintf("GETSTATIC: could not load class %s", className)
+			return throwEx(fr, excNames.ClassNotFoundException, errMsg)
+		}
+		prevLoaded, ok = statics.QueryStatic(fieldName)
+	}
+
+	// if the field can't be found even after instantiating the
+	// containing class, something is wrong so get out of here.
+	if !ok {
+		errMsg := fmt.Sprintf("GETSTATIC: could not find static field %s in class %s", fieldName, className)
+		return throwEx(fr, excNames.NoSuchFieldException, errMsg)
+	}
+
+	// a boolean might be stored as a boolean, a byte, or int64.
+	// We want all forms normalized to int64
+	var value any
+	switch v := prevLoaded.Value.(type) {
+	case bool:
+		value = types.ConvertGoBoolToJavaBool(v)
+	case byte:
+		value = int64(types.JavaByte(v))
+	case int:
+		value = int64(v)
+	default:
+		value = v
+	}
+
+	if types.UsesTwoSlots(prevLoaded.Type) {
+		pushWide(fr, value)
+	} else {
+		push(fr, value)
+	}
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doPutStatic(fr *frames.Frame, _ int64) int { // 0xB3 PUTSTATIC
+	className, fieldName, status, ok := staticFieldName(fr, "PUTSTATIC")
+	if !ok {
+		return status
+	}
+
+	// was this static field previously loaded? Is so, get its location and move on.
+	prevLoaded, ok := statics.QueryStatic(fieldName)
+	if !ok {
+		_, err := InstantiateClass(className, fr.FrameStack)
+		if err != nil {
+			errMsg := fmt.Sprintf("PUTSTATIC: could not load class %s", className)
+			return throwEx(fr, excNames.ClassNotFoundException, errMsg)
+		}
+		prevLoaded, ok = statics.QueryStatic(fieldName)
+	}
+
+	if !ok {
+		errMsg := fmt.Sprintf("PUTSTATIC: could not find static field %s", fieldName)
+		return throwEx(fr, excNames.NoSuchFieldException, errMsg)
+	}
+
+	var value any
+	switch prevLoaded.Type {
+	case types.Bool:
+		value = convertInterfaceToInt64(pop(fr)) & 0x01
+	case types.Char, types.Short, types.Int, types.Long:
+		value = convertInterfaceToInt64(pop(fr))
+	case types.Byte:
+		value = convertInterfaceToByte(pop(fr))
+	case types.Float, types.Double:
+		value = pop(fr).(float64)
+	default:
+		// if it's not a primitive, then it should be a pointer
+		// to an object or to a loaded class
+		value = pop(fr)
+		if value == nil {
+			value = object.Null
+		}
+		switch value.(type) {
+		case *object.Object, *classloader.Klass:
+		default:
+			errMsg := fmt.Sprintf("PUTSTATIC: field %s, type unrecognized: %v", fieldName, value)
+			return throwEx(fr, excNames.InvalidTypeException, errMsg)
+		}
+	}
+
+	_ = statics.AddStatic(fieldName, statics.Static{
+		Type:  prevLoaded.Type,
+		Value: value,
+	})
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doGetField(fr *frames.Frame, _ int64) int { // 0xB4 GETFIELD get field in pointed-to-object
+	fieldName, status, ok := instanceFieldName(fr, "GETFIELD")
+	if !ok {
+		return status
+	}
+
+	ref := pop(fr)
+	if object.IsNull(ref) {
+		errMsg := fmt.Sprintf("GETFIELD: Invalid (null) object reference, fieldName: %s", fieldName)
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+	obj, ok := ref.(*object.Object)
+	if !ok {
+		errMsg := fmt.Sprintf("GETFIELD: Invalid type of object ref: %T, fieldName: %s", ref, fieldName)
+		return throwEx(fr, excNames.InvalidTypeException, errMsg)
+	}
+
+	objField, ok := obj.FieldTable[fieldName]
+	if !ok {
+		errMsg := fmt.Sprintf("GETFIELD PC=%d: Missing field (%s) in FieldTable for %s.%s%s",
+			fr.PC, fieldName, fr.ClName, fr.MethName, fr.MethType)
+		return throwEx(fr, excNames.IllegalArgumentException, errMsg)
+	}
+
+	fieldValue := objField.Fvalue
+	if objField.Ftype == types.StringIndex {
+		fieldValue = stringPool.GetStringPointer(objField.Fvalue.(uint32))
+	} else if objField.Ftype == types.StringClassRef {
+		// if the field type is String pointer and value is a byte array, convert it to a string
+		if bytes, ok := objField.Fvalue.([]byte); ok {
+			fieldValue = object.StringObjectFromByteArray(bytes)
+		}
+	}
+
+	if types.UsesTwoSlots(objField.Ftype) {
+		pushWide(fr, fieldValue)
+	} else {
+		push(fr, fieldValue)
+	}
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doPutField(fr *frames.Frame, _ int64) int { // 0xB5 PUTFIELD place value into an object's field
+	fieldName, status, ok := instanceFieldName(fr, "PUTFIELD")
+	if !ok {
+		return status
+	}
+
+	value := pop(fr) // the value we're placing in the field
+	ref := pop(fr)   // the object we're updating
+	if object.IsNull(ref) {
+		errMsg := fmt.Sprintf("PUTFIELD: Invalid (null) object reference, fieldName: %s", fieldName)
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+	obj, ok := ref.(*object.Object)
+	if !ok {
+		errMsg := fmt.Sprintf("PUTFIELD: Expected an object ref, but observed type %T in %s",
+			ref, inMethod(fr))
+		return throwEx(fr, excNames.InvalidTypeException, errMsg)
+	}
+
+	// if the value we're inserting is a reference to an
+	// array object, we have to modify it to point directly
+	// to the array of primitives, rather than to the array
+	// object
+	if v, ok := value.(*object.Object); ok && !object.IsNull(v) {
+		o, ok := v.FieldTable["value"]
+		if ok && strings.HasPrefix(o.Ftype, types.Array) {
+			value = o.Fvalue
+		}
+	}
+
+	if len(obj.FieldTable) == 0 {
+		return 3
+	}
+
+	objField, ok := obj.FieldTable[fieldName]
+	if !ok {
+		errMsg := fmt.Sprintf("PUTFIELD: In trying for a superclass field, %s referenced by %s.%s is not present",
+			fieldName, fr.ClName, fr.MethName)
+		return throwEx(fr, excNames.NoSuchFieldException, errMsg)
+	}
+
+	// PUTFIELD is not used to update statics. That's for PUTSTATIC to do.
+	if strings.HasPrefix(objField.Ftype, types.Static) {
+		errMsg := fmt.Sprintf("PUTFIELD: invalid attempt to update a static variable in %s.%s",
+			fr.ClName, fr.MethName)
+		return throwEx(fr, excNames.IncompatibleClassChangeError, errMsg)
+	}
+
+	objField.Fvalue = value
+	obj.FieldTable[fieldName] = objField
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doInvokeVirtual(fr *frames.Frame, _ int64) int { // 0xB6 INVOKEVIRTUAL
+	var err error
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	CPentry := CP.CpIndex[CPslot]
+	if CPentry.Type != classloader.MethodRef { // the pointed-to CP entry must be a method reference
+		errMsg := fmt.Sprintf("INVOKEVIRTUAL: Expected a method ref, but got %d in"+
+			"location %d in method %s of class %s\n",
+			CPentry.Type, fr.PC, fr.MethName, fr.ClName)
+		return throwEx(fr, excNames.WrongMethodTypeException, errMsg)
+	}
+
+	className, methodName, methodType :=
+		classloader.GetMethInfoFromCPmethref(CP, CPslot)
+
+	mtEntry, _ := classloader.MTableFetch(className + "." + methodName + methodType)
+	if mtEntry.Meth == nil { // if the method is not in the method table, find it
+		mtEntry, err = classloader.FetchMethodAndCP(className, methodName, methodType)
+		if err != nil || mtEntry.Meth == nil {
+			// TODO: search the superclasses, then the classpath and retry
+			errMsg := "INVOKEVIRTUAL: Class method not found: " + className + "." + methodName + methodType
+			return throwEx(fr, excNames.UnsupportedOperationException, errMsg)
+		}
+	}
+
+	// if we have a native function (here, one implemented in golang, rather than Java),
+	// then follow the JVM spec and push the objectRef and the parameters to the function
+	// as parameters. Consult:
+	// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-6.html#jvms-6.5.invokevirtual
+	switch mtEntry.MType {
+	case 'G': // so we have a native golang function
+		return invokeGfunction(fr, mtEntry, className, methodName, methodType, true, 3)
+	case 'J': // it's a Java or Native function
+		return invokeJavaMethod(fr, mtEntry, className, methodName, methodType, true, 3, "INVOKEVIRTUAL")
+	}
+	return invalidMethodType(fr, "INVOKEVIRTUAL", mtEntry)
+}
+
+func doInvokeSpecial(fr *frames.Frame, _ int64) int { // OxB7 INVOKESPECIAL
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	className, methodName, methodType := classloader.GetMethInfoFromCPmethref(CP, CPslot)
+
+	// if it's a call to java/lang/Object."<init>"()V, which happens frequently,
+	// that function simply returns. So test for it here and if it is, skip the rest
+	fullConstructorName := className + "." + methodName + methodType
+	if fullConstructorName == "java/lang/Object.<init>()V" {
+		pop(fr)  // the object being initialized
+		return 3 // 2 for the CPslot + 1 for next bytecode
+	}
+
+	mtEntry, err := classloader.FetchMethodAndCP(className, methodName, methodType)
+	if err != nil || mtEntry.Meth == nil {
+		// TODO: search the classpath and retry
+		errMsg := "INVOKESPECIAL: Class method not found: " + className + "." + methodName + methodType
+		return throwEx(fr, excNames.UnsupportedOperationException, errMsg)
+	}
+
+	switch mtEntry.MType {
+	case 'G': // it's a golang method
+		return invokeGfunction(fr, mtEntry, className, methodName, methodType, true, 3)
+	case 'J': // The arguments are correctly handled in createAndInitNewFrame()
+		return invokeJavaMethod(fr, mtEntry, className, methodName, methodType, true, 3, "INVOKESPECIAL")
+	}
+	return invalidMethodType(fr, "INVOKESPECIAL", mtEntry)
+}
+
+func doInvokestatic(fr *frames.Frame, _ int64) int { // 0xB8 INVOKESTATIC
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+
+	className, methodName, methodType :=
+		classloader.GetMethInfoFromCPmethref(CP, CPslot)
+
+	mtEntry, err := classloader.FetchMethodAndCP(className, methodName, methodType)
+	if err != nil || mtEntry.Meth == nil {
+		// TODO: search the classpath and retry
+		errMsg := "INVOKESTATIC: Class method not found: " + className + "." + methodName + methodType
+		return throwEx(fr, excNames.UnsupportedOperationException, errMsg)
+	}
+
+	// before we can run the method, we need to either instantiate the class and/or
+	// make sure that its static intializer block (if any) has been run. At this point,
+	// all we know is that the class exists and has been loaded.
+	k := classloader.MethAreaFetch(className)
+	if k != nil && k.Data != nil && k.Data.ClInit == types.ClInitNotRun {
+		err = runInitializationBlock(k, nil, fr.FrameStack)
+		if err != nil {
+			errMsg := fmt.Sprintf("INVOKESTATIC: error running initializer block in %s",
+				className+"."+methodName+methodType)
+			return throwEx(fr, excNames.ClassNotLoadedException, errMsg)
+		}
+	}
+
+	switch mtEntry.MType {
+	case 'G':
+		return invokeGfunction(fr, mtEntry, className, methodName, methodType, false, 3)
+	case 'J':
+		return invokeJavaMethod(fr, mtEntry, className, methodName, methodType, false, 3, "INVOKESTATIC")
+	}
+	return invalidMethodType(fr, "INVOKESTATIC", mtEntry)
+}
+
+func doInvokeinterface(fr *frames.Frame, _ int64) int { // 0xB9 INVOKEINTERFACE
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	count := fr.Meth[fr.PC+3]
+	zeroByte := fr.Meth[fr.PC+4]
+
+	CP := fr.CP.(*classloader.CPool)
+	if count < 1 || CPslot >= len(CP.CpIndex) || zeroByte != 0x00 {
+		errMsg := "Invalid values for INVOKEINTERFACE bytecode"
+		return throwEx(fr, excNames.IllegalClassFormatException, errMsg)
+	}
+
+	CPentry := CP.CpIndex[CPslot]
+	if CPentry.Type != classloader.Interface {
+		errMsg := fmt.Sprintf("INVOKEINTERFACE: CP entry type (%d) did not point to an interface method type (%d)",
+			CPentry.Type, classloader.Interface)
+		return throwEx(fr, excNames.IncompatibleClassChangeError, errMsg) // this is the error thrown by JDK
+	}
+
+	interfaceName, interfaceMethodName, interfaceMethodType := classloader.GetMemberInfoFromCPref(CP, CPslot)
+
+	// now get the objRef pointing to the class containing the call to the method
+	// described just previously. It is located on the op stack below the args to
+	// be passed to the method. (The count operand gives the size of these in words,
+	// which would count longs and doubles twice, so it's not used here.)
+	argCount := len(util.ParseIncomingParamsFromMethTypeString(interfaceMethodType))
+	if fr.TOS-argCount < 0 {
+		errMsg := fmt.Sprintf("stack underflow in INVOKEINTERFACE %s", inMethod(fr))
+		return throwEx(fr, excNames.InternalException, errMsg)
+	}
+	objRef := fr.OpStack[fr.TOS-argCount]
+	if object.IsNull(objRef) {
+		errMsg := fmt.Sprintf("INVOKEINTERFACE: object whose method, %s, is invoked is null",
+			interfaceName+interfaceMethodName+interfaceMethodType)
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+
+	// get the name of the objectRef's class, and make sure it's loaded. (The class
+	// might not have a class file--e.g., if it's a lambda class--so don't reload it.)
+	objRefClassName := *(stringPool.GetStringPointer(objRef.(*object.Object).KlassName))
+	if classloader.MethAreaFetch(objRefClassName) == nil {
+		_ = classloader.LoadClassFromNameOnly(objRefClassName)
+	}
+
+	class := classloader.MethAreaFetch(objRefClassName)
+	if class == nil || class.Data == nil {
+		errMsg := fmt.Sprintf("INVOKEINTERFACE: class %s not found", objRefClassName)
+		return throwEx(fr, excNames.ClassNotLoadedException, errMsg)
+	}
+
+	mtEntry, err := locateInterfaceMeth(class, fr, objRefClassName, interfaceName,
+		interfaceMethodName, interfaceMethodType)
+	if err != nil { // the exception has already been thrown
+		return errorOccurred(fr, err.Error()) // applies only if in test
+	}
+	if mtEntry.Meth == nil { // an exception was thrown and caught
+		return 0
+	}
+
+	switch mtEntry.MType {
+	case 'J':
+		return invokeJavaMethod(fr, mtEntry, class.Data.Name, interfaceMethodName, interfaceMethodType,
+			true, 5, "INVOKEINTERFACE")
+	case 'G': // it's a gfunction (i.e., a native function implemented in golang)
+		return invokeGfunction(fr, mtEntry, interfaceName, interfaceMethodName, interfaceMethodType, true, 5)
+	}
+	return invalidMethodType(fr, "INVOKEINTERFACE", mtEntry)
+}
+
+func doInvokedynamic(fr *frames.Frame, _ int64) int { // 0xBA INVOKEDYNAMIC
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	// the two bytes after that must be zero
+
+	// the call site is linked the first time the instruction executes; see invokedynamic.go
+	site, err := getCallSite(fr, CPslot)
+	if err != nil {
+		errMsg := fmt.Sprintf("INVOKEDYNAMIC: call site in %s.%s%s could not be linked: %s",
+			fr.ClName, fr.MethName, fr.MethType, err.Error())
+		return throwEx(fr, excNames.BootstrapMethodError, errMsg)
+	}
+
+	args := popCallSiteArgs(fr, site.desc, false)
+	ret, err := site.target(fr.FrameStack, args)
+	var javaEx *exceptions.JavaException
+	if errors.As(err, &javaEx) { // Java code called by the target threw an exception
+		if exceptions.Throw(javaEx.Throwable, fr) == exceptions.Caught {
+			return 0
+		}
+		return errorOccurred(fr, err.Error()) // applies only if in test
+	}
+	if err != nil {
+		errMsg := fmt.Sprintf("INVOKEDYNAMIC: error in call site in %s.%s%s: %s",
+			fr.ClName, fr.MethName, fr.MethType, err.Error())
+		return throwEx(fr, excNames.BootstrapMethodError, errMsg)
+	}
+
+	if !strings.HasSuffix(site.desc, ")V") {
+		pushReturnValue(fr, ret, site.desc)
+	}
+	return 5 // 4 for the operands + 1 for the next bytecode
+}
+
+func doNew(fr *frames.Frame, _ int64) int { // 0xBB NEW create and instantiate a new object
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	CPentry := CP.CpIndex[CPslot]
+	if CPentry.Type != classloader.ClassRef {
+		errMsg := "NEW: Invalid type for new object"
+		return throwEx(fr, excNames.ClassFormatError, errMsg)
+	}
+
+	// the classref points to a UTF8 record with the name of the class to instantiate
+	className := *stringPool.GetStringPointer(CP.ClassRefs[CPentry.Slot])
+	if !object.HeapHasRoom(instanceSize(className)) {
+		return throwOutOfMemoryError(fr)
+	}
+	ref, err := InstantiateClass(className, fr.FrameStack)
+	if err != nil {
+		errMsg := fmt.Sprintf("NEW: could not load class %s", className)
+		return throwEx(fr, excNames.ClassNotLoadedException, errMsg)
+	}
+	push(fr, ref.(*object.Object))
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doNewarray(fr *frames.Frame, _ int64) int { // 0xBC NEWARRAY create a new array of primitives
+	size := convertInterfaceToInt64(pop(fr))
+	if size < 0 {
+		errMsg := "NEWARRAY: Invalid size for array"
+		return throwEx(fr, excNames.NegativeArraySizeException, errMsg)
+	}
+
+	actualType := object.JdkArrayTypeToJacobinType(int(fr.Meth[fr.PC+1]))
+	if actualType == object.ERROR || actualType == object.REF {
+		errMsg := "NEWARRAY: Invalid array type specified"
+		return throwEx(fr, excNames.InvalidTypeException, errMsg)
+	}
+
+	if !object.HeapHasRoom(object.ArraySize(uint8(actualType), size)) {
+		return throwOutOfMemoryError(fr)
+	}
+	arrayPtr := object.Make1DimArray(uint8(actualType), size)
+	g := globals.GetGlobalRef()
+	g.ArrayAddressList.PushFront(arrayPtr)
+	push(fr, arrayPtr)
+	return 2 // 1 for the array type + 1 for the next bytecode
+}
+
+func doAnewarray(fr *frames.Frame, _ int64) int { // 0xBD ANEWARRAY create array of references
+	size := convertInterfaceToInt64(pop(fr))
+	if size < 0 {
+		errMsg := "ANEWARRAY: Invalid size for array"
+		return throwEx(fr, excNames.NegativeArraySizeException, errMsg)
+	}
+
+	refTypeSlot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	refType := CP.CpIndex[refTypeSlot]
+	if refType.Type != classloader.ClassRef && refType.Type != classloader.Interface {
+		// TODO: it could also point to an array, per the JVM spec
+		errMsg := "ANEWARRAY: Presently works only with classes and interfaces"
+		return throwEx(fr, excNames.UnsupportedOperationException, errMsg)
+	}
+
+	var refTypeName = ""
+	if refType.Type == classloader.ClassRef {
+		refTypeName = *stringPool.GetStringPointer(CP.ClassRefs[refType.Slot])
+	}
+
+	if !object.HeapHasRoom(object.ArraySize(object.REF, size)) {
+		return throwOutOfMemoryError(fr)
+	}
+	arrayPtr := object.Make1DimRefArray(&refTypeName, size)
+	g := globals.GetGlobalRef()
+	g.ArrayAddressList.PushFront(arrayPtr)
+	push(fr, arrayPtr)
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doArraylength(fr *frames.Frame, _ int64) int { // 0xBE ARRAYLENGTH get size of array
+	// expects a pointer to an array
+	ref := pop(fr)
+	if object.IsNull(ref) {
+		errMsg := "ARRAYLENGTH: Invalid (null) reference to an array"
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+
+	// the type of array reference can vary. For many instances,
+	// it will be a pointer to an array object. In other cases,
+	// such as inside Java String class, the actual primitive
+	// array of bytes will be extracted as a field and passed
+	// to this function, so we need to accommodate all types.
+	var size int64
+	switch r := ref.(type) {
+	case []uint8:
+		size = int64(len(r))
+	case *[]uint8:
+		size = int64(len(*r))
+	case []float64:
+		size = int64(len(r))
+	case []int64:
+		size = int64(len(r))
+	case []*object.Object:
+		size = int64(len(r))
+	case *object.Object:
+		size = object.ArrayLength(r)
+	default:
+		errMsg := fmt.Sprintf("ARRAYLENGTH: Invalid ref.(type): %T", ref)
+		return throwEx(fr, excNames.IllegalArgumentException, errMsg)
+	}
+	push(fr, size)
+	return 1
+}
+
+func doAthrow(fr *frames.Frame, _ int64) int { // 0xBF ATHROW throw an exception
+	// objRef points to an instance of the error/exception class that's being thrown
+	ref := pop(fr)
+	objectRef, ok := ref.(*object.Object)
+	if !ok || object.IsNull(objectRef) {
+		errMsg := "ATHROW: Invalid (null) reference to an exception/error class to throw"
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+
+	if throwException(fr, fr.FrameStack, objectRef) {
+		return 0 // resume execution in the exception handler
+	}
+	errMsg := "ATHROW: uncaught exception: " + *(stringPool.GetStringPointer(objectRef.KlassName))
+	return errorOccurred(fr, errMsg) // applies only if in test
+}
+
+func doCheckcast(fr *frames.Frame, _ int64) int { // 0xC0 CHECKCAST
+	// same as INSTANCEOF but does nothing on null, and doesn't change the stack if the
+	// cast is legal.
+	ref := peek(fr) // peek b/c the objectRef is *not* removed from the op stack
+	if object.IsNull(ref) {
+		return 3 // move past two bytes pointing to comp object
+	}
+
+	obj, ok := ref.(*object.Object)
+	if !ok { // objectRef must be a reference to an object
+		errMsg := fmt.Sprintf("CHECKCAST: Invalid class reference, type=%T", ref)
+		return throwEx(fr, excNames.ClassCastException, errMsg)
+	}
+	objName := *(stringPool.GetStringPointer(obj.KlassName))
+
+	// now, get the class we're casting the object to.
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
+	CP := fr.CP.(*classloader.CPool)
+	className := *(classloader.FetchCPentry(CP, CPslot).StringVal)
+
+	var checkcastStatus bool
+	if strings.HasPrefix(objName, "[") {
+		checkcastStatus = checkcastArray(obj, className)
+	} else {
+		objData := classloader.MethAreaFetch(objName)
+		if objData == nil || objData.Data == nil {
+			_ = classloader.LoadClassFromNameOnly(objName)
+			objData = classloader.MethAreaFetch(objName)
+		}
+		if objData != nil && objData.Data != nil && objData.Data.Access.ClassIsInterface {
+			checkcastStatus = checkcastInterface(obj, className)
+		} else {
+			checkcastStatus = checkcastNonArrayObject(obj, className)
+		}
+	}
+
+	if !checkcastStatus {
+		errMsg := fmt.Sprintf("CHECKCAST: %s is not castable with respect to %s", objName, className)
+		return throwEx(fr, excNames.ClassCastException, errMsg)
+	}
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doInstanceof(fr *frames.Frame, _ int64) int { // 0xC1 INSTANCEOF validate the type of object
+	ref := pop(fr)
+	obj, ok := ref.(*object.Object)
+	if !ok || object.IsNull(obj) {
+		push(fr, int64(0))
+		return 3 // move past index bytes to comp object
+	}
+
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
+	CP := fr.CP.(*classloader.CPool)
+	if CP.CpIndex[CPslot].Type != classloader.ClassRef {
+		push(fr, int64(0))
+		return 3
+	}
+
+	// slot of ClassRef points to a CP entry for a stringPool entry for name of class
+	classNamePtr := classloader.FetchCPentry(CP, CPslot)
+	if classNamePtr.RetType != classloader.IS_STRING_ADDR {
+		errMsg := "INSTANCEOF: Invalid classRef found"
+		return throwEx(fr, excNames.ClassFormatError, errMsg)
+	}
+	className := *(classNamePtr.StringVal)
+	if MainThread.Trace {
+		traceInfo := fmt.Sprintf("INSTANCEOF: className = %s", className)
+		_ = log.Log(traceInfo, log.TRACE_INST)
+	}
+
+	classPtr := classloader.MethAreaFetch(className)
+	if classPtr == nil { // class wasn't loaded, so load it now
+		if classloader.LoadClassFromNameOnly(className) != nil {
+			errMsg := "INSTANCEOF: Could not load class: " + className
+			return throwEx(fr, excNames.ClassNotLoadedException, errMsg)
+		}
+		classPtr = classloader.MethAreaFetch(className)
+	}
+
+	if classPtr == classloader.MethAreaFetch(*(stringPool.GetStringPointer(obj.KlassName))) {
+		push(fr, int64(1))
+	} else {
+		push(fr, int64(0))
+	}
+	return 3 // 2 for the CP slot + 1 for the next bytecode
+}
+
+func doMonitorenter(fr *frames.Frame, _ int64) int { // OxC2 MONITORENTER acquire the monitor of the object on the stack
+	ref := pop(fr)
+	if object.IsNull(ref) {
+		errMsg := "MONITORENTER: Invalid (null) reference"
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+	object.MonitorEnter(ref.(*object.Object), fr.Thread)
+	return 1
+}
+
+func doMonitorexit(fr *frames.Frame, _ int64) int { // OxC3 MONITOREXIT release the monitor of the object on the stack
+	ref := pop(fr)
+	if object.IsNull(ref) {
+		errMsg := "MONITOREXIT: Invalid (null) reference"
+		return throwEx(fr, excNames.NullPointerException, errMsg)
+	}
+	if !object.MonitorExit(ref.(*object.Object), fr.Thread) {
+		errMsg := "MONITOREXIT: current thread is not the owner of the monitor"
+		return throwEx(fr, excNames.IllegalMonitorStateException, errMsg)
+	}
+	return 1
+}
+
+func doWide(fr *frames.Frame, _ int64) int { // 0xC4 use wide versions of bytecode arguments
+	fr.WideInEffect = true
+	return 1
+}
+
+func doMultianewarray(fr *frames.Frame, _ int64) int { // 0xC5 MULTIANEWARRAY create multi-dimensional array
+	// The first two bytes after the bytecode point to a classref entry in the CP.
+	// In turn, it points to a string describing the array of the form [[L or
+	// similar, in which one [ is present for every array dimension, followed by a
+	// single letter describing the type of primitive in the leaf dimension of the array.
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	CPentry := CP.CpIndex[CPslot]
+	if CPentry.Type != classloader.ClassRef {
+		errMsg := "MULTIANEWARRAY: multi-dimensional array presently supports classes only"
+		return throwEx(fr, excNames.InvalidTypeException, errMsg)
+	}
+	arrayDesc := *stringPool.GetStringPointer(CP.ClassRefs[CPentry.Slot])
+
+	var arrayType uint8
+	switch strings.TrimLeft(arrayDesc, "[")[0] {
+	case 'B', 'Z':
+		arrayType = object.BYTE
+	case 'F', 'D':
+		arrayType = object.FLOAT
+	case 'L':
+		arrayType = object.REF
+	default:
+		arrayType = object.INT
+	}
+
+	dimensionCount := int(fr.Meth[fr.PC+3])
+	if dimensionCount > 3 { // TODO: explore arrays of > 5-255 dimensions
+		errMsg := "MULTIANEWARRAY: Jacobin supports arrays only up to three dimensions"
+		return throwEx(fr, excNames.UnsupportedOperationException, errMsg)
+	}
+
+	// the values on the operand stack give the last dimension first when popped
+	// off the stack, so they're stored here in reverse order, so that dimSizes[0]
+	// will hold the first dimension.
+	dimSizes := make([]int64, dimensionCount)
+	for i := dimensionCount - 1; i >= 0; i-- {
+		dimSizes[i] = convertInterfaceToInt64(pop(fr))
+		if dimSizes[i] < 0 {
+			errMsg := "MULTIANEWARRAY: Invalid size for array"
+			return throwEx(fr, excNames.NegativeArraySizeException, errMsg)
+		}
+	}
+
+	// A dimension of zero ends the dimensions, so we check
+	// and cut off the dimensions below and including the 0-sized
+	// one. Because this is almost certainly an error, we also
+	// issue a warning.
+	for i := range dimSizes {
+		if dimSizes[i] == 0 {
+			dimSizes = dimSizes[i+1:] // lop off the prev dims
+			_ = log.Log("MULTIANEWARRAY: Multidimensional array with one dimension of size 0 encountered.",
+				log.WARNING)
+			break
+		}
+	}
+
+	if !object.HeapHasRoom(object.MultiArraySize(arrayType, dimSizes)) {
+		return throwOutOfMemoryError(fr)
+	}
+
+	switch len(dimSizes) {
+	case 3:
+		multiArr := object.Make1DimArray(object.REF, dimSizes[0])
+		actualArray := multiArr.FieldTable["value"].Fvalue.([]*object.Object)
+		for i := 0; i < len(actualArray); i++ {
+			actualArray[i], _ = object.Make2DimArray(dimSizes[1], dimSizes[2], arrayType)
+		}
+		push(fr, multiArr)
+	case 2: // 2-dim array is a special, trivial case
+		multiArr, _ := object.Make2DimArray(dimSizes[0], dimSizes[1], arrayType)
+		push(fr, multiArr)
+	case 1: // due to a zero-length dimension, we might need a single-dimension array
+		push(fr, object.Make1DimArray(arrayType, dimSizes[0]))
+	}
+	return 4 // 2 for the CP slot + 1 for the dimensions + 1 for the next bytecode
+}
+
+// 0xC6, 0xC7: IFNULL, IFNONNULL jump if TOS holds (or doesn't hold) a null address,
+// where null = nil or object.Null
+func doIfnull(fr *frames.Frame, _ int64) int    { return branch(fr, object.IsNull(pop(fr))) }
+func doIfnonnull(fr *frames.Frame, _ int64) int { return branch(fr, !object.IsNull(pop(fr))) }
+
+func doGotow(fr *frames.Frame, _ int64) int { // 0xC8 GOTO_W jump to a four-byte offset from the current PC
+	return int(fourByteOperand(fr, fr.PC+1))
+}
+
+func doJsrw(fr *frames.Frame, _ int64) int { // 0xC9 JSR_W push the return address and jump to a four-byte offset
+	push(fr, int64(fr.PC+5)) // the return address is the bytecode after this one
+	return int(fourByteOperand(fr, fr.PC+1))
+}
+
+// for bytecodes that are invalid or that the JVM spec reserves for debuggers (BREAKPOINT)
+func notImplemented(fr *frames.Frame, _ int64) int {
+	opcode := fr.Meth[fr.PC]
+	missingOpCode := fmt.Sprintf("%d (0x%X)", opcode, opcode)
+	if int(opcode) < len(opcodes.BytecodeNames) {
+		missingOpCode += fmt.Sprintf("(%s)", opcodes.BytecodeNames[opcode])
+	}
+	errMsg := fmt.Sprintf("Invalid bytecode found: %s at location %d in class %s() method %s%s",
+		missingOpCode, fr.PC, fr.ClName, fr.MethName, fr.MethType)
+	return throwEx(fr, excNames.VerifyError, errMsg)
+}
+
+// === helper methods--that is, functions called by dispatched methods (in alpha order) ===
+
+// arrayElement pops an index and an array reference off the op stack and returns the
+// element of the array at that index. The array's elements must be of type T. If an
+// exception is thrown, ok is false and status is the value for the bytecode to return.
+func arrayElement[T any](fr *frames.Frame, opName string) (value T, status int, ok bool) {
+	index := convertInterfaceToInt64(pop(fr))
+	array, status, ok := arrayOperand[T](fr, pop(fr), opName, excNames.InvalidTypeException)
+	if !ok {
+		return value, status, false
+	}
+	if index < 0 || index >= int64(len(array)) {
+		errMsg := fmt.Sprintf("%s, %s: Invalid array subscript: %d", inMethod(fr), opName, index)
+		return value, throwEx(fr, excNames.ArrayIndexOutOfBoundsException, errMsg), false
+	}
+	return array[index], 0, true
+}
+
+// arrayOperand returns the slice of elements of type T referred to by ref, which is either
+// an array object or, as happens in some gfunctions, the raw Go slice. It throws a
+// NullPointerException if ref is null, and the wrongType exception if it's not an array
+// of T, in which case ok is false and status is the value for the bytecode to return.
+func arrayOperand[T any](fr *frames.Frame, ref any, opName string, wrongType int) (array []T, status int, ok bool) {
+	if object.IsNull(ref) {
+		errMsg := fmt.Sprintf("%s, %s: Invalid (null) reference to an array", inMethod(fr), opName)
+		return nil, throwEx(fr, excNames.NullPointerException, errMsg), false
+	}
+
+	switch r := ref.(type) {
+	case *object.Object:
+		if array, ok = r.FieldTable["value"].Fvalue.([]T); ok {
+			return array, 0, true
+		}
+	case []T:
+		return r, 0, true
+	case *[]T:
+		return *r, 0, true
+	}
+
+	errMsg := fmt.Sprintf("%s, %s: Invalid reference type of an array: %T", inMethod(fr), opName, ref)
+	return nil, throwEx(fr, wrongType, errMsg), false
+}
+
+// branch returns the amount to advance the PC for a conditional branch bytecode: the
+// two-byte signed offset that follows the bytecode if the branch is taken, otherwise
+// the length of the instruction.
+func branch(fr *frames.Frame, taken bool) int {
+	if taken {
+		jumpTo := (int16(fr.Meth[fr.PC+1]) * 256) + int16(fr.Meth[fr.PC+2])
+		return int(jumpTo)
+	}
+	return 3 // the 2 bytes forming the unused jumpTo + 1 byte to next bytecode
+}
+
+// divideByZero throws the ArithmeticException for an integer division or remainder by zero
+func divideByZero(fr *frames.Frame, opName string, dividend int64) int {
+	errInfo := fmt.Sprintf("%s: division by zero -- %d/0", opName, dividend)
+	if globals.GetGlobalRef().StrictJDK { // use the HotSpot wording
+		errInfo = "/ by zero"
+	}
+	errMsg := fmt.Sprintf("%s %s", inMethod(fr), errInfo)
+	return throwEx(fr, excNames.ArithmeticException, errMsg)
+}
+
+// the error messages of exceptions that weren't caught, which happens only in tests,
+// keyed by the frame in which they were thrown. interpret() returns them as errors.
+var uncaughtErrors sync.Map
+
+// errorOccurred records errMsg as the error to be returned by interpret() for the frame
+// fr, and returns ERROR_OCCURRED for the bytecode function to return.
+func errorOccurred(fr *frames.Frame, errMsg string) int {
+	uncaughtErrors.Store(fr, errMsg)
+	return exceptions.ERROR_OCCURRED
+}
+
+// floatCompare implements FCMPL, FCMPG, DCMPL, and DCMPG. These push -1, 0, or 1 and
+// differ only in the value they push if either value is NaN.
+func floatCompare(fr *frames.Frame, nanResult int64) int {
+	val2 := pop(fr).(float64)
+	val1 := pop(fr).(float64)
+	switch {
+	case math.IsNaN(val1) || math.IsNaN(val2):
+		push(fr, nanResult)
+	case val1 > val2:
+		push(fr, int64(1))
+	case val1 < val2:
+		push(fr, int64(-1))
+	default:
+		push(fr, int64(0))
+	}
+	return 1
+}
+
+// floatToInt64 converts a float or double to an int or long per the JVM spec: NaN
+// becomes 0 and values outside the range of the target type become its min or max.
+func floatToInt64(val float64, min, max int64) int64 {
+	switch {
+	case math.IsNaN(val):
+		return 0
+	case val >= float64(max):
+		return max
+	case val <= float64(min):
+		return min
+	}
+	return int64(val)
+}
+
+// fourByteOperand returns the signed four-byte operand at position pos in the bytecode
+func fourByteOperand(fr *frames.Frame, pos int) int64 {
+	return fourBytesToInt64(fr.Meth[pos], fr.Meth[pos+1], fr.Meth[pos+2], fr.Meth[pos+3])
+}
+
+// inMethod returns "in class.method", for error messages
+func inMethod(fr *frames.Frame) string {
+	return fmt.Sprintf("in %s.%s", util.ConvertInternalClassNameToUserFormat(fr.ClName), fr.MethName)
+}
+
+// instanceFieldName returns the name of the field referred to by the CP entry in the
+// two bytes following GETFIELD or PUTFIELD.
+func instanceFieldName(fr *frames.Frame, opName string) (fieldName string, status int, ok bool) {
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	fieldEntry := CP.CpIndex[CPslot]
+	if fieldEntry.Type != classloader.FieldRef { // the pointed-to CP entry must be a field reference
+		errMsg := fmt.Sprintf("%s: Expected a field ref, but got %d in "+
+			"location %d in method %s of class %s",
+			opName, fieldEntry.Type, fr.PC, fr.MethName, fr.ClName)
+		return "", throwEx(fr, excNames.NoSuchFieldException, errMsg), false
+	}
+
+	_, fieldName, _ = classloader.GetMemberInfoFromCPref(CP, CPslot)
+	if MainThread.Trace {
+		emitTraceFieldID(opName, fieldName)
+	}
+	return fieldName, 0, true
+}
+
+// invalidMethodType handles a method table entry that's neither a Java method nor a gfunction
+func invalidMethodType(fr *frames.Frame, opName string, mtEntry classloader.MTentry) int {
+	errMsg := fmt.Sprintf("%s: invalid method type '%c' in %s", opName, mtEntry.MType, inMethod(fr))
+	return throwEx(fr, excNames.InternalException, errMsg)
+}
+
+// invokeGfunction pops the arguments (and, if hasObjRef, the object reference) of a
+// gfunction off the op stack, runs the gfunction, and pushes its return value, if any.
+// gfunctions expect longs and doubles to occupy two parameter slots, as they do in
+// runFrame(), so those arguments are passed twice.
+func invokeGfunction(fr *frames.Frame, mtEntry classloader.MTentry,
+	className, methodName, methodType string, hasObjRef bool, PCadvance int) int {
+
+	var params []any
+	paramTypes := util.ParseIncomingParamsFromMethTypeString(methodType)
+	for i := len(paramTypes) - 1; i >= 0; i-- {
+		arg := pop(fr)
+		params = append(params, arg)
+		if paramTypes[i] == types.Long || paramTypes[i] == types.Double {
+			params = append(params, arg)
+		}
+	}
+	if hasObjRef { // the objectRef (the object whose method we're invoking) or a *os.File (stream I/O)
+		params = append(params, pop(fr))
+	}
+
+	ret := gfunction.RunGfunction(mtEntry, fr.FrameStack, className, methodName, methodType,
+		&params, hasObjRef, MainThread.Trace)
+	switch r := ret.(type) {
+	case nil:
+	case error:
+		if errors.Is(r, gfunction.CaughtGfunctionException) {
+			return 0 // the exception handler is now at the top of the frame stack
+		}
+		return errorOccurred(fr, r.Error()) // applies only if in test
+	default: // if it's not an error, then it's a legitimate return value, which we simply push
+		pushReturnValue(fr, ret, methodType)
+	}
+	return PCadvance
+}
+
+// invokeJavaMethod creates the frame for a Java method, moving its arguments (and, if
+// hasObjRef, the object reference) from the op stack to the new frame's locals, and
+// pushes the frame onto the frame stack, which makes interpret() start running it. The
+// PC is first advanced past the invoking instruction, so that execution continues with
+// the next bytecode when the invoked method returns.
+func invokeJavaMethod(fr *frames.Frame, mtEntry classloader.MTentry,
+	className, methodName, methodType string, hasObjRef bool, PCadvance int, opName string) int {
+
+	m := mtEntry.Meth.(classloader.JmEntry)
+	if m.AccessFlags&0x0100 > 0 { // Native code
+		errMsg := opName + ": Native method requested: " + className + "." + methodName + methodType
+		return throwEx(fr, excNames.UnsupportedOperationException, errMsg)
+	}
+
+	fram, err := createAndInitNewFrame(className, methodName, methodType, &m, hasObjRef, fr, false)
+	if err != nil {
+		which, errMsg := frameException(err, opName, className+"."+methodName+methodType)
+		return throwEx(fr, which, errMsg)
+	}
+
+	fr.PC += PCadvance            // point to the next bytecode for when we return from the invoked method
+	fr.FrameStack.PushFront(fram) // push the new frame
+	return 0
+}
+
+// isWide returns whether the op stack entry at index holds a long or a double, which
+// count as two words on the JVM's operand stack. Entries that hold longs and doubles
+// are marked by pushWide(). The type check is needed because ThrowEx() puts the
+// exception object on the stack without going through push().
+func isWide(fr *frames.Frame, index int) bool {
+	if fr.OpStackWide == nil || index < 0 || !fr.OpStackWide[index] {
+		return false
+	}
+	switch fr.OpStack[index].(type) {
+	case int64, float64:
+		return true
+	}
+	return false
+}
+
+func ldc(fr *frames.Frame, width int) int {
+	var idx int
+	if width == 1 { // LDC uses a 1-byte index into the CP, LDC_W uses a 2-byte index
+		idx = int(fr.Meth[fr.PC+1])
+	} else {
 		idx = (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2])
 	}
 
@@ -820,36 +2088,97 @@ func ldc(fr *frames.Frame, width int) int {
 		// This bytecode does not load longs or doubles
 		CPe.EntryType == classloader.DoubleConst ||
 		CPe.EntryType == classloader.LongConst {
-		globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
-		errMsg := fmt.Sprintf("in %s.%s, LDC: Invalid type for bytecode operand",
-			util.ConvertInternalClassNameToUserFormat(fr.ClName), fr.MethName)
-		status := exceptions.ThrowEx(excNames.ClassFormatError, errMsg, fr)
-		if status != exceptions.Caught {
-			return exceptions.ERROR_OCCURRED // applies only if in test
-		}
+		errMsg := fmt.Sprintf("%s, LDC: Invalid type for bytecode operand", inMethod(fr))
+		return throwEx(fr, excNames.ClassFormatError, errMsg)
 	}
 	// if no error
-	switch CPe.RetType {
-	case classloader.IS_INT64:
+	switch {
+	case CPe.EntryType == classloader.ClassRef: // a class constant is the class's Class object
+		push(fr, classloader.ClassMirror(*CPe.StringVal))
+	case CPe.RetType == classloader.IS_INT64:
 		push(fr, CPe.IntVal)
-	case classloader.IS_FLOAT64:
+	case CPe.RetType == classloader.IS_FLOAT64:
 		push(fr, CPe.FloatVal)
-	case classloader.IS_STRUCT_ADDR:
+	case CPe.RetType == classloader.IS_STRUCT_ADDR:
 		push(fr, CPe.AddrVal)
-	case classloader.IS_STRING_ADDR: // returns a string object whose "value" field is a byte array
+	case CPe.RetType == classloader.IS_STRING_ADDR: // returns a string object whose "value" field is a byte array
 		stringAddr := object.StringObjectFromGoString(*CPe.StringVal)
 		push(fr, stringAddr)
 	}
 
-	if width == 1 {
-		return 2 // 1 for the index + 1 for the next bytecode
-	} else {
-		return 3 // 2 for the index + 1 for the next bytecode
+	return width + 1 // the index + 1 for the next bytecode
+}
+
+func loadLocal(fr *frames.Frame, local int64) int {
+	push(fr, fr.Locals[local])
+	return 1
+}
+
+func loadWide(fr *frames.Frame, local int64) int {
+	pushWide(fr, fr.Locals[local])
+	return 1
+}
+
+// localIndex returns the index of the local variable that's the operand of the current
+// bytecode, which is one byte, or two if the bytecode was preceded by WIDE. The second
+// value is the amount to advance the PC to the next bytecode.
+func localIndex(fr *frames.Frame) (int, int) {
+	if fr.WideInEffect {
+		fr.WideInEffect = false
+		return (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]), 3
 	}
+	return int(fr.Meth[fr.PC+1]), 2
 }
 
-func pushInt(fr *frames.Frame, intToPush int64) int {
-	push(fr, intToPush)
+// popIntPair pops two ints off the op stack and returns them in the order they were popped
+func popIntPair(fr *frames.Frame) (int64, int64) {
+	val2 := convertInterfaceToInt64(pop(fr))
+	val1 := convertInterfaceToInt64(pop(fr))
+	return val2, val1
+}
+
+// an op stack entry, as popped by popWords()
+type stackEntry struct {
+	value any
+	wide  bool // the value is a long or double
+}
+
+// popWords pops values off the op stack that together make up the given number of words
+// of the JVM's operand stack, in which longs and doubles take two words and all other
+// values take one. The values are returned with the deepest first. ok is false if the
+// stack holds too few words or if a long or double would have to be split.
+func popWords(fr *frames.Frame, words int) (entries []stackEntry, ok bool) {
+	for words > 0 {
+		if fr.TOS < 0 {
+			return entries, false
+		}
+		entry := stackEntry{wide: isWide(fr, fr.TOS)}
+		entry.value = pop(fr)
+		entries = append([]stackEntry{entry}, entries...)
+		if entry.wide {
+			words -= 2
+		} else {
+			words -= 1
+		}
+	}
+	return entries, words == 0
+}
+
+// pushEntries pushes groups of values that were popped by popWords()
+func pushEntries(fr *frames.Frame, groups ...[]stackEntry) {
+	for _, group := range groups {
+		for _, entry := range group {
+			if entry.wide {
+				pushWide(fr, entry.value)
+			} else {
+				push(fr, entry.value)
+			}
+		}
+	}
+}
+
+func pushDouble(fr *frames.Frame, intToPush int64) int {
+	pushWide(fr, float64(intToPush))
 	return 1
 }
 
@@ -858,7 +2187,165 @@ func pushFloat(fr *frames.Frame, intToPush int64) int {
 	return 1
 }
 
+func pushInt(fr *frames.Frame, intToPush int64) int {
+	push(fr, intToPush)
+	return 1
+}
+
+func pushLong(fr *frames.Frame, intToPush int64) int {
+	pushWide(fr, intToPush)
+	return 1
+}
+
+// pushReturnValue pushes the value returned by a method (or call site) whose descriptor
+// is methodType
+func pushReturnValue(fr *frames.Frame, value any, methodType string) {
+	if strings.HasSuffix(methodType, ")J") || strings.HasSuffix(methodType, ")D") {
+		pushWide(fr, value)
+	} else {
+		push(fr, value)
+	}
+}
+
+// pushWide pushes a long or double onto the op stack. It occupies a single entry, which
+// is marked in the frame's OpStackWide, so that the bytecodes that operate on stack
+// words regardless of type (POP2, DUP2, and the like) can count it as two words.
+func pushWide(fr *frames.Frame, value any) {
+	push(fr, value)
+	if fr.TOS < 0 { // the push failed, which happens only in tests
+		return
+	}
+	if fr.OpStackWide == nil {
+		fr.OpStackWide = make([]bool, len(fr.OpStack))
+	}
+	fr.OpStackWide[fr.TOS] = true
+}
+
+// returnFromMethod removes the frame of the returning method from the frame stack and,
+// if hasValue, moves the return value to the op stack of the caller, which is now at
+// the top of the frame stack.
+func returnFromMethod(fr *frames.Frame, hasValue bool, wide bool) int {
+	var valToReturn any
+	if hasValue {
+		valToReturn = pop(fr)
+	}
+
+	fs := fr.FrameStack
+	fs.Remove(fs.Front())
+	if hasValue && fs.Len() > 0 { // main() has no caller
+		caller := fs.Front().Value.(*frames.Frame)
+		if wide {
+			pushWide(caller, valToReturn)
+		} else {
+			push(caller, valToReturn)
+		}
+	}
+	return 0
+}
+
+// sameReference compares two references for IF_ACMPEQ and IF_ACMPNE. The different
+// representations of null are all equal.
+func sameReference(ref1, ref2 any) bool {
+	if object.IsNull(ref1) || object.IsNull(ref2) {
+		return object.IsNull(ref1) && object.IsNull(ref2)
+	}
+	return ref1 == ref2
+}
+
+// setArrayElement pops an index and an array reference off the op stack and stores value
+// in the element of the array at that index. The array's elements must be of type T.
+func setArrayElement[T any](fr *frames.Frame, opName string, value T) int {
+	index := convertInterfaceToInt64(pop(fr))
+	array, status, ok := arrayOperand[T](fr, pop(fr), opName, excNames.ArrayStoreException)
+	if !ok {
+		return status
+	}
+	if index < 0 || index >= int64(len(array)) {
+		errMsg := fmt.Sprintf("%s, %s: array size is %d but array index is %d",
+			inMethod(fr), opName, len(array), index)
+		return throwEx(fr, excNames.ArrayIndexOutOfBoundsException, errMsg)
+	}
+	array[index] = value
+	return 1
+}
+
+// stackWordError throws the exception for a bytecode that found too few words on the op
+// stack, or that would split a long or double
+func stackWordError(fr *frames.Frame, opName string) int {
+	errMsg := fmt.Sprintf("invalid operand stack for %s %s", opName, inMethod(fr))
+	return throwEx(fr, excNames.InternalException, errMsg)
+}
+
+// staticFieldName returns the class name and the full name (class.field) of the static
+// field referred to by the CP entry in the two bytes following GETSTATIC or PUTSTATIC.
+func staticFieldName(fr *frames.Frame, opName string) (className, fieldName string, status int, ok bool) {
+	CPslot := (int(fr.Meth[fr.PC+1]) * 256) + int(fr.Meth[fr.PC+2]) // next 2 bytes point to CP entry
+	CP := fr.CP.(*classloader.CPool)
+	CPentry := CP.CpIndex[CPslot]
+	if CPentry.Type != classloader.FieldRef { // the pointed-to CP entry must be a field reference
+		errMsg := fmt.Sprintf("%s: Expected a field ref, but got %d in "+
+			"location %d in method %s of class %s",
+			opName, CPentry.Type, fr.PC, fr.MethName, fr.ClName)
+		return "", "", throwEx(fr, excNames.NoSuchFieldException, errMsg), false
+	}
+
+	className, fieldName, _ = classloader.GetMemberInfoFromCPref(CP, CPslot)
+	fieldName = className + "." + fieldName
+	if MainThread.Trace {
+		emitTraceFieldID(opName, fieldName)
+	}
+	return className, fieldName, 0, true
+}
+
 func storeInt(fr *frames.Frame, local int64) int {
+	fr.Locals[local] = convertInterfaceToInt64(pop(fr))
+	return 1
+}
+
+func storeLocal(fr *frames.Frame, local int64) int {
 	fr.Locals[local] = pop(fr)
 	return 1
 }
+
+// longs and doubles occupy two local variables
+func storeWide(fr *frames.Frame, local int64) int {
+	value := pop(fr)
+	fr.Locals[local] = value
+	fr.Locals[local+1] = value
+	return 1
+}
+
+// switchPadding returns the number of padding bytes between a TABLESWITCH or LOOKUPSWITCH
+// bytecode at PC and its operands, which begin at a multiple of four bytes.
+func switchPadding(PC int) int {
+	return (4 - ((PC + 1) % 4)) % 4
+}
+
+// takeError returns the error recorded by errorOccurred() for the frame fr
+func takeError(fr *frames.Frame) error {
+	if errMsg, ok := uncaughtErrors.LoadAndDelete(fr); ok {
+		return errors.New(errMsg.(string))
+	}
+	return fmt.Errorf("error executing bytecode in %s.%s%s", fr.ClName, fr.MethName, fr.MethType)
+}
+
+// throwEx throws the exception via exceptions.ThrowEx(). If the exception is caught, the
+// frame with the handler is now at the top of the frame stack, so throwEx returns 0, which
+// makes interpret() resume there. Otherwise (which happens only in tests), it returns
+// ERROR_OCCURRED, and interpret() returns errMsg as its error.
+func throwEx(fr *frames.Frame, which int, errMsg string) int {
+	globals.GetGlobalRef().ErrorGoStack = string(debug.Stack())
+	if exceptions.ThrowEx(which, errMsg, fr) == exceptions.Caught {
+		return 0
+	}
+	return errorOccurred(fr, errMsg)
+}
+
+// throwOutOfMemoryError throws java.lang.OutOfMemoryError for an allocation that doesn't
+// fit within the maximum heap size. The heap limit is suspended while the exception is
+// thrown, so that there's room for the exception object itself.
+func throwOutOfMemoryError(fr *frames.Frame) int {
+	object.ThrowingOutOfMemoryError(true)
+	defer object.ThrowingOutOfMemoryError(false)
+	return throwEx(fr, excNames.OutOfMemoryError, "Java heap space")
+}
@@ -5,3 +5,504 @@
  */
 
 package jvm
+
+import (
+	"jacobin/classloader"
+	"jacobin/exceptions"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/opcodes"
+	"jacobin/stringPool"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// These tests run bytecodes with interpret(), with an emphasis on the ways in which it
+// differs from runFrame(): longs and doubles take a single op stack entry, which is marked
+// as wide in OpStackWide, and errors are thrown via ThrowEx().
+
+// runs the bytecodes in f with interpret() and returns the error, if any
+func interpretFrame(f *frames.Frame) error {
+	fs := frames.CreateFrameStack()
+	fs.PushFront(f)
+	return interpret(fs)
+}
+
+// an op stack entry that's one word, such as an int, or two words: a long or a double
+func word(value any) stackEntry     { return stackEntry{value: value} }
+func wideWord(value any) stackEntry { return stackEntry{value: value, wide: true} }
+
+// interpretCode runs code with interpret() on a frame whose op stack holds the given
+// entries and which has four local variables, and returns the frame
+func interpretCode(t *testing.T, code []byte, stack ...stackEntry) *frames.Frame {
+	f := newFrame(code[0])
+	f.Meth = append(f.Meth, code[1:]...)
+	f.Locals = make([]any, 4)
+	pushEntries(&f, stack)
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("%s: unexpected error: %s", opcodes.BytecodeNames[code[len(code)-1]], err.Error())
+	}
+	return &f
+}
+
+// stackEntries returns the entries on the op stack, from the bottom up
+func stackEntries(f *frames.Frame) []stackEntry {
+	entries := []stackEntry{}
+	for i := 0; i <= f.TOS; i++ {
+		entries = append(entries, stackEntry{value: f.OpStack[i], wide: isWide(f, i)})
+	}
+	return entries
+}
+
+// int32Operand returns the four bytes of a switch operand
+func int32Operand(value int32) []byte {
+	return []byte{byte(value >> 24), byte(value >> 16), byte(value >> 8), byte(value)}
+}
+
+// LCONST_1, LCONST_1, LADD: the long result takes a single op stack entry
+func TestInterpretLongUsesOneSlot(t *testing.T) {
+	f := newFrame(opcodes.LCONST_1)
+	f.Meth = append(f.Meth, opcodes.LCONST_1, opcodes.LADD)
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("LADD: unexpected error: %s", err.Error())
+	}
+	if f.TOS != 0 {
+		t.Errorf("LADD: expected TOS to be 0, got: %d", f.TOS)
+	}
+	if value := pop(&f).(int64); value != 2 {
+		t.Errorf("LADD: expected a result of 2, got: %d", value)
+	}
+}
+
+// POP2 removes a single long, but two ints
+func TestInterpretPop2(t *testing.T) {
+	f := newFrame(opcodes.ICONST_1)
+	f.Meth = append(f.Meth, opcodes.LCONST_0, opcodes.POP2)
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("POP2: unexpected error: %s", err.Error())
+	}
+	if f.TOS != 0 || peek(&f).(int64) != 1 {
+		t.Errorf("POP2 of a long: expected the int 1 at TOS 0, got TOS: %d", f.TOS)
+	}
+
+	f = newFrame(opcodes.ICONST_1)
+	f.Meth = append(f.Meth, opcodes.ICONST_2, opcodes.ICONST_3, opcodes.POP2)
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("POP2: unexpected error: %s", err.Error())
+	}
+	if f.TOS != 0 || peek(&f).(int64) != 1 {
+		t.Errorf("POP2 of two ints: expected the int 1 at TOS 0, got TOS: %d", f.TOS)
+	}
+}
+
+// DUP2 of a long duplicates just the long, and the copy is still a long
+func TestInterpretDup2Long(t *testing.T) {
+	f := newFrame(opcodes.ICONST_5)
+	f.Meth = append(f.Meth, opcodes.LCONST_1, opcodes.DUP2, opcodes.LADD)
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("DUP2: unexpected error: %s", err.Error())
+	}
+	if f.TOS != 1 {
+		t.Errorf("DUP2: expected TOS to be 1, got: %d", f.TOS)
+	}
+	if value := pop(&f).(int64); value != 2 {
+		t.Errorf("DUP2: expected a long of 2, got: %d", value)
+	}
+	if value := pop(&f).(int64); value != 5 {
+		t.Errorf("DUP2: expected the int 5 beneath the long, got: %d", value)
+	}
+}
+
+// DUP_X1 of an int above a long would split the long, which is an error
+func TestInterpretDupX1SplittingLong(t *testing.T) {
+	globals.InitGlobals("test")
+	f := newFrame(opcodes.LCONST_1)
+	f.Meth = append(f.Meth, opcodes.ICONST_1, opcodes.DUP_X1)
+	err := interpretFrame(&f)
+	if err == nil || !strings.Contains(err.Error(), "DUP_X1") {
+		t.Errorf("DUP_X1: expected an error splitting a long, got: %v", err)
+	}
+}
+
+// IADD wraps around on overflow, as Java ints do
+func TestInterpretIaddOverflow(t *testing.T) {
+	f := newFrame(opcodes.ICONST_1)
+	f.Meth = append(f.Meth, opcodes.IADD)
+	push(&f, int64(0x7FFFFFFF))
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("IADD: unexpected error: %s", err.Error())
+	}
+	if value := pop(&f).(int64); value != -0x80000000 {
+		t.Errorf("IADD: expected overflow to -2147483648, got: %d", value)
+	}
+}
+
+// LRETURN moves the long to the caller's op stack as a single entry
+func TestInterpretLreturn(t *testing.T) {
+	caller := newFrame(opcodes.NOP)
+	called := newFrame(opcodes.LCONST_1)
+	called.Meth = append(called.Meth, opcodes.LRETURN)
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(&caller)
+	fs.PushFront(&called)
+	if err := interpret(fs); err != nil {
+		t.Fatalf("LRETURN: unexpected error: %s", err.Error())
+	}
+	if fs.Len() != 1 || fs.Front().Value.(*frames.Frame) != &caller {
+		t.Fatalf("LRETURN: expected only the caller's frame to remain on the frame stack")
+	}
+	if caller.TOS != 0 || !isWide(&caller, 0) {
+		t.Errorf("LRETURN: expected a long at TOS 0 of the caller, got TOS: %d", caller.TOS)
+	}
+	if value := pop(&caller).(int64); value != 1 {
+		t.Errorf("LRETURN: expected a return value of 1, got: %d", value)
+	}
+}
+
+// IDIV by zero throws an ArithmeticException, which in tests is not caught and
+// is returned as an error
+func TestInterpretIdivByZero(t *testing.T) {
+	globals.InitGlobals("test")
+	f := newFrame(opcodes.ICONST_5)
+	f.Meth = append(f.Meth, opcodes.ICONST_0, opcodes.IDIV)
+	err := interpretFrame(&f)
+	if err == nil || !strings.Contains(err.Error(), "division by zero") {
+		t.Errorf("IDIV: expected a division by zero error, got: %v", err)
+	}
+}
+
+// invoking a method when the frame stack is at the depth allowed by -Xss throws
+// a StackOverflowError, rather than pushing another frame
+func TestInterpretInvokeThrowsStackOverflowError(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	g.ThreadStackSize = 10 * estimatedFrameSize // a stack that holds 10 frames
+	defer func() { g.ThreadStackSize = globals.DefaultThreadStackSize }()
+
+	mtEntry := classloader.MTentry{
+		Meth:  classloader.JmEntry{MaxStack: 1, MaxLocals: 0, Code: []byte{opcodes.RETURN}},
+		MType: 'J',
+	}
+	fs := frames.CreateFrameStack()
+	for fs.Len() < 9 {
+		f := newFrame(opcodes.NOP)
+		f.FrameStack = fs
+		fs.PushFront(&f)
+	}
+
+	caller := fs.Front().Value.(*frames.Frame)
+	if ret := invokeJavaMethod(caller, mtEntry, "Test", "recurse", "()V", false, 3, "INVOKESTATIC"); ret != 0 {
+		t.Fatalf("expected the 10th frame to be pushed, got: %d", ret)
+	}
+	if fs.Len() != 10 {
+		t.Fatalf("expected 10 frames on the frame stack, got: %d", fs.Len())
+	}
+
+	caller = fs.Front().Value.(*frames.Frame)
+	ret := invokeJavaMethod(caller, mtEntry, "Test", "recurse", "()V", false, 3, "INVOKESTATIC")
+	if ret != exceptions.ERROR_OCCURRED {
+		t.Errorf("expected a StackOverflowError for the 11th frame, got: %d", ret)
+	}
+	if fs.Len() != 10 {
+		t.Errorf("expected the frame stack to stay at 10 frames, got: %d", fs.Len())
+	}
+}
+
+// a method that recurses through a gfunction callback--here, a toString() that concatenates
+// its own object, so that the concatenation calls toString() via InvokeMethod--throws a
+// StackOverflowError once the frame stack is full, rather than recursing without limit:
+//
+//	public String toString() { return "" + this; }
+func TestInvokeMethodRecursionThrowsStackOverflowError(t *testing.T) {
+	CP := invokeTestSetup()
+	g := globals.GetGlobalRef()
+	g.ThreadStackSize = 20 * estimatedFrameSize // a stack that holds 20 frames
+	defer func() { g.ThreadStackSize = globals.DefaultThreadStackSize }()
+
+	addInvokeTestMethod("toString", "()Ljava/lang/String;", CP, nil,
+		opcodes.ALOAD_0, opcodes.INVOKEDYNAMIC, 0x00, 0x00, 0x00, 0x00, opcodes.ARETURN)
+	target, err := makeConcat(&callSiteInfo{desc: "(Ltest/Invoke;)Ljava/lang/String;"})
+	if err != nil {
+		t.Fatalf("makeConcat: unexpected error: %s", err.Error())
+	}
+	key := callSiteKey{method: "test/Invoke.toString()Ljava/lang/String;", pc: 1}
+	callSites.Store(key, &callSite{desc: "(Ltest/Invoke;)Ljava/lang/String;", target: target})
+	defer callSites.Delete(key)
+
+	className := "test/Invoke"
+	obj := object.MakeEmptyObjectWithClassName(&className)
+	fs := frames.CreateFrameStack()
+	_, err = InvokeMethod(fs, className, "toString", "()Ljava/lang/String;", []any{obj})
+	if err == nil || !strings.Contains(err.Error(), "StackOverflowError") {
+		t.Errorf("expected a StackOverflowError, got: %v", err)
+	}
+	if fs.Len() != 0 {
+		t.Errorf("expected the frame stack to be empty after the error, got %d frames", fs.Len())
+	}
+}
+
+// the bytecodes that produce a long or a double leave a single wide entry on the op stack,
+// and those that consume them leave a single-word entry
+func TestInterpretLongAndDoubleBytecodes(t *testing.T) {
+	nan := []byte{opcodes.DCONST_0, opcodes.DCONST_0, opcodes.DDIV}
+	for _, test := range []struct {
+		name     string
+		code     []byte
+		expected stackEntry
+	}{
+		{"LSUB", []byte{opcodes.LCONST_1, opcodes.LCONST_1, opcodes.LSUB}, wideWord(int64(0))},
+		{"LMUL", []byte{opcodes.BIPUSH, 7, opcodes.I2L, opcodes.BIPUSH, 6, opcodes.I2L, opcodes.LMUL}, wideWord(int64(42))},
+		{"LDIV", []byte{opcodes.BIPUSH, 7, opcodes.I2L, opcodes.ICONST_2, opcodes.I2L, opcodes.LDIV}, wideWord(int64(3))},
+		{"LREM", []byte{opcodes.BIPUSH, 7, opcodes.I2L, opcodes.ICONST_2, opcodes.I2L, opcodes.LREM}, wideWord(int64(1))},
+		{"LNEG", []byte{opcodes.LCONST_1, opcodes.LNEG}, wideWord(int64(-1))},
+		{"LSHL", []byte{opcodes.LCONST_1, opcodes.BIPUSH, 63, opcodes.LSHL}, wideWord(int64(math.MinInt64))},
+		{"LSHR", []byte{opcodes.LCONST_1, opcodes.LNEG, opcodes.BIPUSH, 65, opcodes.LSHR}, wideWord(int64(-1))},
+		{"LUSHR", []byte{opcodes.LCONST_1, opcodes.LNEG, opcodes.ICONST_1, opcodes.LUSHR}, wideWord(int64(math.MaxInt64))},
+		{"LOR", []byte{opcodes.LCONST_1, opcodes.LCONST_0, opcodes.LOR}, wideWord(int64(1))},
+		{"LXOR", []byte{opcodes.LCONST_1, opcodes.LNEG, opcodes.LCONST_1, opcodes.LXOR}, wideWord(int64(-2))},
+		{"LCMP", []byte{opcodes.LCONST_0, opcodes.LCONST_1, opcodes.LCMP}, word(int64(-1))},
+		{"DSUB", []byte{opcodes.DCONST_0, opcodes.DCONST_1, opcodes.DSUB}, wideWord(-1.0)},
+		{"DMUL", []byte{opcodes.DCONST_1, opcodes.DCONST_1, opcodes.DADD, opcodes.DUP2, opcodes.DMUL}, wideWord(4.0)},
+		{"DDIV", []byte{opcodes.DCONST_1, opcodes.DCONST_0, opcodes.DDIV}, wideWord(math.Inf(1))},
+		{"DNEG", []byte{opcodes.DCONST_1, opcodes.DNEG}, wideWord(-1.0)},
+		{"DCMPL", append(nan, opcodes.DCONST_1, opcodes.DCMPL), word(int64(-1))},
+		{"DCMPG", append(nan, opcodes.DCONST_1, opcodes.DCMPG), word(int64(1))},
+		{"I2L", []byte{opcodes.ICONST_M1, opcodes.I2L}, wideWord(int64(-1))},
+		{"I2D", []byte{opcodes.ICONST_2, opcodes.I2D}, wideWord(2.0)},
+		{"L2I", []byte{opcodes.LCONST_1, opcodes.LNEG, opcodes.L2I}, word(int64(-1))},
+		{"L2F", []byte{opcodes.LCONST_1, opcodes.L2F}, word(1.0)},
+		{"L2D", []byte{opcodes.LCONST_1, opcodes.L2D}, wideWord(1.0)},
+		{"F2L", []byte{opcodes.FCONST_2, opcodes.F2L}, wideWord(int64(2))},
+		{"F2D", []byte{opcodes.FCONST_2, opcodes.F2D}, wideWord(2.0)},
+		{"D2I", []byte{opcodes.DCONST_1, opcodes.DNEG, opcodes.D2I}, word(int64(-1))},
+		{"D2L", []byte{opcodes.DCONST_1, opcodes.DCONST_1, opcodes.DADD, opcodes.D2L}, wideWord(int64(2))},
+		{"D2F", []byte{opcodes.DCONST_1, opcodes.D2F}, word(1.0)},
+		{"LSTORE_1/LLOAD_1", []byte{opcodes.LCONST_1, opcodes.LSTORE_1, opcodes.LLOAD_1, opcodes.LLOAD_1, opcodes.LADD},
+			wideWord(int64(2))},
+		{"LSTORE/LLOAD", []byte{opcodes.LCONST_1, opcodes.LSTORE, 2, opcodes.LLOAD, 2}, wideWord(int64(1))},
+		{"DSTORE_0/DLOAD_0", []byte{opcodes.DCONST_1, opcodes.DSTORE_0, opcodes.DLOAD_0}, wideWord(1.0)},
+	} {
+		f := interpretCode(t, test.code)
+		if entries := stackEntries(f); len(entries) != 1 || entries[0] != test.expected {
+			t.Errorf("%s: expected %+v as the only entry on the op stack, got %+v", test.name, test.expected, entries)
+		}
+	}
+}
+
+// the bytecodes that operate on stack words regardless of type count a long or double as
+// two words, and the entries they move keep their wide markers
+func TestInterpretStackWordBytecodes(t *testing.T) {
+	one, two, three, four := word(int64(1)), word(int64(2)), word(int64(3)), word(int64(4))
+	long, double := wideWord(int64(10)), wideWord(2.5)
+	for _, test := range []struct {
+		name   string
+		opcode byte
+		before []stackEntry
+		after  []stackEntry
+	}{
+		{"POP", opcodes.POP, []stackEntry{one, two}, []stackEntry{one}},
+		{"POP2 of two ints", opcodes.POP2, []stackEntry{one, two, three}, []stackEntry{one}},
+		{"POP2 of a long", opcodes.POP2, []stackEntry{one, long}, []stackEntry{one}},
+		{"DUP", opcodes.DUP, []stackEntry{one}, []stackEntry{one, one}},
+		{"DUP_X1", opcodes.DUP_X1, []stackEntry{one, two}, []stackEntry{two, one, two}},
+		{"DUP_X2 over ints", opcodes.DUP_X2, []stackEntry{one, two, three}, []stackEntry{three, one, two, three}},
+		{"DUP_X2 over a long", opcodes.DUP_X2, []stackEntry{long, three}, []stackEntry{three, long, three}},
+		{"DUP2 of ints", opcodes.DUP2, []stackEntry{one, two}, []stackEntry{one, two, one, two}},
+		{"DUP2 of a double", opcodes.DUP2, []stackEntry{double}, []stackEntry{double, double}},
+		{"DUP2_X1 of ints", opcodes.DUP2_X1, []stackEntry{one, two, three}, []stackEntry{two, three, one, two, three}},
+		{"DUP2_X1 of a long", opcodes.DUP2_X1, []stackEntry{one, long}, []stackEntry{long, one, long}},
+		{"DUP2_X2 of ints over ints", opcodes.DUP2_X2, []stackEntry{one, two, three, four},
+			[]stackEntry{three, four, one, two, three, four}},
+		{"DUP2_X2 of a long over ints", opcodes.DUP2_X2, []stackEntry{one, two, long}, []stackEntry{long, one, two, long}},
+		{"DUP2_X2 of ints over a long", opcodes.DUP2_X2, []stackEntry{long, one, two}, []stackEntry{one, two, long, one, two}},
+		{"DUP2_X2 of a double over a long", opcodes.DUP2_X2, []stackEntry{long, double}, []stackEntry{double, long, double}},
+		{"SWAP", opcodes.SWAP, []stackEntry{one, two}, []stackEntry{two, one}},
+	} {
+		f := interpretCode(t, []byte{test.opcode}, test.before...)
+		if entries := stackEntries(f); !reflect.DeepEqual(entries, test.after) {
+			t.Errorf("%s: expected the op stack %+v, got %+v", test.name, test.after, entries)
+		}
+	}
+}
+
+// a stack word bytecode that would split a long or double is an error
+func TestInterpretStackWordBytecodesSplittingLong(t *testing.T) {
+	globals.InitGlobals("test")
+	long := wideWord(int64(10))
+	for _, test := range []struct {
+		opcode byte
+		before []stackEntry
+	}{
+		{opcodes.POP2, []stackEntry{long, word(int64(1))}},
+		{opcodes.DUP_X2, []stackEntry{word(int64(1)), long}},
+		{opcodes.DUP2_X1, []stackEntry{long, word(int64(1))}},
+		{opcodes.DUP2_X2, []stackEntry{word(int64(1)), long, word(int64(2))}},
+		{opcodes.SWAP, []stackEntry{long, word(int64(1))}},
+	} {
+		f := newFrame(test.opcode)
+		pushEntries(&f, test.before)
+		name := opcodes.BytecodeNames[test.opcode]
+		if err := interpretFrame(&f); err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected an error splitting a long, got: %v", name, err)
+		}
+	}
+}
+
+// TABLESWITCH and LOOKUPSWITCH jump to the offset for the value on the op stack, or to the
+// default offset. Their operands begin at the first four-byte boundary after the opcode.
+func TestInterpretSwitches(t *testing.T) {
+	// each target pushes a value and returns
+	targets := func(code []byte) []byte {
+		return append(code, opcodes.BIPUSH, 10, opcodes.RETURN, opcodes.BIPUSH, 20, opcodes.RETURN,
+			opcodes.BIPUSH, 99, opcodes.RETURN)
+	}
+
+	// TABLESWITCH at 0, so 3 bytes of padding: default, low = 1, high = 2, and 2 offsets;
+	// the targets are at 24, 27, and 30
+	tableswitch := []byte{opcodes.TABLESWITCH, 0, 0, 0}
+	for _, operand := range []int32{30, 1, 2, 24, 27} {
+		tableswitch = append(tableswitch, int32Operand(operand)...)
+	}
+	tableswitch = targets(tableswitch)
+
+	// LOOKUPSWITCH at 1, so 2 bytes of padding: default, 2 pairs of key and offset from the
+	// LOOKUPSWITCH; the targets are at 28, 31, and 34, which are 27, 30, and 33 from it
+	lookupswitch := []byte{opcodes.NOP, opcodes.LOOKUPSWITCH, 0, 0}
+	for _, operand := range []int32{33, 2, -5, 27, 1000, 30} {
+		lookupswitch = append(lookupswitch, int32Operand(operand)...)
+	}
+	lookupswitch = targets(lookupswitch)
+
+	for _, test := range []struct {
+		name     string
+		code     []byte
+		key      int64
+		expected int64
+	}{
+		{"TABLESWITCH", tableswitch, 1, 10},
+		{"TABLESWITCH", tableswitch, 2, 20},
+		{"TABLESWITCH", tableswitch, 0, 99},
+		{"TABLESWITCH", tableswitch, 3, 99},
+		{"LOOKUPSWITCH", lookupswitch, -5, 10},
+		{"LOOKUPSWITCH", lookupswitch, 1000, 20},
+		{"LOOKUPSWITCH", lookupswitch, 0, 99},
+	} {
+		f := interpretCode(t, test.code, word(test.key))
+		if value := pop(f); value != test.expected {
+			t.Errorf("%s of %d: expected the target that pushes %d, got %v", test.name, test.key, test.expected, value)
+		}
+	}
+}
+
+// arrays of longs and doubles are stored to and loaded from via wide entries
+func TestInterpretArrayBytecodes(t *testing.T) {
+	globals.InitGlobals("test")
+	for _, test := range []struct {
+		name      string
+		arrayType byte
+		value     byte // the opcode that pushes the value to store
+		store     byte
+		load      byte
+		expected  stackEntry
+	}{
+		{"int", object.T_INT, opcodes.ICONST_5, opcodes.IASTORE, opcodes.IALOAD, word(int64(5))},
+		{"long", object.T_LONG, opcodes.LCONST_1, opcodes.LASTORE, opcodes.LALOAD, wideWord(int64(1))},
+		{"float", object.T_FLOAT, opcodes.FCONST_2, opcodes.FASTORE, opcodes.FALOAD, word(2.0)},
+		{"double", object.T_DOUBLE, opcodes.DCONST_1, opcodes.DASTORE, opcodes.DALOAD, wideWord(1.0)},
+		{"byte", object.T_BYTE, opcodes.ICONST_M1, opcodes.BASTORE, opcodes.BALOAD, word(int64(-1))},
+	} {
+		// new T[3], stores the value at index 2, then loads it and the array's length
+		f := interpretCode(t, []byte{opcodes.ICONST_3, opcodes.NEWARRAY, test.arrayType, opcodes.ASTORE_0,
+			opcodes.ALOAD_0, opcodes.ICONST_2, test.value, test.store,
+			opcodes.ALOAD_0, opcodes.ICONST_2, test.load,
+			opcodes.ALOAD_0, opcodes.ARRAYLENGTH})
+		expected := []stackEntry{test.expected, word(int64(3))}
+		if entries := stackEntries(f); !reflect.DeepEqual(entries, expected) {
+			t.Errorf("%s array: expected the op stack %+v, got %+v", test.name, expected, entries)
+		}
+	}
+
+	// arrays of references, with one dimension and with two
+	cp := newCPBuilder()
+	stringClass := cp.classRef("java/lang/String")
+	longArrayClass := cp.classRef("[[J")
+	f := newFrame(opcodes.ICONST_2)
+	f.CP = &cp.cp
+	f.Meth = append(f.Meth, opcodes.ANEWARRAY, byte(stringClass>>8), byte(stringClass),
+		opcodes.DUP, opcodes.ARRAYLENGTH, opcodes.SWAP, opcodes.ICONST_1, opcodes.AALOAD,
+		opcodes.ICONST_2, opcodes.ICONST_3, opcodes.MULTIANEWARRAY, byte(longArrayClass>>8), byte(longArrayClass), 2,
+		opcodes.ICONST_1, opcodes.AALOAD, opcodes.ARRAYLENGTH)
+	if err := interpretFrame(&f); err != nil {
+		t.Fatalf("ANEWARRAY: unexpected error: %s", err.Error())
+	}
+	if f.TOS != 2 || f.OpStack[0] != int64(2) || !object.IsNull(f.OpStack[1]) || f.OpStack[2] != int64(3) {
+		t.Errorf("ANEWARRAY, MULTIANEWARRAY: expected 2, null, and 3 on the op stack, got %v", f.OpStack[:f.TOS+1])
+	}
+}
+
+// array bytecodes throw exceptions, which in tests are returned as errors
+func TestInterpretArrayBytecodeErrors(t *testing.T) {
+	globals.InitGlobals("test")
+	for _, test := range []struct {
+		code     []byte
+		expected string
+	}{
+		{[]byte{opcodes.ICONST_M1, opcodes.NEWARRAY, object.T_INT}, "Invalid size"},
+		{[]byte{opcodes.ICONST_1, opcodes.NEWARRAY, object.T_LONG, opcodes.ICONST_1, opcodes.LALOAD}, "Invalid array subscript"},
+		{[]byte{opcodes.ACONST_NULL, opcodes.ARRAYLENGTH}, "null"},
+	} {
+		f := newFrame(test.code[0])
+		f.Meth = append(f.Meth, test.code[1:]...)
+		name := opcodes.BytecodeNames[test.code[len(test.code)-1]]
+		if err := interpretFrame(&f); err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected an error containing %q, got: %v", name, test.expected, err)
+		}
+	}
+}
+
+// the four invoke bytecodes pass longs and doubles, each of which is one entry on the op
+// stack, in two local variables, and return them to the caller as a single wide entry:
+//
+//	static double caller(Invoke o) { return o.scale(((Iface) o).mix(o.twice(add(1L, 2)), 1.0)); }
+func TestInterpretInvokes(t *testing.T) {
+	CP := invokeTestSetup()
+	cp := &cpBuilder{cp: *CP, entries: map[string]uint16{}}
+	add := cp.methodRef("test/Invoke", "add", "(JI)J")
+	twice := cp.methodRef("test/Invoke", "twice", "(J)J")
+	mix := cp.interfaceMethodRef("test/Iface", "mix", "(JD)D")
+	scale := cp.methodRef("test/Invoke", "scale", "(D)D")
+	*CP = cp.cp
+
+	addInvokeTestMethod("add", "(JI)J", CP, nil, opcodes.LLOAD_0, opcodes.ILOAD_2, opcodes.I2L, opcodes.LADD, opcodes.LRETURN)
+	addInvokeTestMethod("twice", "(J)J", CP, nil, opcodes.LLOAD_1, opcodes.LLOAD_1, opcodes.LADD, opcodes.LRETURN)
+	addInvokeTestMethod("mix", "(JD)D", CP, nil, opcodes.LLOAD_1, opcodes.L2D, opcodes.DLOAD_3, opcodes.DADD, opcodes.DRETURN)
+	addInvokeTestMethod("scale", "(D)D", CP, nil, opcodes.DLOAD_1, opcodes.DLOAD_1, opcodes.DADD, opcodes.DRETURN)
+	addInvokeTestMethod("caller", "(Ltest/Invoke;)D", CP, nil,
+		opcodes.ALOAD_0, opcodes.ALOAD_0, opcodes.ALOAD_0, opcodes.LCONST_1, opcodes.ICONST_2,
+		opcodes.INVOKESTATIC, byte(add>>8), byte(add), // 3L
+		opcodes.INVOKESPECIAL, byte(twice>>8), byte(twice), // 6L
+		opcodes.DCONST_1,
+		opcodes.INVOKEINTERFACE, byte(mix>>8), byte(mix), 5, 0, // 7.0
+		opcodes.INVOKEVIRTUAL, byte(scale>>8), byte(scale), // 14.0
+		opcodes.DRETURN)
+
+	// test/Invoke implements test/Iface
+	iface := "test/Iface"
+	k := classloader.MethAreaFetch("test/Invoke")
+	k.Data.Interfaces = []uint16{uint16(stringPool.GetStringIndex(&iface))}
+	k.Data.MethodTable = map[string]*classloader.Method{"mix(JD)D": {}}
+
+	className := "test/Invoke"
+	obj := object.MakeEmptyObjectWithClassName(&className)
+	ret, err := InvokeMethod(frames.CreateFrameStack(), className, "caller", "(Ltest/Invoke;)D", []any{obj})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if ret != 14.0 {
+		t.Errorf("expected a result of 14.0, got %v", ret)
+	}
+}
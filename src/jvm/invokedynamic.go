@@ -0,0 +1,241 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/log"
+	"jacobin/types"
+	"jacobin/util"
+	"sync"
+)
+
+// This file contains the linkage of INVOKEDYNAMIC call sites. The first time an
+// INVOKEDYNAMIC instruction is executed, its bootstrap method is looked up in the
+// BootstrapMethods attribute of the class and run. Rather than running the Java code
+// of the JDK's bootstrap methods (which rely on method handles and on spinning
+// classes with ASM), Jacobin implements the bootstrap methods natively: they're
+// registered in the bootstraps table below. A bootstrap method returns the target
+// of the call site--a function that is executed every time the instruction runs.
+//
+// As in HotSpot, each INVOKEDYNAMIC instruction is its own call site, so the linked
+// call site is cached for the instruction and the bootstrap method runs only once.
+
+// the reference kinds of method handles, per JVMS 5.4.3.5
+const (
+	refGetField         = 1
+	refGetStatic        = 2
+	refPutField         = 3
+	refPutStatic        = 4
+	refInvokeVirtual    = 5
+	refInvokeStatic     = 6
+	refInvokeSpecial    = 7
+	refNewInvokeSpecial = 8
+	refInvokeInterface  = 9
+)
+
+// methodHandle is a resolved CONSTANT_MethodHandle entry
+type methodHandle struct {
+	kind        int
+	className   string
+	name        string
+	desc        string
+	isInterface bool // the handle refers to an interface method
+}
+
+// methodTypeDesc is a resolved CONSTANT_MethodType entry: a method descriptor
+type methodTypeDesc string
+
+// classConstant is a resolved CONSTANT_Class entry: the name of the class
+type classConstant string
+
+// callSiteInfo holds everything a bootstrap method needs to link a call site
+type callSiteInfo struct {
+	caller     *classloader.Klass // the class containing the INVOKEDYNAMIC instruction
+	name       string             // the name given in the INVOKEDYNAMIC's NameAndType
+	desc       string             // the descriptor of the call site, also from the NameAndType
+	staticArgs []any              // the static arguments of the bootstrap method, resolved
+}
+
+// callSiteTarget is what a linked call site executes. It receives the arguments
+// that were popped off the operand stack (one entry per argument, including longs
+// and doubles) and returns the value to push, if any.
+type callSiteTarget func(fs *list.List, args []any) (any, error)
+
+type bootstrapFunc func(site *callSiteInfo) (callSiteTarget, error)
+
+// the natively implemented bootstrap methods, keyed by class and method name. The table
+// is filled in by init() because a bootstrap method's target can execute bytecodes, which
+// can link other call sites.
+var bootstraps map[string]bootstrapFunc
+
+func init() {
+	bootstraps = map[string]bootstrapFunc{
+		"java/lang/invoke/LambdaMetafactory.metafactory":    lambdaMetafactory,
+		"java/lang/invoke/LambdaMetafactory.altMetafactory": lambdaAltMetafactory,
+
+		"java/lang/invoke/StringConcatFactory.makeConcat":              makeConcat,
+		"java/lang/invoke/StringConcatFactory.makeConcatWithConstants": makeConcatWithConstants,
+
+		"java/lang/runtime/ObjectMethods.bootstrap": objectMethodsBootstrap,
+	}
+}
+
+// a linked call site
+type callSite struct {
+	desc   string // the descriptor of the call site
+	target callSiteTarget
+}
+
+// call sites are identified by the method containing the INVOKEDYNAMIC and the
+// instruction's location in that method's bytecode
+type callSiteKey struct {
+	method string
+	pc     int
+}
+
+var callSites sync.Map // callSiteKey -> *callSite
+
+// getCallSite returns the linked call site for the INVOKEDYNAMIC at f.PC, whose
+// operand is CPslot, running the bootstrap method if the call site isn't linked yet.
+func getCallSite(f *frames.Frame, CPslot int) (*callSite, error) {
+	key := callSiteKey{method: f.ClName + "." + f.MethName + f.MethType, pc: f.PC}
+	if site, ok := callSites.Load(key); ok {
+		return site.(*callSite), nil
+	}
+
+	site, err := linkCallSite(f, CPslot)
+	if err != nil {
+		return nil, err
+	}
+
+	// if two threads link the same call site at the same time, both use the first one stored
+	cached, _ := callSites.LoadOrStore(key, site)
+	return cached.(*callSite), nil
+}
+
+// linkCallSite resolves the INVOKEDYNAMIC's CP entry and its bootstrap method and
+// static arguments, and then runs the bootstrap method to get the call site's target.
+func linkCallSite(f *frames.Frame, CPslot int) (*callSite, error) {
+	CP := f.CP.(*classloader.CPool)
+	if CPslot < 1 || CPslot >= len(CP.CpIndex) || CP.CpIndex[CPslot].Type != classloader.InvokeDynamic {
+		return nil, fmt.Errorf("CP entry %d in %s is not an invokedynamic entry", CPslot, f.ClName)
+	}
+	indy := CP.InvokeDynamics[CP.CpIndex[CPslot].Slot]
+	nameAndType := CP.NameAndTypes[CP.CpIndex[indy.NameAndType].Slot]
+
+	caller := classloader.MethAreaFetch(f.ClName)
+	if caller == nil || caller.Data == nil {
+		return nil, fmt.Errorf("class %s is not loaded", f.ClName)
+	}
+	if int(indy.BootstrapIndex) >= len(caller.Data.Bootstraps) {
+		return nil, fmt.Errorf("bootstrap method %d not found in %s", indy.BootstrapIndex, f.ClName)
+	}
+	bsm := caller.Data.Bootstraps[indy.BootstrapIndex]
+
+	bsmHandle, err := resolveMethodHandle(CP, int(bsm.MethodRef))
+	if err != nil {
+		return nil, err
+	}
+
+	site := &callSiteInfo{
+		caller: caller,
+		name:   classloader.FetchUTF8stringFromCPEntryNumber(CP, nameAndType.NameIndex),
+		desc:   classloader.FetchUTF8stringFromCPEntryNumber(CP, nameAndType.DescIndex),
+	}
+	for _, arg := range bsm.Args {
+		staticArg, err := resolveBootstrapArg(CP, int(arg))
+		if err != nil {
+			return nil, err
+		}
+		site.staticArgs = append(site.staticArgs, staticArg)
+	}
+
+	bsmName := bsmHandle.className + "." + bsmHandle.name
+	bootstrap, ok := bootstraps[bsmName]
+	if !ok {
+		return nil, errors.New("unsupported bootstrap method: " + bsmName + bsmHandle.desc)
+	}
+
+	_ = log.Log(fmt.Sprintf("INVOKEDYNAMIC: linking %s%s in %s.%s via %s",
+		site.name, site.desc, f.ClName, f.MethName, bsmName), log.TRACE_INST)
+
+	target, err := bootstrap(site)
+	if err != nil {
+		return nil, err
+	}
+	return &callSite{desc: site.desc, target: target}, nil
+}
+
+// resolveMethodHandle resolves the CONSTANT_MethodHandle at cpIndex
+func resolveMethodHandle(CP *classloader.CPool, cpIndex int) (*methodHandle, error) {
+	if cpIndex < 1 || cpIndex >= len(CP.CpIndex) || CP.CpIndex[cpIndex].Type != classloader.MethodHandle {
+		return nil, fmt.Errorf("CP entry %d is not a method handle", cpIndex)
+	}
+	mh := CP.MethodHandles[CP.CpIndex[cpIndex].Slot]
+	className, name, desc := classloader.GetMemberInfoFromCPref(CP, int(mh.RefIndex))
+	if className == "" || name == "" {
+		return nil, fmt.Errorf("method handle at CP entry %d has an invalid reference", cpIndex)
+	}
+	return &methodHandle{
+		kind:        int(mh.RefKind),
+		className:   className,
+		name:        name,
+		desc:        desc,
+		isInterface: CP.CpIndex[mh.RefIndex].Type == classloader.Interface,
+	}, nil
+}
+
+// resolveBootstrapArg resolves a static argument of a bootstrap method, which
+// must be a loadable CP entry
+func resolveBootstrapArg(CP *classloader.CPool, cpIndex int) (any, error) {
+	if cpIndex < 1 || cpIndex >= len(CP.CpIndex) {
+		return nil, fmt.Errorf("invalid bootstrap argument: CP entry %d", cpIndex)
+	}
+
+	switch CP.CpIndex[cpIndex].Type {
+	case classloader.MethodHandle:
+		return resolveMethodHandle(CP, cpIndex)
+	case classloader.MethodType:
+		descIndex := CP.MethodTypes[CP.CpIndex[cpIndex].Slot]
+		return methodTypeDesc(classloader.FetchUTF8stringFromCPEntryNumber(CP, descIndex)), nil
+	case classloader.ClassRef:
+		return classConstant(classloader.GetClassNameFromCPclassref(CP, uint16(cpIndex))), nil
+	}
+
+	entry := classloader.FetchCPentry(CP, cpIndex)
+	switch entry.RetType {
+	case classloader.IS_INT64:
+		return entry.IntVal, nil
+	case classloader.IS_FLOAT64:
+		return entry.FloatVal, nil
+	case classloader.IS_STRING_ADDR:
+		return *entry.StringVal, nil
+	}
+	return nil, fmt.Errorf("unsupported bootstrap argument: CP entry %d of type %d",
+		cpIndex, CP.CpIndex[cpIndex].Type)
+}
+
+// popCallSiteArgs pops the arguments of a call site with the given descriptor off the
+// operand stack and returns them in order. Longs and doubles, which occupy two slots
+// on the operand stack if longsUseTwoSlots is set (as in runFrame()), occupy one entry
+// in the returned slice.
+func popCallSiteArgs(f *frames.Frame, desc string, longsUseTwoSlots bool) []any {
+	paramTypes := util.ParseIncomingParamsFromMethTypeString(desc)
+	args := make([]any, len(paramTypes))
+	for i := len(paramTypes) - 1; i >= 0; i-- {
+		args[i] = pop(f)
+		if longsUseTwoSlots && (paramTypes[i] == types.Long || paramTypes[i] == types.Double) {
+			pop(f)
+		}
+	}
+	return args
+}
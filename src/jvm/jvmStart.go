@@ -19,6 +19,7 @@ import (
 	"jacobin/thread"
 	"jacobin/types"
 	"os"
+	"strings"
 )
 
 var globPtr *globals.Globals
@@ -62,6 +63,10 @@ func JVMrun() int {
 	globPtr.FuncInstantiateClass = InstantiateClass
 	globPtr.FuncThrowException = exceptions.ThrowExNil
 	globPtr.FuncFillInStackTrace = gfunction.FillInStackTrace
+	globPtr.FuncStartThread = StartThread
+	globPtr.FuncInvokeMethod = InvokeMethod
+	globPtr.FuncRunShutdownHooks = gfunction.RunShutdownHooks
+	globPtr.FuncUncaughtEx = uncaughtException
 
 	_ = log.Log("running program: "+globPtr.JacobinName, log.FINE)
 
@@ -76,6 +81,11 @@ func JVMrun() int {
 	if err != nil {
 		return shutdown.Exit(shutdown.JVM_EXCEPTION)
 	}
+
+	// the system properties depend on the classpath and any -D options, so load them now
+	gfunction.InitSystemProperties()
+	showVMSettings(globPtr) // for -XshowSettings and -XX:+PrintFlagsFinal
+
 	// some CLI options, like -version, show data and immediately exit. This tests for that.
 	if globPtr.ExitNow == true {
 		return shutdown.Exit(shutdown.OK)
@@ -90,7 +100,7 @@ func JVMrun() int {
 
 	var mainClassNameIndex uint32
 	if globPtr.StartingJar != "" {
-		manifestClass, err := classloader.GetMainClassFromJar(classloader.BootstrapCL, globPtr.StartingJar)
+		manifestClass, err := classloader.GetMainClassFromJar(classloader.AppCL, globPtr.StartingJar)
 
 		if err != nil {
 			_ = log.Log(err.Error(), log.INFO)
@@ -101,15 +111,24 @@ func JVMrun() int {
 			_ = log.Log(fmt.Sprintf("no main manifest attribute, in %s", globPtr.StartingJar), log.INFO)
 			return shutdown.Exit(shutdown.APP_EXCEPTION)
 		}
-		mainClassNameIndex, _, err = classloader.LoadClassFromJar(classloader.BootstrapCL, manifestClass, globPtr.StartingJar)
+		mainClassNameIndex, _, err = classloader.LoadClassFromJar(classloader.AppCL, manifestClass, globPtr.StartingJar)
 		if err != nil { // the exceptions message will already have been shown to user
 			return shutdown.Exit(shutdown.JVM_EXCEPTION)
 		}
-	} else if globPtr.StartingClass != "" {
-		mainClassNameIndex, _, err = classloader.LoadClassFromFile(classloader.BootstrapCL, globPtr.StartingClass)
+	} else if strings.HasSuffix(globPtr.StartingClass, ".class") {
+		mainClassNameIndex, _, err = classloader.LoadClassFromFile(classloader.AppCL, globPtr.StartingClass)
 		if err != nil { // the exceptions message will already have been shown to user
 			return shutdown.Exit(shutdown.JVM_EXCEPTION)
 		}
+	} else if globPtr.StartingClass != "" { // a class name, so search the classpath for it
+		mainClassName := strings.ReplaceAll(globPtr.StartingClass, ".", "/")
+		mainClassNameIndex, _, err = classloader.LoadClassFromClasspath(classloader.AppCL, mainClassName)
+		if err != nil {
+			_ = log.Log(err.Error(), log.CLASS)
+			_ = log.Log(fmt.Sprintf("Error: Could not find or load main class %s\n"+
+				"Caused by: java.lang.ClassNotFoundException: %s", globPtr.StartingClass, globPtr.StartingClass), log.INFO)
+			return shutdown.Exit(shutdown.APP_EXCEPTION)
+		}
 	} else {
 		_ = log.Log("Error: No executable program specified. Exiting.", log.INFO)
 		ShowUsage(os.Stdout)
@@ -134,8 +153,13 @@ func JVMrun() int {
 
 	// create the main thread
 	MainThread = thread.CreateThread()
+	MainThread.Name = "main"
+	MainThread.Started = true
 	MainThread.AddThreadToTable(globPtr)
 
+	// from here on, SIGINT and SIGTERM run the shutdown hooks before exiting
+	shutdown.ExitOnSignals()
+
 	// begin execution
 	mainClass := stringPool.GetStringPointer(mainClassNameIndex)
 	_ = log.Log("Starting execution with: "+*mainClass, log.INFO)
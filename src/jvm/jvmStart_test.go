@@ -11,6 +11,7 @@ import (
 	"io"
 	"jacobin/globals"
 	"jacobin/log"
+	"jacobin/opcodes"
 	"os"
 	"path/filepath"
 	"strings"
@@ -108,3 +109,73 @@ func TestNoMainClassInJar(t *testing.T) {
 		t.Errorf("jvmRun() with a jar that has no manifest should have given no main manifest attribute error, got %s", errMsg)
 	}
 }
+
+// badMainClass is a version 52 class, Bad, whose main() method adds two ints
+// without pushing any operands
+var badMainClass = []byte{
+	0xCA, 0xFE, 0xBA, 0xBE, 0x00, 0x00, 0x00, 0x34, 0x00, 0x08, // magic, version 52, 7 CP entries
+	0x01, 0x00, 0x03, 'B', 'a', 'd', // 1: Utf8 Bad
+	0x07, 0x00, 0x01, // 2: Class Bad
+	0x01, 0x00, 0x10, 'j', 'a', 'v', 'a', '/', 'l', 'a', 'n', 'g', '/', 'O', 'b', 'j', 'e', 'c', 't',
+	0x07, 0x00, 0x03, // 4: Class java/lang/Object
+	0x01, 0x00, 0x04, 'm', 'a', 'i', 'n', // 5: Utf8 main
+	0x01, 0x00, 0x16, '(', '[', 'L', 'j', 'a', 'v', 'a', '/', 'l', 'a', 'n', 'g', '/',
+	'S', 't', 'r', 'i', 'n', 'g', ';', ')', 'V', // 6: Utf8 ([Ljava/lang/String;)V
+	0x01, 0x00, 0x04, 'C', 'o', 'd', 'e', // 7: Utf8 Code
+	0x00, 0x21, 0x00, 0x02, 0x00, 0x04, // public super, this = Bad, super = Object
+	0x00, 0x00, 0x00, 0x00, 0x00, 0x01, // no interfaces or fields, one method
+	0x00, 0x09, 0x00, 0x05, 0x00, 0x06, 0x00, 0x01, // public static main, one attribute
+	0x00, 0x07, 0x00, 0x00, 0x00, 0x0E, // Code, 14 bytes
+	0x00, 0x02, 0x00, 0x01, 0x00, 0x00, 0x00, 0x02, // max stack 2, max locals 1, 2 bytes of code
+	opcodes.IADD, opcodes.RETURN,
+	0x00, 0x00, 0x00, 0x00, // no exception handlers or attributes
+	0x00, 0x00, // no class attributes
+}
+
+// a malformed main class started from a .class file is verified at the default -Xverify level
+func TestMalformedMainClassThrowsVerifyError(t *testing.T) {
+	g := globals.GetGlobalRef()
+	globals.InitGlobals("test")
+	g.JacobinName = "test" // prevents a shutdown when the exception hits.
+	g.StartingClass = filepath.Join(t.TempDir(), "Bad.class")
+	g.StrictJDK = false
+
+	if err := os.WriteFile(g.StartingClass, badMainClass, 0644); err != nil {
+		t.Fatalf("Unable to write %s: %s", g.StartingClass, err.Error())
+	}
+
+	log.Init()
+	_ = log.SetLogLevel(log.INFO)
+
+	// redirect stderr & stdout to capture results from stderr
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	errC := make(chan string)
+
+	go func() {
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		errC <- buf.String()
+	}()
+
+	normalStdout := os.Stdout
+	_, wout, _ := os.Pipe()
+	os.Stdout = wout
+
+	JVMrun()
+
+	// restore stderr and stdout to what they were before
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	errMsg := <-errC
+
+	_ = wout.Close()
+	os.Stdout = normalStdout
+
+	if !strings.Contains(errMsg, "java.lang.VerifyError") || !strings.Contains(errMsg, "Bad.main") {
+		t.Errorf("jvmRun() with a malformed main class should have thrown VerifyError, got: %s", errMsg)
+	}
+}
@@ -0,0 +1,564 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/object"
+	"jacobin/opcodes"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// This file contains Jacobin's implementation of java.lang.invoke.LambdaMetafactory,
+// which javac uses as the bootstrap method for lambdas and method references.
+//
+// As in HotSpot, the metafactory "spins" a class for each lambda call site. The class
+// implements the functional interface: its one method (plus any bridge methods) loads
+// the captured arguments from the lambda object's fields, loads the method's own
+// arguments, converts them as needed (boxing, unboxing, widening), and invokes the
+// implementation method--generally a synthetic lambda$ method of the caller, or the
+// method named in a method reference. The bytecode of these methods is generated here,
+// along with a small constant pool to go with it. Because the lambda's method is an
+// ordinary Java method, it is invoked, traced, and unwound by exceptions in the same
+// way as every other method.
+//
+// The call site's target creates an instance of the spun class whose fields hold the
+// captured arguments. A lambda that captures nothing is a singleton.
+
+// the flags passed to altMetafactory()
+const (
+	lambdaFlagSerializable = 1
+	lambdaFlagMarkers      = 2
+	lambdaFlagBridges      = 4
+)
+
+// the number of lambda classes spun so far, used to give each one a unique name
+var lambdaClassCount atomic.Int64
+
+// the boxed equivalents of the primitive types
+var primitiveWrappers = map[string]string{
+	"Z": "java/lang/Boolean",
+	"B": "java/lang/Byte",
+	"C": "java/lang/Character",
+	"S": "java/lang/Short",
+	"I": "java/lang/Integer",
+	"J": "java/lang/Long",
+	"F": "java/lang/Float",
+	"D": "java/lang/Double",
+}
+
+// the specification of a lambda class, as passed to the metafactory
+type lambdaSpec struct {
+	samMethodType          string // the erased signature of the interface method
+	implMethod             *methodHandle
+	instantiatedMethodType string   // the signature of the interface method after generic specialization
+	markers                []string // additional interfaces the lambda implements
+	bridges                []string // additional signatures the lambda implements the interface method with
+}
+
+// "java/lang/invoke/LambdaMetafactory.metafactory" -- the static arguments are
+// the SAM method type, the implementation method handle, and the instantiated method type.
+func lambdaMetafactory(site *callSiteInfo) (callSiteTarget, error) {
+	spec, err := lambdaSpecFromArgs(site.staticArgs)
+	if err != nil {
+		return nil, err
+	}
+	return spinLambda(site, spec)
+}
+
+// "java/lang/invoke/LambdaMetafactory.altMetafactory" -- the first three static
+// arguments are as in metafactory(). They're followed by flags and, depending on
+// the flags, additional marker interfaces and bridge method types.
+func lambdaAltMetafactory(site *callSiteInfo) (callSiteTarget, error) {
+	spec, err := lambdaSpecFromArgs(site.staticArgs)
+	if err != nil {
+		return nil, err
+	}
+
+	args := site.staticArgs[3:]
+	nextInt := func() (int, error) {
+		if len(args) == 0 {
+			return 0, errors.New("altMetafactory: missing argument")
+		}
+		val, ok := args[0].(int64)
+		if !ok {
+			return 0, fmt.Errorf("altMetafactory: expected an int argument, got %T", args[0])
+		}
+		args = args[1:]
+		return int(val), nil
+	}
+
+	flags, err := nextInt()
+	if err != nil {
+		return nil, err
+	}
+
+	if flags&lambdaFlagSerializable != 0 {
+		spec.markers = append(spec.markers, "java/io/Serializable")
+	}
+
+	if flags&lambdaFlagMarkers != 0 {
+		count, err := nextInt()
+		if err != nil || count > len(args) {
+			return nil, errors.New("altMetafactory: invalid marker interfaces")
+		}
+		for _, arg := range args[:count] {
+			marker, ok := arg.(classConstant)
+			if !ok {
+				return nil, fmt.Errorf("altMetafactory: expected a marker interface, got %T", arg)
+			}
+			spec.markers = append(spec.markers, string(marker))
+		}
+		args = args[count:]
+	}
+
+	if flags&lambdaFlagBridges != 0 {
+		count, err := nextInt()
+		if err != nil || count > len(args) {
+			return nil, errors.New("altMetafactory: invalid bridge method types")
+		}
+		for _, arg := range args[:count] {
+			bridge, ok := arg.(methodTypeDesc)
+			if !ok {
+				return nil, fmt.Errorf("altMetafactory: expected a bridge method type, got %T", arg)
+			}
+			spec.bridges = append(spec.bridges, string(bridge))
+		}
+	}
+
+	return spinLambda(site, spec)
+}
+
+// lambdaSpecFromArgs extracts the three static arguments common to both metafactories
+func lambdaSpecFromArgs(args []any) (*lambdaSpec, error) {
+	if len(args) < 3 {
+		return nil, fmt.Errorf("LambdaMetafactory: expected 3 static arguments, got %d", len(args))
+	}
+	samType, ok1 := args[0].(methodTypeDesc)
+	impl, ok2 := args[1].(*methodHandle)
+	instantiatedType, ok3 := args[2].(methodTypeDesc)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("LambdaMetafactory: invalid static arguments: %T, %T, %T",
+			args[0], args[1], args[2])
+	}
+	return &lambdaSpec{
+		samMethodType:          string(samType),
+		implMethod:             impl,
+		instantiatedMethodType: string(instantiatedType),
+	}, nil
+}
+
+// spinLambda creates the lambda class for the call site and returns the call site's
+// target, which creates instances of that class.
+func spinLambda(site *callSiteInfo, spec *lambdaSpec) (callSiteTarget, error) {
+	capturedTypes, ifaceType, ok := util.ParseMethodDescriptor(site.desc)
+	if !ok || !strings.HasPrefix(ifaceType, "L") {
+		return nil, fmt.Errorf("LambdaMetafactory: invalid call site type %s", site.desc)
+	}
+	interfaceName := ifaceType[1 : len(ifaceType)-1]
+
+	callerName := site.caller.Data.Name
+	lambdaName := callerName + "$$Lambda$" + strconv.FormatInt(lambdaClassCount.Add(1), 10)
+
+	cp := newCPBuilder()
+	methods := make(map[string]*classloader.Method)
+	for i, methType := range append([]string{spec.samMethodType}, spec.bridges...) {
+		if _, dup := methods[site.name+methType]; dup {
+			continue
+		}
+		meth, err := genLambdaMethod(cp, lambdaName, capturedTypes, site.name, methType, spec)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			meth.AccessFlags |= 0x0040 | 0x1000 // bridge, synthetic
+		}
+		methods[site.name+methType] = meth
+	}
+
+	var interfaces []uint16
+	for _, iface := range append([]string{interfaceName}, spec.markers...) {
+		interfaces = append(interfaces, uint16(stringPool.GetStringIndex(&iface)))
+	}
+
+	klass := classloader.Klass{
+		Status: 'L',
+		Loader: site.caller.Loader,
+		Data: &classloader.ClData{
+			Name:            lambdaName,
+			NameIndex:       stringPool.GetStringIndex(&lambdaName),
+			SuperclassIndex: types.ObjectPoolStringIndex,
+			Module:          site.caller.Data.Module,
+			Pkg:             site.caller.Data.Pkg,
+			Interfaces:      interfaces,
+			MethodTable:     methods,
+			CP:              cp.cp,
+			Access: classloader.AccessFlags{
+				ClassIsFinal:     true,
+				ClassIsSuper:     true,
+				ClassIsSynthetic: true,
+			},
+			ClInit: types.NoClinit,
+		},
+	}
+	classloader.MethAreaInsert(lambdaName, &klass)
+
+	// the fields of the lambda object hold the captured arguments
+	newLambda := func(args []any) *object.Object {
+		obj := object.MakeEmptyObjectWithClassName(&lambdaName)
+		for i, arg := range args {
+			obj.FieldTable[capturedFieldName(i)] = object.Field{Ftype: fieldTypeFor(capturedTypes[i]), Fvalue: arg}
+		}
+		return obj
+	}
+
+	if len(capturedTypes) == 0 {
+		singleton := newLambda(nil)
+		return func(fs *list.List, args []any) (any, error) {
+			return singleton, nil
+		}, nil
+	}
+	return func(fs *list.List, args []any) (any, error) {
+		return newLambda(args), nil
+	}, nil
+}
+
+// the name of the field holding the i-th captured argument, as in HotSpot
+func capturedFieldName(i int) string {
+	return "arg$" + strconv.Itoa(i+1)
+}
+
+// the field type Jacobin uses for a value of the given type descriptor
+func fieldTypeFor(desc string) string {
+	if strings.HasPrefix(desc, "L") {
+		return types.Ref
+	}
+	return desc
+}
+
+// genLambdaMethod generates the method of the lambda class named methName with the
+// signature methType, which calls the implementation method with the captured
+// arguments followed by the method's own arguments.
+func genLambdaMethod(cp *cpBuilder, lambdaName string, capturedTypes []string,
+	methName, methType string, spec *lambdaSpec) (*classloader.Method, error) {
+
+	impl := spec.implMethod
+	methParams, methReturn, ok1 := util.ParseMethodDescriptor(methType)
+	instParams, instReturn, ok2 := util.ParseMethodDescriptor(spec.instantiatedMethodType)
+	implParams, implReturn, ok3 := util.ParseMethodDescriptor(impl.desc)
+	if !ok1 || !ok2 || !ok3 || len(instParams) != len(methParams) {
+		return nil, fmt.Errorf("LambdaMetafactory: invalid method types %s, %s, %s",
+			methType, spec.instantiatedMethodType, impl.desc)
+	}
+
+	// the types the implementation method receives. An instance method's receiver is the first.
+	targetTypes := implParams
+	switch impl.kind {
+	case refInvokeVirtual, refInvokeSpecial, refInvokeInterface:
+		targetTypes = append([]string{"L" + impl.className + ";"}, implParams...)
+	case refNewInvokeSpecial:
+		implReturn = "L" + impl.className + ";"
+	case refInvokeStatic:
+	default:
+		return nil, fmt.Errorf("LambdaMetafactory: unsupported method handle kind %d for %s.%s",
+			impl.kind, impl.className, impl.name)
+	}
+	if len(capturedTypes)+len(methParams) != len(targetTypes) {
+		return nil, fmt.Errorf("LambdaMetafactory: %s.%s%s cannot implement %s%s with %d captured arguments",
+			impl.className, impl.name, impl.desc, methName, methType, len(capturedTypes))
+	}
+
+	var code []byte
+	stack := 0 // the number of operand stack slots used by the arguments
+
+	if impl.kind == refNewInvokeSpecial {
+		code = append(code, opcodes.NEW)
+		code = appendU16(code, cp.classRef(impl.className))
+		code = append(code, opcodes.DUP)
+		stack += 2
+	}
+
+	// the captured arguments, which are in the fields of the lambda object
+	for i, capType := range capturedTypes {
+		code = append(code, opcodes.ALOAD_0, opcodes.GETFIELD)
+		code = appendU16(code, cp.fieldRef(lambdaName, capturedFieldName(i), capType))
+		var err error
+		if code, err = appendConversion(cp, code, capType, targetTypes[i]); err != nil {
+			return nil, err
+		}
+		stack += typeSlots(targetTypes[i])
+	}
+
+	// the method's own arguments
+	local := 1
+	for i, param := range methParams {
+		code = append(code, loadOpcode(param), byte(local))
+		local += typeSlots(param)
+
+		// the instantiated type is more specific than the erased one (e.g., Integer rather than
+		// Object), and it tells us how to unbox the argument, if need be.
+		from := param
+		if strings.HasPrefix(param, "L") && isWrapperType(instParams[i]) {
+			from = instParams[i]
+		}
+		to := targetTypes[len(capturedTypes)+i]
+		var err error
+		if code, err = appendConversion(cp, code, from, to); err != nil {
+			return nil, err
+		}
+		stack += typeSlots(to)
+	}
+
+	// invoke the implementation method
+	argSlots := 0
+	for _, p := range implParams {
+		argSlots += typeSlots(p)
+	}
+	switch impl.kind {
+	case refInvokeStatic:
+		code = append(code, opcodes.INVOKESTATIC)
+		code = appendU16(code, cp.methodRef(impl.className, impl.name, impl.desc))
+	case refInvokeVirtual:
+		code = append(code, opcodes.INVOKEVIRTUAL)
+		code = appendU16(code, cp.methodRef(impl.className, impl.name, impl.desc))
+	case refInvokeSpecial, refNewInvokeSpecial:
+		code = append(code, opcodes.INVOKESPECIAL)
+		code = appendU16(code, cp.methodRef(impl.className, impl.name, impl.desc))
+	case refInvokeInterface:
+		code = append(code, opcodes.INVOKEINTERFACE)
+		code = appendU16(code, cp.interfaceMethodRef(impl.className, impl.name, impl.desc))
+		code = append(code, byte(argSlots+1), 0)
+	}
+
+	// convert and return the result
+	switch {
+	case methReturn == "V" && implReturn != "V":
+		if typeSlots(implReturn) == 2 {
+			code = append(code, opcodes.POP2)
+		} else {
+			code = append(code, opcodes.POP)
+		}
+	case methReturn != "V" && implReturn == "V":
+		return nil, fmt.Errorf("LambdaMetafactory: %s.%s%s returns void, but %s%s does not",
+			impl.className, impl.name, impl.desc, methName, methType)
+	case methReturn != "V":
+		// a primitive result is boxed per the instantiated return type (e.g., Long
+		// rather than Object), which is a subtype of the erased return type
+		to := methReturn
+		if strings.HasPrefix(methReturn, "L") && isWrapperType(instReturn) {
+			to = instReturn
+		}
+		var err error
+		if code, err = appendConversion(cp, code, implReturn, to); err != nil {
+			return nil, err
+		}
+	}
+	code = append(code, returnOpcode(methReturn))
+
+	return &classloader.Method{
+		AccessFlags: 0x0001, // public
+		Name:        cp.utf8(methName),
+		Desc:        cp.utf8(methType),
+		CodeAttr: classloader.CodeAttrib{
+			MaxStack:  stack + 2, // +2 for a long or double result
+			MaxLocals: local,
+			Code:      code,
+		},
+	}, nil
+}
+
+// appendConversion appends the bytecodes that convert a value of type from to type to,
+// per the rules for the adaptation of the arguments of the implementation method
+// in the Javadoc of LambdaMetafactory. References are assumed to be of the right type.
+func appendConversion(cp *cpBuilder, code []byte, from, to string) ([]byte, error) {
+	fromPrimitive := !isReferenceType(from)
+	toPrimitive := !isReferenceType(to)
+
+	switch {
+	case !fromPrimitive && !toPrimitive:
+		return code, nil
+
+	case fromPrimitive && toPrimitive:
+		return appendWidening(code, from, to)
+
+	case fromPrimitive: // boxing
+		wrapper := primitiveWrappers[from]
+		code = append(code, opcodes.INVOKESTATIC)
+		return appendU16(code, cp.methodRef(wrapper, "valueOf", "("+from+")L"+wrapper+";")), nil
+
+	default: // unboxing, followed by widening if the wrapper is for a narrower type
+		unboxedType := to
+		wrapper := primitiveWrappers[to]
+		for prim, w := range primitiveWrappers {
+			if from == "L"+w+";" {
+				unboxedType, wrapper = prim, w
+			}
+		}
+		code = append(code, opcodes.GETFIELD)
+		code = appendU16(code, cp.fieldRef(wrapper, "value", unboxedType))
+		return appendWidening(code, unboxedType, to)
+	}
+}
+
+// appendWidening appends the bytecode for a widening primitive conversion
+func appendWidening(code []byte, from, to string) ([]byte, error) {
+	isIntLike := func(t string) bool { return t == "B" || t == "C" || t == "S" || t == "I" }
+	switch {
+	case from == to, isIntLike(from) && to == "I", from == "B" && to == "S":
+		return code, nil
+	case isIntLike(from) && to == "J":
+		return append(code, opcodes.I2L), nil
+	case isIntLike(from) && to == "F":
+		return append(code, opcodes.I2F), nil
+	case isIntLike(from) && to == "D":
+		return append(code, opcodes.I2D), nil
+	case from == "J" && to == "F":
+		return append(code, opcodes.L2F), nil
+	case from == "J" && to == "D":
+		return append(code, opcodes.L2D), nil
+	case from == "F" && to == "D":
+		return append(code, opcodes.F2D), nil
+	}
+	return nil, fmt.Errorf("LambdaMetafactory: type %s cannot be converted to %s", from, to)
+}
+
+func isReferenceType(desc string) bool {
+	return strings.HasPrefix(desc, "L") || strings.HasPrefix(desc, "[")
+}
+
+func isWrapperType(desc string) bool {
+	for _, w := range primitiveWrappers {
+		if desc == "L"+w+";" {
+			return true
+		}
+	}
+	return false
+}
+
+// the number of slots a value of the given type occupies in the locals and on the operand stack
+func typeSlots(desc string) int {
+	if desc == "J" || desc == "D" {
+		return 2
+	}
+	return 1
+}
+
+func loadOpcode(desc string) byte {
+	switch desc {
+	case "J":
+		return opcodes.LLOAD
+	case "F":
+		return opcodes.FLOAD
+	case "D":
+		return opcodes.DLOAD
+	case "B", "C", "I", "S", "Z":
+		return opcodes.ILOAD
+	}
+	return opcodes.ALOAD
+}
+
+func returnOpcode(desc string) byte {
+	switch desc {
+	case "V":
+		return opcodes.RETURN
+	case "J":
+		return opcodes.LRETURN
+	case "F":
+		return opcodes.FRETURN
+	case "D":
+		return opcodes.DRETURN
+	case "B", "C", "I", "S", "Z":
+		return opcodes.IRETURN
+	}
+	return opcodes.ARETURN
+}
+
+func appendU16(code []byte, val uint16) []byte {
+	return append(code, byte(val>>8), byte(val))
+}
+
+// cpBuilder builds the constant pool of a class that Jacobin generates
+type cpBuilder struct {
+	cp      classloader.CPool
+	entries map[string]uint16 // the CP entries already added, so that they're not duplicated
+}
+
+func newCPBuilder() *cpBuilder {
+	b := &cpBuilder{entries: make(map[string]uint16)}
+	b.cp.CpIndex = []classloader.CpEntry{{Type: classloader.Dummy, Slot: 0}}
+	return b
+}
+
+// add appends a CP entry of the given type unless an entry with the same key exists
+func (b *cpBuilder) add(key string, entryType uint16, addSlot func() int) uint16 {
+	if index, ok := b.entries[key]; ok {
+		return index
+	}
+	slot := addSlot()
+	b.cp.CpIndex = append(b.cp.CpIndex, classloader.CpEntry{Type: entryType, Slot: uint16(slot)})
+	index := uint16(len(b.cp.CpIndex) - 1)
+	b.entries[key] = index
+	return index
+}
+
+func (b *cpBuilder) utf8(s string) uint16 {
+	return b.add("utf8:"+s, classloader.UTF8, func() int {
+		b.cp.Utf8Refs = append(b.cp.Utf8Refs, s)
+		return len(b.cp.Utf8Refs) - 1
+	})
+}
+
+func (b *cpBuilder) classRef(name string) uint16 {
+	return b.add("class:"+name, classloader.ClassRef, func() int {
+		b.cp.ClassRefs = append(b.cp.ClassRefs, stringPool.GetStringIndex(&name))
+		return len(b.cp.ClassRefs) - 1
+	})
+}
+
+func (b *cpBuilder) nameAndType(name, desc string) uint16 {
+	nameIndex, descIndex := b.utf8(name), b.utf8(desc)
+	return b.add("nat:"+name+":"+desc, classloader.NameAndType, func() int {
+		b.cp.NameAndTypes = append(b.cp.NameAndTypes,
+			classloader.NameAndTypeEntry{NameIndex: nameIndex, DescIndex: descIndex})
+		return len(b.cp.NameAndTypes) - 1
+	})
+}
+
+func (b *cpBuilder) fieldRef(class, name, desc string) uint16 {
+	classIndex, natIndex := b.classRef(class), b.nameAndType(name, desc)
+	return b.add("field:"+class+"."+name+":"+desc, classloader.FieldRef, func() int {
+		b.cp.FieldRefs = append(b.cp.FieldRefs,
+			classloader.FieldRefEntry{ClassIndex: classIndex, NameAndType: natIndex})
+		return len(b.cp.FieldRefs) - 1
+	})
+}
+
+func (b *cpBuilder) methodRef(class, name, desc string) uint16 {
+	classIndex, natIndex := b.classRef(class), b.nameAndType(name, desc)
+	return b.add("method:"+class+"."+name+desc, classloader.MethodRef, func() int {
+		b.cp.MethodRefs = append(b.cp.MethodRefs,
+			classloader.MethodRefEntry{ClassIndex: classIndex, NameAndType: natIndex})
+		return len(b.cp.MethodRefs) - 1
+	})
+}
+
+func (b *cpBuilder) interfaceMethodRef(class, name, desc string) uint16 {
+	classIndex, natIndex := b.classRef(class), b.nameAndType(name, desc)
+	return b.add("imethod:"+class+"."+name+desc, classloader.Interface, func() int {
+		b.cp.InterfaceRefs = append(b.cp.InterfaceRefs,
+			classloader.InterfaceRefEntry{ClassIndex: classIndex, NameAndType: natIndex})
+		return len(b.cp.InterfaceRefs) - 1
+	})
+}
@@ -0,0 +1,357 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"math"
+	"strings"
+)
+
+// This file contains Jacobin's implementation of java.lang.runtime.ObjectMethods, which
+// javac (since Java 16) uses as the bootstrap method of the toString(), equals(), and
+// hashCode() methods of records: the body of each is an INVOKEDYNAMIC whose static
+// arguments are the record class, the names of the components separated by semicolons,
+// and a getter method handle for each component. The call site's target computes the
+// result from the record's fields directly, component by component, with the same
+// results as the JDK's:
+//
+//	toString(): Point[x=1, y=2]
+//	equals():   the other object is of the same class and the components are equal
+//	hashCode(): 31 * h + the hash code of the component, starting with h = 0
+//
+// A component's own toString(), equals(), or hashCode() is called via InvokeMethod, and
+// an exception that it throws is thrown by the INVOKEDYNAMIC.
+
+// the descriptors the three call sites must have, given the record class
+var objectMethodsDescs = map[string]string{
+	"toString": "(L%s;)Ljava/lang/String;",
+	"equals":   "(L%s;Ljava/lang/Object;)Z",
+	"hashCode": "(L%s;)I",
+}
+
+// a component of a record, as described by the bootstrap method's arguments
+type recordComponent struct {
+	name string
+	desc string // the field descriptor of the component
+}
+
+// "java/lang/runtime/ObjectMethods.bootstrap" -- the name of the call site gives the
+// method whose target is wanted.
+func objectMethodsBootstrap(site *callSiteInfo) (callSiteTarget, error) {
+	if len(site.staticArgs) < 2 {
+		return nil, fmt.Errorf("ObjectMethods.bootstrap: expected at least 2 static arguments, got %d",
+			len(site.staticArgs))
+	}
+	recordClass, ok := site.staticArgs[0].(classConstant)
+	if !ok {
+		return nil, fmt.Errorf("ObjectMethods.bootstrap: expected the record class, got %T", site.staticArgs[0])
+	}
+	names, ok := site.staticArgs[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("ObjectMethods.bootstrap: expected the component names, got %T", site.staticArgs[1])
+	}
+
+	getters := site.staticArgs[2:]
+	var nameList []string
+	if names != "" {
+		nameList = strings.Split(names, ";")
+	}
+	if len(nameList) != len(getters) {
+		return nil, fmt.Errorf("ObjectMethods.bootstrap: %d component names for %d getters in %s",
+			len(nameList), len(getters), recordClass)
+	}
+	components := make([]recordComponent, len(getters))
+	for i, arg := range getters {
+		getter, ok := arg.(*methodHandle)
+		if !ok || getter.kind != refGetField || getter.className != string(recordClass) {
+			return nil, fmt.Errorf("ObjectMethods.bootstrap: argument %d is not a getter of a field of %s",
+				i+2, recordClass)
+		}
+		components[i] = recordComponent{name: getter.name, desc: getter.desc}
+	}
+
+	descFormat, ok := objectMethodsDescs[site.name]
+	if !ok {
+		return nil, fmt.Errorf("ObjectMethods.bootstrap: unsupported method %s", site.name)
+	}
+	if site.desc != fmt.Sprintf(descFormat, recordClass) {
+		return nil, fmt.Errorf("ObjectMethods.bootstrap: invalid type %s for %s", site.desc, site.name)
+	}
+
+	switch site.name {
+	case "toString":
+		return recordToString(string(recordClass), nameList, components), nil
+	case "equals":
+		return recordEquals(string(recordClass), components), nil
+	default:
+		return recordHashCode(components), nil
+	}
+}
+
+// recordToString returns the target of toString(), which formats the components as
+// String.valueOf() does, e.g., Point[x=1, y=2]. The names shown are those passed to the
+// bootstrap method, which are the names of the components.
+func recordToString(className string, names []string, components []recordComponent) callSiteTarget {
+	prefix := recordSimpleName(className) + "["
+	return func(fs *list.List, args []any) (any, error) {
+		var sb strings.Builder
+		sb.WriteString(prefix)
+		for i, component := range components {
+			value, err := componentValue(args[0], component)
+			if err != nil {
+				return nil, err
+			}
+			str, err := javaValueToString(fs, value, component.desc)
+			if err != nil {
+				return nil, err
+			}
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(names[i] + "=" + str)
+		}
+		sb.WriteString("]")
+		return object.StringObjectFromGoString(sb.String()), nil
+	}
+}
+
+// recordEquals returns the target of equals(), which is true if the other object is of
+// the same record class and each of its components is equal to this record's
+func recordEquals(className string, components []recordComponent) callSiteTarget {
+	return func(fs *list.List, args []any) (any, error) {
+		if args[0] == args[1] {
+			return types.JavaBoolTrue, nil
+		}
+		other, ok := args[1].(*object.Object)
+		if !ok || object.IsNull(other) || *stringPool.GetStringPointer(other.KlassName) != className {
+			return types.JavaBoolFalse, nil
+		}
+		for _, component := range components {
+			v1, err := componentValue(args[0], component)
+			if err != nil {
+				return nil, err
+			}
+			v2, err := componentValue(other, component)
+			if err != nil {
+				return nil, err
+			}
+			equal, err := javaValuesEqual(fs, v1, v2, component.desc)
+			if err != nil || !equal {
+				return types.JavaBoolFalse, err
+			}
+		}
+		return types.JavaBoolTrue, nil
+	}
+}
+
+// recordHashCode returns the target of hashCode(), which combines the hash codes of the
+// components as 31 * h + c, in the order of the components
+func recordHashCode(components []recordComponent) callSiteTarget {
+	return func(fs *list.List, args []any) (any, error) {
+		hash := int32(0)
+		for _, component := range components {
+			value, err := componentValue(args[0], component)
+			if err != nil {
+				return nil, err
+			}
+			h, err := javaHashCode(fs, value, component.desc)
+			if err != nil {
+				return nil, err
+			}
+			hash = 31*hash + h
+		}
+		return int64(hash), nil
+	}
+}
+
+// componentValue fetches the value of a record's component from the component's field
+func componentValue(record any, component recordComponent) (any, error) {
+	obj, ok := record.(*object.Object)
+	if !ok || object.IsNull(obj) {
+		return nil, fmt.Errorf("ObjectMethods: expected a record, got %T", record)
+	}
+	field, ok := obj.FieldTable[component.name]
+	if !ok {
+		return nil, fmt.Errorf("ObjectMethods: field %s not found in %s",
+			component.name, *stringPool.GetStringPointer(obj.KlassName))
+	}
+	return field.Fvalue, nil
+}
+
+// recordSimpleName returns the simple name of the record class, which for a record that's
+// nested in another class is the name given in the InnerClasses attribute
+func recordSimpleName(className string) string {
+	if k := classloader.MethAreaFetch(className); k != nil && k.Data != nil {
+		for _, inner := range k.Data.InnerClasses {
+			if inner.Name == className && inner.SimpleName != "" {
+				return inner.SimpleName
+			}
+		}
+	}
+	return className[strings.LastIndex(className, "/")+1:]
+}
+
+// javaValuesEqual compares two values of the given type descriptor. Primitives are compared
+// by value, except that floats and doubles are compared as Float.compare() and
+// Double.compare() do, so that NaN equals NaN and 0.0 doesn't equal -0.0. Objects are
+// compared as Objects.equals() does.
+func javaValuesEqual(fs *list.List, v1, v2 any, desc string) (bool, error) {
+	switch desc {
+	case types.Bool, types.Byte, types.Char, types.Short, types.Int, types.Long:
+		return v1.(int64) == v2.(int64), nil
+	case types.Float, types.Double:
+		return floatBits(v1.(float64), desc) == floatBits(v2.(float64), desc), nil
+	}
+
+	if object.IsNull(v1) || object.IsNull(v2) {
+		return object.IsNull(v1) && object.IsNull(v2), nil
+	}
+	obj1, ok1 := v1.(*object.Object)
+	obj2, ok2 := v2.(*object.Object)
+	if !ok1 || !ok2 {
+		return false, fmt.Errorf("ObjectMethods: unexpected values of type %T and %T for %s", v1, v2, desc)
+	}
+	if obj1 == obj2 {
+		return true, nil
+	}
+
+	// strings and boxed primitives are compared by value
+	className := *stringPool.GetStringPointer(obj1.KlassName)
+	sameClass := className == *stringPool.GetStringPointer(obj2.KlassName)
+	if sameClass && className == types.StringClassName {
+		return object.GoStringFromStringObject(obj1) == object.GoStringFromStringObject(obj2), nil
+	}
+	for prim, wrapper := range primitiveWrappers {
+		if sameClass && className == wrapper {
+			f1, ok1 := obj1.FieldTable["value"]
+			f2, ok2 := obj2.FieldTable["value"]
+			if ok1 && ok2 {
+				return javaValuesEqual(fs, f1.Fvalue, f2.Fvalue, prim)
+			}
+		}
+	}
+
+	if overrider := overridingClass(className, "equals(Ljava/lang/Object;)Z"); overrider != "" {
+		return callEquals(fs, overrider, obj1, obj2)
+	}
+	return false, nil // Object.equals() compares identity
+}
+
+// javaHashCode returns the hash code of a value of the given type descriptor: that of the
+// boxed primitive for a primitive, and that of Objects.hashCode() for an object
+func javaHashCode(fs *list.List, value any, desc string) (int32, error) {
+	switch desc {
+	case types.Bool:
+		if value.(int64) != 0 {
+			return 1231, nil
+		}
+		return 1237, nil
+	case types.Byte, types.Char, types.Short, types.Int:
+		return int32(value.(int64)), nil
+	case types.Long:
+		v := value.(int64)
+		return int32(v ^ int64(uint64(v)>>32)), nil
+	case types.Float:
+		return int32(floatBits(value.(float64), desc)), nil
+	case types.Double:
+		bits := floatBits(value.(float64), desc)
+		return int32(bits ^ bits>>32), nil
+	}
+
+	if object.IsNull(value) {
+		return 0, nil
+	}
+	obj, ok := value.(*object.Object)
+	if !ok {
+		return 0, fmt.Errorf("ObjectMethods: unexpected value of type %T for %s", value, desc)
+	}
+
+	className := *stringPool.GetStringPointer(obj.KlassName)
+	if className == types.StringClassName {
+		hash := int32(0)
+		for _, ch := range object.GoStringFromStringObject(obj) {
+			hash = 31*hash + int32(ch)
+		}
+		return hash, nil
+	}
+	for prim, wrapper := range primitiveWrappers {
+		if className == wrapper {
+			if field, ok := obj.FieldTable["value"]; ok {
+				return javaHashCode(fs, field.Fvalue, prim)
+			}
+		}
+	}
+
+	if overrider := overridingClass(className, "hashCode()I"); overrider != "" {
+		ret, err := InvokeMethod(fs, overrider, "hashCode", "()I", []any{obj})
+		if err != nil {
+			return 0, err
+		}
+		hash, ok := ret.(int64)
+		if !ok {
+			return 0, fmt.Errorf("ObjectMethods: %s.hashCode() returned %T", overrider, ret)
+		}
+		return int32(hash), nil
+	}
+	return int32(obj.Mark.Hash), nil // the identity hash code, as from Object.hashCode()
+}
+
+// callEquals calls the equals() method that the class overrider declares
+func callEquals(fs *list.List, overrider string, obj, other *object.Object) (bool, error) {
+	ret, err := InvokeMethod(fs, overrider, "equals", "(Ljava/lang/Object;)Z", []any{obj, other})
+	if err != nil {
+		return false, err
+	}
+	result, ok := ret.(int64)
+	if !ok {
+		return false, fmt.Errorf("ObjectMethods: %s.equals() returned %T", overrider, ret)
+	}
+	return result != types.JavaBoolFalse, nil
+}
+
+// floatBits returns the bits of a float (if desc is F) or double, as Float.floatToIntBits()
+// and Double.doubleToLongBits() do: all NaNs have the same bits
+func floatBits(f float64, desc string) uint64 {
+	if desc == types.Float {
+		if math.IsNaN(f) {
+			return 0x7fc00000
+		}
+		return uint64(math.Float32bits(float32(f)))
+	}
+	if math.IsNaN(f) {
+		return 0x7ff8000000000000
+	}
+	return math.Float64bits(f)
+}
+
+// overridingClass returns the name of the class that declares the method (given by its
+// name and descriptor) that's called on an object of the named class, searching the class
+// and its superclasses other than Object. It returns "" if the method isn't overridden,
+// in which case the one in Object applies.
+func overridingClass(className, nameAndDesc string) string {
+	for cls := className; cls != "" && cls != types.ObjectClassName; {
+		_, found := classloader.MTableFetch(cls + "." + nameAndDesc)
+		k := classloader.MethAreaFetch(cls)
+		if !found && k != nil && k.Data != nil {
+			_, found = k.Data.MethodTable[nameAndDesc]
+		}
+		if found {
+			return cls
+		}
+		if k == nil || k.Data == nil {
+			break
+		}
+		cls = *stringPool.GetStringPointer(k.Data.SuperclassIndex)
+	}
+	return ""
+}
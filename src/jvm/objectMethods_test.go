@@ -0,0 +1,208 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"errors"
+	"jacobin/classloader"
+	"jacobin/exceptions"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/opcodes"
+	"jacobin/types"
+	"math"
+	"testing"
+)
+
+// the bootstrap arguments of the record Point(int x, String label, double weight)
+func pointBootstrapArgs() []any {
+	return []any{
+		classConstant("test/Point"),
+		"x;label;weight",
+		&methodHandle{kind: refGetField, className: "test/Point", name: "x", desc: "I"},
+		&methodHandle{kind: refGetField, className: "test/Point", name: "label", desc: "Ljava/lang/String;"},
+		&methodHandle{kind: refGetField, className: "test/Point", name: "weight", desc: "D"},
+	}
+}
+
+func makePoint(x int64, label any, weight float64) *object.Object {
+	className := "test/Point"
+	point := object.MakeEmptyObjectWithClassName(&className)
+	point.FieldTable["x"] = object.Field{Ftype: types.Int, Fvalue: x}
+	point.FieldTable["label"] = object.Field{Ftype: types.Ref, Fvalue: label}
+	point.FieldTable["weight"] = object.Field{Ftype: types.Double, Fvalue: weight}
+	return point
+}
+
+// objectMethodsTarget links a call site of ObjectMethods.bootstrap for the Point record
+func objectMethodsTarget(t *testing.T, name, desc string) callSiteTarget {
+	site := &callSiteInfo{name: name, desc: desc, staticArgs: pointBootstrapArgs()}
+	target, err := objectMethodsBootstrap(site)
+	if err != nil {
+		t.Fatalf("ObjectMethods.bootstrap(%s): unexpected error: %s", name, err.Error())
+	}
+	return target
+}
+
+func TestRecordToString(t *testing.T) {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+
+	target := objectMethodsTarget(t, "toString", "(Ltest/Point;)Ljava/lang/String;")
+	ret, err := target(frames.CreateFrameStack(), []any{makePoint(-3, object.StringObjectFromGoString("origin"), 1.5)})
+	if err != nil {
+		t.Fatalf("toString: unexpected error in target: %s", err.Error())
+	}
+	expected := "Point[x=-3, label=origin, weight=1.5]"
+	if str := object.GoStringFromStringObject(ret.(*object.Object)); str != expected {
+		t.Errorf("toString: expected %q, got %q", expected, str)
+	}
+
+	// a nested record is shown by its simple name
+	name := "test/Outer$Point"
+	classloader.MethAreaInsert(name, &classloader.Klass{Status: 'L', Loader: "test", Data: &classloader.ClData{
+		Name: name, InnerClasses: []classloader.InnerClass{{Name: name, OuterName: "test/Outer", SimpleName: "Point"}}}})
+	if simpleName := recordSimpleName(name); simpleName != "Point" {
+		t.Errorf("recordSimpleName: expected Point, got %s", simpleName)
+	}
+}
+
+func TestRecordEqualsAndHashCode(t *testing.T) {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+	fs := frames.CreateFrameStack()
+
+	equals := objectMethodsTarget(t, "equals", "(Ltest/Point;Ljava/lang/Object;)Z")
+	hashCode := objectMethodsTarget(t, "hashCode", "(Ltest/Point;)I")
+
+	p1 := makePoint(7, object.StringObjectFromGoString("a"), math.NaN())
+	p2 := makePoint(7, object.StringObjectFromGoString("a"), math.NaN())
+	p3 := makePoint(7, object.StringObjectFromGoString("a"), math.Copysign(0, -1))
+	p4 := makePoint(7, object.StringObjectFromGoString("a"), 0)
+	p5 := makePoint(7, object.Null, 0)
+	otherClass := "test/Other"
+
+	for _, test := range []struct {
+		other    any
+		expected int64
+	}{
+		{p1, types.JavaBoolTrue},
+		{p2, types.JavaBoolTrue}, // NaN equals NaN, as in Double.compare()
+		{p3, types.JavaBoolFalse},
+		{object.Null, types.JavaBoolFalse},
+		{object.MakeEmptyObjectWithClassName(&otherClass), types.JavaBoolFalse},
+	} {
+		ret, err := equals(fs, []any{p1, test.other})
+		if err != nil || ret != test.expected {
+			t.Errorf("equals: expected %d, got %v (error: %v)", test.expected, ret, err)
+		}
+	}
+	if ret, _ := equals(fs, []any{p3, p4}); ret != types.JavaBoolFalse {
+		t.Errorf("equals: expected -0.0 and 0.0 to differ")
+	}
+	if ret, _ := equals(fs, []any{p5, p4}); ret != types.JavaBoolFalse {
+		t.Errorf("equals: expected a null component to differ from a non-null one")
+	}
+	if ret, _ := equals(fs, []any{p5, makePoint(7, object.Null, 0)}); ret != types.JavaBoolTrue {
+		t.Errorf("equals: expected null components to be equal")
+	}
+
+	// the hash code is that of Objects.hash(x, label, weight) without the initial 1
+	h1, _ := hashCode(fs, []any{p1})
+	h2, _ := hashCode(fs, []any{p2})
+	if h1 != h2 {
+		t.Errorf("hashCode: expected equal records to have equal hash codes, got %v and %v", h1, h2)
+	}
+	bits := int64(0x7ff8000000000000)
+	expected := int64(int32(31*(31*7+int32('a')) + int32(bits^bits>>32)))
+	if h1 != expected {
+		t.Errorf("hashCode: expected %d, got %v", expected, h1)
+	}
+	if h5, _ := hashCode(fs, []any{p5}); h5 != int64(31*31*7) {
+		t.Errorf("hashCode: expected %d for a record with a null label and a weight of 0, got %v", 31*31*7, h5)
+	}
+}
+
+func TestObjectMethodsBootstrapErrors(t *testing.T) {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+
+	for _, site := range []*callSiteInfo{
+		{name: "toString", desc: "(Ltest/Point;)Ljava/lang/String;", staticArgs: pointBootstrapArgs()[:4]},
+		{name: "toString", desc: "(Ltest/Other;)Ljava/lang/String;", staticArgs: pointBootstrapArgs()},
+		{name: "equals", desc: "(Ltest/Point;)Z", staticArgs: pointBootstrapArgs()},
+		{name: "compareTo", desc: "(Ltest/Point;Ltest/Point;)I", staticArgs: pointBootstrapArgs()},
+		{name: "hashCode", desc: "(Ltest/Point;)I", staticArgs: []any{"test/Point", ""}},
+		{name: "hashCode", desc: "(Ltest/Point;)I", staticArgs: []any{classConstant("test/Point"), "x",
+			&methodHandle{kind: refInvokeVirtual, className: "test/Point", name: "x", desc: "()I"}}},
+	} {
+		if _, err := objectMethodsBootstrap(site); err == nil {
+			t.Errorf("ObjectMethods.bootstrap(%s%s): expected an error for arguments %v", site.name, site.desc, site.staticArgs)
+		}
+	}
+
+	// a record without components
+	site := &callSiteInfo{name: "toString", desc: "(Ltest/Empty;)Ljava/lang/String;",
+		staticArgs: []any{classConstant("test/Empty"), ""}}
+	target, err := objectMethodsBootstrap(site)
+	if err != nil {
+		t.Fatalf("ObjectMethods.bootstrap: unexpected error for a record without components: %s", err.Error())
+	}
+	className := "test/Empty"
+	ret, _ := target(frames.CreateFrameStack(), []any{object.MakeEmptyObjectWithClassName(&className)})
+	if str := object.GoStringFromStringObject(ret.(*object.Object)); str != "Empty[]" {
+		t.Errorf("toString: expected Empty[], got %q", str)
+	}
+}
+
+// an exception thrown by a component's equals() or hashCode() is returned by the target,
+// so that the INVOKEDYNAMIC throws it, for the record Holder(Object item)
+func TestRecordComponentMethodThrows(t *testing.T) {
+	CP := invokeTestSetup()
+	cp := &cpBuilder{cp: *CP, entries: map[string]uint16{}}
+	excField := cp.fieldRef("test/Invoke", "exc", "Ljava/lang/Object;")
+	*CP = cp.cp
+
+	// both methods of test/Invoke throw the exception in the object's exc field
+	throwExc := []byte{opcodes.ALOAD_0, opcodes.GETFIELD, byte(excField >> 8), byte(excField), opcodes.ATHROW}
+	addInvokeTestMethod("equals", "(Ljava/lang/Object;)Z", CP, nil, throwExc...)
+	addInvokeTestMethod("hashCode", "()I", CP, nil, throwExc...)
+
+	className := "test/Invoke"
+	item := object.MakeEmptyObjectWithClassName(&className)
+	excClass := "java/lang/IllegalStateException"
+	throwable := object.MakeEmptyObjectWithClassName(&excClass)
+	item.FieldTable["exc"] = object.Field{Ftype: types.Ref, Fvalue: throwable}
+
+	holderClass := "test/Holder"
+	holder := object.MakeEmptyObjectWithClassName(&holderClass)
+	holder.FieldTable["item"] = object.Field{Ftype: types.Ref, Fvalue: item}
+	other := object.MakeEmptyObjectWithClassName(&holderClass)
+	other.FieldTable["item"] = object.Field{Ftype: types.Ref, Fvalue: object.MakeEmptyObjectWithClassName(&className)}
+	staticArgs := []any{classConstant(holderClass), "item",
+		&methodHandle{kind: refGetField, className: holderClass, name: "item", desc: "Ljava/lang/Object;"}}
+
+	for _, test := range []struct {
+		name string
+		desc string
+		args []any
+	}{
+		{"equals", "(Ltest/Holder;Ljava/lang/Object;)Z", []any{holder, other}},
+		{"hashCode", "(Ltest/Holder;)I", []any{holder}},
+	} {
+		target, err := objectMethodsBootstrap(&callSiteInfo{name: test.name, desc: test.desc, staticArgs: staticArgs})
+		if err != nil {
+			t.Fatalf("ObjectMethods.bootstrap(%s): unexpected error: %s", test.name, err.Error())
+		}
+		_, err = target(frames.CreateFrameStack(), test.args)
+		var javaEx *exceptions.JavaException
+		if !errors.As(err, &javaEx) || javaEx.Throwable != throwable {
+			t.Errorf("%s: expected the exception thrown by the component's %s(), got: %v", test.name, test.name, err)
+		}
+	}
+}
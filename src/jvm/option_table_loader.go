@@ -9,12 +9,14 @@ package jvm
 import (
 	"errors"
 	"fmt"
+	"jacobin/classloader"
 	"jacobin/execdata"
 	"jacobin/globals"
 	"jacobin/log"
 	"jacobin/statics"
 	"jacobin/types"
 	"os"
+	"strings"
 )
 
 // This set of routines loads the globPtr.Options table with the various
@@ -68,6 +70,17 @@ func LoadOptionsTable(Global globals.Globals) {
 	Global.Options["-client"] = client
 	client.Set = true
 
+	classpath := globals.Option{true, false, 4, setClasspath}
+	Global.Options["-cp"] = classpath
+	Global.Options["-classpath"] = classpath
+	Global.Options["--class-path"] = classpath
+
+	defineProperty := globals.Option{true, false, 2, setSystemProperty}
+	Global.Options["-D"] = defineProperty
+
+	disableArgFiles := globals.Option{true, false, 0, disableArgFileExpansion}
+	Global.Options["--disable-@files"] = disableArgFiles
+
 	dryRun := globals.Option{false, false, 0, notSupported}
 	Global.Options["--dry-run"] = dryRun
 	dryRun.Set = true
@@ -90,6 +103,9 @@ func LoadOptionsTable(Global globals.Globals) {
 	newInterpreter := globals.Option{true, false, 0, newInterpeter}
 	Global.Options["-new"] = newInterpreter
 
+	oldInterpreter := globals.Option{true, false, 0, oldInterpeter}
+	Global.Options["-old"] = oldInterpreter
+
 	showversion := globals.Option{true, false, 0, showVersionStderr}
 	Global.Options["-showversion"] = showversion
 
@@ -110,6 +126,24 @@ func LoadOptionsTable(Global globals.Globals) {
 
 	vversion := globals.Option{true, false, 1, versionStdoutThenExit}
 	Global.Options["--version"] = vversion
+
+	verify := globals.Option{true, false, 1, verifyLevel}
+	Global.Options["-Xverify"] = verify
+
+	executionMode := globals.Option{true, false, 0, setExecutionMode}
+	Global.Options["-Xint"] = executionMode
+	Global.Options["-Xmixed"] = executionMode
+
+	xSize := globals.Option{true, false, 2, setXSizeOption}
+	Global.Options["-Xms"] = xSize
+	Global.Options["-Xmx"] = xSize
+	Global.Options["-Xss"] = xSize
+
+	showSettings := globals.Option{true, false, 1, setShowSettings}
+	Global.Options["-XshowSettings"] = showSettings
+
+	xxFlag := globals.Option{true, false, 1, setXXFlag}
+	Global.Options["-XX"] = xxFlag
 }
 
 // ---- the functions for the supported CLI options, in alphabetic order ----
@@ -122,12 +156,53 @@ func clientVM(pos int, name string, gl *globals.Globals) (int, error) {
 	return pos, nil
 }
 
+// for -cp, -classpath, and --class-path. The classpath is the next arg, except that
+// --class-path also accepts it after an =, and it replaces any classpath set by the
+// CLASSPATH environment variable
+func setClasspath(pos int, argValue string, gl *globals.Globals) (int, error) {
+	optionName, _, _ := getOptionRootAndArgs(gl.Args[pos])
+	if argValue == "" {
+		if len(gl.Args) <= pos+1 {
+			_, _ = fmt.Fprintf(os.Stderr, "Error: %s requires class path specification\n", optionName)
+			return pos, os.ErrInvalid
+		}
+		pos++
+		argValue = gl.Args[pos]
+	}
+	gl.Classpath = classloader.ParseClasspath(argValue)
+	log.Log("Classpath: "+argValue, log.FINE)
+	setOptionToSeen(optionName, gl)
+	return pos, nil
+}
+
+// for -D options, which are in the form -Dkey=value. If there's no =, the value is an
+// empty string. The property is set when the system properties are loaded, overriding
+// any built-in value.
+func setSystemProperty(pos int, argValue string, gl *globals.Globals) (int, error) {
+	key, value, _ := strings.Cut(argValue, "=")
+	if key == "" {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: -D requires a property name\n")
+		return pos, os.ErrInvalid
+	}
+	gl.SystemProperties[key] = value
+	setOptionToSeen("-D", gl)
+	return pos, nil
+}
+
+// for --disable-@files. The @files have already been expanded by HandleCli(), which
+// stops expanding them at this option, so there's nothing further to do.
+func disableArgFileExpansion(pos int, argValue string, gl *globals.Globals) (int, error) {
+	setOptionToSeen("--disable-@files", gl)
+	return pos, nil
+}
+
 // for -jar option. Get the next arg, which must be the JAR filename, and then all remaining args
-// are app args, which are duly added to globPtr.appArgs
+// are app args, which are duly added to globPtr.appArgs. The JAR file is then the entire classpath.
 func getJarFilename(pos int, name string, gl *globals.Globals) (int, error) {
 	setOptionToSeen("-jar", gl)
 	if len(gl.Args) > pos+1 {
 		gl.StartingJar = gl.Args[pos+1]
+		gl.Classpath = []string{gl.StartingJar}
 		log.Log("Starting with JAR file: "+gl.StartingJar, log.FINE)
 		for i := pos + 2; i < len(gl.Args); i++ {
 			gl.AppArgs = append(gl.AppArgs, gl.Args[i])
@@ -230,12 +305,37 @@ func verbosityLevel(pos int, argValue string, gl *globals.Globals) (int, error)
 	return pos, nil
 }
 
+// set which classes the bytecode verifier checks: none, those not loaded by the bootstrap
+// classloader (remote, which is the default), or all of them
+func verifyLevel(pos int, argValue string, gl *globals.Globals) (int, error) {
+	switch argValue {
+	case "none":
+		gl.VerifyLevel = globals.VerifyNone
+	case "remote":
+		gl.VerifyLevel = globals.VerifyRemote
+	case "all":
+		gl.VerifyLevel = globals.VerifyAll
+	default:
+		log.Log("Error: "+argValue+" is not a valid -Xverify option. Ignored.", log.WARNING)
+		return pos, errors.New("Invalid verification level specified: " + argValue)
+	}
+	setOptionToSeen("-Xverify", gl)
+	return pos, nil
+}
+
 func newInterpeter(pos int, name string, gl *globals.Globals) (int, error) {
 	gl.NewInterpreter = true
 	setOptionToSeen("-new", gl)
 	return pos, nil
 }
 
+// use the original, switch-based interpreter, runFrame(), which is the default
+func oldInterpeter(pos int, name string, gl *globals.Globals) (int, error) {
+	gl.NewInterpreter = false
+	setOptionToSeen("-old", gl)
+	return pos, nil
+}
+
 // Marks the given option as having been 'set' that is, specified on the command line
 func setOptionToSeen(optionKey string, gl *globals.Globals) {
 	o := gl.Options[optionKey]
@@ -136,25 +136,24 @@ func runThread(t *thread.ExecThread) error {
 	}()
 
 	for t.Stack.Len() > 0 {
+		var err error
 		if globals.GetGlobalRef().NewInterpreter {
-			interpret(t.Stack)
+			err = interpret(t.Stack)
 		} else {
-			err := runFrame(t.Stack)
-			if err != nil {
-				exceptions.ShowFrameStack(t)
-				if globals.GetGlobalRef().GoStackShown == false {
-					exceptions.ShowGoStackTrace(nil)
-					globals.GetGlobalRef().GoStackShown = true
-				}
-				return err
+			err = runFrame(t.Stack)
+		}
+		if err != nil {
+			exceptions.ShowFrameStack(t)
+			if globals.GetGlobalRef().GoStackShown == false {
+				exceptions.ShowGoStackTrace(nil)
+				globals.GetGlobalRef().GoStackShown = true
 			}
+			return err
 		}
 
-		if globals.GetGlobalRef().NewInterpreter {
-			if t.Stack.Len() == 0 { // true when the last executed frame was main()
-				return nil
-			}
-		} else {
+		// interpret() removes the frame of each method that returns, so the thread
+		// is done when the stack is empty. runFrame() leaves the frame for us to remove.
+		if !globals.GetGlobalRef().NewInterpreter {
 			if t.Stack.Len() == 1 { // true when the last executed frame was main()
 				return nil
 			} else {
@@ -177,6 +176,9 @@ frameInterpreter:
 	// the next statement converts the address of that frame to the more readable 'f'
 	f := fs.Front().Value.(*frames.Frame)
 	f.WideInEffect = false
+	if f.FrameStack == nil { // createAndInitNewFrame() reaches the frame stack via the frame
+		f.FrameStack = fs
+	}
 
 	// the frame's method is not a golang method, so it's Java bytecode, which
 	// is interpreted in the rest of this function.
@@ -188,7 +190,22 @@ frameInterpreter:
 
 		opcode := f.Meth[f.PC]
 		f.ExceptionPC = f.PC // in the event of an exception, here's where we were
-		switch opcode {      // cases listed in numerical value of opcode
+
+		// a synchronized method releases its monitor when it returns
+		if f.SyncObject != nil && opcode >= opcodes.IRETURN && opcode <= opcodes.RETURN {
+			if !exitSyncMethod(f) {
+				glob.ErrorGoStack = string(debug.Stack())
+				errMsg := fmt.Sprintf("%s: current thread is not the owner of the method's monitor",
+					opcodes.BytecodeNames[opcode])
+				status := exceptions.ThrowEx(excNames.IllegalMonitorStateException, errMsg, f)
+				if status != exceptions.Caught {
+					return errors.New(errMsg) // applies only if in test
+				}
+				goto frameInterpreter
+			}
+		}
+
+		switch opcode { // cases listed in numerical value of opcode
 		case opcodes.NOP:
 			break
 		case opcodes.ACONST_NULL: // 0x01   (push null onto opStack)
@@ -272,14 +289,16 @@ frameInterpreter:
 				}
 			}
 			// if no error
-			switch CPe.RetType {
-			case classloader.IS_INT64:
+			switch {
+			case CPe.EntryType == classloader.ClassRef: // a class constant is the class's Class object
+				push(f, classloader.ClassMirror(*CPe.StringVal))
+			case CPe.RetType == classloader.IS_INT64:
 				push(f, CPe.IntVal)
-			case classloader.IS_FLOAT64:
+			case CPe.RetType == classloader.IS_FLOAT64:
 				push(f, CPe.FloatVal)
-			case classloader.IS_STRUCT_ADDR:
+			case CPe.RetType == classloader.IS_STRUCT_ADDR:
 				push(f, CPe.AddrVal)
-			case classloader.IS_STRING_ADDR: // returns a string object whose "value" field is a byte array
+			case CPe.RetType == classloader.IS_STRING_ADDR: // returns a string object whose "value" field is a byte array
 				stringAddr := object.StringObjectFromGoString(*CPe.StringVal)
 				push(f, stringAddr)
 			}
@@ -1719,13 +1738,13 @@ frameInterpreter:
 			}
 
 			// was this static field previously loaded? Is so, get its location and move on.
-			prevLoaded, ok := statics.Statics[fieldName]
+			prevLoaded, ok := statics.QueryStatic(fieldName)
 			if !ok { // if field is not already loaded, then
 				// the class has not been instantiated, so
 				// instantiate the class
 				_, err := InstantiateClass(className, fs)
 				if err == nil {
-					prevLoaded, ok = statics.Statics[fieldName]
+					prevLoaded, ok = statics.QueryStatic(fieldName)
 				} else {
 					glob.ErrorGoStack = string(debug.Stack())
 					errMsg := fmt.Sprintf("GETSTATIC: could not load class %s", className)
@@ -1806,13 +1825,13 @@ frameInterpreter:
 			}
 
 			// was this static field previously loaded? Is so, get its location and move on.
-			prevLoaded, ok := statics.Statics[fieldName]
+			prevLoaded, ok := statics.QueryStatic(fieldName)
 			if !ok { // if field is not already loaded, then
 				// the class has not been instantiated, so
 				// instantiate the class
 				_, err := InstantiateClass(className, fs)
 				if err == nil {
-					prevLoaded, ok = statics.Statics[fieldName]
+					prevLoaded, ok = statics.QueryStatic(fieldName)
 				} else {
 					glob.ErrorGoStack = string(debug.Stack())
 					errMsg := fmt.Sprintf("PUTSTATIC: could not load class %s", className)
@@ -1837,16 +1856,16 @@ frameInterpreter:
 				// be stored as a boolean, a byte (in an array), or int64
 				// We want all forms normalized to int64
 				value = pop(f).(int64) & 0x01
-				statics.Statics[fieldName] = statics.Static{
+				_ = statics.AddStatic(fieldName, statics.Static{
 					Type:  prevLoaded.Type,
 					Value: value,
-				}
+				})
 			case types.Char, types.Short, types.Int, types.Long:
 				value = pop(f).(int64)
-				statics.Statics[fieldName] = statics.Static{
+				_ = statics.AddStatic(fieldName, statics.Static{
 					Type:  prevLoaded.Type,
 					Value: value,
-				}
+				})
 			case types.Byte:
 				var val byte
 				v := pop(f)
@@ -1857,16 +1876,16 @@ frameInterpreter:
 				case byte:
 					val = v.(byte)
 				}
-				statics.Statics[fieldName] = statics.Static{
+				_ = statics.AddStatic(fieldName, statics.Static{
 					Type:  prevLoaded.Type,
 					Value: val,
-				}
+				})
 			case types.Float, types.Double:
 				value = pop(f).(float64)
-				statics.Statics[fieldName] = statics.Static{
+				_ = statics.AddStatic(fieldName, statics.Static{
 					Type:  prevLoaded.Type,
 					Value: value,
-				}
+				})
 
 			default:
 				// if it's not a primitive or a pointer to a class,
@@ -1878,10 +1897,10 @@ frameInterpreter:
 				}
 				switch value.(type) {
 				case *object.Object:
-					statics.Statics[fieldName] = statics.Static{
+					_ = statics.AddStatic(fieldName, statics.Static{
 						Type:  prevLoaded.Type,
 						Value: value,
-					}
+					})
 				case *classloader.Klass:
 					// convert to an *object.Object
 					kPtr := value.(*classloader.Klass)
@@ -1894,10 +1913,10 @@ frameInterpreter:
 
 					obj.FieldTable[fieldName] = objField
 
-					statics.Statics[fieldName] = statics.Static{
+					_ = statics.AddStatic(fieldName, statics.Static{
 						Type:  objField.Ftype,
 						Value: value,
-					}
+					})
 				default:
 					glob.ErrorGoStack = string(debug.Stack())
 					errMsg := fmt.Sprintf("PUTSTATIC: field %s, type unrecognized: %v", fieldName, value)
@@ -2100,7 +2119,7 @@ frameInterpreter:
 			className, methodName, methodType :=
 				classloader.GetMethInfoFromCPmethref(CP, CPslot)
 
-			mtEntry := classloader.MTable[className+"."+methodName+methodType]
+			mtEntry, _ := classloader.MTableFetch(className + "." + methodName + methodType)
 			if mtEntry.Meth == nil { // if the method is not in the method table, find it
 				mtEntry, err = classloader.FetchMethodAndCP(className, methodName, methodType)
 				if err != nil || mtEntry.Meth == nil {
@@ -2169,14 +2188,14 @@ frameInterpreter:
 					}
 				}
 				fram, err := createAndInitNewFrame(
-					className, methodName, methodType, &m, true, f)
+					className, methodName, methodType, &m, true, f, true)
 				if err != nil {
 					glob.ErrorGoStack = string(debug.Stack())
-					errMsg := "INVOKEVIRTUAL: Error creating frame in: " + className + "." + methodName + methodType
-					status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, f)
-					if status != exceptions.Caught {
-						return errors.New(errMsg) // applies only if in test
+					which, errMsg := frameException(err, "INVOKEVIRTUAL", className+"."+methodName+methodType)
+					if exceptions.ThrowEx(which, errMsg, f) != exceptions.Caught {
+						return err // applies only if in test
 					}
+					goto frameInterpreter
 				}
 
 				f.PC += 1                            // move to next bytecode before exiting
@@ -2256,14 +2275,14 @@ frameInterpreter:
 						return errors.New(errMsg) // applies only if in test
 					}
 				}
-				fram, err := createAndInitNewFrame(className, methodName, methodType, &m, true, f)
+				fram, err := createAndInitNewFrame(className, methodName, methodType, &m, true, f, true)
 				if err != nil {
 					glob.ErrorGoStack = string(debug.Stack())
-					errMsg := "INVOKESPECIAL: Error creating frame in: " + className + "." + methodName + methodType
-					status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, f)
-					if status != exceptions.Caught {
-						return errors.New(errMsg) // applies only if in test
+					which, errMsg := frameException(err, "INVOKESPECIAL", className+"."+methodName+methodType)
+					if exceptions.ThrowEx(which, errMsg, f) != exceptions.Caught {
+						return err // applies only if in test
 					}
+					goto frameInterpreter
 				}
 
 				f.PC += 1                            // point to the next bytecode for when we return from the invoked method.
@@ -2347,14 +2366,14 @@ frameInterpreter:
 					}
 				}
 				fram, err := createAndInitNewFrame(
-					className, methodName, methodType, &m, false, f)
+					className, methodName, methodType, &m, false, f, true)
 				if err != nil {
 					glob.ErrorGoStack = string(debug.Stack())
-					errMsg := "INVOKESTATIC: Error creating frame in: " + className + "." + methodName + methodType
-					status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, f)
-					if status != exceptions.Caught {
-						return errors.New(errMsg) // applies only if in test
+					which, errMsg := frameException(err, "INVOKESTATIC", className+"."+methodName+methodType)
+					if exceptions.ThrowEx(which, errMsg, f) != exceptions.Caught {
+						return err // applies only if in test
 					}
+					goto frameInterpreter
 				}
 
 				f.PC += 2                            // 2 == initial PC advance in this bytecode (see above)
@@ -2425,12 +2444,15 @@ frameInterpreter:
 				}
 			}
 
-			// get the name of the objectRef's class, and make sure it's loaded
+			// get the name of the objectRef's class, and make sure it's loaded. (The class
+			// might not have a class file--e.g., if it's a lambda class--so don't reload it.)
 			objRefClassName := *(stringPool.GetStringPointer(objRef.(*object.Object).KlassName))
-			if err := classloader.LoadClassFromNameOnly(objRefClassName); err != nil {
-				// in this case, LoadClassFromNameOnly() will have already thrown the exception
-				if globals.JacobinHome() == "test" {
-					return err // applies only if in test
+			if classloader.MethAreaFetch(objRefClassName) == nil {
+				if err := classloader.LoadClassFromNameOnly(objRefClassName); err != nil {
+					// in this case, LoadClassFromNameOnly() will have already thrown the exception
+					if globals.JacobinHome() == "test" {
+						return err // applies only if in test
+					}
 				}
 			}
 
@@ -2456,15 +2478,15 @@ frameInterpreter:
 			if mtEntry.MType == 'J' {
 				entry := mtEntry.Meth.(classloader.JmEntry)
 				fram, err := createAndInitNewFrame(
-					clData.Name, interfaceMethodName, interfaceMethodType, &entry, true, f)
+					clData.Name, interfaceMethodName, interfaceMethodType, &entry, true, f, true)
 				if err != nil {
 					glob.ErrorGoStack = string(debug.Stack())
-					errMsg := "INVOKEINTERFACE: Error creating frame in: " + clData.Name + "." +
-						interfaceMethodName + interfaceMethodType
-					status := exceptions.ThrowEx(excNames.InvalidStackFrameException, errMsg, f)
-					if status != exceptions.Caught {
-						return errors.New(errMsg) // applies only if in test
+					which, errMsg := frameException(err, "INVOKEINTERFACE",
+						clData.Name+"."+interfaceMethodName+interfaceMethodType)
+					if exceptions.ThrowEx(which, errMsg, f) != exceptions.Caught {
+						return err // applies only if in test
 					}
+					goto frameInterpreter
 				}
 
 				f.PC += 1                            // to point to the next bytecode before exiting
@@ -2478,6 +2500,7 @@ frameInterpreter:
 				for i := 0; i < paramCount; i++ {
 					params = append(params, pop(f))
 				}
+				params = append(params, pop(f)) // the objectRef
 
 				ret := gfunction.RunGfunction(mtEntry, fs, interfaceName, interfaceMethodName, interfaceMethodType, &params, true, MainThread.Trace)
 				if ret != nil {
@@ -2500,6 +2523,51 @@ frameInterpreter:
 				}
 			}
 
+		case opcodes.INVOKEDYNAMIC: // 0xBA invoke the target of a dynamically computed call site
+			CPslot := (int(f.Meth[f.PC+1]) * 256) + int(f.Meth[f.PC+2]) // next 2 bytes point to CP entry
+			// the two bytes after that must be zero
+
+			// the call site is linked the first time the instruction executes; see invokedynamic.go
+			site, err := getCallSite(f, CPslot)
+			f.PC += 4
+			if err != nil {
+				glob.ErrorGoStack = string(debug.Stack())
+				errMsg := fmt.Sprintf("INVOKEDYNAMIC: call site in %s.%s%s could not be linked: %s",
+					f.ClName, f.MethName, f.MethType, err.Error())
+				status := exceptions.ThrowEx(excNames.BootstrapMethodError, errMsg, f)
+				if status != exceptions.Caught {
+					return errors.New(errMsg) // applies only if in test
+				}
+				goto frameInterpreter
+			}
+
+			args := popCallSiteArgs(f, site.desc, true)
+			ret, err := site.target(fs, args)
+			var javaEx *exceptions.JavaException
+			if errors.As(err, &javaEx) { // Java code called by the target threw an exception
+				if exceptions.Throw(javaEx.Throwable, f) != exceptions.Caught {
+					return err // applies only if in test
+				}
+				goto frameInterpreter
+			}
+			if err != nil {
+				glob.ErrorGoStack = string(debug.Stack())
+				errMsg := fmt.Sprintf("INVOKEDYNAMIC: error in call site in %s.%s%s: %s",
+					f.ClName, f.MethName, f.MethType, err.Error())
+				status := exceptions.ThrowEx(excNames.BootstrapMethodError, errMsg, f)
+				if status != exceptions.Caught {
+					return errors.New(errMsg) // applies only if in test
+				}
+				goto frameInterpreter
+			}
+
+			if !strings.HasSuffix(site.desc, ")V") {
+				push(f, ret)
+				if strings.HasSuffix(site.desc, ")D") || strings.HasSuffix(site.desc, ")J") {
+					push(f, ret) // push twice if long or double
+				}
+			}
+
 		case opcodes.NEW: // 0xBB 	new: create and instantiate a new object
 			CPslot := (int(f.Meth[f.PC+1]) * 256) + int(f.Meth[f.PC+2]) // next 2 bytes point to CP entry
 			f.PC += 2
@@ -2521,6 +2589,15 @@ frameInterpreter:
 				className = *stringPool.GetStringPointer(nameStringPoolIndex)
 			}
 
+			if !object.HeapHasRoom(instanceSize(className)) {
+				object.ThrowingOutOfMemoryError(true) // leave room for the exception
+				status := exceptions.ThrowEx(excNames.OutOfMemoryError, "Java heap space", f)
+				object.ThrowingOutOfMemoryError(false)
+				if status != exceptions.Caught {
+					return errors.New("OutOfMemoryError: Java heap space") // applies only if in test
+				}
+				goto frameInterpreter
+			}
 			ref, err := InstantiateClass(className, fs)
 			if err != nil {
 				glob.ErrorGoStack = string(debug.Stack())
@@ -2556,6 +2633,15 @@ frameInterpreter:
 				}
 			}
 
+			if !object.HeapHasRoom(object.ArraySize(uint8(actualType), size)) {
+				object.ThrowingOutOfMemoryError(true) // leave room for the exception
+				status := exceptions.ThrowEx(excNames.OutOfMemoryError, "Java heap space", f)
+				object.ThrowingOutOfMemoryError(false)
+				if status != exceptions.Caught {
+					return errors.New("OutOfMemoryError: Java heap space") // applies only if in test
+				}
+				goto frameInterpreter
+			}
 			arrayPtr := object.Make1DimArray(uint8(actualType), size)
 			g := globals.GetGlobalRef()
 			g.ArrayAddressList.PushFront(arrayPtr)
@@ -2591,6 +2677,15 @@ frameInterpreter:
 				refTypeName = *stringPool.GetStringPointer(refNameStringPoolIndex)
 			}
 
+			if !object.HeapHasRoom(object.ArraySize(object.REF, size)) {
+				object.ThrowingOutOfMemoryError(true) // leave room for the exception
+				status := exceptions.ThrowEx(excNames.OutOfMemoryError, "Java heap space", f)
+				object.ThrowingOutOfMemoryError(false)
+				if status != exceptions.Caught {
+					return errors.New("OutOfMemoryError: Java heap space") // applies only if in test
+				}
+				goto frameInterpreter
+			}
 			arrayPtr := object.Make1DimRefArray(&refTypeName, size)
 			g := globals.GetGlobalRef()
 			g.ArrayAddressList.PushFront(arrayPtr)
@@ -2662,115 +2757,10 @@ frameInterpreter:
 				}
 			}
 
-			// capture the golang stack
-			stack := string(debug.Stack())
-			glob.ErrorGoStack = stack
-
-			// capture the JVM frame stack
-			glob.JVMframeStack = exceptions.GrabFrameStack(fs)
-
-			// get the name of the exception in the format used by HotSpot
-			exceptionClass := *(stringPool.GetStringPointer(objectRef.KlassName))
-			exceptionName := strings.Replace(exceptionClass, "/", ".", -1)
-
-			// get the PC of the exception and check for any catch blocks
-			// if f.ExceptionPC == -1 {
-			//	f.ExceptionPC = f.PC
-			// }
-
-			// find the frame with a valid catch block for this exception, if any
-			catchFrame, handlerBytecode := exceptions.FindCatchFrame(fs, exceptionName, f.ExceptionPC)
-			// if there is no catch block, then print out the data we have (conforming
-			// with whether we want the standard JDK info as elected with the -strictJDK
-			// command-line option)
-			if catchFrame == nil {
-				// if the exception is not caught, then print the data from the stackTraceElements (STEs)
-				// in the Throwable object or subclass (which is generally the specific exception class).
-
-				// start by printing out the name of the exception/error and the thread it occurred on
-				msg := ""
-				if f.Thread == 1 { // if it's thread #1, use its name, "main"
-					msg = fmt.Sprintf("Exception in thread \"main\" %s", exceptionName)
-				} else {
-					msg = fmt.Sprintf("Exception in thread %d %s", f.Thread, exceptionName)
-				}
-
-				appMsg := objectRef.FieldTable["detailMessage"].Fvalue
-				if appMsg != nil {
-					switch appMsg.(type) {
-					case []uint8:
-						st := appMsg.([]uint8)
-						msg += fmt.Sprintf(": %s", string(st))
-					case *object.Object:
-						st := appMsg.(*object.Object)
-						value := st.FieldTable["value"].Fvalue
-						switch value.(type) {
-						case []byte:
-							msg += fmt.Sprintf(": %s", string(st.FieldTable["value"].Fvalue.([]byte)))
-						case uint32:
-							str := stringPool.GetStringPointer(value.(uint32))
-							msg += fmt.Sprintf(": %s", *str)
-						}
-
-					}
-				}
-				_ = log.Log(msg, log.SEVERE)
-
-				steArrayPtr := objectRef.FieldTable["stackTrace"].Fvalue.(*object.Object)
-				rawSteArray := steArrayPtr.FieldTable["value"].Fvalue.([]*object.Object) // []*object.Object (each of which is an STE)
-				for i := 0; i < len(rawSteArray); i++ {
-					ste := rawSteArray[i]
-					methodName := ste.FieldTable["methodName"].Fvalue.(string)
-					if methodName == "<init>" { // don't show constructors
-						continue
-					}
-					rawClassName := ste.FieldTable["declaringClass"].Fvalue.(string)
-					if rawClassName == "java/lang/Throwable" { // don't show Throwable methods
-						continue
-					}
-					className := strings.Replace(rawClassName, "/", ".", -1)
-
-					sourceLine := ste.FieldTable["sourceLine"].Fvalue.(string)
-
-					var s string
-					if sourceLine != "" {
-						s = fmt.Sprintf("\tat %s.%s(%s:%s)", className,
-							methodName, ste.FieldTable["fileName"].Fvalue, sourceLine)
-					} else {
-						s = fmt.Sprintf("\tat %s.%s(%s)", className,
-							methodName, ste.FieldTable["fileName"].Fvalue)
-					}
-					_ = log.Log(s, log.SEVERE)
-				}
-
-				// show Jacobin's JVM stack info if -strictJDK is not set
-				if glob.StrictJDK == false {
-					_ = log.Log(" ", log.SEVERE)
-					for _, frameData := range *glob.JVMframeStack {
-						colon := strings.Index(frameData, ":")
-						shortenedFrameData := frameData[colon+1:]
-						_ = log.Log("\tat"+shortenedFrameData, log.SEVERE)
-					}
-				}
-
-				// all exceptions that got this far are untrapped, so shutdown with an error code
-				shutdown.Exit(shutdown.APP_EXCEPTION)
-
-			} else { // perform the catch operation. We know the frame and the starting bytecode for the handler
-				for fr := fs.Front(); fr != nil; fr = fr.Next() {
-					var frm = fr.Value.(*frames.Frame)
-					// f.ExceptionTable = &m.Exceptions
-					if frm == catchFrame {
-						// frm.Meth = f.Meth[handlerBytecode:]
-						frm.TOS = -1
-						push(frm, objectRef)
-						// frm.PC = 0
-						frm.PC = handlerBytecode
-						// make the frame with the catch block active
-						fs.Front().Value = frm
-						goto frameInterpreter
-					}
-				}
+			// the exception is either caught, in which case the handler's frame is now at the
+			// top of the frame stack, or uncaught, in which case throwException() shuts down
+			if throwException(f, fs, objectRef) {
+				goto frameInterpreter
 			}
 		case opcodes.CHECKCAST: // 0xC0 same as INSTANCEOF but does nothing on null,
 			// and doesn't change the stack if the cast is legal.
@@ -3027,8 +3017,39 @@ frameInterpreter:
 				}
 			}
 
-		case opcodes.MONITORENTER, opcodes.MONITOREXIT: // OxC2 and OxC3. These  are not implemented in the JDK JVM
-			_ = pop(f) // so just pop off the reference on the stack
+		case opcodes.MONITORENTER: // OxC2 acquire the monitor of the object on the stack
+			ref := pop(f)
+			if object.IsNull(ref) {
+				glob.ErrorGoStack = string(debug.Stack())
+				errMsg := "MONITORENTER: Invalid (null) reference"
+				status := exceptions.ThrowEx(excNames.NullPointerException, errMsg, f)
+				if status != exceptions.Caught {
+					return errors.New(errMsg) // applies only if in test
+				}
+				goto frameInterpreter
+			}
+			object.MonitorEnter(ref.(*object.Object), f.Thread)
+
+		case opcodes.MONITOREXIT: // OxC3 release the monitor of the object on the stack
+			ref := pop(f)
+			if object.IsNull(ref) {
+				glob.ErrorGoStack = string(debug.Stack())
+				errMsg := "MONITOREXIT: Invalid (null) reference"
+				status := exceptions.ThrowEx(excNames.NullPointerException, errMsg, f)
+				if status != exceptions.Caught {
+					return errors.New(errMsg) // applies only if in test
+				}
+				goto frameInterpreter
+			}
+			if !object.MonitorExit(ref.(*object.Object), f.Thread) {
+				glob.ErrorGoStack = string(debug.Stack())
+				errMsg := "MONITOREXIT: current thread is not the owner of the monitor"
+				status := exceptions.ThrowEx(excNames.IllegalMonitorStateException, errMsg, f)
+				if status != exceptions.Caught {
+					return errors.New(errMsg) // applies only if in test
+				}
+				goto frameInterpreter
+			}
 
 		case opcodes.WIDE: // 0xC4 Make some bytecodes operate on larger sized operands
 			// https://docs.oracle.com/javase/specs/jvms/se17/html/jvms-6.html#jvms-6.5.wide
@@ -3119,6 +3140,16 @@ frameInterpreter:
 			// Because of the possibility of a zero-sized dimension
 			// affecting the valid number of dimensions, dimensionCount
 			// can no longer be considered reliable. Use len(dimSizes).
+			if !object.HeapHasRoom(object.MultiArraySize(arrayType, dimSizes)) {
+				object.ThrowingOutOfMemoryError(true) // leave room for the exception
+				status := exceptions.ThrowEx(excNames.OutOfMemoryError, "Java heap space", f)
+				object.ThrowingOutOfMemoryError(false)
+				if status != exceptions.Caught {
+					return errors.New("OutOfMemoryError: Java heap space") // applies only if in test
+				}
+				goto frameInterpreter
+			}
+
 			if len(dimSizes) == 3 {
 				multiArr := object.Make1DimArray(object.REF, dimSizes[0])
 				actualArray := multiArr.FieldTable["value"].Fvalue.([]*object.Object)
@@ -3212,12 +3243,19 @@ func subtract[N frames.Number](num1, num2 N) N {
 // that in addition to the method parameter, an object reference is also on
 // the stack and needs to be popped off the caller's opStack and passed in.
 // (This would be the case for invokevirtual, among others.) When false, no
-// object pointer is needed (for invokestatic, among others).
+// object pointer is needed (for invokestatic, among others). The longsUseTwoSlots
+// parameter is true when longs and doubles occupy two slots on the caller's opStack,
+// as they do in runFrame(), and false when they occupy one, as in interpret(). Either
+// way, they occupy two local variables in the new frame, per the JVM spec.
+//
+// If the frame stack is already at the depth allowed by -Xss, no frame is created and
+// errFrameStackFull is returned, for which the caller throws a StackOverflowError.
 func createAndInitNewFrame(
 	className string, methodName string, methodType string,
 	m *classloader.JmEntry,
 	includeObjectRef bool,
-	currFrame *frames.Frame) (*frames.Frame, error) {
+	currFrame *frames.Frame,
+	longsUseTwoSlots bool) (*frames.Frame, error) {
 
 	if MainThread.Trace {
 		traceInfo := fmt.Sprintf("\tcreateAndInitNewFrame: class=%s, meth=%s%s, includeObjectRef=%v, maxStack=%d, maxLocals=%d",
@@ -3225,6 +3263,10 @@ func createAndInitNewFrame(
 		_ = log.Log(traceInfo, log.TRACE_INST)
 	}
 
+	if currFrame.FrameStack != nil && frameStackIsFull(currFrame.FrameStack) {
+		return nil, errFrameStackFull
+	}
+
 	f := currFrame
 
 	stackSize := m.MaxStack + types.StackInflator // Experimental addition, see JACOBIN-494
@@ -3302,7 +3344,9 @@ func createAndInitNewFrame(
 			arg := pop(f).(float64)
 			argList = append(argList, arg)
 			argList = append(argList, arg)
-			pop(f)
+			if longsUseTwoSlots {
+				pop(f)
+			}
 		case 'F': // float
 			arg := pop(f).(float64)
 			argList = append(argList, arg)
@@ -3317,7 +3361,9 @@ func createAndInitNewFrame(
 			arg := pop(f).(int64)
 			argList = append(argList, arg)
 			argList = append(argList, arg)
-			pop(f)
+			if longsUseTwoSlots {
+				pop(f)
+			}
 		case 'L': // pointer/reference
 			arg := pop(f) // can't be *Object b/c the arg could be nil, which would panic
 			argList = append(argList, arg)
@@ -3366,5 +3412,15 @@ func createAndInitNewFrame(
 
 	fram.TOS = -1
 
+	// a synchronized method acquires the monitor of its object (or of its class, if
+	// the method is static) before it begins to execute
+	if m.AccessFlags&0x0020 > 0 {
+		if includeObjectRef {
+			enterSyncMethod(fram, fram.Locals[0])
+		} else {
+			enterSyncMethod(fram, syncObjectForClass(className))
+		}
+	}
+
 	return fram, nil
 }
@@ -7,6 +7,7 @@
 package jvm
 
 import (
+	"container/list"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -18,7 +19,9 @@ import (
 	"jacobin/log"
 	"jacobin/object"
 	"jacobin/opcodes"
+	"jacobin/shutdown"
 	"jacobin/stringPool"
+	"jacobin/thread"
 	"jacobin/types"
 	"jacobin/util"
 	"math"
@@ -405,6 +408,9 @@ func push(f *frames.Frame, x interface{}) {
 	// the actual push
 	f.TOS += 1
 	f.OpStack[f.TOS] = x
+	if f.OpStackWide != nil { // see pushWide() in interpreter.go
+		f.OpStackWide[f.TOS] = false
+	}
 	if MainThread.Trace {
 		LogTraceStack(f)
 	} // trace the resultant stack
@@ -670,3 +676,109 @@ verifyInterfaceMethod:
 
 	return mtEntry, nil
 }
+
+// syncObjectForClass returns the object whose monitor a static synchronized method
+// of the named class acquires. As in HotSpot, it's the class's Class object.
+func syncObjectForClass(className string) *object.Object {
+	return classloader.ClassMirror(className)
+}
+
+// enterSyncMethod acquires the monitor of obj for the synchronized method
+// running in frame f. The monitor is released by exitSyncMethod().
+func enterSyncMethod(f *frames.Frame, obj any) {
+	syncObj, ok := obj.(*object.Object)
+	if !ok || object.IsNull(syncObj) {
+		return
+	}
+	object.MonitorEnter(syncObj, f.Thread)
+	f.SyncObject = syncObj
+}
+
+// exitSyncMethod releases the monitor held by the synchronized method running in
+// frame f, if any. This is done when the method returns or when the frame is
+// discarded due to an exception. Returns false if the thread no longer owns the monitor.
+func exitSyncMethod(f *frames.Frame) bool {
+	if f.SyncObject == nil {
+		return true
+	}
+	syncObj := f.SyncObject.(*object.Object)
+	f.SyncObject = nil
+	return object.MonitorExit(syncObj, f.Thread)
+}
+
+// throwException throws the exception or error objectRef, as the ATHROW bytecode does.
+// If a frame in fs has a handler for it, the frames above that frame are discarded, the
+// exception is pushed onto the handler frame's op stack, and the frame's PC is set to the
+// handler; throwException then returns true. It also returns true if the exception is
+// passed back to a gfunction that called the throwing method (see InvokeMethod). Otherwise,
+// the exception is passed to the thread's uncaught exception handler.
+func throwException(f *frames.Frame, fs *list.List, objectRef *object.Object) bool {
+	glob := globals.GetGlobalRef()
+
+	// capture the golang stack
+	stack := string(debug.Stack())
+	glob.ErrorGoStack = stack
+
+	// capture the JVM frame stack
+	glob.JVMframeStack = exceptions.GrabFrameStack(fs)
+
+	// get the name of the exception in the format used by HotSpot
+	exceptionClass := *(stringPool.GetStringPointer(objectRef.KlassName))
+	exceptionName := strings.Replace(exceptionClass, "/", ".", -1)
+
+	// get the PC of the exception and check for any catch blocks
+	// if f.ExceptionPC == -1 {
+	//	f.ExceptionPC = f.PC
+	// }
+
+	// find the frame with a valid catch block for this exception, if any
+	catchFrame, handlerBytecode := exceptions.FindCatchFrame(fs, exceptionName, f.ExceptionPC)
+	// if there is no catch block, then print out the data we have (conforming
+	// with whether we want the standard JDK info as elected with the -strictJDK
+	// command-line option)
+	if catchFrame == nil {
+		// an exception thrown in a method called from a gfunction goes back to the gfunction,
+		// which resumes when the interpreter returns to the call's base frame
+		if exceptions.UnwindToBaseFrame(fs, objectRef) {
+			return true
+		}
+
+		// show Jacobin's JVM stack info if -strictJDK is not set
+		showJVMframeStack := func() {
+			if glob.StrictJDK == false {
+				_ = log.Log(" ", log.SEVERE)
+				for _, frameData := range *glob.JVMframeStack {
+					colon := strings.Index(frameData, ":")
+					shortenedFrameData := frameData[colon+1:]
+					_ = log.Log("\tat"+shortenedFrameData, log.SEVERE)
+				}
+			}
+		}
+
+		// if the exception is not caught, it's passed to the thread's uncaught exception
+		// handler, and the thread ends. This doesn't return, except in tests.
+		if th := thread.GetThread(f.Thread); th != nil && glob.JacobinName != "test" {
+			uncaughtException(th, objectRef, showJVMframeStack)
+			return false
+		}
+
+		// print the name of the thread followed by the stack trace, including the stack
+		// traces of any suppressed exceptions and causes, just as printStackTrace() does
+		trace, _ := exceptions.StackTraceString(fs, objectRef)
+		msg := fmt.Sprintf("Exception in thread \"%s\" %s", thread.GetThreadName(f.Thread),
+			strings.TrimSuffix(trace, "\n"))
+		_ = log.Log(msg, log.SEVERE)
+		showJVMframeStack()
+
+		shutdown.Exit(shutdown.APP_EXCEPTION)
+		return false // applies only if in test
+	}
+
+	// perform the catch operation. We know the frame and the starting bytecode for the handler.
+	// Discard the frames above the catch frame, making the frame with the catch block active
+	exceptions.UnwindToCatchFrame(fs, catchFrame)
+	catchFrame.TOS = -1
+	push(catchFrame, objectRef)
+	catchFrame.PC = handlerBytecode
+	return true
+}
@@ -17,6 +17,7 @@ import (
 	"jacobin/types"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -675,3 +676,210 @@ func TestInvokevirtualInvalid(t *testing.T) {
 	_ = w.Close()
 	os.Stderr = normalStderr
 }
+
+// sets up a class, jacobin/test/Lambdas, whose constant pool holds an invokedynamic entry that
+// is linked by LambdaMetafactory.metafactory(), with the given call site name and type, SAM
+// method type, and implementation method (a static method in jacobin/test/Object, which is one
+// of the test gfunctions). Returns a frame whose code consists of that INVOKEDYNAMIC.
+func setupLambdaCallSite(t *testing.T, siteName, siteType, samType, implDesc string) *frames.Frame {
+	globals.InitGlobals("test")
+	if err := classloader.Init(); err != nil {
+		t.Fatalf("Failure to load classes in %s", t.Name())
+	}
+	gfunction.CheckTestGfunctionsLoaded()
+	callSites = sync.Map{} // every test's call site is at the same location in the same method
+
+	// the class holding the implementation method must be loaded and initialized
+	testClass := "jacobin/test/Object"
+	classloader.MethAreaInsert(testClass, &classloader.Klass{
+		Status: 'X',
+		Loader: "bootstrap",
+		Data: &classloader.ClData{
+			Name:            testClass,
+			NameIndex:       stringPool.GetStringIndex(&testClass),
+			SuperclassIndex: types.ObjectPoolStringIndex,
+			ClInit:          types.ClInitRun,
+		},
+	})
+
+	cp := newCPBuilder()
+	bsmRef := cp.methodRef("java/lang/invoke/LambdaMetafactory", "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)"+
+			"Ljava/lang/invoke/CallSite;")
+	implRef := cp.methodRef(testClass, "test", implDesc)
+	siteNAT := cp.nameAndType(siteName, siteType)
+	samDesc := cp.utf8(samType)
+
+	CP := &cp.cp
+	addEntry := func(entryType uint16, slot int) uint16 {
+		CP.CpIndex = append(CP.CpIndex, classloader.CpEntry{Type: entryType, Slot: uint16(slot)})
+		return uint16(len(CP.CpIndex) - 1)
+	}
+	CP.MethodHandles = append(CP.MethodHandles,
+		classloader.MethodHandleEntry{RefKind: refInvokeStatic, RefIndex: bsmRef},
+		classloader.MethodHandleEntry{RefKind: refInvokeStatic, RefIndex: implRef})
+	bsmHandle := addEntry(classloader.MethodHandle, 0)
+	implHandle := addEntry(classloader.MethodHandle, 1)
+	CP.MethodTypes = append(CP.MethodTypes, samDesc)
+	samMethodType := addEntry(classloader.MethodType, 0)
+	CP.InvokeDynamics = append(CP.InvokeDynamics,
+		classloader.InvokeDynamicEntry{BootstrapIndex: 0, NameAndType: siteNAT})
+	indy := addEntry(classloader.InvokeDynamic, 0)
+
+	callerName := "jacobin/test/Lambdas"
+	caller := classloader.Klass{
+		Status: 'X',
+		Loader: "bootstrap",
+		Data: &classloader.ClData{
+			Name:            callerName,
+			NameIndex:       stringPool.GetStringIndex(&callerName),
+			SuperclassIndex: types.ObjectPoolStringIndex,
+			Bootstraps: []classloader.BootstrapMethod{{
+				MethodRef: bsmHandle,
+				Args:      []uint16{samMethodType, implHandle, samMethodType},
+			}},
+			CP:     *CP,
+			ClInit: types.ClInitRun,
+		},
+	}
+	classloader.MethAreaInsert(callerName, &caller)
+
+	f := newFrame(opcodes.INVOKEDYNAMIC)
+	f.Meth = append(f.Meth, byte(indy>>8), byte(indy), 0x00, 0x00)
+	f.ClName = callerName
+	f.MethName = "main"
+	f.MethType = "([Ljava/lang/String;)V"
+	f.CP = &caller.Data.CP
+	return &f
+}
+
+// INVOKEDYNAMIC: a lambda that captures nothing is linked once and is a singleton
+func TestInvokeDynamicLambdaNoCapture(t *testing.T) {
+	f := setupLambdaCallSite(t, "applyAsInt", "()Ljava/util/function/IntUnaryOperator;", "(I)I", "(I)I")
+	fs := frames.CreateFrameStack()
+	fs.PushFront(f)
+
+	if err := runFrame(fs); err != nil {
+		t.Fatalf("INVOKEDYNAMIC: Got unexpected error: %s", err.Error())
+	}
+	if f.TOS != 0 {
+		t.Fatalf("INVOKEDYNAMIC: Expecting TOS to be 0, got %d", f.TOS)
+	}
+	lambda := f.OpStack[0].(*object.Object)
+	lambdaClassName := *stringPool.GetStringPointer(lambda.KlassName)
+	if !strings.HasPrefix(lambdaClassName, "jacobin/test/Lambdas$$Lambda$") {
+		t.Errorf("INVOKEDYNAMIC: Unexpected lambda class name: %s", lambdaClassName)
+	}
+
+	lambdaClass := classloader.MethAreaFetch(lambdaClassName)
+	if lambdaClass == nil {
+		t.Fatalf("INVOKEDYNAMIC: Lambda class %s is not in the method area", lambdaClassName)
+	}
+	iface := *stringPool.GetStringPointer(uint32(lambdaClass.Data.Interfaces[0]))
+	if iface != "java/util/function/IntUnaryOperator" {
+		t.Errorf("INVOKEDYNAMIC: Expected lambda class to implement IntUnaryOperator, got %s", iface)
+	}
+
+	// calling the interface method calls the implementation method, test(I)I, which returns 43
+	ret, err := InvokeMethod(fs, lambdaClassName, "applyAsInt", "(I)I", []any{lambda, int64(5)})
+	if err != nil || ret != int64(43) {
+		t.Errorf("INVOKEDYNAMIC: Expected the lambda to return 43, got %v (err: %v)", ret, err)
+	}
+
+	// executing the instruction again uses the linked call site, which returns the same lambda
+	f.PC = 0
+	f.TOS = -1
+	if err = runFrame(fs); err != nil {
+		t.Fatalf("INVOKEDYNAMIC: Got unexpected error on second execution: %s", err.Error())
+	}
+	if f.OpStack[0] != lambda {
+		t.Errorf("INVOKEDYNAMIC: Expected the same lambda object from a non-capturing call site")
+	}
+}
+
+// INVOKEDYNAMIC: the arguments of a capturing lambda are stored in the lambda object
+// and passed to the implementation method
+func TestInvokeDynamicLambdaCapture(t *testing.T) {
+	f := setupLambdaCallSite(t, "getAsInt", "(Ljava/lang/Object;)Ljava/util/function/IntSupplier;",
+		"()I", "(Ljava/lang/Object;)I")
+	captured := object.MakeEmptyObject()
+	push(f, captured)
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(f)
+	if err := runFrame(fs); err != nil {
+		t.Fatalf("INVOKEDYNAMIC: Got unexpected error: %s", err.Error())
+	}
+	if f.TOS != 0 {
+		t.Fatalf("INVOKEDYNAMIC: Expecting TOS to be 0, got %d", f.TOS)
+	}
+	lambda := f.OpStack[0].(*object.Object)
+	if lambda.FieldTable["arg$1"].Fvalue != captured {
+		t.Errorf("INVOKEDYNAMIC: Expected the captured argument in field arg$1, got %v",
+			lambda.FieldTable["arg$1"].Fvalue)
+	}
+
+	lambdaClassName := *stringPool.GetStringPointer(lambda.KlassName)
+	ret, err := InvokeMethod(fs, lambdaClassName, "getAsInt", "()I", []any{lambda})
+	if err != nil || ret != 44 {
+		t.Errorf("INVOKEDYNAMIC: Expected the lambda to return 44, got %v (err: %v)", ret, err)
+	}
+}
+
+// INVOKEDYNAMIC: an unknown bootstrap method results in a BootstrapMethodError
+func TestInvokeDynamicUnknownBootstrap(t *testing.T) {
+	f := setupLambdaCallSite(t, "run", "()Ljava/lang/Runnable;", "()V", "()V")
+	CP := f.CP.(*classloader.CPool)
+	CP.ClassRefs[0] = stringPool.GetStringIndex(&types.ObjectClassName) // the bootstrap method's class
+
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(f)
+	err := runFrame(fs)
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if err == nil || !strings.Contains(err.Error(), "unsupported bootstrap method: java/lang/Object.metafactory") {
+		t.Errorf("INVOKEDYNAMIC: Expected an error for an unknown bootstrap method, got %v", err)
+	}
+}
+
+// the method generated for a lambda boxes and unboxes values as needed
+func TestLambdaMethodBoxing(t *testing.T) {
+	globals.InitGlobals("test")
+	cp := newCPBuilder()
+	spec := &lambdaSpec{
+		samMethodType:          "(Ljava/lang/Object;)Ljava/lang/Object;",
+		implMethod:             &methodHandle{kind: refInvokeStatic, className: "T", name: "square", desc: "(J)J"},
+		instantiatedMethodType: "(Ljava/lang/Long;)Ljava/lang/Long;",
+	}
+	meth, err := genLambdaMethod(cp, "T$$Lambda$1", nil, "apply", spec.samMethodType, spec)
+	if err != nil {
+		t.Fatalf("genLambdaMethod: unexpected error: %s", err.Error())
+	}
+
+	unbox := cp.fieldRef("java/lang/Long", "value", "J")
+	call := cp.methodRef("T", "square", "(J)J")
+	box := cp.methodRef("java/lang/Long", "valueOf", "(J)Ljava/lang/Long;")
+	expected := []byte{
+		opcodes.ALOAD, 1,
+		opcodes.GETFIELD, byte(unbox >> 8), byte(unbox),
+		opcodes.INVOKESTATIC, byte(call >> 8), byte(call),
+		opcodes.INVOKESTATIC, byte(box >> 8), byte(box),
+		opcodes.ARETURN,
+	}
+	if string(meth.CodeAttr.Code) != string(expected) {
+		t.Errorf("genLambdaMethod: expected code % X, got % X", expected, meth.CodeAttr.Code)
+	}
+
+	// a method that returns void cannot implement one that returns a value
+	spec.implMethod.desc = "(J)V"
+	if _, err = genLambdaMethod(cp, "T$$Lambda$1", nil, "apply", spec.samMethodType, spec); err == nil {
+		t.Error("genLambdaMethod: expected an error for a void implementation method")
+	}
+}
@@ -556,10 +556,12 @@ func TestLxor(t *testing.T) {
 	}
 }
 
-// MONITORENTER: The JDK JVM does not implement this, nor do we. So just pop the ref off stack
+// MONITORENTER: acquire the monitor of the object on the stack
 func TestMonitorEnter(t *testing.T) {
 	f := newFrame(opcodes.MONITORENTER)
-	push(&f, &f) // push any value and make sure it gets popped off
+	f.Thread = 1
+	obj := object.MakeEmptyObject()
+	push(&f, obj)
 
 	fs := frames.CreateFrameStack()
 	fs.PushFront(&f) // push the new frame
@@ -568,20 +570,63 @@ func TestMonitorEnter(t *testing.T) {
 	if f.TOS != -1 {
 		t.Errorf("MONITORENTER: Expected an empty stack, but got a tos of: %d", f.TOS)
 	}
+
+	if object.MonitorOwner(obj) != 1 {
+		t.Errorf("MONITORENTER: Expected thread 1 to own the monitor, got: %d", object.MonitorOwner(obj))
+	}
+	object.MonitorExit(obj, 1)
 }
 
-// MONITOREXIT: The JDK JVM does not implement this, nor do we. So just pop the ref off stack
+// MONITOREXIT: release the monitor of the object on the stack
 func TestMonitorExit(t *testing.T) {
 	f := newFrame(opcodes.MONITOREXIT)
-	push(&f, &f) // push any value and make sure it gets popped off
+	f.Thread = 1
+	obj := object.MakeEmptyObject()
+	object.MonitorEnter(obj, 1)
+	push(&f, obj)
 
 	fs := frames.CreateFrameStack()
 	fs.PushFront(&f) // push the new frame
-	_ = runFrame(fs)
+	err := runFrame(fs)
+
+	if err != nil {
+		t.Errorf("MONITOREXIT: Unexpected error: %s", err.Error())
+	}
 
 	if f.TOS != -1 {
 		t.Errorf("MONITOREXIT: Expected an empty stack, but got a tos of: %d", f.TOS)
 	}
+
+	if object.MonitorOwner(obj) != 0 {
+		t.Errorf("MONITOREXIT: Expected the monitor to be released, but it's owned by: %d", object.MonitorOwner(obj))
+	}
+}
+
+// MONITOREXIT: exiting a monitor the thread doesn't own is an IllegalMonitorStateException
+func TestMonitorExitNotOwner(t *testing.T) {
+	globals.InitGlobals("test")
+
+	// redirect stderr to avoid printing error message to console
+	normalStderr := os.Stderr
+	_, w, _ := os.Pipe()
+	os.Stderr = w
+
+	f := newFrame(opcodes.MONITOREXIT)
+	f.Thread = 1
+	push(&f, object.MakeEmptyObject())
+
+	fs := frames.CreateFrameStack()
+	fs.PushFront(&f) // push the new frame
+	err := runFrame(fs)
+
+	_ = w.Close()
+	os.Stderr = normalStderr
+
+	if err == nil {
+		t.Errorf("MONITOREXIT: Expected an error, but got none")
+	} else if !strings.Contains(err.Error(), "not the owner") {
+		t.Errorf("MONITOREXIT: Did not get expected error message, got: %s", err.Error())
+	}
 }
 
 // NEW: Instantiate object -- here with an error
@@ -0,0 +1,54 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"errors"
+	"jacobin/excNames"
+	"jacobin/globals"
+)
+
+// Each thread's frame stack is limited to the depth that fits in the thread stack
+// size set by -Xss (or -XX:ThreadStackSize). Invoking a method when the frame stack
+// is full throws a StackOverflowError, rather than growing the frame stack until
+// Jacobin runs out of memory. The depth is checked in createAndInitNewFrame(), which
+// creates the frames of all invoked Java methods, including those invoked by gfunctions
+// via InvokeMethod().
+
+// estimatedFrameSize is the number of bytes of thread stack that a frame is taken to use.
+// This is about the size of a HotSpot interpreter frame for a small method, so the default
+// 1MB thread stack allows about the same depth of recursion as in HotSpot.
+const estimatedFrameSize = 96
+
+// maxFrameDepth returns the maximum number of frames in a thread's frame stack
+func maxFrameDepth() int {
+	stackSize := globals.GetGlobalRef().ThreadStackSize
+	if stackSize <= 0 { // as in HotSpot, -Xss0 means the default stack size
+		stackSize = globals.DefaultThreadStackSize
+	}
+	return int(stackSize / estimatedFrameSize)
+}
+
+// frameStackIsFull reports whether pushing a frame onto the frame stack would
+// exceed the maximum depth, and so should throw a StackOverflowError
+func frameStackIsFull(fs *list.List) bool {
+	return fs.Len() >= maxFrameDepth()
+}
+
+// errFrameStackFull is returned by createAndInitNewFrame() when the frame stack is full
+var errFrameStackFull = errors.New("StackOverflowError")
+
+// frameException returns the exception (and its message) to throw for an error returned by
+// createAndInitNewFrame() for the method invoked by opName: a StackOverflowError if the
+// frame stack is full, and otherwise an InvalidStackFrameException
+func frameException(err error, opName, methFQN string) (int, string) {
+	if errors.Is(err, errFrameStackFull) {
+		return excNames.StackOverflowError, ""
+	}
+	return excNames.InvalidStackFrameException, opName + ": Error creating frame in: " + methFQN
+}
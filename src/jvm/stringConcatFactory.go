@@ -0,0 +1,259 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"jacobin/object"
+	"jacobin/stringPool"
+	"jacobin/types"
+	"jacobin/util"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// This file contains Jacobin's implementation of java.lang.invoke.StringConcatFactory,
+// which javac (since Java 9) uses as the bootstrap method for string concatenation:
+// "a" + x + "b" compiles to an INVOKEDYNAMIC whose dynamic arguments are the values
+// being concatenated. Rather than spinning method handles, the call site's target
+// concatenates the arguments directly, formatting each one as String.valueOf() does.
+// An exception thrown by an argument's toString() is thrown by the INVOKEDYNAMIC.
+
+// the tags in a makeConcatWithConstants() recipe
+const (
+	concatTagArg   = '\u0001' // the next dynamic argument
+	concatTagConst = '\u0002' // the next constant, which is a static argument of the bootstrap method
+)
+
+// "java/lang/invoke/StringConcatFactory.makeConcatWithConstants" -- the first static
+// argument is the recipe; the remaining ones are the constants it refers to.
+func makeConcatWithConstants(site *callSiteInfo) (callSiteTarget, error) {
+	if len(site.staticArgs) < 1 {
+		return nil, errors.New("makeConcatWithConstants: missing recipe")
+	}
+	recipe, ok := site.staticArgs[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("makeConcatWithConstants: expected a recipe string, got %T", site.staticArgs[0])
+	}
+	return concatTarget(site.desc, recipe, site.staticArgs[1:])
+}
+
+// "java/lang/invoke/StringConcatFactory.makeConcat" -- the arguments are concatenated
+// with nothing in between them.
+func makeConcat(site *callSiteInfo) (callSiteTarget, error) {
+	params, _, ok := util.ParseMethodDescriptor(site.desc)
+	if !ok {
+		return nil, fmt.Errorf("makeConcat: invalid call site type %s", site.desc)
+	}
+	return concatTarget(site.desc, strings.Repeat(string(concatTagArg), len(params)), nil)
+}
+
+// concatTarget checks the recipe against the call site's type and the constants, and
+// returns a target that concatenates the arguments per the recipe.
+func concatTarget(desc, recipe string, constants []any) (callSiteTarget, error) {
+	paramTypes, retType, ok := util.ParseMethodDescriptor(desc)
+	if !ok || retType != "L"+types.StringClassName+";" {
+		return nil, fmt.Errorf("StringConcatFactory: invalid call site type %s", desc)
+	}
+
+	// the constants are formatted once, here, rather than every time the target runs
+	var constStrings []string
+	for _, c := range constants {
+		str, err := constantToString(c)
+		if err != nil {
+			return nil, err
+		}
+		constStrings = append(constStrings, str)
+	}
+
+	argCount := strings.Count(recipe, string(concatTagArg))
+	constCount := strings.Count(recipe, string(concatTagConst))
+	if argCount != len(paramTypes) || constCount > len(constStrings) {
+		return nil, fmt.Errorf("StringConcatFactory: recipe %q does not match type %s and %d constants",
+			recipe, desc, len(constStrings))
+	}
+
+	return func(fs *list.List, args []any) (any, error) {
+		var sb strings.Builder
+		argIndex, constIndex := 0, 0
+		for _, ch := range recipe {
+			switch ch {
+			case concatTagArg:
+				str, err := javaValueToString(fs, args[argIndex], paramTypes[argIndex])
+				if err != nil {
+					return nil, err
+				}
+				sb.WriteString(str)
+				argIndex++
+			case concatTagConst:
+				sb.WriteString(constStrings[constIndex])
+				constIndex++
+			default:
+				sb.WriteRune(ch)
+			}
+		}
+		return object.StringObjectFromGoString(joinSurrogates(sb.String())), nil
+	}, nil
+}
+
+// constantToString formats a constant from the BootstrapMethods attribute
+func constantToString(c any) (string, error) {
+	switch v := c.(type) {
+	case string:
+		return v, nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return formatJavaDouble(v, 64), nil
+	}
+	return "", fmt.Errorf("StringConcatFactory: unsupported constant of type %T", c)
+}
+
+// javaValueToString formats a value of the given type descriptor as String.valueOf() does.
+// For objects other than strings and boxed primitives, this calls the object's toString().
+func javaValueToString(fs *list.List, value any, desc string) (string, error) {
+	switch desc {
+	case types.Bool:
+		if value.(int64) != 0 {
+			return "true", nil
+		}
+		return "false", nil
+	case types.Char:
+		return charToString(value.(int64)), nil
+	case types.Byte, types.Short, types.Int, types.Long:
+		return strconv.FormatInt(value.(int64), 10), nil
+	case types.Float:
+		return formatJavaDouble(value.(float64), 32), nil
+	case types.Double:
+		return formatJavaDouble(value.(float64), 64), nil
+	}
+
+	if object.IsNull(value) {
+		return "null", nil
+	}
+	obj, ok := value.(*object.Object)
+	if !ok {
+		return "", fmt.Errorf("StringConcatFactory: unexpected argument of type %T for %s", value, desc)
+	}
+	className := *stringPool.GetStringPointer(obj.KlassName)
+	if className == types.StringClassName {
+		return object.GoStringFromStringObject(obj), nil
+	}
+
+	// boxed primitives hold their value in the "value" field
+	for prim, wrapper := range primitiveWrappers {
+		if className == wrapper {
+			if field, ok := obj.FieldTable["value"]; ok {
+				return javaValueToString(fs, field.Fvalue, prim)
+			}
+		}
+	}
+
+	return callToString(fs, obj, className)
+}
+
+// callToString calls the toString() method of obj. If neither the object's class nor
+// any of its superclasses (other than Object) declares one, the result is that of
+// Object.toString(): the class name followed by the object's hash code.
+func callToString(fs *list.List, obj *object.Object, className string) (string, error) {
+	const toString = "toString()Ljava/lang/String;"
+
+	if cls := overridingClass(className, toString); cls != "" {
+		ret, err := InvokeMethod(fs, cls, "toString", "()Ljava/lang/String;", []any{obj})
+		if err != nil {
+			return "", err
+		}
+		if object.IsNull(ret) {
+			return "null", nil
+		}
+		str, ok := ret.(*object.Object)
+		if !ok {
+			return "", fmt.Errorf("StringConcatFactory: %s.toString() returned %T", cls, ret)
+		}
+		return object.GoStringFromStringObject(str), nil
+	}
+
+	return strings.ReplaceAll(className, "/", ".") + "@" + strconv.FormatUint(uint64(obj.Mark.Hash), 16), nil
+}
+
+// charToString returns a char, which is a UTF-16 code unit, as a string. A surrogate is
+// not a character on its own, so rather than being replaced with U+FFFD, it's encoded as
+// the three bytes that UTF-8 would use for its value (as in WTF-8), which keeps the code
+// unit. joinSurrogates() then combines a high surrogate and a low one.
+func charToString(ch int64) string {
+	if !utf16.IsSurrogate(rune(ch)) {
+		return string(rune(ch))
+	}
+	return string([]byte{byte(0xE0 | ch>>12), byte(0x80 | (ch>>6)&0x3F), byte(0x80 | ch&0x3F)})
+}
+
+// joinSurrogates replaces each surrogate pair encoded by charToString() with the
+// supplementary character that the pair encodes, as when "" + high + low is evaluated
+// for the two chars of a surrogate pair. Lone surrogates are left as they are.
+func joinSurrogates(str string) string {
+	if !strings.Contains(str, "\xED") { // the first byte of every encoded surrogate
+		return str
+	}
+	var sb strings.Builder
+	for i := 0; i < len(str); {
+		if high, ok := surrogateAt(str, i); ok && high < 0xDC00 {
+			if low, ok := surrogateAt(str, i+3); ok && low >= 0xDC00 {
+				sb.WriteRune(utf16.DecodeRune(high, low))
+				i += 6
+				continue
+			}
+		}
+		sb.WriteByte(str[i])
+		i++
+	}
+	return sb.String()
+}
+
+// surrogateAt returns the surrogate encoded by charToString() at str[i], if there is one
+func surrogateAt(str string, i int) (rune, bool) {
+	if i+3 > len(str) || str[i] != 0xED || str[i+1] < 0xA0 || str[i+1] > 0xBF {
+		return 0, false
+	}
+	return rune(str[i]&0x0F)<<12 | rune(str[i+1]&0x3F)<<6 | rune(str[i+2]&0x3F), true
+}
+
+// formatJavaDouble formats a float or double (bitSize 32 or 64) as Double.toString() and
+// Float.toString() do: the shortest decimal that uniquely identifies the value, with at
+// least one digit after the decimal point, in scientific notation if the magnitude is
+// less than 10^-3 or at least 10^7.
+func formatJavaDouble(d float64, bitSize int) string {
+	switch {
+	case math.IsNaN(d):
+		return "NaN"
+	case math.IsInf(d, 1):
+		return "Infinity"
+	case math.IsInf(d, -1):
+		return "-Infinity"
+	}
+
+	abs := math.Abs(d)
+	if abs == 0 || (abs >= 1e-3 && abs < 1e7) {
+		str := strconv.FormatFloat(d, 'f', -1, bitSize)
+		if !strings.Contains(str, ".") {
+			str += ".0"
+		}
+		return str
+	}
+
+	// Go formats, e.g., 1.0E10 as 1e+10 and 1.5E-5 as 1.5e-05
+	str := strconv.FormatFloat(d, 'e', -1, bitSize)
+	mantissa, exponent, _ := strings.Cut(str, "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(exp)
+}
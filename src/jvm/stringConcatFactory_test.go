@@ -0,0 +1,188 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"jacobin/classloader"
+	"jacobin/frames"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/opcodes"
+	"jacobin/types"
+	"math"
+	"testing"
+)
+
+func TestFormatJavaDouble(t *testing.T) {
+	tests := []struct {
+		value   float64
+		bitSize int
+		want    string
+	}{
+		{0, 64, "0.0"},
+		{math.Copysign(0, -1), 64, "-0.0"},
+		{1, 64, "1.0"},
+		{-2.5, 64, "-2.5"},
+		{0.1, 64, "0.1"},
+		{0.001, 64, "0.001"},
+		{0.0001, 64, "1.0E-4"},
+		{1234567.0, 64, "1234567.0"},
+		{1e7, 64, "1.0E7"},
+		{1.5e300, 64, "1.5E300"},
+		{float64(float32(0.1)), 32, "0.1"},
+		{float64(float32(3.3)), 32, "3.3"},
+		{math.NaN(), 64, "NaN"},
+		{math.Inf(1), 64, "Infinity"},
+		{math.Inf(-1), 32, "-Infinity"},
+	}
+	for _, test := range tests {
+		if got := formatJavaDouble(test.value, test.bitSize); got != test.want {
+			t.Errorf("formatJavaDouble(%v, %d): expected %s, got %s", test.value, test.bitSize, test.want, got)
+		}
+	}
+}
+
+func TestMakeConcatWithConstants(t *testing.T) {
+	globals.InitGlobals("test")
+
+	site := &callSiteInfo{
+		name: "makeConcatWithConstants",
+		desc: "(Ljava/lang/String;IJCZDFLjava/lang/Object;)Ljava/lang/String;",
+		staticArgs: []any{
+			"s=\u0001 i=\u0001 j=\u0001 c=\u0001 z=\u0001 d=\u0001 f=\u0001 o=\u0001 \u0002",
+			"[end]",
+		},
+	}
+	target, err := makeConcatWithConstants(site)
+	if err != nil {
+		t.Fatalf("makeConcatWithConstants: unexpected error: %s", err.Error())
+	}
+
+	args := []any{
+		object.StringObjectFromGoString("str"), int64(-42), int64(1) << 40, int64('x'), int64(1),
+		2.0, float64(float32(1.1)), object.Null,
+	}
+	ret, err := target(frames.CreateFrameStack(), args)
+	if err != nil {
+		t.Fatalf("makeConcatWithConstants: unexpected error in target: %s", err.Error())
+	}
+	expected := "s=str i=-42 j=1099511627776 c=x z=true d=2.0 f=1.1 o=null [end]"
+	if str := object.GoStringFromStringObject(ret.(*object.Object)); str != expected {
+		t.Errorf("makeConcatWithConstants: expected %q, got %q", expected, str)
+	}
+}
+
+func TestMakeConcatObjects(t *testing.T) {
+	globals.InitGlobals("test")
+	_ = classloader.Init()
+
+	// a boxed primitive is formatted like the primitive
+	boxed := object.MakePrimitiveObject("java/lang/Double", "D", 1e10)
+
+	// an object whose class does not override toString() gets Object.toString()
+	className := "jacobin/test/Plain"
+	plain := object.MakeEmptyObjectWithClassName(&className)
+	plain.Mark.Hash = 0x1b6d3586
+
+	site := &callSiteInfo{desc: "(Ljava/lang/Double;Ljacobin/test/Plain;)Ljava/lang/String;"}
+	target, err := makeConcat(site)
+	if err != nil {
+		t.Fatalf("makeConcat: unexpected error: %s", err.Error())
+	}
+	ret, err := target(frames.CreateFrameStack(), []any{boxed, plain})
+	if err != nil {
+		t.Fatalf("makeConcat: unexpected error in target: %s", err.Error())
+	}
+	expected := "1.0E10jacobin.test.Plain@1b6d3586"
+	if str := object.GoStringFromStringObject(ret.(*object.Object)); str != expected {
+		t.Errorf("makeConcat: expected %q, got %q", expected, str)
+	}
+}
+
+func TestMakeConcatWithConstantsBadRecipe(t *testing.T) {
+	site := &callSiteInfo{
+		desc:       "(I)Ljava/lang/String;",
+		staticArgs: []any{"\u0001\u0001"},
+	}
+	if _, err := makeConcatWithConstants(site); err == nil {
+		t.Error("makeConcatWithConstants: expected an error for a recipe with too many arguments")
+	}
+
+	site.staticArgs = []any{"\u0001\u0002"}
+	if _, err := makeConcatWithConstants(site); err == nil {
+		t.Error("makeConcatWithConstants: expected an error for a recipe with a missing constant")
+	}
+}
+
+// chars are concatenated as UTF-16 code units: a surrogate pair passed as two chars
+// is the character it encodes, and a lone surrogate is kept rather than replaced
+func TestMakeConcatSurrogates(t *testing.T) {
+	globals.InitGlobals("test")
+
+	site := &callSiteInfo{desc: "(CCLjava/lang/String;C)Ljava/lang/String;"}
+	target, err := makeConcat(site)
+	if err != nil {
+		t.Fatalf("makeConcat: unexpected error: %s", err.Error())
+	}
+	args := []any{int64(0xD83D), int64(0xDE00), object.StringObjectFromGoString("!"), int64(0xD83D)}
+	ret, err := target(frames.CreateFrameStack(), args)
+	if err != nil {
+		t.Fatalf("makeConcat: unexpected error in target: %s", err.Error())
+	}
+	expected := "\U0001F600!" + charToString(0xD83D)
+	if str := object.GoStringFromStringObject(ret.(*object.Object)); str != expected {
+		t.Errorf("makeConcat: expected %q, got %q", expected, str)
+	}
+	if lone := charToString(0xD83D); lone != "\xED\xA0\xBD" {
+		t.Errorf("charToString: expected the lone surrogate's code unit, got %q", lone)
+	}
+}
+
+// an exception thrown by toString() during a concatenation is thrown by the INVOKEDYNAMIC,
+// so the method doing the concatenation can catch it:
+//
+//	static Object concat(Invoke obj) {
+//	    try { return "" + obj; } catch (IllegalStateException e) { return e; }
+//	}
+func TestMakeConcatToStringThrows(t *testing.T) {
+	CP := invokeTestSetup()
+	cp := &cpBuilder{cp: *CP, entries: map[string]uint16{}}
+	excField := cp.fieldRef("test/Invoke", "exc", "Ljava/lang/Object;")
+	catchType := cp.classRef("java/lang/IllegalStateException")
+	*CP = cp.cp
+
+	// toString() throws the exception in the object's exc field
+	addInvokeTestMethod("toString", "()Ljava/lang/String;", CP, nil,
+		opcodes.ALOAD_0, opcodes.GETFIELD, byte(excField>>8), byte(excField), opcodes.ATHROW)
+	addInvokeTestMethod("concat", "(Ltest/Invoke;)Ljava/lang/Object;", CP,
+		[]classloader.CodeException{{StartPc: 0, EndPc: 7, HandlerPc: 7, CatchType: catchType}},
+		opcodes.ALOAD_0, opcodes.INVOKEDYNAMIC, 0x00, 0x00, 0x00, 0x00,
+		opcodes.ARETURN,
+		opcodes.ARETURN) // the handler returns the exception
+
+	target, err := makeConcat(&callSiteInfo{desc: "(Ltest/Invoke;)Ljava/lang/String;"})
+	if err != nil {
+		t.Fatalf("makeConcat: unexpected error: %s", err.Error())
+	}
+	callSites.Store(callSiteKey{method: "test/Invoke.concat(Ltest/Invoke;)Ljava/lang/Object;", pc: 1},
+		&callSite{desc: "(Ltest/Invoke;)Ljava/lang/String;", target: target})
+	defer callSites.Delete(callSiteKey{method: "test/Invoke.concat(Ltest/Invoke;)Ljava/lang/Object;", pc: 1})
+
+	className := "test/Invoke"
+	obj := object.MakeEmptyObjectWithClassName(&className)
+	excClass := "java/lang/IllegalStateException"
+	throwable := object.MakeEmptyObjectWithClassName(&excClass)
+	obj.FieldTable["exc"] = object.Field{Ftype: types.Ref, Fvalue: throwable}
+
+	ret, err := InvokeMethod(frames.CreateFrameStack(), className, "concat", "(Ltest/Invoke;)Ljava/lang/Object;", []any{obj})
+	if err != nil {
+		t.Fatalf("expected the exception thrown by toString() to be caught, got: %s", err.Error())
+	}
+	if ret != throwable {
+		t.Errorf("expected the exception thrown by toString(), got %v", ret)
+	}
+}
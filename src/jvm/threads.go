@@ -0,0 +1,285 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. Consult jacobin.org.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0) All rights reserved.
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"jacobin/classloader"
+	"jacobin/excNames"
+	"jacobin/exceptions"
+	"jacobin/frames"
+	"jacobin/gfunction"
+	"jacobin/globals"
+	"jacobin/log"
+	"jacobin/object"
+	"jacobin/shutdown"
+	"jacobin/stringPool"
+	"jacobin/thread"
+	"jacobin/types"
+	"jacobin/util"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// This file contains the JVM side of java.lang.Thread: starting application
+// threads on their own goroutines and running Java methods to completion on
+// behalf of gfunctions. The gfunctions reach these functions via the
+// globals.FuncStartThread and globals.FuncInvokeMethod pointers, which are
+// set up in jvmStart.go
+
+// StartThread runs the run() method of a java.lang.Thread on a new goroutine.
+// The thread gets its own frame stack, with the run() frame at the bottom of it,
+// and executes via runThread(), exactly as the main thread does. The parameter is
+// a *thread.ExecThread (passed as any due to circularity in the globals package).
+func StartThread(t any) error {
+	th, ok := t.(*thread.ExecThread)
+	if !ok {
+		errMsg := fmt.Sprintf("StartThread: expected a thread, got %T", t)
+		_ = log.Log(errMsg, log.SEVERE)
+		return errors.New(errMsg)
+	}
+
+	th.Stack = frames.CreateFrameStack()
+	th.Trace = MainThread.Trace
+	th.AddThreadToTable(globals.GetGlobalRef())
+
+	// the daemon status is read once, so that the goroutine's cleanup undoes
+	// exactly what's done here
+	daemon := th.IsDaemon()
+	if !daemon {
+		globals.NonDaemonWg.Add(1)
+	}
+
+	go func() {
+		defer func() {
+			th.RemoveThreadFromTable(globals.GetGlobalRef())
+			close(th.Done)
+			if !daemon {
+				globals.NonDaemonWg.Done()
+			}
+		}()
+
+		// Thread.run() is a gfunction that calls the target's run(). A subclass of
+		// Thread that overrides run() is a Java method, which we run here directly.
+		className := *stringPool.GetStringPointer(th.JavaThread.(*object.Object).KlassName)
+		mte, err := classloader.FetchMethodAndCP(className, "run", "()V")
+		if err != nil {
+			_ = log.Log("StartThread: "+err.Error(), log.SEVERE)
+			return
+		}
+
+		if mte.MType == 'G' {
+			_, err = runThreadMethod(th, className, "run", "()V", []any{th.JavaThread})
+			var javaEx *exceptions.JavaException
+			if errors.As(err, &javaEx) {
+				uncaughtException(th, javaEx.Throwable, nil)
+			}
+			return
+		}
+
+		m := mte.Meth.(classloader.JmEntry)
+		f := frames.CreateFrame(m.MaxStack + types.StackInflator)
+		f.Thread = th.ID
+		f.FrameStack = th.Stack
+		f.ClName = className
+		f.MethName = "run"
+		f.MethType = "()V"
+		f.CP = m.Cp
+		f.Meth = append(f.Meth, m.Code...)
+		for k := 0; k < m.MaxLocals || k < 1; k++ {
+			f.Locals = append(f.Locals, 0)
+		}
+		f.Locals[0] = th.JavaThread
+		if m.AccessFlags&0x0020 > 0 { // a synchronized run() method
+			enterSyncMethod(f, th.JavaThread)
+		}
+
+		_ = frames.PushFrame(th.Stack, f)
+		_ = runThread(th)
+	}()
+
+	return nil
+}
+
+// runThreadMethod runs a method as the first thing on a newly started thread,
+// trapping any panics in the same way that runThread() does.
+func runThreadMethod(th *thread.ExecThread, className, methName, methType string, args []any) (ret any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			glob := globals.GetGlobalRef()
+			glob.ErrorGoStack = string(debug.Stack())
+			exceptions.ShowPanicCause(r)
+			exceptions.ShowFrameStack(th)
+			exceptions.ShowGoStackTrace(nil)
+			err = fmt.Errorf("panic in thread %s", th.GetName())
+		}
+	}()
+	return InvokeMethod(th.Stack, className, methName, methType, args)
+}
+
+// InvokeMethod runs the named method to completion on the frame stack fs and returns
+// the method's return value, if any. It lets gfunctions call back into Java code
+// (e.g., Thread.run() calling the run() method of its Runnable). For instance methods,
+// args[0] is the object reference. Longs and doubles occupy a single entry in args.
+//
+// The method is called from an empty base frame, which receives the return value.
+// The base frame has an Ftype of 'G', which stops the search for exception handlers.
+// An exception that is not caught in the invoked method is recorded in the base frame
+// and returned as an *exceptions.JavaException, so that the caller can rethrow it.
+func InvokeMethod(fs *list.List, className, methName, methType string, args []any) (any, error) {
+	mte, err := classloader.FetchMethodAndCP(className, methName, methType)
+	if err != nil || mte.Meth == nil {
+		errMsg := fmt.Sprintf("InvokeMethod: method not found: %s.%s%s", className, methName, methType)
+		_ = log.Log(errMsg, log.SEVERE)
+		return nil, errors.New(errMsg)
+	}
+
+	// expand longs and doubles to two slots, as they are on the operand stack
+	paramTypes := util.ParseIncomingParamsFromMethTypeString(methType)
+	hasObjRef := len(args) > len(paramTypes)
+	var slots []any
+	for i, arg := range args {
+		slots = append(slots, arg)
+		p := i
+		if hasObjRef {
+			p = i - 1
+		}
+		if p >= 0 && p < len(paramTypes) && (paramTypes[p] == types.Long || paramTypes[p] == types.Double) {
+			slots = append(slots, arg)
+		}
+	}
+
+	base := frames.CreateFrame(len(slots) + 2)
+	if fs.Len() > 0 {
+		base.Thread = fs.Front().Value.(*frames.Frame).Thread
+	}
+	base.FrameStack = fs
+	base.ClName = className
+	base.MethName = methName
+	base.MethType = methType
+	base.Ftype = 'G'
+	fs.PushFront(base)
+	defer func() { // the base frame is removed, along with any frames left above it by an error
+		for e := fs.Front(); e != nil; e = e.Next() {
+			if e.Value == base {
+				exceptions.UnwindToCatchFrame(fs, base)
+				fs.Remove(fs.Front())
+				break
+			}
+		}
+	}()
+
+	if mte.MType == 'G' {
+		var params []any
+		for i := len(slots) - 1; i >= 0; i-- { // RunGfunction expects the params in popped order
+			params = append(params, slots[i])
+		}
+		ret := gfunction.RunGfunction(mte, fs, className, methName, methType, &params, hasObjRef, MainThread.Trace)
+		if base.Thrown != nil {
+			return nil, &exceptions.JavaException{Throwable: base.Thrown.(*object.Object)}
+		}
+		if e, ok := ret.(error); ok {
+			return nil, e
+		}
+		return ret, nil
+	}
+
+	m := mte.Meth.(classloader.JmEntry)
+	if m.AccessFlags&0x0100 > 0 {
+		errMsg := "InvokeMethod: Native method requested: " + className + "." + methName + methType
+		_ = log.Log(errMsg, log.SEVERE)
+		return nil, errors.New(errMsg)
+	}
+
+	// the dispatch-table interpreter keeps longs and doubles in a single slot
+	newInterpreter := globals.GetGlobalRef().NewInterpreter
+	toPush := slots
+	if newInterpreter {
+		toPush = args
+	}
+	for _, arg := range toPush {
+		push(base, arg)
+	}
+	fram, err := createAndInitNewFrame(className, methName, methType, &m, hasObjRef, base, !newInterpreter)
+	if err != nil {
+		// a StackOverflowError is recorded in the base frame, as the base frame is at the top
+		if errors.Is(err, errFrameStackFull) &&
+			exceptions.ThrowEx(excNames.StackOverflowError, "", base) == exceptions.Caught {
+			return nil, &exceptions.JavaException{Throwable: base.Thrown.(*object.Object)}
+		}
+		return nil, err
+	}
+	fs.PushFront(fram)
+
+	// run frames until the invoked method returns to the base frame
+	for fs.Front().Value != base {
+		if newInterpreter { // interpret() removes the frames of methods that return
+			if err = interpret(fs); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err = runFrame(fs); err != nil {
+			return nil, err
+		}
+		if base.Thrown != nil { // the base frame is at the top of the frame stack
+			break
+		}
+		fs.Remove(fs.Front())
+	}
+
+	if base.Thrown != nil {
+		return nil, &exceptions.JavaException{Throwable: base.Thrown.(*object.Object)}
+	}
+	if base.TOS < 0 || strings.HasSuffix(methType, ")V") {
+		return nil, nil
+	}
+	return base.OpStack[0], nil
+}
+
+// uncaughtException is called when an exception is not caught anywhere on the frame
+// stack of thread t. As in HotSpot, the thread's frames are discarded (releasing the
+// monitors of any synchronized methods), the exception is passed to the thread's
+// uncaught exception handler, and then the thread--and only that thread--ends. When
+// the main thread ends this way, the JVM waits for the other non-daemon threads to
+// finish and then exits with a status of 1.
+//
+// diagnostics, if not nil, shows Jacobin's own data about the exception (such as the
+// JVM frame stack). It's called after the stack trace is printed, when the exception
+// is not handled by the application. t is a *thread.ExecThread and throwable is the
+// exception's *object.Object (passed as any due to circularity in the globals package).
+func uncaughtException(t any, throwable any, diagnostics func()) {
+	th := t.(*thread.ExecThread)
+	throwObj := throwable.(*object.Object)
+
+	// an exception that's not caught in the uncaught exception handler is ignored
+	if !th.Terminating {
+		th.Terminating = true
+		if th.Stack.Len() > 0 { // the bottom frame is kept, so that the handler runs on this thread
+			bottom := th.Stack.Back().Value.(*frames.Frame)
+			exceptions.UnwindToCatchFrame(th.Stack, bottom)
+			if bottom.SyncObject != nil {
+				object.MonitorExit(bottom.SyncObject.(*object.Object), bottom.Thread)
+				bottom.SyncObject = nil
+			}
+		}
+
+		if !gfunction.DispatchUncaughtException(th, throwObj) && diagnostics != nil {
+			diagnostics()
+		}
+	}
+
+	if th == &MainThread {
+		globals.NonDaemonWg.Wait()
+		shutdown.Exit(shutdown.JVM_EXCEPTION) // exit status 1, as in HotSpot
+		return
+	}
+	runtime.Goexit() // runs the deferred clean-up in StartThread()
+}
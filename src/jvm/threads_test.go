@@ -0,0 +1,189 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by  the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package jvm
+
+import (
+	"container/list"
+	"errors"
+	"jacobin/classloader"
+	"jacobin/exceptions"
+	"jacobin/frames"
+	"jacobin/gfunction"
+	"jacobin/globals"
+	"jacobin/object"
+	"jacobin/opcodes"
+	"jacobin/stringPool"
+	"jacobin/thread"
+	"jacobin/types"
+	"testing"
+)
+
+// An uncaught exception on a thread other than main is passed to the thread's handler,
+// and then only that thread ends: its frames are discarded and its goroutine exits.
+func TestUncaughtExceptionEndsOnlyItsThread(t *testing.T) {
+	globals.InitGlobals("test")
+	glob := globals.GetGlobalRef()
+
+	th := thread.CreateThread()
+	th.Name = "worker"
+	th.Stack = frames.CreateFrameStack()
+	th.AddThreadToTable(glob)
+	for i := 0; i < 3; i++ {
+		f := frames.CreateFrame(2)
+		f.Thread = th.ID
+		_ = frames.PushFrame(th.Stack, f)
+	}
+
+	handlerClass := "test/Handler"
+	handler := object.MakeEmptyObjectWithClassName(&handlerClass)
+	th.UncaughtHandler = handler
+
+	var invoked []any
+	glob.FuncInvokeMethod = func(fs *list.List, className, methName, methType string, args []any) (any, error) {
+		invoked = append([]any{className, methName, fs.Len()}, args...)
+		return nil, nil
+	}
+
+	excClass := "java/lang/RuntimeException"
+	throwable := object.MakeEmptyObjectWithClassName(&excClass)
+	returned := false
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		uncaughtException(&th, throwable, func() { t.Error("expected no diagnostics when there's a handler") })
+		returned = true
+	}()
+	<-done
+
+	if returned {
+		t.Error("expected uncaughtException() to end the thread's goroutine")
+	}
+	if len(invoked) != 6 || invoked[0] != handlerClass || invoked[1] != "uncaughtException" {
+		t.Fatalf("expected the thread's handler to be called, got %v", invoked)
+	}
+	if invoked[2] != 1 {
+		t.Errorf("expected the handler to run on the thread's bottom frame only, got %d frames", invoked[2])
+	}
+	if invoked[3] != handler || invoked[4] != th.JavaThread || invoked[5] != throwable {
+		t.Errorf("expected the handler to get the Thread and the exception, got %v", invoked[3:])
+	}
+}
+
+// invokeTestSetup loads the class test/Invoke, whose static method
+//
+//	static void thrower(Object e) { throw (Throwable) e; }
+//
+// throws its argument, and returns the class's CP, to which tests add entries
+func invokeTestSetup() *classloader.CPool {
+	globals.InitGlobals("test")
+	classloader.InitMethodArea()
+	gfunction.MTableLoadGFunctions(&classloader.MTable)
+	glob := globals.GetGlobalRef()
+	glob.FuncInstantiateClass = InstantiateClass
+	glob.FuncInvokeMethod = InvokeMethod
+
+	for name, superclass := range map[string]string{
+		"test/Invoke":         types.ObjectClassName,
+		"java/lang/Throwable": types.ObjectClassName,
+		"java/lang/reflect/InvocationTargetException": "java/lang/Throwable",
+	} {
+		classloader.MethAreaInsert(name, &classloader.Klass{
+			Status: 'X',
+			Loader: "bootstrap",
+			Data: &classloader.ClData{
+				Name:            name,
+				NameIndex:       stringPool.GetStringIndex(&name),
+				SuperclassIndex: stringPool.GetStringIndex(&superclass),
+				ClInit:          types.ClInitRun,
+			},
+		})
+	}
+
+	cp := newCPBuilder()
+	CP := &cp.cp
+	addInvokeTestMethod("thrower", "(Ljava/lang/Object;)V", CP, nil, opcodes.ALOAD_0, opcodes.ATHROW)
+	return CP
+}
+
+// addInvokeTestMethod adds a method of test/Invoke to the MTable
+func addInvokeTestMethod(name, desc string, CP *classloader.CPool, handlers []classloader.CodeException, code ...byte) {
+	classloader.AddEntry(&classloader.MTable, "test/Invoke."+name+desc, classloader.MTentry{
+		Meth: classloader.JmEntry{MaxStack: 4, MaxLocals: 2, Code: code,
+			Exceptions: handlers, Cp: CP},
+		MType: 'J',
+	})
+}
+
+// An exception that's not caught by a method run by InvokeMethod is returned to the
+// caller rather than treated as uncaught, and the frame stack is left as it was.
+func TestInvokeMethodReturnsThrownException(t *testing.T) {
+	invokeTestSetup()
+	fs := frames.CreateFrameStack()
+	caller := frames.CreateFrame(2)
+	fs.PushFront(caller)
+
+	excClass := "java/lang/IllegalStateException"
+	throwable := object.MakeEmptyObjectWithClassName(&excClass)
+	_, err := InvokeMethod(fs, "test/Invoke", "thrower", "(Ljava/lang/Object;)V", []any{throwable})
+
+	var javaEx *exceptions.JavaException
+	if !errors.As(err, &javaEx) || javaEx.Throwable != throwable {
+		t.Fatalf("expected the thrown exception to be returned, got: %v", err)
+	}
+	if fs.Len() != 1 || fs.Front().Value != caller {
+		t.Errorf("expected only the caller's frame on the frame stack, got %d frames", fs.Len())
+	}
+}
+
+// An exception thrown by a method called via Method.invoke() is wrapped in an
+// InvocationTargetException, which the caller of invoke() can catch:
+//
+//	static Object caller(Method m, Object[] args) {
+//	    try { return m.invoke(null, args); } catch (InvocationTargetException e) { return e; }
+//	}
+func TestMethodInvokeThrowsInvocationTargetException(t *testing.T) {
+	CP := invokeTestSetup()
+	cp := &cpBuilder{cp: *CP, entries: map[string]uint16{}}
+	invoke := cp.methodRef("java/lang/reflect/Method", "invoke",
+		"(Ljava/lang/Object;[Ljava/lang/Object;)Ljava/lang/Object;")
+	catchType := cp.classRef("java/lang/reflect/InvocationTargetException")
+	*CP = cp.cp
+
+	callerDesc := "(Ljava/lang/reflect/Method;[Ljava/lang/Object;)Ljava/lang/Object;"
+	addInvokeTestMethod("caller", callerDesc, CP,
+		[]classloader.CodeException{{StartPc: 0, EndPc: 7, HandlerPc: 7, CatchType: catchType}},
+		opcodes.ALOAD_0, opcodes.ACONST_NULL, opcodes.ALOAD_1,
+		opcodes.INVOKEVIRTUAL, byte(invoke>>8), byte(invoke),
+		opcodes.ARETURN,
+		opcodes.ARETURN) // the handler returns the exception
+
+	// the Method object for thrower(), as made by Class.getDeclaredMethod()
+	methodClass := "java/lang/reflect/Method"
+	method := object.MakeEmptyObjectWithClassName(&methodClass)
+	method.FieldTable["clazz"] = object.Field{Ftype: types.Ref, Fvalue: classloader.ClassMirror("test/Invoke")}
+	method.FieldTable["name"] = object.Field{Ftype: types.Ref, Fvalue: object.StringObjectFromGoString("thrower")}
+	method.FieldTable["descriptor"] = object.Field{Ftype: types.GolangString, Fvalue: "(Ljava/lang/Object;)V"}
+	method.FieldTable["modifiers"] = object.Field{Ftype: types.Int, Fvalue: int64(0x0008)}
+
+	excClass := "java/lang/IllegalStateException"
+	throwable := object.MakeEmptyObjectWithClassName(&excClass)
+	args := object.Make1DimRefArray(&types.ObjectClassName, 1)
+	args.FieldTable["value"].Fvalue.([]*object.Object)[0] = throwable
+
+	fs := frames.CreateFrameStack()
+	ret, err := InvokeMethod(fs, "test/Invoke", "caller", callerDesc, []any{method, args})
+	if err != nil {
+		t.Fatalf("expected the caller to catch the InvocationTargetException, got: %s", err.Error())
+	}
+	wrapper, ok := ret.(*object.Object)
+	if !ok || *stringPool.GetStringPointer(wrapper.KlassName) != "java/lang/reflect/InvocationTargetException" {
+		t.Fatalf("expected an InvocationTargetException, got %v", ret)
+	}
+	if exceptions.Cause(wrapper) != throwable || wrapper.FieldTable["target"].Fvalue != throwable {
+		t.Errorf("expected the thrown exception to be the cause and target of the InvocationTargetException")
+	}
+}
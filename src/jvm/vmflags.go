@@ -0,0 +1,350 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"jacobin/gfunction"
+	"jacobin/globals"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// This file handles the HotSpot-style VM options: -XX:+Name and -XX:-Name for boolean
+// flags, -XX:Name=value for all other flags, and the -X options, some of which are
+// shorthand for -XX flags (-Xss, -Xmx, and -Xms). The flags that Jacobin recognizes are
+// in the registry, vmFlags, which gives each flag's type and default value and, for
+// those flags that have an effect in Jacobin, the function that applies the flag's
+// value. The values of the flags set on the command line are in globals.VMFlags.
+//
+// As in HotSpot, an unrecognized VM option is a fatal error unless
+// -XX:+IgnoreUnrecognizedVMOptions is specified (anywhere on the command line),
+// in which case Jacobin shows a warning and ignores the option.
+
+type vmFlagType int
+
+const (
+	boolFlag   vmFlagType = iota
+	intFlag               // an int64
+	sizeFlag              // an int64, which on the command line can have a k, m, g, or t suffix
+	doubleFlag            // a float64
+	stringFlag
+)
+
+var vmFlagTypeNames = map[vmFlagType]string{
+	boolFlag: "bool", intFlag: "intx", sizeFlag: "size_t", doubleFlag: "double", stringFlag: "ccstr",
+}
+
+type vmFlag struct {
+	flagType     vmFlagType
+	defaultValue any                                  // a bool, int64, float64, or string, per flagType
+	apply        func(value any, gl *globals.Globals) // applies the flag's value, or nil if the flag has no effect
+}
+
+// the VM flags Jacobin recognizes. Those without an apply function are accepted so that
+// existing launch scripts work, but they have no effect in Jacobin.
+var vmFlags = map[string]vmFlag{
+	"ActiveProcessorCount":        {intFlag, int64(-1), nil},
+	"ExitOnOutOfMemoryError":      {boolFlag, false, nil},
+	"HeapDumpOnOutOfMemoryError":  {boolFlag, false, nil},
+	"HeapDumpPath":                {stringFlag, "", nil},
+	"IgnoreUnrecognizedVMOptions": {boolFlag, false, nil},
+	"InitialHeapSize": {sizeFlag, int64(0), func(value any, gl *globals.Globals) {
+		gl.InitialHeapSize = value.(int64)
+	}},
+	"InitialRAMPercentage": {doubleFlag, 1.5625, nil},
+	"MaxHeapSize": {sizeFlag, int64(0), func(value any, gl *globals.Globals) {
+		gl.MaxHeapSize = value.(int64)
+	}},
+	"MaxJavaStackTraceDepth": {intFlag, int64(1024), func(value any, gl *globals.Globals) {
+		gl.MaxJavaStackTraceDepth = value.(int64)
+	}},
+	"MaxMetaspaceSize":                   {sizeFlag, int64(0), nil},
+	"MaxRAMPercentage":                   {doubleFlag, 25.0, nil},
+	"PrintFlagsFinal":                    {boolFlag, false, nil},
+	"ShowCodeDetailsInExceptionMessages": {boolFlag, true, nil},
+	"ThreadStackSize": {sizeFlag, int64(globals.DefaultThreadStackSize / 1024), // in KB, as in HotSpot
+		func(value any, gl *globals.Globals) { gl.ThreadStackSize = value.(int64) * 1024 }},
+	"TieredCompilation":           {boolFlag, true, nil},
+	"UnlockDiagnosticVMOptions":   {boolFlag, false, nil},
+	"UnlockExperimentalVMOptions": {boolFlag, false, nil},
+	"UseCompressedOops":           {boolFlag, true, nil},
+	"UseG1GC":                     {boolFlag, true, nil},
+	"UseParallelGC":               {boolFlag, false, nil},
+	"UseSerialGC":                 {boolFlag, false, nil},
+	"UseZGC":                      {boolFlag, false, nil},
+}
+
+// the -X options that are shorthand for setting a size flag, with the text of
+// the error message if the size is invalid
+var xSizeOptions = map[string]struct{ flagName, errMsg string }{
+	"-Xms": {"InitialHeapSize", "Invalid initial heap size"},
+	"-Xmx": {"MaxHeapSize", "Invalid maximum heap size"},
+	"-Xss": {"ThreadStackSize", "Invalid thread stack size"},
+}
+
+// errFatalVMOption is returned by the handlers of VM options whose value is invalid, and
+// by handleUnrecognizedVMOption(). HandleCli() stops processing the command line on it.
+var errFatalVMOption = errors.New("Error: Could not create the Java Virtual Machine.\n" +
+	"Error: A fatal exception has occurred. Program will exit.")
+
+// getVMFlag returns the value of a flag: the value set on the command line, if
+// any, or else its default value
+func getVMFlag(name string, gl *globals.Globals) any {
+	if value, ok := gl.VMFlags[name]; ok {
+		return value
+	}
+	return vmFlags[name].defaultValue
+}
+
+// setVMFlag sets the value of a flag and applies it
+func setVMFlag(name string, value any, gl *globals.Globals) {
+	gl.VMFlags[name] = value
+	if apply := vmFlags[name].apply; apply != nil {
+		apply(value, gl)
+	}
+}
+
+// parseMemorySize parses a size such as 512k or 2G into a number of bytes
+func parseMemorySize(size string) (int64, error) {
+	multiplier := int64(1)
+	if size != "" {
+		switch size[len(size)-1] {
+		case 'k', 'K':
+			multiplier = 1 << 10
+		case 'm', 'M':
+			multiplier = 1 << 20
+		case 'g', 'G':
+			multiplier = 1 << 30
+		case 't', 'T':
+			multiplier = 1 << 40
+		}
+		if multiplier != 1 {
+			size = size[:len(size)-1]
+		}
+	}
+
+	value, err := strconv.ParseInt(size, 10, 64)
+	if err != nil || value < 0 || value > (1<<63-1)/multiplier {
+		return 0, errors.New("invalid memory size: " + size)
+	}
+	return value * multiplier, nil
+}
+
+// parseVMFlagValue parses the value of a flag, per its type
+func parseVMFlagValue(flag vmFlag, value string) (any, error) {
+	switch flag.flagType {
+	case intFlag:
+		return strconv.ParseInt(value, 10, 64)
+	case sizeFlag:
+		return parseMemorySize(value)
+	case doubleFlag:
+		return strconv.ParseFloat(value, 64)
+	case stringFlag:
+		return value, nil
+	default:
+		return nil, errors.New("boolean flags are set with + or -")
+	}
+}
+
+// for -XX options, which are in the form -XX:+Name, -XX:-Name, or -XX:Name=value
+func setXXFlag(pos int, argValue string, gl *globals.Globals) (int, error) {
+	var name, value string
+	isBool := strings.HasPrefix(argValue, "+") || strings.HasPrefix(argValue, "-")
+	if isBool {
+		name = argValue[1:]
+	} else {
+		name, value, _ = strings.Cut(argValue, "=")
+	}
+
+	flag, ok := vmFlags[name]
+	switch {
+	case !ok:
+		errMsg := fmt.Sprintf("Unrecognized VM option '%s'", strings.TrimLeft(argValue, "+-"))
+		return pos, handleUnrecognizedVMOption(gl.Args[pos], errMsg, gl)
+	case isBool && flag.flagType != boolFlag:
+		_, _ = fmt.Fprintf(os.Stderr, "Unexpected +/- setting in VM option '%s'\n", argValue)
+		return pos, errFatalVMOption
+	case !isBool && flag.flagType == boolFlag:
+		_, _ = fmt.Fprintf(os.Stderr, "Missing +/- setting for VM option '%s'\n", argValue)
+		return pos, errFatalVMOption
+	}
+
+	if isBool {
+		setVMFlag(name, argValue[0] == '+', gl)
+	} else {
+		flagValue, err := parseVMFlagValue(flag, value)
+		if err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "Improperly specified VM option '%s'\n", argValue)
+			return pos, errFatalVMOption
+		}
+		setVMFlag(name, flagValue, gl)
+	}
+	setOptionToSeen("-XX", gl)
+	return pos, nil
+}
+
+// for -Xss, -Xmx, and -Xms, which are followed immediately by a size, e.g., -Xss512k
+func setXSizeOption(pos int, argValue string, gl *globals.Globals) (int, error) {
+	optionName, _, _ := getOptionRootAndArgs(gl.Args[pos])
+	xOption := xSizeOptions[optionName]
+	size, err := parseMemorySize(argValue)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", xOption.errMsg, gl.Args[pos])
+		return pos, errFatalVMOption
+	}
+
+	if optionName == "-Xss" { // ThreadStackSize is in KB, so round up
+		size = (size + 1023) / 1024
+	}
+	setVMFlag(xOption.flagName, size, gl)
+	setOptionToSeen(optionName, gl)
+	return pos, nil
+}
+
+// for -Xint and -Xmixed. Jacobin has no JIT compiler, so it always runs in interpreted mode.
+func setExecutionMode(pos int, argValue string, gl *globals.Globals) (int, error) {
+	setOptionToSeen(gl.Args[pos], gl)
+	return pos, nil
+}
+
+// for -XshowSettings, which shows the VM settings, the system properties, or both
+// ("all", which is the default) before the program runs
+func setShowSettings(pos int, argValue string, gl *globals.Globals) (int, error) {
+	switch argValue {
+	case "vm", "properties":
+		gl.ShowSettings = argValue
+	default:
+		gl.ShowSettings = "all"
+	}
+	setOptionToSeen("-XshowSettings", gl)
+	return pos, nil
+}
+
+// handleUnrecognizedVMOption shows the error for an unrecognized -X or -XX option and returns
+// errFatalVMOption or, if -XX:+IgnoreUnrecognizedVMOptions was specified, shows a warning and
+// returns nil
+func handleUnrecognizedVMOption(option, errMsg string, gl *globals.Globals) error {
+	if getVMFlag("IgnoreUnrecognizedVMOptions", gl).(bool) {
+		_, _ = fmt.Fprintf(os.Stderr, "Jacobin VM warning: Ignoring unrecognized option %s\n", option)
+		return nil
+	}
+	_, _ = fmt.Fprintln(os.Stderr, errMsg)
+	return errFatalVMOption
+}
+
+// presetIgnoreUnrecognizedVMOptions sets -XX:+IgnoreUnrecognizedVMOptions before any other
+// option is processed, as in HotSpot it applies to all options, wherever it appears
+func presetIgnoreUnrecognizedVMOptions(args []string, gl *globals.Globals) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") { // the main class
+			return
+		}
+		if optionsWithSeparateArg[arg] {
+			i++ // skip the option's value
+			continue
+		}
+		switch arg {
+		case "-XX:+IgnoreUnrecognizedVMOptions":
+			gl.VMFlags["IgnoreUnrecognizedVMOptions"] = true
+		case "-XX:-IgnoreUnrecognizedVMOptions":
+			gl.VMFlags["IgnoreUnrecognizedVMOptions"] = false
+		}
+	}
+}
+
+// showVMSettings shows the settings requested by -XshowSettings, on stderr, and
+// the flags, if -XX:+PrintFlagsFinal was specified, on stdout, as HotSpot does
+func showVMSettings(gl *globals.Globals) {
+	if gl.ShowSettings == "all" || gl.ShowSettings == "vm" {
+		printVMSettings(os.Stderr, gl)
+	}
+	if gl.ShowSettings == "all" || gl.ShowSettings == "properties" {
+		printPropertySettings(os.Stderr, gfunction.CopySystemProperties())
+	}
+	if getVMFlag("PrintFlagsFinal", gl).(bool) {
+		printFlagsFinal(os.Stdout, gl)
+	}
+}
+
+// printVMSettings prints the VM settings in the format of -XshowSettings:vm
+func printVMSettings(out io.Writer, gl *globals.Globals) {
+	_, _ = fmt.Fprintln(out, "VM settings:")
+	if gl.MaxHeapSize != 0 {
+		_, _ = fmt.Fprintf(out, "    Max. Heap Size: %s\n", scaleMemorySize(gl.MaxHeapSize))
+	} else {
+		_, _ = fmt.Fprintln(out, "    Max. Heap Size: unlimited")
+	}
+	if gl.InitialHeapSize != 0 {
+		_, _ = fmt.Fprintf(out, "    Min. Heap Size: %s\n", scaleMemorySize(gl.InitialHeapSize))
+	}
+	_, _ = fmt.Fprintf(out, "    Stack Size: %s\n", scaleMemorySize(gl.ThreadStackSize))
+	_, _ = fmt.Fprintf(out, "    Using VM: Jacobin VM (interpreted mode)\n\n")
+}
+
+// printPropertySettings prints the system properties in the format of -XshowSettings:properties.
+// As in HotSpot, the entries in a path are shown on separate lines.
+func printPropertySettings(out io.Writer, properties map[string]string) {
+	keys := make([]string, 0, len(properties))
+	for key := range properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	_, _ = fmt.Fprintln(out, "Property settings:")
+	for _, key := range keys {
+		value := properties[key]
+		switch {
+		case key == "line.separator":
+			value = strings.NewReplacer("\r", `\r `, "\n", `\n `).Replace(value)
+		case strings.HasSuffix(key, ".path") || strings.HasSuffix(key, ".dirs"):
+			value = strings.ReplaceAll(value, string(os.PathListSeparator), "\n        ")
+		}
+		_, _ = fmt.Fprintf(out, "    %s = %s\n", key, value)
+	}
+	_, _ = fmt.Fprintln(out)
+}
+
+// printFlagsFinal prints the flags and their values in the format of -XX:+PrintFlagsFinal
+func printFlagsFinal(out io.Writer, gl *globals.Globals) {
+	names := make([]string, 0, len(vmFlags))
+	for name := range vmFlags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	_, _ = fmt.Fprintln(out, "[Global flags]")
+	for _, name := range names {
+		origin := "{default}"
+		if _, ok := gl.VMFlags[name]; ok {
+			origin = "{command line}"
+		}
+		_, _ = fmt.Fprintf(out, " %9s %-40s = %-41v {product} %s\n",
+			vmFlagTypeNames[vmFlags[name].flagType], name, getVMFlag(name, gl), origin)
+	}
+}
+
+// scaleMemorySize formats a number of bytes as HotSpot does in -XshowSettings, e.g., 1.00G
+func scaleMemorySize(size int64) string {
+	switch {
+	case size >= 1<<40:
+		return fmt.Sprintf("%.2fT", float64(size)/(1<<40))
+	case size >= 1<<30:
+		return fmt.Sprintf("%.2fG", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.2fM", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.2fK", float64(size)/(1<<10))
+	default:
+		return strconv.FormatInt(size, 10)
+	}
+}
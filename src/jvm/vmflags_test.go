@@ -0,0 +1,140 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package jvm
+
+import (
+	"errors"
+	"io"
+	"jacobin/globals"
+	"os"
+	"strings"
+	"testing"
+)
+
+// runCliCapturingStderr runs HandleCli on the args and returns its error and what it wrote to stderr
+func runCliCapturingStderr(args []string, global *globals.Globals) (error, string) {
+	normalStderr := os.Stderr
+	r, w, _ := os.Pipe()
+	os.Stderr = w
+
+	err := HandleCli(args, global)
+
+	_ = w.Close()
+	out, _ := io.ReadAll(r)
+	os.Stderr = normalStderr
+	return err, string(out)
+}
+
+func TestParseMemorySize(t *testing.T) {
+	sizes := map[string]int64{"0": 0, "1024": 1024, "512k": 512 << 10, "64M": 64 << 20, "2g": 2 << 30, "1T": 1 << 40}
+	for size, expected := range sizes {
+		if value, err := parseMemorySize(size); err != nil || value != expected {
+			t.Errorf("parseMemorySize(%s): expected %d, got %d (error: %v)", size, expected, value, err)
+		}
+	}
+
+	for _, size := range []string{"", "k", "12x", "-1m", "99999999999T"} {
+		if _, err := parseMemorySize(size); err == nil {
+			t.Errorf("parseMemorySize(%s): expected an error", size)
+		}
+	}
+}
+
+func TestXOptionsSetMemoryLimits(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	args := []string{"jacobin", "-Xss512k", "-Xmx2g", "-Xms64m", "-Xint", "-XX:+UseSerialGC",
+		"-XX:MaxJavaStackTraceDepth=50", "-XshowSettings:vm", "Main"}
+	err, msg := runCliCapturingStderr(args, &global)
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, msg)
+	}
+
+	if global.ThreadStackSize != 512*1024 || global.MaxHeapSize != 2<<30 || global.InitialHeapSize != 64<<20 {
+		t.Errorf("expected stack size 512K, max heap 2G, and initial heap 64M, got %d, %d, and %d",
+			global.ThreadStackSize, global.MaxHeapSize, global.InitialHeapSize)
+	}
+	if getVMFlag("ThreadStackSize", &global) != int64(512) {
+		t.Errorf("expected -Xss512k to set ThreadStackSize to 512, got %v", getVMFlag("ThreadStackSize", &global))
+	}
+	if getVMFlag("UseSerialGC", &global) != true || getVMFlag("MaxJavaStackTraceDepth", &global) != int64(50) {
+		t.Errorf("expected -XX flags to be set, got: %v", global.VMFlags)
+	}
+	if getVMFlag("TieredCompilation", &global) != true {
+		t.Errorf("expected TieredCompilation to have its default value, true")
+	}
+	if global.ShowSettings != "vm" || global.StartingClass != "Main" {
+		t.Errorf("expected -XshowSettings:vm and Main, got %q and %q", global.ShowSettings, global.StartingClass)
+	}
+
+	var sb strings.Builder
+	printVMSettings(&sb, &global)
+	if !strings.Contains(sb.String(), "Max. Heap Size: 2.00G") || !strings.Contains(sb.String(), "Stack Size: 512.00K") {
+		t.Errorf("unexpected VM settings: %s", sb.String())
+	}
+}
+
+func TestInvalidVMOptionsAreFatal(t *testing.T) {
+	invalidOptions := map[string]string{
+		"-Xmx2x":                     "Invalid maximum heap size: -Xmx2x",
+		"-XX:+NoSuchFlag":            "Unrecognized VM option 'NoSuchFlag'",
+		"-Xnosuchoption":             "Unrecognized option: -Xnosuchoption",
+		"-XX:MaxHeapSize":            "Improperly specified VM option 'MaxHeapSize'",
+		"-XX:+MaxHeapSize":           "Unexpected +/- setting in VM option '+MaxHeapSize'",
+		"-XX:UseSerialGC=true":       "Missing +/- setting for VM option 'UseSerialGC=true'",
+		"-XX:MaxRAMPercentage=fifty": "Improperly specified VM option 'MaxRAMPercentage=fifty'",
+	}
+
+	for option, expectedMsg := range invalidOptions {
+		global := globals.InitGlobals("test")
+		LoadOptionsTable(global)
+		err, msg := runCliCapturingStderr([]string{"jacobin", option, "Main"}, &global)
+		if !errors.Is(err, errFatalVMOption) || !strings.Contains(msg, expectedMsg) {
+			t.Errorf("%s: expected a fatal error with %q, got %v: %s", option, expectedMsg, err, msg)
+		}
+	}
+}
+
+func TestIgnoreUnrecognizedVMOptions(t *testing.T) {
+	global := globals.InitGlobals("test")
+	LoadOptionsTable(global)
+
+	// the flag applies to unrecognized options that precede it
+	args := []string{"jacobin", "-XX:+NoSuchFlag", "-cp", "lib", "-Xnosuchoption",
+		"-XX:+IgnoreUnrecognizedVMOptions", "Main"}
+	err, msg := runCliCapturingStderr(args, &global)
+	if err != nil {
+		t.Fatalf("unexpected error: %v: %s", err, msg)
+	}
+	if !strings.Contains(msg, "Ignoring unrecognized option -XX:+NoSuchFlag") ||
+		!strings.Contains(msg, "Ignoring unrecognized option -Xnosuchoption") {
+		t.Errorf("expected warnings for the unrecognized options, got: %s", msg)
+	}
+	if global.StartingClass != "Main" {
+		t.Errorf("expected Main as the starting class, got: %s", global.StartingClass)
+	}
+}
+
+func TestPrintPropertySettings(t *testing.T) {
+	properties := map[string]string{
+		"java.class.path": "a.jar" + string(os.PathListSeparator) + "b.jar",
+		"line.separator":  "\r\n",
+		"user.name":       "duke",
+	}
+
+	var sb strings.Builder
+	printPropertySettings(&sb, properties)
+	expected := "Property settings:\n" +
+		"    java.class.path = a.jar\n" +
+		"        b.jar\n" +
+		"    line.separator = \\r \\n \n" +
+		"    user.name = duke\n\n"
+	if sb.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, sb.String())
+	}
+}
@@ -93,6 +93,7 @@ func GetArrayType(arrayType string) string {
 func Make2DimArray(ptrArrSize, leafArrSize int64, arrType uint8) (*Object, error) {
 	ptrArr := MakeEmptyObject()          // ptrArr is the pointer to the array of pointers to the leaf arrays
 	value := make([]*Object, ptrArrSize) // the actual ptr-level array
+	noteAllocation(ptrArrSize * refSize)
 
 	// make the first array in the ptr array and get its type converted to a string
 	firstArray := Make1DimArray(arrType, leafArrSize)
@@ -139,6 +140,7 @@ func Make1DimArray(arrType uint8, size int64) *Object {
 	}
 	value := o.FieldTable["value"]
 	o.KlassName = stringPool.GetStringIndex(&value.Ftype) // in arrays, Klass field is a pointer to the array type string
+	noteAllocation(ArraySize(arrType, size) - ObjectHeaderSize)
 	return o
 }
 
@@ -153,6 +155,7 @@ func Make1DimRefArray(objType *string, size int64) *Object {
 	o.FieldTable["value"] = of
 	o.KlassName = stringPool.GetStringIndex(&of.Ftype)
 	// o.Klass = &of.Ftype
+	noteAllocation(size * refSize)
 	return o
 }
 
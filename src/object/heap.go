@@ -0,0 +1,188 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package object
+
+import (
+	"jacobin/globals"
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// This file contains the approximate accounting of the Java heap, which is used to
+// enforce the limit set by -Xmx and to supply the numbers reported by Runtime's
+// totalMemory(), freeMemory(), and maxMemory().
+//
+// Jacobin objects live on the Go heap, so the size of the Java heap is taken from
+// the Go runtime's memory stats. Reading those stats briefly stops the world, so
+// they're sampled only occasionally: when the limit is first checked (or has changed
+// since it was last checked), and after that, whenever about a megabyte has been
+// allocated. Between samples, the size of each allocation is estimated and added to
+// the last sample. When an allocation would take the heap
+// past the limit, the stats are sampled again and, if need be, a GC is forced before
+// the allocation is refused--so, as in HotSpot, an OutOfMemoryError is thrown only
+// if there's still no room once the garbage has been collected.
+//
+// The bytecodes that allocate (NEW, NEWARRAY, ANEWARRAY, and MULTIANEWARRAY) check
+// for room with HeapHasRoom() before they allocate. Other allocations, such as the
+// strings and arrays built by gfunctions, aren't checked beforehand: if one of them
+// takes the heap past the limit, noteAllocation() records the overflow, and it's
+// reported by HeapOverflowed() when the gfunction returns.
+
+// the estimated sizes, in bytes, of the parts of an object
+const (
+	ObjectHeaderSize = 64 // the Object struct and its empty field table
+	fieldSize        = 48 // an entry in the field table: the name, type, and value of a field
+	refSize          = 8  // a reference, or an int64 or float64 element of an array
+)
+
+// the heap is sampled at least this often, in bytes allocated
+const heapSampleInterval = 1 << 20
+
+var heap struct {
+	sampled   atomic.Int64 // the heap in use at the last sample
+	committed atomic.Int64 // the heap obtained from the OS at the last sample
+	allocated atomic.Int64 // the estimated bytes allocated since the last sample
+	limit     atomic.Int64 // the maximum heap size when HeapHasRoom() last sampled the heap
+	mutex     sync.Mutex   // serializes the sampling and the forced GCs
+	throwing  atomic.Bool  // an OutOfMemoryError is being thrown, so the limit is suspended
+	overflow  atomic.Bool  // an unchecked allocation took the heap past the limit
+}
+
+// sampleHeap updates the heap's numbers from the Go runtime's memory stats
+func sampleHeap() {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	heap.sampled.Store(int64(stats.HeapAlloc))
+	heap.committed.Store(int64(stats.HeapSys - stats.HeapReleased))
+	heap.allocated.Store(0)
+}
+
+// heapInUse returns the estimated size of the heap that's in use
+func heapInUse() int64 {
+	return heap.sampled.Load() + heap.allocated.Load()
+}
+
+// noteAllocation adds an allocation to the estimated size of the heap. If the heap
+// is now past the limit, even after a GC, the overflow is recorded for HeapOverflowed().
+func noteAllocation(size int64) {
+	if heap.allocated.Add(size) > heapSampleInterval && heap.mutex.TryLock() {
+		sampleHeap()
+		heap.mutex.Unlock()
+	}
+	if !HeapHasRoom(0) {
+		heap.overflow.Store(true)
+	}
+}
+
+// NoteInstance adds an object with the given number of fields that's been built
+// without MakeEmptyObject(), as objects instantiated from a class are, to the
+// estimated size of the heap
+func NoteInstance(fieldCount int) {
+	noteAllocation(InstanceSize(fieldCount))
+}
+
+// InstanceSize returns the estimated size of an object with the given number of fields
+func InstanceSize(fieldCount int) int64 {
+	return ObjectHeaderSize + int64(fieldCount)*fieldSize
+}
+
+// ArraySize returns the estimated size of a 1-dimensional array of the given
+// Jacobin array type (BYTE, FLOAT, INT, or REF) and length
+func ArraySize(arrType uint8, length int64) int64 {
+	elementSize := int64(refSize)
+	if arrType == BYTE {
+		elementSize = 1
+	}
+	if length > (math.MaxInt64-ObjectHeaderSize)/elementSize {
+		return math.MaxInt64
+	}
+	return ObjectHeaderSize + length*elementSize
+}
+
+// MultiArraySize returns the estimated size of a multidimensional array, whose
+// leaf arrays are of the given Jacobin array type, with the given dimensions
+func MultiArraySize(arrType uint8, dimSizes []int64) int64 {
+	if len(dimSizes) == 0 {
+		return 0
+	}
+	size := ArraySize(arrType, dimSizes[len(dimSizes)-1])
+	for i := len(dimSizes) - 2; i >= 0; i-- {
+		if dimSizes[i] != 0 && size > (math.MaxInt64-ArraySize(REF, dimSizes[i]))/dimSizes[i] {
+			return math.MaxInt64
+		}
+		size = ArraySize(REF, dimSizes[i]) + dimSizes[i]*size
+	}
+	return size
+}
+
+// HeapHasRoom reports whether an allocation of the given size fits within the maximum
+// heap size (-Xmx). If it doesn't appear to fit, the heap is sampled again and then,
+// if it still doesn't fit, a GC is forced before the answer is given.
+func HeapHasRoom(size int64) bool {
+	maxHeap := globals.GetGlobalRef().MaxHeapSize
+	if maxHeap <= 0 || heap.throwing.Load() {
+		return true
+	}
+	if size > maxHeap {
+		return false
+	}
+	if heap.limit.Load() == maxHeap && heapInUse() <= maxHeap-size {
+		return true
+	}
+
+	// the heap hasn't been sampled since the limit was set, or the allocation doesn't
+	// appear to fit, so it's sampled before the answer is given
+	heap.mutex.Lock()
+	defer heap.mutex.Unlock()
+	sampleHeap()
+	heap.limit.Store(maxHeap)
+	if heapInUse() <= maxHeap-size {
+		return true
+	}
+	runtime.GC()
+	sampleHeap()
+	return heapInUse() <= maxHeap-size
+}
+
+// HeapOverflowed reports whether an allocation that wasn't checked beforehand has
+// taken the heap past the limit since the last OutOfMemoryError, and clears the
+// overflow, so that it's reported only once
+func HeapOverflowed() bool {
+	return heap.overflow.CompareAndSwap(true, false)
+}
+
+// ThrowingOutOfMemoryError suspends the heap limit while an OutOfMemoryError is
+// thrown, so that there's room for the exception object and its stack trace. It's
+// called with true before the exception is thrown and with false after. The error
+// also reports any overflow that's been recorded.
+func ThrowingOutOfMemoryError(throwing bool) {
+	if throwing {
+		heap.overflow.Store(false)
+	}
+	heap.throwing.Store(throwing)
+}
+
+// HeapStats returns the sizes, in bytes, of the heap: the total committed heap, the
+// free part of it, and the maximum heap size. These are the values returned by
+// java.lang.Runtime's totalMemory(), freeMemory(), and maxMemory(). As in HotSpot,
+// if there's no maximum heap size, the maximum is returned as Long.MAX_VALUE.
+func HeapStats() (total, free, maxHeap int64) {
+	heap.mutex.Lock()
+	sampleHeap()
+	heap.mutex.Unlock()
+
+	glob := globals.GetGlobalRef()
+	used := heapInUse()
+	maxHeap = glob.MaxHeapSize
+	if maxHeap <= 0 {
+		maxHeap = math.MaxInt64
+	}
+	total = min(max(heap.committed.Load(), glob.InitialHeapSize, used), maxHeap)
+	return total, max(total-used, 0), maxHeap
+}
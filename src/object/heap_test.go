@@ -0,0 +1,127 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package object
+
+import (
+	"jacobin/globals"
+	"math"
+	"testing"
+)
+
+func TestArraySizes(t *testing.T) {
+	if size := ArraySize(BYTE, 100); size != ObjectHeaderSize+100 {
+		t.Errorf("expected a byte array of 100 to be %d bytes, got %d", ObjectHeaderSize+100, size)
+	}
+	if size := ArraySize(INT, 100); size != ObjectHeaderSize+800 {
+		t.Errorf("expected an int array of 100 to be %d bytes, got %d", ObjectHeaderSize+800, size)
+	}
+	if size := ArraySize(REF, math.MaxInt64/2); size != math.MaxInt64 {
+		t.Errorf("expected a huge array's size to be capped at MaxInt64, got %d", size)
+	}
+
+	// a 3 x 4 array of longs is a ref array of 3 pointing to 3 long arrays of 4
+	expected := ArraySize(REF, 3) + 3*ArraySize(INT, 4)
+	if size := MultiArraySize(INT, []int64{3, 4}); size != expected {
+		t.Errorf("expected a 3 x 4 array to be %d bytes, got %d", expected, size)
+	}
+	if size := MultiArraySize(INT, []int64{1 << 40, 1 << 40, 1 << 40}); size != math.MaxInt64 {
+		t.Errorf("expected a huge multidimensional array's size to be capped at MaxInt64, got %d", size)
+	}
+}
+
+func TestHeapHasRoom(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	defer func() { g.MaxHeapSize = 0 }()
+
+	g.MaxHeapSize = 0 // no limit
+	if !HeapHasRoom(math.MaxInt64) {
+		t.Error("expected any allocation to fit when there's no maximum heap size")
+	}
+
+	// the heap hasn't been sampled yet, as when the VM starts, so the estimate is 0
+	heap.sampled.Store(0)
+	heap.allocated.Store(0)
+	heap.limit.Store(0)
+
+	g.MaxHeapSize = 1 << 10 // -Xmx1k, which is smaller than the Go heap in use
+	if HeapHasRoom(ObjectHeaderSize) {
+		t.Error("expected an allocation not to fit in a heap that's already full")
+	}
+	if heap.sampled.Load() == 0 {
+		t.Error("expected the heap to be sampled when the limit is first checked")
+	}
+
+	ThrowingOutOfMemoryError(true)
+	if !HeapHasRoom(ObjectHeaderSize) {
+		t.Error("expected the limit to be suspended while an OutOfMemoryError is thrown")
+	}
+	ThrowingOutOfMemoryError(false)
+
+	g.MaxHeapSize = 1 << 40
+	if !HeapHasRoom(ArraySize(INT, 1000)) {
+		t.Error("expected an allocation to fit in a 1TB heap")
+	}
+}
+
+// an allocation that isn't checked beforehand, as by a gfunction, records an overflow
+// if it takes the heap past the limit, which is reported once
+func TestHeapOverflow(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	defer func() { g.MaxHeapSize = 0 }()
+	HeapOverflowed() // clear any overflow left by an earlier test
+
+	_ = MakeEmptyObject()
+	if HeapOverflowed() {
+		t.Error("expected no overflow when there's no maximum heap size")
+	}
+
+	g.MaxHeapSize = 1 << 10 // -Xmx1k, which is smaller than the Go heap in use
+	_ = Make1DimArray(INT, 10)
+	if !HeapOverflowed() {
+		t.Error("expected an allocation past the limit to be reported")
+	}
+	if HeapOverflowed() {
+		t.Error("expected the overflow to be reported only once")
+	}
+
+	_ = MakeEmptyObject()
+	ThrowingOutOfMemoryError(true)
+	ThrowingOutOfMemoryError(false)
+	if HeapOverflowed() {
+		t.Error("expected an OutOfMemoryError to report the overflow")
+	}
+
+	if size := InstanceSize(3); size != ObjectHeaderSize+3*fieldSize {
+		t.Errorf("expected an object with 3 fields to be %d bytes, got %d", ObjectHeaderSize+3*fieldSize, size)
+	}
+}
+
+func TestHeapStats(t *testing.T) {
+	globals.InitGlobals("test")
+	g := globals.GetGlobalRef()
+	defer func() { g.MaxHeapSize = 0 }()
+
+	g.MaxHeapSize = 0
+	total, free, maxHeap := HeapStats()
+	if maxHeap != math.MaxInt64 {
+		t.Errorf("expected the maximum heap to be MaxInt64 when there's no limit, got %d", maxHeap)
+	}
+	if total <= 0 || free < 0 || free > total {
+		t.Errorf("expected 0 <= free <= total, got free %d, total %d", free, total)
+	}
+
+	g.MaxHeapSize = 1 << 20
+	total, free, maxHeap = HeapStats()
+	if maxHeap != 1<<20 {
+		t.Errorf("expected the maximum heap to be %d, got %d", 1<<20, maxHeap)
+	}
+	if total > maxHeap || free < 0 {
+		t.Errorf("expected total <= max and free >= 0, got total %d, free %d", total, free)
+	}
+}
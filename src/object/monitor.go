@@ -0,0 +1,197 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package object
+
+import (
+	"sync"
+	"time"
+)
+
+// This file contains the per-object monitors used by MONITORENTER, MONITOREXIT,
+// synchronized methods, and Object.wait()/notify()/notifyAll(). Monitors are
+// re-entrant: the thread that owns a monitor can enter it again, and it releases
+// the monitor only when it has exited as many times as it entered.
+//
+// Monitors are not stored in the object itself (Objects are copied by value in
+// some places, and a lock must not be copied). Instead, they are kept in a side
+// table that is keyed by the object's address. As in HotSpot, a monitor exists
+// only while it's in use: it's created on the first enter and removed from the
+// table when it's released and no other thread is waiting for it.
+
+type monitor struct {
+	owner    int       // the ID of the owning thread, 0 = unowned
+	count    int       // the number of times the owner has entered the monitor
+	entrants int       // the number of threads blocked waiting to enter the monitor
+	waiters  int       // the number of threads in wait(), including those not yet back in the monitor
+	waitSet  []*waiter // the threads in wait() that have not been notified, in order of arrival
+}
+
+// a thread in Object.wait(). Its channel is closed when it is notified.
+type waiter struct {
+	notified chan struct{}
+}
+
+// The reasons a MonitorWait() ends
+const (
+	WaitNotified = iota
+	WaitTimedOut
+	WaitInterrupted
+	WaitNotOwner // the thread did not own the monitor, so it did not wait
+)
+
+var monitorLock sync.Mutex
+var monitorReleased = sync.NewCond(&monitorLock)
+var monitorTable = make(map[*Object]*monitor)
+
+// MonitorEnter acquires the monitor of obj for the given thread, blocking until
+// the monitor is available. If the thread already owns the monitor, the entry
+// count is incremented.
+func MonitorEnter(obj *Object, threadID int) {
+	monitorLock.Lock()
+	defer monitorLock.Unlock()
+
+	m, ok := monitorTable[obj]
+	if !ok {
+		m = &monitor{}
+		monitorTable[obj] = m
+	}
+
+	for m.owner != 0 && m.owner != threadID {
+		m.entrants++
+		monitorReleased.Wait()
+		m.entrants--
+	}
+
+	m.owner = threadID
+	m.count++
+}
+
+// MonitorExit releases one entry of the monitor of obj by the given thread.
+// It returns false if the thread does not own the monitor, in which case the
+// caller should throw an IllegalMonitorStateException.
+func MonitorExit(obj *Object, threadID int) bool {
+	monitorLock.Lock()
+	defer monitorLock.Unlock()
+
+	m, ok := monitorTable[obj]
+	if !ok || m.owner != threadID {
+		return false
+	}
+
+	m.count--
+	if m.count == 0 {
+		m.owner = 0
+		if m.entrants == 0 && m.waiters == 0 {
+			delete(monitorTable, obj)
+		} else {
+			monitorReleased.Broadcast()
+		}
+	}
+	return true
+}
+
+// MonitorOwner returns the ID of the thread that owns the monitor of obj, or
+// 0 if the monitor is not owned by any thread.
+func MonitorOwner(obj *Object) int {
+	monitorLock.Lock()
+	defer monitorLock.Unlock()
+
+	m, ok := monitorTable[obj]
+	if !ok {
+		return 0
+	}
+	return m.owner
+}
+
+// MonitorWait implements Object.wait(): the thread, which must own the monitor of
+// obj, releases the monitor completely and waits until it is notified, the timeout
+// expires (a timeout of 0 means wait forever), or the interrupt channel is signaled.
+// It then re-acquires the monitor, with the same entry count as before, and returns
+// the reason the wait ended.
+func MonitorWait(obj *Object, threadID int, timeout time.Duration, interrupt <-chan struct{}) int {
+	monitorLock.Lock()
+	m, ok := monitorTable[obj]
+	if !ok || m.owner != threadID {
+		monitorLock.Unlock()
+		return WaitNotOwner
+	}
+
+	w := &waiter{notified: make(chan struct{})}
+	m.waitSet = append(m.waitSet, w)
+	m.waiters++
+	savedCount := m.count
+	m.owner = 0
+	m.count = 0
+	monitorReleased.Broadcast()
+	monitorLock.Unlock()
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	reason := WaitNotified
+	select {
+	case <-w.notified:
+	case <-timer:
+		reason = WaitTimedOut
+	case <-interrupt:
+		reason = WaitInterrupted
+	}
+
+	monitorLock.Lock()
+	defer monitorLock.Unlock()
+
+	// if we were not notified, we're still in the wait set. If we were notified
+	// at the same time as the wait timed out or was interrupted, the notification
+	// takes precedence, so that it is not lost (per JLS 17.2.4)
+	inWaitSet := false
+	for i, ws := range m.waitSet {
+		if ws == w {
+			m.waitSet = append(m.waitSet[:i], m.waitSet[i+1:]...)
+			inWaitSet = true
+			break
+		}
+	}
+	if !inWaitSet {
+		reason = WaitNotified
+	}
+
+	for m.owner != 0 {
+		m.entrants++
+		monitorReleased.Wait()
+		m.entrants--
+	}
+	m.owner = threadID
+	m.count = savedCount
+	m.waiters--
+	return reason
+}
+
+// MonitorNotify implements Object.notify() and, if all is true, Object.notifyAll():
+// it wakes up one (or all) of the threads waiting on the monitor of obj. The thread
+// must own the monitor; if it doesn't, MonitorNotify returns false.
+func MonitorNotify(obj *Object, threadID int, all bool) bool {
+	monitorLock.Lock()
+	defer monitorLock.Unlock()
+
+	m, ok := monitorTable[obj]
+	if !ok || m.owner != threadID {
+		return false
+	}
+
+	for len(m.waitSet) > 0 {
+		close(m.waitSet[0].notified)
+		m.waitSet = m.waitSet[1:]
+		if !all {
+			break
+		}
+	}
+	return true
+}
@@ -0,0 +1,131 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin Authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)  Consult jacobin.org.
+ */
+
+package object
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonitorReentrant(t *testing.T) {
+	obj := MakeEmptyObject()
+	MonitorEnter(obj, 1)
+	MonitorEnter(obj, 1)
+
+	if !MonitorExit(obj, 1) {
+		t.Error("first exit of a re-entered monitor should succeed")
+	}
+	if MonitorOwner(obj) != 1 {
+		t.Errorf("monitor should still be owned by thread 1, got %d", MonitorOwner(obj))
+	}
+	if !MonitorExit(obj, 1) {
+		t.Error("second exit of a re-entered monitor should succeed")
+	}
+	if MonitorOwner(obj) != 0 {
+		t.Errorf("monitor should be released, but is owned by %d", MonitorOwner(obj))
+	}
+	if MonitorExit(obj, 1) {
+		t.Error("unbalanced exit should fail")
+	}
+}
+
+func TestMonitorExitByNonOwner(t *testing.T) {
+	obj := MakeEmptyObject()
+	MonitorEnter(obj, 1)
+	if MonitorExit(obj, 2) {
+		t.Error("exit by a thread that doesn't own the monitor should fail")
+	}
+	MonitorExit(obj, 1)
+}
+
+// two threads increment a counter under the same monitor. If the monitor
+// does not exclude the other thread, updates are lost.
+func TestMonitorExcludesOtherThreads(t *testing.T) {
+	obj := MakeEmptyObject()
+	counter := 0
+	wg := sync.WaitGroup{}
+
+	for thread := 1; thread <= 2; thread++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				MonitorEnter(obj, id)
+				c := counter
+				if i%100 == 0 {
+					time.Sleep(time.Microsecond)
+				}
+				counter = c + 1
+				MonitorExit(obj, id)
+			}
+		}(thread)
+	}
+
+	wg.Wait()
+	if counter != 2000 {
+		t.Errorf("expected counter to be 2000, got %d", counter)
+	}
+	if MonitorOwner(obj) != 0 {
+		t.Errorf("monitor should be released, but is owned by %d", MonitorOwner(obj))
+	}
+}
+
+func TestMonitorWaitNotify(t *testing.T) {
+	obj := MakeEmptyObject()
+
+	MonitorEnter(obj, 2)
+	go func() {
+		MonitorEnter(obj, 3)
+		MonitorNotify(obj, 3, false)
+		MonitorExit(obj, 3)
+	}()
+	// the other thread can enter the monitor only once this thread has released it by waiting
+	if r := MonitorWait(obj, 2, 0, nil); r != WaitNotified {
+		t.Errorf("expected the wait to end by notification, got %d", r)
+	}
+	if MonitorOwner(obj) != 2 {
+		t.Errorf("waiting thread should own the monitor again, but owner is %d", MonitorOwner(obj))
+	}
+	MonitorExit(obj, 2)
+}
+
+func TestMonitorWaitTimeoutAndInterrupt(t *testing.T) {
+	obj := MakeEmptyObject()
+	MonitorEnter(obj, 1)
+	MonitorEnter(obj, 1)
+
+	if r := MonitorWait(obj, 1, time.Millisecond, nil); r != WaitTimedOut {
+		t.Errorf("expected the wait to time out, got %d", r)
+	}
+
+	interrupt := make(chan struct{}, 1)
+	interrupt <- struct{}{}
+	if r := MonitorWait(obj, 1, 0, interrupt); r != WaitInterrupted {
+		t.Errorf("expected the wait to be interrupted, got %d", r)
+	}
+
+	// the entry count is restored after a wait, so two exits are needed
+	MonitorExit(obj, 1)
+	if MonitorOwner(obj) != 1 {
+		t.Error("monitor should still be owned after the first exit")
+	}
+	MonitorExit(obj, 1)
+	if MonitorOwner(obj) != 0 {
+		t.Error("monitor should be released after the second exit")
+	}
+}
+
+func TestMonitorWaitNotifyNotOwner(t *testing.T) {
+	obj := MakeEmptyObject()
+	if r := MonitorWait(obj, 1, 0, nil); r != WaitNotOwner {
+		t.Errorf("expected wait by a non-owner to fail, got %d", r)
+	}
+	if MonitorNotify(obj, 1, true) {
+		t.Error("expected notifyAll by a non-owner to fail")
+	}
+}
@@ -29,7 +29,7 @@ type Object struct {
 // These mark word contains values for different purposes. Here,
 // we use the first four bytes for a hash value, which is taken
 // from the address of the object. The 'misc' field will eventually
-// contain other values, such as locking and monitoring items.
+// contain other values. (Monitors are kept in a side table, see monitor.go.)
 type MarkWord struct {
 	Hash uint32 // contains hash code which is the lower 32 bits of the address
 	Misc uint32 // at present unused
@@ -55,6 +55,7 @@ func MakeEmptyObject() *Object {
 
 	// initialize the map of this object's fields
 	o.FieldTable = make(map[string]Field)
+	noteAllocation(ObjectHeaderSize)
 	return &o
 }
 
@@ -67,6 +68,7 @@ func MakeEmptyObjectWithClassName(className *string) *Object {
 
 	// initialize the map of this object's fields
 	o.FieldTable = make(map[string]Field)
+	noteAllocation(ObjectHeaderSize)
 	return &o
 }
 
@@ -66,6 +66,7 @@ func NewStringObject() *Object {
 	//   CASE_INSENSITIVE_ORDER
 	//   serialPersistentFields
 
+	noteAllocation(InstanceSize(len(s.FieldTable)))
 	return s
 }
 
@@ -73,6 +74,7 @@ func NewStringObject() *Object {
 func StringObjectFromGoString(str string) *Object {
 	newStr := NewStringObject()
 	newStr.FieldTable["value"] = Field{Ftype: types.ByteArray, Fvalue: []byte(str)}
+	noteAllocation(int64(len(str)))
 	return newStr
 }
 
@@ -108,6 +110,7 @@ func ByteArrayFromStringObject(obj *Object) []byte {
 func StringObjectFromByteArray(bytes []byte) *Object {
 	newStr := NewStringObject()
 	newStr.FieldTable["value"] = Field{Ftype: types.ByteArray, Fvalue: bytes}
+	noteAllocation(int64(len(bytes)))
 	return newStr
 }
 
@@ -13,6 +13,9 @@ import (
 	"jacobin/log"
 	"jacobin/statics"
 	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
 )
 
 // The various flags that can be passed to the exit() function, reflecting
@@ -28,12 +31,64 @@ const (
 	UNKNOWN_ERROR
 )
 
-// This is the exit-to-O/S function.
-// TODO: Check a list of JVM Shutdown hooks before closing down in order to have an orderly exit.
+// set once the shutdown hooks have been started. They're run only once.
+var hooksStarted atomic.Bool
+
+// This is the exit-to-O/S function. Before exiting, it runs the application's
+// shutdown hooks (registered via Runtime.addShutdownHook()) and waits for them to finish.
 func Exit(errorCondition ExitStatus) int {
 	globals.LoaderWg.Wait()
 	g := globals.GetGlobalRef()
-	if g.JacobinName == "test" || g.JacobinName == "testWithoutShutdown" {
+
+	// on a normal end of the main thread, the JVM keeps running until all
+	// non-daemon threads have finished. System.exit() sets ExitNow, so it doesn't wait.
+	if errorCondition == OK && !g.ExitNow {
+		globals.NonDaemonWg.Wait()
+	}
+	runShutdownHooks()
+
+	return exit(errorCondition)
+}
+
+// Halt exits without running the shutdown hooks, as Runtime.halt() does
+func Halt(errorCondition ExitStatus) int {
+	globals.LoaderWg.Wait()
+	return exit(errorCondition)
+}
+
+// runShutdownHooks runs the shutdown hooks, unless they've already been started. In
+// HotSpot, an exit that's requested while the hooks are running blocks forever; here,
+// it exits without waiting for the hooks, so that a hook that calls System.exit() or
+// that throws an uncaught exception doesn't leave the JVM hung.
+func runShutdownHooks() {
+	if hooksStarted.CompareAndSwap(false, true) {
+		globals.GetGlobalRef().FuncRunShutdownHooks()
+	}
+}
+
+// ExitOnSignals makes SIGINT and SIGTERM exit the JVM after running the shutdown
+// hooks. As in HotSpot, the exit status is 128 + the signal number.
+func ExitOnSignals() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		sig := <-signals
+		runShutdownHooks()
+		status := 128
+		if sigNum, ok := sig.(syscall.Signal); ok {
+			status += int(sigNum)
+		}
+		os.Exit(status)
+	}()
+}
+
+// exit logs the exit and exits to the O/S. In test mode, it returns instead: 0 for OK and
+// 1 for any other status. Outside test mode, the status is passed as is to the O/S, so
+// that Runtime.exit(3) doesn't mistake an application's status for TEST_OK.
+func exit(errorCondition ExitStatus) int {
+	g := globals.GetGlobalRef()
+	testMode := g.JacobinName == "test" || g.JacobinName == "testWithoutShutdown"
+	if testMode {
 		if errorCondition == OK {
 			errorCondition = TEST_OK
 		} else {
@@ -42,16 +97,18 @@ func Exit(errorCondition ExitStatus) int {
 	}
 
 	msg := fmt.Sprintf("shutdown.Exit(%d) requested", errorCondition)
-	if log.Log(msg, log.INFO) != nil {
-		errorCondition = UNKNOWN_ERROR
-	}
+	logErr := log.Log(msg, log.INFO)
 
-	if errorCondition == TEST_OK {
-		return 0
-	} else if errorCondition == TEST_ERR {
+	if testMode {
+		if errorCondition == TEST_OK && logErr == nil {
+			return 0
+		}
 		return 1
 	}
 
+	if logErr != nil {
+		errorCondition = UNKNOWN_ERROR
+	}
 	if errorCondition != OK {
 		statics.DumpStatics()
 		config.DumpConfig(os.Stderr)
@@ -11,6 +11,8 @@ import (
 	"jacobin/globals"
 	"jacobin/log"
 	"os"
+	"os/exec"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -77,3 +79,60 @@ func TestShutdownReturn(t *testing.T) {
 		t.Errorf("Expecting exit() return value of 0, but got %d", ret)
 	}
 }
+
+func TestShutdownRunsHooksOnce(t *testing.T) {
+	globals.InitGlobals("test")
+	gl := globals.GetGlobalRef()
+	gl.JacobinName = "test"
+	hooksStarted.Store(false)
+
+	hookRuns := 0
+	gl.FuncRunShutdownHooks = func() { hookRuns++ }
+
+	Halt(OK)
+	if hookRuns != 0 {
+		t.Errorf("Expecting Halt() not to run the shutdown hooks, but they ran %d times", hookRuns)
+	}
+
+	Exit(OK)
+	Exit(OK)
+	if hookRuns != 1 {
+		t.Errorf("Expecting the shutdown hooks to run once, but they ran %d times", hookRuns)
+	}
+}
+
+// in test mode, every status other than OK is returned as 1, including the statuses that
+// have the same values as TEST_OK and TEST_ERR
+func TestShutdownReturnsTestStatuses(t *testing.T) {
+	globals.InitGlobals("test")
+	gl := globals.GetGlobalRef()
+	gl.JacobinName = "test"
+
+	for status, expected := range map[ExitStatus]int{OK: 0, APP_EXCEPTION: 1, TEST_OK: 1, TEST_ERR: 1} {
+		if ret := Halt(status); ret != expected {
+			t.Errorf("Expecting Halt(%d) to return %d, but got %d", status, expected, ret)
+		}
+	}
+}
+
+// outside test mode, the status is passed to the O/S as is. Since that exits the process,
+// the test runs itself in a child process that calls Halt().
+func TestShutdownExitsWithJavaStatus(t *testing.T) {
+	if status := os.Getenv("JACOBIN_HALT_STATUS"); status != "" {
+		globals.InitGlobals("jacobin")
+		os.Stderr, _ = os.Open(os.DevNull)
+		s, _ := strconv.Atoi(status)
+		Halt(s)
+		os.Exit(99) // not reached
+	}
+
+	for _, status := range []int{TEST_OK, TEST_ERR, 42} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestShutdownExitsWithJavaStatus$")
+		cmd.Env = append(os.Environ(), "JACOBIN_HALT_STATUS="+strconv.Itoa(status))
+		err := cmd.Run()
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok || exitErr.ExitCode() != status {
+			t.Errorf("Expecting Halt(%d) to exit with status %d, but got: %v", status, status, err)
+		}
+	}
+}
@@ -56,12 +56,21 @@ func AddStatic(name string, s Static) error {
 		_ = log.Log(errMsg, log.SEVERE)
 		return errors.New(errMsg)
 	}
-	staticsMutex.RLock()
+	staticsMutex.Lock()
 	Statics[name] = s
-	staticsMutex.RUnlock()
+	staticsMutex.Unlock()
 	return nil
 }
 
+// QueryStatic returns the entry for the named static from the Statics table
+// using a mutex. The boolean is false if the static is not in the table.
+func QueryStatic(name string) (Static, bool) {
+	staticsMutex.RLock()
+	s, ok := Statics[name]
+	staticsMutex.RUnlock()
+	return s, ok
+}
+
 // PreloadStatics preloads static fields from java.lang.String and other
 // immediately necessary statics. It's called in jvmStart.go
 func PreloadStatics() {
@@ -122,7 +131,7 @@ func GetStaticValue(className string, fieldName string) any {
 	staticName := className + "." + fieldName
 
 	// was this static field previously loaded? Is so, get its location and move on.
-	prevLoaded, ok := Statics[staticName]
+	prevLoaded, ok := QueryStatic(staticName)
 	if !ok {
 		glob := globals.GetGlobalRef()
 		glob.ErrorGoStack = string(debug.Stack())
@@ -153,6 +162,8 @@ func GetStaticValue(className string, fieldName string) any {
 // DumpStatics dumps the contents of the statics table in sorted order to stderr
 func DumpStatics() {
 	_, _ = fmt.Fprintln(os.Stderr, "\n===== DumpStatics BEGIN")
+	staticsMutex.RLock()
+	defer staticsMutex.RUnlock()
 	// Create an array of keys.
 	keys := make([]string, 0, len(Statics))
 	for key := range Statics {
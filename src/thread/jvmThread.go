@@ -1,6 +1,6 @@
 /*
  * Jacobin VM - A Java virtual machine
- * Copyright (c) 2022-3 by the Jacobin Authors. All rights reserved.
+ * Copyright (c) 2022-4 by the Jacobin Authors. All rights reserved.
  * Licensed under Mozilla Public License 2.0 (MPL 2.0)
  */
 
@@ -9,18 +9,49 @@ package thread
 import (
 	"container/list"
 	"jacobin/globals"
+	"strconv"
 )
 
-// Creates a JVM program execution thread. These threads are extremely limited.
-// They basically hold a Stack of frames. They push and popFrame frames as required.
-// They begin execution; they exit when execution ends.
+// Creates a JVM program execution thread. Each thread holds a Stack of frames,
+// which it pushes and pops as required. The main thread is created by the JVM at
+// start-up; all other threads are created by the application via java.lang.Thread,
+// in which case each thread runs on its own goroutine. They begin execution; they
+// exit when execution ends.
 
 type ExecThread struct {
 	ID    int        // the thread ID
 	Stack *list.List // the JVM Stack (frame stack, that is) for this thread
 	Trace bool       // do we trace instructions?
+
+	// the following fields are the Java-visible state of the thread. Name, Daemon,
+	// Priority, and Started can be changed by other threads, so they're accessed while
+	// holding glob.ThreadLock, via the methods below.
+	Name       string        // the thread name, e.g., "main" or "Thread-0"
+	Daemon     bool          // daemon threads don't keep the JVM from exiting
+	Priority   int64         // the Java thread priority, 1-10
+	JavaThread any           // the java.lang.Thread object for this thread, if any
+	Target     any           // the Runnable passed to the Thread constructor, if any
+	Started    bool          // has start() been called?
+	Done       chan struct{} // closed when the thread has finished executing
+
+	// uncaught exceptions: Group is the ThreadGroup the thread was created in, if one
+	// was specified, and UncaughtHandler is the handler set by setUncaughtExceptionHandler()
+	Group           any
+	UncaughtHandler any
+	Terminating     bool // the thread is dying of an uncaught exception
+
+	// interruption: Interrupted is the Java interrupt status. InterruptCh is signaled
+	// when the status is set, so that a thread blocked in wait(), sleep(), or join() wakes up.
+	Interrupted bool
+	InterruptCh chan struct{}
 }
 
+const (
+	MinPriority  = 1
+	NormPriority = 5
+	MaxPriority  = 10
+)
+
 // CreateThread creates an execution thread and initializes it with default values
 // All Jacobin execution threads *must* use this function to create a thread
 func CreateThread() ExecThread {
@@ -28,6 +59,9 @@ func CreateThread() ExecThread {
 	t.ID = incrementThreadNumber()
 	t.Stack = nil
 	t.Trace = false
+	t.Priority = NormPriority
+	t.Done = make(chan struct{})
+	t.InterruptCh = make(chan struct{}, 1)
 	return t
 }
 
@@ -39,6 +73,157 @@ func (t *ExecThread) AddThreadToTable(glob *globals.Globals) {
 	glob.ThreadLock.Unlock()
 }
 
+// RemoveThreadFromTable removes a thread that has finished executing from
+// the global thread table
+func (t *ExecThread) RemoveThreadFromTable(glob *globals.Globals) {
+	glob.ThreadLock.Lock()
+	delete(glob.Threads, t.ID)
+	glob.ThreadLock.Unlock()
+}
+
+// IsAlive returns true if the thread has been started and has not yet finished
+func (t *ExecThread) IsAlive() bool {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	started := t.Started
+	glob.ThreadLock.Unlock()
+	if !started {
+		return false
+	}
+	select {
+	case <-t.Done:
+		return false
+	default:
+		return true
+	}
+}
+
+// GetName returns the name of the thread
+func (t *ExecThread) GetName() string {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	return t.Name
+}
+
+// SetName changes the name of the thread
+func (t *ExecThread) SetName(name string) {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	t.Name = name
+	glob.ThreadLock.Unlock()
+}
+
+// GetPriority returns the Java priority of the thread
+func (t *ExecThread) GetPriority() int64 {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	return t.Priority
+}
+
+// SetPriority changes the Java priority of the thread. The caller checks the range.
+func (t *ExecThread) SetPriority(priority int64) {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	t.Priority = priority
+	glob.ThreadLock.Unlock()
+}
+
+// IsDaemon returns true if the thread is a daemon thread
+func (t *ExecThread) IsDaemon() bool {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	return t.Daemon
+}
+
+// SetDaemon marks the thread as a daemon or a user thread. This can't be done while
+// the thread is running, so it returns false, and leaves the status unchanged, if the
+// thread has been started and has not yet finished.
+func (t *ExecThread) SetDaemon(daemon bool) bool {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	if t.Started {
+		select {
+		case <-t.Done:
+		default:
+			return false
+		}
+	}
+	t.Daemon = daemon
+	return true
+}
+
+// Interrupt sets the interrupt status of the thread and wakes it up if it's
+// blocked in wait(), sleep(), or join()
+func (t *ExecThread) Interrupt() {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	t.Interrupted = true
+	glob.ThreadLock.Unlock()
+
+	select {
+	case t.InterruptCh <- struct{}{}:
+	default: // a wake-up is already pending
+	}
+}
+
+// IsInterrupted returns the interrupt status of the thread
+func (t *ExecThread) IsInterrupted() bool {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	return t.Interrupted
+}
+
+// ClearInterrupt clears the interrupt status of the thread and returns the
+// status it had before being cleared
+func (t *ExecThread) ClearInterrupt() bool {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	defer glob.ThreadLock.Unlock()
+	wasInterrupted := t.Interrupted
+	t.Interrupted = false
+
+	select {
+	case <-t.InterruptCh: // discard any pending wake-up
+	default:
+	}
+	return wasInterrupted
+}
+
+// GetThread returns the thread in the global thread table with the given ID,
+// or nil if no such thread is in the table
+func GetThread(id int) *ExecThread {
+	glob := globals.GetGlobalRef()
+	glob.ThreadLock.Lock()
+	th, ok := glob.Threads[id].(*ExecThread)
+	glob.ThreadLock.Unlock()
+	if !ok {
+		return nil
+	}
+	return th
+}
+
+// GetThreadName returns the name of the thread with the given ID in the
+// format HotSpot uses in error messages. Thread #1 is always "main".
+func GetThreadName(id int) string {
+	th := GetThread(id)
+	var name string
+	if th != nil {
+		name = th.GetName()
+	}
+	if name == "" {
+		if id == 1 {
+			return "main"
+		}
+		return "Thread-" + strconv.Itoa(id)
+	}
+	return name
+}
+
 // threads are assigned a monotonically incrementing integer ID. This function
 // increments the counter and returns its value as the integer ID to use
 func incrementThreadNumber() int {
@@ -91,3 +91,44 @@ func addThreadsToTable(numThreads int) {
 		th.AddThreadToTable(glob)
 	}
 }
+
+// the Java-visible state of a thread can be read and changed by other threads. Run
+// with -race, this test checks that the accesses hold the thread lock.
+func TestThreadStateAccessors(t *testing.T) {
+	globals.InitGlobals("test")
+	th := CreateThread()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			th.SetName("worker")
+			th.SetPriority(MaxPriority)
+			th.SetDaemon(true)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			_, _, _ = th.GetName(), th.GetPriority(), th.IsDaemon()
+		}
+	}()
+	wg.Wait()
+
+	if th.GetName() != "worker" || th.GetPriority() != MaxPriority || !th.IsDaemon() {
+		t.Errorf("Expected a daemon thread named worker with priority %d, got %s, %d, %v",
+			MaxPriority, th.GetName(), th.GetPriority(), th.IsDaemon())
+	}
+
+	// the daemon status can't be changed while the thread is running, but it can be
+	// once the thread has finished
+	th.Started = true
+	if th.SetDaemon(false) || !th.IsDaemon() {
+		t.Error("Expected SetDaemon() to fail on a running thread")
+	}
+	close(th.Done)
+	if !th.SetDaemon(false) || th.IsDaemon() {
+		t.Error("Expected SetDaemon() to succeed on a finished thread")
+	}
+}
@@ -97,3 +97,55 @@ func ParseIncomingParamsFromMethTypeString(s string) []string {
 	}
 	return params
 }
+
+// ParseMethodDescriptor splits a method descriptor, such as (ILjava/lang/String;[J)V,
+// into the field descriptors of its parameters and its return type. Unlike
+// ParseIncomingParamsFromMethTypeString(), the types are not reduced: I, Ljava/lang/String;
+// and [J are returned as is. If the descriptor is malformed, ok is false.
+func ParseMethodDescriptor(desc string) (params []string, ret string, ok bool) {
+	params = make([]string, 0)
+	if len(desc) < 3 || desc[0] != '(' {
+		return params, "", false
+	}
+
+	i := 1
+	for i < len(desc) && desc[i] != ')' {
+		end := fieldDescriptorEnd(desc, i)
+		if end < 0 {
+			return params, "", false
+		}
+		params = append(params, desc[i:end])
+		i = end
+	}
+	if i >= len(desc) {
+		return params, "", false
+	}
+
+	ret = desc[i+1:]
+	if ret != "V" && fieldDescriptorEnd(ret, 0) != len(ret) {
+		return params, "", false
+	}
+	return params, ret, true
+}
+
+// returns the index just past the field descriptor that starts at desc[start], or -1
+func fieldDescriptorEnd(desc string, start int) int {
+	i := start
+	for i < len(desc) && desc[i] == '[' {
+		i++
+	}
+	if i >= len(desc) {
+		return -1
+	}
+	switch desc[i] {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z':
+		return i + 1
+	case 'L':
+		for j := i + 1; j < len(desc); j++ {
+			if desc[j] == ';' {
+				return j + 1
+			}
+		}
+	}
+	return -1
+}
@@ -136,3 +136,33 @@ func TestParseIncomingReferenceParamsFromMethType17(t *testing.T) {
 func TestParseIncomingReferenceParamsFromMethType18(t *testing.T) {
 	checker(t, 18, "(JD[I[F[[[Ljava/lang/String;[[[J)V", 6, "JD[I[F[[[L[[[J")
 }
+
+func TestParseMethodDescriptor(t *testing.T) {
+	params, ret, ok := ParseMethodDescriptor("(ILjava/lang/String;[JZ[[Ljava/lang/Object;)Ljava/util/List;")
+	if !ok {
+		t.Fatal("Expected a valid descriptor to parse")
+	}
+	expected := []string{"I", "Ljava/lang/String;", "[J", "Z", "[[Ljava/lang/Object;"}
+	if len(params) != len(expected) {
+		t.Fatalf("Expected %d parameters, got %d: %v", len(expected), len(params), params)
+	}
+	for i := range expected {
+		if params[i] != expected[i] {
+			t.Errorf("Expected parameter %d to be %s, got %s", i, expected[i], params[i])
+		}
+	}
+	if ret != "Ljava/util/List;" {
+		t.Errorf("Expected return type Ljava/util/List;, got %s", ret)
+	}
+
+	params, ret, ok = ParseMethodDescriptor("()V")
+	if !ok || len(params) != 0 || ret != "V" {
+		t.Errorf("Expected ()V to have no parameters and return V, got %v %s %v", params, ret, ok)
+	}
+
+	for _, bad := range []string{"", "V", "(I", "(Ljava/lang/String)V", "(Q)V", "()"} {
+		if _, _, ok = ParseMethodDescriptor(bad); ok {
+			t.Errorf("Expected descriptor %q to be rejected", bad)
+		}
+	}
+}
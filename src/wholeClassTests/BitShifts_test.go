@@ -91,15 +91,15 @@ func TestRunBitShifts(t *testing.T) {
     // an actual specified option on the command line.
     if len(_JVM_ARGS) > 0 {
         if len(_APP_ARGS) > 0 {
-            cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS, _APP_ARGS)
+            cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS, _APP_ARGS)
         } else {
-            cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS)
+            cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS)
         }
     } else {
         if len(_APP_ARGS) > 0 {
-            cmd = exec.Command(_JACOBIN, _TESTCLASS, _APP_ARGS)
+            cmd = jacobinCommand(_TESTCLASS, _APP_ARGS)
         } else {
-            cmd = exec.Command(_JACOBIN, _TESTCLASS)
+            cmd = jacobinCommand(_TESTCLASS)
         }
     }
 
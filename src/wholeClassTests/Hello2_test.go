@@ -105,15 +105,15 @@ func TestRunHello2(t *testing.T) {
 	// an actual specified option on the command line.
 	if len(_JVM_ARGS) > 0 {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS)
 		}
 	} else {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS)
+			cmd = jacobinCommand(_TESTCLASS)
 		}
 	}
 
@@ -163,15 +163,15 @@ func TestRunHello2VerboseClass(t *testing.T) {
 	// an actual specified option on the command line.
 	if len(_JVM_ARGS) > 0 {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS)
 		}
 	} else {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS)
+			cmd = jacobinCommand(_TESTCLASS)
 		}
 	}
 
@@ -220,15 +220,15 @@ func TestRunHello2VerboseFinest(t *testing.T) {
 	// an actual specified option on the command line.
 	if len(_JVM_ARGS) > 0 {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS)
 		}
 	} else {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS)
+			cmd = jacobinCommand(_TESTCLASS)
 		}
 	}
 
@@ -277,15 +277,15 @@ func TestRunHello2TraceInst(t *testing.T) {
 	// an actual specified option on the command line.
 	if len(_JVM_ARGS) > 0 {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS)
 		}
 	} else {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS)
+			cmd = jacobinCommand(_TESTCLASS)
 		}
 	}
 
@@ -85,15 +85,15 @@ func TestTableSwitchNoArgs(t *testing.T) {
 	// an actual specified option on the command line.
 	if len(_JVM_ARGS) > 0 {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _JVM_ARGS, _TESTCLASS)
+			cmd = jacobinCommand(_JVM_ARGS, _TESTCLASS)
 		}
 	} else {
 		if len(_APP_ARGS) > 0 {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS, _APP_ARGS)
+			cmd = jacobinCommand(_TESTCLASS, _APP_ARGS)
 		} else {
-			cmd = exec.Command(_JACOBIN, _TESTCLASS)
+			cmd = jacobinCommand(_TESTCLASS)
 		}
 	}
 
@@ -135,7 +135,7 @@ func TestTableSwitchManyArgs(t *testing.T) {
 		t.Skip()
 	}
 
-	cmd = exec.Command(_JACOBIN, _TESTCLASS, "1", "2", "3", "4", "5", "6", "7", "8", "9", "10")
+	cmd = jacobinCommand(_TESTCLASS, "1", "2", "3", "4", "5", "6", "7", "8", "9", "10")
 	// get the stdout and stderr contents from the file execution
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
@@ -6,7 +6,19 @@
 
 package wholeClassTests
 
+import "os/exec"
+
 var _JACOBIN string
 var _JVM_ARGS string
 var _TESTCLASS string
 var _APP_ARGS string
+
+// the JVM options that select the interpreter under test, per the environment
+// variable JACOBIN_INTERPRETER; see TestMain() in interpreters_test.go
+var _INTERPRETER_ARGS []string
+
+// jacobinCommand returns the command to run Jacobin with the given arguments
+// under the interpreter being tested
+func jacobinCommand(args ...string) *exec.Cmd {
+	return exec.Command(_JACOBIN, append(_INTERPRETER_ARGS, args...)...)
+}
@@ -11,7 +11,6 @@ import (
 	"io"
 	"log"
 	"os"
-	"os/exec"
 	"strings"
 	"testing"
 )
@@ -69,7 +68,7 @@ func TestRunClientVersion(t *testing.T) {
 		t.Errorf("Missing Jacobin executable, which was specified as %s", _JACOBIN)
 	}
 
-	cmd := exec.Command(_JACOBIN, "-client", "-version")
+	cmd := jacobinCommand("-client", "-version")
 
 	// get the stdout and stderr contents from the file execution
 	stderr, err := cmd.StderrPipe()
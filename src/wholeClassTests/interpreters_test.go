@@ -0,0 +1,27 @@
+/*
+ * Jacobin VM - A Java virtual machine
+ * Copyright (c) 2024 by the Jacobin authors. All rights reserved.
+ * Licensed under Mozilla Public License 2.0 (MPL 2.0)
+ */
+
+package wholeClassTests
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain runs the tests in this package with the interpreter named by the
+// environment variable JACOBIN_INTERPRETER: "new" for the dispatch-table interpreter
+// (-new) or "old" for the original one (-old). If it's not set, Jacobin's default
+// interpreter is used. Both interpreters must pass every test, so the suite is run
+// once for each:
+//
+//	JACOBIN_INTERPRETER=new go test ./wholeClassTests
+//	JACOBIN_INTERPRETER=old go test ./wholeClassTests
+func TestMain(m *testing.M) {
+	if interpreter := os.Getenv("JACOBIN_INTERPRETER"); interpreter != "" {
+		_INTERPRETER_ARGS = []string{"-" + interpreter}
+	}
+	os.Exit(m.Run())
+}